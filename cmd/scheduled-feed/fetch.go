@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ossf/package-feeds/config"
+	"github.com/ossf/package-feeds/feeds"
+)
+
+const fetchCommand = "fetch"
+
+var (
+	errFetchArgs = errors.New("--feed and --package are required")
+	errFetch     = errors.New("failed to fetch package")
+)
+
+type fetchArgs struct {
+	feed    string
+	pkg     string
+	timeout time.Duration
+}
+
+// Parses the arguments of the fetch command,
+// `scheduled-feed fetch --feed <feed> --package <package> [--timeout <duration>]`.
+func parseFetchArgs(args []string) (fetchArgs, error) {
+	flags := flag.NewFlagSet(fetchCommand, flag.ContinueOnError)
+	feed := flags.String("feed", "", "the feed to fetch the package from, e.g. npm")
+	pkg := flags.String("package", "", "the name of the package to fetch")
+	timeout := flags.Duration("timeout", time.Minute, "the maximum duration of the fetch")
+	if err := flags.Parse(args); err != nil {
+		return fetchArgs{}, err
+	}
+	if *feed == "" || *pkg == "" {
+		flags.Usage()
+		return fetchArgs{}, errFetchArgs
+	}
+	return fetchArgs{feed: *feed, pkg: *pkg, timeout: *timeout}, nil
+}
+
+// Releases the resources held by a feed constructed by a command, failures are logged as
+// the command has completed.
+func closeFeed(feed feeds.ScheduledFeed) {
+	if err := feeds.Close(feed); err != nil {
+		log.WithError(err).WithField("feed", feed.GetName()).Error("Failed to close feed")
+	}
+}
+
+// Fetches every version of a single package by polling the feed for just that package, as
+// for critical packages, and prints the resolved packages to out as JSON. The options of
+// the feed are taken from the configuration when it is configured. Nothing is published
+// and no cursors are stored.
+func fetch(out io.Writer, appConfig *config.ScheduledFeedConfig, args fetchArgs) error {
+	options := feeds.FeedOptions{}
+	for _, entry := range appConfig.Feeds {
+		if entry.Type == args.feed {
+			options = entry.Options
+		}
+	}
+	// Only the options for reaching the registry and enriching packages are kept, those
+	// selecting which packages are polled are replaced by the single package.
+	options.Packages = &[]string{args.pkg}
+	options.PackagesFile = ""
+	options.PackagesURL = ""
+	options.PackagePollIntervals = nil
+	options.Denylist = nil
+	options.Mode = ""
+	options.CursorStore = nil
+	if options.TLS == nil {
+		options.TLS = appConfig.TLS
+	}
+	eventHandler, err := appConfig.GetEventHandler()
+	if err != nil {
+		return err
+	}
+	feed, err := feeds.NewFeed(args.feed, options, eventHandler)
+	if err != nil {
+		return err
+	}
+	defer closeFeed(feed)
+
+	ctx, cancel := context.WithTimeout(context.Background(), args.timeout)
+	defer cancel()
+	pkgs, errs := feed.Latest(ctx, time.Time{})
+	if len(errs) > 0 {
+		msgs := []string{}
+		for _, err := range errs {
+			msgs = append(msgs, err.Error())
+		}
+		return fmt.Errorf("%w : %v", errFetch, strings.Join(msgs, "; "))
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(pkgs)
+}
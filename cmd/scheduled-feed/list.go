@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ossf/package-feeds/config"
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/feeds/scheduler"
+)
+
+const (
+	listFormatTable = "table"
+	listFormatJSON  = "json"
+
+	modeFirehose = "firehose"
+	modeCritical = "critical"
+)
+
+var errListFormat = errors.New("unsupported list format")
+
+type feedListing struct {
+	Name        string `json:"name"`
+	Mode        string `json:"mode"`
+	NumPackages int    `json:"num_packages"`
+	BaseURL     string `json:"base_url,omitempty"`
+	Schedule    string `json:"schedule"`
+}
+
+// Lists the feeds constructed from the configuration with their mode, number of packages,
+// registry URL and schedule, printing them to out as a table or JSON. The publisher is
+// not constructed and no feeds are polled.
+func listFeeds(out io.Writer, appConfig *config.ScheduledFeedConfig, format string) error {
+	if format != listFormatTable && format != listFormatJSON {
+		return fmt.Errorf("%w : %v", errListFormat, format)
+	}
+	scheduledFeeds, err := appConfig.GetScheduledFeeds()
+	if err != nil {
+		return err
+	}
+
+	pollRate, err := time.ParseDuration(appConfig.PollRate)
+	if err != nil {
+		return fmt.Errorf("failed to parse poll_rate to duration: %w", err)
+	}
+	// Nothing is published, so the schedules can be resolved without a publisher.
+	sched := scheduler.New(scheduledFeeds, nil, appConfig.HTTPPort, schedulerOptions(appConfig)...)
+	defer closeScheduler(sched)
+	feedSchedules, err := sched.Validate(pollRate, appConfig.Timer)
+	if err != nil {
+		return err
+	}
+
+	listings := []feedListing{}
+	for _, feed := range scheduledFeeds {
+		listing := feedListing{
+			Name:     feed.GetName(),
+			Mode:     modeFirehose,
+			Schedule: feedSchedules[feed.GetName()],
+		}
+		if packages := feed.GetFeedOptions().Packages; packages != nil {
+			listing.Mode = modeCritical
+			listing.NumPackages = len(*packages)
+		}
+		if f, ok := feed.(feeds.BaseURLFeed); ok {
+			listing.BaseURL = f.GetBaseURL()
+		}
+		listings = append(listings, listing)
+	}
+	sort.Slice(listings, func(i, j int) bool {
+		return listings[i].Name < listings[j].Name
+	})
+
+	if format == listFormatJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(listings)
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tMODE\tPACKAGES\tBASE URL\tSCHEDULE")
+	for _, listing := range listings {
+		schedule := listing.Schedule
+		if schedule == "" {
+			schedule = "HTTP requests only"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n",
+			listing.Name, listing.Mode, listing.NumPackages, listing.BaseURL, schedule)
+	}
+	return w.Flush()
+}
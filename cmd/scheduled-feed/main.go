@@ -2,38 +2,99 @@ package main
 
 import (
 	"context"
+	"flag"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/ossf/package-feeds/config"
 	"github.com/ossf/package-feeds/feeds/scheduler"
+	"github.com/ossf/package-feeds/publisher"
+	"github.com/ossf/package-feeds/utils"
+)
+
+var (
+	validateConfig    = flag.Bool("validate", false, "validate the configuration and exit without polling")
+	checkConnectivity = flag.Bool("check-connectivity", false,
+		"when validating, poll each feed once to check the registry can be reached")
+	listFeedsFlag = flag.Bool("list-feeds", false, "list the configured feeds and their options and exit without polling")
+	listFormat    = flag.String("list-format", listFormatTable, "the format of --list-feeds output, table or json")
 )
 
 func main() {
-	configPath, useConfig := os.LookupEnv("PACKAGE_FEEDS_CONFIG_PATH")
-	var err error
-
-	var appConfig *config.ScheduledFeedConfig
-	if useConfig {
-		appConfig, err = config.FromFile(configPath)
-		log.Infof("Using config from file: %v", configPath)
-	} else {
-		appConfig = config.Default()
-		log.Info("No config specified, using default configuration")
+	if len(os.Args) > 1 && os.Args[1] == replayCommand {
+		args, err := parseReplayArgs(os.Args[2:])
+		if err != nil {
+			os.Exit(2)
+		}
+		if args.feed != "" {
+			if err := replayWindow(os.Stdout, loadConfig(), args); err != nil {
+				log.Fatalf("Failed to replay packages from %s: %v", args.feed, err)
+			}
+			return
+		}
+		if err := replay(os.Stdout, loadConfig(), args.dryRun); err != nil {
+			log.Fatalf("Failed to replay dead-letter file: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == fetchCommand {
+		args, err := parseFetchArgs(os.Args[2:])
+		if err != nil {
+			os.Exit(2)
+		}
+		if err := fetch(os.Stdout, loadConfig(), args); err != nil {
+			log.Fatalf("Failed to fetch %s from %s: %v", args.pkg, args.feed, err)
+		}
+		return
 	}
+	flag.Parse()
+
+	appConfig := loadConfig()
+
+	shutdownTracing, err := initTracing(context.TODO())
 	if err != nil {
-		log.Fatal(err)
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	if shutdownTracing != nil {
+		log.Info("Exporting trace spans over OTLP")
+	}
+	defer closeTracing(shutdownTracing)
+
+	if *listFeedsFlag {
+		if err := listFeeds(os.Stdout, appConfig, *listFormat); err != nil {
+			log.Fatalf("Failed to list feeds: %v", err)
+		}
+		return
+	}
+
+	if *validateConfig {
+		if err := validate(os.Stdout, appConfig, *checkConnectivity); err != nil {
+			log.Fatalf("Invalid configuration: %v", err)
+		}
+		return
 	}
 
-	pub, err := appConfig.PubConfig.ToPublisher(context.TODO())
+	pub, err := appConfig.GetPublisher(context.TODO())
 	if err != nil {
 		log.Fatalf("Failed to initialize publisher from config: %v", err)
 	}
 	log.Infof("Using %q publisher", pub.Name())
 
+	serializer, err := appConfig.GetSerializer()
+	if err != nil {
+		log.Fatalf("Failed to initialize serializer from config: %v", err)
+	}
+
+	enricher, err := appConfig.GetEnricher()
+	if err != nil {
+		log.Fatalf("Failed to initialize enrichers from config: %v", err)
+	}
+
 	scheduledFeeds, err := appConfig.GetScheduledFeeds()
 	feedNames := []string{}
 	for k := range scheduledFeeds {
@@ -48,9 +109,107 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to parse poll_rate to duration: %v", err)
 	}
-	sched := scheduler.New(scheduledFeeds, pub, appConfig.HTTPPort)
-	err = sched.Run(pollRate, appConfig.Timer)
+	opts := schedulerOptions(appConfig)
+	opts = append(opts, scheduler.WithSerializer(serializer))
+	if enricher != nil {
+		opts = append(opts, scheduler.WithEnricher(enricher))
+	}
+	sched := scheduler.New(scheduledFeeds, pub, appConfig.HTTPPort, opts...)
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- sched.Run(pollRate, appConfig.Timer)
+	}()
+	select {
+	case err = <-runErr:
+	case sig := <-stop:
+		log.WithField("signal", sig).Print("Shutting down")
+	}
+	closeScheduler(sched)
+	// Packages held by the publisher, such as those of open blob objects, are written.
+	if closeErr := publisher.Close(context.Background(), pub); closeErr != nil {
+		log.WithError(closeErr).Error("Failed to close publisher")
+	}
+	if err != nil {
+		// log.Fatal exits without running deferred calls, the spans are exported first.
+		closeTracing(shutdownTracing)
+		log.Fatal(err)
+	}
+}
+
+// Releases the resources held by the feeds of the scheduler, failures are logged as there
+// is nothing more to do when exiting.
+func closeScheduler(sched *scheduler.Scheduler) {
+	if err := sched.Close(); err != nil {
+		log.WithError(err).Error("Failed to close scheduler")
+	}
+}
+
+// Loads the configuration from the file at PACKAGE_FEEDS_CONFIG_PATH, or the default
+// configuration if unset.
+func loadConfig() *config.ScheduledFeedConfig {
+	configPath, useConfig := os.LookupEnv("PACKAGE_FEEDS_CONFIG_PATH")
+	if !useConfig {
+		log.Info("No config specified, using default configuration")
+		return config.Default()
+	}
+	appConfig, err := config.FromFile(configPath)
 	if err != nil {
 		log.Fatal(err)
 	}
+	log.Infof("Using config from file: %v", configPath)
+	if appConfig.HTTPTransport != nil {
+		utils.ConfigureTransport(*appConfig.HTTPTransport)
+	}
+	if appConfig.HTTPDebug != nil && appConfig.HTTPDebug.Enabled {
+		// Requests are logged at debug level, which would otherwise be discarded.
+		log.SetLevel(log.DebugLevel)
+		utils.ConfigureHTTPDebug(*appConfig.HTTPDebug)
+	}
+	return appConfig
+}
+
+// Scheduler options derived from the application configuration.
+func schedulerOptions(appConfig *config.ScheduledFeedConfig) []scheduler.Option {
+	opts := []scheduler.Option{scheduler.WithJitter(appConfig.Jitter)}
+	if appConfig.CircuitBreaker != nil {
+		opts = append(opts, scheduler.WithCircuitBreaker(
+			appConfig.CircuitBreaker.Threshold, appConfig.CircuitBreaker.Cooldown))
+	}
+	if appConfig.RecentPackages != 0 {
+		opts = append(opts, scheduler.WithRecentPackages(appConfig.RecentPackages))
+	}
+	if len(appConfig.Labels) > 0 {
+		opts = append(opts, scheduler.WithLabels(appConfig.Labels))
+	}
+	if appConfig.MaxConcurrentFeeds != 0 {
+		opts = append(opts, scheduler.WithMaxConcurrentFeeds(appConfig.MaxConcurrentFeeds))
+	}
+	if appConfig.MaxLookback != 0 {
+		opts = append(opts, scheduler.WithMaxLookback(appConfig.MaxLookback))
+	}
+	if appConfig.PollsOnStart() {
+		opts = append(opts, scheduler.WithPollOnStart())
+	}
+	if appConfig.CycleSummary {
+		opts = append(opts, scheduler.WithCycleSummary())
+	}
+	if appConfig.OrderedPublishing {
+		opts = append(opts, scheduler.WithOrderedPublishing())
+	}
+	if appConfig.PublishQueue != nil {
+		policy := scheduler.QueuePolicy(appConfig.PublishQueue.Policy)
+		if policy == "" {
+			policy = scheduler.QueueBlock
+		}
+		opts = append(opts, scheduler.WithPublishQueue(appConfig.PublishQueue.Capacity, policy))
+	}
+	if disabled := appConfig.DisabledFeeds(); len(disabled) > 0 {
+		opts = append(opts, scheduler.WithDisabledFeeds(disabled...))
+	}
+	if appConfig.Clock != nil {
+		opts = append(opts, scheduler.WithClock(appConfig.Clock))
+	}
+	return opts
 }
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ossf/package-feeds/config"
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/publisher"
+	"github.com/ossf/package-feeds/publisher/deadletter"
+)
+
+const replayCommand = "replay"
+
+var (
+	errNoDeadLetterFile = errors.New("dead_letter_file is not configured")
+	errReplayArgs       = errors.New("--from and --to require --feed")
+	errReplayWindow     = errors.New("--feed requires a window where --from is before --to")
+	errReplay           = errors.New("failed to replay packages")
+)
+
+type replayArgs struct {
+	dryRun bool
+	// The feed whose packages are replayed from the registry, or empty to replay the
+	// dead-letter file.
+	feed    string
+	from    time.Time
+	to      time.Time
+	timeout time.Duration
+}
+
+// Parses the arguments of the replay command, `scheduled-feed replay [--dry-run]` to replay
+// the dead-letter file or
+// `scheduled-feed replay --feed <feed> --from <time> [--to <time>] [--timeout <duration>] [--dry-run]`
+// to replay the packages created within a window from the registry.
+func parseReplayArgs(args []string) (replayArgs, error) {
+	flags := flag.NewFlagSet(replayCommand, flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "list the dead-letter entries or packages without sending them")
+	feed := flags.String("feed", "", "replay the packages created within the window from the registry of the feed")
+	from := flags.String("from", "", "the start of the window replayed from the feed as an RFC 3339 time, inclusive")
+	to := flags.String("to", "", "the end of the window replayed from the feed as an RFC 3339 time, exclusive, "+
+		"defaults to now")
+	timeout := flags.Duration("timeout", 10*time.Minute, "the maximum duration of fetching the window from the feed")
+	if err := flags.Parse(args); err != nil {
+		return replayArgs{}, err
+	}
+	parsed := replayArgs{dryRun: *dryRun, feed: *feed, to: time.Now(), timeout: *timeout}
+	if *feed == "" {
+		if *from != "" || *to != "" {
+			return replayArgs{}, replayUsage(flags, errReplayArgs)
+		}
+		return parsed, nil
+	}
+	if *from == "" {
+		return replayArgs{}, replayUsage(flags, errReplayWindow)
+	}
+	var err error
+	if parsed.from, err = time.Parse(time.RFC3339, *from); err != nil {
+		return replayArgs{}, replayUsage(flags, fmt.Errorf("invalid --from: %w", err))
+	}
+	if *to != "" {
+		if parsed.to, err = time.Parse(time.RFC3339, *to); err != nil {
+			return replayArgs{}, replayUsage(flags, fmt.Errorf("invalid --to: %w", err))
+		}
+	}
+	if !parsed.from.Before(parsed.to) {
+		return replayArgs{}, replayUsage(flags, errReplayWindow)
+	}
+	return parsed, nil
+}
+
+// Reports the invalid arguments along with the usage of the command.
+func replayUsage(flags *flag.FlagSet, err error) error {
+	fmt.Fprintln(flags.Output(), err)
+	flags.Usage()
+	return err
+}
+
+// Re-sends the entries of the dead-letter file to the configured publishers which failed to
+// send them, entries which fail to send again are kept for the next replay. With dryRun the entries are
+// listed without being sent.
+func replay(out io.Writer, appConfig *config.ScheduledFeedConfig, dryRun bool) error {
+	if appConfig.DeadLetterFile == "" {
+		return errNoDeadLetterFile
+	}
+	file := deadletter.NewFile(appConfig.DeadLetterFile)
+
+	if dryRun {
+		entries, err := file.Read()
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			fmt.Fprintf(out, "%s %s: %s\n  %s\n",
+				entry.Time.Format("2006-01-02T15:04:05Z07:00"), entry.Publisher, entry.Error, entry.Body)
+		}
+		fmt.Fprintf(out, "%d entries would be replayed\n", len(entries))
+		return nil
+	}
+
+	pub, byID, err := appConfig.GetPrimaryPublisher(context.TODO())
+	if err != nil {
+		return fmt.Errorf("failed to initialize publisher from config: %w", err)
+	}
+	replayed, failed, err := deadletter.Replay(context.TODO(), file, pub, byID)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%d entries replayed, %d entries failed and were kept\n", replayed, failed)
+	return nil
+}
+
+// Fetches the packages created within the window from the registry of the feed and
+// publishes them through the configured publishers and enrichers, e.g. to backfill a
+// consumer. Only feeds which can query their registry for a past window support this. The
+// options of the feed are taken from the configuration when it is configured, and the
+// cursors of the running application are neither read nor advanced. With dryRun the
+// packages are listed without being sent.
+func replayWindow(out io.Writer, appConfig *config.ScheduledFeedConfig, args replayArgs) error {
+	options := feeds.FeedOptions{}
+	for _, entry := range appConfig.Feeds {
+		if entry.Type == args.feed {
+			options = entry.Options
+		}
+	}
+	// The window replaces the packages polled and the cursor of the feed.
+	options.Packages = nil
+	options.PackagesFile = ""
+	options.PackagesURL = ""
+	options.CursorStore = nil
+	if options.TLS == nil {
+		options.TLS = appConfig.TLS
+	}
+	eventHandler, err := appConfig.GetEventHandler()
+	if err != nil {
+		return err
+	}
+	feed, err := feeds.NewFeed(args.feed, options, eventHandler)
+	if err != nil {
+		return err
+	}
+	defer closeFeed(feed)
+
+	ctx, cancel := context.WithTimeout(context.Background(), args.timeout)
+	defer cancel()
+	pkgs, errs := feeds.Between(ctx, feed, args.from, args.to)
+	for _, err := range errs {
+		if errors.Is(err, feeds.ErrNoHistory) {
+			return err
+		}
+	}
+
+	if args.dryRun {
+		for _, pkg := range pkgs {
+			fmt.Fprintf(out, "%s %s@%s\n", pkg.CreatedDate.Format(time.RFC3339), pkg.Name, pkg.Version)
+		}
+		fmt.Fprintf(out, "%d packages would be replayed\n", len(pkgs))
+	} else {
+		replayed, pubErrs, err := publishReplayed(appConfig, pkgs)
+		if err != nil {
+			return err
+		}
+		errs = append(errs, pubErrs...)
+		fmt.Fprintf(out, "%d packages replayed, %d packages failed to publish\n", replayed, len(pkgs)-replayed)
+	}
+	if len(errs) > 0 {
+		msgs := []string{}
+		for _, err := range errs {
+			msgs = append(msgs, err.Error())
+		}
+		return fmt.Errorf("%w : %v", errReplay, strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+// Publishes the replayed packages as the scheduler would, returning the number published
+// and the errors of those which failed to publish.
+func publishReplayed(appConfig *config.ScheduledFeedConfig, pkgs []*feeds.Package) (int, []error, error) {
+	ctx := context.Background()
+	pub, err := appConfig.GetPublisher(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to initialize publisher from config: %w", err)
+	}
+	serializer, err := appConfig.GetSerializer()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to initialize serializer from config: %w", err)
+	}
+	enricher, err := appConfig.GetEnricher()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to initialize enrichers from config: %w", err)
+	}
+
+	replayed := 0
+	errs := []error{}
+	for _, pkg := range pkgs {
+		if enricher != nil {
+			if err := enricher.Enrich(ctx, pkg); err != nil {
+				log.WithFields(log.Fields{
+					"feed":    pkg.Type,
+					"name":    pkg.Name,
+					"version": pkg.Version,
+				}).WithError(err).Warn("Failed to enrich package")
+			}
+		}
+		if len(appConfig.Labels) > 0 {
+			pkg.Labels = appConfig.Labels
+		}
+		body, err := serializer.Serialize(pkg)
+		if err == nil {
+			sendCtx := publisher.ContextWithContentType(publisher.ContextWithPackage(ctx, pkg), serializer.ContentType())
+			err = pub.Send(sendCtx, body)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s@%s: %w", pkg.Name, pkg.Version, err))
+			continue
+		}
+		replayed++
+	}
+	if err := publisher.Flush(ctx, pub); err != nil {
+		errs = append(errs, err)
+	}
+	return replayed, errs, nil
+}
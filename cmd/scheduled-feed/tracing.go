@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+const (
+	serviceName = "package-feeds"
+	// The time allowed to export the remaining spans on exit.
+	tracingShutdownTimeout = 5 * time.Second
+)
+
+// Configures exporting of trace spans over OTLP when an OTLP endpoint is provided by the
+// standard OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT environment
+// variables. The exporter reads its remaining configuration from the standard environment
+// variables. Returns the shutdown of the tracer provider, which exports the batched spans,
+// or nil as tracing is disabled if no endpoint is provided.
+func initTracing(ctx context.Context) (func(context.Context) error, error) {
+	_, endpoint := os.LookupEnv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	_, tracesEndpoint := os.LookupEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if !endpoint && !tracesEndpoint {
+		return nil, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(serviceName),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown, nil
+}
+
+// Exports the spans batched by the tracer provider and shuts it down, failures are logged as
+// there is nothing more to do when exiting.
+func closeTracing(shutdown func(context.Context) error) {
+	if shutdown == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+	defer cancel()
+	if err := shutdown(ctx); err != nil {
+		log.WithError(err).Error("Failed to shut down tracing")
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/ossf/package-feeds/config"
+	"github.com/ossf/package-feeds/feeds/scheduler"
+)
+
+var errConnectivity = errors.New("failed to poll feeds")
+
+// Validates the configuration by constructing the publisher, feeds and scheduler as they
+// would be at startup, printing a summary to out. If checkConnectivity is set, each feed
+// is polled once with a cutoff of now so that no packages are returned. Nothing is sent
+// to the publisher.
+func validate(out io.Writer, appConfig *config.ScheduledFeedConfig, checkConnectivity bool) error {
+	pub, err := appConfig.GetPublisher(context.TODO())
+	if err != nil {
+		return fmt.Errorf("failed to initialize publisher from config: %w", err)
+	}
+	fmt.Fprintf(out, "Publisher: %s\n", pub.Name())
+	if _, err := appConfig.GetEnricher(); err != nil {
+		return fmt.Errorf("failed to initialize enrichers from config: %w", err)
+	}
+
+	if checkConnectivity {
+		// Polling must not advance the persisted cursors of the deployed application.
+		appConfig.CursorFile = ""
+	}
+	scheduledFeeds, err := appConfig.GetScheduledFeeds()
+	if err != nil {
+		return err
+	}
+
+	pollRate, err := time.ParseDuration(appConfig.PollRate)
+	if err != nil {
+		return fmt.Errorf("failed to parse poll_rate to duration: %w", err)
+	}
+	sched := scheduler.New(scheduledFeeds, pub, appConfig.HTTPPort, schedulerOptions(appConfig)...)
+	defer closeScheduler(sched)
+	feedSchedules, err := sched.Validate(pollRate, appConfig.Timer)
+	if err != nil {
+		return err
+	}
+
+	feedNames := []string{}
+	for name := range scheduledFeeds {
+		feedNames = append(feedNames, name)
+	}
+	sort.Strings(feedNames)
+
+	fmt.Fprintln(out, "Feeds:")
+	failedFeeds := 0
+	for _, name := range feedNames {
+		schedule := feedSchedules[scheduledFeeds[name].GetName()]
+		if schedule == "" {
+			schedule = "HTTP requests only"
+		}
+		fmt.Fprintf(out, "  %s: %s\n", name, schedule)
+
+		if !checkConnectivity {
+			continue
+		}
+		_, errs := scheduledFeeds[name].Latest(context.TODO(), time.Now())
+		for _, err := range errs {
+			fmt.Fprintf(out, "    error: %v\n", err)
+		}
+		if len(errs) > 0 {
+			failedFeeds++
+		}
+	}
+	if failedFeeds > 0 {
+		return fmt.Errorf("%w : %d feeds returned errors", errConnectivity, failedFeeds)
+	}
+	fmt.Fprintln(out, "Configuration is valid")
+	return nil
+}
@@ -2,13 +2,16 @@ package config_test
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ossf/package-feeds/config"
 	"github.com/ossf/package-feeds/events"
 	"github.com/ossf/package-feeds/feeds"
 	"github.com/ossf/package-feeds/feeds/pypi"
 	"github.com/ossf/package-feeds/feeds/scheduler"
+	"github.com/ossf/package-feeds/publisher"
 	"github.com/ossf/package-feeds/publisher/stdout"
 )
 
@@ -86,6 +89,92 @@ func TestGetScheduledFeeds(t *testing.T) {
 	}
 }
 
+func TestGetScheduledFeedsDisabled(t *testing.T) {
+	t.Parallel()
+
+	c, err := config.NewConfigFromBytes([]byte(`
+feeds:
+- type: npm
+  enabled: false
+  options:
+    packages:
+    - lodash
+- type: pypi
+  enabled: true
+- type: crates
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheduledFeeds, err := c.GetScheduledFeeds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := scheduledFeeds["npm"]; ok {
+		t.Errorf("disabled npm feed was found in scheduled feeds after GetScheduledFeeds()")
+	}
+	if len(scheduledFeeds) != 2 {
+		t.Errorf("GetScheduledFeeds() returned %v feeds when the 2 enabled feeds were expected", len(scheduledFeeds))
+	}
+	if disabled := c.DisabledFeeds(); len(disabled) != 1 || disabled[0] != "npm" {
+		t.Errorf("DisabledFeeds() returned %v when [npm] was expected", disabled)
+	}
+	// The configuration of the disabled feed is kept.
+	if packages := c.Feeds[0].Options.Packages; packages == nil || len(*packages) != 1 {
+		t.Errorf("packages of the disabled feed were not kept")
+	}
+}
+
+func TestGetScheduledFeedsTLS(t *testing.T) {
+	t.Parallel()
+
+	c, err := config.NewConfigFromBytes([]byte(`
+tls:
+  insecure_skip_verify: true
+feeds:
+- type: npm
+- type: pypi
+  options:
+    tls:
+      insecure_skip_verify: false
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheduledFeeds, err := c.GetScheduledFeeds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	npmOptions := scheduledFeeds["npm"].GetFeedOptions()
+	if npmOptions.TLS != nil || npmOptions.DefaultTLS == nil || !npmOptions.DefaultTLS.InsecureSkipVerify {
+		t.Errorf("npm feed was not configured with the top level tls configuration")
+	}
+	pypiOptions := scheduledFeeds["pypi"].GetFeedOptions()
+	if pypiOptions.TLS == nil || pypiOptions.TLS.InsecureSkipVerify {
+		t.Errorf("pypi feed tls configuration was overridden by the top level tls configuration")
+	}
+}
+
+func TestGetScheduledFeedsClock(t *testing.T) {
+	t.Parallel()
+
+	c, err := config.NewConfigFromBytes([]byte(`
+feeds:
+- type: npm
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Clock = feeds.NewFakeClock(time.Date(2021, 5, 11, 12, 0, 0, 0, time.UTC))
+	scheduledFeeds, err := c.GetScheduledFeeds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clock := scheduledFeeds["npm"].GetFeedOptions().Clock; clock != c.Clock {
+		t.Errorf("npm feed was configured with the clock %v when the clock of the configuration was expected", clock)
+	}
+}
+
 func TestLoadFeedConfigUnknownFeedType(t *testing.T) {
 	t.Parallel()
 
@@ -206,3 +295,215 @@ func TestEventHandlerConfiguration(t *testing.T) {
 		t.Errorf("configured filter incorrectly rejects component `baz` from being dispatched")
 	}
 }
+
+func TestGetPublisherMultiple(t *testing.T) {
+	t.Parallel()
+
+	c, err := config.NewConfigFromBytes([]byte(`
+publishers:
+- type: stdout
+- type: stdout
+`))
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	pub, err := c.GetPublisher(context.TODO())
+	if err != nil {
+		t.Fatalf("failed to create publishers from config: %v", err)
+	}
+	if _, ok := pub.(*publisher.Multi); !ok {
+		t.Errorf("multiple publishers produced a %T instead of a multi publisher", pub)
+	}
+}
+
+func TestGetEnricher(t *testing.T) {
+	t.Parallel()
+
+	c, err := config.NewConfigFromBytes([]byte(`
+enrichers:
+- type: ossindex
+  config:
+    url: https://ossindex.sonatype.org
+`))
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	enricher, err := c.GetEnricher()
+	if err != nil {
+		t.Fatalf("failed to create enrichers from config: %v", err)
+	}
+	if enrichers, ok := enricher.(feeds.Enrichers); !ok || len(enrichers) != 1 {
+		t.Errorf("GetEnricher() returned %#v when a single composed enricher was expected", enricher)
+	}
+
+	c.Enrichers[0].Type = "foo"
+	if _, err := c.GetEnricher(); err == nil {
+		t.Errorf("GetEnricher() returned no error for an unknown enricher type")
+	}
+
+	c.Enrichers = nil
+	if enricher, err := c.GetEnricher(); enricher != nil || err != nil {
+		t.Errorf("GetEnricher() returned %v, %v when no enrichers are configured", enricher, err)
+	}
+}
+
+func TestGetPublisherFieldNaming(t *testing.T) {
+	t.Parallel()
+
+	c, err := config.NewConfigFromBytes([]byte(`
+publisher:
+  type: stdout
+  field_naming: camelCase
+`))
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	if c.PubConfig.FieldNaming != publisher.FieldNamingCamelCase {
+		t.Fatalf("field naming was parsed as %q instead of %q", c.PubConfig.FieldNaming, publisher.FieldNamingCamelCase)
+	}
+	if _, err := c.GetPublisher(context.TODO()); err != nil {
+		t.Fatalf("failed to create publisher from config: %v", err)
+	}
+
+	c.PubConfig.FieldNaming = "kebab-case"
+	if _, err := c.GetPublisher(context.TODO()); err == nil {
+		t.Errorf("expected an error creating a publisher with an unknown field naming")
+	}
+}
+
+func TestGetPublisherFormat(t *testing.T) {
+	t.Parallel()
+
+	c, err := config.NewConfigFromBytes([]byte(`
+publisher:
+  type: stdout
+  format: osv
+`))
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	if c.PubConfig.Format != publisher.FormatOSV {
+		t.Fatalf("format was parsed as %q instead of %q", c.PubConfig.Format, publisher.FormatOSV)
+	}
+	if _, err := c.GetPublisher(context.TODO()); err != nil {
+		t.Fatalf("failed to create publisher from config: %v", err)
+	}
+
+	c.PubConfig.FieldNaming = publisher.FieldNamingCamelCase
+	if _, err := c.GetPublisher(context.TODO()); err == nil {
+		t.Errorf("expected an error creating a publisher with field naming for the osv format")
+	}
+}
+
+func TestGetSerializer(t *testing.T) {
+	t.Parallel()
+
+	c, err := config.NewConfigFromBytes([]byte(`
+publishers:
+  - type: stdout
+    format: osv
+  - type: stdout
+    format: osv
+`))
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	serializer, err := c.GetSerializer()
+	if err != nil {
+		t.Fatalf("failed to create serializer from config: %v", err)
+	}
+	if _, ok := serializer.(publisher.OSVSerializer); !ok {
+		t.Errorf("GetSerializer() returned %T when the publishers share the osv format", serializer)
+	}
+
+	c.Publishers[1].Format = ""
+	serializer, err = c.GetSerializer()
+	if err != nil {
+		t.Fatalf("failed to create serializer from config: %v", err)
+	}
+	if _, ok := serializer.(publisher.JSONSerializer); !ok {
+		t.Errorf("GetSerializer() returned %T when the publishers have different formats", serializer)
+	}
+}
+
+func TestGetPublisherKafkaSerialization(t *testing.T) {
+	t.Parallel()
+
+	// Avro encodes the package itself, so options rendering the message are rejected rather
+	// than silently discarded.
+	for _, option := range []string{"envelope: true", "format: osv", "field_naming: camelCase"} {
+		c, err := config.NewConfigFromBytes([]byte(`
+publisher:
+  type: kafka
+  ` + option + `
+  config:
+    brokers:
+    - 127.0.0.1:9092
+    topic: packagefeeds
+    serialization: avro
+    schema_registry_url: http://127.0.0.1:8081
+`))
+		if err != nil {
+			t.Fatalf("failed to parse config: %v", err)
+		}
+		_, err = c.GetPublisher(context.TODO())
+		if err == nil || !strings.Contains(err.Error(), "kafka serialization") {
+			t.Errorf("GetPublisher() returned `%v` when an error was expected for %v with avro", err, option)
+		}
+	}
+}
+
+func TestGetPublisherRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	c, err := config.NewConfigFromBytes([]byte(`
+publishers:
+  - type: stdout
+    max_retries: 3
+    backoff: 2s
+    on_failure: drop
+  - type: stdout
+    on_failure: deadletter
+`))
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	pc := c.Publishers[0]
+	if pc.MaxRetries != 3 || pc.Backoff != 2*time.Second || pc.OnFailure != publisher.OnFailureDrop {
+		t.Fatalf("retry policy was parsed as %v, %v and %q", pc.MaxRetries, pc.Backoff, pc.OnFailure)
+	}
+	if _, err := c.GetPublisher(context.TODO()); err == nil {
+		t.Errorf("expected an error creating a dead-letter publisher without a dead-letter file")
+	}
+
+	c.DeadLetterFile = "dead-letter.jsonl"
+	if _, err := c.GetPublisher(context.TODO()); err != nil {
+		t.Fatalf("failed to create publisher from config: %v", err)
+	}
+}
+
+func TestGetPrimaryPublisherIDs(t *testing.T) {
+	t.Parallel()
+
+	c, err := config.NewConfigFromBytes([]byte(`
+publishers:
+  - type: stdout
+  - type: stdout
+    on_failure: drop
+`))
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	_, byID, err := c.GetPrimaryPublisher(context.TODO())
+	if err != nil {
+		t.Fatalf("failed to create publisher from config: %v", err)
+	}
+	for _, id := range []string{"0:stdout", "1:stdout"} {
+		if _, ok := byID[id]; !ok {
+			t.Errorf("GetPrimaryPublisher() returned no publisher for %q", id)
+		}
+	}
+	if len(byID) != 2 {
+		t.Errorf("GetPrimaryPublisher() returned %v publishers when 2 were expected", len(byID))
+	}
+}
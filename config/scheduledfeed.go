@@ -14,6 +14,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 
+	"github.com/ossf/package-feeds/enrichers/ossindex"
 	"github.com/ossf/package-feeds/events"
 	"github.com/ossf/package-feeds/feeds"
 	"github.com/ossf/package-feeds/feeds/crates"
@@ -24,15 +25,36 @@ import (
 	"github.com/ossf/package-feeds/feeds/pypi"
 	"github.com/ossf/package-feeds/feeds/rubygems"
 	"github.com/ossf/package-feeds/publisher"
+	"github.com/ossf/package-feeds/publisher/blob"
+	"github.com/ossf/package-feeds/publisher/cyclonedx"
+	"github.com/ossf/package-feeds/publisher/deadletter"
+	"github.com/ossf/package-feeds/publisher/elasticsearch"
 	"github.com/ossf/package-feeds/publisher/gcppubsub"
+	"github.com/ossf/package-feeds/publisher/httpendpoint"
 	"github.com/ossf/package-feeds/publisher/kafkapubsub"
 	"github.com/ossf/package-feeds/publisher/stdout"
+	"github.com/ossf/package-feeds/publisher/syslog"
+
+	// Register feeds which are not part of the default configuration.
+	_ "github.com/ossf/package-feeds/feeds/bioconductor"
+	_ "github.com/ossf/package-feeds/feeds/conda"
+	_ "github.com/ossf/package-feeds/feeds/cpan"
+	_ "github.com/ossf/package-feeds/feeds/gitea"
+	_ "github.com/ossf/package-feeds/feeds/gitlab"
+	_ "github.com/ossf/package-feeds/feeds/helm"
+	_ "github.com/ossf/package-feeds/feeds/homebrew"
+	_ "github.com/ossf/package-feeds/feeds/localdir"
+	_ "github.com/ossf/package-feeds/feeds/pub"
+	_ "github.com/ossf/package-feeds/feeds/swiftpackageindex"
+	_ "github.com/ossf/package-feeds/feeds/terraform"
 )
 
 var (
-	errUnknownFeed     = errors.New("unknown feed type")
 	errUnknownPub      = errors.New("unknown publisher type")
 	errUnknownSinkType = errors.New("unknown sink type")
+	errUnknownEnricher = errors.New("unknown enricher type")
+	errNoDeadLetter    = errors.New("on_failure deadletter requires dead_letter_file")
+	errSerializedBody  = errors.New("option can't be used with a kafka serialization other than json")
 )
 
 // Loads a ScheduledFeedConfig struct from a yaml config file.
@@ -49,12 +71,21 @@ func FromFile(configPath string) (*ScheduledFeedConfig, error) {
 func NewConfigFromBytes(yamlBytes []byte) (*ScheduledFeedConfig, error) {
 	config := Default()
 
-	err := unmarshalStrict(yamlBytes, config)
+	err := validateConfig(yamlBytes)
+	if err != nil {
+		return nil, err
+	}
+	err = unmarshalStrict(yamlBytes, config)
 	if err != nil {
 		return nil, err
 	}
 	config.applyEnvVars()
 
+	err = config.ResolveSecrets(context.TODO(), DefaultSecretProviders())
+	if err != nil {
+		return nil, err
+	}
+
 	return config, nil
 }
 
@@ -84,7 +115,7 @@ func AddTo(ls *[]int, value int) {
 }
 
 // Constructs a map of ScheduledFeeds to enable based on the Feeds
-// provided from configuration, indexed by the feed type.
+// provided from configuration, indexed by the feed type. Disabled feeds are not constructed.
 func (sc *ScheduledFeedConfig) GetScheduledFeeds() (map[string]feeds.ScheduledFeed, error) {
 	scheduledFeeds := map[string]feeds.ScheduledFeed{}
 	eventHandler, err := sc.GetEventHandler()
@@ -92,7 +123,19 @@ func (sc *ScheduledFeedConfig) GetScheduledFeeds() (map[string]feeds.ScheduledFe
 		return nil, err
 	}
 
+	// Without a cursor file, cursors are only kept for the lifetime of the process.
+	var cursorStore feeds.CursorStore = feeds.NewMemoryCursorStore()
+	if sc.CursorFile != "" {
+		cursorStore = feeds.NewFileCursorStore(sc.CursorFile)
+	}
+
 	for _, entry := range sc.Feeds {
+		if !entry.IsEnabled() {
+			continue
+		}
+		entry.Options.CursorStore = cursorStore
+		entry.Options.DefaultTLS = sc.TLS
+		entry.Options.Clock = sc.Clock
 		feed, err := entry.ToFeed(eventHandler)
 		if err != nil {
 			return nil, err
@@ -103,6 +146,23 @@ func (sc *ScheduledFeedConfig) GetScheduledFeeds() (map[string]feeds.ScheduledFe
 	return scheduledFeeds, nil
 }
 
+// DisabledFeeds returns the types of the feeds disabled in the configuration.
+func (sc *ScheduledFeedConfig) DisabledFeeds() []string {
+	disabled := []string{}
+	for _, entry := range sc.Feeds {
+		if !entry.IsEnabled() {
+			disabled = append(disabled, entry.Type)
+		}
+	}
+	return disabled
+}
+
+// PollsOnStart returns whether feeds with a timer are polled at startup, they are unless
+// explicitly disabled.
+func (sc *ScheduledFeedConfig) PollsOnStart() bool {
+	return sc.PollOnStart == nil || *sc.PollOnStart
+}
+
 func (sc *ScheduledFeedConfig) GetEventHandler() (*events.Handler, error) {
 	var err error
 	if sc.EventsConfig == nil {
@@ -127,13 +187,176 @@ func (ec *EventsConfig) ToEventHandler() (*events.Handler, error) {
 	return events.NewHandler(sink, ec.EventFilter), nil
 }
 
+// Produces the Publisher to be used for pushing packages, when several publishers are
+// configured these are wrapped in a publisher.Multi. If a dead-letter file is configured,
+// messages which a publisher fails to send are written to it. Packages sent to it must be
+// serialized by the serializer produced by GetSerializer.
+func (sc *ScheduledFeedConfig) GetPublisher(ctx context.Context) (publisher.Publisher, error) {
+	if sc.DeadLetterFile == "" {
+		for _, pc := range sc.publisherConfigs() {
+			if pc.OnFailure == publisher.OnFailureDeadLetter {
+				return nil, fmt.Errorf("%w : %v publisher", errNoDeadLetter, pc.Type)
+			}
+		}
+		pub, _, err := sc.getPublisher(ctx, nil)
+		return pub, err
+	}
+	pub, _, err := sc.getPublisher(ctx, deadletter.NewFile(sc.DeadLetterFile))
+	return pub, err
+}
+
+// Produces the configured publisher as GetPublisher does, without writing failed messages
+// to the dead-letter file. The publishers it wraps are also returned, indexed by the id
+// recorded with the messages they failed to send.
+func (sc *ScheduledFeedConfig) GetPrimaryPublisher(ctx context.Context) (
+	publisher.Publisher, map[string]publisher.Publisher, error) {
+	return sc.getPublisher(ctx, nil)
+}
+
+// Produces the Serializer which packages are serialized with before they are sent to the
+// configured publisher. This is the format shared by all publishers, or JSON when their
+// formats differ, in which case the publishers of other formats serialize packages again.
+func (sc *ScheduledFeedConfig) GetSerializer() (publisher.Serializer, error) {
+	return publisher.NewSerializer(sc.sharedFormat())
+}
+
+// Returns the format of all configured publishers, json if their formats differ.
+func (sc *ScheduledFeedConfig) sharedFormat() string {
+	format := ""
+	for i, pc := range sc.publisherConfigs() {
+		pcFormat := pc.Format
+		if pcFormat == "" {
+			pcFormat = publisher.FormatJSON
+		}
+		if i > 0 && pcFormat != format {
+			return publisher.FormatJSON
+		}
+		format = pcFormat
+	}
+	return format
+}
+
+func (sc *ScheduledFeedConfig) publisherConfigs() []PublisherConfig {
+	if len(sc.Publishers) == 0 {
+		return []PublisherConfig{sc.PubConfig}
+	}
+	return sc.Publishers
+}
+
+// Identifies the publisher at the index of the configured publishers.
+func publisherID(i int, pc PublisherConfig) string {
+	return fmt.Sprintf("%d:%s", i, pc.Type)
+}
+
+// Produces the configured publishers, indexed by their id. When the dead-letter file is
+// provided, the messages which publishers without another on_failure policy fail to send
+// are written to it.
+func (sc *ScheduledFeedConfig) getPublisher(ctx context.Context, file *deadletter.File) (
+	publisher.Publisher, map[string]publisher.Publisher, error) {
+	pubs := []publisher.Publisher{}
+	byID := map[string]publisher.Publisher{}
+	format := sc.sharedFormat()
+	for i, pc := range sc.publisherConfigs() {
+		pub, err := pc.toPublisher(ctx, format)
+		if err != nil {
+			return nil, nil, err
+		}
+		byID[publisherID(i, pc)] = pub
+		if file != nil && (pc.OnFailure == "" || pc.OnFailure == publisher.OnFailureDeadLetter) {
+			pub = deadletter.WithDeadLetter(pub, file, publisherID(i, pc))
+		}
+		pubs = append(pubs, pub)
+	}
+	if len(pubs) == 1 {
+		return pubs[0], byID, nil
+	}
+	return publisher.NewMulti(pubs...), byID, nil
+}
+
 // Produces a Publisher object from the provided PublisherConfig
 // The PublisherConfig.Type value is evaluated and the appropriate Publisher is
 // constructed from the Config field. If the type is not a recognised Publisher type,
-// an error is returned.
+// an error is returned. The publisher uses the configured format, envelope and field naming,
+// and retries failed sends according to the configured policy. Packages sent to it must be
+// serialized as JSON.
 func (pc PublisherConfig) ToPublisher(ctx context.Context) (publisher.Publisher, error) {
+	return pc.toPublisher(ctx, publisher.FormatJSON)
+}
+
+// Produces the publisher as ToPublisher does, for packages which are sent to it already
+// serialized in the serialized format. Packages are only serialized again by the publisher
+// when its format differs.
+func (pc PublisherConfig) toPublisher(ctx context.Context, serialized string) (publisher.Publisher, error) {
+	if err := publisher.ValidateFormat(pc.Format, pc.FieldNaming); err != nil {
+		return nil, err
+	}
+	pub, err := pc.newPublisher(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if pc.Envelope {
+		pub = publisher.WithEnvelope(pub)
+	}
+	format := pc.Format
+	if format == serialized {
+		format = ""
+	}
+	pub, err = publisher.WithSerializer(pub, format)
+	if err != nil {
+		return nil, err
+	}
+	pub, err = publisher.WithFieldNaming(pub, pc.FieldNaming)
+	if err != nil {
+		return nil, err
+	}
+	return publisher.WithRetry(pub, publisher.RetryPolicy{
+		MaxRetries: pc.MaxRetries,
+		Backoff:    pc.Backoff,
+		OnFailure:  pc.OnFailure,
+	})
+}
+
+// Returns an error when the publisher serializes packages itself, e.g. as Avro, and options
+// rendering the message are set, as the rendered message would be discarded.
+func (pc PublisherConfig) checkSerialization(serialization string) error {
+	if serialization == "" || serialization == kafkapubsub.SerializationJSON {
+		return nil
+	}
+	switch {
+	case pc.Format != "" && pc.Format != publisher.FormatJSON:
+		return fmt.Errorf("%w : format %v", errSerializedBody, pc.Format)
+	case pc.FieldNaming != "" && pc.FieldNaming != publisher.FieldNamingSnakeCase:
+		return fmt.Errorf("%w : field_naming %v", errSerializedBody, pc.FieldNaming)
+	case pc.Envelope:
+		return fmt.Errorf("%w : envelope", errSerializedBody)
+	}
+	return nil
+}
+
+func (pc PublisherConfig) newPublisher(ctx context.Context) (publisher.Publisher, error) {
 	var err error
 	switch pc.Type {
+	case blob.PublisherType:
+		var blobConfig blob.Config
+		err = strictDecode(pc.Config, &blobConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode blob config: %w", err)
+		}
+		return blob.FromConfig(ctx, blobConfig)
+	case cyclonedx.PublisherType:
+		var cdxConfig cyclonedx.Config
+		err = strictDecode(pc.Config, &cdxConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode cyclonedx config: %w", err)
+		}
+		return cyclonedx.FromConfig(ctx, cdxConfig)
+	case elasticsearch.PublisherType:
+		var esConfig elasticsearch.Config
+		err = strictDecode(pc.Config, &esConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode elasticsearch config: %w", err)
+		}
+		return elasticsearch.FromConfig(ctx, esConfig)
 	case gcppubsub.PublisherType:
 		var gcpConfig gcppubsub.Config
 		err = strictDecode(pc.Config, &gcpConfig)
@@ -141,43 +364,74 @@ func (pc PublisherConfig) ToPublisher(ctx context.Context) (publisher.Publisher,
 			return nil, fmt.Errorf("failed to decode gcppubsub config: %w", err)
 		}
 		return gcppubsub.FromConfig(ctx, gcpConfig)
+	case httpendpoint.PublisherType:
+		var httpConfig httpendpoint.Config
+		err = strictDecode(pc.Config, &httpConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode httpendpoint config: %w", err)
+		}
+		return httpendpoint.FromConfig(ctx, httpConfig)
 	case kafkapubsub.PublisherType:
 		var kafkaConfig kafkapubsub.Config
 		err = strictDecode(pc.Config, &kafkaConfig)
 		if err != nil {
 			return nil, fmt.Errorf("failed to decode kafkapubsub config: %w", err)
 		}
+		if err := pc.checkSerialization(kafkaConfig.Serialization); err != nil {
+			return nil, err
+		}
 		return kafkapubsub.FromConfig(ctx, kafkaConfig)
 	case stdout.PublisherType:
 		return stdout.New(), nil
+	case syslog.PublisherType:
+		var syslogConfig syslog.Config
+		err = strictDecode(pc.Config, &syslogConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode syslog config: %w", err)
+		}
+		return syslog.FromConfig(ctx, syslogConfig)
 	default:
 		return nil, fmt.Errorf("%w : %v", errUnknownPub, pc.Type)
 	}
 }
 
-// Constructs the appropriate feed for the given type, providing the
-// options to the feed.
-func (fc FeedConfig) ToFeed(eventHandler *events.Handler) (feeds.ScheduledFeed, error) {
-	switch fc.Type {
-	case crates.FeedName:
-		return crates.New(fc.Options, eventHandler)
-	case goproxy.FeedName:
-		return goproxy.New(fc.Options)
-	case npm.FeedName:
-		return npm.New(fc.Options, eventHandler)
-	case nuget.FeedName:
-		return nuget.New(fc.Options)
-	case pypi.FeedName:
-		return pypi.New(fc.Options, eventHandler)
-	case packagist.FeedName:
-		return packagist.New(fc.Options)
-	case rubygems.FeedName:
-		return rubygems.New(fc.Options, eventHandler)
+// GetEnricher returns the configured enrichers composed into a single enricher, or nil if
+// no enrichers are configured.
+func (sc *ScheduledFeedConfig) GetEnricher() (feeds.Enricher, error) {
+	if len(sc.Enrichers) == 0 {
+		return nil, nil
+	}
+	enrichers := feeds.Enrichers{}
+	for _, ec := range sc.Enrichers {
+		enricher, err := ec.ToEnricher()
+		if err != nil {
+			return nil, err
+		}
+		enrichers = append(enrichers, enricher)
+	}
+	return enrichers, nil
+}
+
+// Constructs the enricher of the given type.
+func (ec EnricherConfig) ToEnricher() (feeds.Enricher, error) {
+	switch ec.Type {
+	case ossindex.EnricherType:
+		var ossIndexConfig ossindex.Config
+		if err := strictDecode(ec.Config, &ossIndexConfig); err != nil {
+			return nil, fmt.Errorf("failed to decode ossindex config: %w", err)
+		}
+		return ossindex.New(ossIndexConfig)
 	default:
-		return nil, fmt.Errorf("%w : %v", errUnknownFeed, fc.Type)
+		return nil, fmt.Errorf("%w : %v", errUnknownEnricher, ec.Type)
 	}
 }
 
+// Constructs the appropriate feed for the given type, providing the
+// options to the feed. Feeds are looked up by type in the feeds registry.
+func (fc FeedConfig) ToFeed(eventHandler *events.Handler) (feeds.ScheduledFeed, error) {
+	return feeds.NewFeed(fc.Type, fc.Options, eventHandler)
+}
+
 // Decode an input using mapstruct decoder with strictness enabled, errors will be returned in
 // the case of unused fields.
 func strictDecode(input, out interface{}) error {
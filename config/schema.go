@@ -0,0 +1,331 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xeipuuv/gojsonschema"
+	"gopkg.in/yaml.v3"
+)
+
+// The JSON Schema configuration files are validated against before being decoded. Unknown
+// fields are rejected, fields holding durations use the "duration" format which accepts
+// values understood by time.ParseDuration, e.g. "5m".
+const configSchema = `{
+	"$schema": "http://json-schema.org/draft-07/schema#",
+	"title": "package-feeds configuration",
+	"type": "object",
+	"additionalProperties": false,
+	"properties": {
+		"publisher": {"$ref": "#/definitions/publisher"},
+		"dead_letter_file": {"type": "string"},
+		"publishers": {"type": "array", "items": {"$ref": "#/definitions/publisher"}},
+		"enrichers": {"type": "array", "items": {"$ref": "#/definitions/enricher"}},
+		"feeds": {"type": "array", "items": {"$ref": "#/definitions/feed"}},
+		"http_port": {"type": "integer", "minimum": 0, "maximum": 65535},
+		"poll_rate": {"type": "string", "format": "duration"},
+		"timer": {"type": "boolean"},
+		"jitter": {"type": "number", "minimum": 0, "exclusiveMaximum": 1},
+		"recent_packages": {"type": "integer", "minimum": 0},
+		"max_concurrent_feeds": {"type": "integer", "minimum": 0},
+		"max_lookback": {"type": "string", "format": "duration"},
+		"cycle_summary": {"type": "boolean"},
+		"ordered_publishing": {"type": "boolean"},
+		"poll_on_start": {"type": "boolean"},
+		"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+		"cursor_file": {"type": "string"},
+		"tls": {"$ref": "#/definitions/tls"},
+		"http_debug": {
+			"type": "object",
+			"additionalProperties": false,
+			"properties": {
+				"enabled": {"type": "boolean"},
+				"log_bodies": {"type": "boolean"},
+				"max_body_size": {"type": "integer", "minimum": 0}
+			}
+		},
+		"http_transport": {
+			"type": "object",
+			"additionalProperties": false,
+			"properties": {
+				"max_idle_conns_per_host": {"type": "integer", "minimum": 0},
+				"max_conns_per_host": {"type": "integer", "minimum": 0},
+				"idle_conn_timeout": {"type": "string", "format": "duration"}
+			}
+		},
+		"publish_queue": {
+			"type": "object",
+			"additionalProperties": false,
+			"required": ["capacity"],
+			"properties": {
+				"capacity": {"type": "integer", "minimum": 1},
+				"policy": {"enum": ["block", "drop_oldest"]}
+			}
+		},
+		"circuit_breaker": {
+			"type": "object",
+			"additionalProperties": false,
+			"properties": {
+				"threshold": {"type": "integer", "minimum": 0},
+				"cooldown": {"type": "string", "format": "duration"}
+			}
+		},
+		"events": {
+			"type": "object",
+			"additionalProperties": false,
+			"properties": {
+				"sink": {"type": "string"},
+				"filter": {
+					"type": "object",
+					"additionalProperties": false,
+					"properties": {
+						"enabled_event_types": {"type": "array", "items": {"type": "string"}},
+						"disabled_event_types": {"type": "array", "items": {"type": "string"}},
+						"enabled_components": {"type": "array", "items": {"type": "string"}}
+					}
+				}
+			}
+		}
+	},
+	"definitions": {
+		"publisher": {
+			"type": "object",
+			"additionalProperties": false,
+			"properties": {
+				"type": {"type": "string"},
+				"config": {"type": ["object", "null"]},
+				"format": {"enum": ["", "json", "osv"]},
+				"field_naming": {"enum": ["", "snake_case", "camelCase"]},
+				"envelope": {"type": "boolean"},
+				"max_retries": {"type": "integer", "minimum": 0},
+				"backoff": {"type": "string", "format": "duration"},
+				"on_failure": {"enum": ["", "drop", "deadletter", "block"]}
+			}
+		},
+		"enricher": {
+			"type": "object",
+			"additionalProperties": false,
+			"required": ["type"],
+			"properties": {
+				"type": {"type": "string"},
+				"config": {"type": ["object", "null"]}
+			}
+		},
+		"feed": {
+			"type": "object",
+			"additionalProperties": false,
+			"required": ["type"],
+			"properties": {
+				"type": {"type": "string"},
+				"enabled": {"type": "boolean"},
+				"options": {"$ref": "#/definitions/feedOptions"}
+			}
+		},
+		"feedOptions": {
+			"type": ["object", "null"],
+			"additionalProperties": false,
+			"properties": {
+				"packages": {"type": "array", "items": {"type": "string"}},
+				"packages_file": {"type": "string"},
+				"packages_url": {"type": "string"},
+				"packages_refresh_interval": {"type": "string", "format": "duration"},
+				"package_poll_intervals": {
+					"type": "object",
+					"additionalProperties": {"type": "string", "format": "duration"}
+				},
+				"poll_rate": {"type": "string", "format": "duration"},
+				"min_age": {"type": "string", "format": "duration"},
+				"backfill": {"type": "string", "format": "duration"},
+				"max_packages_per_poll": {"type": "integer", "minimum": 0},
+				"poll_timeout": {"type": "string", "format": "duration"},
+				"heartbeat": {"type": "boolean"},
+				"channel": {"type": "string"},
+				"subdir": {"type": "string"},
+				"release": {"type": "string"},
+				"repository": {"type": "string"},
+				"include": {"type": "string"},
+				"path": {"type": "string"},
+				"registry_url": {"type": "string"},
+				"base_urls": {"type": "array", "items": {"type": "string"}},
+				"registries": {
+					"type": "array",
+					"items": {
+						"type": "object",
+						"properties": {
+							"url": {"type": "string"},
+							"token": {"type": "string"}
+						},
+						"required": ["url"],
+						"additionalProperties": false
+					}
+				},
+				"registry_token": {"type": "string"},
+				"request_headers": {"type": "object", "additionalProperties": {"type": "string"}},
+				"request_params": {"type": "object", "additionalProperties": {"type": "string"}},
+				"repositories": {"type": "object", "additionalProperties": {"type": "string"}},
+				"owner": {"type": "string"},
+				"package_type": {"type": "string"},
+				"project": {"type": "string"},
+				"tls": {"$ref": "#/definitions/tls"},
+				"max_response_size": {"type": "integer", "minimum": 0},
+				"republish_threshold": {"type": "string", "format": "duration"},
+				"latest_version_only": {"type": "boolean"},
+				"unpublish_events": {"type": "boolean"},
+				"version_jump_threshold": {"type": "integer", "minimum": 0},
+				"enrich_downloads": {"type": "boolean"},
+				"include_raw": {"type": "boolean"},
+				"install_scripts": {"type": "boolean"},
+				"deprecations": {"type": "boolean"},
+				"tarball_stats": {"type": "boolean"},
+				"licenses": {"type": "boolean"},
+				"denylist": {"type": "array", "items": {"type": "string"}},
+				"mode": {"type": "string"}
+			}
+		},
+		"tls": {
+			"type": "object",
+			"additionalProperties": false,
+			"properties": {
+				"ca_file": {"type": "string"},
+				"cert_file": {"type": "string"},
+				"key_file": {"type": "string"},
+				"insecure_skip_verify": {"type": "boolean"}
+			}
+		}
+	}
+}`
+
+var (
+	errInvalidConfig = errors.New("invalid configuration")
+
+	loadSchemaOnce   sync.Once
+	loadedSchema     *gojsonschema.Schema
+	errLoadingSchema error
+)
+
+// Checks values of the "duration" format can be parsed by time.ParseDuration, values of
+// other types are rejected by the type of the field instead.
+type durationFormatChecker struct{}
+
+func (durationFormatChecker) IsFormat(input interface{}) bool {
+	s, ok := input.(string)
+	if !ok {
+		return true
+	}
+	_, err := time.ParseDuration(s)
+	return err == nil
+}
+
+func loadSchema() (*gojsonschema.Schema, error) {
+	loadSchemaOnce.Do(func() {
+		gojsonschema.FormatCheckers.Add("duration", durationFormatChecker{})
+		loadedSchema, errLoadingSchema = gojsonschema.NewSchema(gojsonschema.NewStringLoader(configSchema))
+	})
+	return loadedSchema, errLoadingSchema
+}
+
+// Validates yaml configuration data against the configuration schema, returning an error
+// describing each invalid field, e.g. "feeds[2].options.poll_rate: invalid duration".
+func validateConfig(data []byte) error {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if doc == nil {
+		// Empty configuration, the defaults are used.
+		return nil
+	}
+	schema, err := loadSchema()
+	if err != nil {
+		return err
+	}
+	result, err := schema.Validate(gojsonschema.NewGoLoader(jsonCompatible(doc)))
+	if err != nil {
+		return err
+	}
+	if result.Valid() {
+		return nil
+	}
+	msgs := []string{}
+	for _, resultErr := range result.Errors() {
+		msgs = append(msgs, describeSchemaError(resultErr))
+	}
+	sort.Strings(msgs)
+	return fmt.Errorf("%w : %v", errInvalidConfig, strings.Join(msgs, "; "))
+}
+
+// Converts a value decoded from yaml into one which can be encoded as json, maps with keys
+// which are not strings are converted to maps keyed by the string form of their keys.
+func jsonCompatible(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = jsonCompatible(value)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for key, value := range v {
+			m[fmt.Sprint(key)] = jsonCompatible(value)
+		}
+		return m
+	case []interface{}:
+		for i, value := range v {
+			v[i] = jsonCompatible(value)
+		}
+		return v
+	}
+	return v
+}
+
+// Describes a schema validation error as the path of the field followed by the problem,
+// e.g. "feeds[0].options.packges: unknown field".
+func describeSchemaError(err gojsonschema.ResultError) string {
+	path := fieldPath(err.Field())
+	details := err.Details()
+	switch err.Type() {
+	case "additional_property_not_allowed":
+		return fmt.Sprintf("%s: unknown field", joinFieldPath(path, fmt.Sprint(details["property"])))
+	case "required":
+		return fmt.Sprintf("%s: required", joinFieldPath(path, fmt.Sprint(details["property"])))
+	case "format":
+		return fmt.Sprintf("%s: invalid %v", path, details["format"])
+	case "enum":
+		return fmt.Sprintf("%s: must be one of %v", path, details["allowed"])
+	case "invalid_type":
+		return fmt.Sprintf("%s: expected %v but got %v", path, details["expected"], details["given"])
+	}
+	return fmt.Sprintf("%s: %s", path, err.Description())
+}
+
+// Converts a field as reported by gojsonschema, e.g. "feeds.2.options", into the form
+// "feeds[2].options". The root of the document is reported as "(root)".
+func fieldPath(field string) string {
+	if field == gojsonschema.STRING_CONTEXT_ROOT {
+		return field
+	}
+	var b strings.Builder
+	for _, part := range strings.Split(field, ".") {
+		if _, err := strconv.Atoi(part); err == nil {
+			b.WriteString("[" + part + "]")
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(".")
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
+
+func joinFieldPath(path, field string) string {
+	if path == gojsonschema.STRING_CONTEXT_ROOT {
+		return field
+	}
+	return path + "." + field
+}
@@ -0,0 +1,149 @@
+package config_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ossf/package-feeds/config"
+)
+
+func TestConfigSchemaValid(t *testing.T) {
+	t.Parallel()
+
+	c, err := config.NewConfigFromBytes([]byte(`
+feeds:
+- type: npm
+  options:
+    packages:
+    - foo
+    package_poll_intervals:
+      foo: 1h
+    poll_rate: 10m
+    tls:
+      ca_file: /etc/ssl/ca.pem
+- type: pypi
+  options:
+publisher:
+  type: stdout
+  config:
+poll_rate: 5m
+timer: true
+jitter: 0.1
+labels:
+  env: prod
+circuit_breaker:
+  threshold: 3
+  cooldown: 10m
+publish_queue:
+  capacity: 1000
+  policy: drop_oldest
+`))
+	if err != nil {
+		t.Fatalf("NewConfigFromBytes() returned unexpected error: %v", err)
+	}
+	if len(c.Feeds) != 2 {
+		t.Errorf("Feeds is expected to be 2 but was `%v`", len(c.Feeds))
+	}
+}
+
+func TestConfigSchemaInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		config   string
+		expected string
+	}{
+		{
+			name: "invalid feed duration",
+			config: `
+feeds:
+- type: npm
+- type: pypi
+- type: crates
+  options:
+    poll_rate: 5 minutes
+`,
+			expected: "feeds[2].options.poll_rate: invalid duration",
+		},
+		{
+			name: "misindented feed option",
+			config: `
+feeds:
+- type: npm
+  packages:
+  - foo
+`,
+			expected: "feeds[0].packages: unknown field",
+		},
+		{
+			name: "misspelled field",
+			config: `
+pol_rate: 5m
+`,
+			expected: "pol_rate: unknown field",
+		},
+		{
+			name: "missing feed type",
+			config: `
+feeds:
+- options:
+    poll_rate: 5m
+`,
+			expected: "feeds[0].type: required",
+		},
+		{
+			name: "wrong type",
+			config: `
+http_port: eighty
+`,
+			expected: "http_port: expected integer but got string",
+		},
+		{
+			name: "invalid nested duration",
+			config: `
+circuit_breaker:
+  threshold: 3
+  cooldown: 10
+`,
+			expected: "circuit_breaker.cooldown: expected string but got integer",
+		},
+		{
+			name: "invalid publish queue policy",
+			config: `
+publish_queue:
+  capacity: 1000
+  policy: drop_newest
+`,
+			expected: `publish_queue.policy: must be one of "block", "drop_oldest"`,
+		},
+		{
+			name: "invalid field naming",
+			config: `
+publisher:
+  type: stdout
+  field_naming: kebab-case
+`,
+			expected: `publisher.field_naming: must be one of "", "snake_case", "camelCase"`,
+		},
+		{
+			name: "unknown on_failure",
+			config: `
+publisher:
+  type: stdout
+  on_failure: retry
+`,
+			expected: `publisher.on_failure: must be one of "", "drop", "deadletter", "block"`,
+		},
+	}
+	for _, test := range tests {
+		_, err := config.NewConfigFromBytes([]byte(test.config))
+		if err == nil {
+			t.Errorf("%s: config successfully parsed when an error was expected", test.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%s: NewConfigFromBytes() returned `%v` which does not include `%v`", test.name, err, test.expected)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ossf/package-feeds/utils"
+)
+
+const (
+	envSecretScheme   = "env"
+	vaultSecretScheme = "vault"
+)
+
+var (
+	errSecretNotFound   = errors.New("secret not found")
+	errInvalidSecretRef = errors.New("invalid secret reference")
+	errVaultNotSet      = errors.New("VAULT_ADDR and VAULT_TOKEN must be set to resolve vault secrets")
+)
+
+// SecretProvider resolves references to secrets held outside of the configuration. References
+// take the form scheme://ref, the provider is given the ref following the scheme.
+type SecretProvider interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// DefaultSecretProviders returns the providers used when loading configuration, these resolve
+// env://NAME from environment variables and vault://path#key from HashiCorp Vault.
+func DefaultSecretProviders() map[string]SecretProvider {
+	return map[string]SecretProvider{
+		envSecretScheme: EnvSecretProvider{},
+		vaultSecretScheme: &VaultSecretProvider{
+			Address: os.Getenv("VAULT_ADDR"),
+			Token:   os.Getenv("VAULT_TOKEN"),
+		},
+	}
+}
+
+// EnvSecretProvider resolves secrets from environment variables.
+type EnvSecretProvider struct{}
+
+func (EnvSecretProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("%w : environment variable %v is not set", errSecretNotFound, ref)
+	}
+	return value, nil
+}
+
+// VaultSecretProvider resolves secrets from a HashiCorp Vault KV secrets engine, references
+// are of the form path#key e.g. secret/data/kafka#password. Both versions of the KV secrets
+// engine are supported.
+type VaultSecretProvider struct {
+	Address string
+	Token   string
+}
+
+var vaultHTTPClient = utils.NewHTTPClient(10 * time.Second)
+
+func (p *VaultSecretProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	if p.Address == "" || p.Token == "" {
+		return "", errVaultNotSet
+	}
+	parts := strings.SplitN(ref, "#", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("%w : vault secrets must be referenced as vault://path#key", errInvalidSecretRef)
+	}
+	path, key := parts[0], parts[1]
+
+	secretURL, err := utils.URLPathJoin(p.Address, "/v1/"+path)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, secretURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", p.Token)
+	resp, err := vaultHTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	err = utils.CheckResponseStatus(resp)
+	if err != nil {
+		return "", fmt.Errorf("failed to read vault secret %v: %w", path, err)
+	}
+
+	secret := struct {
+		Data map[string]interface{} `json:"data"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", err
+	}
+	data := secret.Data
+	// The KV version 2 engine nests the secret data alongside its metadata.
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+	value, ok := data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("%w : key %v in vault secret %v", errSecretNotFound, key, path)
+	}
+	return value, nil
+}
+
+// ResolveSecrets replaces references to secrets in publisher and enricher configuration, feed registry
+// tokens and feed request headers and parameters with the values resolved by the provider
+// registered for the reference's scheme.
+// Values with schemes which have no provider, such as publisher URLs, are left unchanged.
+func (sc *ScheduledFeedConfig) ResolveSecrets(ctx context.Context, providers map[string]SecretProvider) error {
+	var err error
+	sc.PubConfig.Config, err = resolveSecretValues(ctx, providers, sc.PubConfig.Config)
+	if err != nil {
+		return err
+	}
+	for i := range sc.Publishers {
+		sc.Publishers[i].Config, err = resolveSecretValues(ctx, providers, sc.Publishers[i].Config)
+		if err != nil {
+			return err
+		}
+	}
+	for i := range sc.Enrichers {
+		sc.Enrichers[i].Config, err = resolveSecretValues(ctx, providers, sc.Enrichers[i].Config)
+		if err != nil {
+			return err
+		}
+	}
+	for i := range sc.Feeds {
+		options := &sc.Feeds[i].Options
+		options.RegistryToken, err = resolveSecret(ctx, providers, options.RegistryToken)
+		if err != nil {
+			return err
+		}
+		for j := range options.Registries {
+			options.Registries[j].Token, err = resolveSecret(ctx, providers, options.Registries[j].Token)
+			if err != nil {
+				return err
+			}
+		}
+		for _, values := range []map[string]string{options.RequestHeaders, options.RequestParams} {
+			for name, value := range values {
+				values[name], err = resolveSecret(ctx, providers, value)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func resolveSecretValues(ctx context.Context, providers map[string]SecretProvider,
+	v interface{}) (interface{}, error) {
+	var err error
+	switch v := v.(type) {
+	case string:
+		return resolveSecret(ctx, providers, v)
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key], err = resolveSecretValues(ctx, providers, value)
+			if err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i], err = resolveSecretValues(ctx, providers, value)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
+
+func resolveSecret(ctx context.Context, providers map[string]SecretProvider, value string) (string, error) {
+	parts := strings.SplitN(value, "://", 2)
+	if len(parts) != 2 {
+		return value, nil
+	}
+	provider, ok := providers[parts[0]]
+	if !ok {
+		return value, nil
+	}
+	secret, err := provider.Resolve(ctx, parts[1])
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve secret %v: %w", value, err)
+	}
+	return secret, nil
+}
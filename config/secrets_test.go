@@ -0,0 +1,229 @@
+package config_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/config"
+	testutils "github.com/ossf/package-feeds/utils/test"
+)
+
+func TestResolveSecretsEnv(t *testing.T) {
+	t.Parallel()
+
+	const envVar = "PACKAGE_FEEDS_TEST_ES_PASSWORD"
+	if err := os.Setenv(envVar, "hunter2"); err != nil {
+		t.Fatalf("failed to set environment variable: %v", err)
+	}
+	defer func() {
+		if err := os.Unsetenv(envVar); err != nil {
+			t.Errorf("failed to unset environment variable: %v", err)
+		}
+	}()
+
+	c, err := config.NewConfigFromBytes([]byte(`
+feeds:
+- type: npm
+  options:
+    registry_token: env://` + envVar + `
+- type: npm
+  options:
+    registries:
+    - url: https://npm.example.com/
+      token: env://` + envVar + `
+publisher:
+  type: elasticsearch
+  config:
+    url: http://127.0.0.1:9200
+    index: events
+    password: env://` + envVar + `
+`))
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	pubConfig, ok := c.PubConfig.Config.(map[string]interface{})
+	if !ok {
+		t.Fatalf("publisher config was parsed as %T instead of a map", c.PubConfig.Config)
+	}
+	if pubConfig["password"] != "hunter2" {
+		t.Errorf("publisher password was resolved as %v instead of the environment variable", pubConfig["password"])
+	}
+	if pubConfig["url"] != "http://127.0.0.1:9200" {
+		t.Errorf("publisher url %v was modified when resolving secrets", pubConfig["url"])
+	}
+	if c.Feeds[0].Options.RegistryToken != "hunter2" {
+		t.Errorf("registry token was resolved as %v instead of the environment variable", c.Feeds[0].Options.RegistryToken)
+	}
+	if token := c.Feeds[1].Options.Registries[0].Token; token != "hunter2" {
+		t.Errorf("registries token was resolved as %v instead of the environment variable", token)
+	}
+}
+
+func TestResolveSecretsEnvMissing(t *testing.T) {
+	t.Parallel()
+
+	_, err := config.NewConfigFromBytes([]byte(`
+publisher:
+  type: elasticsearch
+  config:
+    password: env://PACKAGE_FEEDS_TEST_UNSET
+`))
+	if err == nil {
+		t.Fatalf("expected an error resolving an unset environment variable")
+	}
+}
+
+func TestResolveSecretsVault(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/v1/secret/data/kafka": func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Vault-Token") != "root" {
+				http.Error(w, "permission denied", http.StatusForbidden)
+				return
+			}
+			_, err := w.Write([]byte(`{"data": {"data": {"password": "hunter2"}, "metadata": {"version": 1}}}`))
+			if err != nil {
+				http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+			}
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	c := &config.ScheduledFeedConfig{
+		PubConfig: config.PublisherConfig{
+			Type: "elasticsearch",
+			Config: map[string]interface{}{
+				"password": "vault://secret/data/kafka#password",
+			},
+		},
+	}
+	providers := map[string]config.SecretProvider{
+		"vault": &config.VaultSecretProvider{Address: srv.URL, Token: "root"},
+	}
+	if err := c.ResolveSecrets(context.Background(), providers); err != nil {
+		t.Fatalf("failed to resolve secrets: %v", err)
+	}
+	pubConfig := c.PubConfig.Config.(map[string]interface{})
+	if pubConfig["password"] != "hunter2" {
+		t.Errorf("publisher password was resolved as %v instead of the vault secret", pubConfig["password"])
+	}
+
+	c.PubConfig.Config = map[string]interface{}{"password": "vault://secret/data/kafka#username"}
+	if err := c.ResolveSecrets(context.Background(), providers); err == nil {
+		t.Errorf("expected an error resolving a missing vault key")
+	}
+}
+
+func TestResolveSecretsHTTPEndpointToken(t *testing.T) {
+	t.Parallel()
+
+	const envVar = "PACKAGE_FEEDS_TEST_HTTP_TOKEN"
+	if err := os.Setenv(envVar, "s3cret"); err != nil {
+		t.Fatalf("failed to set environment variable: %v", err)
+	}
+	defer func() {
+		if err := os.Unsetenv(envVar); err != nil {
+			t.Errorf("failed to unset environment variable: %v", err)
+		}
+	}()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/events": func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer s3cret" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+			}
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	c, err := config.NewConfigFromBytes([]byte(`
+publisher:
+  type: httpendpoint
+  config:
+    url: ` + srv.URL + `/events
+    bearer_token: env://` + envVar + `
+`))
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	pub, err := c.GetPublisher(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get publisher: %v", err)
+	}
+	if err := pub.Send(context.Background(), []byte(`{}`)); err != nil {
+		t.Errorf("failed to send with the resolved bearer token: %v", err)
+	}
+}
+
+func TestResolveSecretsFeedRequestOptions(t *testing.T) {
+	t.Parallel()
+
+	const envVar = "PACKAGE_FEEDS_TEST_REGISTRY_API_KEY"
+	if err := os.Setenv(envVar, "s3cret"); err != nil {
+		t.Fatalf("failed to set environment variable: %v", err)
+	}
+	defer func() {
+		if err := os.Unsetenv(envVar); err != nil {
+			t.Errorf("failed to unset environment variable: %v", err)
+		}
+	}()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/foo": func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Api-Key") != "s3cret" || r.URL.Query().Get("client") != "package-feeds" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			_, err := w.Write([]byte(`{
+	"name": "foo",
+	"versions": {"1.0.0": {"name": "foo", "version": "1.0.0"}},
+	"time": {
+		"created": "2021-05-11T18:32:01.000Z",
+		"1.0.0": "2021-05-11T18:32:01.000Z",
+		"modified": "2021-05-11T18:32:01.000Z"
+	}
+}`))
+			if err != nil {
+				http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+			}
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	c, err := config.NewConfigFromBytes([]byte(`
+feeds:
+- type: npm
+  options:
+    registry_url: ` + srv.URL + `
+    packages:
+    - foo
+    request_headers:
+      X-Api-Key: env://` + envVar + `
+    request_params:
+      client: package-feeds
+`))
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	scheduledFeeds, err := c.GetScheduledFeeds()
+	if err != nil {
+		t.Fatalf("failed to get feeds: %v", err)
+	}
+	feed, ok := scheduledFeeds["npm"]
+	if !ok {
+		t.Fatalf("npm feed was not configured")
+	}
+	pkgs, errs := feed.Latest(context.Background(), time.Time{})
+	if len(errs) != 0 {
+		t.Fatalf("requests were made without the configured headers and parameters: %v", errs)
+	}
+	if len(pkgs) != 1 || pkgs[0].Name != "foo" {
+		t.Errorf("Latest() returned %v packages when only foo was expected", len(pkgs))
+	}
+}
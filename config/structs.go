@@ -1,14 +1,29 @@
 package config
 
 import (
+	"time"
+
 	"github.com/ossf/package-feeds/events"
 	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/utils"
 )
 
 type ScheduledFeedConfig struct {
 	// Configures the publisher for pushing packages after polling.
 	PubConfig PublisherConfig `yaml:"publisher"`
 
+	// The path of a file which messages the publisher failed to send are written to, these
+	// can be replayed with the replay command.
+	DeadLetterFile string `yaml:"dead_letter_file"`
+
+	// Configures several publishers which each receive all packages, this takes
+	// precedence over PubConfig when provided.
+	Publishers []PublisherConfig `yaml:"publishers"`
+
+	// Configures enrichers which add information to each package before it is published,
+	// such as its vulnerabilities.
+	Enrichers []EnricherConfig `yaml:"enrichers"`
+
 	// Configures the feeds to be used for polling from package repositories.
 	Feeds []FeedConfig `yaml:"feeds"`
 
@@ -16,20 +31,116 @@ type ScheduledFeedConfig struct {
 	PollRate string `yaml:"poll_rate"`
 	Timer    bool   `yaml:"timer"`
 
+	// The maximum random delay applied to scheduled polls, as a fraction of the poll interval.
+	Jitter float64 `yaml:"jitter"`
+
+	// The number of recently published packages kept per feed and served by `GET /recent`.
+	RecentPackages int `yaml:"recent_packages"`
+
+	// The maximum number of feeds polled at once, zero allows every feed to be polled at once.
+	MaxConcurrentFeeds int `yaml:"max_concurrent_feeds"`
+
+	// Static labels added to every published package, identifying the deployment.
+	Labels map[string]string `yaml:"labels"`
+
+	// The furthest the cutoff of a poll may be before the start of the poll, zero if unlimited.
+	MaxLookback time.Duration `yaml:"max_lookback"`
+
+	// Publishes a summary of each poll cycle through the publisher.
+	CycleSummary bool `yaml:"cycle_summary"`
+
+	// Publishes the packages of the feeds polled together in each cycle in order of their
+	// creation, rather than feed by feed.
+	OrderedPublishing bool `yaml:"ordered_publishing"`
+
+	// Whether feeds with a timer are polled once at startup rather than waiting for the
+	// first tick of the timer. Defaults to true.
+	PollOnStart *bool `yaml:"poll_on_start"`
+
+	// The path of a file used to persist cursors for feeds which poll incrementally.
+	CursorFile string `yaml:"cursor_file"`
+
+	// Configures TLS connections to registries for feeds which do not configure their own.
+	TLS *utils.TLSConfig `yaml:"tls"`
+
+	// Tunes the pool of connections to registries shared by feeds.
+	HTTPTransport *utils.TransportConfig `yaml:"http_transport"`
+
+	// Logs the requests made to registries at debug level.
+	HTTPDebug *utils.HTTPDebugConfig `yaml:"http_debug"`
+
+	// Configures pausing the polling of feeds which repeatedly fail.
+	CircuitBreaker *CircuitBreakerConfig `yaml:"circuit_breaker"`
+
+	// Configures a bounded queue between polling and the publisher.
+	PublishQueue *PublishQueueConfig `yaml:"publish_queue"`
+
 	// Configures the EventHandler instance to be used throughout the package-feeds application.
 	EventsConfig *EventsConfig `yaml:"events"`
 
+	// Provides the current time to feeds and the scheduler, allowing polls to be driven
+	// deterministically in tests. This is not configurable and defaults to the system time.
+	Clock feeds.Clock `yaml:"-"`
+
 	eventHandler *events.Handler
 }
 
 type PublisherConfig struct {
 	Type   string      `mapstructure:"type"`
 	Config interface{} `mapstructure:"config"`
+
+	// The format packages are published in, either json (default) or osv.
+	Format string `mapstructure:"format" yaml:"format"`
+
+	// The naming of fields in published packages, either snake_case (default) or camelCase.
+	// Only applies to the json format.
+	FieldNaming string `mapstructure:"field_naming" yaml:"field_naming"`
+
+	// Wraps each package in an envelope carrying the poll cycle, poll time, feed and first
+	// seen time of the package.
+	Envelope bool `mapstructure:"envelope" yaml:"envelope"`
+
+	// The number of times a failed send is retried, and the delay before the first retry
+	// which is doubled for each subsequent retry.
+	MaxRetries int           `mapstructure:"max_retries" yaml:"max_retries"`
+	Backoff    time.Duration `mapstructure:"backoff" yaml:"backoff"`
+	// The handling of messages which still fail to send after retrying, either drop,
+	// deadletter or block. The error is returned when unset.
+	OnFailure string `mapstructure:"on_failure" yaml:"on_failure"`
+}
+
+// EnricherConfig configures an enricher run on each package before it is published.
+type EnricherConfig struct {
+	Type   string      `mapstructure:"type"`
+	Config interface{} `mapstructure:"config"`
 }
 
 type FeedConfig struct {
 	Type    string            `mapstructure:"type"`
 	Options feeds.FeedOptions `mapstructure:"options"`
+
+	// Whether the feed is polled, a disabled feed keeps its configuration but is skipped
+	// entirely. Defaults to true.
+	Enabled *bool `mapstructure:"enabled" yaml:"enabled"`
+}
+
+// IsEnabled returns whether the feed is polled, feeds are enabled unless explicitly disabled.
+func (fc FeedConfig) IsEnabled() bool {
+	return fc.Enabled == nil || *fc.Enabled
+}
+
+type CircuitBreakerConfig struct {
+	// The number of consecutive failed polls of a feed before polling is paused.
+	Threshold int `yaml:"threshold"`
+	// The duration polling of a feed is paused for.
+	Cooldown time.Duration `yaml:"cooldown"`
+}
+
+type PublishQueueConfig struct {
+	// The maximum number of packages waiting to be published.
+	Capacity int `yaml:"capacity"`
+	// The policy applied when the queue is full, either block (default) or drop_oldest.
+	Policy string `yaml:"policy"`
 }
 
 type EventsConfig struct {
@@ -0,0 +1,139 @@
+// Package ossindex enriches packages with the advisories of Sonatype OSS Index.
+// https://ossindex.sonatype.org/rest
+package ossindex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/utils"
+)
+
+const (
+	EnricherType = "ossindex"
+
+	defaultURL          = "https://ossindex.sonatype.org/"
+	componentReportPath = "/api/v3/component-report"
+	requestTimeout      = 10 * time.Second
+	// Component reports hold a few advisories, a larger response indicates a misbehaving server.
+	maxResponseSize = 4 << 20
+)
+
+var (
+	errURL  = errors.New("invalid oss index url")
+	errJSON = errors.New("error unmarshaling oss index component report")
+	errAuth = errors.New("oss index username and token must be provided together")
+)
+
+type Config struct {
+	// The base URL of OSS Index, defaults to https://ossindex.sonatype.org/.
+	URL string `mapstructure:"url"`
+	// Credentials of an OSS Index account, raising the rate limit of requests. The token may
+	// reference a secret.
+	Username string `mapstructure:"username"`
+	Token    string `mapstructure:"token"`
+}
+
+// Enricher attaches the vulnerabilities reported by OSS Index for the package-url of each
+// package. Packages of ecosystems unknown to OSS Index have no vulnerabilities attached.
+type Enricher struct {
+	reportURL string
+	username  string
+	token     string
+	client    *http.Client
+}
+
+func New(config Config) (*Enricher, error) {
+	baseURL := defaultURL
+	if config.URL != "" {
+		baseURL = config.URL
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("%w : %v", errURL, baseURL)
+	}
+	if (config.Username == "") != (config.Token == "") {
+		return nil, errAuth
+	}
+	reportURL, err := utils.URLPathJoin(baseURL, componentReportPath)
+	if err != nil {
+		return nil, err
+	}
+	return &Enricher{
+		reportURL: reportURL,
+		username:  config.Username,
+		token:     config.Token,
+		client:    utils.NewHTTPClient(requestTimeout),
+	}, nil
+}
+
+type componentReportRequest struct {
+	Coordinates []string `json:"coordinates"`
+}
+
+type componentReport struct {
+	Coordinates     string          `json:"coordinates"`
+	Vulnerabilities []vulnerability `json:"vulnerabilities"`
+}
+
+type vulnerability struct {
+	ID        string  `json:"id"`
+	Title     string  `json:"title"`
+	CVE       string  `json:"cve"`
+	CVSSScore float64 `json:"cvssScore"`
+	Reference string  `json:"reference"`
+}
+
+// Enrich sets the vulnerabilities of the package to those reported by OSS Index, packages
+// without any reported vulnerabilities are left unchanged.
+func (e *Enricher) Enrich(ctx context.Context, pkg *feeds.Package) error {
+	body, err := json.Marshal(componentReportRequest{Coordinates: []string{pkg.PURL()}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.reportURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.ossindex.component-report-request.v1+json")
+	req.Header.Set("Accept", "application/vnd.ossindex.component-report.v1+json")
+	if e.username != "" {
+		req.SetBasicAuth(e.username, e.token)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := utils.CheckResponseStatus(resp); err != nil {
+		return fmt.Errorf("failed to fetch oss index component report: %w", err)
+	}
+	data, err := utils.LimitedReadAll(resp.Body, maxResponseSize)
+	if err != nil {
+		return err
+	}
+	reports := []componentReport{}
+	if err := json.Unmarshal(data, &reports); err != nil {
+		return fmt.Errorf("%w : %v", errJSON, err)
+	}
+	for _, report := range reports {
+		for _, vuln := range report.Vulnerabilities {
+			pkg.Vulnerabilities = append(pkg.Vulnerabilities, feeds.Vulnerability{
+				ID:        vuln.ID,
+				Title:     vuln.Title,
+				CVE:       vuln.CVE,
+				CVSSScore: vuln.CVSSScore,
+				Reference: vuln.Reference,
+			})
+		}
+	}
+	return nil
+}
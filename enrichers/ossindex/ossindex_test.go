@@ -0,0 +1,112 @@
+package ossindex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/utils"
+	testutils "github.com/ossf/package-feeds/utils/test"
+)
+
+func TestEnrich(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		componentReportPath: func(w http.ResponseWriter, r *http.Request) {
+			username, token, ok := r.BasicAuth()
+			if r.Method != http.MethodPost || !ok || username != "user" || token != "s3cr3t" {
+				http.Error(w, "unexpected request", http.StatusBadRequest)
+				return
+			}
+			request := componentReportRequest{}
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			reports := []componentReport{}
+			for _, coordinates := range request.Coordinates {
+				report := componentReport{Coordinates: coordinates}
+				if coordinates == "pkg:npm/lodash@4.17.20" {
+					report.Vulnerabilities = []vulnerability{{
+						ID:        "CVE-2021-23337",
+						Title:     "[CVE-2021-23337] Command Injection",
+						CVE:       "CVE-2021-23337",
+						CVSSScore: 7.2,
+						Reference: "https://ossindex.sonatype.org/vulnerability/CVE-2021-23337",
+					}}
+				}
+				reports = append(reports, report)
+			}
+			if err := json.NewEncoder(w).Encode(reports); err != nil {
+				http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+			}
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	enricher, err := New(Config{URL: srv.URL, Username: "user", Token: "s3cr3t"})
+	if err != nil {
+		t.Fatalf("Failed to create oss index enricher: %v", err)
+	}
+	vulnerable := feeds.NewPackage(time.Now(), "lodash", "4.17.20", "npm", feeds.EcosystemNPM)
+	if err := enricher.Enrich(context.Background(), vulnerable); err != nil {
+		t.Fatalf("Enrich() returned unexpected error: %v", err)
+	}
+	expected := []feeds.Vulnerability{{
+		ID:        "CVE-2021-23337",
+		Title:     "[CVE-2021-23337] Command Injection",
+		CVE:       "CVE-2021-23337",
+		CVSSScore: 7.2,
+		Reference: "https://ossindex.sonatype.org/vulnerability/CVE-2021-23337",
+	}}
+	if !reflect.DeepEqual(vulnerable.Vulnerabilities, expected) {
+		t.Errorf("Enrich() attached vulnerabilities %+v when %+v was expected", vulnerable.Vulnerabilities, expected)
+	}
+
+	fixed := feeds.NewPackage(time.Now(), "lodash", "4.17.21", "npm", feeds.EcosystemNPM)
+	if err := enricher.Enrich(context.Background(), fixed); err != nil {
+		t.Fatalf("Enrich() returned unexpected error: %v", err)
+	}
+	if len(fixed.Vulnerabilities) != 0 {
+		t.Errorf("Enrich() attached vulnerabilities %+v to a package without any", fixed.Vulnerabilities)
+	}
+}
+
+func TestEnrichUnavailable(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		componentReportPath: func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "rate limited", http.StatusTooManyRequests)
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	enricher, err := New(Config{URL: srv.URL})
+	if err != nil {
+		t.Fatalf("Failed to create oss index enricher: %v", err)
+	}
+	pkg := feeds.NewPackage(time.Now(), "lodash", "4.17.20", "npm", feeds.EcosystemNPM)
+	if err := enricher.Enrich(context.Background(), pkg); !errors.Is(err, utils.ErrUnsuccessfulRequest) {
+		t.Errorf("Enrich() returned %v when ErrUnsuccessfulRequest was expected", err)
+	}
+}
+
+func TestNewInvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New(Config{URL: "ossindex"}); !errors.Is(err, errURL) {
+		t.Errorf("New() returned %v for a url without a scheme when errURL was expected", err)
+	}
+	if _, err := New(Config{Username: "user"}); !errors.Is(err, errAuth) {
+		t.Errorf("New() returned %v for a username without a token when errAuth was expected", err)
+	}
+}
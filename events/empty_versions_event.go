@@ -0,0 +1,24 @@
+package events
+
+import (
+	"fmt"
+)
+
+// EmptyVersionsEvent is dispatched when a critical package is found but no versions of it
+// could be resolved.
+type EmptyVersionsEvent struct {
+	Feed    string
+	Package string
+}
+
+func (e EmptyVersionsEvent) GetComponent() string {
+	return FeedsComponentType
+}
+
+func (e EmptyVersionsEvent) GetType() string {
+	return EmptyVersionsEventType
+}
+
+func (e EmptyVersionsEvent) GetMessage() string {
+	return fmt.Sprintf("no versions were found for critical package %v when polling %v feed", e.Package, e.Feed)
+}
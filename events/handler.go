@@ -2,7 +2,13 @@ package events
 
 const (
 	// Event Types.
-	LossyFeedEventType = "LOSSY_FEED"
+	LossyFeedEventType          = "LOSSY_FEED"
+	EmptyVersionsEventType      = "EMPTY_VERSIONS"
+	UnpublishEventType          = "UNPUBLISH"
+	YankEventType               = "YANK"
+	RemovalEventType            = "REMOVAL"
+	VersionJumpEventType        = "VERSION_JUMP"
+	MalformedTimestampEventType = "MALFORMED_TIMESTAMP"
 
 	// Components.
 	FeedsComponentType = "Feeds"
@@ -83,9 +89,9 @@ func (h *Handler) GetFilter() Filter {
 // Checks whether an event should be dispatched under the configured
 // filter options.
 // Options are applied as follows:
-// 	- disabled event types are always disabled.
-//  - enabled event types are enabled
-//  - enabled components are enabled except for disabled event types.
+//   - disabled event types are always disabled.
+//   - enabled event types are enabled
+//   - enabled components are enabled except for disabled event types.
 func (f Filter) ShouldDispatch(e Event) bool {
 	dispatch := false
 	eComponent := e.GetComponent()
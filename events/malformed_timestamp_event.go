@@ -0,0 +1,27 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MalformedTimestampEvent is dispatched when versions of a package are skipped as the
+// registry reported a creation time for them which couldn't be parsed.
+type MalformedTimestampEvent struct {
+	Feed     string
+	Package  string
+	Versions []string
+}
+
+func (e MalformedTimestampEvent) GetComponent() string {
+	return FeedsComponentType
+}
+
+func (e MalformedTimestampEvent) GetType() string {
+	return MalformedTimestampEventType
+}
+
+func (e MalformedTimestampEvent) GetMessage() string {
+	return fmt.Sprintf("versions %v of package %v in %v feed were skipped as their timestamps couldn't be parsed",
+		strings.Join(e.Versions, ", "), e.Package, e.Feed)
+}
@@ -0,0 +1,30 @@
+package events
+
+import (
+	"fmt"
+)
+
+// RemovalEvent is dispatched when a release, or the files of a release, are removed from
+// a registry, or when an entire project is removed in which case Version is empty.
+type RemovalEvent struct {
+	Feed    string
+	Package string
+	Version string
+	// The action recorded by the registry, e.g. "remove release" or "remove project".
+	Action string
+}
+
+func (e RemovalEvent) GetComponent() string {
+	return FeedsComponentType
+}
+
+func (e RemovalEvent) GetType() string {
+	return RemovalEventType
+}
+
+func (e RemovalEvent) GetMessage() string {
+	if e.Version == "" {
+		return fmt.Sprintf("%v was removed from %v feed (%v)", e.Package, e.Feed, e.Action)
+	}
+	return fmt.Sprintf("version %v of %v was removed from %v feed (%v)", e.Version, e.Package, e.Feed, e.Action)
+}
@@ -0,0 +1,27 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UnpublishEvent is dispatched when a critical package is found to have been unpublished,
+// carrying the versions which were removed.
+type UnpublishEvent struct {
+	Feed     string
+	Package  string
+	Versions []string
+}
+
+func (e UnpublishEvent) GetComponent() string {
+	return FeedsComponentType
+}
+
+func (e UnpublishEvent) GetType() string {
+	return UnpublishEventType
+}
+
+func (e UnpublishEvent) GetMessage() string {
+	return fmt.Sprintf("critical package %v was unpublished from %v feed, removing versions [%v]",
+		e.Package, e.Feed, strings.Join(e.Versions, ", "))
+}
@@ -0,0 +1,28 @@
+package events
+
+import (
+	"fmt"
+)
+
+// VersionJumpEvent is dispatched when the latest version of a critical package changes
+// suspiciously, either leaping by several major versions or decreasing, which may indicate
+// that the package has been hijacked.
+type VersionJumpEvent struct {
+	Feed            string
+	Package         string
+	PreviousVersion string
+	Version         string
+}
+
+func (e VersionJumpEvent) GetComponent() string {
+	return FeedsComponentType
+}
+
+func (e VersionJumpEvent) GetType() string {
+	return VersionJumpEventType
+}
+
+func (e VersionJumpEvent) GetMessage() string {
+	return fmt.Sprintf("latest version of critical package %v in %v feed changed suspiciously from %v to %v",
+		e.Package, e.Feed, e.PreviousVersion, e.Version)
+}
@@ -0,0 +1,27 @@
+package events
+
+import (
+	"fmt"
+)
+
+// YankEvent is dispatched when a release is yanked, it remains available but is no longer
+// selected by installers unless pinned.
+type YankEvent struct {
+	Feed    string
+	Package string
+	Version string
+	// The action recorded by the registry, e.g. "yank release".
+	Action string
+}
+
+func (e YankEvent) GetComponent() string {
+	return FeedsComponentType
+}
+
+func (e YankEvent) GetType() string {
+	return YankEventType
+}
+
+func (e YankEvent) GetMessage() string {
+	return fmt.Sprintf("version %v of %v was yanked from %v feed (%v)", e.Version, e.Package, e.Feed, e.Action)
+}
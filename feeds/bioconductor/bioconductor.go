@@ -0,0 +1,218 @@
+package bioconductor
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ossf/package-feeds/events"
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/utils"
+)
+
+const (
+	FeedName          = "bioconductor"
+	viewsFile         = "VIEWS"
+	defaultRelease    = "release"
+	defaultRepository = "bioc"
+	publicationLayout = "2006-01-02"
+)
+
+var (
+	httpClient           = utils.NewHTTPClient(60 * time.Second)
+	errUnknownRepository = errors.New("unknown bioconductor repository")
+
+	// Repository names mapped to their path within a release.
+	repositoryPaths = map[string]string{
+		"bioc":            "bioc",
+		"data-annotation": "data/annotation",
+		"data-experiment": "data/experiment",
+		"workflows":       "workflows",
+	}
+)
+
+// Package is a single package record within the VIEWS index.
+type Package struct {
+	Name    string
+	Version string
+	// The date the package version was published, zero if not provided by the index.
+	Published time.Time
+}
+
+func (p *Package) createdDate(indexModified time.Time) time.Time {
+	if p.Published.IsZero() {
+		return indexModified
+	}
+	return p.Published
+}
+
+// Parses a VIEWS index, which consists of Debian control file style records separated
+// by blank lines. Only the fields required for packages are retained.
+func parseViews(r io.Reader) ([]*Package, error) {
+	pkgs := []*Package{}
+	var current *Package
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			current = nil
+			continue
+		}
+		// Lines beginning with whitespace continue the value of the previous field.
+		if line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if current == nil {
+			current = &Package{}
+			pkgs = append(pkgs, current)
+		}
+		value := strings.TrimSpace(parts[1])
+		switch parts[0] {
+		case "Package":
+			current.Name = value
+		case "Version":
+			current.Version = value
+		case "Date/Publication":
+			if published, err := time.Parse(publicationLayout, value); err == nil {
+				current.Published = published
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return pkgs, nil
+}
+
+// Fetches the VIEWS index for a repository, alongside the time the index was last modified,
+// or a zero time if it is unknown.
+func fetchViews(ctx context.Context, baseURL, release, repositoryPath string) ([]*Package, time.Time, error) {
+	indexURL, err := utils.URLPathJoin(baseURL, "packages", release, repositoryPath, viewsFile)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	resp, err := utils.Get(ctx, httpClient, indexURL)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	err = utils.CheckResponseStatus(resp)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to fetch bioconductor VIEWS: %w", err)
+	}
+
+	pkgs, err := parseViews(utils.NewUTF8OnlyReader(resp.Body))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	// The time is left zero when the registry doesn't report it.
+	indexModified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return pkgs, indexModified, nil
+}
+
+type Feed struct {
+	baseURL        string
+	release        string
+	repositoryPath string
+	options        feeds.FeedOptions
+	clock          feeds.Clock
+
+	// Package versions seen in the previous poll indexed by name, nil prior to the first poll.
+	seen   map[string]string
+	seenMu sync.Mutex
+}
+
+func init() { //nolint:gochecknoinits
+	feeds.Register(FeedName, func(options feeds.FeedOptions, _ *events.Handler) (feeds.ScheduledFeed, error) {
+		return New(options)
+	})
+}
+
+func New(feedOptions feeds.FeedOptions) (*Feed, error) {
+	if feedOptions.Packages != nil {
+		return nil, feeds.UnsupportedOptionError{
+			Feed:   FeedName,
+			Option: "packages",
+		}
+	}
+	release := feedOptions.Release
+	if release == "" {
+		release = defaultRelease
+	}
+	repository := feedOptions.Repository
+	if repository == "" {
+		repository = defaultRepository
+	}
+	repositoryPath, ok := repositoryPaths[repository]
+	if !ok {
+		return nil, fmt.Errorf("%w : %v", errUnknownRepository, repository)
+	}
+	return &Feed{
+		baseURL:        "https://bioconductor.org/",
+		release:        release,
+		repositoryPath: repositoryPath,
+		options:        feedOptions,
+		clock:          feeds.ClockOrReal(feedOptions.Clock),
+	}, nil
+}
+
+// Latest diffs the package versions in the repository's VIEWS index against those seen in
+// the previous poll, emitting a package for each new or changed version. The index doesn't
+// provide precise timestamps, so the first poll only records the current versions and the
+// cutoff isn't applied.
+func (feed *Feed) Latest(ctx context.Context, cutoff time.Time) ([]*feeds.Package, []error) {
+	pkgs := []*feeds.Package{}
+	views, indexModified, err := fetchViews(ctx, feed.baseURL, feed.release, feed.repositoryPath)
+	if err != nil {
+		return nil, []error{err}
+	}
+	if indexModified.IsZero() {
+		indexModified = feed.clock.Now().UTC()
+	}
+
+	feed.seenMu.Lock()
+	defer feed.seenMu.Unlock()
+
+	firstPoll := feed.seen == nil
+	seen := make(map[string]string, len(views))
+	for _, view := range views {
+		if view.Name == "" || view.Version == "" {
+			continue
+		}
+		seen[view.Name] = view.Version
+		if firstPoll || feed.seen[view.Name] == view.Version {
+			continue
+		}
+		created := view.createdDate(indexModified)
+		pkg := feeds.NewPackage(created, view.Name, view.Version, FeedName, feeds.EcosystemBioconductor)
+		pkgs = append(pkgs, pkg)
+	}
+	feed.seen = seen
+
+	return pkgs, []error{}
+}
+
+func (feed *Feed) GetName() string {
+	return FeedName
+}
+
+func (feed *Feed) GetFeedOptions() feeds.FeedOptions {
+	return feed.options
+}
+
+func (feed *Feed) GetBaseURL() string {
+	return feed.baseURL
+}
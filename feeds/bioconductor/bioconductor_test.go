@@ -0,0 +1,170 @@
+package bioconductor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/utils"
+	testutils "github.com/ossf/package-feeds/utils/test"
+)
+
+func TestBioconductorLatestDiff(t *testing.T) {
+	t.Parallel()
+
+	poll := 0
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/packages/release/bioc/VIEWS": func(w http.ResponseWriter, r *http.Request) {
+			poll++
+			if poll == 1 {
+				viewsResponse(w, r)
+			} else {
+				updatedViewsResponse(w, r)
+			}
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	feed, err := New(feeds.FeedOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create bioconductor feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	cutoff := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 0 {
+		t.Fatalf("Latest() produced %v packages on the first poll instead of the expected 0", len(pkgs))
+	}
+
+	pkgs, errs = feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	// FooPkg is updated and BazPkg is added, BarPkg is unchanged.
+	if len(pkgs) != 2 {
+		t.Fatalf("Latest() produced %v packages instead of the expected 2", len(pkgs))
+	}
+	if pkgs[0].Name != "FooPkg" || pkgs[0].Version != "1.2.1" {
+		t.Errorf("Unexpected package %s@%s found in place of FooPkg@1.2.1", pkgs[0].Name, pkgs[0].Version)
+	}
+	expectedTime := time.Date(2021, 5, 20, 0, 0, 0, 0, time.UTC)
+	if !pkgs[0].CreatedDate.Equal(expectedTime) {
+		t.Errorf("Unexpected created date `%s` found in place of expected `%s`", pkgs[0].CreatedDate, expectedTime)
+	}
+	if pkgs[1].Name != "BazPkg" || pkgs[1].Version != "0.99.0" {
+		t.Errorf("Unexpected package %s@%s found in place of BazPkg@0.99.0", pkgs[1].Name, pkgs[1].Version)
+	}
+	// BazPkg has no publication date so the modification time of the index is used.
+	expectedTime = time.Date(2021, 5, 21, 12, 0, 0, 0, time.UTC)
+	if !pkgs[1].CreatedDate.Equal(expectedTime) {
+		t.Errorf("Unexpected created date `%s` found in place of expected `%s`", pkgs[1].CreatedDate, expectedTime)
+	}
+	for _, pkg := range pkgs {
+		if pkg.Type != FeedName {
+			t.Errorf("Feed type not set correctly in bioconductor package following Latest()")
+		}
+		if pkg.Ecosystem != feeds.EcosystemBioconductor {
+			t.Errorf("Ecosystem not set correctly in bioconductor package following Latest()")
+		}
+	}
+}
+
+func TestBioconductorRepository(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/packages/3.13/data/annotation/VIEWS": viewsResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	feed, err := New(feeds.FeedOptions{
+		Release:    "3.13",
+		Repository: "data-annotation",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create bioconductor feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	_, errs := feed.Latest(context.Background(), time.Time{})
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+
+	_, err = New(feeds.FeedOptions{Repository: "foo"})
+	if !errors.Is(err, errUnknownRepository) {
+		t.Errorf("New() returned `%v` when an unknown repository error was expected", err)
+	}
+}
+
+func TestBioconductorNotFound(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/packages/release/bioc/VIEWS": testutils.NotFoundHandlerFunc,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	feed, err := New(feeds.FeedOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create bioconductor feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	_, errs := feed.Latest(context.Background(), time.Time{})
+	if len(errs) != 1 {
+		t.Fatalf("feed.Latest() returned %v errors when 1 was expected", len(errs))
+	}
+	if !errors.Is(errs[0], utils.ErrUnsuccessfulRequest) {
+		t.Errorf("Unexpected error `%v` when an unsuccessful request error was expected", errs[0])
+	}
+}
+
+func viewsResponse(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Last-Modified", "Thu, 20 May 2021 12:00:00 GMT")
+	_, err := w.Write([]byte(`Package: FooPkg
+Version: 1.2.0
+Depends: R (>= 4.0.0), methods,
+        BiocGenerics
+Title: Foo package
+Date/Publication: 2021-05-19
+
+Package: BarPkg
+Version: 2.0.0
+Title: Bar package
+Date/Publication: 2021-04-01
+`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
+
+func updatedViewsResponse(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Last-Modified", "Fri, 21 May 2021 12:00:00 GMT")
+	_, err := w.Write([]byte(`Package: FooPkg
+Version: 1.2.1
+Depends: R (>= 4.0.0), methods,
+        BiocGenerics
+Title: Foo package
+Date/Publication: 2021-05-20
+
+Package: BarPkg
+Version: 2.0.0
+Title: Bar package
+Date/Publication: 2021-04-01
+
+Package: BazPkg
+Version: 0.99.0
+Title: Baz package
+`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
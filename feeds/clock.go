@@ -0,0 +1,61 @@
+package feeds
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock provides the current time to the scheduler and feeds, allowing the progression
+// of cutoffs and package ages to be controlled in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// RealClock returns the Clock of the system time.
+func RealClock() Clock {
+	return realClock{}
+}
+
+// ClockOrReal returns the clock, or the RealClock if clock is nil.
+func ClockOrReal(clock Clock) Clock {
+	if clock == nil {
+		return RealClock()
+	}
+	return clock
+}
+
+// FakeClock is a Clock whose time only changes when it is set or advanced.
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set sets the current time of the clock.
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// Advance moves the current time of the clock forward by d.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,175 @@
+package conda
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ossf/package-feeds/events"
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/utils"
+)
+
+const (
+	FeedName       = "conda"
+	repodataFile   = "repodata.json"
+	defaultChannel = "conda-forge"
+	defaultSubdir  = "noarch"
+)
+
+var httpClient = utils.NewHTTPClient(60 * time.Second)
+
+// repodata is the index of a channel subdir, artifacts are keyed by their filename.
+type repodata struct {
+	Packages      map[string]*Package `json:"packages"`
+	CondaPackages map[string]*Package `json:"packages.conda"`
+}
+
+// Package is a single artifact record within repodata.json.
+type Package struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Timestamp is the build time in milliseconds since the epoch, older
+	// artifacts may not have this field set.
+	Timestamp int64 `json:"timestamp"`
+}
+
+func (p *Package) createdDate(indexModified time.Time) time.Time {
+	if p.Timestamp == 0 {
+		return indexModified
+	}
+	return time.Unix(0, p.Timestamp*int64(time.Millisecond)).UTC()
+}
+
+// Fetches the repodata.json index for a channel subdir, alongside the time the index
+// was last modified, or a zero time if it is unknown.
+func fetchRepodata(ctx context.Context, baseURL, channel, subdir string) (map[string]*Package, time.Time, error) {
+	indexURL, err := utils.URLPathJoin(baseURL, channel, subdir, repodataFile)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	resp, err := utils.Get(ctx, httpClient, indexURL)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	err = utils.CheckResponseStatus(resp)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to fetch conda repodata: %w", err)
+	}
+
+	data := &repodata{}
+	err = json.NewDecoder(resp.Body).Decode(data)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	// The time is left zero when the registry doesn't report it.
+	indexModified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+
+	artifacts := make(map[string]*Package, len(data.Packages)+len(data.CondaPackages))
+	for filename, pkg := range data.Packages {
+		artifacts[filename] = pkg
+	}
+	for filename, pkg := range data.CondaPackages {
+		artifacts[filename] = pkg
+	}
+	return artifacts, indexModified, nil
+}
+
+type Feed struct {
+	baseURL string
+	channel string
+	subdir  string
+	options feeds.FeedOptions
+	clock   feeds.Clock
+
+	// Artifact filenames seen in the previous poll, nil prior to the first poll.
+	seen   map[string]bool
+	seenMu sync.Mutex
+}
+
+func init() { //nolint:gochecknoinits
+	feeds.Register(FeedName, func(options feeds.FeedOptions, _ *events.Handler) (feeds.ScheduledFeed, error) {
+		return New(options)
+	})
+}
+
+func New(feedOptions feeds.FeedOptions) (*Feed, error) {
+	if feedOptions.Packages != nil {
+		return nil, feeds.UnsupportedOptionError{
+			Feed:   FeedName,
+			Option: "packages",
+		}
+	}
+	channel := feedOptions.Channel
+	if channel == "" {
+		channel = defaultChannel
+	}
+	subdir := feedOptions.Subdir
+	if subdir == "" {
+		subdir = defaultSubdir
+	}
+	return &Feed{
+		baseURL: "https://conda.anaconda.org/",
+		channel: channel,
+		subdir:  subdir,
+		options: feedOptions,
+		clock:   feeds.ClockOrReal(feedOptions.Clock),
+	}, nil
+}
+
+// Latest diffs the artifacts in the channel's repodata.json against those seen in the
+// previous poll, emitting a package for each new artifact. repodata.json is a full snapshot
+// of the channel, so on the first poll artifacts without a timestamp are recorded but not
+// emitted as their creation date cannot be determined.
+func (feed *Feed) Latest(ctx context.Context, cutoff time.Time) ([]*feeds.Package, []error) {
+	pkgs := []*feeds.Package{}
+	artifacts, indexModified, err := fetchRepodata(ctx, feed.baseURL, feed.channel, feed.subdir)
+	if err != nil {
+		return nil, []error{err}
+	}
+	if indexModified.IsZero() {
+		indexModified = feed.clock.Now().UTC()
+	}
+
+	feed.seenMu.Lock()
+	defer feed.seenMu.Unlock()
+
+	firstPoll := feed.seen == nil
+	for filename, artifact := range artifacts {
+		if feed.seen[filename] {
+			continue
+		}
+		if firstPoll && artifact.Timestamp == 0 {
+			continue
+		}
+		created := artifact.createdDate(indexModified)
+		pkg := feeds.NewPackage(created, artifact.Name, artifact.Version, FeedName, feeds.EcosystemConda)
+		pkgs = append(pkgs, pkg)
+	}
+
+	feed.seen = make(map[string]bool, len(artifacts))
+	for filename := range artifacts {
+		feed.seen[filename] = true
+	}
+
+	pkgs = feeds.ApplyCutoff(pkgs, cutoff)
+	return pkgs, []error{}
+}
+
+func (feed *Feed) GetName() string {
+	return FeedName
+}
+
+func (feed *Feed) GetFeedOptions() feeds.FeedOptions {
+	return feed.options
+}
+
+func (feed *Feed) GetBaseURL() string {
+	return feed.baseURL
+}
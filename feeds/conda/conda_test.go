@@ -0,0 +1,280 @@
+package conda
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/utils"
+	testutils "github.com/ossf/package-feeds/utils/test"
+)
+
+func TestCondaLatest(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/conda-forge/noarch/repodata.json": repodataResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	feed, err := New(feeds.FeedOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create conda feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	cutoff := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+
+	// The artifact without a timestamp is not emitted on the first poll, and the
+	// artifact built in 2020 is before the cutoff.
+	if len(pkgs) != 2 {
+		t.Fatalf("Latest() produced %v packages instead of the expected 2", len(pkgs))
+	}
+	for _, pkg := range pkgs {
+		if pkg.Name != "foopackage" {
+			t.Errorf("Unexpected package `%s` found in place of expected `foopackage`", pkg.Name)
+		}
+		if pkg.Version != "1.0.1" {
+			t.Errorf("Unexpected version `%s` found in place of expected `1.0.1`", pkg.Version)
+		}
+		if pkg.Type != FeedName {
+			t.Errorf("Feed type not set correctly in conda package following Latest()")
+		}
+		if pkg.Ecosystem != feeds.EcosystemConda {
+			t.Errorf("Ecosystem not set correctly in conda package following Latest()")
+		}
+	}
+	expectedTime := time.Date(2021, 2, 3, 8, 41, 18, 901000000, time.UTC)
+	if !pkgs[0].CreatedDate.Equal(expectedTime) {
+		t.Errorf("Unexpected created date `%s` found in place of expected `%s`", pkgs[0].CreatedDate, expectedTime)
+	}
+}
+
+func TestCondaLatestDiff(t *testing.T) {
+	t.Parallel()
+
+	poll := 0
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/conda-forge/noarch/repodata.json": func(w http.ResponseWriter, r *http.Request) {
+			poll++
+			if poll == 1 {
+				repodataResponse(w, r)
+			} else {
+				updatedRepodataResponse(w, r)
+			}
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	feed, err := New(feeds.FeedOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create conda feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	cutoff := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 2 {
+		t.Fatalf("Latest() produced %v packages instead of the expected 2", len(pkgs))
+	}
+
+	pkgMap := map[string]*feeds.Package{}
+	for _, pkg := range pkgs {
+		pkgMap[pkg.Name] = pkg
+	}
+	if pkg, ok := pkgMap["foopackage"]; !ok || pkg.Version != "1.1.0" {
+		t.Errorf("Missing foopackage 1.1.0")
+	}
+	// Artifacts without a timestamp fall back to the Last-Modified time of the index.
+	indexModified := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	if pkg, ok := pkgMap["bazpackage"]; !ok || !pkg.CreatedDate.Equal(indexModified) {
+		t.Errorf("bazpackage was not emitted with the index modification time as its created date")
+	}
+}
+
+func TestCondaLatestWithoutLastModified(t *testing.T) {
+	t.Parallel()
+
+	poll := 0
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/conda-forge/noarch/repodata.json": func(w http.ResponseWriter, r *http.Request) {
+			poll++
+			if poll == 1 {
+				repodataResponse(withoutLastModified{w}, r)
+			} else {
+				updatedRepodataResponse(withoutLastModified{w}, r)
+			}
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	now := time.Date(2021, 3, 2, 9, 0, 0, 0, time.UTC)
+	feed, err := New(feeds.FeedOptions{Clock: feeds.NewFakeClock(now)})
+	if err != nil {
+		t.Fatalf("Failed to create conda feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	cutoff := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, errs := feed.Latest(context.Background(), cutoff); len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	// Artifacts without a timestamp fall back to the time of the feed's clock when the index
+	// doesn't report its modification time.
+	pkgMap := map[string]*feeds.Package{}
+	for _, pkg := range pkgs {
+		pkgMap[pkg.Name] = pkg
+	}
+	if pkg, ok := pkgMap["bazpackage"]; !ok || !pkg.CreatedDate.Equal(now) {
+		t.Errorf("bazpackage was not emitted with the time of the clock as its created date")
+	}
+}
+
+func TestCondaNotFound(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/conda-forge/noarch/repodata.json": testutils.NotFoundHandlerFunc,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	feed, err := New(feeds.FeedOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create conda feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 1 {
+		t.Fatalf("feed.Latest() returned %v errors when 1 was expected", len(errs))
+	}
+	if !errors.Is(errs[len(errs)-1], utils.ErrUnsuccessfulRequest) {
+		t.Fatalf("feed.Latest() returned an error which did not match the expected error")
+	}
+}
+
+func TestCondaChannelOptions(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/bioconda/linux-64/repodata.json": repodataResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	feed, err := New(feeds.FeedOptions{Channel: "bioconda", Subdir: "linux-64"})
+	if err != nil {
+		t.Fatalf("Failed to create conda feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	cutoff := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+}
+
+func repodataResponse(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Last-Modified", "Mon, 01 Feb 2021 12:00:00 GMT")
+	_, err := w.Write([]byte(`
+{
+	"info": {"subdir": "noarch"},
+	"packages": {
+		"foopackage-1.0.1-pyhd8ed1ab_0.tar.bz2": {
+			"name": "foopackage",
+			"version": "1.0.1",
+			"build": "pyhd8ed1ab_0",
+			"timestamp": 1612341678901
+		},
+		"barpackage-0.1.0-py_0.tar.bz2": {
+			"name": "barpackage",
+			"version": "0.1.0",
+			"build": "py_0",
+			"timestamp": 1580515200000
+		},
+		"quxpackage-2.0-py_0.tar.bz2": {
+			"name": "quxpackage",
+			"version": "2.0",
+			"build": "py_0"
+		}
+	},
+	"packages.conda": {
+		"foopackage-1.0.1-pyhd8ed1ab_0.conda": {
+			"name": "foopackage",
+			"version": "1.0.1",
+			"build": "pyhd8ed1ab_0",
+			"timestamp": 1612341678901
+		}
+	}
+}
+`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
+
+func updatedRepodataResponse(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Last-Modified", "Mon, 01 Mar 2021 12:00:00 GMT")
+	_, err := w.Write([]byte(`
+{
+	"info": {"subdir": "noarch"},
+	"packages": {
+		"foopackage-1.0.1-pyhd8ed1ab_0.tar.bz2": {
+			"name": "foopackage",
+			"version": "1.0.1",
+			"build": "pyhd8ed1ab_0",
+			"timestamp": 1612341678901
+		},
+		"foopackage-1.1.0-pyhd8ed1ab_0.tar.bz2": {
+			"name": "foopackage",
+			"version": "1.1.0",
+			"build": "pyhd8ed1ab_0",
+			"timestamp": 1614585600000
+		},
+		"quxpackage-2.0-py_0.tar.bz2": {
+			"name": "quxpackage",
+			"version": "2.0",
+			"build": "py_0"
+		},
+		"bazpackage-0.2-py_0.tar.bz2": {
+			"name": "bazpackage",
+			"version": "0.2",
+			"build": "py_0"
+		}
+	}
+}
+`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
+
+// Removes the Last-Modified header from a response before it is written.
+type withoutLastModified struct {
+	http.ResponseWriter
+}
+
+func (w withoutLastModified) Write(b []byte) (int, error) {
+	w.Header().Del("Last-Modified")
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,220 @@
+package cpan
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ossf/package-feeds/events"
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/utils"
+)
+
+const (
+	FeedName   = "cpan"
+	searchPath = "/v1/release/_search"
+
+	// The number of releases requested per page, and the maximum number of pages requested
+	// for the firehose or a distribution by a single poll.
+	pageSize = 100
+	maxPages = 10
+
+	// Release dates are UTC without a zone designator, e.g. "2021-05-11T18:32:01".
+	dateLayout = "2006-01-02T15:04:05"
+)
+
+var (
+	httpClient = utils.NewHTTPClient(10 * time.Second)
+
+	errModuleName         = errors.New("name is a module name, distribution names use - in place of ::")
+	errInvalidPackageName = errors.New("name must be words of letters, digits and _ joined by -")
+
+	// A distribution name, e.g. Moose or libwww-perl.
+	distributionRegexp = regexp.MustCompile(`^[A-Za-z0-9_]+(-[A-Za-z0-9_]+)*$`)
+)
+
+// A release of a distribution as returned by the MetaCPAN release search.
+type release struct {
+	Distribution string `json:"distribution"`
+	Version      string `json:"version"`
+	Date         string `json:"date"`
+}
+
+type searchResponse struct {
+	Hits struct {
+		Hits []struct {
+			Source release `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+// Parses the date of a release, dates of older releases may include a zone designator.
+func (r release) createdDate() (time.Time, error) {
+	date, err := time.ParseInLocation(dateLayout, r.Date, time.UTC)
+	if err != nil {
+		return time.Parse(time.RFC3339, r.Date)
+	}
+	return date, nil
+}
+
+type Feed struct {
+	lossyFeedAlerter *feeds.LossyFeedAlerter
+	baseURL          string
+	packages         *[]string
+	options          feeds.FeedOptions
+}
+
+func init() { //nolint:gochecknoinits
+	feeds.Register(FeedName, func(options feeds.FeedOptions, eventHandler *events.Handler) (feeds.ScheduledFeed, error) {
+		return New(options, eventHandler)
+	})
+}
+
+func New(feedOptions feeds.FeedOptions, eventHandler *events.Handler) (*Feed, error) {
+	return &Feed{
+		lossyFeedAlerter: feeds.NewLossyFeedAlerter(eventHandler),
+		baseURL:          "https://fastapi.metacpan.org",
+		packages:         feedOptions.Packages,
+		options:          feedOptions,
+	}, nil
+}
+
+func (feed *Feed) fetchPage(ctx context.Context, query string, from int) ([]release, error) {
+	searchURL, err := utils.URLPathJoin(feed.baseURL, searchPath)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	if query != "" {
+		params.Set("q", query)
+	}
+	params.Set("sort", "date:desc")
+	params.Set("size", strconv.Itoa(pageSize))
+	params.Set("from", strconv.Itoa(from))
+	resp, err := utils.Get(ctx, httpClient, searchURL+"?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	err = utils.CheckResponseStatus(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch cpan release data: %w", err)
+	}
+	response := &searchResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return nil, err
+	}
+	releases := []release{}
+	for _, hit := range response.Hits.Hits {
+		releases = append(releases, hit.Source)
+	}
+	return releases, nil
+}
+
+// Fetches the releases matching the query from the pages of the release search, most
+// recently uploaded first, until a release uploaded before the cutoff is found. Releases
+// whose date can't be parsed are returned as errors, along with the error of a page which
+// failed to be fetched.
+func (feed *Feed) fetchReleases(ctx context.Context, query string,
+	cutoff time.Time) ([]*feeds.Package, []error, error) {
+	pkgs := []*feeds.Package{}
+	errs := []error{}
+	for page := 0; page < maxPages; page++ {
+		releases, err := feed.fetchPage(ctx, query, page*pageSize)
+		if err != nil {
+			// Releases fetched from earlier pages could still be processed.
+			return pkgs, errs, err
+		}
+		reachedCutoff := false
+		for _, r := range releases {
+			created, err := r.createdDate()
+			if err != nil {
+				errs = append(errs, feeds.PackagePollError{Name: r.Distribution, Err: err})
+				continue
+			}
+			if created.Before(cutoff) {
+				reachedCutoff = true
+			}
+			pkgs = append(pkgs, feeds.NewPackage(created, r.Distribution, r.Version, FeedName, feeds.EcosystemCPAN))
+		}
+		if reachedCutoff || len(releases) < pageSize {
+			break
+		}
+	}
+	return pkgs, errs, nil
+}
+
+func (feed *Feed) Latest(ctx context.Context, cutoff time.Time) ([]*feeds.Package, []error) {
+	if feed.packages != nil {
+		return feed.latestPackages(ctx, cutoff)
+	}
+	pkgs, errs, err := feed.fetchReleases(ctx, "", cutoff)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	feed.lossyFeedAlerter.ProcessPackages(FeedName, pkgs)
+	return feeds.ApplyCutoff(pkgs, cutoff), errs
+}
+
+// Fetches the releases of each configured distribution.
+func (feed *Feed) latestPackages(ctx context.Context, cutoff time.Time) ([]*feeds.Package, []error) {
+	type result struct {
+		pkgs []*feeds.Package
+		errs []error
+	}
+	results := make(chan result)
+	for _, name := range *feed.packages {
+		go func(name string) {
+			pkgs, errs, err := feed.fetchReleases(ctx, "distribution:"+name, cutoff)
+			if err != nil {
+				errs = append(errs, feeds.PackagePollError{Name: name, Err: err})
+			}
+			results <- result{pkgs: pkgs, errs: errs}
+		}(name)
+	}
+
+	pkgs := []*feeds.Package{}
+	errs := []error{}
+	for range *feed.packages {
+		r := <-results
+		pkgs = append(pkgs, r.pkgs...)
+		errs = append(errs, r.errs...)
+	}
+	if len(pkgs) == 0 && len(errs) > 0 {
+		return nil, append(errs, feeds.ErrNoPackagesPolled)
+	}
+	// Packages polled concurrently are returned in a consistent order.
+	feeds.SortByCreatedDate(pkgs)
+	return feeds.ApplyCutoff(pkgs, cutoff), errs
+}
+
+// ValidatePackageName checks the name is a distribution name, such as HTTP-Tiny, rather
+// than the name of a module it provides, such as HTTP::Tiny.
+func (feed *Feed) ValidatePackageName(name string) error {
+	if strings.Contains(name, "::") {
+		return errModuleName
+	}
+	if !distributionRegexp.MatchString(name) {
+		return errInvalidPackageName
+	}
+	return nil
+}
+
+func (feed *Feed) GetName() string {
+	return FeedName
+}
+
+func (feed *Feed) GetFeedOptions() feeds.FeedOptions {
+	return feed.options
+}
+
+func (feed *Feed) GetBaseURL() string {
+	return feed.baseURL
+}
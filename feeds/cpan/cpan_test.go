@@ -0,0 +1,190 @@
+package cpan
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/events"
+	"github.com/ossf/package-feeds/feeds"
+	testutils "github.com/ossf/package-feeds/utils/test"
+)
+
+var searchStart = time.Date(2021, 5, 11, 18, 0, 0, 0, time.UTC)
+
+// Serves pages of the releases, each of which is uploaded a minute before the previous.
+func releasesHandler(t *testing.T, releases []release, requests *int, mu *sync.Mutex) testutils.HTTPHandlerFunc {
+	t.Helper()
+	return func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		*requests++
+		mu.Unlock()
+		query := r.URL.Query()
+		if query.Get("sort") != "date:desc" {
+			http.Error(w, "releases must be sorted by date", http.StatusBadRequest)
+			return
+		}
+		from, err := strconv.Atoi(query.Get("from"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		size, err := strconv.Atoi(query.Get("size"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp := searchResponse{}
+		for i := from; i < from+size && i < len(releases); i++ {
+			hit := struct {
+				Source release `json:"_source"`
+			}{Source: releases[i]}
+			resp.Hits.Hits = append(resp.Hits.Hits, hit)
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+		}
+	}
+}
+
+func TestCPANLatest(t *testing.T) {
+	t.Parallel()
+
+	releases := []release{}
+	for i := 0; i < 250; i++ {
+		releases = append(releases, release{
+			Distribution: "Dist-" + strconv.Itoa(i),
+			Version:      "1.0" + strconv.Itoa(i),
+			Date:         searchStart.Add(-time.Duration(i) * time.Minute).Format(dateLayout),
+		})
+	}
+	releases[3].Date = "yesterday"
+	var mu sync.Mutex
+	requests := 0
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		searchPath: releasesHandler(t, releases, &requests, &mu),
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	feed, err := New(feeds.FeedOptions{}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create cpan feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	// The cutoff is within the second page, so the third page isn't requested.
+	cutoff := searchStart.Add(-150*time.Minute - time.Second)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if requests != 2 {
+		t.Errorf("Latest() requested %v pages when 2 were expected", requests)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("Latest() returned %v errors when 1 was expected: %v", len(errs), errs)
+	}
+	var pollErr feeds.PackagePollError
+	if !errors.As(errs[0], &pollErr) || pollErr.Name != "Dist-3" {
+		t.Errorf("Latest() returned error `%v` when a poll error of Dist-3 was expected", errs[0])
+	}
+	// The releases up to the cutoff, other than the release with an invalid date.
+	if len(pkgs) != 150 {
+		t.Fatalf("Latest() returned %v packages when 150 were expected", len(pkgs))
+	}
+	for _, pkg := range pkgs {
+		if pkg.Name == "Dist-0" {
+			if pkg.Version != "1.00" || !pkg.CreatedDate.Equal(searchStart) {
+				t.Errorf("Dist-0 was returned as version %v created at %v instead of 1.00 created at %v",
+					pkg.Version, pkg.CreatedDate, searchStart)
+			}
+		}
+		if pkg.Type != FeedName || pkg.Ecosystem != feeds.EcosystemCPAN {
+			t.Errorf("%v has type %q and ecosystem %q instead of cpan and CPAN", pkg.Name, pkg.Type, pkg.Ecosystem)
+		}
+	}
+}
+
+func TestCPANCriticalPackages(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		searchPath: func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("q") != "distribution:Moose" {
+				http.NotFound(w, r)
+				return
+			}
+			_, err := w.Write([]byte(`
+{
+	"hits": {
+		"total": 3,
+		"hits": [
+			{"_source": {"distribution": "Moose", "version": "2.2015", "date": "2021-05-11T10:00:00", "author": "ETHER"}},
+			{"_source": {"distribution": "Moose", "version": "2.2014", "date": "2021-01-04T10:00:00", "author": "ETHER"}},
+			{"_source": {"distribution": "Moose", "version": "2.2013", "date": "2020-06-01T10:00:00", "author": "ETHER"}}
+		]
+	}
+}
+`))
+			if err != nil {
+				http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+			}
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	packages := []string{"Moose", "Missing"}
+	feed, err := New(feeds.FeedOptions{Packages: &packages}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create cpan feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	cutoff := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 1 {
+		t.Fatalf("Latest() returned %v errors when 1 was expected: %v", len(errs), errs)
+	}
+	var pollErr feeds.PackagePollError
+	if !errors.As(errs[0], &pollErr) || pollErr.Name != "Missing" {
+		t.Errorf("Latest() returned error `%v` when a poll error of Missing was expected", errs[0])
+	}
+	if len(pkgs) != 2 {
+		t.Fatalf("Latest() returned %v packages when 2 were expected", len(pkgs))
+	}
+	// Packages are sorted most recently created first.
+	for i, version := range []string{"2.2015", "2.2014"} {
+		if pkgs[i].Name != "Moose" || pkgs[i].Version != version {
+			t.Errorf("Latest() returned %v@%v when Moose@%v was expected", pkgs[i].Name, pkgs[i].Version, version)
+		}
+	}
+	if purl := pkgs[0].PURL(); purl != "pkg:cpan/Moose@2.2015" {
+		t.Errorf("Moose has purl %v instead of pkg:cpan/Moose@2.2015", purl)
+	}
+}
+
+func TestCPANValidatePackageName(t *testing.T) {
+	t.Parallel()
+
+	feed := &Feed{}
+	for _, name := range []string{"Moose", "libwww-perl", "HTTP-Tiny", "Data-Dumper_2"} {
+		if err := feed.ValidatePackageName(name); err != nil {
+			t.Errorf("ValidatePackageName(%q) returned unexpected error: %v", name, err)
+		}
+	}
+	invalid := map[string]error{
+		"HTTP::Tiny": errModuleName,
+		"":           errInvalidPackageName,
+		"HTTP-":      errInvalidPackageName,
+		"HTTP Tiny":  errInvalidPackageName,
+	}
+	for name, expected := range invalid {
+		if err := feed.ValidatePackageName(name); !errors.Is(err, expected) {
+			t.Errorf("ValidatePackageName(%q) returned %v when %v was expected", name, err, expected)
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package crates
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/ossf/package-feeds/events"
@@ -14,16 +16,29 @@ import (
 const (
 	FeedName     = "crates"
 	activityPath = "/api/v1/summary"
+	cratesPath   = "/api/v1/crates"
+
+	// The number of crates listed as just updated by the summary.
+	summaryLimit = 10
+	// The number of crates requested per page of recently updated crates.
+	perPage = 100
+	// The maximum number of pages of recently updated crates requested by a single poll.
+	maxPages = 10
 )
 
-var httpClient = &http.Client{
-	Timeout: 10 * time.Second,
-}
+var httpClient = utils.NewHTTPClient(10 * time.Second)
 
 type crates struct {
 	JustUpdated []*Package `json:"just_updated"`
 }
 
+type cratesPage struct {
+	Crates []*Package `json:"crates"`
+	Meta   struct {
+		NextPage string `json:"next_page"`
+	} `json:"meta"`
+}
+
 // Package stores the information from crates.io updates.
 type Package struct {
 	ID               string    `json:"id"`
@@ -35,12 +50,12 @@ type Package struct {
 }
 
 // Gets crates.io packages.
-func fetchPackages(baseURL string) ([]*Package, error) {
+func fetchPackages(ctx context.Context, baseURL string) ([]*Package, error) {
 	pkgURL, err := utils.URLPathJoin(baseURL, activityPath)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := httpClient.Get(pkgURL)
+	resp, err := utils.Get(ctx, httpClient, pkgURL)
 	if err != nil {
 		return nil, err
 	}
@@ -60,12 +75,65 @@ func fetchPackages(baseURL string) ([]*Package, error) {
 	return v.JustUpdated, nil
 }
 
+// Fetches a page of crates, most recently updated first. Whether there is a next page
+// is also returned.
+func fetchCratesPage(ctx context.Context, baseURL string, page int) ([]*Package, bool, error) {
+	pageURL, err := utils.URLPathJoin(baseURL, cratesPath)
+	if err != nil {
+		return nil, false, err
+	}
+	params := url.Values{}
+	params.Set("sort", "recent-updates")
+	params.Set("per_page", strconv.Itoa(perPage))
+	params.Set("page", strconv.Itoa(page))
+	resp, err := utils.Get(ctx, httpClient, pageURL+"?"+params.Encode())
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	err = utils.CheckResponseStatus(resp)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to fetch crates package data: %w", err)
+	}
+
+	v := &cratesPage{}
+	err = json.NewDecoder(resp.Body).Decode(v)
+	if err != nil {
+		return nil, false, err
+	}
+	return v.Crates, v.Meta.NextPage != "", nil
+}
+
+// Fetches the crates updated after the cutoff from the pages of recently updated crates,
+// requesting pages until a crate updated before the cutoff is found.
+func fetchUpdatedCrates(ctx context.Context, baseURL string, cutoff time.Time) ([]*Package, error) {
+	packages := []*Package{}
+	for page := 1; page <= maxPages; page++ {
+		pkgs, hasNext, err := fetchCratesPage(ctx, baseURL, page)
+		if err != nil {
+			return packages, err
+		}
+		packages = append(packages, pkgs...)
+		if !hasNext || len(pkgs) == 0 || pkgs[len(pkgs)-1].UpdatedAt.Before(cutoff) {
+			break
+		}
+	}
+	return packages, nil
+}
+
 type Feed struct {
 	lossyFeedAlerter *feeds.LossyFeedAlerter
 	baseURL          string
 	options          feeds.FeedOptions
 }
 
+func init() { //nolint:gochecknoinits
+	feeds.Register(FeedName, func(options feeds.FeedOptions, eventHandler *events.Handler) (feeds.ScheduledFeed, error) {
+		return New(options, eventHandler)
+	})
+}
+
 func New(feedOptions feeds.FeedOptions, eventHandler *events.Handler) (*Feed, error) {
 	if feedOptions.Packages != nil {
 		return nil, feeds.UnsupportedOptionError{
@@ -80,20 +148,36 @@ func New(feedOptions feeds.FeedOptions, eventHandler *events.Handler) (*Feed, er
 	}, nil
 }
 
-func (feed Feed) Latest(cutoff time.Time) ([]*feeds.Package, []error) {
+func (feed Feed) Latest(ctx context.Context, cutoff time.Time) ([]*feeds.Package, []error) {
 	pkgs := []*feeds.Package{}
-	packages, err := fetchPackages(feed.baseURL)
+	errs := []error{}
+	packages, err := fetchPackages(ctx, feed.baseURL)
 	if err != nil {
 		return pkgs, []error{err}
 	}
+	// The summary only lists the most recently updated crates, when all of them were
+	// updated after the cutoff older crates are fetched from the pages of updated crates.
+	if len(packages) >= summaryLimit && packages[len(packages)-1].UpdatedAt.After(cutoff) {
+		updated, err := fetchUpdatedCrates(ctx, feed.baseURL, cutoff)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		packages = append(packages, updated...)
+	}
+	seen := map[string]bool{}
 	for _, pkg := range packages {
-		pkg := feeds.NewPackage(pkg.UpdatedAt, pkg.Name, pkg.NewestVersion, FeedName)
+		key := pkg.Name + "@" + pkg.NewestVersion
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		pkg := feeds.NewPackage(pkg.UpdatedAt, pkg.Name, pkg.NewestVersion, FeedName, feeds.EcosystemCratesIO)
 		pkgs = append(pkgs, pkg)
 	}
 	feed.lossyFeedAlerter.ProcessPackages(FeedName, pkgs)
 
 	pkgs = feeds.ApplyCutoff(pkgs, cutoff)
-	return pkgs, []error{}
+	return pkgs, errs
 }
 
 func (feed Feed) GetName() string {
@@ -103,3 +187,7 @@ func (feed Feed) GetName() string {
 func (feed Feed) GetFeedOptions() feeds.FeedOptions {
 	return feed.options
 }
+
+func (feed Feed) GetBaseURL() string {
+	return feed.baseURL
+}
@@ -1,8 +1,13 @@
 package crates
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -27,7 +32,7 @@ func TestCratesLatest(t *testing.T) {
 	}
 
 	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
-	pkgs, errs := feed.Latest(cutoff)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
 	if len(errs) != 0 {
 		t.Fatalf("feed.Latest returned error: %v", err)
 	}
@@ -49,6 +54,9 @@ func TestCratesLatest(t *testing.T) {
 		if p.Type != FeedName {
 			t.Errorf("Feed type not set correctly in crates package following Latest()")
 		}
+		if p.Ecosystem != feeds.EcosystemCratesIO {
+			t.Errorf("Ecosystem not set correctly in crates package following Latest()")
+		}
 	}
 }
 
@@ -67,7 +75,7 @@ func TestCratesNotFound(t *testing.T) {
 	}
 
 	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
-	_, errs := feed.Latest(cutoff)
+	_, errs := feed.Latest(context.Background(), cutoff)
 	if len(errs) == 0 {
 		t.Fatalf("feed.Latest() was successful when an error was expected")
 	}
@@ -76,6 +84,78 @@ func TestCratesNotFound(t *testing.T) {
 	}
 }
 
+func TestCratesLatestPaged(t *testing.T) {
+	t.Parallel()
+
+	const numCrates = 250
+	baseTime := time.Date(2021, 3, 19, 12, 0, 0, 0, time.UTC)
+	// Crates named crate-N updated N minutes before the base time.
+	crate := func(i int) *Package {
+		return &Package{
+			Name:          fmt.Sprintf("crate-%d", i),
+			UpdatedAt:     baseTime.Add(-time.Duration(i) * time.Minute),
+			NewestVersion: "1.0.0",
+		}
+	}
+
+	var mu sync.Mutex
+	pagesRequested := []int{}
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		activityPath: func(w http.ResponseWriter, r *http.Request) {
+			v := &crates{}
+			for i := 0; i < summaryLimit; i++ {
+				v.JustUpdated = append(v.JustUpdated, crate(i))
+			}
+			if err := json.NewEncoder(w).Encode(v); err != nil {
+				http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+			}
+		},
+		cratesPath: func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			page, err := strconv.Atoi(query.Get("page"))
+			if err != nil || query.Get("sort") != "recent-updates" {
+				http.Error(w, "invalid query", http.StatusBadRequest)
+				return
+			}
+			mu.Lock()
+			pagesRequested = append(pagesRequested, page)
+			mu.Unlock()
+			v := &cratesPage{}
+			for i := (page - 1) * perPage; i < page*perPage && i < numCrates; i++ {
+				v.Crates = append(v.Crates, crate(i))
+			}
+			if page*perPage < numCrates {
+				v.Meta.NextPage = fmt.Sprintf("?page=%d", page+1)
+			}
+			if err := json.NewEncoder(w).Encode(v); err != nil {
+				http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+			}
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	feed, err := New(feeds.FeedOptions{}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create crates feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	// The cutoff is within the second page of crates.
+	cutoff := baseTime.Add(-150 * time.Minute).Add(-time.Second)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 151 {
+		t.Fatalf("Latest() produced %v packages instead of the expected 151", len(pkgs))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(pagesRequested) != 2 {
+		t.Errorf("Pages %v were requested when only pages 1 and 2 were expected", pagesRequested)
+	}
+}
+
 func cratesSummaryResponse(w http.ResponseWriter, r *http.Request) {
 	_, err := w.Write([]byte(`
 {
@@ -0,0 +1,98 @@
+package feeds
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// CursorStore persists opaque cursors for feeds which poll incrementally from a
+// registry specific position, such as a changelog serial, rather than a timestamp.
+type CursorStore interface {
+	// Get returns the cursor stored for the feed, or an empty string if none is stored.
+	Get(feed string) (string, error)
+	// Set stores the cursor for the feed, replacing any previously stored cursor.
+	Set(feed, cursor string) error
+}
+
+// FileCursorStore is a CursorStore which persists cursors for all feeds as a json
+// object in a single file, allowing cursors to survive restarts.
+type FileCursorStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileCursorStore(path string) *FileCursorStore {
+	return &FileCursorStore{path: path}
+}
+
+func (s *FileCursorStore) Get(feed string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cursors, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	return cursors[feed], nil
+}
+
+func (s *FileCursorStore) Set(feed, cursor string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cursors, err := s.load()
+	if err != nil {
+		return err
+	}
+	cursors[feed] = cursor
+	data, err := json.Marshal(cursors)
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first so that a failed write can't corrupt stored cursors.
+	tmpPath := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path)
+}
+
+func (s *FileCursorStore) load() (map[string]string, error) {
+	cursors := map[string]string{}
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return cursors, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &cursors); err != nil {
+		return nil, err
+	}
+	return cursors, nil
+}
+
+// MemoryCursorStore is a CursorStore which keeps cursors in memory, so cursors are lost on
+// restart. This suits deployments without persistent storage, and tests.
+type MemoryCursorStore struct {
+	mu      sync.Mutex
+	cursors map[string]string
+}
+
+func NewMemoryCursorStore() *MemoryCursorStore {
+	return &MemoryCursorStore{cursors: map[string]string{}}
+}
+
+func (s *MemoryCursorStore) Get(feed string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cursors[feed], nil
+}
+
+func (s *MemoryCursorStore) Set(feed, cursor string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cursors[feed] = cursor
+	return nil
+}
@@ -0,0 +1,40 @@
+package feeds
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCursorStoreRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "cursors.json")
+	stores := map[string]CursorStore{
+		"file":   NewFileCursorStore(path),
+		"memory": NewMemoryCursorStore(),
+	}
+	for name, store := range stores {
+		if cursor, err := store.Get("npm"); err != nil || cursor != "" {
+			t.Errorf("%s: Get() returned %q, %v when no cursor is stored", name, cursor, err)
+		}
+		for _, cursor := range []string{"1000", "now-1001"} {
+			if err := store.Set("npm", cursor); err != nil {
+				t.Fatalf("%s: Set() returned unexpected error: %v", name, err)
+			}
+			if err := store.Set("pypi", "42"); err != nil {
+				t.Fatalf("%s: Set() returned unexpected error: %v", name, err)
+			}
+			if got, err := store.Get("npm"); err != nil || got != cursor {
+				t.Errorf("%s: Get() returned %q, %v when %q was stored", name, got, err, cursor)
+			}
+		}
+		if got, err := store.Get("pypi"); err != nil || got != "42" {
+			t.Errorf("%s: Get() returned %q, %v for another feed when 42 was stored", name, got, err)
+		}
+	}
+
+	// Cursors stored in a file are kept by a new store of the same file, as after a restart.
+	if got, err := NewFileCursorStore(path).Get("npm"); err != nil || got != "now-1001" {
+		t.Errorf("Get() returned %q, %v from a new store of the file when now-1001 was stored", got, err)
+	}
+}
@@ -0,0 +1,45 @@
+package feeds
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+var errInvalidDenylist = errors.New("invalid denylist pattern")
+
+// Denylist matches the names of packages which a feed skips, such as auto-generated or spam
+// packages flooding a registry. A nil Denylist matches no packages.
+type Denylist struct {
+	patterns []*regexp.Regexp
+}
+
+// NewDenylist compiles the regular expressions of a denylist, a name is denied if any of
+// the expressions matches part of it. Nil is returned for an empty list of patterns.
+func NewDenylist(patterns []string) (*Denylist, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	d := &Denylist{}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w : %v", errInvalidDenylist, err)
+		}
+		d.patterns = append(d.patterns, re)
+	}
+	return d, nil
+}
+
+// Denied returns whether the package name matches the denylist.
+func (d *Denylist) Denied(name string) bool {
+	if d == nil {
+		return false
+	}
+	for _, re := range d.patterns {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package feeds
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDenylist(t *testing.T) {
+	t.Parallel()
+
+	denylist, err := NewDenylist([]string{`^@spam-[0-9]+/`, `-autogen$`})
+	if err != nil {
+		t.Fatalf("NewDenylist() returned unexpected error: %v", err)
+	}
+	tests := map[string]bool{
+		"@spam-123/foo":   true,
+		"foo-autogen":     true,
+		"@spam/foo":       false,
+		"foo-autogen-cli": false,
+		"lodash":          false,
+	}
+	for name, denied := range tests {
+		if denylist.Denied(name) != denied {
+			t.Errorf("Denied(%q) returned %v when %v was expected", name, !denied, denied)
+		}
+	}
+
+	var empty *Denylist
+	if empty.Denied("foo") {
+		t.Errorf("An empty denylist denied foo")
+	}
+	if _, err := NewDenylist([]string{"(foo"}); !errors.Is(err, errInvalidDenylist) {
+		t.Errorf("NewDenylist() returned `%v` when an invalid denylist error was expected", err)
+	}
+}
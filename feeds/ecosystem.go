@@ -0,0 +1,87 @@
+package feeds
+
+import "strings"
+
+// Ecosystem identifies the package ecosystem of a package, independently of the feed which
+// produced it. Values follow the ecosystem names used by OSV where one exists.
+// https://ossf.github.io/osv-schema/#affectedpackage-field
+type Ecosystem string
+
+const (
+	EcosystemBioconductor Ecosystem = "Bioconductor"
+	EcosystemConda        Ecosystem = "conda"
+	EcosystemCPAN         Ecosystem = "CPAN"
+	EcosystemCratesIO     Ecosystem = "crates.io"
+	EcosystemGo           Ecosystem = "Go"
+	EcosystemHomebrew     Ecosystem = "Homebrew"
+	EcosystemMaven        Ecosystem = "Maven"
+	EcosystemNPM          Ecosystem = "npm"
+	EcosystemNuGet        Ecosystem = "NuGet"
+	EcosystemPackagist    Ecosystem = "Packagist"
+	EcosystemPub          Ecosystem = "Pub"
+	EcosystemPyPI         Ecosystem = "PyPI"
+	EcosystemRubyGems     Ecosystem = "RubyGems"
+	EcosystemSwiftURL     Ecosystem = "SwiftURL"
+)
+
+type ecosystemInfo struct {
+	// The package-url type of the ecosystem.
+	// https://github.com/package-url/purl-spec/blob/master/PURL-TYPES.rst
+	purlType string
+	// Whether the ecosystem is defined by OSV.
+	osv bool
+}
+
+var ecosystems = map[Ecosystem]ecosystemInfo{
+	EcosystemBioconductor: {purlType: "bioconductor", osv: true},
+	EcosystemConda:        {purlType: "conda"},
+	EcosystemCPAN:         {purlType: "cpan"},
+	EcosystemCratesIO:     {purlType: "cargo", osv: true},
+	EcosystemGo:           {purlType: "golang", osv: true},
+	EcosystemHomebrew:     {purlType: "homebrew"},
+	EcosystemMaven:        {purlType: "maven", osv: true},
+	EcosystemNPM:          {purlType: "npm", osv: true},
+	EcosystemNuGet:        {purlType: "nuget", osv: true},
+	EcosystemPackagist:    {purlType: "composer", osv: true},
+	EcosystemPub:          {purlType: "pub", osv: true},
+	EcosystemPyPI:         {purlType: "pypi", osv: true},
+	EcosystemRubyGems:     {purlType: "gem", osv: true},
+	EcosystemSwiftURL:     {purlType: "swift", osv: true},
+}
+
+// Names of feeds which differ from the name of their ecosystem, accepted by ParseEcosystem.
+var ecosystemAliases = map[string]Ecosystem{
+	"crates":            EcosystemCratesIO,
+	"goproxy":           EcosystemGo,
+	"swiftpackageindex": EcosystemSwiftURL,
+}
+
+// ParseEcosystem returns the ecosystem with the given name, ignoring case. The names of
+// the feeds of an ecosystem are also accepted, e.g. "crates" for crates.io.
+func ParseEcosystem(name string) (Ecosystem, bool) {
+	name = strings.ToLower(name)
+	if e, ok := ecosystemAliases[name]; ok {
+		return e, true
+	}
+	for e := range ecosystems {
+		if strings.ToLower(string(e)) == name {
+			return e, true
+		}
+	}
+	return "", false
+}
+
+// PURLType returns the package-url type of the ecosystem, or an empty string for an unknown
+// ecosystem.
+func (e Ecosystem) PURLType() string {
+	return ecosystems[e].purlType
+}
+
+// OSV returns the name of the ecosystem used by OSV, or an empty string for ecosystems
+// which OSV does not define.
+func (e Ecosystem) OSV() string {
+	if !ecosystems[e].osv {
+		return ""
+	}
+	return string(e)
+}
@@ -0,0 +1,51 @@
+package feeds
+
+import "testing"
+
+func TestParseEcosystem(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		expected Ecosystem
+		ok       bool
+	}{
+		{"npm", EcosystemNPM, true},
+		{"PyPI", EcosystemPyPI, true},
+		{"pypi", EcosystemPyPI, true},
+		{"crates.io", EcosystemCratesIO, true},
+		{"crates", EcosystemCratesIO, true},
+		{"goproxy", EcosystemGo, true},
+		{"swiftpackageindex", EcosystemSwiftURL, true},
+		{"maven", EcosystemMaven, true},
+		{"cocoapods", "", false},
+	}
+	for _, test := range tests {
+		ecosystem, ok := ParseEcosystem(test.name)
+		if ecosystem != test.expected || ok != test.ok {
+			t.Errorf("ParseEcosystem(%q) returned (%q, %v) instead of (%q, %v)",
+				test.name, ecosystem, ok, test.expected, test.ok)
+		}
+	}
+}
+
+func TestEcosystemOSV(t *testing.T) {
+	t.Parallel()
+
+	if osv := EcosystemCratesIO.OSV(); osv != "crates.io" {
+		t.Errorf("OSV() returned %q instead of crates.io", osv)
+	}
+	if osv := EcosystemHomebrew.OSV(); osv != "" {
+		t.Errorf("OSV() returned %q for an ecosystem OSV does not define", osv)
+	}
+}
+
+func TestEcosystemsHavePURLType(t *testing.T) {
+	t.Parallel()
+
+	for ecosystem, info := range ecosystems {
+		if info.purlType == "" {
+			t.Errorf("Ecosystem %q has no package-url type", ecosystem)
+		}
+	}
+}
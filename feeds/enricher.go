@@ -0,0 +1,46 @@
+package feeds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var errEnrich = errors.New("failed to enrich package")
+
+// Enricher adds information to packages between polling and publishing, e.g. the advisories
+// of a vulnerability database. Enrichment is fail-soft, a package which fails to be enriched
+// is still published. Enrichers must be safe for concurrent use.
+type Enricher interface {
+	Enrich(ctx context.Context, pkg *Package) error
+}
+
+// Enrichers composes several enrichers, each enriches the package in turn. A failure of one
+// enricher does not prevent the others enriching the package, the failures are returned
+// together.
+type Enrichers []Enricher
+
+func (e Enrichers) Enrich(ctx context.Context, pkg *Package) error {
+	failures := []string{}
+	for _, enricher := range e {
+		if err := enricher.Enrich(ctx, pkg); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%w : %v", errEnrich, strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// Vulnerability is an advisory affecting a package version, attached by an enricher.
+type Vulnerability struct {
+	ID    string `json:"id"`
+	Title string `json:"title,omitempty"`
+	// The CVE assigned to the vulnerability, if any.
+	CVE       string  `json:"cve,omitempty"`
+	CVSSScore float64 `json:"cvss_score,omitempty"`
+	// A URL describing the vulnerability.
+	Reference string `json:"reference,omitempty"`
+}
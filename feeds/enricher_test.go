@@ -0,0 +1,37 @@
+package feeds
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type enricherFunc func(ctx context.Context, pkg *Package) error
+
+func (f enricherFunc) Enrich(ctx context.Context, pkg *Package) error {
+	return f(ctx, pkg)
+}
+
+func TestEnrichers(t *testing.T) {
+	t.Parallel()
+
+	errFailed := errors.New("registry unavailable")
+	enrichers := Enrichers{
+		enricherFunc(func(ctx context.Context, pkg *Package) error {
+			return errFailed
+		}),
+		enricherFunc(func(ctx context.Context, pkg *Package) error {
+			pkg.License = "MIT"
+			return nil
+		}),
+	}
+	pkg := &Package{Name: "foo"}
+	err := enrichers.Enrich(context.Background(), pkg)
+	if !errors.Is(err, errEnrich) || !strings.Contains(err.Error(), errFailed.Error()) {
+		t.Errorf("Enrich() returned %v when the failure of the first enricher was expected", err)
+	}
+	if pkg.License != "MIT" {
+		t.Errorf("Enrich() set license %q when the second enricher was expected to set MIT", pkg.License)
+	}
+}
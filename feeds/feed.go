@@ -1,12 +1,17 @@
 package feeds
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
+
+	"github.com/ossf/package-feeds/utils"
 )
 
-const schemaVer = "1.0"
+const schemaVer = "1.14"
 
 var ErrNoPackagesPolled = errors.New("no packages were successfully polled")
 
@@ -16,19 +21,251 @@ type UnsupportedOptionError struct {
 }
 
 type ScheduledFeed interface {
-	Latest(cutoff time.Time) ([]*Package, []error)
+	// Latest returns the packages created after the cutoff, requests made to the
+	// registry are bound to ctx.
+	Latest(ctx context.Context, cutoff time.Time) ([]*Package, []error)
 	GetFeedOptions() FeedOptions
 	GetName() string
 }
 
+// A registry polled by a feed, with the token used to authenticate requests to it.
+type RegistryOptions struct {
+	URL   string `yaml:"url"`
+	Token string `yaml:"token"`
+}
+
+// Implemented by feeds which track their position in the registry, such as a changelog
+// serial, rather than relying on the cutoff. The position reached by a poll is committed by
+// the scheduler once the packages of the poll have been published, so that packages which
+// failed to publish are polled again.
+type CommittingFeed interface {
+	// Commit stores the position reached by the most recent poll.
+	Commit() error
+}
+
+// Implemented by feeds holding resources, such as a watcher of a directory, which must be
+// released once the feed is no longer polled.
+type ClosingFeed interface {
+	// Close releases the resources of the feed, it is not polled again.
+	Close() error
+}
+
+// Close releases the resources of the feed if it is a ClosingFeed.
+func Close(feed ScheduledFeed) error {
+	if closing, ok := feed.(ClosingFeed); ok {
+		return closing.Close()
+	}
+	return nil
+}
+
+// Implemented by feeds holding state between polls, such as when each package was last
+// polled. A feed constructed again to poll a reloaded package list takes over the state of
+// the feed it replaces, so that reloading the list doesn't reset it.
+type StatefulFeed interface {
+	// CarryState takes over the state of the previous feed, which was constructed by the
+	// same factory with a different package list.
+	CarryState(previous ScheduledFeed)
+}
+
+// Implemented by feeds which poll a registry over HTTP.
+type BaseURLFeed interface {
+	// GetBaseURL returns the URL of the registry polled by the feed.
+	GetBaseURL() string
+}
+
+// Implemented by feeds which poll several registries, or a registry served from several
+// hosts, in addition to BaseURLFeed.
+type RegistryURLsFeed interface {
+	// GetRegistryURLs returns the URL of every registry polled by the feed.
+	GetRegistryURLs() []string
+}
+
 // General configuration options for feeds.
 type FeedOptions struct {
 	// A collection of package names to poll instead of standard firehose behaviour.
 	// Not supported by all feeds.
 	Packages *[]string `yaml:"packages"`
 
+	// A file or URL listing the packages to poll in place of Packages, either as a json array
+	// or one package per line. The list is reloaded every PackagesRefreshInterval, defaulting
+	// to 5 minutes, without a restart. Only one of Packages, PackagesFile and PackagesURL may
+	// be provided.
+	PackagesFile            string        `yaml:"packages_file"`
+	PackagesURL             string        `yaml:"packages_url"`
+	PackagesRefreshInterval time.Duration `yaml:"packages_refresh_interval"`
+
+	// Poll intervals for individual packages in Packages, allowing packages which are rarely
+	// updated to be polled less often than the feed. Packages without an interval are polled
+	// on every poll of the feed.
+	// Only supported by the npm, pub and pypi feeds.
+	PackagePollIntervals map[string]time.Duration `yaml:"package_poll_intervals"`
+
 	// Cron string for scheduling the polling for the feed.
 	PollRate string `yaml:"poll_rate"`
+
+	// The minimum age of a package before it is processed. Packages created more recently
+	// are deferred to a later poll, allowing registry data to become consistent.
+	MinAge time.Duration `yaml:"min_age"`
+
+	// The duration before the first poll of the feed for which packages are emitted, allowing
+	// a new feed to be seeded with recent packages. Subsequent polls are unaffected.
+	Backfill time.Duration `yaml:"backfill"`
+
+	// The maximum number of packages emitted by a single poll, when exceeded only the
+	// most recently created packages are kept. Zero means unlimited.
+	MaxPackagesPerPoll int `yaml:"max_packages_per_poll"`
+
+	// The maximum duration of a single poll of the feed, distinct from the timeout of the
+	// individual requests made by the feed. Zero means unlimited.
+	PollTimeout time.Duration `yaml:"poll_timeout"`
+
+	// Publishes a heartbeat after each successful poll which found no packages, so that
+	// consumers know the feed is alive.
+	Heartbeat bool `yaml:"heartbeat"`
+
+	// The channel to poll packages from.
+	// Only supported by the conda feed.
+	Channel string `yaml:"channel"`
+
+	// The platform subdirectory of a channel to poll packages from.
+	// Only supported by the conda feed.
+	Subdir string `yaml:"subdir"`
+
+	// The release to poll packages from, e.g. "release", "devel" or "3.13".
+	// Only supported by the bioconductor feed.
+	Release string `yaml:"release"`
+
+	// The repository within a release to poll packages from.
+	// Only supported by the bioconductor feed.
+	Repository string `yaml:"repository"`
+
+	// Whether to poll formulae, casks or both.
+	// Only supported by the homebrew feed.
+	Include string `yaml:"include"`
+
+	// The directory to read package metadata files from.
+	// Only supported by the localdir feed.
+	Path string `yaml:"path"`
+
+	// The base URL of the registry to poll, allowing mirrors and private registries to be used.
+	// Only supported by the npm, gitea and gitlab feeds.
+	RegistryURL string `yaml:"registry_url"`
+
+	// The base URLs of several registries to poll, e.g. a public registry and its mirror or a
+	// private registry. The packages of each are merged, with each version emitted once.
+	// Only one of RegistryURL, BaseURLs and Registries may be provided.
+	// Only supported by the npm feed.
+	BaseURLs []string `yaml:"base_urls"`
+
+	// Several registries to poll in place of BaseURLs, each with the token authenticating
+	// requests to it, so that a token is never sent to another registry.
+	// Only supported by the npm feed.
+	Registries []RegistryOptions `yaml:"registries"`
+
+	// A token used to authenticate requests to the registry of RegistryURL, or the default
+	// registry of the feed.
+	// Only supported by the npm, gitea, gitlab and swiftpackageindex feeds.
+	RegistryToken string `yaml:"registry_token"`
+
+	// Extra headers and query parameters added to every request the feed makes to its
+	// registry, e.g. an API key for a higher rate limit. Values may reference secrets.
+	RequestHeaders map[string]string `yaml:"request_headers"`
+	RequestParams  map[string]string `yaml:"request_params"`
+
+	// The URLs of the chart repositories to poll, indexed by the name of the repository.
+	// Only supported by the helm feed.
+	Repositories map[string]string `yaml:"repositories"`
+
+	// The user or organization owning the packages to poll.
+	// Only supported by the gitea feed.
+	Owner string `yaml:"owner"`
+
+	// The type of packages to poll, e.g. "npm", "pypi" or "container".
+	// Only supported by the gitea and gitlab feeds.
+	PackageType string `yaml:"package_type"`
+
+	// The ID or path of the project owning the packages to poll, e.g. "my-group/my-project".
+	// Only supported by the gitlab feed.
+	Project string `yaml:"project"`
+
+	// Configures TLS connections to the registry, e.g. trusting a private CA or
+	// authenticating with a client certificate.
+	TLS *utils.TLSConfig `yaml:"tls"`
+
+	// The maximum size in bytes of a response body from the registry, larger responses are
+	// rejected. Defaults to 32MiB.
+	// Only supported by the npm feed.
+	MaxResponseSize int64 `yaml:"max_response_size"`
+
+	// Marks a version as republished when its publish time moves more than this duration
+	// later between polls, or it is listed again after being removed. Zero disables this.
+	// Only supported by the npm feed.
+	RepublishThreshold time.Duration `yaml:"republish_threshold"`
+
+	// Emits only the most recently created version of each package polled.
+	// Only supported by the npm feed.
+	LatestVersionOnly bool `yaml:"latest_version_only"`
+
+	// Dispatches an event for unpublished packages in Packages rather than reporting an error.
+	// Only supported by the npm feed.
+	UnpublishEvents bool `yaml:"unpublish_events"`
+
+	// Dispatches an event when the latest version of a package in Packages leaps by more
+	// than this number of major versions, or decreases. Zero disables this.
+	// Only supported by the npm feed.
+	VersionJumpThreshold int `yaml:"version_jump_threshold"`
+
+	// Regular expressions matching the names of packages to skip, e.g. auto-generated or spam
+	// packages. Denied packages aren't fetched from the registry.
+	// Only supported by the npm feed.
+	Denylist []string `yaml:"denylist"`
+
+	// Sets HasInstallScripts on versions which run scripts on install, a risk signal as these
+	// run arbitrary code when the package is installed.
+	// Only supported by the npm feed.
+	InstallScripts bool `yaml:"install_scripts"`
+
+	// Sets Deprecated and DeprecationMessage on versions which have been deprecated by their
+	// maintainers.
+	// Only supported by the npm feed.
+	Deprecations bool `yaml:"deprecations"`
+
+	// Sets UnpackedSize and FileCount on versions from the `dist` of the version, a sudden
+	// increase of either may indicate a compromised release.
+	// Only supported by the npm feed.
+	TarballStats bool `yaml:"tarball_stats"`
+
+	// Sets License on versions from the license declared by the version, normalized toward
+	// an SPDX identifier.
+	// Only supported by the npm feed.
+	Licenses bool `yaml:"licenses"`
+
+	// Fetches the number of downloads of each package in the last week, requiring a request
+	// per package. Packages whose count can't be fetched are emitted without one.
+	// Only supported by the npm feed.
+	EnrichDownloads bool `yaml:"enrich_downloads"`
+
+	// Sets Raw on versions to the metadata of the version returned by the registry, for
+	// consumers needing metadata which isn't otherwise emitted.
+	// Only supported by the npm feed.
+	IncludeRaw bool `yaml:"include_raw"`
+
+	// Selects an alternative method of polling the registry.
+	// Only supported by the pypi and npm feeds.
+	Mode string `yaml:"mode"`
+
+	// Persists cursors between polls for feeds which poll incrementally, this is
+	// provided by the application rather than feed configuration.
+	CursorStore CursorStore `yaml:"-"`
+
+	// Provides the current time used when deciding which packages are due to be polled,
+	// this is provided by the application and defaults to the system time.
+	Clock Clock `yaml:"-"`
+
+	// Configures TLS connections to the registry when TLS isn't set, this is provided by
+	// the application from the top level configuration. Unlike TLS, it is ignored by feeds
+	// which don't poll a registry.
+	DefaultTLS *utils.TLSConfig `yaml:"-"`
 }
 
 // Marshalled json output validated against package.schema.json.
@@ -38,6 +275,52 @@ type Package struct {
 	CreatedDate time.Time `json:"created_date"`
 	Type        string    `json:"type"`
 	SchemaVer   string    `json:"schema_ver"`
+	// The ecosystem of the package, whereas Type names the feed which produced it.
+	Ecosystem Ecosystem `json:"ecosystem,omitempty"`
+	// When the package was first seen by a poll, whereas CreatedDate is reported by the
+	// registry. Set by the scheduler, packages emitted by later polls keep this time. Nil
+	// for packages which weren't polled by the scheduler, such as those of a replay.
+	FirstSeen *time.Time `json:"first_seen,omitempty"`
+	// Set when an existing version was published again, e.g. with a new tarball.
+	Republished bool `json:"republished,omitempty"`
+	// The subresource integrity string of the published artifact, e.g. "sha512-...".
+	Integrity string `json:"integrity,omitempty"`
+	// Set when the release carries a signature or attestation made by its publisher.
+	Signed bool `json:"signed,omitempty"`
+	// The predicate types of attestations published with the release, e.g. SLSA provenance.
+	Attestations []string `json:"attestations,omitempty"`
+	// The https URL of the source repository of the package, when provided by the registry.
+	SourceRepo string `json:"source_repo,omitempty"`
+	// The number of downloads of the package in the last week, when enrichment with download
+	// counts is enabled and the count could be fetched.
+	Downloads int `json:"downloads,omitempty"`
+	// Set when the version runs scripts on install, e.g. a preinstall or postinstall script,
+	// when detection of install scripts is enabled.
+	HasInstallScripts bool `json:"has_install_scripts,omitempty"`
+	// Set when the version has been deprecated by its maintainers, with the message given
+	// for the deprecation, when detection of deprecations is enabled.
+	Deprecated         bool   `json:"deprecated,omitempty"`
+	DeprecationMessage string `json:"deprecation_message,omitempty"`
+	// The unpacked size in bytes and the number of files of the published tarball, when
+	// enrichment with tarball stats is enabled and the registry reports them.
+	UnpackedSize int64 `json:"unpacked_size,omitempty"`
+	FileCount    int   `json:"file_count,omitempty"`
+	// The license declared by the version, normalized toward an SPDX identifier, when
+	// enrichment with licenses is enabled and the version declares one.
+	License string `json:"license,omitempty"`
+	// The advisories affecting the version, when attached by a vulnerability enricher.
+	Vulnerabilities []Vulnerability `json:"vulnerabilities,omitempty"`
+	// Static labels identifying the deployment which published the package, e.g. its
+	// environment or region. Set by the scheduler from configuration.
+	Labels map[string]string `json:"labels,omitempty"`
+	// The unmodified metadata of the version returned by the registry, e.g. the version's
+	// manifest from the npm package document, when inclusion of raw responses is enabled.
+	Raw json.RawMessage `json:"raw,omitempty"`
+	// The poll cycle and the time of the poll which produced the package, set by the
+	// scheduler. These are not part of the package payload, they are only published in the
+	// envelope of publishers configured with one.
+	CycleID  string    `json:"-"`
+	PolledAt time.Time `json:"-"`
 }
 
 type PackagePollError struct {
@@ -49,26 +332,139 @@ func (err PackagePollError) Error() string {
 	return fmt.Sprintf("Polling for package %s returned error: %v", err.Name, err.Err)
 }
 
-func NewPackage(created time.Time, name, version, feed string) *Package {
+func NewPackage(created time.Time, name, version, feed string, ecosystem Ecosystem) *Package {
 	return &Package{
 		Name:        name,
 		Version:     version,
 		CreatedDate: created,
 		Type:        feed,
 		SchemaVer:   schemaVer,
+		Ecosystem:   ecosystem,
 	}
 }
 
+// CutoffMode defines how packages created exactly at a cutoff are treated.
+type CutoffMode int
+
+const (
+	// CutoffInclusive retains packages created exactly at the cutoff.
+	CutoffInclusive CutoffMode = iota
+	// CutoffExclusive drops packages created exactly at the cutoff.
+	CutoffExclusive
+)
+
+// ApplyCutoff removes packages created before the cutoff, packages created
+// exactly at the cutoff are retained (inclusive boundary).
 func ApplyCutoff(pkgs []*Package, cutoff time.Time) []*Package {
+	return ApplyCutoffWithMode(pkgs, cutoff, CutoffInclusive)
+}
+
+// ApplyCutoffExclusive removes packages created at or before the cutoff. This should
+// be used when the cutoff is carried over from a previous poll, to ensure a package
+// created exactly at the boundary isn't emitted a second time.
+func ApplyCutoffExclusive(pkgs []*Package, cutoff time.Time) []*Package {
+	return ApplyCutoffWithMode(pkgs, cutoff, CutoffExclusive)
+}
+
+// ApplyCutoffWithMode removes packages created before the cutoff, with the treatment of
+// packages created exactly at the cutoff being defined by mode.
+func ApplyCutoffWithMode(pkgs []*Package, cutoff time.Time, mode CutoffMode) []*Package {
+	filteredPackages := []*Package{}
+	for _, pkg := range pkgs {
+		if pkg.CreatedDate.After(cutoff) {
+			filteredPackages = append(filteredPackages, pkg)
+		} else if pkg.CreatedDate.Equal(cutoff) && mode == CutoffInclusive {
+			filteredPackages = append(filteredPackages, pkg)
+		}
+	}
+	return filteredPackages
+}
+
+// ApplyMinAge removes packages created less than minAge before now, these packages should
+// be processed by a later poll once their registry data is stable. When a minimum age is
+// used the cutoff provided to a feed should also be shifted back by minAge, so that deferred
+// packages are within the cutoff of the later poll.
+func ApplyMinAge(pkgs []*Package, minAge time.Duration, now time.Time) []*Package {
+	if minAge <= 0 {
+		return pkgs
+	}
+	maxCreated := now.Add(-minAge)
 	filteredPackages := []*Package{}
 	for _, pkg := range pkgs {
-		if !pkg.CreatedDate.Before(cutoff) {
+		if !pkg.CreatedDate.After(maxCreated) {
 			filteredPackages = append(filteredPackages, pkg)
 		}
 	}
 	return filteredPackages
 }
 
+// SortOrder defines the direction packages are sorted in by their CreatedDate.
+type SortOrder int
+
+const (
+	// NewestFirst sorts the most recently created packages first.
+	NewestFirst SortOrder = iota
+	// OldestFirst sorts the least recently created packages first.
+	OldestFirst
+)
+
+// SortByCreatedDate sorts packages in order of most recent CreatedDate. Packages sharing a
+// CreatedDate are ordered by Type, Name then Version, so that the order is reproducible
+// regardless of the order packages were fetched in.
+func SortByCreatedDate(pkgs []*Package) {
+	SortByCreatedDateWithOrder(pkgs, NewestFirst)
+}
+
+// SortByCreatedDateWithOrder sorts packages by their CreatedDate in the direction defined by
+// order. Packages sharing a CreatedDate are ordered by Type, Name then Version in either
+// direction.
+func SortByCreatedDateWithOrder(pkgs []*Package, order SortOrder) {
+	sort.SliceStable(pkgs, func(i, j int) bool {
+		a, b := pkgs[i], pkgs[j]
+		switch {
+		case !a.CreatedDate.Equal(b.CreatedDate):
+			if order == OldestFirst {
+				return a.CreatedDate.Before(b.CreatedDate)
+			}
+			return b.CreatedDate.Before(a.CreatedDate)
+		case a.Type != b.Type:
+			return a.Type < b.Type
+		case a.Name != b.Name:
+			return a.Name < b.Name
+		default:
+			return a.Version < b.Version
+		}
+	})
+}
+
+// LatestVersions returns the most recently created version of each package in pkgs, which
+// must be sorted in order of most recent CreatedDate.
+func LatestVersions(pkgs []*Package) []*Package {
+	latest := []*Package{}
+	seen := map[string]bool{}
+	for _, pkg := range pkgs {
+		if seen[pkg.Name] {
+			continue
+		}
+		seen[pkg.Name] = true
+		latest = append(latest, pkg)
+	}
+	return latest
+}
+
+// ApplyMaxPackages truncates pkgs to the maxPackages most recently created packages,
+// returning the remaining packages and the number of packages dropped. A maxPackages of
+// zero or less means unlimited.
+func ApplyMaxPackages(pkgs []*Package, maxPackages int) ([]*Package, int) {
+	if maxPackages <= 0 || len(pkgs) <= maxPackages {
+		return pkgs, 0
+	}
+	sorted := make([]*Package, len(pkgs))
+	copy(sorted, pkgs)
+	SortByCreatedDate(sorted)
+	return sorted[:maxPackages], len(pkgs) - maxPackages
+}
+
 func (err UnsupportedOptionError) Error() string {
 	return fmt.Sprintf("unsupported option `%v` supplied to %v feed", err.Option, err.Feed)
 }
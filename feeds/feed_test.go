@@ -1,7 +1,9 @@
 package feeds
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -44,6 +46,55 @@ func TestValidSchema(t *testing.T) {
 	}
 }
 
+func TestValidSchemaOptionalFields(t *testing.T) {
+	t.Parallel()
+
+	pkg := dummyPackage
+	pkg.Republished = true
+	pkg.Integrity = "sha1-LNJvAl5ZLT5IYDgcYk4/aQRGbvM="
+	pkg.Signed = true
+	pkg.Attestations = []string{"https://slsa.dev/provenance/v1"}
+	pkg.SourceRepo = "https://github.com/foo-user/bar-package"
+	pkg.Downloads = 1234
+	pkg.HasInstallScripts = true
+	pkg.Deprecated = true
+	pkg.DeprecationMessage = "Use bar-package instead"
+	pkg.UnpackedSize = 52480
+	pkg.FileCount = 12
+	pkg.License = "MIT"
+	pkg.Raw = []byte(`{"name": "foo", "dist-tags": {"latest": "1.0.0"}}`)
+	pkg.Vulnerabilities = []Vulnerability{{
+		ID:        "CVE-2021-23337",
+		Title:     "[CVE-2021-23337] Command Injection",
+		CVE:       "CVE-2021-23337",
+		CVSSScore: 7.2,
+		Reference: "https://ossindex.sonatype.org/vulnerability/CVE-2021-23337",
+	}}
+	pkg.Ecosystem = EcosystemNPM
+	pkg.Labels = map[string]string{"env": "prod"}
+	firstSeen := time.Now().UTC()
+	pkg.FirstSeen = &firstSeen
+	result, err := gojsonschema.Validate(schemaLoader, gojsonschema.NewGoLoader(pkg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid() {
+		t.Fatalf("The Package json with optional fields is not valid against the current schema: %v", result.Errors())
+	}
+}
+
+func TestFirstSeenOmitted(t *testing.T) {
+	t.Parallel()
+
+	data, err := json.Marshal(dummyPackage)
+	if err != nil {
+		t.Fatalf("Failed to marshal package: %v", err)
+	}
+	if strings.Contains(string(data), "first_seen") {
+		t.Errorf("Package without a first seen time was marshalled as %s", data)
+	}
+}
+
 func TestInvalidSchema(t *testing.T) {
 	t.Parallel()
 
@@ -75,3 +126,180 @@ func TestInvalidSchema(t *testing.T) {
 		t.Fatalf("Non-conformant field format incorrectly validated")
 	}
 }
+
+func TestApplyCutoffBoundary(t *testing.T) {
+	t.Parallel()
+
+	cutoff := time.Date(2021, 4, 20, 14, 30, 0, 0, time.UTC)
+	before := NewPackage(cutoff.Add(-time.Nanosecond), "before", "1.0", "foo", "")
+	at := NewPackage(cutoff, "at", "1.0", "foo", "")
+	after := NewPackage(cutoff.Add(time.Nanosecond), "after", "1.0", "foo", "")
+	pkgs := []*Package{before, at, after}
+
+	tests := []struct {
+		name     string
+		mode     CutoffMode
+		expected []*Package
+	}{
+		{
+			name:     "inclusive",
+			mode:     CutoffInclusive,
+			expected: []*Package{at, after},
+		},
+		{
+			name:     "exclusive",
+			mode:     CutoffExclusive,
+			expected: []*Package{after},
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			filtered := ApplyCutoffWithMode(pkgs, cutoff, test.mode)
+			if len(filtered) != len(test.expected) {
+				t.Fatalf("ApplyCutoffWithMode returned %v packages when %v were expected",
+					len(filtered), len(test.expected))
+			}
+			for i, pkg := range test.expected {
+				if filtered[i] != pkg {
+					t.Errorf("Unexpected package `%s` found in place of expected `%s`", filtered[i].Name, pkg.Name)
+				}
+			}
+		})
+	}
+
+	if len(ApplyCutoff(pkgs, cutoff)) != 2 {
+		t.Errorf("ApplyCutoff did not retain the package created exactly at the cutoff")
+	}
+	if len(ApplyCutoffExclusive(pkgs, cutoff)) != 1 {
+		t.Errorf("ApplyCutoffExclusive did not drop the package created exactly at the cutoff")
+	}
+}
+
+func TestApplyMinAge(t *testing.T) {
+	t.Parallel()
+
+	minAge := 5 * time.Minute
+	firstPoll := time.Date(2021, 4, 20, 14, 30, 0, 0, time.UTC)
+	secondPoll := firstPoll.Add(10 * time.Minute)
+	fresh := NewPackage(firstPoll.Add(-time.Minute), "fresh", "1.0", "foo", "")
+	stable := NewPackage(firstPoll.Add(-10*time.Minute), "stable", "1.0", "foo", "")
+	pkgs := []*Package{fresh, stable}
+
+	// The fresh package is younger than the minimum age so is deferred.
+	filtered := ApplyMinAge(ApplyCutoff(pkgs, firstPoll.Add(-time.Hour-minAge)), minAge, firstPoll)
+	if len(filtered) != 1 || filtered[0] != stable {
+		t.Fatalf("ApplyMinAge did not defer the freshly created package")
+	}
+
+	// The following poll uses the first poll as its cutoff, shifted back by the minimum age.
+	filtered = ApplyMinAge(ApplyCutoff(pkgs, firstPoll.Add(-minAge)), minAge, secondPoll)
+	if len(filtered) != 1 || filtered[0] != fresh {
+		t.Fatalf("The deferred package was not included in the following poll")
+	}
+
+	if len(ApplyMinAge(pkgs, 0, firstPoll)) != len(pkgs) {
+		t.Errorf("ApplyMinAge filtered packages when no minimum age was provided")
+	}
+}
+
+func TestSortByCreatedDateTies(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)
+	foo := NewPackage(base, "Foo", "1.0", "test", "")
+	bar := NewPackage(base, "Bar", "2.0", "test", "")
+	barPatch := NewPackage(base, "Bar", "1.0", "test", "")
+	newest := NewPackage(base.Add(time.Minute), "Qux", "1.0", "test", "")
+
+	// Packages sharing a created date are ordered the same regardless of their initial order.
+	for _, pkgs := range [][]*Package{
+		{foo, bar, barPatch, newest},
+		{barPatch, newest, foo, bar},
+	} {
+		SortByCreatedDate(pkgs)
+		expected := []*Package{newest, barPatch, bar, foo}
+		for i := range pkgs {
+			if pkgs[i] != expected[i] {
+				t.Errorf("SortByCreatedDate placed %v@%v at %v when %v@%v was expected",
+					pkgs[i].Name, pkgs[i].Version, i, expected[i].Name, expected[i].Version)
+			}
+		}
+	}
+}
+
+func TestSortByCreatedDateWithOrder(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)
+	foo := NewPackage(base, "Foo", "1.0", "npm", "")
+	bar := NewPackage(base, "Bar", "1.0", "pypi", "")
+	newest := NewPackage(base.Add(time.Minute), "Qux", "1.0", "npm", "")
+
+	// Packages sharing a created date are ordered by feed in either direction.
+	tests := []struct {
+		name     string
+		order    SortOrder
+		expected []*Package
+	}{
+		{name: "newest first", order: NewestFirst, expected: []*Package{newest, foo, bar}},
+		{name: "oldest first", order: OldestFirst, expected: []*Package{foo, bar, newest}},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			pkgs := []*Package{bar, newest, foo}
+			SortByCreatedDateWithOrder(pkgs, test.order)
+			for i := range pkgs {
+				if pkgs[i] != test.expected[i] {
+					t.Errorf("SortByCreatedDateWithOrder placed %v@%v at %v when %v@%v was expected",
+						pkgs[i].Name, pkgs[i].Version, i, test.expected[i].Name, test.expected[i].Version)
+				}
+			}
+		})
+	}
+}
+
+func TestLatestVersions(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)
+	pkgs := []*Package{
+		NewPackage(base.Add(2*time.Minute), "Foo", "1.1", "test", ""),
+		NewPackage(base.Add(time.Minute), "Bar", "1.0", "test", ""),
+		NewPackage(base, "Foo", "1.0", "test", ""),
+	}
+	latest := LatestVersions(pkgs)
+	if len(latest) != 2 || latest[0].Version != "1.1" || latest[1].Name != "Bar" {
+		t.Errorf("LatestVersions did not keep only the newest version of each package: %v", latest)
+	}
+}
+
+func TestApplyMaxPackages(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)
+	pkgs := []*Package{
+		NewPackage(base.Add(time.Minute), "Foo", "1.0", "test", ""),
+		NewPackage(base.Add(3*time.Minute), "Bar", "1.0", "test", ""),
+		NewPackage(base, "Baz", "1.0", "test", ""),
+		NewPackage(base.Add(2*time.Minute), "Qux", "1.0", "test", ""),
+	}
+
+	kept, dropped := ApplyMaxPackages(pkgs, 2)
+	if dropped != 2 {
+		t.Errorf("ApplyMaxPackages dropped %v packages when 2 were expected", dropped)
+	}
+	if len(kept) != 2 || kept[0].Name != "Bar" || kept[1].Name != "Qux" {
+		t.Fatalf("ApplyMaxPackages did not keep the newest packages: %v", kept)
+	}
+
+	kept, dropped = ApplyMaxPackages(pkgs, 0)
+	if dropped != 0 || len(kept) != len(pkgs) {
+		t.Errorf("ApplyMaxPackages with no maximum dropped %v packages", dropped)
+	}
+}
@@ -0,0 +1,219 @@
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/ossf/package-feeds/events"
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/utils"
+)
+
+const (
+	FeedName           = "gitea"
+	packagesPathFormat = "/api/v1/packages/%s"
+
+	// The number of package versions requested per page, the maximum allowed by default.
+	pageLimit = 50
+	// The maximum number of pages requested by a single poll.
+	maxPages = 20
+)
+
+var (
+	httpClient            = utils.NewHTTPClient(10 * time.Second)
+	errMissingRegistryURL = errors.New("registry_url must be provided for the gitea feed")
+	errMissingOwner       = errors.New("owner must be provided for the gitea feed")
+)
+
+// Maps Gitea package types to the ecosystem of their packages, types without an
+// ecosystem such as container images are emitted without one.
+var ecosystems = map[string]feeds.Ecosystem{
+	"cargo":    feeds.EcosystemCratesIO,
+	"composer": feeds.EcosystemPackagist,
+	"conda":    feeds.EcosystemConda,
+	"go":       feeds.EcosystemGo,
+	"npm":      feeds.EcosystemNPM,
+	"nuget":    feeds.EcosystemNuGet,
+	"pub":      feeds.EcosystemPub,
+	"pypi":     feeds.EcosystemPyPI,
+	"rubygems": feeds.EcosystemRubyGems,
+	"swift":    feeds.EcosystemSwiftURL,
+}
+
+// Package is a package version as listed by the Gitea package API.
+type Package struct {
+	Type      string    `json:"type"`
+	Name      string    `json:"name"`
+	Version   string    `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Feed struct {
+	baseURL     string
+	owner       string
+	token       string
+	packageType string
+	packages    *[]string
+	options     feeds.FeedOptions
+}
+
+func init() { //nolint:gochecknoinits
+	feeds.Register(FeedName, func(options feeds.FeedOptions, _ *events.Handler) (feeds.ScheduledFeed, error) {
+		return New(options)
+	})
+}
+
+func New(feedOptions feeds.FeedOptions) (*Feed, error) {
+	if feedOptions.RegistryURL == "" {
+		return nil, errMissingRegistryURL
+	}
+	if feedOptions.Owner == "" {
+		return nil, errMissingOwner
+	}
+	return &Feed{
+		baseURL:     feedOptions.RegistryURL,
+		owner:       feedOptions.Owner,
+		token:       feedOptions.RegistryToken,
+		packageType: feedOptions.PackageType,
+		packages:    feedOptions.Packages,
+		options:     feedOptions,
+	}, nil
+}
+
+// Fetches a page of the package versions of the owner, most recently created first. The
+// versions are filtered by the package type of the feed and by query when not empty.
+func (feed *Feed) fetchPage(ctx context.Context, query string, page int) ([]*Package, error) {
+	pageURL, err := utils.URLPathJoin(feed.baseURL, fmt.Sprintf(packagesPathFormat, url.PathEscape(feed.owner)))
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("page", strconv.Itoa(page))
+	params.Set("limit", strconv.Itoa(pageLimit))
+	if feed.packageType != "" {
+		params.Set("type", feed.packageType)
+	}
+	if query != "" {
+		params.Set("q", query)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if feed.token != "" {
+		req.Header.Set("Authorization", "token "+feed.token)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	err = utils.CheckResponseStatus(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch gitea packages: %w", err)
+	}
+
+	pkgs := []*Package{}
+	err = json.NewDecoder(resp.Body).Decode(&pkgs)
+	if err != nil {
+		return nil, err
+	}
+	return pkgs, nil
+}
+
+// Fetches the package versions created after the cutoff, requesting pages until a version
+// created before the cutoff is found.
+func (feed *Feed) fetchVersions(ctx context.Context, query string, cutoff time.Time) ([]*Package, error) {
+	versions := []*Package{}
+	for page := 1; page <= maxPages; page++ {
+		pkgs, err := feed.fetchPage(ctx, query, page)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, pkgs...)
+		if len(pkgs) < pageLimit || pkgs[len(pkgs)-1].CreatedAt.Before(cutoff) {
+			break
+		}
+	}
+	return versions, nil
+}
+
+// Fetches the versions of a single package, the package API matches the query against
+// package names as a substring so other packages are filtered out.
+func (feed *Feed) fetchPackage(ctx context.Context, name string, cutoff time.Time) ([]*Package, error) {
+	versions, err := feed.fetchVersions(ctx, name, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	pkgs := []*Package{}
+	for _, version := range versions {
+		if version.Name == name {
+			pkgs = append(pkgs, version)
+		}
+	}
+	return pkgs, nil
+}
+
+func (feed *Feed) Latest(ctx context.Context, cutoff time.Time) ([]*feeds.Package, []error) {
+	versions := []*Package{}
+	errs := []error{}
+	if feed.packages == nil {
+		var err error
+		versions, err = feed.fetchVersions(ctx, "", cutoff)
+		if err != nil {
+			return nil, []error{err}
+		}
+	} else {
+		type result struct {
+			pkgs []*Package
+			err  error
+		}
+		results := make(chan result)
+		for _, name := range *feed.packages {
+			go func(name string) {
+				pkgs, err := feed.fetchPackage(ctx, name, cutoff)
+				if err != nil {
+					err = feeds.PackagePollError{Name: name, Err: err}
+				}
+				results <- result{pkgs: pkgs, err: err}
+			}(name)
+		}
+		for i := 0; i < len(*feed.packages); i++ {
+			r := <-results
+			if r.err != nil {
+				errs = append(errs, r.err)
+				continue
+			}
+			versions = append(versions, r.pkgs...)
+		}
+		if len(versions) == 0 && len(errs) > 0 {
+			return nil, append(errs, feeds.ErrNoPackagesPolled)
+		}
+	}
+
+	pkgs := []*feeds.Package{}
+	for _, version := range versions {
+		pkg := feeds.NewPackage(version.CreatedAt, version.Name, version.Version, FeedName, ecosystems[version.Type])
+		pkgs = append(pkgs, pkg)
+	}
+	return feeds.ApplyCutoff(pkgs, cutoff), errs
+}
+
+func (feed *Feed) GetName() string {
+	return FeedName
+}
+
+func (feed *Feed) GetFeedOptions() feeds.FeedOptions {
+	return feed.options
+}
+
+func (feed *Feed) GetBaseURL() string {
+	return feed.baseURL
+}
@@ -0,0 +1,150 @@
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/utils"
+	testutils "github.com/ossf/package-feeds/utils/test"
+)
+
+const packagesPath = "/api/v1/packages/my-org"
+
+var baseTime = time.Date(2021, 5, 11, 12, 0, 0, 0, time.UTC)
+
+func TestGiteaLatest(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		packagesPath: packagesResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	feed, err := New(feeds.FeedOptions{
+		RegistryURL:   srv.URL,
+		Owner:         "my-org",
+		RegistryToken: "s3cr3t",
+		PackageType:   "npm",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create gitea feed: %v", err)
+	}
+
+	// The cutoff is within the second page of versions.
+	cutoff := baseTime.Add(-60 * time.Minute).Add(-time.Second)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 61 {
+		t.Fatalf("Latest() produced %v packages instead of the expected 61", len(pkgs))
+	}
+	if pkgs[0].Name != "foo" || pkgs[0].Version != "1.0.0" || !pkgs[0].CreatedDate.Equal(baseTime) {
+		t.Errorf("Unexpected package %s@%s created %v in place of foo@1.0.0 created %v",
+			pkgs[0].Name, pkgs[0].Version, pkgs[0].CreatedDate, baseTime)
+	}
+	for _, pkg := range pkgs {
+		if pkg.Type != FeedName {
+			t.Errorf("Feed type not set correctly in gitea package following Latest()")
+		}
+		if pkg.Ecosystem != feeds.EcosystemNPM {
+			t.Errorf("Ecosystem not set correctly in gitea package following Latest()")
+		}
+	}
+}
+
+func TestGiteaLatestWithPackages(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		packagesPath: packagesResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	packages := []string{"foo", "missing"}
+	feed, err := New(feeds.FeedOptions{
+		RegistryURL:   srv.URL,
+		Owner:         "my-org",
+		RegistryToken: "s3cr3t",
+		Packages:      &packages,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create gitea feed: %v", err)
+	}
+
+	pkgs, errs := feed.Latest(context.Background(), baseTime.Add(-time.Hour))
+	if len(errs) != 1 {
+		t.Fatalf("feed.Latest returned %v errors when 1 was expected", len(errs))
+	}
+	var pollErr feeds.PackagePollError
+	isNotFound := errors.As(errs[0], &pollErr) && errors.Is(pollErr.Err, utils.ErrUnsuccessfulRequest)
+	if !isNotFound || pollErr.Name != "missing" {
+		t.Errorf("feed.Latest returned `%v` when a poll error for missing was expected", errs[0])
+	}
+	// Packages with names containing the query are not emitted.
+	if len(pkgs) != 1 || pkgs[0].Name != "foo" {
+		t.Fatalf("Latest() produced %v packages when only foo was expected", len(pkgs))
+	}
+}
+
+func TestGiteaRequiredOptions(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(feeds.FeedOptions{Owner: "my-org"})
+	if !errors.Is(err, errMissingRegistryURL) {
+		t.Errorf("New() returned `%v` when a missing registry url error was expected", err)
+	}
+	_, err = New(feeds.FeedOptions{RegistryURL: "https://gitea.example.com"})
+	if !errors.Is(err, errMissingOwner) {
+		t.Errorf("New() returned `%v` when a missing owner error was expected", err)
+	}
+}
+
+// Lists package versions created a minute apart, most recently created first. The first
+// version of each page is named foo and the others foo-N, queries for other names are not
+// found.
+func packagesResponse(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "token s3cr3t" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	query := r.URL.Query()
+	if q := query.Get("q"); q != "" && q != "foo" {
+		http.NotFound(w, r)
+		return
+	}
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || query.Get("limit") != strconv.Itoa(pageLimit) {
+		http.Error(w, "invalid pagination", http.StatusBadRequest)
+		return
+	}
+	pkgType := query.Get("type")
+	if pkgType == "" {
+		pkgType = "npm"
+	}
+	pkgs := []Package{}
+	for i := (page - 1) * pageLimit; i < page*pageLimit; i++ {
+		name := fmt.Sprintf("foo-%d", i)
+		if i == 0 {
+			name = "foo"
+		}
+		pkgs = append(pkgs, Package{
+			Type:      pkgType,
+			Name:      name,
+			Version:   "1.0.0",
+			CreatedAt: baseTime.Add(-time.Duration(i) * time.Minute),
+		})
+	}
+	if err := json.NewEncoder(w).Encode(pkgs); err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
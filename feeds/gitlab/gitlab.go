@@ -0,0 +1,303 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ossf/package-feeds/events"
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/utils"
+)
+
+const (
+	FeedName           = "gitlab"
+	packagesPathFormat = "/api/v4/projects/%s/packages"
+
+	defaultRegistryURL = "https://gitlab.com/"
+
+	// The number of package versions requested per page, the maximum allowed by GitLab.
+	perPage = 100
+	// The maximum number of pages requested by a single poll.
+	maxPages = 20
+
+	// The number of times a rate limited request is retried, and the maximum time waited
+	// for a rate limit to reset before each retry.
+	maxRateLimitRetries = 3
+	maxRateLimitWait    = time.Minute
+)
+
+var (
+	httpClient        = utils.NewHTTPClient(10 * time.Second)
+	errMissingProject = errors.New("project must be provided for the gitlab feed")
+)
+
+// Maps GitLab package types to the ecosystem of their packages, types without an
+// ecosystem such as generic packages are emitted without one.
+var ecosystems = map[string]feeds.Ecosystem{
+	"composer": feeds.EcosystemPackagist,
+	"golang":   feeds.EcosystemGo,
+	"maven":    feeds.EcosystemMaven,
+	"npm":      feeds.EcosystemNPM,
+	"nuget":    feeds.EcosystemNuGet,
+	"pypi":     feeds.EcosystemPyPI,
+	"rubygems": feeds.EcosystemRubyGems,
+}
+
+// Package is a package version as listed by the GitLab project packages API.
+type Package struct {
+	Name        string    `json:"name"`
+	Version     string    `json:"version"`
+	PackageType string    `json:"package_type"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type Feed struct {
+	baseURL     string
+	project     string
+	token       string
+	packageType string
+	packages    *[]string
+	options     feeds.FeedOptions
+	clock       feeds.Clock
+}
+
+func init() { //nolint:gochecknoinits
+	feeds.Register(FeedName, func(options feeds.FeedOptions, _ *events.Handler) (feeds.ScheduledFeed, error) {
+		return New(options)
+	})
+}
+
+func New(feedOptions feeds.FeedOptions) (*Feed, error) {
+	if feedOptions.Project == "" {
+		return nil, errMissingProject
+	}
+	baseURL := defaultRegistryURL
+	if feedOptions.RegistryURL != "" {
+		baseURL = feedOptions.RegistryURL
+	}
+	return &Feed{
+		baseURL:     baseURL,
+		project:     feedOptions.Project,
+		token:       feedOptions.RegistryToken,
+		packageType: feedOptions.PackageType,
+		packages:    feedOptions.Packages,
+		options:     feedOptions,
+		clock:       feeds.ClockOrReal(feedOptions.Clock),
+	}, nil
+}
+
+// Returns the URL of the first page of package versions of the project, most recently
+// created first. The versions are filtered by the package type of the feed and by name
+// when not empty.
+func (feed *Feed) packagesURL(name string) string {
+	// The project path is escaped as a single path segment, e.g. "my-group%2Fmy-project".
+	packagesPath := fmt.Sprintf(packagesPathFormat, url.PathEscape(feed.project))
+	params := url.Values{}
+	params.Set("order_by", "created_at")
+	params.Set("sort", "desc")
+	params.Set("per_page", strconv.Itoa(perPage))
+	if feed.packageType != "" {
+		params.Set("package_type", feed.packageType)
+	}
+	if name != "" {
+		params.Set("package_name", name)
+	}
+	return strings.TrimSuffix(feed.baseURL, "/") + packagesPath + "?" + params.Encode()
+}
+
+// Returns the time to wait before making further requests once the rate limit has been
+// reached, taken from the Retry-After or RateLimit-Reset headers and capped at
+// maxRateLimitWait.
+func rateLimitDelay(header http.Header, now time.Time) time.Duration {
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if reset, err := strconv.ParseInt(header.Get("RateLimit-Reset"), 10, 64); err == nil {
+		delay = time.Unix(reset, 0).Sub(now)
+	}
+	if delay < 0 {
+		return 0
+	}
+	if delay > maxRateLimitWait {
+		return maxRateLimitWait
+	}
+	return delay
+}
+
+// Waits for the delay, returning early with an error if the context is done.
+func wait(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Fetches a page of package versions, retrying requests which are rejected by the rate
+// limit once it has reset. The URL of the next page is returned, or an empty string if
+// this is the last page.
+func (feed *Feed) fetchPage(ctx context.Context, pageURL string) ([]*Package, string, error) {
+	var resp *http.Response
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+		if err != nil {
+			return nil, "", err
+		}
+		if feed.token != "" {
+			req.Header.Set("PRIVATE-TOKEN", feed.token)
+		}
+		resp, err = httpClient.Do(req)
+		if err != nil {
+			return nil, "", err
+		}
+		if resp.StatusCode != http.StatusTooManyRequests || attempt == maxRateLimitRetries {
+			break
+		}
+		resp.Body.Close()
+		if err := wait(ctx, rateLimitDelay(resp.Header, feed.clock.Now())); err != nil {
+			return nil, "", err
+		}
+	}
+	defer resp.Body.Close()
+
+	err := utils.CheckResponseStatus(resp)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch gitlab packages: %w", err)
+	}
+
+	pkgs := []*Package{}
+	err = json.NewDecoder(resp.Body).Decode(&pkgs)
+	if err != nil {
+		return nil, "", err
+	}
+	nextURL := nextLink(resp.Header.Get("Link"))
+	// Avoid being rejected by the rate limit when requesting the next page.
+	if nextURL != "" && resp.Header.Get("RateLimit-Remaining") == "0" {
+		if err := wait(ctx, rateLimitDelay(resp.Header, feed.clock.Now())); err != nil {
+			return nil, "", err
+		}
+	}
+	return pkgs, nextURL, nil
+}
+
+// Returns the URL of the next page from a Link header, e.g.
+// `<https://gitlab.com/api/v4/projects/1/packages?page=2>; rel="next"`.
+func nextLink(header string) string {
+	for _, link := range strings.Split(header, ",") {
+		parts := strings.Split(link, ";")
+		if len(parts) < 2 {
+			continue
+		}
+		for _, param := range parts[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(parts[0]), "<>")
+			}
+		}
+	}
+	return ""
+}
+
+// Fetches the package versions created after the cutoff, following the Link header until a
+// version created before the cutoff is found.
+func (feed *Feed) fetchVersions(ctx context.Context, name string, cutoff time.Time) ([]*Package, error) {
+	versions := []*Package{}
+	pageURL := feed.packagesURL(name)
+	for page := 0; page < maxPages && pageURL != ""; page++ {
+		pkgs, nextURL, err := feed.fetchPage(ctx, pageURL)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, pkgs...)
+		if len(pkgs) == 0 || pkgs[len(pkgs)-1].CreatedAt.Before(cutoff) {
+			break
+		}
+		pageURL = nextURL
+	}
+	return versions, nil
+}
+
+// Fetches the versions of a single package, the packages API matches the name as a
+// substring so other packages are filtered out.
+func (feed *Feed) fetchPackage(ctx context.Context, name string, cutoff time.Time) ([]*Package, error) {
+	versions, err := feed.fetchVersions(ctx, name, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	pkgs := []*Package{}
+	for _, version := range versions {
+		if version.Name == name {
+			pkgs = append(pkgs, version)
+		}
+	}
+	return pkgs, nil
+}
+
+func (feed *Feed) Latest(ctx context.Context, cutoff time.Time) ([]*feeds.Package, []error) {
+	versions := []*Package{}
+	errs := []error{}
+	if feed.packages == nil {
+		var err error
+		versions, err = feed.fetchVersions(ctx, "", cutoff)
+		if err != nil {
+			return nil, []error{err}
+		}
+	} else {
+		type result struct {
+			pkgs []*Package
+			err  error
+		}
+		results := make(chan result)
+		for _, name := range *feed.packages {
+			go func(name string) {
+				pkgs, err := feed.fetchPackage(ctx, name, cutoff)
+				if err != nil {
+					err = feeds.PackagePollError{Name: name, Err: err}
+				}
+				results <- result{pkgs: pkgs, err: err}
+			}(name)
+		}
+		for i := 0; i < len(*feed.packages); i++ {
+			r := <-results
+			if r.err != nil {
+				errs = append(errs, r.err)
+				continue
+			}
+			versions = append(versions, r.pkgs...)
+		}
+		if len(versions) == 0 && len(errs) > 0 {
+			return nil, append(errs, feeds.ErrNoPackagesPolled)
+		}
+	}
+
+	pkgs := []*feeds.Package{}
+	for _, version := range versions {
+		pkg := feeds.NewPackage(version.CreatedAt, version.Name, version.Version, FeedName,
+			ecosystems[version.PackageType])
+		pkgs = append(pkgs, pkg)
+	}
+	// Packages polled concurrently are returned in a consistent order.
+	feeds.SortByCreatedDate(pkgs)
+	return feeds.ApplyCutoff(pkgs, cutoff), errs
+}
+
+func (feed *Feed) GetName() string {
+	return FeedName
+}
+
+func (feed *Feed) GetFeedOptions() feeds.FeedOptions {
+	return feed.options
+}
+
+func (feed *Feed) GetBaseURL() string {
+	return feed.baseURL
+}
@@ -0,0 +1,252 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/utils"
+	testutils "github.com/ossf/package-feeds/utils/test"
+)
+
+const (
+	packagesPath = "/api/v4/projects/my-group/my-project/packages"
+	numVersions  = 150
+)
+
+var baseTime = time.Date(2021, 5, 11, 12, 0, 0, 0, time.UTC)
+
+func TestGitlabLatest(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		packagesPath: packagesResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	feed, err := New(feeds.FeedOptions{
+		RegistryURL:   srv.URL,
+		Project:       "my-group/my-project",
+		RegistryToken: "s3cr3t",
+		PackageType:   "npm",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create gitlab feed: %v", err)
+	}
+
+	// The cutoff is within the second page of versions.
+	cutoff := baseTime.Add(-120 * time.Minute).Add(-time.Second)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 121 {
+		t.Fatalf("Latest() produced %v packages instead of the expected 121", len(pkgs))
+	}
+	if pkgs[0].Name != "foo" || pkgs[0].Version != "1.0.0" || !pkgs[0].CreatedDate.Equal(baseTime) {
+		t.Errorf("Unexpected package %s@%s created %v in place of foo@1.0.0 created %v",
+			pkgs[0].Name, pkgs[0].Version, pkgs[0].CreatedDate, baseTime)
+	}
+	for _, pkg := range pkgs {
+		if pkg.Type != FeedName {
+			t.Errorf("Feed type not set correctly in gitlab package following Latest()")
+		}
+		if pkg.Ecosystem != feeds.EcosystemNPM {
+			t.Errorf("Ecosystem not set correctly in gitlab package following Latest()")
+		}
+	}
+}
+
+func TestGitlabLatestWithPackages(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		packagesPath: packagesResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	packages := []string{"foo", "missing"}
+	feed, err := New(feeds.FeedOptions{
+		RegistryURL:   srv.URL,
+		Project:       "my-group/my-project",
+		RegistryToken: "s3cr3t",
+		Packages:      &packages,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create gitlab feed: %v", err)
+	}
+
+	pkgs, errs := feed.Latest(context.Background(), baseTime.Add(-time.Hour))
+	if len(errs) != 1 {
+		t.Fatalf("feed.Latest returned %v errors when 1 was expected", len(errs))
+	}
+	var pollErr feeds.PackagePollError
+	isNotFound := errors.As(errs[0], &pollErr) && errors.Is(pollErr.Err, utils.ErrUnsuccessfulRequest)
+	if !isNotFound || pollErr.Name != "missing" {
+		t.Errorf("feed.Latest returned `%v` when a poll error for missing was expected", errs[0])
+	}
+	// Packages with names containing the query are not emitted.
+	if len(pkgs) != 1 || pkgs[0].Name != "foo" {
+		t.Fatalf("Latest() produced %v packages when only foo was expected", len(pkgs))
+	}
+}
+
+func TestGitlabRateLimited(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	requests := 0
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		packagesPath: func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			requests++
+			limited := requests == 1
+			mu.Unlock()
+			if limited {
+				w.Header().Set("RateLimit-Remaining", "0")
+				w.Header().Set("RateLimit-Reset", strconv.FormatInt(time.Now().Unix(), 10))
+				http.Error(w, "rate limited", http.StatusTooManyRequests)
+				return
+			}
+			packagesResponse(w, r)
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	feed, err := New(feeds.FeedOptions{
+		RegistryURL:   srv.URL,
+		Project:       "my-group/my-project",
+		RegistryToken: "s3cr3t",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create gitlab feed: %v", err)
+	}
+
+	// The rate limited request is retried once the rate limit resets.
+	pkgs, errs := feed.Latest(context.Background(), baseTime.Add(-time.Minute))
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 2 {
+		t.Fatalf("Latest() produced %v packages instead of the expected 2", len(pkgs))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != 2 {
+		t.Errorf("%v requests were made when 2 were expected", requests)
+	}
+}
+
+func TestGitlabRequiredOptions(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(feeds.FeedOptions{})
+	if !errors.Is(err, errMissingProject) {
+		t.Errorf("New() returned `%v` when a missing project error was expected", err)
+	}
+}
+
+func TestRateLimitDelay(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1620000000, 0)
+	tests := []struct {
+		header   http.Header
+		expected time.Duration
+	}{
+		{http.Header{"Retry-After": []string{"5"}}, 5 * time.Second},
+		{http.Header{"Ratelimit-Reset": []string{"1620000010"}}, 10 * time.Second},
+		{http.Header{"Ratelimit-Reset": []string{"1619999990"}}, 0},
+		{http.Header{"Ratelimit-Reset": []string{"1620003600"}}, maxRateLimitWait},
+		{http.Header{}, 0},
+	}
+	for _, test := range tests {
+		if delay := rateLimitDelay(test.header, now); delay != test.expected {
+			t.Errorf("rateLimitDelay(%v) returned %v instead of %v", test.header, delay, test.expected)
+		}
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	t.Parallel()
+
+	header := `<https://gitlab.com/api/v4/projects/1/packages?page=1>; rel="first", ` +
+		`<https://gitlab.com/api/v4/projects/1/packages?page=3>; rel="next", ` +
+		`<https://gitlab.com/api/v4/projects/1/packages?page=5>; rel="last"`
+	if next := nextLink(header); next != "https://gitlab.com/api/v4/projects/1/packages?page=3" {
+		t.Errorf("nextLink() returned %q instead of the link to page 3", next)
+	}
+	if next := nextLink(`<https://gitlab.com/api/v4/projects/1/packages?page=1>; rel="first"`); next != "" {
+		t.Errorf("nextLink() returned %q when there is no next page", next)
+	}
+}
+
+// Lists package versions created a minute apart, most recently created first, linking to
+// the next page. The first version is named foo and the others foo-N, queries for other
+// names are not found.
+func packagesResponse(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("PRIVATE-TOKEN") != "s3cr3t" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !strings.Contains(r.URL.EscapedPath(), "my-group%2Fmy-project") {
+		http.Error(w, "project path not escaped", http.StatusBadRequest)
+		return
+	}
+	query := r.URL.Query()
+	if name := query.Get("package_name"); name != "" && name != "foo" {
+		http.NotFound(w, r)
+		return
+	}
+	if query.Get("order_by") != "created_at" || query.Get("sort") != "desc" ||
+		query.Get("per_page") != strconv.Itoa(perPage) {
+		http.Error(w, "invalid ordering", http.StatusBadRequest)
+		return
+	}
+	page := 1
+	if p := query.Get("page"); p != "" {
+		var err error
+		if page, err = strconv.Atoi(p); err != nil {
+			http.Error(w, "invalid page", http.StatusBadRequest)
+			return
+		}
+	}
+	pkgType := query.Get("package_type")
+	if pkgType == "" {
+		pkgType = "npm"
+	}
+	pkgs := []Package{}
+	for i := (page - 1) * perPage; i < page*perPage && i < numVersions; i++ {
+		name := fmt.Sprintf("foo-%d", i)
+		if i == 0 {
+			name = "foo"
+		}
+		pkgs = append(pkgs, Package{
+			Name:        name,
+			Version:     "1.0.0",
+			PackageType: pkgType,
+			CreatedAt:   baseTime.Add(-time.Duration(i) * time.Minute),
+		})
+	}
+	if page*perPage < numVersions {
+		query.Set("page", strconv.Itoa(page+1))
+		next := fmt.Sprintf("http://%s%s?%s", r.Host, r.URL.EscapedPath(), query.Encode())
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next))
+	}
+	w.Header().Set("RateLimit-Remaining", "0")
+	w.Header().Set("RateLimit-Reset", strconv.FormatInt(time.Now().Unix(), 10))
+	if err := json.NewEncoder(w).Encode(pkgs); err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
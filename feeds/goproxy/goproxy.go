@@ -1,13 +1,15 @@
 package goproxy
 
 import (
-	"bufio"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"net/http"
+	"io"
 	"net/url"
+	"strconv"
 	"time"
 
+	"github.com/ossf/package-feeds/events"
 	"github.com/ossf/package-feeds/feeds"
 	"github.com/ossf/package-feeds/utils"
 )
@@ -15,9 +17,12 @@ import (
 const (
 	FeedName  = "goproxy"
 	indexPath = "/index"
+
+	// The maximum number of entries returned by a single request to the index.
+	indexPageSize = 2000
 )
 
-var httpClient = &http.Client{Timeout: 10 * time.Second}
+var httpClient = utils.NewHTTPClient(10 * time.Second)
 
 type PackageJSON struct {
 	Path      string `json:"Path"`
@@ -31,7 +36,42 @@ type Package struct {
 	Version      string
 }
 
-func fetchPackages(baseURL string, since time.Time) ([]Package, error) {
+// Fetches the packages added to the index since the given time, requesting further pages
+// until the index is exhausted or, if until is set, a page reaches past until.
+func fetchPackages(ctx context.Context, baseURL string, since, until time.Time, pageSize int) ([]Package, error) {
+	packages := []Package{}
+	seen := map[string]bool{}
+	for {
+		page, err := fetchPage(ctx, baseURL, since, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, pkg := range page {
+			// Pages start at the timestamp of the last entry of the previous page, so
+			// entries at the boundary are returned twice.
+			key := pkg.Title + "@" + pkg.Version
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			packages = append(packages, pkg)
+		}
+		if len(page) < pageSize {
+			return packages, nil
+		}
+		next := page[len(page)-1].ModifiedDate
+		if !until.IsZero() && !next.Before(until) {
+			return packages, nil
+		}
+		if !next.After(since) {
+			// A page of entries sharing a timestamp can't be paged past.
+			return packages, nil
+		}
+		since = next
+	}
+}
+
+func fetchPage(ctx context.Context, baseURL string, since time.Time, pageSize int) ([]Package, error) {
 	var packages []Package
 	indexURL, err := utils.URLPathJoin(baseURL, indexPath)
 	if err != nil {
@@ -43,9 +83,10 @@ func fetchPackages(baseURL string, since time.Time) ([]Package, error) {
 	}
 	params := url.Values{}
 	params.Add("since", since.Format(time.RFC3339))
+	params.Add("limit", strconv.Itoa(pageSize))
 	pkgURL.RawQuery = params.Encode()
 
-	resp, err := httpClient.Get(pkgURL.String())
+	resp, err := utils.Get(ctx, httpClient, pkgURL.String())
 	if err != nil {
 		return nil, err
 	}
@@ -56,10 +97,14 @@ func fetchPackages(baseURL string, since time.Time) ([]Package, error) {
 		return nil, fmt.Errorf("failed to fetch goproxy package data: %w", err)
 	}
 
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
+	// The index is streamed as newline delimited JSON.
+	decoder := utils.NewNDJSONDecoder(resp.Body)
+	for {
 		var packageJSON PackageJSON
-		err = json.Unmarshal([]byte(scanner.Text()), &packageJSON)
+		err = decoder.Decode(ctx, &packageJSON)
+		if errors.Is(err, io.EOF) {
+			break
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -75,16 +120,19 @@ func fetchPackages(baseURL string, since time.Time) ([]Package, error) {
 		}
 		packages = append(packages, pkg)
 	}
-	err = scanner.Err()
-	if err != nil {
-		return nil, err
-	}
 	return packages, nil
 }
 
 type Feed struct {
-	baseURL string
-	options feeds.FeedOptions
+	baseURL  string
+	pageSize int
+	options  feeds.FeedOptions
+}
+
+func init() { //nolint:gochecknoinits
+	feeds.Register(FeedName, func(options feeds.FeedOptions, _ *events.Handler) (feeds.ScheduledFeed, error) {
+		return New(options)
+	})
 }
 
 func New(feedOptions feeds.FeedOptions) (*Feed, error) {
@@ -95,23 +143,38 @@ func New(feedOptions feeds.FeedOptions) (*Feed, error) {
 		}
 	}
 	return &Feed{
-		baseURL: "https://index.golang.org/",
-		options: feedOptions,
+		baseURL:  "https://index.golang.org/",
+		pageSize: indexPageSize,
+		options:  feedOptions,
 	}, nil
 }
 
-func (feed Feed) Latest(cutoff time.Time) ([]*feeds.Package, []error) {
+func (feed Feed) Latest(ctx context.Context, cutoff time.Time) ([]*feeds.Package, []error) {
 	pkgs := []*feeds.Package{}
-	packages, err := fetchPackages(feed.baseURL, cutoff)
+	packages, err := fetchPackages(ctx, feed.baseURL, cutoff, time.Time{}, feed.pageSize)
 	if err != nil {
 		return pkgs, []error{err}
 	}
+	pkgs = feeds.ApplyCutoff(toPackages(packages), cutoff)
+	return pkgs, []error{}
+}
+
+// Between returns the packages added to the index within the window, the index can be
+// paged through from any time so the window may be arbitrarily far in the past.
+func (feed Feed) Between(ctx context.Context, from, to time.Time) ([]*feeds.Package, []error) {
+	packages, err := fetchPackages(ctx, feed.baseURL, from, to, feed.pageSize)
+	if err != nil {
+		return []*feeds.Package{}, []error{err}
+	}
+	return feeds.ApplyWindow(toPackages(packages), from, to), []error{}
+}
+
+func toPackages(packages []Package) []*feeds.Package {
+	pkgs := []*feeds.Package{}
 	for _, pkg := range packages {
-		pkg := feeds.NewPackage(pkg.ModifiedDate, pkg.Title, pkg.Version, FeedName)
-		pkgs = append(pkgs, pkg)
+		pkgs = append(pkgs, feeds.NewPackage(pkg.ModifiedDate, pkg.Title, pkg.Version, FeedName, feeds.EcosystemGo))
 	}
-	pkgs = feeds.ApplyCutoff(pkgs, cutoff)
-	return pkgs, []error{}
+	return pkgs
 }
 
 func (feed Feed) GetName() string {
@@ -121,3 +184,7 @@ func (feed Feed) GetName() string {
 func (feed Feed) GetFeedOptions() feeds.FeedOptions {
 	return feed.options
 }
+
+func (feed Feed) GetBaseURL() string {
+	return feed.baseURL
+}
@@ -1,8 +1,12 @@
 package goproxy
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -26,7 +30,7 @@ func TestGoProxyLatest(t *testing.T) {
 	feed.baseURL = srv.URL
 
 	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
-	pkgs, errs := feed.Latest(cutoff)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
 	if len(errs) != 0 {
 		t.Fatalf("feed.Latest returned error: %v", err)
 	}
@@ -48,6 +52,132 @@ func TestGoProxyLatest(t *testing.T) {
 		if p.Type != FeedName {
 			t.Errorf("Feed type not set correctly in goproxy package following Latest()")
 		}
+		if p.Ecosystem != feeds.EcosystemGo {
+			t.Errorf("Ecosystem not set correctly in goproxy package following Latest()")
+		}
+	}
+}
+
+func TestGoProxyLatestPaged(t *testing.T) {
+	t.Parallel()
+
+	index := []string{
+		`{"Path": "golang.org/x/foo","Version": "v0.1.0","Timestamp": "2021-05-01T10:00:00Z"}`,
+		`{"Path": "golang.org/x/bar","Version": "v0.1.0","Timestamp": "2021-05-02T10:00:00Z"}`,
+		`{"Path": "golang.org/x/baz","Version": "v0.1.0","Timestamp": "2021-05-03T10:00:00Z"}`,
+		`{"Path": "golang.org/x/qux","Version": "v0.1.0","Timestamp": "2021-05-04T10:00:00Z"}`,
+	}
+	requests := 0
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		indexPath: pagedIndex(index, &requests),
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	feed, err := New(feeds.FeedOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create goproxy feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+	feed.pageSize = 2
+
+	cutoff := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	expected := []string{"golang.org/x/bar", "golang.org/x/baz", "golang.org/x/qux"}
+	if len(pkgs) != len(expected) {
+		t.Fatalf("Latest() produced %v packages instead of the expected %v", len(pkgs), len(expected))
+	}
+	for i, name := range expected {
+		if pkgs[i].Name != name {
+			t.Errorf("Unexpected package `%s` found in place of expected `%s`", pkgs[i].Name, name)
+		}
+	}
+	if requests != 3 {
+		t.Errorf("%v requests were made to the index instead of the expected 3", requests)
+	}
+}
+
+func TestGoProxyBetween(t *testing.T) {
+	t.Parallel()
+
+	index := []string{
+		`{"Path": "golang.org/x/foo","Version": "v0.1.0","Timestamp": "2021-05-01T10:00:00Z"}`,
+		`{"Path": "golang.org/x/bar","Version": "v0.1.0","Timestamp": "2021-05-02T10:00:00Z"}`,
+		`{"Path": "golang.org/x/baz","Version": "v0.1.0","Timestamp": "2021-05-03T10:00:00Z"}`,
+		`{"Path": "golang.org/x/qux","Version": "v0.1.0","Timestamp": "2021-05-04T10:00:00Z"}`,
+		`{"Path": "golang.org/x/quux","Version": "v0.1.0","Timestamp": "2021-05-05T10:00:00Z"}`,
+	}
+	requests := 0
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		indexPath: pagedIndex(index, &requests),
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	feed, err := New(feeds.FeedOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create goproxy feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+	feed.pageSize = 2
+
+	from := time.Date(2021, 5, 2, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2021, 5, 3, 12, 0, 0, 0, time.UTC)
+	pkgs, errs := feed.Between(context.Background(), from, to)
+	if len(errs) != 0 {
+		t.Fatalf("feed.Between returned error: %v", errs[len(errs)-1])
+	}
+	expected := []string{"golang.org/x/bar", "golang.org/x/baz"}
+	if len(pkgs) != len(expected) {
+		t.Fatalf("Between() produced %v packages instead of the expected %v", len(pkgs), len(expected))
+	}
+	for i, name := range expected {
+		if pkgs[i].Name != name {
+			t.Errorf("Unexpected package `%s` found in place of expected `%s`", pkgs[i].Name, name)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("%v requests were made to the index when paging should stop at the end of the window "+
+			"after 2", requests)
+	}
+}
+
+// Serves the entries of the index since the given time, inclusive, up to the limit,
+// counting the requests made.
+func pagedIndex(index []string, requests *int) testutils.HTTPHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		since, err := time.Parse(time.RFC3339, r.URL.Query().Get("since"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		page := []string{}
+		for _, entry := range index {
+			var pkg PackageJSON
+			if err := json.Unmarshal([]byte(entry), &pkg); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			timestamp, err := time.Parse(time.RFC3339, pkg.Timestamp)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if !timestamp.Before(since) && len(page) < limit {
+				page = append(page, entry)
+			}
+		}
+		_, err = w.Write([]byte(strings.Join(page, "\n")))
+		if err != nil {
+			http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+		}
 	}
 }
 
@@ -66,7 +196,7 @@ func TestGoproxyNotFound(t *testing.T) {
 	feed.baseURL = srv.URL
 
 	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
-	_, errs := feed.Latest(cutoff)
+	_, errs := feed.Latest(context.Background(), cutoff)
 	if len(errs) == 0 {
 		t.Fatalf("feed.Latest() was successful when an error was expected")
 	}
@@ -0,0 +1,201 @@
+package helm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ossf/package-feeds/events"
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/utils"
+)
+
+const (
+	FeedName  = "helm"
+	indexPath = "index.yaml"
+)
+
+var (
+	httpClient = utils.NewHTTPClient(30 * time.Second)
+
+	errMissingRepositories = errors.New("repositories must be provided for the helm feed")
+	errInvalidRepository   = errors.New("invalid helm chart repository url")
+)
+
+// The index of a chart repository, listing every version of each chart in the repository.
+type repositoryIndex struct {
+	Entries map[string][]struct {
+		Version string `yaml:"version"`
+		Created string `yaml:"created"`
+	} `yaml:"entries"`
+}
+
+// A chart version listed by the index of a repository.
+type chartVersion struct {
+	name    string
+	version string
+	created time.Time
+}
+
+func (v chartVersion) key() string {
+	return v.name + "@" + v.version
+}
+
+type Feed struct {
+	// The URL of each chart repository, indexed by the name packages are emitted with.
+	repositories map[string]string
+	options      feeds.FeedOptions
+
+	// The versions listed by the index of each repository on the previous poll, nil for
+	// repositories which haven't yet been polled successfully.
+	mu       sync.Mutex
+	previous map[string]map[string]bool
+}
+
+func init() { //nolint:gochecknoinits
+	feeds.Register(FeedName, func(options feeds.FeedOptions, _ *events.Handler) (feeds.ScheduledFeed, error) {
+		return New(options)
+	})
+}
+
+func New(feedOptions feeds.FeedOptions) (*Feed, error) {
+	if len(feedOptions.Repositories) == 0 {
+		return nil, errMissingRepositories
+	}
+	if feedOptions.Packages != nil {
+		return nil, feeds.UnsupportedOptionError{
+			Feed:   FeedName,
+			Option: "packages",
+		}
+	}
+	for name, repoURL := range feedOptions.Repositories {
+		u, err := url.Parse(repoURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("%w : %v: %v", errInvalidRepository, name, repoURL)
+		}
+	}
+	return &Feed{
+		repositories: feedOptions.Repositories,
+		options:      feedOptions,
+		previous:     map[string]map[string]bool{},
+	}, nil
+}
+
+// Fetches every chart version listed by the index of a repository.
+func fetchIndex(ctx context.Context, repoURL string) ([]chartVersion, error) {
+	indexURL, err := utils.URLPathJoin(repoURL, indexPath)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := utils.Get(ctx, httpClient, indexURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	err = utils.CheckResponseStatus(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch helm repository index: %w", err)
+	}
+	body, err := utils.LimitedReadAll(resp.Body, utils.DefaultMaxResponseSize)
+	if err != nil {
+		return nil, err
+	}
+	index := &repositoryIndex{}
+	if err := yaml.Unmarshal(body, index); err != nil {
+		return nil, fmt.Errorf("failed to parse helm repository index: %w", err)
+	}
+	versions := []chartVersion{}
+	for name, entries := range index.Entries {
+		for _, entry := range entries {
+			created, err := time.Parse(time.RFC3339Nano, entry.Created)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse created time of %s %s: %w", name, entry.Version, err)
+			}
+			versions = append(versions, chartVersion{name: name, version: entry.Version, created: created})
+		}
+	}
+	return versions, nil
+}
+
+// Returns the versions which weren't listed by the index of the repository on the previous
+// poll, recording the versions now listed. On the first poll of a repository the versions
+// created after the cutoff are returned.
+func (feed *Feed) newVersions(repo string, versions []chartVersion, cutoff time.Time) []chartVersion {
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+	previous := feed.previous[repo]
+	current := map[string]bool{}
+	added := []chartVersion{}
+	for _, v := range versions {
+		current[v.key()] = true
+		isNew := !previous[v.key()]
+		if previous == nil {
+			isNew = v.created.After(cutoff)
+		}
+		if isNew {
+			added = append(added, v)
+		}
+	}
+	feed.previous[repo] = current
+	return added
+}
+
+func (feed *Feed) Latest(ctx context.Context, cutoff time.Time) ([]*feeds.Package, []error) {
+	type result struct {
+		repo     string
+		versions []chartVersion
+		err      error
+	}
+	results := make(chan result)
+	for repo, repoURL := range feed.repositories {
+		go func(repo, repoURL string) {
+			versions, err := fetchIndex(ctx, repoURL)
+			if err != nil {
+				err = fmt.Errorf("failed to poll helm repository %s (%s): %w", repo, repoURL, err)
+			}
+			results <- result{repo: repo, versions: versions, err: err}
+		}(repo, repoURL)
+	}
+
+	pkgs := []*feeds.Package{}
+	errs := []error{}
+	for range feed.repositories {
+		r := <-results
+		if r.err != nil {
+			// The versions of the repository are compared against its last successful poll.
+			errs = append(errs, r.err)
+			continue
+		}
+		for _, v := range feed.newVersions(r.repo, r.versions, cutoff) {
+			pkgs = append(pkgs, feeds.NewPackage(v.created, r.repo+"/"+v.name, v.version, FeedName, ""))
+		}
+	}
+	if len(errs) == len(feed.repositories) {
+		return nil, append(errs, feeds.ErrNoPackagesPolled)
+	}
+	// Repositories polled concurrently are returned in a consistent order.
+	feeds.SortByCreatedDate(pkgs)
+	return pkgs, errs
+}
+
+func (feed *Feed) GetName() string {
+	return FeedName
+}
+
+func (feed *Feed) GetFeedOptions() feeds.FeedOptions {
+	return feed.options
+}
+
+func (feed *Feed) GetRegistryURLs() []string {
+	urls := []string{}
+	for _, repoURL := range feed.repositories {
+		urls = append(urls, repoURL)
+	}
+	return urls
+}
@@ -0,0 +1,142 @@
+package helm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/utils"
+	testutils "github.com/ossf/package-feeds/utils/test"
+)
+
+const stableIndex = `apiVersion: v1
+entries:
+  nginx:
+  - name: nginx
+    version: 1.1.0
+    created: "2021-05-11T11:30:00.123456789Z"
+  - name: nginx
+    version: 1.0.0
+    created: "2021-05-10T12:00:00Z"
+  redis:
+  - name: redis
+    version: 2.0.0
+    created: 2021-05-11T11:00:00+01:00
+generated: "2021-05-11T12:00:00Z"
+`
+
+const chartsIndex = `apiVersion: v1
+entries:
+  grafana:
+  - name: grafana
+    version: 6.0.0
+    created: "2021-05-11T11:45:00Z"
+`
+
+func TestHelmLatest(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	stable := stableIndex
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/stable/index.yaml": func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			writeIndex(w, stable)
+		},
+		"/charts/index.yaml": func(w http.ResponseWriter, r *http.Request) {
+			writeIndex(w, chartsIndex)
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	feed, err := New(feeds.FeedOptions{Repositories: map[string]string{
+		"stable":  srv.URL + "/stable",
+		"charts":  srv.URL + "/charts/",
+		"missing": srv.URL + "/missing",
+	}})
+	if err != nil {
+		t.Fatalf("Failed to create helm feed: %v", err)
+	}
+
+	// The first poll of each repository emits the versions created after the cutoff.
+	cutoff := time.Date(2021, 5, 11, 9, 0, 0, 0, time.UTC)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 1 {
+		t.Fatalf("feed.Latest returned %v errors when 1 was expected", len(errs))
+	}
+	if !errors.Is(errs[0], utils.ErrUnsuccessfulRequest) || !strings.Contains(errs[0].Error(), "missing") {
+		t.Errorf("feed.Latest returned `%v` when an error fetching the missing repository was expected", errs[0])
+	}
+	expectPackages(t, pkgs, []string{"charts/grafana@6.0.0", "stable/nginx@1.1.0", "stable/redis@2.0.0"})
+	for _, pkg := range pkgs {
+		if pkg.Type != FeedName {
+			t.Errorf("Feed type not set correctly in helm package following Latest()")
+		}
+	}
+
+	// Later polls emit the versions added to the index since the previous poll, regardless
+	// of when they were created.
+	mu.Lock()
+	stable = strings.Replace(stable, "  redis:\n", `  - name: nginx
+    version: 0.9.0
+    created: "2021-05-01T12:00:00Z"
+  redis:
+`, 1)
+	mu.Unlock()
+	pkgs, _ = feed.Latest(context.Background(), time.Date(2021, 5, 11, 12, 0, 0, 0, time.UTC))
+	expectPackages(t, pkgs, []string{"stable/nginx@0.9.0"})
+}
+
+func TestHelmAllRepositoriesFailed(t *testing.T) {
+	t.Parallel()
+
+	srv := testutils.HTTPServerMock(map[string]testutils.HTTPHandlerFunc{})
+	defer srv.Close()
+
+	feed, err := New(feeds.FeedOptions{Repositories: map[string]string{"missing": srv.URL}})
+	if err != nil {
+		t.Fatalf("Failed to create helm feed: %v", err)
+	}
+	_, errs := feed.Latest(context.Background(), time.Time{})
+	if len(errs) != 2 || !errors.Is(errs[1], feeds.ErrNoPackagesPolled) {
+		t.Errorf("feed.Latest returned %v when a repository error and no packages polled error were expected", errs)
+	}
+}
+
+func TestHelmInvalidOptions(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New(feeds.FeedOptions{}); !errors.Is(err, errMissingRepositories) {
+		t.Errorf("New() returned `%v` when a missing repositories error was expected", err)
+	}
+	_, err := New(feeds.FeedOptions{Repositories: map[string]string{"stable": "charts.example.com"}})
+	if !errors.Is(err, errInvalidRepository) {
+		t.Errorf("New() returned `%v` when an invalid repository error was expected", err)
+	}
+}
+
+func expectPackages(t *testing.T, pkgs []*feeds.Package, expected []string) {
+	t.Helper()
+	if len(pkgs) != len(expected) {
+		t.Fatalf("Latest() produced %v packages instead of the expected %v", len(pkgs), len(expected))
+	}
+	for i, pkg := range pkgs {
+		if name := fmt.Sprintf("%s@%s", pkg.Name, pkg.Version); name != expected[i] {
+			t.Errorf("Unexpected package %s found in place of %s", name, expected[i])
+		}
+	}
+}
+
+func writeIndex(w http.ResponseWriter, index string) {
+	if _, err := w.Write([]byte(index)); err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,44 @@
+package feeds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNoHistory is returned when fetching a past time window from a feed which can only
+// fetch the packages recently created.
+var ErrNoHistory = errors.New("feed does not support fetching packages from a past time window")
+
+// HistoricalFeed is implemented by feeds whose registry can be queried for the packages
+// created at any time in the past, e.g. the pypi changelog or the go module index, rather
+// than only the most recent packages.
+type HistoricalFeed interface {
+	// Between returns the packages created within the window from from until to, it may
+	// also return packages created after to. Requests made to the registry are bound to ctx.
+	Between(ctx context.Context, from, to time.Time) ([]*Package, []error)
+}
+
+// Between fetches the packages created from from until, but excluding, to from the feed,
+// or returns ErrNoHistory if the feed is not a HistoricalFeed. Unlike polling, no cursor
+// of the feed is read or advanced.
+func Between(ctx context.Context, feed ScheduledFeed, from, to time.Time) ([]*Package, []error) {
+	historical, ok := feed.(HistoricalFeed)
+	if !ok {
+		return nil, []error{fmt.Errorf("%w : %v", ErrNoHistory, feed.GetName())}
+	}
+	pkgs, errs := historical.Between(ctx, from, to)
+	return ApplyWindow(pkgs, from, to), errs
+}
+
+// ApplyWindow removes packages created before from, or at or after to.
+func ApplyWindow(pkgs []*Package, from, to time.Time) []*Package {
+	filteredPackages := []*Package{}
+	for _, pkg := range ApplyCutoff(pkgs, from) {
+		if pkg.CreatedDate.Before(to) {
+			filteredPackages = append(filteredPackages, pkg)
+		}
+	}
+	return filteredPackages
+}
@@ -0,0 +1,43 @@
+package feeds
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type historicalDummyFeed struct {
+	dummyFeed
+	pkgs []*Package
+}
+
+func (feed historicalDummyFeed) Between(ctx context.Context, from, to time.Time) ([]*Package, []error) {
+	return feed.pkgs, nil
+}
+
+func TestBetween(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2021, 5, 11, 12, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+	feed := historicalDummyFeed{pkgs: []*Package{
+		{Name: "before", CreatedDate: from.Add(-time.Second)},
+		{Name: "from", CreatedDate: from},
+		{Name: "within", CreatedDate: from.Add(time.Minute)},
+		{Name: "to", CreatedDate: to},
+	}}
+	pkgs, errs := Between(context.Background(), feed, from, to)
+	if len(errs) != 0 {
+		t.Fatalf("Between() returned unexpected errors: %v", errs)
+	}
+	if len(pkgs) != 2 || pkgs[0].Name != "from" || pkgs[1].Name != "within" {
+		t.Errorf("Between() returned %v when the packages created from the start of the window "+
+			"until its end were expected", pkgs)
+	}
+
+	_, errs = Between(context.Background(), dummyFeed{}, from, to)
+	if len(errs) != 1 || !errors.Is(errs[0], ErrNoHistory) {
+		t.Errorf("Between() returned %v when ErrNoHistory was expected for a feed without history", errs)
+	}
+}
@@ -0,0 +1,218 @@
+package homebrew
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ossf/package-feeds/events"
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/utils"
+)
+
+const (
+	FeedName    = "homebrew"
+	formulaPath = "/api/formula.json"
+	caskPath    = "/api/cask.json"
+
+	includeFormulae = "formulae"
+	includeCasks    = "casks"
+	includeBoth     = "both"
+)
+
+var (
+	httpClient        = utils.NewHTTPClient(60 * time.Second)
+	errUnknownInclude = errors.New("unknown homebrew include option")
+)
+
+type Formula struct {
+	Name     string `json:"name"`
+	Versions struct {
+		Stable string `json:"stable"`
+	} `json:"versions"`
+}
+
+type Cask struct {
+	Token   string `json:"token"`
+	Version string `json:"version"`
+}
+
+// A formula or cask version, keyed by kind so that formulae and casks sharing a name are
+// tracked separately.
+type release struct {
+	key     string
+	name    string
+	version string
+}
+
+// Fetches a JSON API index into out, returning the time the index was last modified, or a
+// zero time if it is unknown.
+func fetchIndex(ctx context.Context, baseURL, path string, out interface{}) (time.Time, error) {
+	indexURL, err := utils.URLPathJoin(baseURL, path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	resp, err := utils.Get(ctx, httpClient, indexURL)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	err = utils.CheckResponseStatus(resp)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to fetch homebrew index: %w", err)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(out)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// The time is left zero when the registry doesn't report it.
+	indexModified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return indexModified, nil
+}
+
+func fetchFormulae(ctx context.Context, baseURL string) ([]release, time.Time, error) {
+	formulae := []*Formula{}
+	indexModified, err := fetchIndex(ctx, baseURL, formulaPath, &formulae)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	releases := make([]release, 0, len(formulae))
+	for _, formula := range formulae {
+		releases = append(releases, release{
+			key:     "formula/" + formula.Name,
+			name:    formula.Name,
+			version: formula.Versions.Stable,
+		})
+	}
+	return releases, indexModified, nil
+}
+
+func fetchCasks(ctx context.Context, baseURL string) ([]release, time.Time, error) {
+	casks := []*Cask{}
+	indexModified, err := fetchIndex(ctx, baseURL, caskPath, &casks)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	releases := make([]release, 0, len(casks))
+	for _, cask := range casks {
+		releases = append(releases, release{
+			key:     "cask/" + cask.Token,
+			name:    cask.Token,
+			version: cask.Version,
+		})
+	}
+	return releases, indexModified, nil
+}
+
+type Feed struct {
+	baseURL         string
+	includeFormulae bool
+	includeCasks    bool
+	options         feeds.FeedOptions
+	clock           feeds.Clock
+
+	// Versions seen in the previous poll indexed by kind and name, nil prior to the first poll.
+	seen   map[string]string
+	seenMu sync.Mutex
+}
+
+func init() { //nolint:gochecknoinits
+	feeds.Register(FeedName, func(options feeds.FeedOptions, _ *events.Handler) (feeds.ScheduledFeed, error) {
+		return New(options)
+	})
+}
+
+func New(feedOptions feeds.FeedOptions) (*Feed, error) {
+	if feedOptions.Packages != nil {
+		return nil, feeds.UnsupportedOptionError{
+			Feed:   FeedName,
+			Option: "packages",
+		}
+	}
+	feed := &Feed{
+		baseURL: "https://formulae.brew.sh/",
+		options: feedOptions,
+		clock:   feeds.ClockOrReal(feedOptions.Clock),
+	}
+	switch feedOptions.Include {
+	case "", includeBoth:
+		feed.includeFormulae = true
+		feed.includeCasks = true
+	case includeFormulae:
+		feed.includeFormulae = true
+	case includeCasks:
+		feed.includeCasks = true
+	default:
+		return nil, fmt.Errorf("%w : %v", errUnknownInclude, feedOptions.Include)
+	}
+	return feed, nil
+}
+
+// Latest diffs the formula and cask versions in the Homebrew API against those seen in the
+// previous poll, emitting a package for each new or changed version. The API is a snapshot
+// without release timestamps, so the first poll only records the current versions and the
+// cutoff isn't applied.
+func (feed *Feed) Latest(ctx context.Context, cutoff time.Time) ([]*feeds.Package, []error) {
+	pkgs := []*feeds.Package{}
+	releases := []release{}
+	var indexModified time.Time
+	if feed.includeFormulae {
+		formulae, modified, err := fetchFormulae(ctx, feed.baseURL)
+		if err != nil {
+			return nil, []error{err}
+		}
+		releases = append(releases, formulae...)
+		indexModified = modified
+	}
+	if feed.includeCasks {
+		casks, modified, err := fetchCasks(ctx, feed.baseURL)
+		if err != nil {
+			return nil, []error{err}
+		}
+		releases = append(releases, casks...)
+		if modified.After(indexModified) {
+			indexModified = modified
+		}
+	}
+	if indexModified.IsZero() {
+		indexModified = feed.clock.Now().UTC()
+	}
+
+	feed.seenMu.Lock()
+	defer feed.seenMu.Unlock()
+
+	firstPoll := feed.seen == nil
+	seen := make(map[string]string, len(releases))
+	for _, r := range releases {
+		if r.name == "" || r.version == "" {
+			continue
+		}
+		seen[r.key] = r.version
+		if firstPoll || feed.seen[r.key] == r.version {
+			continue
+		}
+		pkgs = append(pkgs, feeds.NewPackage(indexModified, r.name, r.version, FeedName, feeds.EcosystemHomebrew))
+	}
+	feed.seen = seen
+
+	return pkgs, []error{}
+}
+
+func (feed *Feed) GetName() string {
+	return FeedName
+}
+
+func (feed *Feed) GetFeedOptions() feeds.FeedOptions {
+	return feed.options
+}
+
+func (feed *Feed) GetBaseURL() string {
+	return feed.baseURL
+}
@@ -0,0 +1,143 @@
+package homebrew
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/utils"
+	testutils "github.com/ossf/package-feeds/utils/test"
+)
+
+func TestHomebrewLatestDiff(t *testing.T) {
+	t.Parallel()
+
+	poll := 0
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		formulaPath: func(w http.ResponseWriter, r *http.Request) {
+			poll++
+			if poll == 1 {
+				writeResponse(w, `[
+					{"name": "foo", "versions": {"stable": "1.0.0"}},
+					{"name": "bar", "versions": {"stable": "2.0.0"}}
+				]`)
+			} else {
+				writeResponse(w, `[
+					{"name": "foo", "versions": {"stable": "1.0.1"}},
+					{"name": "bar", "versions": {"stable": "2.0.0"}},
+					{"name": "baz", "versions": {"stable": "0.1.0"}}
+				]`)
+			}
+		},
+		caskPath: func(w http.ResponseWriter, r *http.Request) {
+			writeResponse(w, `[{"token": "qux", "version": "3.0.0"}]`)
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	feed, err := New(feeds.FeedOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create homebrew feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	pkgs, errs := feed.Latest(context.Background(), time.Time{})
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 0 {
+		t.Fatalf("Latest() produced %v packages on the first poll instead of the expected 0", len(pkgs))
+	}
+
+	pkgs, errs = feed.Latest(context.Background(), time.Time{})
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	// foo is updated and baz is added, bar and the qux cask are unchanged.
+	if len(pkgs) != 2 {
+		t.Fatalf("Latest() produced %v packages instead of the expected 2", len(pkgs))
+	}
+	if pkgs[0].Name != "foo" || pkgs[0].Version != "1.0.1" {
+		t.Errorf("Unexpected package %s@%s found in place of foo@1.0.1", pkgs[0].Name, pkgs[0].Version)
+	}
+	if pkgs[1].Name != "baz" || pkgs[1].Version != "0.1.0" {
+		t.Errorf("Unexpected package %s@%s found in place of baz@0.1.0", pkgs[1].Name, pkgs[1].Version)
+	}
+	for _, pkg := range pkgs {
+		if pkg.Type != FeedName {
+			t.Errorf("Feed type not set correctly in homebrew package following Latest()")
+		}
+		if pkg.Ecosystem != feeds.EcosystemHomebrew {
+			t.Errorf("Ecosystem not set correctly in homebrew package following Latest()")
+		}
+	}
+}
+
+func TestHomebrewCasksOnly(t *testing.T) {
+	t.Parallel()
+
+	poll := 0
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		caskPath: func(w http.ResponseWriter, r *http.Request) {
+			poll++
+			if poll == 1 {
+				writeResponse(w, `[{"token": "qux", "version": "3.0.0"}]`)
+			} else {
+				writeResponse(w, `[{"token": "qux", "version": "3.1.0"}]`)
+			}
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	feed, err := New(feeds.FeedOptions{Include: includeCasks})
+	if err != nil {
+		t.Fatalf("Failed to create homebrew feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	if _, errs := feed.Latest(context.Background(), time.Time{}); len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	pkgs, errs := feed.Latest(context.Background(), time.Time{})
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 1 || pkgs[0].Name != "qux" || pkgs[0].Version != "3.1.0" {
+		t.Fatalf("Latest() produced %v when only qux@3.1.0 was expected", pkgs)
+	}
+
+	_, err = New(feeds.FeedOptions{Include: "bottles"})
+	if !errors.Is(err, errUnknownInclude) {
+		t.Errorf("New() returned `%v` when an unknown include error was expected", err)
+	}
+}
+
+func TestHomebrewNotFound(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		formulaPath: testutils.NotFoundHandlerFunc,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	feed, err := New(feeds.FeedOptions{Include: includeFormulae})
+	if err != nil {
+		t.Fatalf("Failed to create homebrew feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	_, errs := feed.Latest(context.Background(), time.Time{})
+	if len(errs) != 1 || !errors.Is(errs[0], utils.ErrUnsuccessfulRequest) {
+		t.Fatalf("feed.Latest() returned %v when an unsuccessful request error was expected", errs)
+	}
+}
+
+func writeResponse(w http.ResponseWriter, body string) {
+	_, err := w.Write([]byte(body))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,48 @@
+package feeds
+
+import "strings"
+
+// Common spellings of licenses given to registries, indexed by their lowercase form, and the
+// SPDX identifier of each. https://spdx.org/licenses/
+var licenseAliases = map[string]string{
+	"mit":                         "MIT",
+	"mit license":                 "MIT",
+	"isc":                         "ISC",
+	"isc license":                 "ISC",
+	"apache 2":                    "Apache-2.0",
+	"apache 2.0":                  "Apache-2.0",
+	"apache-2":                    "Apache-2.0",
+	"apache-2.0":                  "Apache-2.0",
+	"apache license 2.0":          "Apache-2.0",
+	"apache license, version 2.0": "Apache-2.0",
+	"apache software license":     "Apache-2.0",
+	"bsd":                         "BSD-3-Clause",
+	"bsd-2-clause":                "BSD-2-Clause",
+	"bsd-3-clause":                "BSD-3-Clause",
+	"bsd license":                 "BSD-3-Clause",
+	"0bsd":                        "0BSD",
+	"gpl-2.0":                     "GPL-2.0-only",
+	"gplv2":                       "GPL-2.0-only",
+	"gpl-3.0":                     "GPL-3.0-only",
+	"gplv3":                       "GPL-3.0-only",
+	"lgpl-2.1":                    "LGPL-2.1-only",
+	"lgpl-3.0":                    "LGPL-3.0-only",
+	"agpl-3.0":                    "AGPL-3.0-only",
+	"mpl-2.0":                     "MPL-2.0",
+	"mpl 2.0":                     "MPL-2.0",
+	"unlicense":                   "Unlicense",
+	"the unlicense":               "Unlicense",
+	"cc0-1.0":                     "CC0-1.0",
+	"wtfpl":                       "WTFPL",
+}
+
+// NormalizeLicense converts a license as given by a registry toward its SPDX identifier, e.g.
+// `Apache 2.0` becomes `Apache-2.0`. Licenses which aren't recognized, including SPDX
+// expressions such as `(MIT OR Apache-2.0)`, are returned with surrounding whitespace trimmed.
+func NormalizeLicense(license string) string {
+	license = strings.TrimSpace(license)
+	if spdx, ok := licenseAliases[strings.ToLower(license)]; ok {
+		return spdx
+	}
+	return license
+}
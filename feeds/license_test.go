@@ -0,0 +1,27 @@
+package feeds
+
+import "testing"
+
+func TestNormalizeLicense(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		license  string
+		expected string
+	}{
+		{"MIT", "MIT"},
+		{" mit ", "MIT"},
+		{"Apache 2.0", "Apache-2.0"},
+		{"Apache License, Version 2.0", "Apache-2.0"},
+		{"BSD", "BSD-3-Clause"},
+		{"GPLv3", "GPL-3.0-only"},
+		{"(MIT OR Apache-2.0)", "(MIT OR Apache-2.0)"},
+		{"SEE LICENSE IN LICENSE.txt", "SEE LICENSE IN LICENSE.txt"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if license := NormalizeLicense(test.license); license != test.expected {
+			t.Errorf("NormalizeLicense(%q) returned %q when %q was expected", test.license, license, test.expected)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package localdir
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ossf/package-feeds/events"
+	"github.com/ossf/package-feeds/feeds"
+)
+
+const (
+	FeedName      = "localdir"
+	metadataExt   = ".json"
+	arrayJSONChar = '['
+)
+
+var (
+	errMissingPath     = errors.New("path must be provided for the localdir feed")
+	errInvalidMetadata = errors.New("invalid package metadata file")
+)
+
+// Package is the metadata of a single package version within a metadata file.
+type Package struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// The ecosystem of the package, defaults to the name of this feed.
+	Type string `json:"type"`
+	// Defaults to the modification time of the metadata file.
+	CreatedDate *time.Time `json:"created_date"`
+}
+
+// Reads a metadata file, which contains either a single package object or an array of
+// package objects.
+func readMetadataFile(path string, modified time.Time) ([]*feeds.Package, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var metadata []*Package
+	trimmed := strings.TrimSpace(string(data))
+	if len(trimmed) > 0 && trimmed[0] == arrayJSONChar {
+		err = json.Unmarshal(data, &metadata)
+	} else {
+		single := &Package{}
+		err = json.Unmarshal(data, single)
+		metadata = []*Package{single}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %v", errInvalidMetadata, path, err)
+	}
+
+	pkgs := []*feeds.Package{}
+	for _, m := range metadata {
+		if m.Name == "" || m.Version == "" {
+			return nil, fmt.Errorf("%w %s: name and version are required", errInvalidMetadata, path)
+		}
+		created := modified
+		if m.CreatedDate != nil {
+			created = *m.CreatedDate
+		}
+		pkgType := m.Type
+		if pkgType == "" {
+			pkgType = FeedName
+		}
+		// Unknown types leave the ecosystem unset.
+		ecosystem, _ := feeds.ParseEcosystem(pkgType)
+		pkgs = append(pkgs, feeds.NewPackage(created, m.Name, m.Version, pkgType, ecosystem))
+	}
+	return pkgs, nil
+}
+
+type Feed struct {
+	path    string
+	options feeds.FeedOptions
+
+	// Watches the directory for written metadata files, nil if watching failed.
+	watcher *fsnotify.Watcher
+
+	// Metadata files written since the previous poll, as reported by the watcher.
+	changed   map[string]bool
+	changedMu sync.Mutex
+}
+
+func init() { //nolint:gochecknoinits
+	feeds.Register(FeedName, func(options feeds.FeedOptions, _ *events.Handler) (feeds.ScheduledFeed, error) {
+		return New(options)
+	})
+}
+
+func New(feedOptions feeds.FeedOptions) (*Feed, error) {
+	if feedOptions.Packages != nil {
+		return nil, feeds.UnsupportedOptionError{
+			Feed:   FeedName,
+			Option: "packages",
+		}
+	}
+	if feedOptions.Path == "" {
+		return nil, errMissingPath
+	}
+	feed := &Feed{
+		path:    feedOptions.Path,
+		options: feedOptions,
+		changed: map[string]bool{},
+	}
+	if err := feed.watch(); err != nil {
+		log.WithError(err).WithField("path", feed.path).Warn(
+			"Failed to watch directory for changes, falling back to polling modification times")
+	}
+	return feed, nil
+}
+
+// Watches the directory for written metadata files, this detects files which are synced
+// with their original modification time preserved.
+func (feed *Feed) watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(feed.path); err != nil {
+		if closeErr := watcher.Close(); closeErr != nil {
+			log.WithError(closeErr).Warn("Failed to close directory watcher")
+		}
+		return err
+	}
+	feed.watcher = watcher
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Rename) == 0 ||
+					filepath.Ext(event.Name) != metadataExt {
+					continue
+				}
+				feed.changedMu.Lock()
+				feed.changed[event.Name] = true
+				feed.changedMu.Unlock()
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.WithError(err).WithField("path", feed.path).Warn("Error watching directory")
+			}
+		}
+	}()
+	return nil
+}
+
+// Latest emits the packages of metadata files in the directory which have been modified
+// since the cutoff, or which the watcher reported as written since the previous poll.
+func (feed *Feed) Latest(ctx context.Context, cutoff time.Time) ([]*feeds.Package, []error) {
+	files, err := ioutil.ReadDir(feed.path)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	feed.changedMu.Lock()
+	changed := feed.changed
+	feed.changed = map[string]bool{}
+	feed.changedMu.Unlock()
+
+	pkgs := []*feeds.Package{}
+	errs := []error{}
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != metadataExt {
+			continue
+		}
+		path := filepath.Join(feed.path, file.Name())
+		if !file.ModTime().After(cutoff) && !changed[path] {
+			continue
+		}
+		filePkgs, err := readMetadataFile(path, file.ModTime())
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		pkgs = append(pkgs, filePkgs...)
+	}
+	return pkgs, errs
+}
+
+// Close stops watching the directory, the goroutine receiving the events of the watcher
+// exits once the watcher is closed.
+func (feed *Feed) Close() error {
+	if feed.watcher == nil {
+		return nil
+	}
+	return feed.watcher.Close()
+}
+
+func (feed *Feed) GetName() string {
+	return FeedName
+}
+
+func (feed *Feed) GetFeedOptions() feeds.FeedOptions {
+	return feed.options
+}
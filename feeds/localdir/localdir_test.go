@@ -0,0 +1,135 @@
+package localdir
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+)
+
+func writeMetadataFile(t *testing.T, path, content string, modified time.Time) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write metadata file: %v", err)
+	}
+	if err := os.Chtimes(path, modified, modified); err != nil {
+		t.Fatalf("Failed to set metadata file modification time: %v", err)
+	}
+}
+
+func TestLocalDirLatest(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cutoff := time.Now().Add(-time.Hour)
+	writeMetadataFile(t, filepath.Join(dir, "old.json"),
+		`{"name": "old", "version": "1.0.0"}`, cutoff.Add(-time.Hour))
+	writeMetadataFile(t, filepath.Join(dir, "new.json"),
+		`[{"name": "foo", "version": "1.0.0", "type": "npm"},
+		  {"name": "bar", "version": "2.0.0", "created_date": "2021-05-20T12:00:00Z"}]`,
+		cutoff.Add(time.Minute))
+	writeMetadataFile(t, filepath.Join(dir, "ignored.txt"), `not metadata`, cutoff.Add(time.Minute))
+
+	feed, err := New(feeds.FeedOptions{Path: dir})
+	if err != nil {
+		t.Fatalf("Failed to create localdir feed: %v", err)
+	}
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 2 {
+		t.Fatalf("Latest() produced %v packages instead of the expected 2", len(pkgs))
+	}
+	if pkgs[0].Name != "foo" || pkgs[0].Type != "npm" {
+		t.Errorf("Unexpected package %s of type %s found in place of foo of type npm", pkgs[0].Name, pkgs[0].Type)
+	}
+	if pkgs[0].Ecosystem != feeds.EcosystemNPM {
+		t.Errorf("Unexpected ecosystem %s found in place of npm", pkgs[0].Ecosystem)
+	}
+	if !pkgs[0].CreatedDate.Equal(cutoff.Add(time.Minute)) {
+		t.Errorf("Package created date %v is not the modification time of the file", pkgs[0].CreatedDate)
+	}
+	if pkgs[1].Name != "bar" || pkgs[1].Type != FeedName {
+		t.Errorf("Unexpected package %s of type %s found in place of bar of type %s", pkgs[1].Name, pkgs[1].Type, FeedName)
+	}
+	expectedCreated := time.Date(2021, 5, 20, 12, 0, 0, 0, time.UTC)
+	if !pkgs[1].CreatedDate.Equal(expectedCreated) {
+		t.Errorf("Package created date %v does not match expected %v", pkgs[1].CreatedDate, expectedCreated)
+	}
+}
+
+func TestLocalDirWatchPreservedModificationTime(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	feed, err := New(feeds.FeedOptions{Path: dir})
+	if err != nil {
+		t.Fatalf("Failed to create localdir feed: %v", err)
+	}
+
+	// A synced file keeps its original modification time, which is before the cutoff.
+	cutoff := time.Now()
+	writeMetadataFile(t, filepath.Join(dir, "synced.json"),
+		`{"name": "synced", "version": "1.0.0"}`, cutoff.Add(-24*time.Hour))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		pkgs, errs := feed.Latest(context.Background(), cutoff)
+		if len(errs) != 0 {
+			t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+		}
+		if len(pkgs) == 1 && pkgs[0].Name == "synced" {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("Watcher did not detect the synced metadata file")
+}
+
+func TestLocalDirClose(t *testing.T) {
+	t.Parallel()
+
+	feed, err := New(feeds.FeedOptions{Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Failed to create localdir feed: %v", err)
+	}
+	if feed.watcher == nil {
+		t.Fatalf("Feed is not watching the directory")
+	}
+	if err := feed.Close(); err != nil {
+		t.Fatalf("Close() returned unexpected error: %v", err)
+	}
+	select {
+	case _, ok := <-feed.watcher.Events:
+		if ok {
+			t.Errorf("Watcher reported an event after the feed was closed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("Watcher was not closed by Close()")
+	}
+}
+
+func TestLocalDirInvalidMetadata(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeMetadataFile(t, filepath.Join(dir, "invalid.json"), `{"name": "foo"}`, time.Now())
+
+	feed, err := New(feeds.FeedOptions{Path: dir})
+	if err != nil {
+		t.Fatalf("Failed to create localdir feed: %v", err)
+	}
+	_, errs := feed.Latest(context.Background(), time.Time{})
+	if len(errs) != 1 {
+		t.Fatalf("feed.Latest returned %v errors when 1 was expected", len(errs))
+	}
+
+	if _, err := New(feeds.FeedOptions{}); err == nil {
+		t.Errorf("New() did not return an error when no path was provided")
+	}
+}
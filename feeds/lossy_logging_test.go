@@ -18,15 +18,15 @@ func TestProcessPackagesNoOverlap(t *testing.T) {
 
 	baseTime := time.Date(2021, 4, 20, 14, 30, 0, 0, time.UTC)
 	pkgs1 := []*Package{
-		NewPackage(baseTime.Add(-time.Minute*2), "foopkg", "1.0", feedName),
-		NewPackage(baseTime.Add(-time.Minute*3), "barpkg", "1.0", feedName),
+		NewPackage(baseTime.Add(-time.Minute*2), "foopkg", "1.0", feedName, ""),
+		NewPackage(baseTime.Add(-time.Minute*3), "barpkg", "1.0", feedName, ""),
 	}
 	// Populate previous packages
 	lossyFeedAlerter.ProcessPackages(feedName, pkgs1)
 
 	pkgs2 := []*Package{
-		NewPackage(baseTime, "bazpkg", "1.0", feedName),
-		NewPackage(baseTime.Add(-time.Minute*1), "quxpkg", "2.0", feedName),
+		NewPackage(baseTime, "bazpkg", "1.0", feedName, ""),
+		NewPackage(baseTime.Add(-time.Minute*1), "quxpkg", "2.0", feedName, ""),
 	}
 	// Trigger no overlap
 	lossyFeedAlerter.ProcessPackages(feedName, pkgs2)
@@ -53,16 +53,16 @@ func TestProcessPackagesWithOverlap(t *testing.T) {
 
 	baseTime := time.Date(2021, 4, 20, 14, 30, 0, 0, time.UTC)
 	pkgs1 := []*Package{
-		NewPackage(baseTime.Add(-time.Minute*2), "foopkg", "1.0", feedName),
-		NewPackage(baseTime.Add(-time.Minute*3), "barpkg", "1.0", feedName),
+		NewPackage(baseTime.Add(-time.Minute*2), "foopkg", "1.0", feedName, ""),
+		NewPackage(baseTime.Add(-time.Minute*3), "barpkg", "1.0", feedName, ""),
 	}
 	// Populate previous packages
 	lossyFeedAlerter.ProcessPackages(feedName, pkgs1)
 
 	pkgs2 := []*Package{
-		NewPackage(baseTime, "bazpkg", "1.0", feedName),
-		NewPackage(baseTime.Add(-time.Minute*1), "quxpkg", "2.0", feedName),
-		NewPackage(baseTime.Add(-time.Minute*2), "foopkg", "1.0", feedName),
+		NewPackage(baseTime, "bazpkg", "1.0", feedName, ""),
+		NewPackage(baseTime.Add(-time.Minute*1), "quxpkg", "2.0", feedName, ""),
+		NewPackage(baseTime.Add(-time.Minute*2), "foopkg", "1.0", feedName, ""),
 	}
 	// Trigger overlap
 	lossyFeedAlerter.ProcessPackages(feedName, pkgs2)
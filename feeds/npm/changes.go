@@ -0,0 +1,324 @@
+package npm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ossf/package-feeds/events"
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/metrics"
+	"github.com/ossf/package-feeds/utils"
+)
+
+const (
+	changesPath = "/_changes"
+
+	defaultChangesURL = "https://replicate.npmjs.com/"
+
+	// The number of changes requested per page, and the maximum number of pages
+	// fetched per poll. Remaining changes are fetched by the next poll.
+	changesLimit    = 500
+	maxChangesPages = 10
+	// The milliseconds the continuous changes feed waits for further changes before ending
+	// the response, once every change has been streamed.
+	changesTimeout = 1000
+
+	designDocPrefix = "_design/"
+
+	// The number of polls a changed package which failed to be fetched is retried by,
+	// before it is given up on.
+	maxChangeRetries = 5
+)
+
+var errInvalidChangesCursor = errors.New("invalid npm changes cursor")
+
+// The sequence of a change in the CouchDB replication database. Sequences are opaque and
+// may be either strings or numbers depending on the CouchDB version, they are stored as
+// strings and passed back to `since` unmodified.
+type changesSeq string
+
+func (s *changesSeq) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*s = changesSeq(str)
+		return nil
+	}
+	var num json.Number
+	if err := json.Unmarshal(data, &num); err != nil {
+		return fmt.Errorf("%w : %v", errJSON, err)
+	}
+	*s = changesSeq(num.String())
+	return nil
+}
+
+type change struct {
+	Seq     changesSeq `json:"seq"`
+	ID      string     `json:"id"`
+	Deleted bool       `json:"deleted"`
+}
+
+type changesResponse struct {
+	Results []change
+	LastSeq changesSeq
+}
+
+// A line of the continuous changes feed, either a change or the final line holding the
+// sequence of the last change streamed.
+type changesLine struct {
+	change
+	LastSeq changesSeq `json:"last_seq"`
+}
+
+type dbInfoResponse struct {
+	UpdateSeq changesSeq `json:"update_seq"`
+}
+
+// The persisted position in the changes feed. Polled is the time at which the poll that
+// stored the cursor started, versions of changed packages created since then are emitted.
+// Retry holds the changed packages which failed to be fetched, indexed by package name, so
+// that they are fetched again by the next poll although the sequence moved past them.
+type changesCursor struct {
+	Seq    changesSeq             `json:"seq"`
+	Polled time.Time              `json:"polled"`
+	Retry  map[string]retryChange `json:"retry,omitempty"`
+}
+
+// A changed package which failed to be fetched.
+type retryChange struct {
+	// Versions created since this time are emitted, the Polled time of the cursor when the
+	// package changed.
+	Since    time.Time `json:"since"`
+	Attempts int       `json:"attempts"`
+}
+
+func fetchUpdateSeq(ctx context.Context, reg registry) (changesSeq, error) {
+	start := time.Now()
+	resp, err := reg.get(ctx, "/")
+	metrics.ObserveRegistryRequest(FeedName, "changes", start)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	err = utils.CheckResponseStatus(resp)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch npm replication database info: %w", err)
+	}
+	body, err := utils.LimitedReadAll(resp.Body, reg.maxResponseSize)
+	if err != nil {
+		return "", err
+	}
+	info := &dbInfoResponse{}
+	if err := json.Unmarshal(body, info); err != nil {
+		return "", fmt.Errorf("%w : %v", errJSON, err)
+	}
+	return info.UpdateSeq, nil
+}
+
+func fetchChanges(ctx context.Context, reg registry, since changesSeq) (*changesResponse, error) {
+	query := url.Values{}
+	query.Set("since", string(since))
+	query.Set("limit", strconv.Itoa(changesLimit))
+	query.Set("feed", "continuous")
+	query.Set("timeout", strconv.Itoa(changesTimeout))
+	start := time.Now()
+	resp, err := reg.getWithQuery(ctx, changesPath, query)
+	metrics.ObserveRegistryRequest(FeedName, "changes", start)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	err = utils.CheckResponseStatus(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch npm changes: %w", err)
+	}
+	// Changes are streamed a line at a time, so are decoded as they arrive.
+	decoder := utils.NewNDJSONDecoder(utils.NewLimitedReader(resp.Body, reg.maxResponseSize))
+	changes := &changesResponse{}
+	for {
+		line := changesLine{}
+		err := decoder.Decode(ctx, &line)
+		if errors.Is(err, io.EOF) {
+			return changes, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w : %v", errJSON, err)
+		}
+		if line.LastSeq != "" {
+			changes.LastSeq = line.LastSeq
+			continue
+		}
+		changes.Results = append(changes.Results, line.change)
+	}
+}
+
+// Polls the CouchDB `_changes` feed of the npm replication database incrementally,
+// tracking the sequence of the last processed change.
+type changesPoller struct {
+	store feeds.CursorStore
+	// Provides the time of each poll, recorded in the cursor.
+	clock feeds.Clock
+
+	mu sync.Mutex
+	// The last processed change, nil if unknown.
+	cursor *changesCursor
+}
+
+func newChangesPoller(store feeds.CursorStore, clock feeds.Clock) *changesPoller {
+	return &changesPoller{store: store, clock: feeds.ClockOrReal(clock)}
+}
+
+func (c *changesPoller) lastCursor() (*changesCursor, error) {
+	if c.cursor != nil || c.store == nil {
+		return c.cursor, nil
+	}
+	stored, err := c.store.Get(FeedName)
+	if err != nil || stored == "" {
+		return nil, err
+	}
+	cursor := &changesCursor{}
+	if err := json.Unmarshal([]byte(stored), cursor); err != nil {
+		return nil, fmt.Errorf("%w : %v", errInvalidChangesCursor, err)
+	}
+	c.cursor = cursor
+	return cursor, nil
+}
+
+func (c *changesPoller) setCursor(cursor *changesCursor) error {
+	c.cursor = cursor
+	if c.store == nil {
+		return nil
+	}
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return err
+	}
+	return c.store.Set(FeedName, string(data))
+}
+
+// Fetches the versions of packages changed since the last processed change. If no
+// sequence is known, the current sequence is fetched and stored and no packages are
+// returned.
+func (c *changesPoller) latest(ctx context.Context, changesReg, reg registry,
+	republished *republishDetector, eventHandler *events.Handler) ([]*feeds.Package, []error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pollStart := c.clock.Now()
+	cursor, err := c.lastCursor()
+	if err != nil {
+		return nil, []error{err}
+	}
+	if cursor == nil {
+		seq, err := fetchUpdateSeq(ctx, changesReg)
+		if err != nil {
+			return nil, []error{err}
+		}
+		if err := c.setCursor(&changesCursor{Seq: seq, Polled: pollStart}); err != nil {
+			return nil, []error{err}
+		}
+		return []*feeds.Package{}, nil
+	}
+
+	// Packages are often changed several times in quick succession, so are only fetched once.
+	// Packages which failed to be fetched by a previous poll are fetched again.
+	changed := []string{}
+	seen := map[string]bool{}
+	for name := range cursor.Retry {
+		seen[name] = true
+		changed = append(changed, name)
+	}
+	sort.Strings(changed)
+	seq := cursor.Seq
+	// Whether every change up to the start of the poll was fetched, otherwise the remaining
+	// changes may include versions created before the poll started.
+	complete := false
+	var errs []error
+	for page := 0; page < maxChangesPages; page++ {
+		changes, err := fetchChanges(ctx, changesReg, seq)
+		if err != nil {
+			if page == 0 {
+				return nil, []error{err}
+			}
+			errs = append(errs, err)
+			break
+		}
+		for _, change := range changes.Results {
+			if change.Deleted || strings.HasPrefix(change.ID, designDocPrefix) || seen[change.ID] {
+				continue
+			}
+			seen[change.ID] = true
+			changed = append(changed, change.ID)
+		}
+		if changes.LastSeq != "" {
+			seq = changes.LastSeq
+		}
+		if len(changes.Results) < changesLimit {
+			complete = true
+			break
+		}
+	}
+
+	pkgs := []*feeds.Package{}
+	results, fetchErrs := fetchPackageVersions(ctx, reg, changed, republished, eventHandler)
+	errs = append(errs, fetchErrs...)
+	since := func(name string) time.Time {
+		if retry, ok := cursor.Retry[name]; ok {
+			return retry.Since
+		}
+		return cursor.Polled
+	}
+	for _, result := range results {
+		for _, pkg := range result.versions {
+			if pkg.CreatedDate.Before(since(result.title)) {
+				continue
+			}
+			pkgs = append(pkgs, newFeedPackage(pkg))
+		}
+	}
+
+	// If the poll was cancelled or passed its deadline, the changes are fetched again
+	// by the next poll rather than being skipped.
+	if ctx.Err() != nil {
+		return pkgs, errs
+	}
+	next := &changesCursor{Seq: seq, Polled: cursor.Polled}
+	if complete {
+		next.Polled = pollStart
+	}
+	for _, err := range fetchErrs {
+		var pollErr feeds.PackagePollError
+		if !errors.As(err, &pollErr) {
+			continue
+		}
+		attempts := cursor.Retry[pollErr.Name].Attempts + 1
+		if attempts > maxChangeRetries {
+			log.WithFields(log.Fields{
+				"feed":     FeedName,
+				"package":  pollErr.Name,
+				"attempts": attempts,
+			}).Warn("Gave up fetching changed package")
+			continue
+		}
+		if next.Retry == nil {
+			next.Retry = map[string]retryChange{}
+		}
+		next.Retry[pollErr.Name] = retryChange{Since: since(pollErr.Name), Attempts: attempts}
+	}
+	if err := c.setCursor(next); err != nil {
+		errs = append(errs, err)
+	}
+	return pkgs, errs
+}
@@ -0,0 +1,255 @@
+package npm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/events"
+	"github.com/ossf/package-feeds/feeds"
+	testutils "github.com/ossf/package-feeds/utils/test"
+)
+
+func TestNpmChangesFirstPoll(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/": npmDBInfoResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	store := feeds.NewFileCursorStore(filepath.Join(t.TempDir(), "cursors.json"))
+
+	feed, err := New(feeds.FeedOptions{
+		Mode:        modeChanges,
+		CursorStore: store,
+	}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create npm feed: %v", err)
+	}
+	feed.changesURL = srv.URL
+
+	// The first poll without a stored sequence only establishes the sequence.
+	pkgs, errs := feed.Latest(context.Background(), time.Time{})
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 0 {
+		t.Fatalf("Latest() produced %v packages on the first poll instead of 0", len(pkgs))
+	}
+	stored, err := store.Get(FeedName)
+	if err != nil {
+		t.Fatalf("Failed to get stored cursor: %v", err)
+	}
+	cursor := &changesCursor{}
+	if err := json.Unmarshal([]byte(stored), cursor); err != nil {
+		t.Fatalf("Failed to parse stored cursor %q: %v", stored, err)
+	}
+	if cursor.Seq != "42" {
+		t.Errorf("Stored sequence is %q instead of the expected 42", cursor.Seq)
+	}
+}
+
+func TestNpmChangesLatest(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		changesPath:   npmChangesResponse,
+		"/FooPackage": fooVersionInfoResponse,
+		"/BarPackage": barVersionInfoResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	store := feeds.NewFileCursorStore(filepath.Join(t.TempDir(), "cursors.json"))
+	// Resume from a cursor stored by a previous run.
+	stored, err := json.Marshal(changesCursor{
+		Seq:    "10-abc",
+		Polled: time.Date(2021, 5, 11, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal cursor: %v", err)
+	}
+	if err := store.Set(FeedName, string(stored)); err != nil {
+		t.Fatalf("Failed to store cursor: %v", err)
+	}
+
+	feed, err := New(feeds.FeedOptions{
+		Mode:        modeChanges,
+		CursorStore: store,
+	}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create npm feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+	feed.changesURL = srv.URL
+
+	pkgs, errs := feed.Latest(context.Background(), time.Now())
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	// Only versions created since the previous poll are emitted.
+	const expectedNumPackages = 2
+	if len(pkgs) != expectedNumPackages {
+		t.Fatalf("Latest() produced %v packages instead of the expected %v", len(pkgs), expectedNumPackages)
+	}
+	if pkgs[0].Name != "FooPackage" || pkgs[0].Version != "1.0.1" {
+		t.Errorf("Unexpected package %s@%s found in place of FooPackage@1.0.1", pkgs[0].Name, pkgs[0].Version)
+	}
+	if pkgs[1].Name != "BarPackage" || pkgs[1].Version != "0.5.0-alpha" {
+		t.Errorf("Unexpected package %s@%s found in place of BarPackage@0.5.0-alpha", pkgs[1].Name, pkgs[1].Version)
+	}
+
+	stored2, err := store.Get(FeedName)
+	if err != nil {
+		t.Fatalf("Failed to get stored cursor: %v", err)
+	}
+	cursor := &changesCursor{}
+	if err := json.Unmarshal([]byte(stored2), cursor); err != nil {
+		t.Fatalf("Failed to parse stored cursor %q: %v", stored2, err)
+	}
+	if cursor.Seq != "14-def" {
+		t.Errorf("Stored sequence is %q instead of the expected 14-def", cursor.Seq)
+	}
+}
+
+func TestNpmChangesRetryFailedPackages(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	barRequests := 0
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		changesPath: func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("since") == "14-def" {
+				if _, err := w.Write([]byte(`{"last_seq": "14-def", "pending": 0}`)); err != nil {
+					http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+				}
+				return
+			}
+			npmChangesResponse(w, r)
+		},
+		"/FooPackage": fooVersionInfoResponse,
+		// BarPackage fails to be fetched by the first poll.
+		"/BarPackage": func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			barRequests++
+			failed := barRequests == 1
+			mu.Unlock()
+			if failed {
+				http.Error(w, `{"error":"unavailable"}`, http.StatusNotFound)
+				return
+			}
+			barVersionInfoResponse(w, r)
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	store := feeds.NewFileCursorStore(filepath.Join(t.TempDir(), "cursors.json"))
+	stored, err := json.Marshal(changesCursor{
+		Seq:    "10-abc",
+		Polled: time.Date(2021, 5, 11, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal cursor: %v", err)
+	}
+	if err := store.Set(FeedName, string(stored)); err != nil {
+		t.Fatalf("Failed to store cursor: %v", err)
+	}
+
+	feed, err := New(feeds.FeedOptions{
+		Mode:        modeChanges,
+		CursorStore: store,
+	}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create npm feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+	feed.changesURL = srv.URL
+
+	pkgs, errs := feed.Latest(context.Background(), time.Now())
+	if len(errs) != 1 {
+		t.Fatalf("feed.Latest returned %v errors instead of the expected 1", len(errs))
+	}
+	var pollErr feeds.PackagePollError
+	if !errors.As(errs[0], &pollErr) || pollErr.Name != "BarPackage" {
+		t.Fatalf("feed.Latest returned %v instead of a poll error for BarPackage", errs[0])
+	}
+	if len(pkgs) != 1 || pkgs[0].Name != "FooPackage" {
+		t.Fatalf("Latest() produced %v packages instead of only FooPackage", len(pkgs))
+	}
+
+	// The next poll fetches BarPackage again, although no further changes were made.
+	pkgs, errs = feed.Latest(context.Background(), time.Now())
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 1 || pkgs[0].Name != "BarPackage" || pkgs[0].Version != "0.5.0-alpha" {
+		t.Fatalf("Latest() produced %v packages instead of only BarPackage@0.5.0-alpha", len(pkgs))
+	}
+	stored2, err := store.Get(FeedName)
+	if err != nil {
+		t.Fatalf("Failed to get stored cursor: %v", err)
+	}
+	cursor := &changesCursor{}
+	if err := json.Unmarshal([]byte(stored2), cursor); err != nil {
+		t.Fatalf("Failed to parse stored cursor %q: %v", stored2, err)
+	}
+	if cursor.Seq != "14-def" || len(cursor.Retry) != 0 {
+		t.Errorf("Stored cursor is %q instead of sequence 14-def without retries", stored2)
+	}
+}
+
+func TestNpmChangesWithPackages(t *testing.T) {
+	t.Parallel()
+
+	packages := []string{"FooPackage"}
+	_, err := New(feeds.FeedOptions{
+		Mode:     modeChanges,
+		Packages: &packages,
+	}, events.NewNullHandler())
+	if err == nil {
+		t.Fatalf("Expected error creating changes npm feed with packages")
+	}
+}
+
+func TestNpmUnsupportedMode(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(feeds.FeedOptions{Mode: "foo"}, events.NewNullHandler())
+	if !errors.Is(err, errUnsupportedMode) {
+		t.Fatalf("New() returned %v instead of the expected %v", err, errUnsupportedMode)
+	}
+}
+
+func npmDBInfoResponse(w http.ResponseWriter, r *http.Request) {
+	_, err := w.Write([]byte(`{"db_name": "registry", "doc_count": 3000000, "update_seq": 42}`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
+
+// Streams the changes as a continuous feed, including a heartbeat. Includes a deleted
+// package and a design document, which should be ignored, and a package changed twice,
+// which should only be fetched once.
+func npmChangesResponse(w http.ResponseWriter, r *http.Request) {
+	if since := r.URL.Query().Get("since"); since != "10-abc" {
+		http.Error(w, "unexpected since: "+since, http.StatusBadRequest)
+		return
+	}
+	if r.URL.Query().Get("feed") != "continuous" {
+		http.Error(w, "expected a continuous feed", http.StatusBadRequest)
+		return
+	}
+	_, err := w.Write([]byte(`{"seq": "11-abc", "id": "FooPackage", "changes": [{"rev": "1-a"}]}
+{"seq": "12-abc", "id": "_design/app", "changes": [{"rev": "1-b"}]}
+
+{"seq": "13-abc", "id": "BazPackage", "changes": [{"rev": "2-c"}], "deleted": true}
+{"seq": "13-def", "id": "FooPackage", "changes": [{"rev": "2-a"}]}
+{"seq": "14-def", "id": "BarPackage", "changes": [{"rev": "1-d"}]}
+{"last_seq": "14-def", "pending": 0}
+`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
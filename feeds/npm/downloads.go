@@ -0,0 +1,93 @@
+package npm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/metrics"
+	"github.com/ossf/package-feeds/utils"
+)
+
+const (
+	downloadsPath = "/downloads/point/last-week"
+
+	defaultDownloadsURL = "https://api.npmjs.org/"
+)
+
+type downloadsResponse struct {
+	Downloads int `json:"downloads"`
+}
+
+// Fetches the number of downloads of a package in the last week.
+func fetchDownloads(ctx context.Context, api registry, name string) (int, error) {
+	start := time.Now()
+	resp, err := api.get(ctx, downloadsPath+"/"+name)
+	metrics.ObserveRegistryRequest(FeedName, "downloads", start)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	err = utils.CheckResponseStatus(resp)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch npm download counts: %w", err)
+	}
+	body, err := utils.LimitedReadAll(resp.Body, api.maxResponseSize)
+	if err != nil {
+		return 0, err
+	}
+	downloads := &downloadsResponse{}
+	if err := json.Unmarshal(body, downloads); err != nil {
+		return 0, fmt.Errorf("%w : %v", errJSON, err)
+	}
+	return downloads.Downloads, nil
+}
+
+// Sets the number of downloads in the last week of each package, the count is fetched once
+// per package name with at most maxConcurrentFetches requests in flight. Counts which can't
+// be fetched are logged and left unset, the packages are still emitted.
+func enrichDownloads(ctx context.Context, api registry, pkgs []*feeds.Package) {
+	byName := map[string][]*feeds.Package{}
+	for _, pkg := range pkgs {
+		byName[pkg.Name] = append(byName[pkg.Name], pkg)
+	}
+	names := make(chan string, len(byName))
+	for name := range byName {
+		names <- name
+	}
+	close(names)
+
+	type result struct {
+		name      string
+		downloads int
+		err       error
+	}
+	results := make(chan result)
+	workers := maxConcurrentFetches
+	if len(byName) < workers {
+		workers = len(byName)
+	}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for name := range names {
+				downloads, err := fetchDownloads(ctx, api, name)
+				results <- result{name: name, downloads: downloads, err: err}
+			}
+		}()
+	}
+	for range byName {
+		r := <-results
+		if r.err != nil {
+			log.WithError(r.err).WithField("name", r.name).Warn("Failed to fetch npm download counts")
+			continue
+		}
+		for _, pkg := range byName[r.name] {
+			pkg.Downloads = r.downloads
+		}
+	}
+}
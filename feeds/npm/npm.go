@@ -1,35 +1,87 @@
 package npm
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
-	"encoding/xml"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
+	"net/url"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	log "github.com/sirupsen/logrus"
+
 	"github.com/ossf/package-feeds/events"
 	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/metrics"
 	"github.com/ossf/package-feeds/utils"
+	"github.com/ossf/package-feeds/utils/feedparser"
 )
 
 const (
 	FeedName = "npm"
 	rssPath  = "/-/rss"
+
+	modeRSS     = "rss"
+	modeChanges = "changes"
+	modeSearch  = "search"
+
+	defaultRegistryURL = "https://registry.npmjs.org/"
+	requestTimeout     = 10 * time.Second
+
+	// The maximum number of critical packages fetched concurrently.
+	maxConcurrentFetches = 16
 )
 
 var (
-	httpClient = &http.Client{
-		Timeout: 10 * time.Second,
-	}
+	httpClient     = utils.NewHTTPClient(requestTimeout)
 	errJSON        = errors.New("error unmarshaling json response internally")
 	errUnpublished = errors.New("package is currently unpublished")
+	errTimestamps  = errors.New("no version timestamps could be parsed")
+	errRegistryURL = errors.New("invalid npm registry url")
+
+	errConflictingRegistries = errors.New("only one of registry_url, base_urls and registries may be provided")
+	errRegistryTokenScope    = errors.New("registry_token can't be used with several registries, " +
+		"tokens are set per registry with registries")
+
+	errUnsupportedMode = errors.New("unsupported npm feed mode")
 )
 
-type Response struct {
-	PackageEvents []PackageEvent `xml:"channel>item"`
+// An npm compatible registry, requests are authenticated with a bearer token when set.
+// Response bodies larger than maxResponseSize are rejected.
+type registry struct {
+	baseURL         string
+	token           string
+	maxResponseSize int64
+	// Whether versions read from package documents carry their manifest as Raw.
+	includeRaw bool
+}
+
+func (r registry) get(ctx context.Context, path string) (*http.Response, error) {
+	return r.getWithQuery(ctx, path, nil)
+}
+
+func (r registry) getWithQuery(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	reqURL, err := utils.URLPathJoin(r.baseURL, path)
+	if err != nil {
+		return nil, err
+	}
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+	return httpClient.Do(req)
 }
 
 type Package struct {
@@ -37,19 +89,61 @@ type Package struct {
 	CreatedDate time.Time
 	Version     string
 	Unpublished bool
+	Republished bool
+	Integrity   string
+	// The predicate types of the attestations published with the version.
+	Attestations []string
+	SourceRepo   string
+	// Whether the version runs scripts on install.
+	HasInstallScripts bool
+	// The message given when the version was deprecated, empty unless deprecated.
+	Deprecation string
+	// The unpacked size and file count of the tarball, zero when not reported.
+	UnpackedSize int64
+	FileCount    int
+	// The normalized license declared by the version.
+	License string
+	// The manifest of the version, its entry in the `versions` object of the package
+	// document, unmodified.
+	Raw json.RawMessage
+}
+
+// Returned when a package has been unpublished, carrying the versions listed in the
+// `unpublished` block of the package.
+type unpublishedError struct {
+	pkgTitle string
+	versions []string
+}
+
+func newUnpublishedError(pkgTitle string, unpublished interface{}) unpublishedError {
+	err := unpublishedError{pkgTitle: pkgTitle, versions: []string{}}
+	info, _ := unpublished.(map[string]interface{})
+	versions, _ := info["versions"].([]interface{})
+	for _, version := range versions {
+		if v, ok := version.(string); ok {
+			err.versions = append(err.versions, v)
+		}
+	}
+	return err
+}
+
+func (err unpublishedError) Error() string {
+	return fmt.Sprintf("%s %v", err.pkgTitle, errUnpublished)
+}
+
+func (err unpublishedError) Unwrap() error {
+	return errUnpublished
 }
 
 type PackageEvent struct {
-	Title string `xml:"title"`
+	Title string
 }
 
 // Returns a slice of PackageEvent{} structs.
-func fetchPackageEvents(baseURL string) ([]PackageEvent, error) {
-	pkgURL, err := utils.URLPathJoin(baseURL, rssPath)
-	if err != nil {
-		return nil, err
-	}
-	resp, err := httpClient.Get(pkgURL)
+func fetchPackageEvents(ctx context.Context, reg registry) ([]PackageEvent, error) {
+	start := time.Now()
+	resp, err := reg.get(ctx, rssPath)
+	metrics.ObserveRegistryRequest(FeedName, "rss", start)
 	if err != nil {
 		return nil, err
 	}
@@ -59,23 +153,26 @@ func fetchPackageEvents(baseURL string) ([]PackageEvent, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch npm package data: %w", err)
 	}
-	rssResponse := &Response{}
-	reader := utils.NewUTF8OnlyReader(resp.Body)
-	err = xml.NewDecoder(reader).Decode(rssResponse)
+	entries, err := feedparser.Parse(utils.NewLimitedReader(resp.Body, reg.maxResponseSize))
 	if err != nil {
 		return nil, err
 	}
-	return rssResponse.PackageEvents, nil
+	packageEvents := make([]PackageEvent, 0, len(entries))
+	for _, entry := range entries {
+		packageEvents = append(packageEvents, PackageEvent{Title: entry.Title})
+	}
+	return packageEvents, nil
 }
 
 // Gets the package version & corresponding created date from NPM. Returns
-// a slice of {}Package.
-func fetchPackage(baseURL, pkgTitle string) ([]*Package, error) {
-	versionURL, err := utils.URLPathJoin(baseURL, pkgTitle)
-	if err != nil {
-		return nil, err
-	}
-	resp, err := httpClient.Get(versionURL)
+// a slice of {}Package. When republished is non-nil, versions republished since the
+// package was last fetched are marked as republished. Versions whose created date can't be
+// parsed are skipped and reported, an error is only returned when no version could be parsed.
+func fetchPackage(ctx context.Context, reg registry, pkgTitle string,
+	republished *republishDetector, eventHandler *events.Handler) ([]*Package, error) {
+	start := time.Now()
+	resp, err := reg.get(ctx, pkgTitle)
+	metrics.ObserveRegistryRequest(FeedName, "package", start)
 	if err != nil {
 		return nil, err
 	}
@@ -86,7 +183,7 @@ func fetchPackage(baseURL, pkgTitle string) ([]*Package, error) {
 		return nil, fmt.Errorf("failed to fetch npm package version data: %w", err)
 	}
 
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := utils.LimitedReadAll(resp.Body, reg.maxResponseSize)
 	if err != nil {
 		return nil, err
 	}
@@ -108,10 +205,22 @@ func fetchPackage(baseURL, pkgTitle string) ([]*Package, error) {
 	// versions that no longer exist. For a given 24h period no further versions can
 	// be uploaded, with any previous versions never being available again.
 	// https://www.npmjs.com/policies/unpublish
-	_, unPublished := versions["unpublished"]
+	unpublished, unPublished := versions["unpublished"]
 
 	if unPublished {
-		return nil, fmt.Errorf("%s %w", pkgTitle, errUnpublished)
+		return nil, newUnpublishedError(pkgTitle, unpublished)
+	}
+
+	versionInfo, _ := jsonMap["versions"].(map[string]interface{})
+	pkgRepo := sourceRepo(jsonMap)
+	// The manifests are only kept as they were received when needed, as documents are large.
+	var manifests struct {
+		Versions map[string]json.RawMessage `json:"versions"`
+	}
+	if reg.includeRaw {
+		if err := json.Unmarshal(body, &manifests); err != nil {
+			return nil, fmt.Errorf("%w : %v for package %s", errJSON, err, pkgTitle)
+		}
 	}
 
 	// Remove redundant entries in map, we're only interested in actual version pairs.
@@ -121,29 +230,232 @@ func fetchPackage(baseURL, pkgTitle string) ([]*Package, error) {
 	// Create slice of Package{} to allow sorting of a slice, as maps
 	// are unordered.
 	versionSlice := []*Package{}
+	malformed := []string{}
 	for version, timestamp := range versions {
-		date, err := time.Parse(time.RFC3339, timestamp.(string))
+		// The registry keeps the time of versions which were unpublished, only the versions
+		// listed by the `versions` object still exist.
+		if _, listed := versionInfo[version]; versionInfo != nil && !listed {
+			continue
+		}
+		value, _ := timestamp.(string)
+		date, err := time.Parse(time.RFC3339, value)
 		if err != nil {
-			return nil, err
+			malformed = append(malformed, version)
+			continue
+		}
+		// Versions published without a repository fall back to that of the package.
+		repo := sourceRepo(versionInfo[version])
+		if repo == "" {
+			repo = pkgRepo
+		}
+		unpackedSize, fileCount := tarballStats(versionInfo[version])
+		versionSlice = append(versionSlice, &Package{
+			Title:        pkgTitle,
+			CreatedDate:  date,
+			Version:      version,
+			Integrity:    integrity(versionInfo[version]),
+			Attestations: attestations(versionInfo[version]),
+			SourceRepo:   repo,
+
+			HasInstallScripts: hasInstallScripts(versionInfo[version]),
+			Deprecation:       deprecation(versionInfo[version]),
+			UnpackedSize:      unpackedSize,
+			FileCount:         fileCount,
+			License:           license(versionInfo[version]),
+			Raw:               manifests.Versions[version],
+		})
+	}
+
+	if len(malformed) > 0 {
+		sort.Strings(malformed)
+		reportMalformedTimestamps(eventHandler, pkgTitle, malformed)
+		if len(versionSlice) == 0 {
+			return nil, fmt.Errorf("%w : %v", errTimestamps, pkgTitle)
 		}
-		versionSlice = append(versionSlice,
-			&Package{Title: pkgTitle, CreatedDate: date, Version: version})
 	}
 
-	// Sort slice of versions into order of most recent.
-	sort.SliceStable(versionSlice, func(i, j int) bool {
-		return versionSlice[j].CreatedDate.Before(versionSlice[i].CreatedDate)
+	// Sort slice of versions into order of most recent, versions sharing a created date are
+	// ordered by version as map iteration order is random.
+	sort.Slice(versionSlice, func(i, j int) bool {
+		if !versionSlice[i].CreatedDate.Equal(versionSlice[j].CreatedDate) {
+			return versionSlice[j].CreatedDate.Before(versionSlice[i].CreatedDate)
+		}
+		return versionSlice[i].Version < versionSlice[j].Version
 	})
 
+	if republished != nil {
+		listed := map[string]bool{}
+		for version := range versionInfo {
+			listed[version] = true
+		}
+		republished.detect(reg.baseURL, pkgTitle, versionSlice, listed)
+	}
+
 	return versionSlice, nil
 }
 
-func fetchAllPackages(url string) ([]*feeds.Package, []error) {
+// Logs, counts and dispatches an event for the versions of a package skipped as their created
+// date couldn't be parsed, so that a quirk of the registry data doesn't go unnoticed.
+func reportMalformedTimestamps(eventHandler *events.Handler, pkgTitle string, versions []string) {
+	metrics.MalformedTimestamps.WithLabelValues(FeedName).Add(float64(len(versions)))
+	log.WithFields(log.Fields{
+		"feed":     FeedName,
+		"package":  pkgTitle,
+		"versions": versions,
+	}).Warn("Skipped versions with malformed timestamps")
+	err := eventHandler.DispatchEvent(events.MalformedTimestampEvent{
+		Feed:     FeedName,
+		Package:  pkgTitle,
+		Versions: versions,
+	})
+	if err != nil {
+		log.WithError(err).Error("failed to dispatch event via event handler")
+	}
+}
+
+// Returns the integrity of a version's tarball from the `dist` object of the version, as
+// a subresource integrity string e.g. "sha512-...". Older versions only have a hex encoded
+// sha1 `shasum`, which is converted to the same form. Returns an empty string if neither is
+// present.
+func integrity(versionInfo interface{}) string {
+	info, _ := versionInfo.(map[string]interface{})
+	dist, _ := info["dist"].(map[string]interface{})
+	if sri, ok := dist["integrity"].(string); ok && sri != "" {
+		return sri
+	}
+	shasum, _ := dist["shasum"].(string)
+	sum, err := hex.DecodeString(shasum)
+	if err != nil || len(sum) == 0 {
+		return ""
+	}
+	return "sha1-" + base64.StdEncoding.EncodeToString(sum)
+}
+
+// Returns the predicate types of the attestations published with a version from the `dist`
+// object of the version, e.g. "https://slsa.dev/provenance/v1" for versions published with
+// provenance. Versions without attestations return nil.
+func attestations(versionInfo interface{}) []string {
+	info, _ := versionInfo.(map[string]interface{})
+	dist, _ := info["dist"].(map[string]interface{})
+	attestations, _ := dist["attestations"].(map[string]interface{})
+	provenance, _ := attestations["provenance"].(map[string]interface{})
+	if predicateType, ok := provenance["predicateType"].(string); ok && predicateType != "" {
+		return []string{predicateType}
+	}
+	return nil
+}
+
+// Returns the unpacked size in bytes and the number of files of a version's tarball from the
+// `dist` object of the version. Versions published by older clients report neither, and
+// return zero.
+func tarballStats(versionInfo interface{}) (int64, int) {
+	info, _ := versionInfo.(map[string]interface{})
+	dist, _ := info["dist"].(map[string]interface{})
+	unpackedSize, _ := dist["unpackedSize"].(float64)
+	fileCount, _ := dist["fileCount"].(float64)
+	return int64(unpackedSize), int(fileCount)
+}
+
+// Returns the license declared by a version, normalized toward an SPDX identifier. The
+// `license` of a version is usually an SPDX expression, but older versions may give an object
+// with a `type`, or a `licenses` array of such objects which are combined with OR. Returns an
+// empty string if the version declares no license.
+func license(versionInfo interface{}) string {
+	info, _ := versionInfo.(map[string]interface{})
+	if license := licenseType(info["license"]); license != "" {
+		return license
+	}
+	licenses, _ := info["licenses"].([]interface{})
+	types := []string{}
+	for _, license := range licenses {
+		if license := licenseType(license); license != "" {
+			types = append(types, license)
+		}
+	}
+	if len(types) > 1 {
+		return "(" + strings.Join(types, " OR ") + ")"
+	}
+	return strings.Join(types, "")
+}
+
+// Returns the normalized license of either a string or an object with a `type`.
+func licenseType(license interface{}) string {
+	switch license := license.(type) {
+	case string:
+		return feeds.NormalizeLicense(license)
+	case map[string]interface{}:
+		licenseType, _ := license["type"].(string)
+		return feeds.NormalizeLicense(licenseType)
+	}
+	return ""
+}
+
+// The lifecycle scripts run by npm when a package is installed.
+var installScripts = []string{"preinstall", "install", "postinstall"}
+
+// Returns whether a version runs scripts on install, from the `scripts` of the version or
+// the `hasInstallScript` flag set by the registry, e.g. for packages with a binding.gyp.
+func hasInstallScripts(versionInfo interface{}) bool {
+	info, _ := versionInfo.(map[string]interface{})
+	if flag, ok := info["hasInstallScript"].(bool); ok && flag {
+		return true
+	}
+	scripts, _ := info["scripts"].(map[string]interface{})
+	for _, name := range installScripts {
+		if script, ok := scripts[name].(string); ok && script != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the message of a deprecated version, from the `deprecated` field of the version.
+// Versions which aren't deprecated have no field, or an empty message once un-deprecated.
+func deprecation(versionInfo interface{}) string {
+	info, _ := versionInfo.(map[string]interface{})
+	message, _ := info["deprecated"].(string)
+	return message
+}
+
+// Returns the normalized URL of the source repository from the `repository` field of a
+// version or package, which is either an object with a `url` or a string such as
+// "github:user/repo". Returns an empty string if there is no usable repository.
+func sourceRepo(info interface{}) string {
+	infoMap, _ := info.(map[string]interface{})
+	switch repo := infoMap["repository"].(type) {
+	case string:
+		return feeds.NormalizeSourceRepo(repo)
+	case map[string]interface{}:
+		repoURL, _ := repo["url"].(string)
+		return feeds.NormalizeSourceRepo(repoURL)
+	}
+	return ""
+}
+
+func newFeedPackage(pkg *Package) *feeds.Package {
+	feedPkg := feeds.NewPackage(pkg.CreatedDate, pkg.Title, pkg.Version, FeedName, feeds.EcosystemNPM)
+	feedPkg.Republished = pkg.Republished
+	feedPkg.Integrity = pkg.Integrity
+	feedPkg.Attestations = pkg.Attestations
+	feedPkg.Signed = len(pkg.Attestations) > 0
+	feedPkg.SourceRepo = pkg.SourceRepo
+	feedPkg.HasInstallScripts = pkg.HasInstallScripts
+	feedPkg.Deprecated = pkg.Deprecation != ""
+	feedPkg.DeprecationMessage = pkg.Deprecation
+	feedPkg.UnpackedSize = pkg.UnpackedSize
+	feedPkg.FileCount = pkg.FileCount
+	feedPkg.License = pkg.License
+	feedPkg.Raw = pkg.Raw
+	return feedPkg
+}
+
+func fetchAllPackages(ctx context.Context, reg registry, republished *republishDetector,
+	denylist *feeds.Denylist, eventHandler *events.Handler) ([]*feeds.Package, []error) {
 	pkgs := []*feeds.Package{}
 	errs := []error{}
 	packageChannel := make(chan []*Package)
 	errChannel := make(chan error)
-	packageEvents, err := fetchPackageEvents(url)
+	packageEvents, err := fetchPackageEvents(ctx, reg)
 	if err != nil {
 		// If we can't generate package events then return early.
 		return pkgs, append(errs, err)
@@ -151,13 +463,20 @@ func fetchAllPackages(url string) ([]*feeds.Package, []error) {
 	// Handle the possibility of multiple releases of the same package
 	// within the polled `packages` slice.
 	uniquePackages := make(map[string]int)
+	numDenied := 0
 	for _, pkg := range packageEvents {
+		// Denied packages are skipped before the request for their versions is made.
+		if denylist.Denied(pkg.Title) {
+			numDenied++
+			continue
+		}
 		uniquePackages[pkg.Title]++
 	}
+	logDenied(numDenied)
 
 	for pkgTitle, count := range uniquePackages {
 		go func(pkgTitle string, count int) {
-			pkgs, err := fetchPackage(url, pkgTitle)
+			pkgs, err := fetchPackage(ctx, reg, pkgTitle, republished, eventHandler)
 			if err != nil {
 				if !errors.Is(err, errUnpublished) {
 					err = feeds.PackagePollError{Name: pkgTitle, Err: err}
@@ -165,7 +484,11 @@ func fetchAllPackages(url string) ([]*feeds.Package, []error) {
 				errChannel <- err
 				return
 			}
-			// Apply count slice
+			// Apply count slice, the registry may list fewer versions than the rss has events
+			// for the package as unpublished versions and malformed timestamps are skipped.
+			if count > len(pkgs) {
+				count = len(pkgs)
+			}
 			packageChannel <- pkgs[:count]
 		}(pkgTitle, count)
 	}
@@ -174,9 +497,7 @@ func fetchAllPackages(url string) ([]*feeds.Package, []error) {
 		select {
 		case npmPkgs := <-packageChannel:
 			for _, pkg := range npmPkgs {
-				feedPkg := feeds.NewPackage(pkg.CreatedDate, pkg.Title,
-					pkg.Version, FeedName)
-				pkgs = append(pkgs, feedPkg)
+				pkgs = append(pkgs, newFeedPackage(pkg))
 			}
 		case err := <-errChannel:
 			// When polling the 'firehose' unpublished packages
@@ -189,69 +510,354 @@ func fetchAllPackages(url string) ([]*feeds.Package, []error) {
 	return pkgs, errs
 }
 
-func fetchCriticalPackages(url string, packages []string) ([]*feeds.Package, []error) {
-	pkgs := []*feeds.Package{}
+// Records the number of packages skipped by a poll as they match the denylist.
+func logDenied(numDenied int) {
+	if numDenied == 0 {
+		return
+	}
+	metrics.DeniedPackages.WithLabelValues(FeedName).Add(float64(numDenied))
+	log.WithFields(log.Fields{
+		"feed":       FeedName,
+		"num_denied": numDenied,
+	}).Print("Skipped packages matching the denylist")
+}
+
+// The versions fetched for a package.
+type packageVersions struct {
+	title    string
+	versions []*Package
+}
+
+// Fetches the versions of several packages concurrently, with at most maxConcurrentFetches
+// requests in flight. Errors other than errUnpublished are wrapped in a PackagePollError.
+func fetchPackageVersions(ctx context.Context, reg registry, packages []string,
+	republished *republishDetector, eventHandler *events.Handler) ([]packageVersions, []error) {
+	results := []packageVersions{}
 	errs := []error{}
-	packageChannel := make(chan []*Package)
+	packageChannel := make(chan packageVersions)
 	errChannel := make(chan error)
 
+	titles := make(chan string, len(packages))
 	for _, pkgTitle := range packages {
-		go func(pkgTitle string) {
-			pkgs, err := fetchPackage(url, pkgTitle)
-			if err != nil {
-				if !errors.Is(err, errUnpublished) {
-					err = feeds.PackagePollError{Name: pkgTitle, Err: err}
+		titles <- pkgTitle
+	}
+	close(titles)
+
+	workers := maxConcurrentFetches
+	if len(packages) < workers {
+		workers = len(packages)
+	}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for pkgTitle := range titles {
+				if err := ctx.Err(); err != nil {
+					// The poll was cancelled or passed its deadline, the remaining packages
+					// are reported as failed so that they are polled again.
+					errChannel <- feeds.PackagePollError{Name: pkgTitle, Err: err}
+					continue
 				}
-				errChannel <- err
-				return
+				pkgs, err := fetchPackage(ctx, reg, pkgTitle, republished, eventHandler)
+				if err != nil {
+					if !errors.Is(err, errUnpublished) {
+						err = feeds.PackagePollError{Name: pkgTitle, Err: err}
+					}
+					errChannel <- err
+					continue
+				}
+				packageChannel <- packageVersions{title: pkgTitle, versions: pkgs}
 			}
-			packageChannel <- pkgs
-		}(pkgTitle)
+		}()
 	}
 
 	for i := 0; i < len(packages); i++ {
 		select {
-		case npmPkgs := <-packageChannel:
-			for _, pkg := range npmPkgs {
-				feedPkg := feeds.NewPackage(pkg.CreatedDate, pkg.Title,
-					pkg.Version, FeedName)
-				pkgs = append(pkgs, feedPkg)
-			}
+		case result := <-packageChannel:
+			results = append(results, result)
 		case err := <-errChannel:
-			// Assume if a package has been unpublished that it is a valid reason
-			// to log the error when polling for 'critical' packages. This could
-			// be changed for a 'lossy' type event instead. Further packages should
-			// be proccessed.
 			errs = append(errs, err)
 		}
 	}
+	return results, errs
+}
+
+func fetchCriticalPackages(ctx context.Context, reg registry, packages []string, republished *republishDetector,
+	eventHandler *events.Handler, unpublishEvents bool) ([]*feeds.Package, []error) {
+	pkgs := []*feeds.Package{}
+	// Assume if a package has been unpublished that it is a valid reason to log the error
+	// when polling for 'critical' packages, unless an event is dispatched instead. Further
+	// packages should be proccessed.
+	results, fetchErrs := fetchPackageVersions(ctx, reg, packages, republished, eventHandler)
+	errs := []error{}
+	for _, err := range fetchErrs {
+		var unpublishedErr unpublishedError
+		if !unpublishEvents || !errors.As(err, &unpublishedErr) {
+			errs = append(errs, err)
+			continue
+		}
+		err := eventHandler.DispatchEvent(events.UnpublishEvent{
+			Feed:     FeedName,
+			Package:  unpublishedErr.pkgTitle,
+			Versions: unpublishedErr.versions,
+		})
+		if err != nil {
+			log.WithError(err).Error("failed to dispatch event via event handler")
+		}
+	}
+	for _, critical := range results {
+		if len(critical.versions) == 0 {
+			// The package exists but none of its versions could be resolved, which
+			// is likely misconfiguration or unexpected registry data.
+			err := eventHandler.DispatchEvent(events.EmptyVersionsEvent{
+				Feed:    FeedName,
+				Package: critical.title,
+			})
+			if err != nil {
+				log.WithError(err).Error("failed to dispatch event via event handler")
+			}
+		}
+		for _, pkg := range critical.versions {
+			pkgs = append(pkgs, newFeedPackage(pkg))
+		}
+	}
 	return pkgs, errs
 }
 
 type Feed struct {
 	packages         *[]string
 	lossyFeedAlerter *feeds.LossyFeedAlerter
+	eventHandler     *events.Handler
 	baseURL          string
+	token            string
+	maxResponseSize  int64
+	intervals        *feeds.PackageIntervals
 	options          feeds.FeedOptions
+
+	// Set when polling the replication database changes rather than RSS.
+	changes    *changesPoller
+	changesURL string
+
+	// The registries polled in addition to baseURL, each with its own token.
+	additionalRegistries []feeds.RegistryOptions
+
+	// The base URL of the npm API serving download counts.
+	downloadsURL string
+
+	// Matches the names of packages skipped when polling all packages.
+	denylist *feeds.Denylist
+
+	// The versions previously seen of each critical package.
+	seenVersions *seenVersions
+
+	// Set when versions which were republished are flagged.
+	republished *republishDetector
+}
+
+func init() { //nolint:gochecknoinits
+	feeds.Register(FeedName, func(options feeds.FeedOptions, eventHandler *events.Handler) (feeds.ScheduledFeed, error) {
+		return New(options, eventHandler)
+	})
+}
+
+// Returns the registries polled by the feed, the registry of registry_url or the default
+// registry authenticated with registry_token, the unauthenticated registries of base_urls, or
+// the registries of registries with their own tokens.
+func configuredRegistries(feedOptions feeds.FeedOptions) ([]feeds.RegistryOptions, error) {
+	configured := 0
+	for _, set := range []bool{
+		feedOptions.RegistryURL != "", len(feedOptions.BaseURLs) > 0, len(feedOptions.Registries) > 0,
+	} {
+		if set {
+			configured++
+		}
+	}
+	if configured > 1 {
+		return nil, errConflictingRegistries
+	}
+	registries := []feeds.RegistryOptions{{URL: defaultRegistryURL, Token: feedOptions.RegistryToken}}
+	switch {
+	case feedOptions.RegistryURL != "":
+		registries[0].URL = feedOptions.RegistryURL
+	case len(feedOptions.BaseURLs) > 0:
+		if feedOptions.RegistryToken != "" {
+			return nil, errRegistryTokenScope
+		}
+		registries = make([]feeds.RegistryOptions, 0, len(feedOptions.BaseURLs))
+		for _, baseURL := range feedOptions.BaseURLs {
+			registries = append(registries, feeds.RegistryOptions{URL: baseURL})
+		}
+	case len(feedOptions.Registries) > 0:
+		if feedOptions.RegistryToken != "" {
+			return nil, errRegistryTokenScope
+		}
+		registries = feedOptions.Registries
+	}
+	for _, reg := range registries {
+		u, err := url.Parse(reg.URL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("%w : %v", errRegistryURL, reg.URL)
+		}
+	}
+	return registries, nil
 }
 
 func New(feedOptions feeds.FeedOptions, eventHandler *events.Handler) (*Feed, error) {
-	return &Feed{
-		packages:         feedOptions.Packages,
-		lossyFeedAlerter: feeds.NewLossyFeedAlerter(eventHandler),
-		baseURL:          "https://registry.npmjs.org/",
-		options:          feedOptions,
-	}, nil
+	registries, err := configuredRegistries(feedOptions)
+	if err != nil {
+		return nil, err
+	}
+	intervals, err := feeds.NewPackageIntervals(feedOptions)
+	if err != nil {
+		return nil, err
+	}
+	denylist, err := feeds.NewDenylist(feedOptions.Denylist)
+	if err != nil {
+		return nil, err
+	}
+	maxResponseSize := int64(utils.DefaultMaxResponseSize)
+	if feedOptions.MaxResponseSize > 0 {
+		maxResponseSize = feedOptions.MaxResponseSize
+	}
+	feed := &Feed{
+		packages:             feedOptions.Packages,
+		lossyFeedAlerter:     feeds.NewLossyFeedAlerter(eventHandler),
+		eventHandler:         eventHandler,
+		baseURL:              registries[0].URL,
+		token:                registries[0].Token,
+		additionalRegistries: registries[1:],
+		maxResponseSize:      maxResponseSize,
+		intervals:            intervals,
+		options:              feedOptions,
+		changesURL:           defaultChangesURL,
+		downloadsURL:         defaultDownloadsURL,
+		denylist:             denylist,
+		seenVersions:         newSeenVersions(feedOptions.CursorStore),
+	}
+	if feedOptions.RepublishThreshold > 0 {
+		feed.republished = newRepublishDetector(feedOptions.RepublishThreshold, feedOptions.Clock)
+	}
+	// Packages configured to be polled are never denied.
+	if denylist != nil && (feedOptions.Packages != nil || feedOptions.Mode == modeChanges) {
+		return nil, feeds.UnsupportedOptionError{
+			Feed:   FeedName,
+			Option: "denylist",
+		}
+	}
+	switch feedOptions.Mode {
+	case "", modeRSS:
+	case modeSearch:
+		if feedOptions.Packages != nil {
+			return nil, feeds.UnsupportedOptionError{
+				Feed:   FeedName,
+				Option: "packages",
+			}
+		}
+		log.WithFields(log.Fields{
+			"feed":      FeedName,
+			"max_pages": maxSearchPages,
+		}).Warn("npm search mode is best-effort, search results are ranked by relevance so packages " +
+			"beyond the pages fetched are missed, and only the latest version of each package is emitted")
+	case modeChanges:
+		if feedOptions.Packages != nil {
+			return nil, feeds.UnsupportedOptionError{
+				Feed:   FeedName,
+				Option: "packages",
+			}
+		}
+		// The changes of a single replication database are followed.
+		if len(feed.additionalRegistries) > 0 {
+			return nil, feeds.UnsupportedOptionError{
+				Feed:   FeedName,
+				Option: "base_urls",
+			}
+		}
+		feed.changes = newChangesPoller(feedOptions.CursorStore, feedOptions.Clock)
+	default:
+		return nil, fmt.Errorf("%w : %v", errUnsupportedMode, feedOptions.Mode)
+	}
+	return feed, nil
 }
 
-func (feed Feed) Latest(cutoff time.Time) ([]*feeds.Package, []error) {
+func (feed Feed) Latest(ctx context.Context, cutoff time.Time) ([]*feeds.Package, []error) {
+	pkgs, errs := feed.latest(ctx, cutoff)
+	if !feed.options.InstallScripts {
+		// Install scripts are found in the package documents fetched by every poll, but are
+		// only emitted when enabled.
+		for _, pkg := range pkgs {
+			pkg.HasInstallScripts = false
+		}
+	}
+	if !feed.options.Deprecations {
+		for _, pkg := range pkgs {
+			pkg.Deprecated = false
+			pkg.DeprecationMessage = ""
+		}
+	}
+	if !feed.options.TarballStats {
+		for _, pkg := range pkgs {
+			pkg.UnpackedSize = 0
+			pkg.FileCount = 0
+		}
+	}
+	if !feed.options.Licenses {
+		for _, pkg := range pkgs {
+			pkg.License = ""
+		}
+	}
+	if feed.options.EnrichDownloads && len(pkgs) > 0 {
+		// Download counts are fetched once the cutoff has been applied, to limit the requests made.
+		api := registry{baseURL: feed.downloadsURL, maxResponseSize: feed.maxResponseSize}
+		enrichDownloads(ctx, api, pkgs)
+	}
+	return pkgs, errs
+}
+
+func (feed Feed) latest(ctx context.Context, cutoff time.Time) ([]*feeds.Package, []error) {
 	pkgs := []*feeds.Package{}
 	var errs []error
 
-	if feed.packages == nil {
-		pkgs, errs = fetchAllPackages(feed.baseURL)
+	reg := registry{
+		baseURL:         feed.baseURL,
+		token:           feed.token,
+		maxResponseSize: feed.maxResponseSize,
+		includeRaw:      feed.options.IncludeRaw,
+	}
+	if feed.changes != nil {
+		changesReg := reg
+		changesReg.baseURL = feed.changesURL
+		changesReg.token = ""
+		// Every change since the last poll is processed, so the cutoff is not used.
+		pkgs, errs = feed.changes.latest(ctx, changesReg, reg, feed.republished, feed.eventHandler)
+		feeds.SortByCreatedDate(pkgs)
+		if feed.options.LatestVersionOnly {
+			pkgs = feeds.LatestVersions(pkgs)
+		}
+		return pkgs, errs
+	}
+	if feed.options.Mode == modeSearch {
+		pkgs, errs = feed.pollRegistries(reg, func(reg registry) ([]*feeds.Package, []error) {
+			return fetchSearchPackages(ctx, reg, feed.denylist)
+		})
+	} else if feed.packages == nil {
+		pkgs, errs = feed.pollRegistries(reg, func(reg registry) ([]*feeds.Package, []error) {
+			return fetchAllPackages(ctx, reg, feed.republished, feed.denylist, feed.eventHandler)
+		})
 	} else {
-		pkgs, errs = fetchCriticalPackages(feed.baseURL, *feed.packages)
+		now := feed.intervals.Now()
+		due := feed.intervals.Due(*feed.packages, now)
+		if len(due) == 0 {
+			return pkgs, nil
+		}
+		pkgs, errs = feed.pollRegistries(reg, func(reg registry) ([]*feeds.Package, []error) {
+			return fetchCriticalPackages(ctx, reg, due, feed.republished,
+				feed.eventHandler, feed.options.UnpublishEvents)
+		})
+		if feed.options.UnpublishEvents || feed.options.VersionJumpThreshold > 0 {
+			if err := feed.compareSeenVersions(pkgs, errs); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		// Recorded once the cutoff has been applied, which depends on the previous poll.
+		defer feed.intervals.Polled(due, errs, now)
 	}
 
 	if len(pkgs) == 0 {
@@ -261,21 +867,144 @@ func (feed Feed) Latest(cutoff time.Time) ([]*feeds.Package, []error) {
 
 	// Ensure packages are sorted by CreatedDate in order of most recent, as goroutine
 	// concurrency isn't deterministic.
-	sort.SliceStable(pkgs, func(i, j int) bool {
-		return pkgs[j].CreatedDate.Before(pkgs[i].CreatedDate)
-	})
+	feeds.SortByCreatedDate(pkgs)
 
-	// TODO: Add an event for checking if the previous package list contains entries
-	// that do not exist in the latest package list when polling for critical packages.
-	// This can highlight cases where specific versions have been unpublished.
 	if feed.packages == nil {
 		feed.lossyFeedAlerter.ProcessPackages(FeedName, pkgs)
 	}
+	if feed.options.LatestVersionOnly {
+		pkgs = feeds.LatestVersions(pkgs)
+	}
+	if feed.packages == nil {
+		return feeds.ApplyCutoff(pkgs, cutoff), errs
+	}
 
-	pkgs = feeds.ApplyCutoff(pkgs, cutoff)
+	// Packages polled less often than the feed are filtered by their own cutoff.
+	return feed.intervals.ApplyCutoff(pkgs, cutoff), errs
+}
+
+// Compares the versions of each critical package with those seen by a previous poll,
+// dispatching an UnpublishEvent for packages which no longer list versions when unpublish
+// events are enabled, and a VersionJumpEvent for packages whose latest version leaps by
+// more than the version jump threshold or decreases. Packages which failed to be polled in
+// any registry are not compared, as their versions may be incomplete.
+func (feed Feed) compareSeenVersions(pkgs []*feeds.Package, errs []error) error {
+	failed := map[string]bool{}
+	for _, err := range errs {
+		var pollErr feeds.PackagePollError
+		if errors.As(err, &pollErr) {
+			failed[pollErr.Name] = true
+		}
+	}
+	current := map[string][]string{}
+	for _, pkg := range pkgs {
+		if !failed[pkg.Name] {
+			current[pkg.Name] = append(current[pkg.Name], pkg.Version)
+		}
+	}
+	previous, err := feed.seenVersions.update(current)
+	if err != nil {
+		return fmt.Errorf("failed to record npm package versions: %w", err)
+	}
+	names := []string{}
+	for pkg := range previous {
+		names = append(names, pkg)
+	}
+	sort.Strings(names)
+	dispatched := []events.Event{}
+	for _, pkg := range names {
+		versions := previous[pkg]
+		removed := removedVersions(versions, current[pkg])
+		if feed.options.UnpublishEvents && len(removed) > 0 {
+			dispatched = append(dispatched, events.UnpublishEvent{
+				Feed:     FeedName,
+				Package:  pkg,
+				Versions: removed,
+			})
+		}
+		if feed.options.VersionJumpThreshold <= 0 {
+			continue
+		}
+		if jump := versionJump(pkg, versions, current[pkg], feed.options.VersionJumpThreshold); jump != nil {
+			dispatched = append(dispatched, *jump)
+		}
+	}
+	for _, event := range dispatched {
+		if err := feed.eventHandler.DispatchEvent(event); err != nil {
+			log.WithError(err).Error("failed to dispatch event via event handler")
+		}
+	}
+	return nil
+}
+
+// Polls each registry concurrently, merging the packages found. A version found in several
+// registries is emitted once, taken from the first registry listing it. Each registry is
+// authenticated with its own token. Errors are reported per registry, so a registry which is
+// down doesn't prevent the packages of the others being emitted.
+func (feed Feed) pollRegistries(reg registry,
+	poll func(reg registry) ([]*feeds.Package, []error)) ([]*feeds.Package, []error) {
+	if len(feed.additionalRegistries) == 0 {
+		return poll(reg)
+	}
+	registries := append([]feeds.RegistryOptions{{URL: feed.baseURL, Token: feed.token}},
+		feed.additionalRegistries...)
+	baseURLs := make([]string, len(registries))
+	type result struct {
+		pkgs []*feeds.Package
+		errs []error
+	}
+	results := make([]result, len(registries))
+	var wg sync.WaitGroup
+	for i, options := range registries {
+		baseURLs[i] = options.URL
+		wg.Add(1)
+		go func(i int, reg registry) {
+			defer wg.Done()
+			pkgs, errs := poll(reg)
+			results[i] = result{pkgs: pkgs, errs: errs}
+		}(i, registry{
+			baseURL:         options.URL,
+			token:           options.Token,
+			maxResponseSize: reg.maxResponseSize,
+			includeRaw:      reg.includeRaw,
+		})
+	}
+	wg.Wait()
+
+	pkgs := []*feeds.Package{}
+	errs := []error{}
+	seen := map[string]bool{}
+	for i, r := range results {
+		for _, pkg := range r.pkgs {
+			key := pkg.Name + "@" + pkg.Version
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			pkgs = append(pkgs, pkg)
+		}
+		for _, err := range r.errs {
+			errs = append(errs, fmt.Errorf("npm registry %s: %w", baseURLs[i], err))
+		}
+	}
 	return pkgs, errs
 }
 
+// CarryState takes over the versions seen and the times packages were polled from the feed
+// polling the previous package list.
+func (feed *Feed) CarryState(previous feeds.ScheduledFeed) {
+	prev, ok := previous.(*Feed)
+	if !ok {
+		return
+	}
+	feed.intervals.Carry(prev.intervals)
+	feed.lossyFeedAlerter = prev.lossyFeedAlerter
+	feed.seenVersions = prev.seenVersions
+	if feed.republished != nil && prev.republished != nil {
+		feed.republished = prev.republished
+	}
+}
+
 func (feed Feed) GetName() string {
 	return FeedName
 }
@@ -283,3 +1012,15 @@ func (feed Feed) GetName() string {
 func (feed Feed) GetFeedOptions() feeds.FeedOptions {
 	return feed.options
 }
+
+func (feed Feed) GetBaseURL() string {
+	return feed.baseURL
+}
+
+func (feed Feed) GetRegistryURLs() []string {
+	urls := []string{feed.baseURL}
+	for _, reg := range feed.additionalRegistries {
+		urls = append(urls, reg.URL)
+	}
+	return urls
+}
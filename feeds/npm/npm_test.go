@@ -1,15 +1,26 @@
 package npm
 
 import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
 	"github.com/ossf/package-feeds/events"
 	"github.com/ossf/package-feeds/feeds"
+	feedtest "github.com/ossf/package-feeds/feeds/testutil"
+	"github.com/ossf/package-feeds/metrics"
+	"github.com/ossf/package-feeds/utils"
 	testutils "github.com/ossf/package-feeds/utils/test"
 )
 
@@ -33,7 +44,7 @@ func TestNpmLatest(t *testing.T) {
 	}
 
 	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
-	pkgs, errs := feed.Latest(cutoff)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
 	if len(errs) != 0 {
 		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
 	}
@@ -96,6 +107,186 @@ func TestNpmLatest(t *testing.T) {
 	if len(pkgs) != 4 {
 		t.Errorf("Unexpected amount of *feed.Package{} generated: %v", len(pkgs))
 	}
+	for _, pkg := range pkgs {
+		if pkg.Ecosystem != feeds.EcosystemNPM {
+			t.Errorf("Unexpected ecosystem `%s` found in place of expected `npm`", pkg.Ecosystem)
+		}
+	}
+}
+
+func TestNpmLatestFewerVersionsThanEvents(t *testing.T) {
+	t.Parallel()
+
+	// RemovedPackage has three events in the rss but only lists one version, the others
+	// were unpublished, and EmptyListedPackage no longer lists any version.
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/-/rss/": rssResponse("RemovedPackage", "RemovedPackage", "RemovedPackage",
+			"EmptyListedPackage", "FooPackage"),
+		"/RemovedPackage":     removedVersionInfoResponse,
+		"/EmptyListedPackage": emptyListedVersionInfoResponse,
+		"/FooPackage":         fooVersionInfoResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	feed, err := New(feeds.FeedOptions{}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create new npm feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	versions := []string{}
+	for _, pkg := range pkgs {
+		versions = append(versions, pkg.Name+"@"+pkg.Version)
+	}
+	sort.Strings(versions)
+	expected := "FooPackage@1.0.1,RemovedPackage@1.0.0"
+	if strings.Join(versions, ",") != expected {
+		t.Errorf("Latest() produced %v when %v was expected", versions, expected)
+	}
+}
+
+func TestNpmLatestDenylist(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	fetched := map[string]bool{}
+	recorded := func(handler testutils.HTTPHandlerFunc) testutils.HTTPHandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			fetched[r.URL.Path] = true
+			mu.Unlock()
+			handler(w, r)
+		}
+	}
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/-/rss/":     npmLatestPackagesResponse,
+		"/FooPackage": recorded(fooVersionInfoResponse),
+		"/BarPackage": recorded(barVersionInfoResponse),
+		"/BazPackage": recorded(bazVersionInfoResponse),
+		"/QuxPackage": recorded(quxVersionInfoResponse),
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	deniedBefore := testutil.ToFloat64(metrics.DeniedPackages.WithLabelValues(FeedName))
+	feed, err := New(feeds.FeedOptions{Denylist: []string{"^Baz", "^Qux"}}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create new npm feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	for _, pkg := range pkgs {
+		if pkg.Name != "FooPackage" && pkg.Name != "BarPackage" {
+			t.Errorf("Latest() emitted %s which matches the denylist", pkg.Name)
+		}
+	}
+	if len(pkgs) != 2 {
+		t.Errorf("Latest() produced %v packages instead of the expected 2", len(pkgs))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if fetched["/BazPackage"] || fetched["/QuxPackage"] {
+		t.Errorf("Packages matching the denylist were fetched: %v", fetched)
+	}
+	// The rss feed includes BazPackage twice and QuxPackage once.
+	if denied := testutil.ToFloat64(metrics.DeniedPackages.WithLabelValues(FeedName)) - deniedBefore; denied != 3 {
+		t.Errorf("%v packages were counted as denied when 3 were expected", denied)
+	}
+}
+
+func TestNpmLatestVersionOnly(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/-/rss/":     npmLatestPackagesResponse,
+		"/FooPackage": fooVersionInfoResponse,
+		"/BarPackage": barVersionInfoResponse,
+		"/BazPackage": bazVersionInfoResponse,
+		"/QuxPackage": quxVersionInfoResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	feed, err := New(feeds.FeedOptions{LatestVersionOnly: true}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create new npm feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 3 {
+		t.Fatalf("Latest() produced %v packages instead of the expected 3", len(pkgs))
+	}
+	for _, pkg := range pkgs {
+		if pkg.Name == "BazPackage" && pkg.Version != "1.1" {
+			t.Errorf("Unexpected version `%s` of BazPackage found in place of expected `1.1`", pkg.Version)
+		}
+	}
+}
+
+func TestNpmRegistryRequestMetrics(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/-/rss/":     npmLatestPackagesResponse,
+		"/FooPackage": fooVersionInfoResponse,
+		"/BarPackage": barVersionInfoResponse,
+		"/BazPackage": bazVersionInfoResponse,
+		"/QuxPackage": quxVersionInfoResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	feed, err := New(feeds.FeedOptions{}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create new npm feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	_, errs := feed.Latest(context.Background(), time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC))
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	observed := map[string]bool{}
+	for _, family := range families {
+		if family.GetName() != "registry_request_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["feed"] == FeedName && metric.GetHistogram().GetSampleCount() > 0 {
+				observed[labels["endpoint"]] = true
+			}
+		}
+	}
+	for _, endpoint := range []string{"rss", "package"} {
+		if !observed[endpoint] {
+			t.Errorf("No registry request duration was recorded for the %v endpoint", endpoint)
+		}
+	}
 }
 
 func TestNpmCritical(t *testing.T) {
@@ -120,7 +311,7 @@ func TestNpmCritical(t *testing.T) {
 	}
 
 	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
-	pkgs, errs := feed.Latest(cutoff)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
 	if len(errs) != 0 {
 		t.Fatalf("Failed to call Latest() with err: %v", errs[len(errs)-1])
 	}
@@ -154,46 +345,1107 @@ func TestNpmCritical(t *testing.T) {
 	}
 }
 
-func TestNpmCriticalUnpublished(t *testing.T) {
+func TestNpmCriticalUnpublished(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/FooPackage": fooVersionInfoResponse,
+		"/QuxPackage": quxVersionInfoResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	packages := []string{
+		"FooPackage",
+		"QuxPackage",
+	}
+
+	feed, err := New(feeds.FeedOptions{Packages: &packages}, events.NewNullHandler())
+	feed.baseURL = srv.URL
+
+	if err != nil {
+		t.Fatalf("Failed to create new npm feed: %v", err)
+	}
+
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+
+	if len(errs) != 1 {
+		t.Fatalf("feed.Latest() returned %v errors when 1 was expected", len(errs))
+	}
+
+	if !errors.Is(errs[len(errs)-1], errUnpublished) {
+		t.Fatalf("Failed to return unpublished error when polling for an unpublished package, instead: %v", err)
+	}
+
+	if !strings.Contains(errs[len(errs)-1].Error(), "QuxPackage") {
+		t.Fatalf("Failed to correctly include the package name in unpublished error, instead: %v", errs[len(errs)-1])
+	}
+
+	// Even though QuxPackage is unpublished, the error should be
+	// logged and FooPackage should still be processed.
+	if len(pkgs) != 3 {
+		t.Fatalf("Latest() produced %v packages instead of the expected 3", len(pkgs))
+	}
+}
+
+func TestNpmCriticalUnpublishEvents(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/FooPackage": fooVersionInfoResponse,
+		"/QuxPackage": quxVersionInfoResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	packages := []string{
+		"FooPackage",
+		"QuxPackage",
+	}
+
+	sink := &events.MockSink{}
+	filter := events.NewFilter([]string{events.UnpublishEventType}, nil, nil)
+	feed, err := New(feeds.FeedOptions{
+		Packages:        &packages,
+		UnpublishEvents: true,
+	}, events.NewHandler(sink, *filter))
+	if err != nil {
+		t.Fatalf("Failed to create new npm feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	// The unpublished package is reported as an event rather than an error.
+	if len(errs) != 0 {
+		t.Fatalf("Failed to call Latest() with err: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 3 {
+		t.Fatalf("Latest() produced %v packages instead of the expected 3", len(pkgs))
+	}
+
+	dispatched := sink.GetEvents()
+	if len(dispatched) != 1 {
+		t.Fatalf("%v events were dispatched instead of the expected 1", len(dispatched))
+	}
+	event, ok := dispatched[0].(events.UnpublishEvent)
+	if !ok || event.Package != "QuxPackage" || event.Feed != FeedName {
+		t.Fatalf("Unexpected event %#v dispatched in place of an UnpublishEvent for QuxPackage", dispatched[0])
+	}
+	if len(event.Versions) != 2 || event.Versions[0] != "1.0" || event.Versions[1] != "1.1" {
+		t.Errorf("UnpublishEvent has versions %v instead of the expected [1.0 1.1]", event.Versions)
+	}
+}
+
+func TestNpmCriticalEmptyVersions(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/FooPackage":   fooVersionInfoResponse,
+		"/EmptyPackage": emptyVersionInfoResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	packages := []string{
+		"FooPackage",
+		"EmptyPackage",
+	}
+
+	sink := &events.MockSink{}
+	filter := events.NewFilter([]string{events.EmptyVersionsEventType}, nil, nil)
+	feed, err := New(feeds.FeedOptions{Packages: &packages}, events.NewHandler(sink, *filter))
+	if err != nil {
+		t.Fatalf("Failed to create new npm feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("Failed to call Latest() with err: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 3 {
+		t.Fatalf("Latest() produced %v packages instead of the expected 3", len(pkgs))
+	}
+
+	dispatched := sink.GetEvents()
+	if len(dispatched) != 1 {
+		t.Fatalf("%v events were dispatched instead of the expected 1", len(dispatched))
+	}
+	event, ok := dispatched[0].(events.EmptyVersionsEvent)
+	if !ok || event.Package != "EmptyPackage" || event.Feed != FeedName {
+		t.Errorf("Unexpected event %#v dispatched in place of an EmptyVersionsEvent for EmptyPackage", dispatched[0])
+	}
+}
+
+func TestNpmCriticalMalformedTimestamps(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/MalformedPackage": malformedVersionInfoResponse,
+		"/UnparsedPackage":  unparsedVersionInfoResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	packages := []string{
+		"MalformedPackage",
+		"UnparsedPackage",
+	}
+
+	sink := &events.MockSink{}
+	filter := events.NewFilter([]string{events.MalformedTimestampEventType}, nil, nil)
+	feed, err := New(feeds.FeedOptions{Packages: &packages}, events.NewHandler(sink, *filter))
+	if err != nil {
+		t.Fatalf("Failed to create new npm feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	malformedBefore := testutil.ToFloat64(metrics.MalformedTimestamps.WithLabelValues(FeedName))
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+
+	// The versions with parseable timestamps are emitted despite the malformed version.
+	versions := []string{}
+	for _, pkg := range pkgs {
+		versions = append(versions, pkg.Name+"@"+pkg.Version)
+	}
+	expected := "MalformedPackage@1.1.0,MalformedPackage@1.0.1,MalformedPackage@1.0.0"
+	if strings.Join(versions, ",") != expected {
+		t.Errorf("Latest() produced %v when %v was expected", versions, expected)
+	}
+	// A package without any parseable timestamp is reported as an error.
+	if len(errs) != 1 {
+		t.Fatalf("Latest() returned errors %v when a single error was expected", errs)
+	}
+	var pollErr feeds.PackagePollError
+	if !errors.As(errs[0], &pollErr) || pollErr.Name != "UnparsedPackage" || !errors.Is(pollErr.Err, errTimestamps) {
+		t.Errorf("Latest() returned error %v when errTimestamps polling UnparsedPackage was expected", errs[0])
+	}
+
+	malformed := testutil.ToFloat64(metrics.MalformedTimestamps.WithLabelValues(FeedName)) - malformedBefore
+	if malformed != 3 {
+		t.Errorf("%v malformed timestamps were counted when 3 were expected", malformed)
+	}
+	dispatched := map[string]string{}
+	for _, e := range sink.GetEvents() {
+		event, ok := e.(events.MalformedTimestampEvent)
+		if !ok || event.Feed != FeedName {
+			t.Fatalf("Unexpected event %#v dispatched in place of a MalformedTimestampEvent", e)
+		}
+		dispatched[event.Package] = strings.Join(event.Versions, ",")
+	}
+	if dispatched["MalformedPackage"] != "2.0.0" || dispatched["UnparsedPackage"] != "0.1.0,0.2.0" ||
+		len(dispatched) != 2 {
+		t.Errorf("Events were dispatched for versions %v when MalformedPackage 2.0.0 and "+
+			"UnparsedPackage 0.1.0,0.2.0 were expected", dispatched)
+	}
+}
+
+func TestNpmLatestMalformedTimestampsDuplicateEvents(t *testing.T) {
+	t.Parallel()
+
+	// MalformedPackage has four events in the rss, but one of its four versions has a
+	// malformed timestamp and is skipped.
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/-/rss/": rssResponse("MalformedPackage", "MalformedPackage", "MalformedPackage",
+			"MalformedPackage"),
+		"/MalformedPackage": malformedVersionInfoResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	feed, err := New(feeds.FeedOptions{}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create new npm feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	versions := []string{}
+	for _, pkg := range pkgs {
+		versions = append(versions, pkg.Name+"@"+pkg.Version)
+	}
+	expected := "MalformedPackage@1.1.0,MalformedPackage@1.0.1,MalformedPackage@1.0.0"
+	if strings.Join(versions, ",") != expected {
+		t.Errorf("Latest() produced %v when %v was expected", versions, expected)
+	}
+}
+
+func TestNpmCriticalPollDeadline(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/FooPackage":  fooVersionInfoResponse,
+		"/SlowPackage": slowResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	packages := []string{
+		"FooPackage",
+		"SlowPackage",
+	}
+
+	feed, err := New(feeds.FeedOptions{Packages: &packages}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create new npm feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	pkgs, errs := feed.Latest(ctx, cutoff)
+
+	if len(errs) != 1 {
+		t.Fatalf("feed.Latest returned %v errors when 1 was expected", len(errs))
+	}
+	var pollErr feeds.PackagePollError
+	isDeadline := errors.As(errs[0], &pollErr) && errors.Is(pollErr.Err, context.DeadlineExceeded)
+	if !isDeadline || pollErr.Name != "SlowPackage" {
+		t.Errorf("feed.Latest returned `%v` when a deadline error for SlowPackage was expected", errs[0])
+	}
+	// The packages fetched before the deadline are still returned.
+	if len(pkgs) != 3 {
+		t.Fatalf("Latest() produced %v packages instead of the expected 3", len(pkgs))
+	}
+	for _, pkg := range pkgs {
+		if pkg.Name != "FooPackage" {
+			t.Errorf("Unexpected package `%s` found in place of expected `FooPackage`", pkg.Name)
+		}
+	}
+}
+
+func TestNpmSameTimestampOrdering(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/TiedPackage": tiedVersionInfoResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+	reg := registry{baseURL: srv.URL, maxResponseSize: utils.DefaultMaxResponseSize}
+
+	// Map iteration order is random, so repeated fetches would reorder ties if unhandled.
+	for i := 0; i < 10; i++ {
+		pkgs, err := fetchPackage(context.Background(), reg, "TiedPackage", nil, events.NewNullHandler())
+		if err != nil {
+			t.Fatalf("fetchPackage returned error: %v", err)
+		}
+		versions := []string{}
+		for _, pkg := range pkgs {
+			versions = append(versions, pkg.Version)
+		}
+		if strings.Join(versions, ",") != "2.0.0,1.0.0,1.0.1" {
+			t.Fatalf("fetchPackage ordered versions %v when 2.0.0,1.0.0,1.0.1 was expected", versions)
+		}
+	}
+}
+
+func TestNpmResponseTooLarge(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/-/rss/":     npmLatestPackagesResponse,
+		"/FooPackage": fooVersionInfoResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	reg := registry{baseURL: srv.URL, maxResponseSize: 64}
+
+	_, err := fetchPackageEvents(context.Background(), reg)
+	if !errors.Is(err, utils.ErrResponseTooLarge) {
+		t.Errorf("fetchPackageEvents returned error %v when ErrResponseTooLarge was expected", err)
+	}
+	_, err = fetchPackage(context.Background(), reg, "FooPackage", nil, events.NewNullHandler())
+	if !errors.Is(err, utils.ErrResponseTooLarge) {
+		t.Errorf("fetchPackage returned error %v when ErrResponseTooLarge was expected", err)
+	}
+}
+
+func TestNpmGzipEncodedEvents(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/-/rss/": gzipResponse(npmLatestPackagesResponse),
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	packageEvents, err := fetchPackageEvents(context.Background(), registry{baseURL: srv.URL})
+	if err != nil {
+		t.Fatalf("Failed to fetch gzip encoded package events: %v", err)
+	}
+	titles := []string{}
+	for _, event := range packageEvents {
+		titles = append(titles, event.Title)
+	}
+	expected := []string{"FooPackage", "BarPackage", "BazPackage", "BazPackage", "QuxPackage"}
+	if strings.Join(titles, ",") != strings.Join(expected, ",") {
+		t.Errorf("Fetched package events %v when %v were expected", titles, expected)
+	}
+}
+
+func TestNpmCriticalRepublished(t *testing.T) {
+	t.Parallel()
+
+	// The document served for QuuxPackage, replaced between polls.
+	var mu sync.Mutex
+	doc := ""
+	serve := func(versions map[string]string, listed []string, modified string) {
+		mu.Lock()
+		defer mu.Unlock()
+		times := []string{`"modified": "` + modified + `"`}
+		for version, created := range versions {
+			times = append(times, fmt.Sprintf("%q: %q", version, created))
+		}
+		manifests := []string{}
+		for _, version := range listed {
+			manifests = append(manifests, fmt.Sprintf(`%q: {"name": "QuuxPackage", "version": %q}`, version, version))
+		}
+		doc = fmt.Sprintf(`{"name": "QuuxPackage", "versions": {%s}, "time": {%s}}`,
+			strings.Join(manifests, ", "), strings.Join(times, ", "))
+	}
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/QuuxPackage": func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			if _, err := w.Write([]byte(doc)); err != nil {
+				http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+			}
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	packages := []string{"QuuxPackage"}
+	feed, err := New(feeds.FeedOptions{
+		Packages:           &packages,
+		RepublishThreshold: time.Hour,
+		Clock:              feeds.NewFakeClock(now),
+	}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create new npm feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	republished := func() map[string]time.Time {
+		t.Helper()
+		pkgs, errs := feed.Latest(context.Background(), cutoff)
+		if len(errs) != 0 {
+			t.Fatalf("Failed to call Latest() with err: %v", errs[len(errs)-1])
+		}
+		found := map[string]time.Time{}
+		for _, pkg := range pkgs {
+			if pkg.Republished {
+				found[pkg.Version] = pkg.CreatedDate
+			}
+		}
+		return found
+	}
+	versions := map[string]string{
+		"1.0.0": "2021-04-02T10:00:00.000Z",
+		"2.0.0": "2021-05-03T16:20:00.000Z",
+	}
+	serve(versions, []string{"1.0.0", "2.0.0"}, "2021-05-03T16:20:00.000Z")
+	if found := republished(); len(found) != 0 {
+		t.Errorf("The first poll flagged %v as republished", found)
+	}
+
+	// Metadata-only changes update the modified time of the document, not of its versions.
+	serve(versions, []string{"1.0.0", "2.0.0"}, "2021-06-01T09:12:45.000Z")
+	if found := republished(); len(found) != 0 {
+		t.Errorf("A metadata change flagged %v as republished", found)
+	}
+
+	// 2.0.0 is published again, its time moving later.
+	versions["2.0.0"] = "2021-06-01T09:12:45.000Z"
+	serve(versions, []string{"1.0.0", "2.0.0"}, "2021-06-01T09:12:45.000Z")
+	found := republished()
+	expected := time.Date(2021, 6, 1, 9, 12, 45, 0, time.UTC)
+	if len(found) != 1 || !found["2.0.0"].Equal(expected) {
+		t.Errorf("Flagged %v as republished when 2.0.0 created %v was expected", found, expected)
+	}
+
+	// 1.0.0 is removed from the versions, keeping its time, then listed again.
+	serve(versions, []string{"2.0.0"}, "2021-06-01T10:00:00.000Z")
+	if found := republished(); len(found) != 0 {
+		t.Errorf("Removing a version flagged %v as republished", found)
+	}
+	serve(versions, []string{"1.0.0", "2.0.0"}, "2021-06-01T11:00:00.000Z")
+	found = republished()
+	if len(found) != 1 || !found["1.0.0"].Equal(now) {
+		t.Errorf("Flagged %v as republished when 1.0.0 seen again at %v was expected", found, now)
+	}
+}
+
+func TestNpmPrivateRegistry(t *testing.T) {
+	t.Parallel()
+
+	const token = "s3cr3t"
+	requireAuth := func(handler testutils.HTTPHandlerFunc) testutils.HTTPHandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer "+token {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			handler(w, r)
+		}
+	}
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/-/rss":      requireAuth(npmLatestPackagesResponse),
+		"/FooPackage": requireAuth(fooVersionInfoResponse),
+		"/BarPackage": requireAuth(barVersionInfoResponse),
+		"/BazPackage": requireAuth(bazVersionInfoResponse),
+		"/QuxPackage": requireAuth(quxVersionInfoResponse),
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	feed, err := New(feeds.FeedOptions{
+		RegistryURL:   srv.URL,
+		RegistryToken: token,
+	}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create new npm feed: %v", err)
+	}
+
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("Failed to call Latest() with err: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) == 0 {
+		t.Fatalf("Latest() produced no packages from the private registry")
+	}
+
+	// Requests without the token are rejected by the registry.
+	feed, err = New(feeds.FeedOptions{RegistryURL: srv.URL}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create new npm feed: %v", err)
+	}
+	_, errs = feed.Latest(context.Background(), cutoff)
+	if len(errs) == 0 || !strings.Contains(errs[0].Error(), "401") {
+		t.Fatalf("Expected an unauthorized error polling without a token, got: %v", errs)
+	}
+}
+
+func TestNpmInvalidRegistryURL(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(feeds.FeedOptions{RegistryURL: "registry.example.com"}, events.NewNullHandler())
+	if !errors.Is(err, errRegistryURL) {
+		t.Fatalf("Expected errRegistryURL creating a feed with an invalid registry url, got: %v", err)
+	}
+}
+
+func TestNpmCriticalBaseURLs(t *testing.T) {
+	t.Parallel()
+
+	public := testutils.HTTPServerMock(map[string]testutils.HTTPHandlerFunc{
+		"/FooPackage": fooVersionInfoResponse,
+		"/BarPackage": barVersionInfoResponse,
+	})
+	defer public.Close()
+	// The private registry mirrors FooPackage and serves BazPackage, which isn't public.
+	private := testutils.HTTPServerMock(map[string]testutils.HTTPHandlerFunc{
+		"/FooPackage": fooVersionInfoResponse,
+		"/BazPackage": bazVersionInfoResponse,
+	})
+	defer private.Close()
+	down := testutils.HTTPServerMock(map[string]testutils.HTTPHandlerFunc{})
+	down.Close()
+
+	packages := []string{"FooPackage", "BarPackage", "BazPackage"}
+	feed, err := New(feeds.FeedOptions{
+		Packages: &packages,
+		BaseURLs: []string{public.URL, private.URL, down.URL},
+	}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create new npm feed: %v", err)
+	}
+
+	cutoff := time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	// Each registry reports the packages it doesn't serve.
+	if len(errs) != 5 {
+		t.Fatalf("feed.Latest returned %v errors when 5 were expected: %v", len(errs), errs)
+	}
+	for _, err := range errs {
+		var pollErr feeds.PackagePollError
+		if !errors.As(err, &pollErr) {
+			t.Errorf("feed.Latest returned `%v` when a package poll error was expected", err)
+		}
+	}
+	if !strings.Contains(errs[len(errs)-1].Error(), down.URL) {
+		t.Errorf("feed.Latest returned `%v` when an error naming the registry was expected", errs[len(errs)-1])
+	}
+
+	// The versions of FooPackage served by both registries are emitted once, the cutoff is
+	// applied to the merged packages.
+	expected := []string{"FooPackage@1.0.1", "BarPackage@0.5.0-alpha", "BazPackage@1.1", "BazPackage@1.0"}
+	if len(pkgs) != len(expected) {
+		t.Fatalf("Latest() produced %v packages instead of the expected %v", len(pkgs), len(expected))
+	}
+	for i, pkg := range pkgs {
+		if name := pkg.Name + "@" + pkg.Version; name != expected[i] {
+			t.Errorf("Unexpected package %s found in place of %s", name, expected[i])
+		}
+	}
+}
+
+func TestNpmCriticalRegistryTokens(t *testing.T) {
+	t.Parallel()
+
+	// Each registry only accepts its own token, or no token.
+	authenticated := func(token string, handler testutils.HTTPHandlerFunc) testutils.HTTPHandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			expected := ""
+			if token != "" {
+				expected = "Bearer " + token
+			}
+			if auth := r.Header.Get("Authorization"); auth != expected {
+				http.Error(w, "unexpected authorization: "+auth, http.StatusUnauthorized)
+				return
+			}
+			handler(w, r)
+		}
+	}
+	public := testutils.HTTPServerMock(map[string]testutils.HTTPHandlerFunc{
+		"/FooPackage": authenticated("", fooVersionInfoResponse),
+	})
+	defer public.Close()
+	private := testutils.HTTPServerMock(map[string]testutils.HTTPHandlerFunc{
+		"/FooPackage": authenticated("s3cr3t", fooVersionInfoResponse),
+	})
+	defer private.Close()
+
+	packages := []string{"FooPackage"}
+	feed, err := New(feeds.FeedOptions{
+		Packages: &packages,
+		Registries: []feeds.RegistryOptions{
+			{URL: public.URL},
+			{URL: private.URL, Token: "s3cr3t"},
+		},
+	}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create new npm feed: %v", err)
+	}
+	_, errs := feed.Latest(context.Background(), time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC))
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+}
+
+func TestNpmConflictingRegistries(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(feeds.FeedOptions{
+		RegistryURL: "https://npm.example.com/",
+		BaseURLs:    []string{"https://registry.npmjs.org/"},
+	}, events.NewNullHandler())
+	if !errors.Is(err, errConflictingRegistries) {
+		t.Errorf("Expected errConflictingRegistries creating a feed with registry_url and base_urls, got: %v", err)
+	}
+	_, err = New(feeds.FeedOptions{
+		BaseURLs:   []string{"https://registry.npmjs.org/"},
+		Registries: []feeds.RegistryOptions{{URL: "https://npm.example.com/"}},
+	}, events.NewNullHandler())
+	if !errors.Is(err, errConflictingRegistries) {
+		t.Errorf("Expected errConflictingRegistries creating a feed with base_urls and registries, got: %v", err)
+	}
+	// The token would be sent to every registry.
+	_, err = New(feeds.FeedOptions{
+		BaseURLs:      []string{"https://registry.npmjs.org/", "https://npm.example.com/"},
+		RegistryToken: "s3cr3t",
+	}, events.NewNullHandler())
+	if !errors.Is(err, errRegistryTokenScope) {
+		t.Errorf("Expected errRegistryTokenScope creating a feed with base_urls and registry_token, got: %v", err)
+	}
+	_, err = New(feeds.FeedOptions{BaseURLs: []string{"https://registry.npmjs.org/", "npm.example.com"}},
+		events.NewNullHandler())
+	if !errors.Is(err, errRegistryURL) {
+		t.Errorf("Expected errRegistryURL creating a feed with an invalid base url, got: %v", err)
+	}
+}
+
+func TestNpmCriticalIntegrity(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/IntegrityPackage": integrityVersionInfoResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	packages := []string{"IntegrityPackage"}
+	feed, err := New(feeds.FeedOptions{Packages: &packages}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create new npm feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("Failed to call Latest() with err: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 3 {
+		t.Fatalf("Latest() produced %v packages instead of the expected 3", len(pkgs))
+	}
+
+	expected := map[string]string{
+		"1.0.0": "sha512-v2kDEe57lecTulaDIuNTPy3Ry4gLGJ6Z1O3vE1krgXZNrsQ+LFTGHVxVjcXPs17LhbZVGedAJv8XZ1tvj5FvSg==",
+		// Only a shasum is available, which is converted to a sha1 integrity string.
+		"0.9.0": "sha1-LNJvAl5ZLT5IYDgcYk4/aQRGbvM=",
+		"0.8.0": "",
+	}
+	for _, pkg := range pkgs {
+		if pkg.Integrity != expected[pkg.Version] {
+			t.Errorf("IntegrityPackage@%s has integrity %q instead of %q", pkg.Version, pkg.Integrity, expected[pkg.Version])
+		}
+	}
+
+	// The integrity is included in the body sent to the publisher.
+	body, err := json.Marshal(pkgs[0])
+	if err != nil {
+		t.Fatalf("Failed to marshal package: %v", err)
+	}
+	if !strings.Contains(string(body), `"integrity":"`+expected["1.0.0"]+`"`) {
+		t.Errorf("Marshaled package %s does not include the integrity", body)
+	}
+}
+
+func TestNpmCriticalProvenance(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/ProvenancePackage": provenanceVersionInfoResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	packages := []string{"ProvenancePackage"}
+	feed, err := New(feeds.FeedOptions{Packages: &packages}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create new npm feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("Failed to call Latest() with err: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 2 {
+		t.Fatalf("Latest() produced %v packages instead of the expected 2", len(pkgs))
+	}
+
+	const provenance = "https://slsa.dev/provenance/v1"
+	for _, pkg := range pkgs {
+		switch pkg.Version {
+		case "2.0.0":
+			if !pkg.Signed || len(pkg.Attestations) != 1 || pkg.Attestations[0] != provenance {
+				t.Errorf("ProvenancePackage@2.0.0 has signed %v and attestations %v instead of true and [%v]",
+					pkg.Signed, pkg.Attestations, provenance)
+			}
+		case "1.0.0":
+			if pkg.Signed || len(pkg.Attestations) != 0 {
+				t.Errorf("ProvenancePackage@1.0.0 has signed %v and attestations %v instead of false and none",
+					pkg.Signed, pkg.Attestations)
+			}
+		default:
+			t.Errorf("Unexpected version %v of ProvenancePackage", pkg.Version)
+		}
+	}
+}
+
+func TestNpmCriticalSourceRepo(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/RepoPackage": repoVersionInfoResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	packages := []string{"RepoPackage"}
+	feed, err := New(feeds.FeedOptions{Packages: &packages}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create new npm feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("Failed to call Latest() with err: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 2 {
+		t.Fatalf("Latest() produced %v packages instead of the expected 2", len(pkgs))
+	}
+
+	expected := map[string]string{
+		"2.0.0": "https://github.com/foo/repo-package",
+		// The version has no repository, so the repository of the package is used.
+		"1.0.0": "https://github.com/foo/old-repo-package",
+	}
+	for _, pkg := range pkgs {
+		if pkg.SourceRepo != expected[pkg.Version] {
+			t.Errorf("RepoPackage@%s has source repo %q instead of %q", pkg.Version, pkg.SourceRepo, expected[pkg.Version])
+		}
+	}
+}
+
+func TestNpmCriticalDownloads(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	downloadRequests := 0
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/FooPackage": fooVersionInfoResponse,
+		"/BarPackage": barVersionInfoResponse,
+		"/downloads/point/last-week/FooPackage": func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			downloadRequests++
+			mu.Unlock()
+			_, err := w.Write([]byte(`{"downloads":1234,"start":"2021-05-04","end":"2021-05-10","package":"FooPackage"}`))
+			if err != nil {
+				http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+			}
+		},
+		// The count of BarPackage is unavailable, which shouldn't prevent it from being emitted.
+		"/downloads/point/last-week/BarPackage": func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"error":"package BarPackage not found"}`, http.StatusNotFound)
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	packages := []string{"FooPackage", "BarPackage"}
+	feed, err := New(feeds.FeedOptions{Packages: &packages, EnrichDownloads: true}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create new npm feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+	feed.downloadsURL = srv.URL
+
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("Failed to call Latest() with err: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 5 {
+		t.Fatalf("Latest() produced %v packages instead of the expected 5", len(pkgs))
+	}
+	for _, pkg := range pkgs {
+		expected := 0
+		if pkg.Name == "FooPackage" {
+			expected = 1234
+		}
+		if pkg.Downloads != expected {
+			t.Errorf("%s@%s has %v downloads instead of %v", pkg.Name, pkg.Version, pkg.Downloads, expected)
+		}
+	}
+	// The count is requested once for all versions of a package.
+	mu.Lock()
+	defer mu.Unlock()
+	if downloadRequests != 1 {
+		t.Errorf("Download counts of FooPackage were requested %v times instead of once", downloadRequests)
+	}
+}
+
+func TestNpmCriticalInstallScripts(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/ScriptsPackage": scriptsVersionInfoResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	packages := []string{"ScriptsPackage"}
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, enabled := range []bool{true, false} {
+		feed, err := New(feeds.FeedOptions{Packages: &packages, InstallScripts: enabled}, events.NewNullHandler())
+		if err != nil {
+			t.Fatalf("Failed to create new npm feed: %v", err)
+		}
+		feed.baseURL = srv.URL
+
+		pkgs, errs := feed.Latest(context.Background(), cutoff)
+		if len(errs) != 0 {
+			t.Fatalf("Failed to call Latest() with err: %v", errs[len(errs)-1])
+		}
+		if len(pkgs) != 2 {
+			t.Fatalf("Latest() produced %v packages instead of the expected 2", len(pkgs))
+		}
+		for _, pkg := range pkgs {
+			// Only 1.1.0 has a postinstall script, which is only emitted when enabled.
+			expected := enabled && pkg.Version == "1.1.0"
+			if pkg.HasInstallScripts != expected {
+				t.Errorf("ScriptsPackage@%s has install scripts %v instead of %v with install_scripts %v",
+					pkg.Version, pkg.HasInstallScripts, expected, enabled)
+			}
+		}
+	}
+}
+
+func TestNpmCriticalDeprecated(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/DeprecatedPackage": deprecatedVersionInfoResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	packages := []string{"DeprecatedPackage"}
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, enabled := range []bool{true, false} {
+		feed, err := New(feeds.FeedOptions{Packages: &packages, Deprecations: enabled}, events.NewNullHandler())
+		if err != nil {
+			t.Fatalf("Failed to create new npm feed: %v", err)
+		}
+		feed.baseURL = srv.URL
+
+		pkgs, errs := feed.Latest(context.Background(), cutoff)
+		if len(errs) != 0 {
+			t.Fatalf("Failed to call Latest() with err: %v", errs[len(errs)-1])
+		}
+		if len(pkgs) != 3 {
+			t.Fatalf("Latest() produced %v packages instead of the expected 3", len(pkgs))
+		}
+		for _, pkg := range pkgs {
+			// Only the latest version is deprecated, 1.1.0 was un-deprecated with an empty
+			// message. Deprecations are only emitted when enabled.
+			expected := ""
+			if enabled && pkg.Version == "2.0.0" {
+				expected = "No longer maintained, use NewPackage instead"
+			}
+			if pkg.Deprecated != (expected != "") || pkg.DeprecationMessage != expected {
+				t.Errorf("DeprecatedPackage@%s has deprecated %v with message %q instead of %q with deprecations %v",
+					pkg.Version, pkg.Deprecated, pkg.DeprecationMessage, expected, enabled)
+			}
+		}
+	}
+}
+
+func TestNpmCriticalTarballStats(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/TarballPackage": tarballStatsVersionInfoResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	packages := []string{"TarballPackage"}
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, enabled := range []bool{true, false} {
+		feed, err := New(feeds.FeedOptions{Packages: &packages, TarballStats: enabled}, events.NewNullHandler())
+		if err != nil {
+			t.Fatalf("Failed to create new npm feed: %v", err)
+		}
+		feed.baseURL = srv.URL
+
+		pkgs, errs := feed.Latest(context.Background(), cutoff)
+		if len(errs) != 0 {
+			t.Fatalf("Failed to call Latest() with err: %v", errs[len(errs)-1])
+		}
+		if len(pkgs) != 2 {
+			t.Fatalf("Latest() produced %v packages instead of the expected 2", len(pkgs))
+		}
+		// 1.0.0 was published by an older client which doesn't report tarball stats.
+		expected := map[string]struct {
+			unpackedSize int64
+			fileCount    int
+		}{
+			"1.0.0": {},
+			"2.0.0": {unpackedSize: 5242880, fileCount: 340},
+		}
+		for _, pkg := range pkgs {
+			want := expected[pkg.Version]
+			if !enabled {
+				want = expected["1.0.0"]
+			}
+			if pkg.UnpackedSize != want.unpackedSize || pkg.FileCount != want.fileCount {
+				t.Errorf("TarballPackage@%s has unpacked size %v and file count %v instead of %v and %v "+
+					"with tarball stats %v", pkg.Version, pkg.UnpackedSize, pkg.FileCount, want.unpackedSize,
+					want.fileCount, enabled)
+			}
+		}
+	}
+}
+
+func TestNpmCriticalLicenses(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/LicensedPackage": licensedVersionInfoResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	packages := []string{"LicensedPackage"}
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, enabled := range []bool{true, false} {
+		feed, err := New(feeds.FeedOptions{Packages: &packages, Licenses: enabled}, events.NewNullHandler())
+		if err != nil {
+			t.Fatalf("Failed to create new npm feed: %v", err)
+		}
+		feed.baseURL = srv.URL
+
+		pkgs, errs := feed.Latest(context.Background(), cutoff)
+		if len(errs) != 0 {
+			t.Fatalf("Failed to call Latest() with err: %v", errs[len(errs)-1])
+		}
+		if len(pkgs) != 4 {
+			t.Fatalf("Latest() produced %v packages instead of the expected 4", len(pkgs))
+		}
+		// Older versions declare licenses as objects, and 0.1.0 declares none.
+		expected := map[string]string{
+			"0.1.0": "",
+			"0.2.0": "Apache-2.0",
+			"0.3.0": "(MIT OR GPL-2.0-only)",
+			"1.0.0": "MIT",
+		}
+		for _, pkg := range pkgs {
+			want := expected[pkg.Version]
+			if !enabled {
+				want = ""
+			}
+			if pkg.License != want {
+				t.Errorf("LicensedPackage@%s has license %q instead of %q with licenses %v",
+					pkg.Version, pkg.License, want, enabled)
+			}
+		}
+	}
+}
+
+func TestNpmCriticalIncludeRaw(t *testing.T) {
 	t.Parallel()
 
 	handlers := map[string]testutils.HTTPHandlerFunc{
-		"/FooPackage": fooVersionInfoResponse,
-		"/QuxPackage": quxVersionInfoResponse,
+		"/LicensedPackage": licensedVersionInfoResponse,
 	}
 	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
 
-	packages := []string{
-		"FooPackage",
-		"QuxPackage",
+	served := httptest.NewRecorder()
+	licensedVersionInfoResponse(served, httptest.NewRequest(http.MethodGet, "/LicensedPackage", nil))
+	var doc struct {
+		Versions map[string]json.RawMessage `json:"versions"`
+	}
+	if err := json.Unmarshal(served.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Failed to parse the package document: %v", err)
+	}
+
+	packages := []string{"LicensedPackage"}
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, enabled := range []bool{true, false} {
+		feed, err := New(feeds.FeedOptions{Packages: &packages, IncludeRaw: enabled}, events.NewNullHandler())
+		if err != nil {
+			t.Fatalf("Failed to create new npm feed: %v", err)
+		}
+		feed.baseURL = srv.URL
+
+		pkgs, errs := feed.Latest(context.Background(), cutoff)
+		if len(errs) != 0 {
+			t.Fatalf("Failed to call Latest() with err: %v", errs[len(errs)-1])
+		}
+		if len(pkgs) != 4 {
+			t.Fatalf("Latest() produced %v packages instead of the expected 4", len(pkgs))
+		}
+		for _, pkg := range pkgs {
+			if !enabled {
+				if pkg.Raw != nil {
+					t.Errorf("LicensedPackage@%s has raw %s when include_raw is disabled", pkg.Version, pkg.Raw)
+				}
+				continue
+			}
+			// Each version carries its own manifest rather than the whole document.
+			expected := canonicalJSON(t, doc.Versions[pkg.Version])
+			if raw := canonicalJSON(t, pkg.Raw); raw != expected {
+				t.Errorf("LicensedPackage@%s has raw %s instead of its manifest %s", pkg.Version, raw, expected)
+			}
+		}
 	}
+}
+
+func TestNpmCriticalFixtures(t *testing.T) {
+	t.Parallel()
 
+	srv := feedtest.ServeFixtures(t, "testdata/critical/registry")
+	packages := []string{"fixture-foo", "fixture-bar"}
 	feed, err := New(feeds.FeedOptions{Packages: &packages}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create new npm feed: %v", err)
+	}
 	feed.baseURL = srv.URL
 
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	feedtest.AssertLatest(t, feed, cutoff, "testdata/critical/expected.json")
+}
+
+func TestNpmCriticalConformance(t *testing.T) {
+	t.Parallel()
+
+	srv := feedtest.ServeFixtures(t, "testdata/critical/registry")
+	// The missing package checks the errors of packages which fail to be polled.
+	packages := []string{"fixture-foo", "fixture-bar", "fixture-missing"}
+	feed, err := New(feeds.FeedOptions{Packages: &packages}, events.NewNullHandler())
 	if err != nil {
 		t.Fatalf("Failed to create new npm feed: %v", err)
 	}
+	feed.baseURL = srv.URL
 
-	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
-	pkgs, errs := feed.Latest(cutoff)
+	feedtest.FeedConformanceTest(t, feed, "fixture-missing")
+}
 
-	if len(errs) != 1 {
-		t.Fatalf("feed.Latest() returned %v errors when 1 was expected", len(errs))
+func TestNpmCriticalPackagePollInterval(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	requests := map[string]int{}
+	counted := func(handler testutils.HTTPHandlerFunc) testutils.HTTPHandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			requests[r.URL.Path]++
+			mu.Unlock()
+			handler(w, r)
+		}
+	}
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/FooPackage": counted(fooVersionInfoResponse),
+		"/BarPackage": counted(barVersionInfoResponse),
 	}
+	srv := testutils.HTTPServerMock(handlers)
 
-	if !errors.Is(errs[len(errs)-1], errUnpublished) {
-		t.Fatalf("Failed to return unpublished error when polling for an unpublished package, instead: %v", err)
+	packages := []string{"FooPackage", "BarPackage"}
+	feed, err := New(feeds.FeedOptions{
+		Packages:             &packages,
+		PackagePollIntervals: map[string]time.Duration{"BarPackage": 24 * time.Hour},
+	}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create new npm feed: %v", err)
 	}
+	feed.baseURL = srv.URL
 
-	if !strings.Contains(errs[len(errs)-1].Error(), "QuxPackage") {
-		t.Fatalf("Failed to correctly include the package name in unpublished error, instead: %v", errs[len(errs)-1])
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		_, errs := feed.Latest(context.Background(), cutoff)
+		if len(errs) != 0 {
+			t.Fatalf("Failed to call Latest() with err: %v", errs[len(errs)-1])
+		}
 	}
 
-	// Even though QuxPackage is unpublished, the error should be
-	// logged and FooPackage should still be processed.
-	if len(pkgs) != 3 {
-		t.Fatalf("Latest() produced %v packages instead of the expected 3", len(pkgs))
+	// BarPackage isn't due on the second poll, FooPackage uses the feed's interval.
+	if requests["/FooPackage"] != 2 {
+		t.Errorf("FooPackage was polled %v times instead of 2", requests["/FooPackage"])
+	}
+	if requests["/BarPackage"] != 1 {
+		t.Errorf("BarPackage was polled %v times instead of 1", requests["/BarPackage"])
 	}
 }
 
@@ -205,7 +1457,7 @@ func TestNpmNonUtf8Response(t *testing.T) {
 	}
 	srv := testutils.HTTPServerMock(handlers)
 
-	pkgs, err := fetchPackageEvents(srv.URL)
+	pkgs, err := fetchPackageEvents(context.Background(), registry{baseURL: srv.URL})
 	if err != nil {
 		t.Fatalf("Failed to fetch packages: %v", err)
 	}
@@ -235,7 +1487,7 @@ func TestNpmNotFound(t *testing.T) {
 	}
 
 	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
-	_, errs := feed.Latest(cutoff)
+	_, errs := feed.Latest(context.Background(), cutoff)
 	if len(errs) != 2 {
 		t.Fatalf("feed.Latest() returned %v errors when 2 were expected", len(errs))
 	}
@@ -264,7 +1516,7 @@ func TestNpmPartialNotFound(t *testing.T) {
 	}
 
 	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
-	pkgs, errs := feed.Latest(cutoff)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
 	if len(errs) != 1 {
 		t.Fatalf("feed.Latest() returned %v errors when 1 was expected", len(errs))
 	}
@@ -303,7 +1555,7 @@ func TestNpmCriticalPartialNotFound(t *testing.T) {
 	}
 
 	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
-	pkgs, errs := feed.Latest(cutoff)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
 	if len(errs) != 1 {
 		t.Fatalf("feed.Latest() returned %v errors when 1 was expected", len(errs))
 	}
@@ -320,6 +1572,24 @@ func TestNpmCriticalPartialNotFound(t *testing.T) {
 	}
 }
 
+// gzipResponse encodes the response of handler with gzip, regardless of the
+// Accept-Encoding of the request.
+func gzipResponse(handler testutils.HTTPHandlerFunc) testutils.HTTPHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		handler(rec, r)
+		w.Header().Set("Content-Encoding", "gzip")
+		gw := gzip.NewWriter(w)
+		if _, err := gw.Write(rec.Body.Bytes()); err != nil {
+			http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+			return
+		}
+		if err := gw.Close(); err != nil {
+			http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+		}
+	}
+}
+
 func npmLatestPackagesResponse(w http.ResponseWriter, r *http.Request) {
 	_, err := w.Write([]byte(`
 <?xml version="1.0" encoding="UTF-8"?><rss>
@@ -360,6 +1630,70 @@ func npmLatestPackagesResponse(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Responds with an rss feed with an event for each of the titles, in order.
+func rssResponse(titles ...string) testutils.HTTPHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		items := ""
+		for _, title := range titles {
+			items += fmt.Sprintf(`
+		<item>
+			<title><![CDATA[%s]]></title>
+			<pubDate>Tue, 11 May 2021 14:19:45 GMT</pubDate>
+		</item>`, title)
+		}
+		_, err := w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><rss>
+	<channel>
+		<title><![CDATA[npm recent updates]]></title>` + items + `
+	</channel>
+</rss>
+`))
+		if err != nil {
+			http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+		}
+	}
+}
+
+// RemovedPackage keeps the times of the unpublished versions 1.1.0 and 2.0.0, but only
+// lists 1.0.0 in its versions.
+func removedVersionInfoResponse(w http.ResponseWriter, r *http.Request) {
+	_, err := w.Write([]byte(`
+{
+	"name": "RemovedPackage",
+	"time": {
+		"created": "2021-03-22T13:07:29.000Z",
+		"1.0.0": "2021-03-22T13:07:29.000Z",
+		"1.1.0": "2021-04-02T09:12:45.000Z",
+		"2.0.0": "2021-05-11T14:19:45.000Z",
+		"modified": "2021-05-11T18:34:12.000Z"
+	},
+	"versions": {
+		"1.0.0": {"version": "1.0.0"}
+	}
+}
+`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
+
+// EmptyListedPackage keeps the time of its only version, which is no longer listed.
+func emptyListedVersionInfoResponse(w http.ResponseWriter, r *http.Request) {
+	_, err := w.Write([]byte(`
+{
+	"name": "EmptyListedPackage",
+	"time": {
+		"created": "2021-05-11T14:19:45.000Z",
+		"1.0.0": "2021-05-11T14:19:45.000Z",
+		"modified": "2021-05-11T18:34:12.000Z"
+	},
+	"versions": {}
+}
+`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
+
 func fooVersionInfoResponse(w http.ResponseWriter, r *http.Request) {
 	_, err := w.Write([]byte(`
 {
@@ -382,6 +1716,42 @@ func fooVersionInfoResponse(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func malformedVersionInfoResponse(w http.ResponseWriter, r *http.Request) {
+	_, err := w.Write([]byte(`
+{
+	"name": "MalformedPackage",
+	"time": {
+		"created": "2021-03-22T13:07:29.000Z",
+		"1.0.0": "2021-03-22T13:07:29.000Z",
+		"modified": "2021-05-11T18:34:12.000Z",
+		"1.0.1": "2021-04-02T09:12:45.000Z",
+		"2.0.0": "2021-05-11 18:32:01",
+		"1.1.0": "2021-05-10T08:00:00.000Z"
+	}
+}
+`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
+
+func unparsedVersionInfoResponse(w http.ResponseWriter, r *http.Request) {
+	_, err := w.Write([]byte(`
+{
+	"name": "UnparsedPackage",
+	"time": {
+		"created": "2021-03-22T13:07:29.000Z",
+		"0.1.0": "yesterday",
+		"modified": "2021-05-11T18:34:12.000Z",
+		"0.2.0": null
+	}
+}
+`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
+
 func barVersionInfoResponse(w http.ResponseWriter, r *http.Request) {
 	_, err := w.Write([]byte(`
 {
@@ -451,6 +1821,320 @@ func quxVersionInfoResponse(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Responds once the request is cancelled, simulating a slow registry.
+func slowResponse(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-r.Context().Done():
+	case <-time.After(10 * time.Second):
+	}
+	http.Error(w, "timed out", http.StatusGatewayTimeout)
+}
+
+func tiedVersionInfoResponse(w http.ResponseWriter, r *http.Request) {
+	_, err := w.Write([]byte(`
+{
+	"name": "TiedPackage",
+	"time": {
+		"created": "2021-05-11T17:00:00.000Z",
+		"1.0.1": "2021-05-11T17:00:00.000Z",
+		"1.0.0": "2021-05-11T17:00:00.000Z",
+		"2.0.0": "2021-05-11T18:00:00.000Z",
+		"modified": "2021-05-11T18:00:00.000Z"
+	}
+}
+`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
+
+func emptyVersionInfoResponse(w http.ResponseWriter, r *http.Request) {
+	_, err := w.Write([]byte(`
+{
+	"name": "EmptyPackage",
+	"time": {
+		"created": "2021-04-02T10:00:00.000Z",
+		"modified": "2021-06-01T09:12:45.000Z"
+	}
+}
+`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
+
+func provenanceVersionInfoResponse(w http.ResponseWriter, r *http.Request) {
+	_, err := w.Write([]byte(`
+{
+	"name": "ProvenancePackage",
+	"dist-tags": {
+		"latest": "2.0.0"
+	},
+	"versions": {
+		"1.0.0": {
+			"dist": {
+				"integrity": "sha512-v2kDEe57lecTulaDIuNTPy3Ry4gLGJ6Z1O3vE1krgXZNrsQ+LFTGHVxVjcXPs17LhbZVGedAJv8XZ1tvj5FvSg==",
+				"signatures": [{"keyid": "SHA256:jl3bwswu80PjjokCgh0o2w5c2U4LhQAE57gj9cz1kzA", "sig": "MEUCIQ"}]
+			}
+		},
+		"2.0.0": {
+			"dist": {
+				"integrity": "sha512-v2kDEe57lecTulaDIuNTPy3Ry4gLGJ6Z1O3vE1krgXZNrsQ+LFTGHVxVjcXPs17LhbZVGedAJv8XZ1tvj5FvSg==",
+				"signatures": [{"keyid": "SHA256:jl3bwswu80PjjokCgh0o2w5c2U4LhQAE57gj9cz1kzA", "sig": "MEUCIQ"}],
+				"attestations": {
+					"url": "https://registry.npmjs.org/-/npm/v1/attestations/ProvenancePackage@2.0.0",
+					"provenance": {"predicateType": "https://slsa.dev/provenance/v1"}
+				}
+			}
+		}
+	},
+	"time": {
+		"created": "2021-04-02T10:00:00.000Z",
+		"1.0.0": "2021-04-02T10:00:00.000Z",
+		"modified": "2021-05-03T16:20:00.000Z",
+		"2.0.0": "2021-05-03T16:20:00.000Z"
+	}
+}
+`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
+
+func integrityVersionInfoResponse(w http.ResponseWriter, r *http.Request) {
+	_, err := w.Write([]byte(`
+{
+	"name": "IntegrityPackage",
+	"dist-tags": {
+		"latest": "1.0.0"
+	},
+	"versions": {
+		"0.8.0": {
+			"name": "IntegrityPackage",
+			"version": "0.8.0"
+		},
+		"0.9.0": {
+			"name": "IntegrityPackage",
+			"version": "0.9.0",
+			"dist": {
+				"shasum": "2cd26f025e592d3e4860381c624e3f6904466ef3",
+				"tarball": "https://registry.npmjs.org/IntegrityPackage/-/IntegrityPackage-0.9.0.tgz"
+			}
+		},
+		"1.0.0": {
+			"name": "IntegrityPackage",
+			"version": "1.0.0",
+			"dist": {
+				"integrity": "sha512-v2kDEe57lecTulaDIuNTPy3Ry4gLGJ6Z1O3vE1krgXZNrsQ+LFTGHVxVjcXPs17LhbZVGedAJv8XZ1tvj5FvSg==",
+				"shasum": "2cd26f025e592d3e4860381c624e3f6904466ef3",
+				"tarball": "https://registry.npmjs.org/IntegrityPackage/-/IntegrityPackage-1.0.0.tgz"
+			}
+		}
+	},
+	"time": {
+		"created": "2021-03-01T10:00:00.000Z",
+		"0.8.0": "2021-03-01T10:00:00.000Z",
+		"0.9.0": "2021-04-01T10:00:00.000Z",
+		"1.0.0": "2021-05-01T10:00:00.000Z",
+		"modified": "2021-05-01T10:00:05.000Z"
+	}
+}
+`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
+
+func repoVersionInfoResponse(w http.ResponseWriter, r *http.Request) {
+	_, err := w.Write([]byte(`
+{
+	"name": "RepoPackage",
+	"dist-tags": {
+		"latest": "2.0.0"
+	},
+	"repository": "github:foo/old-repo-package",
+	"versions": {
+		"1.0.0": {
+			"name": "RepoPackage",
+			"version": "1.0.0"
+		},
+		"2.0.0": {
+			"name": "RepoPackage",
+			"version": "2.0.0",
+			"repository": {
+				"type": "git",
+				"url": "git+https://github.com/foo/repo-package.git"
+			}
+		}
+	},
+	"time": {
+		"created": "2021-04-01T10:00:00.000Z",
+		"1.0.0": "2021-04-01T10:00:00.000Z",
+		"2.0.0": "2021-05-01T10:00:00.000Z",
+		"modified": "2021-05-01T10:00:05.000Z"
+	}
+}
+`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
+
+func scriptsVersionInfoResponse(w http.ResponseWriter, r *http.Request) {
+	_, err := w.Write([]byte(`
+{
+	"name": "ScriptsPackage",
+	"dist-tags": {
+		"latest": "1.1.0"
+	},
+	"versions": {
+		"1.0.0": {
+			"name": "ScriptsPackage",
+			"version": "1.0.0",
+			"scripts": {
+				"test": "jest"
+			}
+		},
+		"1.1.0": {
+			"name": "ScriptsPackage",
+			"version": "1.1.0",
+			"scripts": {
+				"test": "jest",
+				"postinstall": "node install.js"
+			}
+		}
+	},
+	"time": {
+		"created": "2021-04-01T10:00:00.000Z",
+		"1.0.0": "2021-04-01T10:00:00.000Z",
+		"1.1.0": "2021-05-01T10:00:00.000Z",
+		"modified": "2021-05-01T10:00:05.000Z"
+	}
+}
+`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
+
+func tarballStatsVersionInfoResponse(w http.ResponseWriter, r *http.Request) {
+	_, err := w.Write([]byte(`
+{
+	"name": "TarballPackage",
+	"dist-tags": {
+		"latest": "2.0.0"
+	},
+	"versions": {
+		"1.0.0": {
+			"name": "TarballPackage",
+			"version": "1.0.0",
+			"dist": {
+				"shasum": "2cd26f025e592d3e4860381c624e3f6904466ef3"
+			}
+		},
+		"2.0.0": {
+			"name": "TarballPackage",
+			"version": "2.0.0",
+			"dist": {
+				"integrity": "sha512-Zm9vYmFy",
+				"fileCount": 340,
+				"unpackedSize": 5242880
+			}
+		}
+	},
+	"time": {
+		"created": "2021-04-01T10:00:00.000Z",
+		"1.0.0": "2021-04-01T10:00:00.000Z",
+		"2.0.0": "2021-05-01T10:00:00.000Z",
+		"modified": "2021-05-01T10:00:00.000Z"
+	}
+}
+`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
+
+func licensedVersionInfoResponse(w http.ResponseWriter, r *http.Request) {
+	_, err := w.Write([]byte(`
+{
+	"name": "LicensedPackage",
+	"dist-tags": {
+		"latest": "1.0.0"
+	},
+	"versions": {
+		"0.1.0": {
+			"name": "LicensedPackage",
+			"version": "0.1.0"
+		},
+		"0.2.0": {
+			"name": "LicensedPackage",
+			"version": "0.2.0",
+			"license": {"type": "Apache 2.0", "url": "https://www.apache.org/licenses/LICENSE-2.0"}
+		},
+		"0.3.0": {
+			"name": "LicensedPackage",
+			"version": "0.3.0",
+			"licenses": [{"type": "MIT"}, {"type": "GPL-2.0"}]
+		},
+		"1.0.0": {
+			"name": "LicensedPackage",
+			"version": "1.0.0",
+			"license": "MIT"
+		}
+	},
+	"time": {
+		"created": "2021-04-01T10:00:00.000Z",
+		"0.1.0": "2021-04-01T10:00:00.000Z",
+		"0.2.0": "2021-04-10T10:00:00.000Z",
+		"0.3.0": "2021-04-20T10:00:00.000Z",
+		"1.0.0": "2021-05-01T10:00:00.000Z",
+		"modified": "2021-05-01T10:00:00.000Z"
+	}
+}
+`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
+
+func deprecatedVersionInfoResponse(w http.ResponseWriter, r *http.Request) {
+	_, err := w.Write([]byte(`
+{
+	"name": "DeprecatedPackage",
+	"dist-tags": {
+		"latest": "2.0.0"
+	},
+	"versions": {
+		"1.0.0": {
+			"name": "DeprecatedPackage",
+			"version": "1.0.0"
+		},
+		"1.1.0": {
+			"name": "DeprecatedPackage",
+			"version": "1.1.0",
+			"deprecated": ""
+		},
+		"2.0.0": {
+			"name": "DeprecatedPackage",
+			"version": "2.0.0",
+			"deprecated": "No longer maintained, use NewPackage instead"
+		}
+	},
+	"time": {
+		"created": "2021-04-01T10:00:00.000Z",
+		"1.0.0": "2021-04-01T10:00:00.000Z",
+		"1.1.0": "2021-04-15T10:00:00.000Z",
+		"2.0.0": "2021-05-01T10:00:00.000Z",
+		"modified": "2021-05-20T10:00:00.000Z"
+	}
+}
+`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
+
 func nonUtf8Response(w http.ResponseWriter, r *http.Request) {
 	_, err := w.Write([]byte(`
 <?xml version="1.0" encoding="UTF-8"?><rss>
@@ -470,3 +2154,18 @@ func nonUtf8Response(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
 	}
 }
+
+// Parses the JSON document and marshals it again, so that documents can be compared
+// regardless of their formatting.
+func canonicalJSON(t *testing.T, data []byte) string {
+	t.Helper()
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Failed to parse JSON %q: %v", data, err)
+	}
+	canonical, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Failed to marshal JSON: %v", err)
+	}
+	return string(canonical)
+}
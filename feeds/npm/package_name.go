@@ -0,0 +1,80 @@
+package npm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The maximum length of a package name, including its scope.
+const maxPackageNameLength = 214
+
+var (
+	errPackageNameLength = fmt.Errorf("name must be between 1 and %d characters", maxPackageNameLength)
+	errPackageNameSpaces = errors.New("name must not have leading or trailing spaces")
+	errPackageNameStart  = errors.New("name must not start with . or _")
+	errPackageNameChars  = errors.New("name must only contain URL-safe characters")
+	errPackageNameScope  = errors.New("scoped name must be of the form @scope/name")
+	errPackageNameSlash  = errors.New("name must not contain a / unless scoped, a scope must start with @")
+	errPackageNameBanned = errors.New("name is not allowed by the registry")
+)
+
+// Names which the registry does not allow as package names.
+var bannedPackageNames = map[string]bool{
+	"node_modules": true,
+	"favicon.ico":  true,
+}
+
+// ValidatePackageName checks the name against the package name rules of the npm registry,
+// such as scoped names being of the form @scope/name. Names with capital letters are
+// accepted, as they may no longer be published but legacy packages still use them.
+func (feed Feed) ValidatePackageName(name string) error {
+	if name == "" || len(name) > maxPackageNameLength {
+		return errPackageNameLength
+	}
+	if strings.TrimSpace(name) != name {
+		return errPackageNameSpaces
+	}
+	if !strings.HasPrefix(name, "@") {
+		if strings.Contains(name, "/") {
+			return errPackageNameSlash
+		}
+		return validateNamePart(name)
+	}
+	parts := strings.Split(name[1:], "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return errPackageNameScope
+	}
+	for _, part := range parts {
+		if err := validateNamePart(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validates a package name, or the scope or name of a scoped package name.
+func validateNamePart(part string) error {
+	if strings.HasPrefix(part, ".") || strings.HasPrefix(part, "_") {
+		return errPackageNameStart
+	}
+	if bannedPackageNames[strings.ToLower(part)] {
+		return errPackageNameBanned
+	}
+	for _, c := range part {
+		if !isURLSafe(c) {
+			return errPackageNameChars
+		}
+	}
+	return nil
+}
+
+// Whether the character is left unescaped by encodeURIComponent, as the registry requires.
+func isURLSafe(c rune) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	default:
+		return strings.ContainsRune("-_.!~*'()", c)
+	}
+}
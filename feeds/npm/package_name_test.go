@@ -0,0 +1,65 @@
+package npm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ossf/package-feeds/events"
+	"github.com/ossf/package-feeds/feeds"
+)
+
+func TestValidatePackageName(t *testing.T) {
+	t.Parallel()
+
+	valid := []string{
+		"left-pad",
+		"lodash.merge",
+		"@babel/core",
+		"@types/node",
+		"JSONStream",
+		"highlight.js",
+		"a",
+		"is-it~()*!'",
+		strings.Repeat("a", maxPackageNameLength),
+	}
+	for _, name := range valid {
+		if err := (Feed{}).ValidatePackageName(name); err != nil {
+			t.Errorf("ValidatePackageName(%q) returned unexpected error: %v", name, err)
+		}
+	}
+
+	tooLong := strings.Repeat("a", maxPackageNameLength+1)
+	invalid := map[string]error{
+		"":                  errPackageNameLength,
+		tooLong:             errPackageNameLength,
+		" left-pad":         errPackageNameSpaces,
+		".bin":              errPackageNameStart,
+		"_private":          errPackageNameStart,
+		"@_scope/name":      errPackageNameStart,
+		"node_modules":      errPackageNameBanned,
+		"left pad":          errPackageNameChars,
+		"café":              errPackageNameChars,
+		"@babel/core:7":     errPackageNameChars,
+		"babel/core":        errPackageNameSlash,
+		"@babel":            errPackageNameScope,
+		"@babel/":           errPackageNameScope,
+		"@/core":            errPackageNameScope,
+		"@babel/core/extra": errPackageNameScope,
+	}
+	for name, expected := range invalid {
+		if err := (Feed{}).ValidatePackageName(name); !errors.Is(err, expected) {
+			t.Errorf("ValidatePackageName(%q) returned %v when %v was expected", name, err, expected)
+		}
+	}
+}
+
+func TestNewFeedInvalidPackageNames(t *testing.T) {
+	t.Parallel()
+
+	packages := []string{"@babel/core", "types/node", "left-pad"}
+	_, err := feeds.NewFeed(FeedName, feeds.FeedOptions{Packages: &packages}, events.NewNullHandler())
+	if err == nil || !strings.Contains(err.Error(), `"types/node"`) || strings.Contains(err.Error(), "left-pad") {
+		t.Errorf("NewFeed() returned %v when only the invalid name types/node should be reported", err)
+	}
+}
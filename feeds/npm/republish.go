@@ -0,0 +1,83 @@
+package npm
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+)
+
+// The number of packages whose versions are remembered when detecting republished
+// versions, the packages seen least recently are forgotten first.
+const maxRepublishPackages = 100000
+
+// Detects versions which were republished by comparing the publish time of each version
+// with that of a previous poll. The registry keeps the `time` entry of a version fixed
+// once it is published, so a version whose time moves later, or which is listed again
+// after being removed, has been published again. The document's `modified` time is not
+// used, as metadata-only changes such as dist-tags or readmes also update it.
+type republishDetector struct {
+	threshold time.Duration
+	clock     feeds.Clock
+
+	mu sync.Mutex
+	// The versions seen of each package, indexed by registry and package name.
+	packages map[string]*seenPublishTimes
+	// The keys of packages in the order they were first seen, for forgetting packages.
+	order []string
+}
+
+type seenPublishTimes struct {
+	// The publish time of each version ever listed by the package.
+	times map[string]time.Time
+	// The versions listed by the package when it was last polled.
+	listed map[string]bool
+}
+
+func newRepublishDetector(threshold time.Duration, clock feeds.Clock) *republishDetector {
+	return &republishDetector{
+		threshold: threshold,
+		clock:     feeds.ClockOrReal(clock),
+		packages:  map[string]*seenPublishTimes{},
+	}
+}
+
+// Marks the versions of the package which were republished since it was last polled, and
+// records the versions for the next poll. listed holds the versions of the `versions`
+// object of the package document. Versions listed again after being removed keep their
+// original publish time, so their created date is set to when they were seen again in
+// order for them to be emitted by this poll. Nothing is marked the first time a package
+// is seen.
+func (d *republishDetector) detect(baseURL, pkgTitle string, versions []*Package, listed map[string]bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := baseURL + "/" + pkgTitle
+	seen, ok := d.packages[key]
+	if !ok {
+		seen = &seenPublishTimes{times: map[string]time.Time{}}
+		d.packages[key] = seen
+		d.order = append(d.order, key)
+		if len(d.order) > maxRepublishPackages {
+			delete(d.packages, d.order[0])
+			d.order = d.order[1:]
+		}
+	}
+	for _, version := range versions {
+		if !listed[version.Version] {
+			continue
+		}
+		published := version.CreatedDate
+		previous, known := seen.times[version.Version]
+		switch {
+		case !known || seen.listed == nil:
+		case version.CreatedDate.Sub(previous) > d.threshold:
+			version.Republished = true
+		case !seen.listed[version.Version]:
+			version.Republished = true
+			version.CreatedDate = d.clock.Now().UTC()
+		}
+		seen.times[version.Version] = published
+	}
+	seen.listed = listed
+}
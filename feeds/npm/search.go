@@ -0,0 +1,112 @@
+package npm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/metrics"
+	"github.com/ossf/package-feeds/utils"
+)
+
+const (
+	searchPath = "/-/v1/search"
+
+	// The text searched for, the search API requires a query so this matches every package.
+	// It can be replaced with the `text` request parameter of the feed.
+	defaultSearchText = "boost-exact:false"
+
+	// The number of packages requested per page, the maximum allowed by the registry, and
+	// the maximum number of pages fetched per poll.
+	searchPageSize = 250
+	maxSearchPages = 10
+)
+
+type searchResponse struct {
+	Objects []searchObject `json:"objects"`
+	Total   int            `json:"total"`
+}
+
+type searchObject struct {
+	Package searchPackage `json:"package"`
+}
+
+// The latest version of a package returned by the search API.
+type searchPackage struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Date    string `json:"date"`
+	Links   struct {
+		Repository string `json:"repository"`
+	} `json:"links"`
+}
+
+func fetchSearchPage(ctx context.Context, reg registry, from int) (*searchResponse, error) {
+	query := url.Values{}
+	query.Set("text", defaultSearchText)
+	query.Set("size", strconv.Itoa(searchPageSize))
+	query.Set("from", strconv.Itoa(from))
+	start := time.Now()
+	resp, err := reg.getWithQuery(ctx, searchPath, query)
+	metrics.ObserveRegistryRequest(FeedName, "search", start)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := utils.CheckResponseStatus(resp); err != nil {
+		return nil, fmt.Errorf("failed to search npm packages: %w", err)
+	}
+	body, err := utils.LimitedReadAll(resp.Body, reg.maxResponseSize)
+	if err != nil {
+		return nil, err
+	}
+	page := &searchResponse{}
+	if err := json.Unmarshal(body, page); err != nil {
+		return nil, fmt.Errorf("%w : %v", errJSON, err)
+	}
+	return page, nil
+}
+
+// Polls the search API of the registry, which returns the latest version of each package
+// along with its metadata so no request per package is needed. This is best-effort: results
+// are ranked by relevance rather than date, so pages are fetched until the results are
+// exhausted or maxSearchPages have been fetched, and packages modified since the cutoff
+// beyond those pages are missed. Only the latest version of each package is returned.
+func fetchSearchPackages(ctx context.Context, reg registry, denylist *feeds.Denylist) ([]*feeds.Package, []error) {
+	pkgs := []*feeds.Package{}
+	errs := []error{}
+	numDenied := 0
+	for page := 0; page < maxSearchPages; page++ {
+		from := page * searchPageSize
+		results, err := fetchSearchPage(ctx, reg, from)
+		if err != nil {
+			errs = append(errs, err)
+			break
+		}
+		for _, object := range results.Objects {
+			searchPkg := object.Package
+			date, err := time.Parse(time.RFC3339, searchPkg.Date)
+			if err != nil {
+				errs = append(errs, feeds.PackagePollError{Name: searchPkg.Name, Err: err})
+				continue
+			}
+			if denylist.Denied(searchPkg.Name) {
+				numDenied++
+				continue
+			}
+			pkg := feeds.NewPackage(date, searchPkg.Name, searchPkg.Version, FeedName, feeds.EcosystemNPM)
+			pkg.SourceRepo = feeds.NormalizeSourceRepo(searchPkg.Links.Repository)
+			pkgs = append(pkgs, pkg)
+		}
+		if len(results.Objects) < searchPageSize || from+searchPageSize >= results.Total {
+			break
+		}
+	}
+	logDenied(numDenied)
+	return pkgs, errs
+}
@@ -0,0 +1,116 @@
+package npm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/events"
+	"github.com/ossf/package-feeds/feeds"
+	testutils "github.com/ossf/package-feeds/utils/test"
+)
+
+func TestNpmSearchLatest(t *testing.T) {
+	t.Parallel()
+
+	cutoff := time.Date(2021, 5, 11, 12, 0, 0, 0, time.UTC)
+	var mu sync.Mutex
+	requested := []string{}
+	// Results are ranked by relevance rather than date. The first page is full of packages
+	// modified after the cutoff, the second is full of older packages and the last holds a
+	// recent package among older ones, so every page is fetched.
+	searchHandler := func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		mu.Lock()
+		requested = append(requested, query.Get("from"))
+		mu.Unlock()
+		if query.Get("text") == "" || query.Get("size") != strconv.Itoa(searchPageSize) {
+			http.Error(w, "unexpected query "+r.URL.RawQuery, http.StatusBadRequest)
+			return
+		}
+		from, err := strconv.Atoi(query.Get("from"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		objects := []searchObject{}
+		count := searchPageSize
+		if from >= 2*searchPageSize {
+			count = 3
+		}
+		for i := 0; i < count; i++ {
+			n := from + i
+			pkg := searchPackage{
+				Name:    fmt.Sprintf("pkg-%d", n),
+				Version: "1.0.0",
+				Date:    cutoff.Add(time.Duration(1000-n) * time.Minute).Format(time.RFC3339),
+			}
+			if n >= searchPageSize && n != 2*searchPageSize+1 {
+				pkg.Date = cutoff.Add(-time.Hour).Format(time.RFC3339)
+			}
+			if n == 0 {
+				pkg.Links.Repository = "git+https://github.com/foo/pkg-0.git"
+			}
+			objects = append(objects, searchObject{Package: pkg})
+		}
+		body, err := json.Marshal(searchResponse{Objects: objects, Total: 10000})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if _, err := w.Write(body); err != nil {
+			http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+		}
+	}
+	srv := testutils.HTTPServerMock(map[string]testutils.HTTPHandlerFunc{
+		searchPath: searchHandler,
+	})
+	defer srv.Close()
+
+	feed, err := New(feeds.FeedOptions{Mode: modeSearch, RegistryURL: srv.URL}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create npm feed: %v", err)
+	}
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+
+	expected := []string{"0", strconv.Itoa(searchPageSize), strconv.Itoa(2 * searchPageSize)}
+	if !reflect.DeepEqual(requested, expected) {
+		t.Errorf("Pages were requested from %v when %v was expected", requested, expected)
+	}
+	if len(pkgs) != searchPageSize+1 {
+		t.Fatalf("Latest() returned %v packages when %v were expected", len(pkgs), searchPageSize+1)
+	}
+	latest := pkgs[0]
+	if latest.Name != "pkg-0" || latest.Version != "1.0.0" || !latest.CreatedDate.Equal(cutoff.Add(1000*time.Minute)) {
+		t.Errorf("Latest() returned %v@%v created at %v as the most recent package",
+			latest.Name, latest.Version, latest.CreatedDate)
+	}
+	if latest.SourceRepo != "https://github.com/foo/pkg-0" {
+		t.Errorf("Latest() returned source repo %q when https://github.com/foo/pkg-0 was expected",
+			latest.SourceRepo)
+	}
+	for _, pkg := range pkgs {
+		if !pkg.CreatedDate.After(cutoff) {
+			t.Errorf("Latest() returned %v created at %v before the cutoff", pkg.Name, pkg.CreatedDate)
+		}
+	}
+}
+
+func TestNpmSearchPackagesUnsupported(t *testing.T) {
+	t.Parallel()
+
+	packages := []string{"lodash"}
+	_, err := New(feeds.FeedOptions{Mode: modeSearch, Packages: &packages}, events.NewNullHandler())
+	if err == nil {
+		t.Fatalf("New() returned no error when packages were configured in search mode")
+	}
+}
@@ -0,0 +1,120 @@
+package npm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ossf/package-feeds/events"
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/utils/semver"
+)
+
+// The key the versions of critical packages are stored under in the CursorStore, distinct
+// from the cursor of the changes feed.
+const seenVersionsKey = FeedName + "/versions"
+
+var errInvalidSeenVersions = errors.New("invalid stored npm package versions")
+
+// Remembers the versions previously seen of each critical package, so that versions which
+// are no longer listed can be reported as unpublished and suspicious changes to the latest
+// version detected. The versions are persisted in the
+// CursorStore when one is configured, so that versions removed whilst the feed wasn't
+// running are detected by the first poll after a restart.
+type seenVersions struct {
+	store feeds.CursorStore
+
+	mu sync.Mutex
+	// The sorted versions of each package indexed by package name, nil until loaded.
+	versions map[string][]string
+}
+
+func newSeenVersions(store feeds.CursorStore) *seenVersions {
+	return &seenVersions{store: store}
+}
+
+func (s *seenVersions) load() error {
+	if s.versions != nil {
+		return nil
+	}
+	versions := map[string][]string{}
+	if s.store != nil {
+		stored, err := s.store.Get(seenVersionsKey)
+		if err != nil {
+			return err
+		}
+		if stored != "" {
+			if err := json.Unmarshal([]byte(stored), &versions); err != nil {
+				return fmt.Errorf("%w : %v", errInvalidSeenVersions, err)
+			}
+		}
+	}
+	s.versions = versions
+	return nil
+}
+
+// Records the versions currently listed by each package, returning the versions previously
+// seen of each of the packages indexed by package name. Packages which have not been seen
+// before are omitted.
+func (s *seenVersions) update(current map[string][]string) (map[string][]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	previous := map[string][]string{}
+	for pkg, versions := range current {
+		if seen, ok := s.versions[pkg]; ok {
+			previous[pkg] = seen
+		}
+		sorted := append([]string{}, versions...)
+		sort.Strings(sorted)
+		s.versions[pkg] = sorted
+	}
+	if s.store == nil {
+		return previous, nil
+	}
+	data, err := json.Marshal(s.versions)
+	if err != nil {
+		return nil, err
+	}
+	return previous, s.store.Set(seenVersionsKey, string(data))
+}
+
+// Returns the versions which are no longer listed.
+func removedVersions(previous, current []string) []string {
+	listed := map[string]bool{}
+	for _, version := range current {
+		listed[version] = true
+	}
+	removed := []string{}
+	for _, version := range previous {
+		if !listed[version] {
+			removed = append(removed, version)
+		}
+	}
+	return removed
+}
+
+// Returns the event reporting a suspicious change of the latest semantic version of a
+// package, either a major version leap of more than threshold or a decrease, or nil if the
+// change isn't suspicious.
+func versionJump(pkg string, previous, current []string, threshold int) *events.VersionJumpEvent {
+	before := semver.Latest(previous)
+	after := semver.Latest(current)
+	if before == nil || after == nil {
+		return nil
+	}
+	if after.Compare(before) >= 0 && after.Major-before.Major <= threshold {
+		return nil
+	}
+	return &events.VersionJumpEvent{
+		Feed:            FeedName,
+		Package:         pkg,
+		PreviousVersion: before.String(),
+		Version:         after.String(),
+	}
+}
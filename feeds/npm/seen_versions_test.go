@@ -0,0 +1,244 @@
+package npm
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/events"
+	"github.com/ossf/package-feeds/feeds"
+	testutils "github.com/ossf/package-feeds/utils/test"
+)
+
+func fooRemovedVersionInfoResponse(w http.ResponseWriter, r *http.Request) {
+	_, err := w.Write([]byte(`
+{
+	"name": "FooPackage",
+	"time": {
+		"created" : "2021-03-22T13:07:29.000Z",
+		"1.0.0": "2021-03-22T13:07:29.000Z",
+		"modified": "2021-05-12T09:01:47.000Z",
+		"1.0.1": "2021-05-11T18:32:01.000Z"
+	}
+}
+`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
+
+func TestNpmCriticalRemovedVersionsAcrossRestart(t *testing.T) {
+	t.Parallel()
+
+	// Version 0.9.1 is removed once the first feed has stopped.
+	var removed int32
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/FooPackage": func(w http.ResponseWriter, r *http.Request) {
+			if atomic.LoadInt32(&removed) == 1 {
+				fooRemovedVersionInfoResponse(w, r)
+				return
+			}
+			fooVersionInfoResponse(w, r)
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+	store := feeds.NewFileCursorStore(filepath.Join(t.TempDir(), "cursors.json"))
+	packages := []string{"FooPackage"}
+
+	newFeed := func(sink *events.MockSink) *Feed {
+		filter := events.NewFilter([]string{events.UnpublishEventType}, nil, nil)
+		feed, err := New(feeds.FeedOptions{
+			Packages:        &packages,
+			UnpublishEvents: true,
+			CursorStore:     store,
+		}, events.NewHandler(sink, *filter))
+		if err != nil {
+			t.Fatalf("Failed to create new npm feed: %v", err)
+		}
+		feed.baseURL = srv.URL
+		return feed
+	}
+
+	sink := &events.MockSink{}
+	if _, errs := newFeed(sink).Latest(context.Background(), time.Time{}); len(errs) != 0 {
+		t.Fatalf("Failed to call Latest() with err: %v", errs[len(errs)-1])
+	}
+	if len(sink.GetEvents()) != 0 {
+		t.Fatalf("%v events were dispatched by the first poll instead of 0", len(sink.GetEvents()))
+	}
+
+	// The restarted feed loads the versions seen before the restart from the store.
+	atomic.StoreInt32(&removed, 1)
+	sink = &events.MockSink{}
+	pkgs, errs := newFeed(sink).Latest(context.Background(), time.Time{})
+	if len(errs) != 0 {
+		t.Fatalf("Failed to call Latest() with err: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 2 {
+		t.Errorf("Latest() produced %v packages instead of the expected 2", len(pkgs))
+	}
+	dispatched := sink.GetEvents()
+	if len(dispatched) != 1 {
+		t.Fatalf("%v events were dispatched after the restart instead of the expected 1", len(dispatched))
+	}
+	event, ok := dispatched[0].(events.UnpublishEvent)
+	if !ok || event.Package != "FooPackage" || event.Feed != FeedName {
+		t.Fatalf("Unexpected event %#v dispatched in place of an UnpublishEvent for FooPackage", dispatched[0])
+	}
+	if len(event.Versions) != 1 || event.Versions[0] != "0.9.1" {
+		t.Errorf("UnpublishEvent has versions %v instead of the expected [0.9.1]", event.Versions)
+	}
+}
+
+func fooJumpVersionInfoResponse(w http.ResponseWriter, r *http.Request) {
+	_, err := w.Write([]byte(`
+{
+	"name": "FooPackage",
+	"time": {
+		"created" : "2021-03-22T13:07:29.000Z",
+		"1.0.0": "2021-03-22T13:07:29.000Z",
+		"modified": "2021-05-12T02:11:09.000Z",
+		"0.9.1": "2021-03-23T05:17:43.000Z",
+		"1.0.1": "2021-05-11T18:32:01.000Z",
+		"99.0.0": "2021-05-12T02:11:09.000Z"
+	}
+}
+`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
+
+// Version 0.9.1 was unpublished, the registry keeps its time but no longer lists it.
+func fooUnlistedVersionInfoResponse(w http.ResponseWriter, r *http.Request) {
+	_, err := w.Write([]byte(`
+{
+	"name": "FooPackage",
+	"time": {
+		"created" : "2021-03-22T13:07:29.000Z",
+		"1.0.0": "2021-03-22T13:07:29.000Z",
+		"modified": "2021-05-12T09:01:47.000Z",
+		"0.9.1": "2021-03-23T05:17:43.000Z",
+		"1.0.1": "2021-05-11T18:32:01.000Z"
+	},
+	"versions": {
+		"1.0.0": {"name": "FooPackage", "version": "1.0.0"},
+		"1.0.1": {"name": "FooPackage", "version": "1.0.1"}
+	}
+}
+`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
+
+func TestNpmCriticalUnlistedVersionRemoved(t *testing.T) {
+	t.Parallel()
+
+	var removed int32
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/FooPackage": func(w http.ResponseWriter, r *http.Request) {
+			if atomic.LoadInt32(&removed) == 1 {
+				fooUnlistedVersionInfoResponse(w, r)
+				return
+			}
+			fooVersionInfoResponse(w, r)
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+	packages := []string{"FooPackage"}
+	sink := &events.MockSink{}
+	filter := events.NewFilter([]string{events.UnpublishEventType}, nil, nil)
+	feed, err := New(feeds.FeedOptions{
+		Packages:        &packages,
+		UnpublishEvents: true,
+	}, events.NewHandler(sink, *filter))
+	if err != nil {
+		t.Fatalf("Failed to create new npm feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	if _, errs := feed.Latest(context.Background(), time.Time{}); len(errs) != 0 {
+		t.Fatalf("Failed to call Latest() with err: %v", errs[len(errs)-1])
+	}
+	atomic.StoreInt32(&removed, 1)
+	pkgs, errs := feed.Latest(context.Background(), time.Time{})
+	if len(errs) != 0 {
+		t.Fatalf("Failed to call Latest() with err: %v", errs[len(errs)-1])
+	}
+	for _, pkg := range pkgs {
+		if pkg.Version == "0.9.1" {
+			t.Errorf("Latest() produced the unlisted version %s@%s", pkg.Name, pkg.Version)
+		}
+	}
+	dispatched := sink.GetEvents()
+	if len(dispatched) != 1 {
+		t.Fatalf("%v events were dispatched instead of the expected 1", len(dispatched))
+	}
+	event, ok := dispatched[0].(events.UnpublishEvent)
+	if !ok || len(event.Versions) != 1 || event.Versions[0] != "0.9.1" {
+		t.Errorf("Unexpected event %#v dispatched in place of an UnpublishEvent for FooPackage@0.9.1", dispatched[0])
+	}
+}
+
+func TestNpmCriticalVersionJump(t *testing.T) {
+	t.Parallel()
+
+	// Each poll is served the next response, leaping to 99.0.0 and then back to 1.0.1.
+	responses := []testutils.HTTPHandlerFunc{
+		fooVersionInfoResponse,
+		fooJumpVersionInfoResponse,
+		fooVersionInfoResponse,
+	}
+	var poll int32
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/FooPackage": func(w http.ResponseWriter, r *http.Request) {
+			responses[atomic.LoadInt32(&poll)](w, r)
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	packages := []string{"FooPackage"}
+	sink := &events.MockSink{}
+	filter := events.NewFilter([]string{events.VersionJumpEventType}, nil, nil)
+	feed, err := New(feeds.FeedOptions{
+		Packages:             &packages,
+		VersionJumpThreshold: 10,
+	}, events.NewHandler(sink, *filter))
+	if err != nil {
+		t.Fatalf("Failed to create new npm feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	expected := []*events.VersionJumpEvent{
+		nil,
+		{Feed: FeedName, Package: "FooPackage", PreviousVersion: "1.0.1", Version: "99.0.0"},
+		{Feed: FeedName, Package: "FooPackage", PreviousVersion: "99.0.0", Version: "1.0.1"},
+	}
+	for i, want := range expected {
+		atomic.StoreInt32(&poll, int32(i))
+		before := len(sink.GetEvents())
+		if _, errs := feed.Latest(context.Background(), time.Time{}); len(errs) != 0 {
+			t.Fatalf("Failed to call Latest() with err: %v", errs[len(errs)-1])
+		}
+		dispatched := sink.GetEvents()[before:]
+		if want == nil {
+			if len(dispatched) != 0 {
+				t.Errorf("Poll %v dispatched %v events when none were expected", i, len(dispatched))
+			}
+			continue
+		}
+		if len(dispatched) != 1 {
+			t.Fatalf("Poll %v dispatched %v events instead of the expected 1", i, len(dispatched))
+		}
+		if event, ok := dispatched[0].(events.VersionJumpEvent); !ok || event != *want {
+			t.Errorf("Poll %v dispatched %#v when %#v was expected", i, dispatched[0], *want)
+		}
+	}
+}
@@ -1,12 +1,13 @@
 package nuget
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
 	"time"
 
+	"github.com/ossf/package-feeds/events"
 	"github.com/ossf/package-feeds/feeds"
 	"github.com/ossf/package-feeds/utils"
 )
@@ -18,9 +19,7 @@ const (
 )
 
 var (
-	httpClient = http.Client{
-		Timeout: 10 * time.Second,
-	}
+	httpClient        = utils.NewHTTPClient(10 * time.Second)
 	errCatalogService = errors.New("error fetching catalog service")
 )
 
@@ -55,13 +54,13 @@ type nugetPackageDetails struct {
 	Created   time.Time `json:"published"`
 }
 
-func fetchCatalogService(baseURL string) (*nugetService, error) {
+func fetchCatalogService(ctx context.Context, baseURL string) (*nugetService, error) {
 	var err error
 	catalogServiceURL, err := utils.URLPathJoin(baseURL, indexPath)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := httpClient.Get(catalogServiceURL)
+	resp, err := utils.Get(ctx, httpClient, catalogServiceURL)
 	if err != nil {
 		return nil, err
 	}
@@ -88,8 +87,8 @@ func fetchCatalogService(baseURL string) (*nugetService, error) {
 		errCatalogService, catalogServiceURL)
 }
 
-func fetchCatalogPages(catalogURL string) ([]*catalogPage, error) {
-	resp, err := httpClient.Get(catalogURL)
+func fetchCatalogPages(ctx context.Context, catalogURL string) ([]*catalogPage, error) {
+	resp, err := utils.Get(ctx, httpClient, catalogURL)
 	if err != nil {
 		return nil, err
 	}
@@ -110,8 +109,8 @@ func fetchCatalogPages(catalogURL string) ([]*catalogPage, error) {
 	return c.Pages, nil
 }
 
-func fetchCatalogPage(url string) ([]*catalogLeaf, error) {
-	resp, err := httpClient.Get(url)
+func fetchCatalogPage(ctx context.Context, url string) ([]*catalogLeaf, error) {
+	resp, err := utils.Get(ctx, httpClient, url)
 	if err != nil {
 		return nil, err
 	}
@@ -132,8 +131,8 @@ func fetchCatalogPage(url string) ([]*catalogLeaf, error) {
 	return page.Packages, nil
 }
 
-func fetchPackageInfo(url string) (*nugetPackageDetails, error) {
-	resp, err := httpClient.Get(url)
+func fetchPackageInfo(ctx context.Context, url string) (*nugetPackageDetails, error) {
+	resp, err := utils.Get(ctx, httpClient, url)
 	if err != nil {
 		return nil, err
 	}
@@ -159,6 +158,12 @@ type Feed struct {
 	options feeds.FeedOptions
 }
 
+func init() { //nolint:gochecknoinits
+	feeds.Register(FeedName, func(options feeds.FeedOptions, _ *events.Handler) (feeds.ScheduledFeed, error) {
+		return New(options)
+	})
+}
+
 func New(feedOptions feeds.FeedOptions) (*Feed, error) {
 	if feedOptions.Packages != nil {
 		return nil, feeds.UnsupportedOptionError{
@@ -175,16 +180,16 @@ func New(feedOptions feeds.FeedOptions) (*Feed, error) {
 // Latest will parse all creation events for packages in the nuget.org catalog feed
 // for packages that have been published since the cutoff
 // https://docs.microsoft.com/en-us/nuget/api/catalog-resource
-func (feed Feed) Latest(cutoff time.Time) ([]*feeds.Package, []error) {
+func (feed Feed) Latest(ctx context.Context, cutoff time.Time) ([]*feeds.Package, []error) {
 	pkgs := []*feeds.Package{}
 	var errs []error
 
-	catalogService, err := fetchCatalogService(feed.baseURL)
+	catalogService, err := fetchCatalogService(ctx, feed.baseURL)
 	if err != nil {
 		return nil, append(errs, err)
 	}
 
-	catalogPages, err := fetchCatalogPages(catalogService.URI)
+	catalogPages, err := fetchCatalogPages(ctx, catalogService.URI)
 	if err != nil {
 		return nil, append(errs, err)
 	}
@@ -194,7 +199,7 @@ func (feed Feed) Latest(cutoff time.Time) ([]*feeds.Package, []error) {
 			continue
 		}
 
-		page, err := fetchCatalogPage(catalogPage.URI)
+		page, err := fetchCatalogPage(ctx, catalogPage.URI)
 		if err != nil {
 			errs = append(errs, err)
 			continue
@@ -209,13 +214,13 @@ func (feed Feed) Latest(cutoff time.Time) ([]*feeds.Package, []error) {
 				continue // Not currently interested in package deletion events
 			}
 
-			pkgInfo, err := fetchPackageInfo(catalogLeafNode.URI)
+			pkgInfo, err := fetchPackageInfo(ctx, catalogLeafNode.URI)
 			if err != nil {
 				errs = append(errs, err)
 				continue
 			}
 
-			pkg := feeds.NewPackage(pkgInfo.Created, pkgInfo.PackageID, pkgInfo.Version, FeedName)
+			pkg := feeds.NewPackage(pkgInfo.Created, pkgInfo.PackageID, pkgInfo.Version, FeedName, feeds.EcosystemNuGet)
 			pkgs = append(pkgs, pkg)
 		}
 	}
@@ -231,3 +236,7 @@ func (feed Feed) GetName() string {
 func (feed Feed) GetFeedOptions() feeds.FeedOptions {
 	return feed.options
 }
+
+func (feed Feed) GetBaseURL() string {
+	return feed.baseURL
+}
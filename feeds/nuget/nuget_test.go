@@ -1,6 +1,7 @@
 package nuget
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -39,7 +40,7 @@ func TestCanParseFeed(t *testing.T) {
 
 	cutoff := time.Now().Add(-5 * time.Minute)
 
-	results, errs := sut.Latest(cutoff)
+	results, errs := sut.Latest(context.Background(), cutoff)
 	if len(errs) != 0 {
 		t.Fatal(errs[len(errs)-1])
 	}
@@ -64,6 +65,10 @@ func TestCanParseFeed(t *testing.T) {
 	if result.Type != expectedType {
 		t.Fatalf("expected type %s but %s was retrieved", expectedType, result.Type)
 	}
+
+	if result.Ecosystem != feeds.EcosystemNuGet {
+		t.Fatalf("expected ecosystem %s but %s was retrieved", feeds.EcosystemNuGet, result.Ecosystem)
+	}
 }
 
 func indexMock(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,117 @@
+package feeds
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var errUnknownIntervalPackage = errors.New("poll interval configured for a package which is not polled")
+
+// PackageIntervals tracks when individually polled packages were last polled, allowing
+// packages configured with a poll interval to be polled less often than the feed.
+type PackageIntervals struct {
+	intervals map[string]time.Duration
+	clock     Clock
+
+	mu         sync.Mutex
+	lastPolled map[string]time.Time
+}
+
+// NewPackageIntervals returns the PackageIntervals for the packages and package poll
+// intervals of the feed options.
+func NewPackageIntervals(feedOptions FeedOptions) (*PackageIntervals, error) {
+	packages := map[string]bool{}
+	if feedOptions.Packages != nil {
+		for _, pkg := range *feedOptions.Packages {
+			packages[pkg] = true
+		}
+	}
+	for pkg := range feedOptions.PackagePollIntervals {
+		if !packages[pkg] {
+			return nil, fmt.Errorf("%w : %v", errUnknownIntervalPackage, pkg)
+		}
+	}
+	return &PackageIntervals{
+		intervals:  feedOptions.PackagePollIntervals,
+		clock:      ClockOrReal(feedOptions.Clock),
+		lastPolled: map[string]time.Time{},
+	}, nil
+}
+
+// Now returns the current time of the clock of the feed options.
+func (p *PackageIntervals) Now() time.Time {
+	return p.clock.Now()
+}
+
+// Due returns the packages which are due to be polled at now. Packages without an interval
+// and packages which haven't been polled are always due.
+func (p *PackageIntervals) Due(packages []string, now time.Time) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	due := []string{}
+	for _, pkg := range packages {
+		interval := p.intervals[pkg]
+		lastPolled, polled := p.lastPolled[pkg]
+		if interval <= 0 || !polled || now.Sub(lastPolled) >= interval {
+			due = append(due, pkg)
+		}
+	}
+	return due
+}
+
+// ApplyCutoff removes packages created before the cutoff, packages created exactly at the
+// cutoff are retained. Packages configured with an
+// interval use the time they were last polled as the cutoff when this is earlier, so that
+// versions created whilst the package wasn't due are not missed.
+func (p *PackageIntervals) ApplyCutoff(pkgs []*Package, cutoff time.Time) []*Package {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	filtered := []*Package{}
+	for _, pkg := range pkgs {
+		pkgCutoff := cutoff
+		if lastPolled, ok := p.lastPolled[pkg.Name]; ok && p.intervals[pkg.Name] > 0 && lastPolled.Before(cutoff) {
+			pkgCutoff = lastPolled
+		}
+		if !pkg.CreatedDate.Before(pkgCutoff) {
+			filtered = append(filtered, pkg)
+		}
+	}
+	return filtered
+}
+
+// Carry takes over when each package was last polled from the intervals of a previous feed.
+func (p *PackageIntervals) Carry(previous *PackageIntervals) {
+	previous.mu.Lock()
+	lastPolled := make(map[string]time.Time, len(previous.lastPolled))
+	for pkg, polled := range previous.lastPolled {
+		lastPolled[pkg] = polled
+	}
+	previous.mu.Unlock()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for pkg, polled := range lastPolled {
+		p.lastPolled[pkg] = polled
+	}
+}
+
+// Polled records that the packages were polled at now, packages which failed to be polled
+// as reported by a PackagePollError in errs are not recorded.
+func (p *PackageIntervals) Polled(packages []string, errs []error, now time.Time) {
+	failed := map[string]bool{}
+	for _, err := range errs {
+		var pollErr PackagePollError
+		if errors.As(err, &pollErr) {
+			failed[pollErr.Name] = true
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, pkg := range packages {
+		if !failed[pkg] {
+			p.lastPolled[pkg] = now
+		}
+	}
+}
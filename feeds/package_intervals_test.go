@@ -0,0 +1,103 @@
+package feeds
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPackageIntervals(t *testing.T) {
+	t.Parallel()
+
+	packages := []string{"foo", "bar"}
+	intervals, err := NewPackageIntervals(FeedOptions{
+		Packages:             &packages,
+		PackagePollIntervals: map[string]time.Duration{"bar": time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create package intervals: %v", err)
+	}
+
+	start := time.Date(2021, 5, 11, 12, 0, 0, 0, time.UTC)
+	if due := intervals.Due(packages, start); len(due) != 2 {
+		t.Fatalf("Due() returned %v packages before polling when 2 were expected", len(due))
+	}
+	intervals.Polled(packages, nil, start)
+
+	// bar isn't due again until its interval has passed.
+	due := intervals.Due(packages, start.Add(5*time.Minute))
+	if len(due) != 1 || due[0] != "foo" {
+		t.Fatalf("Due() returned %v when only foo was expected", due)
+	}
+	if due := intervals.Due(packages, start.Add(time.Hour)); len(due) != 2 {
+		t.Fatalf("Due() returned %v packages after the interval when 2 were expected", len(due))
+	}
+
+	// bar uses the time it was last polled as its cutoff, as it was not polled since.
+	cutoff := start.Add(55 * time.Minute)
+	pkgs := []*Package{
+		NewPackage(start.Add(10*time.Minute), "foo", "1.0.0", "npm", EcosystemNPM),
+		NewPackage(start.Add(10*time.Minute), "bar", "1.0.0", "npm", EcosystemNPM),
+		NewPackage(start.Add(-10*time.Minute), "bar", "0.9.0", "npm", EcosystemNPM),
+	}
+	filtered := intervals.ApplyCutoff(pkgs, cutoff)
+	if len(filtered) != 1 || filtered[0].Name != "bar" || filtered[0].Version != "1.0.0" {
+		t.Errorf("ApplyCutoff() returned %v packages when only bar@1.0.0 was expected", len(filtered))
+	}
+}
+
+func TestPackageIntervalsFailedPoll(t *testing.T) {
+	t.Parallel()
+
+	packages := []string{"bar"}
+	intervals, err := NewPackageIntervals(FeedOptions{
+		Packages:             &packages,
+		PackagePollIntervals: map[string]time.Duration{"bar": time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create package intervals: %v", err)
+	}
+	start := time.Now()
+	intervals.Polled(packages, []error{PackagePollError{Name: "bar", Err: ErrNoPackagesPolled}}, start)
+	if due := intervals.Due(packages, start.Add(time.Minute)); len(due) != 1 {
+		t.Errorf("Due() did not return the package whose poll failed")
+	}
+}
+
+func TestPackageIntervalsUnknownPackage(t *testing.T) {
+	t.Parallel()
+
+	packages := []string{"foo"}
+	_, err := NewPackageIntervals(FeedOptions{
+		Packages:             &packages,
+		PackagePollIntervals: map[string]time.Duration{"bar": time.Hour},
+	})
+	if !errors.Is(err, errUnknownIntervalPackage) {
+		t.Fatalf("Expected errUnknownIntervalPackage, got: %v", err)
+	}
+}
+
+func TestPackageIntervalsCarry(t *testing.T) {
+	t.Parallel()
+
+	packages := []string{"bar"}
+	options := FeedOptions{
+		Packages:             &packages,
+		PackagePollIntervals: map[string]time.Duration{"bar": time.Hour},
+	}
+	previous, err := NewPackageIntervals(options)
+	if err != nil {
+		t.Fatalf("Failed to create package intervals: %v", err)
+	}
+	start := time.Now()
+	previous.Polled(packages, nil, start)
+
+	intervals, err := NewPackageIntervals(options)
+	if err != nil {
+		t.Fatalf("Failed to create package intervals: %v", err)
+	}
+	intervals.Carry(previous)
+	if due := intervals.Due(packages, start.Add(time.Minute)); len(due) != 0 {
+		t.Errorf("Due() returned %v when the package was polled by the previous intervals", due)
+	}
+}
@@ -0,0 +1,238 @@
+package feeds
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ossf/package-feeds/events"
+	"github.com/ossf/package-feeds/utils"
+)
+
+// DefaultPackagesRefreshInterval is the interval at which a package list loaded from a file
+// or URL is reloaded when no interval is configured.
+const DefaultPackagesRefreshInterval = 5 * time.Minute
+
+var (
+	errConflictingPackageLists = errors.New("only one of packages, packages_file and packages_url may be provided")
+	errInvalidPackageList      = errors.New("invalid package list")
+
+	packageListClient = utils.NewHTTPClient(10 * time.Second)
+)
+
+// Loads a list of packages to poll from PackagesFile or PackagesURL.
+type packageListLoader func(ctx context.Context) ([]string, error)
+
+func newPackageListLoader(options FeedOptions) packageListLoader {
+	if options.PackagesFile != "" {
+		return func(ctx context.Context) ([]string, error) {
+			data, err := ioutil.ReadFile(options.PackagesFile)
+			if err != nil {
+				return nil, err
+			}
+			return parsePackageList(data)
+		}
+	}
+	return func(ctx context.Context) ([]string, error) {
+		resp, err := utils.Get(ctx, packageListClient, options.PackagesURL)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if err := utils.CheckResponseStatus(resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch package list: %w", err)
+		}
+		data, err := utils.LimitedReadAll(resp.Body, utils.DefaultMaxResponseSize)
+		if err != nil {
+			return nil, err
+		}
+		return parsePackageList(data)
+	}
+}
+
+// Parses a package list given either as a json array of names, or as a name per line.
+// Blank lines and lines starting with # are ignored.
+func parsePackageList(data []byte) ([]string, error) {
+	data = bytes.TrimSpace(data)
+	packages := []string{}
+	if bytes.HasPrefix(data, []byte("[")) {
+		if err := json.Unmarshal(data, &packages); err != nil {
+			return nil, fmt.Errorf("%w : %v", errInvalidPackageList, err)
+		}
+		return packages, nil
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		packages = append(packages, line)
+	}
+	return packages, nil
+}
+
+// A feed polling a package list loaded from a file or URL, which is reloaded periodically.
+// When the list changes the underlying feed is constructed again with the new list, taking
+// effect from the next poll. The new feed takes over the state of the previous feed when it
+// is a StatefulFeed.
+type packageListFeed struct {
+	factory      Factory
+	options      FeedOptions
+	eventHandler *events.Handler
+	load         packageListLoader
+	interval     time.Duration
+	clock        Clock
+
+	mu       sync.Mutex
+	feed     ScheduledFeed
+	packages []string
+	loaded   time.Time
+}
+
+func newPackageListFeed(factory Factory, options FeedOptions, eventHandler *events.Handler) (*packageListFeed, error) {
+	if options.Packages != nil || (options.PackagesFile != "" && options.PackagesURL != "") {
+		return nil, errConflictingPackageLists
+	}
+	interval := options.PackagesRefreshInterval
+	if interval <= 0 {
+		interval = DefaultPackagesRefreshInterval
+	}
+	f := &packageListFeed{
+		factory:      factory,
+		options:      options,
+		eventHandler: eventHandler,
+		load:         newPackageListLoader(options),
+		interval:     interval,
+		clock:        ClockOrReal(options.Clock),
+	}
+	// The list is loaded when the feed is constructed, so that an invalid list fails at startup.
+	if err := f.reload(context.Background()); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Loads the package list, constructing the underlying feed again if it has changed.
+func (f *packageListFeed) reload(ctx context.Context) error {
+	packages, err := f.load(ctx)
+	if err != nil {
+		return err
+	}
+	f.loaded = f.clock.Now()
+	if f.feed != nil && equalStrings(packages, f.packages) {
+		return nil
+	}
+	options := f.options
+	options.Packages = &packages
+	feed, err := f.factory(options, f.eventHandler)
+	if err != nil {
+		return err
+	}
+	if stateful, ok := feed.(StatefulFeed); ok && f.feed != nil {
+		stateful.CarryState(f.feed)
+	}
+	if f.feed != nil {
+		// The replaced feed is no longer polled.
+		if err := Close(f.feed); err != nil {
+			log.WithError(err).WithField("feed", f.feed.GetName()).Warn("Failed to close the replaced feed")
+		}
+		log.WithFields(log.Fields{
+			"feed":         feed.GetName(),
+			"num_packages": len(packages),
+		}).Print("Reloaded package list")
+	}
+	f.feed = feed
+	f.packages = packages
+	return nil
+}
+
+// Returns the underlying feed, reloading the package list first if it is due. A failed
+// reload keeps polling the previously loaded list.
+func (f *packageListFeed) current(ctx context.Context) (ScheduledFeed, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.clock.Now().Sub(f.loaded) < f.interval {
+		return f.feed, nil
+	}
+	if err := f.reload(ctx); err != nil {
+		return f.feed, fmt.Errorf("failed to reload package list: %w", err)
+	}
+	return f.feed, nil
+}
+
+func (f *packageListFeed) Latest(ctx context.Context, cutoff time.Time) ([]*Package, []error) {
+	feed, err := f.current(ctx)
+	pkgs, errs := feed.Latest(ctx, cutoff)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return pkgs, errs
+}
+
+// Between fetches the window from the feed polling the current package list, without
+// reloading it.
+func (f *packageListFeed) Between(ctx context.Context, from, to time.Time) ([]*Package, []error) {
+	f.mu.Lock()
+	feed := f.feed
+	f.mu.Unlock()
+	return Between(ctx, feed, from, to)
+}
+
+// Commit commits the position reached by the feed which polled the current package list.
+func (f *packageListFeed) Commit() error {
+	f.mu.Lock()
+	feed := f.feed
+	f.mu.Unlock()
+	if committing, ok := feed.(CommittingFeed); ok {
+		return committing.Commit()
+	}
+	return nil
+}
+
+// Close closes the feed polling the current package list.
+func (f *packageListFeed) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return Close(f.feed)
+}
+
+func (f *packageListFeed) GetName() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.feed.GetName()
+}
+
+func (f *packageListFeed) GetFeedOptions() FeedOptions {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.feed.GetFeedOptions()
+}
+
+func (f *packageListFeed) GetBaseURL() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if feed, ok := f.feed.(BaseURLFeed); ok {
+		return feed.GetBaseURL()
+	}
+	return ""
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,247 @@
+package feeds
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/events"
+)
+
+// A feed emitting a package for each package it polls.
+type packageListDummyFeed struct {
+	options FeedOptions
+}
+
+func (feed packageListDummyFeed) Latest(ctx context.Context, cutoff time.Time) ([]*Package, []error) {
+	pkgs := []*Package{}
+	for _, name := range *feed.options.Packages {
+		pkgs = append(pkgs, NewPackage(cutoff, name, "1.0.0", feed.GetName(), ""))
+	}
+	return pkgs, nil
+}
+
+func (feed packageListDummyFeed) GetName() string {
+	return "package-list-dummy"
+}
+
+func (feed packageListDummyFeed) GetFeedOptions() FeedOptions {
+	return feed.options
+}
+
+func newPackageListDummyFeed(options FeedOptions, _ *events.Handler) (ScheduledFeed, error) {
+	return packageListDummyFeed{options: options}, nil
+}
+
+func packageNames(pkgs []*Package) []string {
+	names := []string{}
+	for _, pkg := range pkgs {
+		names = append(names, pkg.Name)
+	}
+	return names
+}
+
+func TestPackageListFileReload(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "packages.txt")
+	if err := ioutil.WriteFile(path, []byte("# Critical packages\nfoo\n\nbar\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	clock := NewFakeClock(time.Now())
+	feed, err := newPackageListFeed(newPackageListDummyFeed, FeedOptions{
+		PackagesFile:            path,
+		PackagesRefreshInterval: time.Minute,
+		Clock:                   clock,
+	}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create package list feed: %v", err)
+	}
+
+	pkgs, errs := feed.Latest(context.Background(), time.Time{})
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	if names := packageNames(pkgs); !equalStrings(names, []string{"foo", "bar"}) {
+		t.Errorf("Latest() polled %v when foo and bar were expected", names)
+	}
+	if packages := feed.GetFeedOptions().Packages; packages == nil || len(*packages) != 2 {
+		t.Errorf("GetFeedOptions() returned packages %v when foo and bar were expected", packages)
+	}
+
+	if err := ioutil.WriteFile(path, []byte(`["baz"]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// The list isn't reloaded until the refresh interval has passed.
+	pkgs, _ = feed.Latest(context.Background(), time.Time{})
+	if names := packageNames(pkgs); !equalStrings(names, []string{"foo", "bar"}) {
+		t.Errorf("Latest() polled %v before the refresh interval when foo and bar were expected", names)
+	}
+	clock.Advance(time.Minute)
+	pkgs, errs = feed.Latest(context.Background(), time.Time{})
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	if names := packageNames(pkgs); !equalStrings(names, []string{"baz"}) {
+		t.Errorf("Latest() polled %v after the list was reloaded when only baz was expected", names)
+	}
+
+	// A list which fails to load keeps the previous list, reporting an error.
+	if err := ioutil.WriteFile(path, []byte(`["baz"`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute)
+	pkgs, errs = feed.Latest(context.Background(), time.Time{})
+	if len(errs) != 1 || !errors.Is(errs[0], errInvalidPackageList) {
+		t.Errorf("feed.Latest returned %v when an invalid package list error was expected", errs)
+	}
+	if names := packageNames(pkgs); !equalStrings(names, []string{"baz"}) {
+		t.Errorf("Latest() polled %v after a failed reload when only baz was expected", names)
+	}
+}
+
+func TestPackageListURL(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte("foo\nbar\n")); err != nil {
+			t.Errorf("Failed to write package list: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	feed, err := newPackageListFeed(newPackageListDummyFeed, FeedOptions{PackagesURL: srv.URL},
+		events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create package list feed: %v", err)
+	}
+	pkgs, errs := feed.Latest(context.Background(), time.Time{})
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	if names := packageNames(pkgs); !equalStrings(names, []string{"foo", "bar"}) {
+		t.Errorf("Latest() polled %v when foo and bar were expected", names)
+	}
+}
+
+func TestPackageListConflicting(t *testing.T) {
+	t.Parallel()
+
+	packages := []string{"foo"}
+	_, err := newPackageListFeed(newPackageListDummyFeed, FeedOptions{
+		Packages:     &packages,
+		PackagesFile: "packages.txt",
+	}, events.NewNullHandler())
+	if !errors.Is(err, errConflictingPackageLists) {
+		t.Errorf("newPackageListFeed() returned `%v` when a conflicting package lists error was expected", err)
+	}
+}
+
+// A feed counting its polls, carrying the count over from the feed it replaces, which also
+// fetches past windows and commits.
+type statefulDummyFeed struct {
+	packageListDummyFeed
+	polls   *int
+	commits *int
+	closed  bool
+}
+
+func (feed *statefulDummyFeed) Latest(ctx context.Context, cutoff time.Time) ([]*Package, []error) {
+	*feed.polls++
+	return feed.packageListDummyFeed.Latest(ctx, cutoff)
+}
+
+func (feed *statefulDummyFeed) Between(ctx context.Context, from, to time.Time) ([]*Package, []error) {
+	return feed.packageListDummyFeed.Latest(ctx, from)
+}
+
+func (feed *statefulDummyFeed) Commit() error {
+	*feed.commits++
+	return nil
+}
+
+func (feed *statefulDummyFeed) Close() error {
+	feed.closed = true
+	return nil
+}
+
+func (feed *statefulDummyFeed) CarryState(previous ScheduledFeed) {
+	if prev, ok := previous.(*statefulDummyFeed); ok {
+		feed.polls = prev.polls
+		feed.commits = prev.commits
+	}
+}
+
+func newStatefulDummyFeed(options FeedOptions, _ *events.Handler) (ScheduledFeed, error) {
+	return &statefulDummyFeed{
+		packageListDummyFeed: packageListDummyFeed{options: options},
+		polls:                new(int),
+		commits:              new(int),
+	}, nil
+}
+
+func TestPackageListReloadCarriesState(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "packages.txt")
+	if err := ioutil.WriteFile(path, []byte("foo\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	clock := NewFakeClock(time.Now())
+	feed, err := newPackageListFeed(withRequestOptions(newStatefulDummyFeed), FeedOptions{
+		PackagesFile:            path,
+		PackagesRefreshInterval: time.Minute,
+		Clock:                   clock,
+	}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create package list feed: %v", err)
+	}
+
+	feed.Latest(context.Background(), time.Time{})
+	if err := feed.Commit(); err != nil {
+		t.Fatalf("Commit() returned unexpected error: %v", err)
+	}
+	replaced, ok := feed.feed.(*statefulDummyFeed)
+	if !ok {
+		t.Fatalf("package list feed polls %T when the stateful feed was expected", feed.feed)
+	}
+	if err := ioutil.WriteFile(path, []byte("bar\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute)
+	pkgs, _ := feed.Latest(context.Background(), time.Time{})
+	if names := packageNames(pkgs); !equalStrings(names, []string{"bar"}) {
+		t.Fatalf("Latest() polled %v after the list was reloaded when only bar was expected", names)
+	}
+	if err := feed.Commit(); err != nil {
+		t.Fatalf("Commit() returned unexpected error: %v", err)
+	}
+	stateful, ok := feed.feed.(*statefulDummyFeed)
+	if !ok {
+		t.Fatalf("package list feed polls %T when the stateful feed was expected", feed.feed)
+	}
+	if *stateful.polls != 2 || *stateful.commits != 2 {
+		t.Errorf("Reloaded feed counted %v polls and %v commits when the state of the previous feed was expected",
+			*stateful.polls, *stateful.commits)
+	}
+	if !replaced.closed || stateful.closed {
+		t.Errorf("Reload closed the replaced feed: %v and the new feed: %v when only the replaced feed "+
+			"should be closed", replaced.closed, stateful.closed)
+	}
+	if err := feed.Close(); err != nil || !stateful.closed {
+		t.Errorf("Close() returned %v and closed the current feed: %v", err, stateful.closed)
+	}
+
+	pkgs, errs := Between(context.Background(), feed, clock.Now().Add(-time.Hour), clock.Now().Add(time.Hour))
+	if len(errs) != 0 {
+		t.Fatalf("Between() returned unexpected errors: %v", errs)
+	}
+	if names := packageNames(pkgs); !equalStrings(names, []string{"bar"}) {
+		t.Errorf("Between() fetched %v when only bar was expected", names)
+	}
+}
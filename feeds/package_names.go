@@ -0,0 +1,46 @@
+package feeds
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ossf/package-feeds/events"
+)
+
+var errInvalidPackageNames = errors.New("invalid package names")
+
+// PackageNameValidator is implemented by feeds which can check that the names of the
+// packages they are configured to poll are well-formed for their registry, so that a typo
+// in a critical package list fails when the feed is constructed rather than polling as a
+// package missing from the registry.
+type PackageNameValidator interface {
+	// ValidatePackageName returns an error describing why the name is not well-formed.
+	ValidatePackageName(name string) error
+}
+
+// Wraps the factory so that the configured packages of feeds which validate package names
+// are checked once the feed is constructed. Every invalid name is reported in the error.
+func withPackageNameValidation(factory Factory) Factory {
+	return func(options FeedOptions, eventHandler *events.Handler) (ScheduledFeed, error) {
+		feed, err := factory(options, eventHandler)
+		if err != nil || options.Packages == nil {
+			return feed, err
+		}
+		validator, ok := feed.(PackageNameValidator)
+		if !ok {
+			return feed, nil
+		}
+		failures := []string{}
+		for _, name := range *options.Packages {
+			if err := validator.ValidatePackageName(name); err != nil {
+				failures = append(failures, fmt.Sprintf("%q: %v", name, err))
+			}
+		}
+		if len(failures) > 0 {
+			return nil, fmt.Errorf("%w of feed %s : %v", errInvalidPackageNames, feed.GetName(),
+				strings.Join(failures, "; "))
+		}
+		return feed, nil
+	}
+}
@@ -0,0 +1,41 @@
+package feeds
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ossf/package-feeds/events"
+)
+
+var errUppercase = errors.New("name must be lowercase")
+
+type validatingDummyFeed struct {
+	dummyFeed
+}
+
+func (feed validatingDummyFeed) ValidatePackageName(name string) error {
+	if strings.ToLower(name) != name {
+		return errUppercase
+	}
+	return nil
+}
+
+func TestPackageNameValidation(t *testing.T) {
+	t.Parallel()
+
+	factory := withPackageNameValidation(func(options FeedOptions, _ *events.Handler) (ScheduledFeed, error) {
+		return validatingDummyFeed{dummyFeed{options: options}}, nil
+	})
+	packages := []string{"foo", "Bar", "BAZ"}
+	_, err := factory(FeedOptions{Packages: &packages}, events.NewNullHandler())
+	if !errors.Is(err, errInvalidPackageNames) || !strings.Contains(err.Error(), `"Bar"`) ||
+		!strings.Contains(err.Error(), `"BAZ"`) || strings.Contains(err.Error(), `"foo"`) {
+		t.Errorf("Constructing the feed returned %v when each invalid name should be reported", err)
+	}
+
+	packages = []string{"foo", "bar"}
+	if _, err := factory(FeedOptions{Packages: &packages}, events.NewNullHandler()); err != nil {
+		t.Errorf("Constructing the feed returned unexpected error: %v", err)
+	}
+}
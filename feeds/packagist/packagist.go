@@ -1,21 +1,21 @@
 package packagist
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/ossf/package-feeds/events"
 	"github.com/ossf/package-feeds/feeds"
 	"github.com/ossf/package-feeds/utils"
 )
 
 const FeedName = "packagist"
 
-var httpClient = &http.Client{
-	Timeout: 10 * time.Second,
-}
+var httpClient = utils.NewHTTPClient(10 * time.Second)
 
 type response struct {
 	Actions   []actions `json:"actions"`
@@ -34,6 +34,12 @@ type Feed struct {
 	options     feeds.FeedOptions
 }
 
+func init() { //nolint:gochecknoinits
+	feeds.Register(FeedName, func(options feeds.FeedOptions, _ *events.Handler) (feeds.ScheduledFeed, error) {
+		return New(options)
+	})
+}
+
 func New(feedOptions feeds.FeedOptions) (*Feed, error) {
 	if feedOptions.Packages != nil {
 		return nil, feeds.UnsupportedOptionError{
@@ -48,12 +54,12 @@ func New(feedOptions feeds.FeedOptions) (*Feed, error) {
 	}, nil
 }
 
-func fetchPackages(updateHost string, since time.Time) ([]actions, error) {
+func fetchPackages(ctx context.Context, updateHost string, since time.Time) ([]actions, error) {
 	pkgURL, err := utils.URLPathJoin(updateHost, "/metadata/changes.json")
 	if err != nil {
 		return nil, err
 	}
-	request, err := http.NewRequest(http.MethodGet, pkgURL, nil)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, pkgURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -81,8 +87,8 @@ func fetchPackages(updateHost string, since time.Time) ([]actions, error) {
 	return apiResponse.Actions, nil
 }
 
-func fetchVersionInformation(versionHost string, action actions) ([]*feeds.Package, error) {
-	resp, err := httpClient.Get(fmt.Sprintf("%s/p2/%s.json", versionHost, action.Package))
+func fetchVersionInformation(ctx context.Context, versionHost string, action actions) ([]*feeds.Package, error) {
+	resp, err := utils.Get(ctx, httpClient, fmt.Sprintf("%s/p2/%s.json", versionHost, action.Package))
 	if err != nil {
 		return nil, err
 	}
@@ -102,7 +108,7 @@ func fetchVersionInformation(versionHost string, action actions) ([]*feeds.Packa
 	pkgs := []*feeds.Package{}
 	for pkgName, versions := range versionResponse.Packages {
 		for _, version := range versions {
-			pkg := feeds.NewPackage(version.Time, pkgName, version.Version, FeedName)
+			pkg := feeds.NewPackage(version.Time, pkgName, version.Version, FeedName, feeds.EcosystemPackagist)
 			if err != nil {
 				continue
 			}
@@ -114,10 +120,10 @@ func fetchVersionInformation(versionHost string, action actions) ([]*feeds.Packa
 }
 
 // Latest returns all package updates of packagist packages since cutoff.
-func (f Feed) Latest(cutoff time.Time) ([]*feeds.Package, []error) {
+func (f Feed) Latest(ctx context.Context, cutoff time.Time) ([]*feeds.Package, []error) {
 	pkgs := []*feeds.Package{}
 	var errs []error
-	packages, err := fetchPackages(f.updateHost, cutoff)
+	packages, err := fetchPackages(ctx, f.updateHost, cutoff)
 	if err != nil {
 		return nil, append(errs, err)
 	}
@@ -128,7 +134,7 @@ func (f Feed) Latest(cutoff time.Time) ([]*feeds.Package, []error) {
 		if pkg.Type == "delete" {
 			continue
 		}
-		updates, err := fetchVersionInformation(f.versionHost, pkg)
+		updates, err := fetchVersionInformation(ctx, f.versionHost, pkg)
 		if err != nil {
 			errs = append(errs, fmt.Errorf("error in fetching version information: %w", err))
 			continue
@@ -146,3 +152,11 @@ func (f Feed) GetName() string {
 func (f Feed) GetFeedOptions() feeds.FeedOptions {
 	return f.options
 }
+
+func (f Feed) GetBaseURL() string {
+	return f.updateHost
+}
+
+func (f Feed) GetRegistryURLs() []string {
+	return []string{f.updateHost, f.versionHost}
+}
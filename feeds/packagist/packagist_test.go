@@ -1,6 +1,7 @@
 package packagist
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"testing"
@@ -29,7 +30,7 @@ func TestFetch(t *testing.T) {
 	feed.versionHost = srv.URL
 
 	cutoff := time.Unix(1614513658, 0)
-	latest, errs := feed.Latest(cutoff)
+	latest, errs := feed.Latest(context.Background(), cutoff)
 	if len(errs) != 0 {
 		t.Fatalf("got error: %v", errs[len(errs)-1])
 	}
@@ -43,6 +44,9 @@ func TestFetch(t *testing.T) {
 		if pkg.Name == "to-delete/deleted-package" {
 			t.Fatalf("pkg to-delete/deleted-package was deleted and should not be included here")
 		}
+		if pkg.Ecosystem != feeds.EcosystemPackagist {
+			t.Fatalf("package returned with ecosystem %s instead of %s", pkg.Ecosystem, feeds.EcosystemPackagist)
+		}
 	}
 }
 
@@ -64,7 +68,7 @@ func TestPackagistNotFound(t *testing.T) {
 	feed.versionHost = srv.URL
 
 	cutoff := time.Unix(1614513658, 0)
-	_, errs := feed.Latest(cutoff)
+	_, errs := feed.Latest(context.Background(), cutoff)
 	if len(errs) != 1 {
 		t.Fatalf("feed.Latest() returned %v errors when 1 was expected", len(errs))
 	}
@@ -0,0 +1,46 @@
+package feeds
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// PollResult describes the outcome of a single poll of a feed.
+type PollResult struct {
+	Feed     string
+	Packages []*Package
+	Errors   []error
+	Duration time.Duration
+	PolledAt time.Time
+	// Set when the feed was not polled, e.g. because its circuit breaker is open.
+	Skipped bool
+	// Set when the feed is disabled in the configuration, so is never polled.
+	Disabled bool
+}
+
+type pollResultJSON struct {
+	Feed        string    `json:"feed"`
+	PolledAt    time.Time `json:"polled_at"`
+	NumPackages int       `json:"num_packages"`
+	Errors      []string  `json:"errors"`
+	Duration    string    `json:"duration"`
+	Skipped     bool      `json:"skipped,omitempty"`
+	Disabled    bool      `json:"disabled,omitempty"`
+}
+
+// MarshalJSON summarizes the result, packages are counted rather than included.
+func (r PollResult) MarshalJSON() ([]byte, error) {
+	summary := pollResultJSON{
+		Feed:        r.Feed,
+		PolledAt:    r.PolledAt,
+		NumPackages: len(r.Packages),
+		Errors:      []string{},
+		Duration:    r.Duration.String(),
+		Skipped:     r.Skipped,
+		Disabled:    r.Disabled,
+	}
+	for _, err := range r.Errors {
+		summary.Errors = append(summary.Errors, err.Error())
+	}
+	return json.Marshal(summary)
+}
@@ -0,0 +1,262 @@
+package pub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ossf/package-feeds/events"
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/utils"
+	"github.com/ossf/package-feeds/utils/feedparser"
+)
+
+const (
+	FeedName          = "pub"
+	atomPath          = "/feed.atom"
+	packagePathFormat = "/api/packages/%s"
+	// pub.dev requests that API clients identify themselves.
+	userAgent = "package-feeds (github.com/ossf/package-feeds)"
+)
+
+var (
+	httpClient            = utils.NewHTTPClient(10 * time.Second)
+	errInvalidEntryTitle  = errors.New("invalid entry title provided by pub.dev atom feed")
+	errInvalidEntryDate   = errors.New("invalid entry updated date provided by pub.dev atom feed")
+	errInvalidPackageName = errors.New("name must only contain lowercase letters, digits and _, " +
+		"starting with a letter or _")
+
+	// A valid package name, as required by pub.dev for the name of a package's pubspec.
+	packageNameRegexp = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+)
+
+// Name and version are parsed from entry titles, which are formatted as "v1.0.0 of foo".
+func nameAndVersion(entry *feedparser.Entry) (string, string, error) {
+	parts := strings.Fields(entry.Title)
+	if len(parts) != 3 || parts[1] != "of" || !strings.HasPrefix(parts[0], "v") {
+		return "", "", fmt.Errorf("%w : %q", errInvalidEntryTitle, entry.Title)
+	}
+	return parts[2], strings.TrimPrefix(parts[0], "v"), nil
+}
+
+type packageResponse struct {
+	Name     string            `json:"name"`
+	Versions []*packageVersion `json:"versions"`
+}
+
+type packageVersion struct {
+	Version   string    `json:"version"`
+	Published time.Time `json:"published"`
+}
+
+func get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	return httpClient.Do(req)
+}
+
+// Fetches the entries of the atom feed of recently published package versions.
+func fetchAtomEntries(ctx context.Context, baseURL string) ([]*feedparser.Entry, error) {
+	feedURL, err := utils.URLPathJoin(baseURL, atomPath)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := get(ctx, feedURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	err = utils.CheckResponseStatus(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pub package data: %w", err)
+	}
+
+	return feedparser.Parse(resp.Body)
+}
+
+// Fetches all versions of a package alongside their publish date.
+func fetchPackage(ctx context.Context, baseURL, pkgName string) ([]*packageVersion, error) {
+	pkgURL, err := utils.URLPathJoin(baseURL, fmt.Sprintf(packagePathFormat, pkgName))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := get(ctx, pkgURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	err = utils.CheckResponseStatus(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pub package version data: %w", err)
+	}
+
+	pkg := &packageResponse{}
+	err = json.NewDecoder(resp.Body).Decode(pkg)
+	if err != nil {
+		return nil, err
+	}
+	return pkg.Versions, nil
+}
+
+func fetchAllPackages(ctx context.Context, baseURL string) ([]*feeds.Package, []error) {
+	pkgs := []*feeds.Package{}
+	errs := []error{}
+	entries, err := fetchAtomEntries(ctx, baseURL)
+	if err != nil {
+		return pkgs, append(errs, err)
+	}
+	for _, entry := range entries {
+		name, version, err := nameAndVersion(entry)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if entry.Updated.IsZero() {
+			errs = append(errs, feeds.PackagePollError{
+				Name: name,
+				Err:  fmt.Errorf("%w : version %v", errInvalidEntryDate, version),
+			})
+			continue
+		}
+		pkgs = append(pkgs, feeds.NewPackage(entry.Updated, name, version, FeedName, feeds.EcosystemPub))
+	}
+	return pkgs, errs
+}
+
+func fetchCriticalPackages(ctx context.Context, baseURL string, packages []string) ([]*feeds.Package, []error) {
+	pkgs := []*feeds.Package{}
+	errs := []error{}
+	packageChannel := make(chan []*feeds.Package)
+	errChannel := make(chan error)
+
+	for _, pkgName := range packages {
+		go func(pkgName string) {
+			versions, err := fetchPackage(ctx, baseURL, pkgName)
+			if err != nil {
+				errChannel <- feeds.PackagePollError{Name: pkgName, Err: err}
+				return
+			}
+			versionPkgs := []*feeds.Package{}
+			for _, v := range versions {
+				versionPkgs = append(versionPkgs, feeds.NewPackage(v.Published, pkgName, v.Version, FeedName, feeds.EcosystemPub))
+			}
+			packageChannel <- versionPkgs
+		}(pkgName)
+	}
+
+	for i := 0; i < len(packages); i++ {
+		select {
+		case versionPkgs := <-packageChannel:
+			pkgs = append(pkgs, versionPkgs...)
+		case err := <-errChannel:
+			errs = append(errs, err)
+		}
+	}
+	return pkgs, errs
+}
+
+type Feed struct {
+	packages         *[]string
+	lossyFeedAlerter *feeds.LossyFeedAlerter
+	baseURL          string
+	intervals        *feeds.PackageIntervals
+	options          feeds.FeedOptions
+}
+
+func init() { //nolint:gochecknoinits
+	feeds.Register(FeedName, func(options feeds.FeedOptions, eventHandler *events.Handler) (feeds.ScheduledFeed, error) {
+		return New(options, eventHandler)
+	})
+}
+
+func New(feedOptions feeds.FeedOptions, eventHandler *events.Handler) (*Feed, error) {
+	intervals, err := feeds.NewPackageIntervals(feedOptions)
+	if err != nil {
+		return nil, err
+	}
+	return &Feed{
+		packages:         feedOptions.Packages,
+		lossyFeedAlerter: feeds.NewLossyFeedAlerter(eventHandler),
+		baseURL:          "https://pub.dev/",
+		intervals:        intervals,
+		options:          feedOptions,
+	}, nil
+}
+
+func (feed Feed) Latest(ctx context.Context, cutoff time.Time) ([]*feeds.Package, []error) {
+	pkgs := []*feeds.Package{}
+	var errs []error
+
+	if feed.packages == nil {
+		pkgs, errs = fetchAllPackages(ctx, feed.baseURL)
+	} else {
+		now := feed.intervals.Now()
+		due := feed.intervals.Due(*feed.packages, now)
+		if len(due) == 0 {
+			return pkgs, nil
+		}
+		pkgs, errs = fetchCriticalPackages(ctx, feed.baseURL, due)
+		// Recorded once the cutoff has been applied, which depends on the previous poll.
+		defer feed.intervals.Polled(due, errs, now)
+	}
+
+	if len(pkgs) == 0 {
+		// If none of the packages were successfully polled for, return early.
+		return nil, append(errs, feeds.ErrNoPackagesPolled)
+	}
+
+	// Ensure packages are sorted by CreatedDate in order of most recent, as goroutine
+	// concurrency isn't deterministic.
+	feeds.SortByCreatedDate(pkgs)
+
+	// Lossy feed detection is only necessary for firehose fetching.
+	if feed.packages == nil {
+		feed.lossyFeedAlerter.ProcessPackages(FeedName, pkgs)
+		return feeds.ApplyCutoff(pkgs, cutoff), errs
+	}
+
+	// Packages polled less often than the feed are filtered by their own cutoff.
+	return feed.intervals.ApplyCutoff(pkgs, cutoff), errs
+}
+
+// ValidatePackageName checks the name is a valid pub package name, e.g. http or
+// flutter_bloc.
+func (feed Feed) ValidatePackageName(name string) error {
+	if !packageNameRegexp.MatchString(name) {
+		return errInvalidPackageName
+	}
+	return nil
+}
+
+// CarryState takes over the times packages were polled from the feed polling the previous
+// package list.
+func (feed *Feed) CarryState(previous feeds.ScheduledFeed) {
+	prev, ok := previous.(*Feed)
+	if !ok {
+		return
+	}
+	feed.intervals.Carry(prev.intervals)
+	feed.lossyFeedAlerter = prev.lossyFeedAlerter
+}
+
+func (feed Feed) GetName() string {
+	return FeedName
+}
+
+func (feed Feed) GetFeedOptions() feeds.FeedOptions {
+	return feed.options
+}
+
+func (feed Feed) GetBaseURL() string {
+	return feed.baseURL
+}
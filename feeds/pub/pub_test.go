@@ -0,0 +1,265 @@
+package pub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/events"
+	"github.com/ossf/package-feeds/feeds"
+	testutils "github.com/ossf/package-feeds/utils/test"
+)
+
+func TestPubLatest(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		atomPath: pubAtomResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	feed, err := New(feeds.FeedOptions{}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create pub feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+
+	if len(pkgs) != 2 {
+		t.Fatalf("Latest() produced %v packages instead of the expected 2", len(pkgs))
+	}
+	if pkgs[0].Name != "foopackage" {
+		t.Errorf("Unexpected package `%s` found in place of expected `foopackage`", pkgs[0].Name)
+	}
+	if pkgs[1].Name != "barpackage" {
+		t.Errorf("Unexpected package `%s` found in place of expected `barpackage`", pkgs[1].Name)
+	}
+	if pkgs[0].Version != "1.0.1" {
+		t.Errorf("Unexpected version `%s` found in place of expected `1.0.1`", pkgs[0].Version)
+	}
+	if pkgs[1].Version != "0.5.0-dev.1" {
+		t.Errorf("Unexpected version `%s` found in place of expected `0.5.0-dev.1`", pkgs[1].Version)
+	}
+	fooTime := time.Date(2021, 5, 20, 10, 15, 0, 0, time.UTC)
+	if !pkgs[0].CreatedDate.Equal(fooTime) {
+		t.Errorf("Unexpected created date `%s` found in place of expected `%s`", pkgs[0].CreatedDate, fooTime)
+	}
+
+	for _, p := range pkgs {
+		if p.Type != FeedName {
+			t.Errorf("Feed type not set correctly in pub package following Latest()")
+		}
+		if p.Ecosystem != feeds.EcosystemPub {
+			t.Errorf("Ecosystem not set correctly in pub package following Latest()")
+		}
+	}
+}
+
+func TestPubCritical(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/api/packages/foopackage": fooPackageResponse,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	packages := []string{"foopackage"}
+	feed, err := New(feeds.FeedOptions{Packages: &packages}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create pub feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	cutoff := time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+
+	// 1.0.0 was published before the cutoff.
+	if len(pkgs) != 1 {
+		t.Fatalf("Latest() produced %v packages instead of the expected 1", len(pkgs))
+	}
+	if pkgs[0].Version != "1.0.1" {
+		t.Errorf("Unexpected version `%s` found in place of expected `1.0.1`", pkgs[0].Version)
+	}
+}
+
+func TestPubCriticalPartialNotFound(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/api/packages/foopackage": fooPackageResponse,
+		"/api/packages/barpackage": testutils.NotFoundHandlerFunc,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	packages := []string{"foopackage", "barpackage"}
+	feed, err := New(feeds.FeedOptions{Packages: &packages}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create pub feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 1 {
+		t.Fatalf("feed.Latest() returned %v errors when 1 was expected", len(errs))
+	}
+	var pollErr feeds.PackagePollError
+	if !errors.As(errs[len(errs)-1], &pollErr) || pollErr.Name != "barpackage" {
+		t.Fatalf("Failed to wrap the package error in feeds.PackagePollError, instead: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 2 {
+		t.Fatalf("Latest() produced %v packages instead of the expected 2", len(pkgs))
+	}
+}
+
+func TestPubNotFound(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		atomPath: testutils.NotFoundHandlerFunc,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	feed, err := New(feeds.FeedOptions{}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create pub feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 2 {
+		t.Fatalf("feed.Latest() returned %v errors when 2 were expected", len(errs))
+	}
+	if !errors.Is(errs[len(errs)-1], feeds.ErrNoPackagesPolled) {
+		t.Fatalf("feed.Latest() returned an error which did not match the expected error")
+	}
+}
+
+func TestPubLatestInvalidEntryDate(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		atomPath: func(w http.ResponseWriter, r *http.Request) {
+			_, err := w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+	<entry>
+		<title>v0.5.0-dev.1 of barpackage</title>
+		<updated>yesterday</updated>
+	</entry>
+	<entry>
+		<title>v1.0.1 of foopackage</title>
+		<updated>2021-05-20T10:15:00.000Z</updated>
+	</entry>
+</feed>
+`))
+			if err != nil {
+				http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+			}
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	feed, err := New(feeds.FeedOptions{}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create pub feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	// The entry without a valid date is reported, the other entries are still returned.
+	pkgs, errs := feed.Latest(context.Background(), time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC))
+	var pollErr feeds.PackagePollError
+	if len(errs) != 1 || !errors.As(errs[0], &pollErr) || pollErr.Name != "barpackage" ||
+		!errors.Is(pollErr.Err, errInvalidEntryDate) {
+		t.Fatalf("feed.Latest returned %v when an invalid date error for barpackage was expected", errs)
+	}
+	if len(pkgs) != 1 || pkgs[0].Name != "foopackage" {
+		t.Fatalf("Latest() produced %v packages when only foopackage was expected", len(pkgs))
+	}
+}
+
+func pubAtomResponse(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.UserAgent(), "package-feeds") {
+		http.Error(w, "missing user agent", http.StatusForbidden)
+		return
+	}
+	_, err := w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+	<id>https://pub.dev/feed.atom</id>
+	<title>Pub Packages for Dart</title>
+	<updated>2021-05-20T10:15:00.000Z</updated>
+	<entry>
+		<id>urn:uuid:2a8c6a5e-9e1a-4a8c-9d8f-3c1c6a2a7b01</id>
+		<title>v0.5.0-dev.1 of barpackage</title>
+		<updated>2021-05-20T09:02:30.000Z</updated>
+		<author><name>bar.dev</name></author>
+		<content type="html">A bar package.</content>
+		<link href="https://pub.dev/packages/barpackage" rel="alternate"/>
+	</entry>
+	<entry>
+		<id>urn:uuid:0f4c1b64-71a4-4c3e-9b55-6d0e5ad4a7c2</id>
+		<title>v1.0.1 of foopackage</title>
+		<updated>2021-05-20T10:15:00.000Z</updated>
+		<author><name>foo.dev</name></author>
+		<content type="html">A foo package.</content>
+		<link href="https://pub.dev/packages/foopackage" rel="alternate"/>
+	</entry>
+</feed>
+`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
+
+func fooPackageResponse(w http.ResponseWriter, r *http.Request) {
+	_, err := w.Write([]byte(`
+{
+	"name": "foopackage",
+	"latest": {
+		"version": "1.0.1",
+		"published": "2021-05-20T10:15:00.000Z"
+	},
+	"versions": [
+		{
+			"version": "1.0.0",
+			"archive_url": "https://pub.dartlang.org/packages/foopackage/versions/1.0.0.tar.gz",
+			"published": "2021-04-02T16:41:07.000Z"
+		},
+		{
+			"version": "1.0.1",
+			"archive_url": "https://pub.dartlang.org/packages/foopackage/versions/1.0.1.tar.gz",
+			"published": "2021-05-20T10:15:00.000Z"
+		}
+	]
+}
+`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
+
+func TestPubValidatePackageName(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"http", "flutter_bloc", "_internal", "path2"} {
+		if err := (Feed{}).ValidatePackageName(name); err != nil {
+			t.Errorf("ValidatePackageName(%q) returned unexpected error: %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "Flutter", "flutter-bloc", "2d", "flutter bloc"} {
+		if err := (Feed{}).ValidatePackageName(name); !errors.Is(err, errInvalidPackageName) {
+			t.Errorf("ValidatePackageName(%q) returned %v when errInvalidPackageName was expected", name, err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package feeds
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PURL produces the canonical package-url for the package e.g. `pkg:npm/%40angular/core@1.0.1`.
+// The type is derived from the ecosystem of the package, packages without an ecosystem
+// use the ecosystem named by their type, or otherwise the type itself.
+// https://github.com/package-url/purl-spec
+func (p *Package) PURL() string {
+	ecosystem := p.Ecosystem
+	if ecosystem == "" {
+		ecosystem, _ = ParseEcosystem(p.Type)
+	}
+	purlType := ecosystem.PURLType()
+	if purlType == "" {
+		purlType = strings.ToLower(p.Type)
+	}
+
+	var namespace []string
+	name := p.Name
+	switch purlType {
+	case "maven":
+		// Maven packages are named as `groupId:artifactId`.
+		if i := strings.LastIndex(name, ":"); i >= 0 {
+			namespace = []string{name[:i]}
+			name = name[i+1:]
+		}
+	case "npm", "composer", "golang":
+		// Scoped npm packages, composer vendors and go module paths are
+		// namespaced by their leading path segments.
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			namespace = strings.Split(name[:i], "/")
+			name = name[i+1:]
+		}
+	case "pypi":
+		name = strings.ReplaceAll(strings.ToLower(name), "_", "-")
+	}
+
+	parts := []string{}
+	for _, segment := range namespace {
+		parts = append(parts, purlEscape(segment))
+	}
+	parts = append(parts, purlEscape(name))
+
+	purl := fmt.Sprintf("pkg:%s/%s", purlType, strings.Join(parts, "/"))
+	if p.Version != "" {
+		purl += "@" + purlEscape(p.Version)
+	}
+	return purl
+}
+
+// Percent-encodes all characters of a purl component other than unreserved characters.
+func purlEscape(s string) string {
+	var sb strings.Builder
+	for _, b := range []byte(s) {
+		if isUnreserved(b) {
+			sb.WriteByte(b)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", b)
+		}
+	}
+	return sb.String()
+}
+
+func isUnreserved(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9') ||
+		b == '-' || b == '.' || b == '_' || b == '~'
+}
@@ -0,0 +1,45 @@
+package feeds
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		feed     string
+		name     string
+		version  string
+		expected string
+	}{
+		{"npm", "foopackage", "1.0.1", "pkg:npm/foopackage@1.0.1"},
+		{"npm", "@angular/core", "12.0.0", "pkg:npm/%40angular/core@12.0.0"},
+		{"pypi", "Django_Rest", "2.0.0", "pkg:pypi/django-rest@2.0.0"},
+		{"crates", "serde", "1.0.126", "pkg:cargo/serde@1.0.126"},
+		{"goproxy", "github.com/foo-user/bar-package", "v0.1.1", "pkg:golang/github.com/foo-user/bar-package@v0.1.1"},
+		{"rubygems", "rails", "6.1.3", "pkg:gem/rails@6.1.3"},
+		{"nuget", "Foo.Package", "1.0.0", "pkg:nuget/Foo.Package@1.0.0"},
+		{"packagist", "ossf/package", "v1.0.0", "pkg:composer/ossf/package@v1.0.0"},
+		{"maven", "org.apache.commons:commons-lang3", "3.12.0", "pkg:maven/org.apache.commons/commons-lang3@3.12.0"},
+		{"npm", "foopackage", "1.0.0+build.1", "pkg:npm/foopackage@1.0.0%2Bbuild.1"},
+		{"npm", "foopackage", "", "pkg:npm/foopackage"},
+	}
+	for _, test := range tests {
+		pkg := NewPackage(time.Now(), test.name, test.version, test.feed, "")
+		if purl := pkg.PURL(); purl != test.expected {
+			t.Errorf("PURL() produced `%v` when `%v` was expected", purl, test.expected)
+		}
+	}
+}
+
+func TestPURLFromEcosystem(t *testing.T) {
+	t.Parallel()
+
+	// The ecosystem takes precedence over the type of the package.
+	pkg := NewPackage(time.Now(), "serde", "1.0.126", "localdir", EcosystemCratesIO)
+	if purl := pkg.PURL(); purl != "pkg:cargo/serde@1.0.126" {
+		t.Errorf("PURL() produced `%v` when `pkg:cargo/serde@1.0.126` was expected", purl)
+	}
+}
@@ -0,0 +1,428 @@
+package pypi
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ossf/package-feeds/events"
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/utils"
+	"github.com/ossf/package-feeds/utils/pep440"
+)
+
+const (
+	xmlrpcPath = "/pypi"
+
+	changelogEntryLength = 5
+	newReleaseAction     = "new release"
+	yankReleaseAction    = "yank release"
+	// Prefixes the actions removing a project, a release, or a file of a release.
+	removeActionPrefix = "remove "
+)
+
+var (
+	errXMLRPCFault            = errors.New("pypi XML-RPC API returned a fault")
+	errInvalidXMLRPCValue     = errors.New("unexpected value in pypi XML-RPC response")
+	errInvalidChangelogEntry  = errors.New("invalid changelog entry provided by pypi XML-RPC API")
+	errInvalidChangelogSerial = errors.New("invalid changelog serial")
+)
+
+type xmlrpcResponse struct {
+	Params []xmlrpcValue `xml:"params>param>value"`
+	Fault  *xmlrpcValue  `xml:"fault>value"`
+}
+
+type xmlrpcMember struct {
+	Name  string      `xml:"name"`
+	Value xmlrpcValue `xml:"value"`
+}
+
+type xmlrpcValue struct {
+	String  *string        `xml:"string"`
+	Int     *string        `xml:"int"`
+	I4      *string        `xml:"i4"`
+	Nil     *struct{}      `xml:"nil"`
+	Array   []xmlrpcValue  `xml:"array>data>value"`
+	Members []xmlrpcMember `xml:"struct>member"`
+	// Values without a type element are strings.
+	Text string `xml:",chardata"`
+}
+
+func (v xmlrpcValue) str() string {
+	switch {
+	case v.String != nil:
+		return *v.String
+	case v.Nil != nil:
+		return ""
+	default:
+		return strings.TrimSpace(v.Text)
+	}
+}
+
+func (v xmlrpcValue) int() (int64, error) {
+	raw := v.Int
+	if raw == nil {
+		raw = v.I4
+	}
+	if raw == nil {
+		return 0, fmt.Errorf("%w : expected int", errInvalidXMLRPCValue)
+	}
+	return strconv.ParseInt(strings.TrimSpace(*raw), 10, 64)
+}
+
+// A changelog entry of the form (name, version, timestamp, action, serial).
+type changelogEntry struct {
+	Name      string
+	Version   string
+	Timestamp time.Time
+	Action    string
+	Serial    int64
+}
+
+// Whether the action of the entry corresponds to a release being published, either as a
+// new release or a file being added to a release, e.g "add source file foopy-1.0.tar.gz".
+func (e changelogEntry) isRelease() bool {
+	if e.Action == newReleaseAction {
+		return true
+	}
+	return strings.HasPrefix(e.Action, "add ") && strings.Contains(e.Action, " file ")
+}
+
+// The normalized version of the release. Entries for files added to a release without a
+// version take the version from the filename, e.g. "add py3 file foopy-1.0-py3-none-any.whl".
+func (e changelogEntry) releaseVersion() string {
+	if e.Version != "" {
+		return normalizeVersion(e.Version)
+	}
+	fields := strings.Fields(e.Action)
+	if !e.isRelease() || len(fields) == 0 {
+		return ""
+	}
+	version, err := pep440.VersionFromFilename(e.Name, fields[len(fields)-1])
+	if err != nil {
+		return ""
+	}
+	return version
+}
+
+// Returns the event reporting an entry which yanks a release or removes a project, release
+// or file, or nil for any other entry.
+func (e changelogEntry) removalEvent() events.Event {
+	version := ""
+	if e.Version != "" {
+		version = normalizeVersion(e.Version)
+	}
+	switch {
+	case e.Action == yankReleaseAction:
+		return events.YankEvent{Feed: FeedName, Package: e.Name, Version: version, Action: e.Action}
+	case strings.HasPrefix(e.Action, removeActionPrefix):
+		return events.RemovalEvent{Feed: FeedName, Package: e.Name, Version: version, Action: e.Action}
+	default:
+		return nil
+	}
+}
+
+func parseChangelogEntry(v xmlrpcValue) (changelogEntry, error) {
+	if len(v.Array) != changelogEntryLength {
+		return changelogEntry{}, errInvalidChangelogEntry
+	}
+	timestamp, err := v.Array[2].int()
+	if err != nil {
+		return changelogEntry{}, fmt.Errorf("%w : %v", errInvalidChangelogEntry, err)
+	}
+	serial, err := v.Array[4].int()
+	if err != nil {
+		return changelogEntry{}, fmt.Errorf("%w : %v", errInvalidChangelogEntry, err)
+	}
+	return changelogEntry{
+		Name:      v.Array[0].str(),
+		Version:   v.Array[1].str(),
+		Timestamp: time.Unix(timestamp, 0).UTC(),
+		Action:    v.Array[3].str(),
+		Serial:    serial,
+	}, nil
+}
+
+// Encodes an int64 or bool as an XML-RPC param.
+func encodeXMLRPCParam(param interface{}) (string, error) {
+	switch v := param.(type) {
+	case int64:
+		return fmt.Sprintf("<param><value><int>%d</int></value></param>", v), nil
+	case bool:
+		b := 0
+		if v {
+			b = 1
+		}
+		return fmt.Sprintf("<param><value><boolean>%d</boolean></value></param>", b), nil
+	default:
+		return "", fmt.Errorf("%w : unsupported param type %T", errInvalidXMLRPCValue, param)
+	}
+}
+
+// Calls an XML-RPC method on the pypi API with int64 or bool parameters.
+func callXMLRPC(ctx context.Context, baseURL, method string, params ...interface{}) (xmlrpcValue, error) {
+	rpcURL, err := utils.URLPathJoin(baseURL, xmlrpcPath)
+	if err != nil {
+		return xmlrpcValue{}, err
+	}
+	encodedParams := ""
+	for _, param := range params {
+		encoded, err := encodeXMLRPCParam(param)
+		if err != nil {
+			return xmlrpcValue{}, err
+		}
+		encodedParams += encoded
+	}
+	body := fmt.Sprintf(`<?xml version="1.0"?>
+<methodCall><methodName>%s</methodName><params>%s</params></methodCall>`, method, encodedParams)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rpcURL, bytes.NewBufferString(body))
+	if err != nil {
+		return xmlrpcValue{}, err
+	}
+	req.Header.Set("Content-Type", "text/xml")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return xmlrpcValue{}, err
+	}
+	defer resp.Body.Close()
+
+	err = utils.CheckResponseStatus(resp)
+	if err != nil {
+		return xmlrpcValue{}, fmt.Errorf("failed to call pypi XML-RPC method %s: %w", method, err)
+	}
+
+	rpcResponse := &xmlrpcResponse{}
+	reader := utils.NewUTF8OnlyReader(resp.Body)
+	err = xml.NewDecoder(reader).Decode(rpcResponse)
+	if err != nil {
+		return xmlrpcValue{}, err
+	}
+	if rpcResponse.Fault != nil {
+		for _, member := range rpcResponse.Fault.Members {
+			if member.Name == "faultString" {
+				return xmlrpcValue{}, fmt.Errorf("%w : %v", errXMLRPCFault, member.Value.str())
+			}
+		}
+		return xmlrpcValue{}, errXMLRPCFault
+	}
+	if len(rpcResponse.Params) != 1 {
+		return xmlrpcValue{}, fmt.Errorf("%w : expected a single return value", errInvalidXMLRPCValue)
+	}
+	return rpcResponse.Params[0], nil
+}
+
+func fetchLastSerial(ctx context.Context, baseURL string) (int64, error) {
+	value, err := callXMLRPC(ctx, baseURL, "changelog_last_serial")
+	if err != nil {
+		return 0, err
+	}
+	return value.int()
+}
+
+func fetchChangelogSinceSerial(ctx context.Context, baseURL string, serial int64) ([]changelogEntry, []error) {
+	value, err := callXMLRPC(ctx, baseURL, "changelog_since_serial", serial)
+	if err != nil {
+		return nil, []error{err}
+	}
+	return parseChangelog(value)
+}
+
+// Fetches the changelog entries since the given time, including the serial of each entry.
+func fetchChangelogSinceTime(ctx context.Context, baseURL string, since time.Time) ([]changelogEntry, []error) {
+	value, err := callXMLRPC(ctx, baseURL, "changelog", since.Unix(), true)
+	if err != nil {
+		return nil, []error{err}
+	}
+	return parseChangelog(value)
+}
+
+// Fetches the changelog entries from from until to. The registry limits the entries of each
+// response, so the changelog is fetched again from the serial of the last entry until an
+// entry at or after to is reached, or no newer entries are returned.
+func fetchChangelogBetween(ctx context.Context, baseURL string, from, to time.Time) ([]changelogEntry, []error) {
+	entries, errs := fetchChangelogSinceTime(ctx, baseURL, from)
+	page := entries
+	for len(page) > 0 {
+		last := page[len(page)-1]
+		if !last.Timestamp.Before(to) {
+			break
+		}
+		var pageErrs []error
+		page, pageErrs = fetchChangelogSinceSerial(ctx, baseURL, last.Serial)
+		errs = append(errs, pageErrs...)
+		// Only entries after the serial are expected, anything else would be fetched again.
+		if len(page) > 0 && page[len(page)-1].Serial <= last.Serial {
+			break
+		}
+		entries = append(entries, page...)
+	}
+	return entries, errs
+}
+
+func parseChangelog(value xmlrpcValue) ([]changelogEntry, []error) {
+	entries := []changelogEntry{}
+	errs := []error{}
+	for _, v := range value.Array {
+		entry, err := parseChangelogEntry(v)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, errs
+}
+
+// Polls the pypi changelog incrementally, tracking the serial of the last processed
+// changelog entry. The serial reached by a poll is only stored once it is committed, after
+// the packages of the poll are published.
+type changelogPoller struct {
+	store        feeds.CursorStore
+	eventHandler *events.Handler
+
+	mu sync.Mutex
+	// The serial of the last processed changelog entry, or -1 if unknown.
+	serial int64
+	// The serial reached by the most recent poll which is yet to be committed, or -1 if none.
+	pending int64
+}
+
+func newChangelogPoller(store feeds.CursorStore, eventHandler *events.Handler) *changelogPoller {
+	return &changelogPoller{
+		store:        store,
+		eventHandler: eventHandler,
+		serial:       -1,
+		pending:      -1,
+	}
+}
+
+func (c *changelogPoller) lastSerial() (int64, error) {
+	if c.serial >= 0 || c.store == nil {
+		return c.serial, nil
+	}
+	cursor, err := c.store.Get(FeedName)
+	if err != nil || cursor == "" {
+		return -1, err
+	}
+	serial, err := strconv.ParseInt(cursor, 10, 64)
+	if err != nil {
+		return -1, fmt.Errorf("%w : %v", errInvalidChangelogSerial, err)
+	}
+	c.serial = serial
+	return serial, nil
+}
+
+func (c *changelogPoller) setSerial(serial int64) error {
+	c.serial = serial
+	if c.store == nil {
+		return nil
+	}
+	return c.store.Set(FeedName, strconv.FormatInt(serial, 10))
+}
+
+// Stores the serial reached by the most recent poll, so that the next poll resumes after it.
+func (c *changelogPoller) commit() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.pending <= c.serial {
+		return nil
+	}
+	pending := c.pending
+	c.pending = -1
+	return c.setSerial(pending)
+}
+
+// Fetches the packages released since the last committed changelog entry. If no serial is
+// known, the current serial is fetched and stored and no packages are returned, unless
+// backfillSince is set in which case the packages released since then are returned.
+// Entries yanking or removing releases dispatch an event rather than producing packages.
+// Until the serial reached is committed, each poll fetches the entries again.
+func (c *changelogPoller) latest(ctx context.Context, baseURL string,
+	backfillSince time.Time) ([]*feeds.Package, []error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pending = -1
+	serial, err := c.lastSerial()
+	if err != nil {
+		return nil, []error{err}
+	}
+	var entries []changelogEntry
+	var errs []error
+	switch {
+	case serial >= 0:
+		entries, errs = fetchChangelogSinceSerial(ctx, baseURL, serial)
+	case !backfillSince.IsZero():
+		// Entries are fetched by time, any entry sets the serial to resume from.
+		entries, errs = fetchChangelogSinceTime(ctx, baseURL, backfillSince)
+		if len(entries) == 0 && len(errs) > 0 {
+			return nil, errs
+		}
+		if len(entries) == 0 {
+			serial, err = fetchLastSerial(ctx, baseURL)
+			if err != nil {
+				return nil, []error{err}
+			}
+			if err := c.setSerial(serial); err != nil {
+				return nil, []error{err}
+			}
+		}
+	default:
+		serial, err = fetchLastSerial(ctx, baseURL)
+		if err != nil {
+			return nil, []error{err}
+		}
+		if err := c.setSerial(serial); err != nil {
+			return nil, []error{err}
+		}
+		return []*feeds.Package{}, nil
+	}
+
+	maxSerial := serial
+	for _, entry := range entries {
+		if entry.Serial > maxSerial {
+			maxSerial = entry.Serial
+		}
+		if event := entry.removalEvent(); event != nil {
+			if err := c.eventHandler.DispatchEvent(event); err != nil {
+				log.WithError(err).Error("failed to dispatch event via event handler")
+			}
+		}
+	}
+	if maxSerial > serial {
+		c.pending = maxSerial
+	}
+	return releasePackages(entries), errs
+}
+
+// Returns the packages released by the changelog entries, releases are logged once per
+// file added so packages are de-duplicated.
+func releasePackages(entries []changelogEntry) []*feeds.Package {
+	pkgs := []*feeds.Package{}
+	seen := map[string]bool{}
+	for _, entry := range entries {
+		version := entry.releaseVersion()
+		if !entry.isRelease() || version == "" {
+			continue
+		}
+		key := entry.Name + "@" + version
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		pkgs = append(pkgs, feeds.NewPackage(entry.Timestamp, entry.Name, version, FeedName, feeds.EcosystemPyPI))
+	}
+	return pkgs
+}
@@ -0,0 +1,430 @@
+package pypi
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/events"
+	"github.com/ossf/package-feeds/feeds"
+	testutils "github.com/ossf/package-feeds/utils/test"
+)
+
+func TestPypiChangelogLatest(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		xmlrpcPath: xmlrpcHandle,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	store := feeds.NewFileCursorStore(filepath.Join(t.TempDir(), "cursors.json"))
+
+	feed, err := New(feeds.FeedOptions{
+		Mode:        modeChangelog,
+		CursorStore: store,
+	}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create pypi feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	// The first poll without a stored serial only establishes the serial.
+	pkgs, errs := feed.Latest(context.Background(), time.Time{})
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 0 {
+		t.Fatalf("Latest() produced %v packages on the first poll instead of 0", len(pkgs))
+	}
+	if serial, err := store.Get(FeedName); err != nil || serial != "1000" {
+		t.Fatalf("Stored serial is %q (%v) instead of the expected 1000", serial, err)
+	}
+
+	// Simulate a restart, the serial should be resumed from the store.
+	feed, err = New(feeds.FeedOptions{
+		Mode:        modeChangelog,
+		CursorStore: store,
+	}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create pypi feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	pkgs, errs = feed.Latest(context.Background(), time.Time{})
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	const expectedNumPackages = 2
+	if len(pkgs) != expectedNumPackages {
+		t.Fatalf("Latest() produced %v packages instead of the expected %v", len(pkgs), expectedNumPackages)
+	}
+	if pkgs[0].Name != "foopy" || pkgs[0].Version != "2.1" {
+		t.Errorf("Unexpected package %s@%s found in place of foopy@2.1", pkgs[0].Name, pkgs[0].Version)
+	}
+	if pkgs[1].Name != "barpy" || pkgs[1].Version != "1.1" {
+		t.Errorf("Unexpected package %s@%s found in place of barpy@1.1", pkgs[1].Name, pkgs[1].Version)
+	}
+	if pkgs[0].Ecosystem != feeds.EcosystemPyPI {
+		t.Errorf("Unexpected ecosystem %s found in place of %s", pkgs[0].Ecosystem, feeds.EcosystemPyPI)
+	}
+	expectedCreated := time.Unix(1617000000, 0).UTC()
+	if !pkgs[0].CreatedDate.Equal(expectedCreated) {
+		t.Errorf("Package created date %v does not match expected %v", pkgs[0].CreatedDate, expectedCreated)
+	}
+
+	// The serial is only stored once committed, until then polls fetch the same entries.
+	if serial, err := store.Get(FeedName); err != nil || serial != "1000" {
+		t.Errorf("Stored serial is %q (%v) before being committed instead of 1000", serial, err)
+	}
+	pkgs, errs = feed.Latest(context.Background(), time.Time{})
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != expectedNumPackages {
+		t.Fatalf("Latest() produced %v packages before committing instead of the expected %v",
+			len(pkgs), expectedNumPackages)
+	}
+	if err := feed.Commit(); err != nil {
+		t.Fatalf("Failed to commit feed: %v", err)
+	}
+	if serial, err := store.Get(FeedName); err != nil || serial != "1004" {
+		t.Errorf("Stored serial is %q (%v) instead of the expected 1004", serial, err)
+	}
+}
+
+func TestPypiChangelogBackfill(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		xmlrpcPath: xmlrpcHandle,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	store := feeds.NewFileCursorStore(filepath.Join(t.TempDir(), "cursors.json"))
+
+	feed, err := New(feeds.FeedOptions{
+		Mode:        modeChangelog,
+		CursorStore: store,
+		Backfill:    24 * time.Hour,
+	}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create pypi feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	// Without a stored serial the first poll fetches the changelog since the cutoff.
+	pkgs, errs := feed.Latest(context.Background(), time.Unix(1616900000, 0))
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 1 || pkgs[0].Name != "quxpy" || pkgs[0].Version != "0.1" {
+		t.Fatalf("Latest() produced %v packages when only quxpy@0.1 was expected", len(pkgs))
+	}
+	if err := feed.Commit(); err != nil {
+		t.Fatalf("Failed to commit feed: %v", err)
+	}
+	if serial, err := store.Get(FeedName); err != nil || serial != "1000" {
+		t.Fatalf("Stored serial is %q (%v) instead of the expected 1000", serial, err)
+	}
+
+	// Subsequent polls resume from the serial.
+	pkgs, errs = feed.Latest(context.Background(), time.Now())
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 2 {
+		t.Fatalf("Latest() produced %v packages instead of the expected 2", len(pkgs))
+	}
+}
+
+func TestPypiBetween(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		xmlrpcPath: xmlrpcHandle,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	store := feeds.NewFileCursorStore(filepath.Join(t.TempDir(), "cursors.json"))
+
+	feed, err := New(feeds.FeedOptions{Mode: modeChangelog, CursorStore: store}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create pypi feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	from := time.Unix(1616900000, 0)
+	pkgs, errs := feed.Between(context.Background(), from, time.Unix(1616960000, 0))
+	if len(errs) != 0 {
+		t.Fatalf("feed.Between returned error: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 1 || pkgs[0].Name != "quxpy" || pkgs[0].Version != "0.1" {
+		t.Fatalf("Between() produced %v packages when only quxpy@0.1 was expected", len(pkgs))
+	}
+	if serial, err := store.Get(FeedName); err != nil || serial != "" {
+		t.Errorf("Stored serial is %q (%v) when Between() should not store a serial", serial, err)
+	}
+
+	// Packages released at the end of the window are excluded.
+	pkgs, errs = feed.Between(context.Background(), from, time.Unix(1616950000, 0))
+	if len(errs) != 0 || len(pkgs) != 0 {
+		t.Errorf("Between() produced %v packages and errors %v when none were expected", len(pkgs), errs)
+	}
+}
+
+func TestPypiBetweenPaging(t *testing.T) {
+	t.Parallel()
+
+	serialRequests := 0
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		xmlrpcPath: func(w http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if strings.Contains(string(body), "changelog_since_serial") {
+				serialRequests++
+				if !strings.Contains(string(body), "<int>1000</int>") {
+					t.Errorf("Changelog was fetched past the end of the window: %s", body)
+				}
+			}
+			r.Body = ioutil.NopCloser(strings.NewReader(string(body)))
+			xmlrpcHandle(w, r)
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	feed, err := New(feeds.FeedOptions{}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create pypi feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	// The entries after the first response are fetched from its last serial, stopping at the
+	// first entry after the window.
+	pkgs, errs := feed.Between(context.Background(), time.Unix(1616900000, 0), time.Unix(1617000002, 0))
+	if len(errs) != 0 {
+		t.Fatalf("feed.Between returned error: %v", errs[len(errs)-1])
+	}
+	names := []string{}
+	for _, pkg := range pkgs {
+		names = append(names, pkg.Name+"@"+pkg.Version)
+	}
+	if strings.Join(names, ",") != "quxpy@0.1,foopy@2.1" {
+		t.Errorf("Between() produced %v when quxpy@0.1 and foopy@2.1 were expected", names)
+	}
+	if serialRequests != 1 {
+		t.Errorf("Between() fetched the changelog by serial %v times when once was expected", serialRequests)
+	}
+}
+
+func TestPypiChangelogWithPackages(t *testing.T) {
+	t.Parallel()
+
+	packages := []string{"foopy"}
+	_, err := New(feeds.FeedOptions{
+		Mode:     modeChangelog,
+		Packages: &packages,
+	}, events.NewNullHandler())
+	if err == nil {
+		t.Fatalf("Expected error creating changelog pypi feed with packages")
+	}
+}
+
+func TestChangelogEntryReleaseVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		entry changelogEntry
+		want  string
+	}{
+		{entry: changelogEntry{Name: "foopy", Version: "1.0-alpha1", Action: newReleaseAction}, want: "1.0a1"},
+		{entry: changelogEntry{Name: "foopy", Version: "0.1-custom", Action: newReleaseAction}, want: "0.1-custom"},
+		{entry: changelogEntry{Name: "foopy", Action: "add py3 file foopy-1.0.post1-py3-none-any.whl"}, want: "1.0.post1"},
+		{entry: changelogEntry{Name: "foo-py", Action: "add source file foo-py-1!2.0.tar.gz"}, want: "1!2.0"},
+		{entry: changelogEntry{Name: "foopy", Action: "remove file foopy-1.0.tar.gz"}, want: ""},
+		{entry: changelogEntry{Name: "foopy", Action: "create"}, want: ""},
+	}
+	for _, test := range tests {
+		if got := test.entry.releaseVersion(); got != test.want {
+			t.Errorf("releaseVersion() of %+v = %q when %q was expected", test.entry, got, test.want)
+		}
+	}
+}
+
+func TestPypiChangelogFault(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		xmlrpcPath: xmlrpcFaultHandle,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	feed, err := New(feeds.FeedOptions{Mode: modeChangelog}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to create pypi feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	_, errs := feed.Latest(context.Background(), time.Time{})
+	if len(errs) != 1 {
+		t.Fatalf("Latest() returned %v errors instead of the expected 1", len(errs))
+	}
+	if !strings.Contains(errs[0].Error(), "rate limited") {
+		t.Errorf("Error %v does not contain the fault string", errs[0])
+	}
+}
+
+func TestPypiChangelogYankAndRemoval(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		xmlrpcPath: xmlrpcRemovalsHandle,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	store := feeds.NewFileCursorStore(filepath.Join(t.TempDir(), "cursors.json"))
+	if err := store.Set(FeedName, "2000"); err != nil {
+		t.Fatalf("Failed to store serial: %v", err)
+	}
+
+	sink := &events.MockSink{}
+	filter := events.NewFilter(nil, nil, []string{events.FeedsComponentType})
+	feed, err := New(feeds.FeedOptions{
+		Mode:        modeChangelog,
+		CursorStore: store,
+	}, events.NewHandler(sink, *filter))
+	if err != nil {
+		t.Fatalf("Failed to create pypi feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	pkgs, errs := feed.Latest(context.Background(), time.Time{})
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	// Yanks and removals are reported as events rather than packages.
+	if len(pkgs) != 1 || pkgs[0].Name != "barpy" || pkgs[0].Version != "1.2" {
+		t.Fatalf("Latest() produced %v packages when only barpy@1.2 was expected", len(pkgs))
+	}
+	dispatched := sink.GetEvents()
+	if len(dispatched) != 2 {
+		t.Fatalf("%v events were dispatched instead of the expected 2", len(dispatched))
+	}
+	yank, ok := dispatched[0].(events.YankEvent)
+	if !ok || yank.GetType() != events.YankEventType {
+		t.Fatalf("Unexpected event %#v dispatched in place of a YankEvent", dispatched[0])
+	}
+	if yank.Feed != FeedName || yank.Package != "foopy" || yank.Version != "2.1a1" || yank.Action != "yank release" {
+		t.Errorf("YankEvent %+v does not match the yanked release foopy@2.1a1", yank)
+	}
+	removal, ok := dispatched[1].(events.RemovalEvent)
+	if !ok || removal.GetType() != events.RemovalEventType {
+		t.Fatalf("Unexpected event %#v dispatched in place of a RemovalEvent", dispatched[1])
+	}
+	if removal.Package != "bazpy" || removal.Version != "" || removal.Action != "remove project" {
+		t.Errorf("RemovalEvent %+v does not match the removed project bazpy", removal)
+	}
+	if err := feed.Commit(); err != nil {
+		t.Fatalf("Failed to commit feed: %v", err)
+	}
+	if serial, err := store.Get(FeedName); err != nil || serial != "2003" {
+		t.Errorf("Stored serial is %q (%v) instead of the expected 2003", serial, err)
+	}
+}
+
+func xmlrpcHandle(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var response string
+	switch {
+	case strings.Contains(string(body), "changelog_last_serial"):
+		response = `<?xml version='1.0'?>
+<methodResponse><params><param><value><int>1000</int></value></param></params></methodResponse>`
+	case strings.Contains(string(body), "<methodName>changelog</methodName>") &&
+		strings.Contains(string(body), "<int>1616900000</int><") &&
+		strings.Contains(string(body), "<boolean>1</boolean>"):
+		response = `<?xml version='1.0'?>
+<methodResponse><params><param><value><array><data>
+<value><array><data>
+<value><string>quxpy</string></value><value><string>0.1</string></value>
+<value><int>1616950000</int></value><value><string>new release</string></value><value><int>1000</int></value>
+</data></array></value>
+</data></array></value></param></params></methodResponse>`
+	case strings.Contains(string(body), "<int>1000</int>"):
+		response = `<?xml version='1.0'?>
+<methodResponse><params><param><value><array><data>
+<value><array><data>
+<value><string>foopy</string></value><value><string>2.1</string></value>
+<value><int>1617000000</int></value><value><string>new release</string></value><value><int>1001</int></value>
+</data></array></value>
+<value><array><data>
+<value><string>foopy</string></value><value><string>2.1</string></value>
+<value><int>1617000001</int></value><value><string>add source file foopy-2.1.tar.gz</string></value>
+<value><int>1002</int></value>
+</data></array></value>
+<value><array><data>
+<value><string>bazpy</string></value><value><nil/></value>
+<value><int>1617000002</int></value><value><string>create</string></value><value><int>1003</int></value>
+</data></array></value>
+<value><array><data>
+<value>barpy</value><value>1.1</value>
+<value><i4>1617000003</i4></value><value><string>add py3 file barpy-1.1-py3-none-any.whl</string></value>
+<value><int>1004</int></value>
+</data></array></value>
+</data></array></value></param></params></methodResponse>`
+	default:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+		return
+	}
+	_, err = w.Write([]byte(response))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
+
+func xmlrpcFaultHandle(w http.ResponseWriter, r *http.Request) {
+	_, err := w.Write([]byte(`<?xml version='1.0'?>
+<methodResponse><fault><value><struct>
+<member><name>faultCode</name><value><int>-32500</int></value></member>
+<member><name>faultString</name><value><string>rate limited</string></value></member>
+</struct></value></fault></methodResponse>`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
+
+func xmlrpcRemovalsHandle(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !strings.Contains(string(body), "<int>2000</int>") {
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+		return
+	}
+	_, err = w.Write([]byte(`<?xml version='1.0'?>
+<methodResponse><params><param><value><array><data>
+<value><array><data>
+<value><string>foopy</string></value><value><string>2.1-alpha1</string></value>
+<value><int>1617000000</int></value><value><string>yank release</string></value><value><int>2001</int></value>
+</data></array></value>
+<value><array><data>
+<value><string>barpy</string></value><value><string>1.2</string></value>
+<value><int>1617000001</int></value><value><string>new release</string></value><value><int>2002</int></value>
+</data></array></value>
+<value><array><data>
+<value><string>bazpy</string></value><value><nil/></value>
+<value><int>1617000002</int></value><value><string>remove project</string></value><value><int>2003</int></value>
+</data></array></value>
+</data></array></value></param></params></methodResponse>`))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
@@ -1,29 +1,37 @@
 package pypi
 
 import (
+	"context"
 	"encoding/xml"
 	"errors"
 	"fmt"
-	"net/http"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/ossf/package-feeds/events"
 	"github.com/ossf/package-feeds/feeds"
 	"github.com/ossf/package-feeds/utils"
+	"github.com/ossf/package-feeds/utils/pep440"
 )
 
 const (
 	FeedName          = "pypi"
+	modeRSS           = "rss"
+	modeChangelog     = "changelog"
 	updatesPath       = "/rss/updates.xml"
 	packagePathFormat = "/rss/project/%s/releases.xml"
 )
 
 var (
-	httpClient = &http.Client{
-		Timeout: 10 * time.Second,
-	}
+	httpClient               = utils.NewHTTPClient(10 * time.Second)
 	errInvalidLinkForPackage = errors.New("invalid link provided by pypi API")
+	errUnsupportedMode       = errors.New("unsupported pypi feed mode")
+	errInvalidPackageName    = errors.New("name must only contain letters, digits, ., _ and -, starting and " +
+		"ending with a letter or digit")
+
+	// A valid project name, as defined by PEP 508.
+	packageNameRegexp = regexp.MustCompile(`^(?i)([A-Z0-9]|[A-Z0-9][A-Z0-9._-]*[A-Z0-9])$`)
 )
 
 type Response struct {
@@ -54,6 +62,16 @@ func (p *Package) Version() (string, error) {
 	return parts[len(parts)-2], nil
 }
 
+// Normalizes a version as specified by PEP 440, e.g. "1.0-alpha1" is normalized to
+// "1.0a1". Legacy versions which predate PEP 440 are returned unchanged.
+func normalizeVersion(version string) string {
+	normalized, err := pep440.Normalize(version)
+	if err != nil {
+		return version
+	}
+	return normalized
+}
+
 type rfc1123Time struct {
 	time.Time
 }
@@ -72,12 +90,12 @@ func (t *rfc1123Time) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error
 	return nil
 }
 
-func fetchPackages(baseURL string) ([]*Package, error) {
+func fetchPackages(ctx context.Context, baseURL string) ([]*Package, error) {
 	pkgURL, err := utils.URLPathJoin(baseURL, updatesPath)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := httpClient.Get(pkgURL)
+	resp, err := utils.Get(ctx, httpClient, pkgURL)
 	if err != nil {
 		return nil, err
 	}
@@ -97,7 +115,7 @@ func fetchPackages(baseURL string) ([]*Package, error) {
 	return rssResponse.Packages, nil
 }
 
-func fetchCriticalPackages(baseURL string, packageList []string) ([]*Package, []error) {
+func fetchCriticalPackages(ctx context.Context, baseURL string, packageList []string) ([]*Package, []error) {
 	responseChannel := make(chan *Response)
 	errChannel := make(chan error)
 
@@ -109,7 +127,7 @@ func fetchCriticalPackages(baseURL string, packageList []string) ([]*Package, []
 				errChannel <- feeds.PackagePollError{Name: pkgName, Err: err}
 				return
 			}
-			resp, err := httpClient.Get(pkgURL)
+			resp, err := utils.Get(ctx, httpClient, pkgURL)
 			if err != nil {
 				errChannel <- feeds.PackagePollError{Name: pkgName, Err: err}
 				return
@@ -153,35 +171,100 @@ type Feed struct {
 	lossyFeedAlerter *feeds.LossyFeedAlerter
 	baseURL          string
 
+	// Set when polling the XML-RPC changelog rather than RSS.
+	changelog *changelogPoller
+
+	intervals *feeds.PackageIntervals
+
 	options feeds.FeedOptions
 }
 
+func init() { //nolint:gochecknoinits
+	feeds.Register(FeedName, func(options feeds.FeedOptions, eventHandler *events.Handler) (feeds.ScheduledFeed, error) {
+		return New(options, eventHandler)
+	})
+}
+
 func New(feedOptions feeds.FeedOptions, eventHandler *events.Handler) (*Feed, error) {
-	return &Feed{
+	intervals, err := feeds.NewPackageIntervals(feedOptions)
+	if err != nil {
+		return nil, err
+	}
+	feed := &Feed{
 		packages:         feedOptions.Packages,
 		lossyFeedAlerter: feeds.NewLossyFeedAlerter(eventHandler),
 		baseURL:          "https://pypi.org/",
+		intervals:        intervals,
 		options:          feedOptions,
-	}, nil
+	}
+	switch feedOptions.Mode {
+	case "", modeRSS:
+	case modeChangelog:
+		if feedOptions.Packages != nil {
+			return nil, feeds.UnsupportedOptionError{
+				Feed:   FeedName,
+				Option: "packages",
+			}
+		}
+		feed.changelog = newChangelogPoller(feedOptions.CursorStore, eventHandler)
+	default:
+		return nil, fmt.Errorf("%w : %v", errUnsupportedMode, feedOptions.Mode)
+	}
+	return feed, nil
+}
+
+// Between returns the packages released within the window from the XML-RPC changelog,
+// which can be queried from any time regardless of the mode of the feed. The serial of a
+// feed in changelog mode is not read or advanced, and yanks and removals in the window are
+// not reported.
+func (feed Feed) Between(ctx context.Context, from, to time.Time) ([]*feeds.Package, []error) {
+	entries, errs := fetchChangelogBetween(ctx, feed.baseURL, from, to)
+	return feeds.ApplyWindow(releasePackages(entries), from, to), errs
 }
 
-func (feed Feed) Latest(cutoff time.Time) ([]*feeds.Package, []error) {
+// Commit stores the changelog serial reached by the most recent poll of a feed in changelog
+// mode, once its packages have been published.
+func (feed Feed) Commit() error {
+	if feed.changelog == nil {
+		return nil
+	}
+	return feed.changelog.commit()
+}
+
+func (feed Feed) Latest(ctx context.Context, cutoff time.Time) ([]*feeds.Package, []error) {
 	pkgs := []*feeds.Package{}
 	var pypiPackages []*Package
 	var errs []error
 	var err error
 
+	if feed.changelog != nil {
+		// Every changelog entry since the last poll is processed, so the cutoff is only
+		// used to backfill when no serial is known.
+		var backfillSince time.Time
+		if feed.options.Backfill > 0 {
+			backfillSince = cutoff
+		}
+		return feed.changelog.latest(ctx, feed.baseURL, backfillSince)
+	}
+
 	if feed.packages == nil {
 		// Firehose fetch all packages.
 		// If this fails then we need to return, as it's the only source of
 		// data.
-		pypiPackages, err = fetchPackages(feed.baseURL)
+		pypiPackages, err = fetchPackages(ctx, feed.baseURL)
 		if err != nil {
 			return nil, append(errs, err)
 		}
 	} else {
 		// Fetch specific packages individually from configured packages list.
-		pypiPackages, errs = fetchCriticalPackages(feed.baseURL, *feed.packages)
+		now := feed.intervals.Now()
+		due := feed.intervals.Due(*feed.packages, now)
+		if len(due) == 0 {
+			return pkgs, nil
+		}
+		pypiPackages, errs = fetchCriticalPackages(ctx, feed.baseURL, due)
+		// Recorded once the cutoff has been applied, which depends on the previous poll.
+		defer feed.intervals.Polled(due, errs, now)
 		if len(pypiPackages) == 0 {
 			// If none of the packages were successfully polled for, return early.
 			return nil, append(errs, feeds.ErrNoPackagesPolled)
@@ -199,23 +282,43 @@ func (feed Feed) Latest(cutoff time.Time) ([]*feeds.Package, []error) {
 			errs = append(errs, err)
 			continue
 		}
-		pkg := feeds.NewPackage(pkg.CreatedDate.Time, pkgName, pkgVersion, FeedName)
+		pkg := feeds.NewPackage(pkg.CreatedDate.Time, pkgName, normalizeVersion(pkgVersion), FeedName, feeds.EcosystemPyPI)
 		pkgs = append(pkgs, pkg)
 	}
 
 	// Lossy feed detection is only necessary for firehose fetching
 	if feed.packages == nil {
 		feed.lossyFeedAlerter.ProcessPackages(FeedName, pkgs)
+		return feeds.ApplyCutoff(pkgs, cutoff), errs
 	}
 
-	pkgs = feeds.ApplyCutoff(pkgs, cutoff)
-	return pkgs, errs
+	// Packages polled less often than the feed are filtered by their own cutoff.
+	return feed.intervals.ApplyCutoff(pkgs, cutoff), errs
 }
 
 func (feed Feed) GetPackageList() *[]string {
 	return feed.packages
 }
 
+// ValidatePackageName checks the name is a valid project name as defined by PEP 508.
+func (feed Feed) ValidatePackageName(name string) error {
+	if !packageNameRegexp.MatchString(name) {
+		return errInvalidPackageName
+	}
+	return nil
+}
+
+// CarryState takes over the times packages were polled from the feed polling the previous
+// package list.
+func (feed *Feed) CarryState(previous feeds.ScheduledFeed) {
+	prev, ok := previous.(*Feed)
+	if !ok {
+		return
+	}
+	feed.intervals.Carry(prev.intervals)
+	feed.lossyFeedAlerter = prev.lossyFeedAlerter
+}
+
 func (feed Feed) GetName() string {
 	return FeedName
 }
@@ -223,3 +326,7 @@ func (feed Feed) GetName() string {
 func (feed Feed) GetFeedOptions() feeds.FeedOptions {
 	return feed.options
 }
+
+func (feed Feed) GetBaseURL() string {
+	return feed.baseURL
+}
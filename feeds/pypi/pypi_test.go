@@ -1,6 +1,7 @@
 package pypi
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"strings"
@@ -27,7 +28,7 @@ func TestPypiLatest(t *testing.T) {
 	feed.baseURL = srv.URL
 
 	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
-	pkgs, errs := feed.Latest(cutoff)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
 	if len(errs) != 0 {
 		t.Fatalf("feed.Latest returned error: %v", err)
 	}
@@ -49,6 +50,9 @@ func TestPypiLatest(t *testing.T) {
 		if p.Type != FeedName {
 			t.Errorf("Feed type not set correctly in pypi package following Latest()")
 		}
+		if p.Ecosystem != feeds.EcosystemPyPI {
+			t.Errorf("Ecosystem not set correctly in pypi package following Latest()")
+		}
 	}
 }
 
@@ -74,7 +78,7 @@ func TestPypiCriticalLatest(t *testing.T) {
 	feed.baseURL = srv.URL
 
 	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
-	pkgs, errs := feed.Latest(cutoff)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
 	if len(errs) != 0 {
 		t.Fatalf("Failed to call Latest() with err: %v", errs[len(errs)-1])
 	}
@@ -127,7 +131,7 @@ func TestPypiAllNotFound(t *testing.T) {
 	feed.baseURL = srv.URL
 
 	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
-	_, errs := feed.Latest(cutoff)
+	_, errs := feed.Latest(context.Background(), cutoff)
 	if len(errs) != 3 {
 		t.Fatalf("feed.Latest() returned %v errors when 3 were expected", len(errs))
 	}
@@ -158,7 +162,7 @@ func TestPypiCriticalPartialNotFound(t *testing.T) {
 	feed.baseURL = srv.URL
 
 	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
-	pkgs, errs := feed.Latest(cutoff)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
 	if len(errs) != 1 {
 		t.Fatalf("feed.Latest() returned %v errors when 1 was expected", len(errs))
 	}
@@ -262,3 +266,18 @@ func barpyReleasesResponse(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
 	}
 }
+
+func TestPypiValidatePackageName(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"numpy", "Django", "zope.interface", "typing_extensions", "a", "py-3"} {
+		if err := (Feed{}).ValidatePackageName(name); err != nil {
+			t.Errorf("ValidatePackageName(%q) returned unexpected error: %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "-numpy", "numpy.", "scikit learn", "numpy==1.0", "café"} {
+		if err := (Feed{}).ValidatePackageName(name); !errors.Is(err, errInvalidPackageName) {
+			t.Errorf("ValidatePackageName(%q) returned %v when errInvalidPackageName was expected", name, err)
+		}
+	}
+}
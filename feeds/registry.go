@@ -0,0 +1,63 @@
+package feeds
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ossf/package-feeds/events"
+)
+
+var (
+	ErrUnknownFeed = errors.New("unknown feed type")
+
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+// Factory constructs a feed from its options.
+type Factory func(FeedOptions, *events.Handler) (ScheduledFeed, error)
+
+// Register makes a feed available by name, feeds should register themselves in an init
+// function. Register panics if a feed is registered twice with the same name.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("feed %s registered twice", name))
+	}
+	registry[name] = factory
+}
+
+// NewFeed constructs the registered feed with the given name.
+func NewFeed(name string, options FeedOptions, eventHandler *events.Handler) (ScheduledFeed, error) {
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w : %v", ErrUnknownFeed, name)
+	}
+	// Request options only apply to requests made to the registry, not to a package list.
+	// Package names are validated by the feed itself, so before it is wrapped.
+	factory = withRequestOptions(withPackageNameValidation(factory))
+	if options.PackagesFile != "" || options.PackagesURL != "" {
+		return newPackageListFeed(factory, options, eventHandler)
+	}
+	return factory(options, eventHandler)
+}
+
+// Registered returns the sorted names of all registered feeds.
+func Registered() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := []string{}
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
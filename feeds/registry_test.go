@@ -0,0 +1,80 @@
+package feeds
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/events"
+	"github.com/ossf/package-feeds/utils"
+)
+
+type dummyFeed struct {
+	options FeedOptions
+}
+
+func (feed dummyFeed) Latest(ctx context.Context, cutoff time.Time) ([]*Package, []error) {
+	return []*Package{}, nil
+}
+
+func (feed dummyFeed) GetName() string {
+	return "dummy"
+}
+
+func (feed dummyFeed) GetFeedOptions() FeedOptions {
+	return feed.options
+}
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	Register("dummy", func(options FeedOptions, _ *events.Handler) (ScheduledFeed, error) {
+		return dummyFeed{options: options}, nil
+	})
+
+	feed, err := NewFeed("dummy", FeedOptions{PollRate: "5m"}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("Failed to build registered feed: %v", err)
+	}
+	if feed.GetName() != "dummy" || feed.GetFeedOptions().PollRate != "5m" {
+		t.Errorf("Registered feed was not built with the provided options")
+	}
+
+	_, err = NewFeed("unregistered", FeedOptions{}, events.NewNullHandler())
+	if !errors.Is(err, ErrUnknownFeed) {
+		t.Errorf("NewFeed() returned `%v` when an unknown feed error was expected", err)
+	}
+}
+
+func TestRegistryRequestOptionsUnsupported(t *testing.T) {
+	t.Parallel()
+
+	Register("dummy-without-registry", func(options FeedOptions, _ *events.Handler) (ScheduledFeed, error) {
+		return dummyFeed{options: options}, nil
+	})
+
+	// The feed doesn't report its registry, so the hosts to send the headers to are unknown.
+	_, err := NewFeed("dummy-without-registry", FeedOptions{
+		RequestHeaders: map[string]string{"X-Api-Key": "s3cret"},
+	}, events.NewNullHandler())
+	var unsupported UnsupportedOptionError
+	if !errors.As(err, &unsupported) || unsupported.Option != "request_headers" {
+		t.Errorf("NewFeed() returned `%v` when an unsupported request_headers error was expected", err)
+	}
+
+	// The feed makes no connections for its own tls configuration to apply to, the top level
+	// tls configuration is ignored.
+	_, err = NewFeed("dummy-without-registry", FeedOptions{
+		TLS: &utils.TLSConfig{InsecureSkipVerify: true},
+	}, events.NewNullHandler())
+	if !errors.As(err, &unsupported) || unsupported.Option != "tls" {
+		t.Errorf("NewFeed() returned `%v` when an unsupported tls error was expected", err)
+	}
+	_, err = NewFeed("dummy-without-registry", FeedOptions{
+		DefaultTLS: &utils.TLSConfig{InsecureSkipVerify: true},
+	}, events.NewNullHandler())
+	if err != nil {
+		t.Errorf("NewFeed() returned unexpected error for the top level tls configuration: %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package feeds
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ossf/package-feeds/events"
+	"github.com/ossf/package-feeds/utils"
+)
+
+// requestOptionsFeed is a feed whose polls add the configured headers and query parameters
+// to every request made to the hosts of its registries, and make them with the configured
+// TLS connections.
+type requestOptionsFeed struct {
+	ScheduledFeed
+	hosts     []string
+	headers   map[string]string
+	params    map[string]string
+	transport http.RoundTripper
+}
+
+// Wraps the factory so that feeds configured with request headers, parameters or TLS apply
+// them to their requests. Feeds which don't report the URL of their registry don't support
+// them, other than the top level TLS configuration which they ignore.
+func withRequestOptions(factory Factory) Factory {
+	return func(options FeedOptions, eventHandler *events.Handler) (ScheduledFeed, error) {
+		feed, err := factory(options, eventHandler)
+		tlsConfig := options.TLS
+		if tlsConfig == nil {
+			tlsConfig = options.DefaultTLS
+		}
+		if err != nil || (len(options.RequestHeaders) == 0 && len(options.RequestParams) == 0 && tlsConfig == nil) {
+			return feed, err
+		}
+		hosts := registryHosts(feed)
+		if len(hosts) == 0 {
+			switch {
+			case len(options.RequestHeaders) != 0:
+				return nil, UnsupportedOptionError{Feed: feed.GetName(), Option: "request_headers"}
+			case len(options.RequestParams) != 0:
+				return nil, UnsupportedOptionError{Feed: feed.GetName(), Option: "request_params"}
+			case options.TLS != nil:
+				return nil, UnsupportedOptionError{Feed: feed.GetName(), Option: "tls"}
+			}
+			return feed, nil
+		}
+		wrapped := &requestOptionsFeed{
+			ScheduledFeed: feed,
+			hosts:         hosts,
+			headers:       options.RequestHeaders,
+			params:        options.RequestParams,
+		}
+		if tlsConfig != nil {
+			transport, err := utils.NewTLSTransport(tlsConfig)
+			if err != nil {
+				return nil, err
+			}
+			wrapped.transport = transport
+		}
+		return wrapped, nil
+	}
+}
+
+// Returns a context applying the options to requests made with it.
+func (f *requestOptionsFeed) context(ctx context.Context) context.Context {
+	ctx = utils.ContextWithRequestOptions(ctx, f.hosts, f.headers, f.params)
+	return utils.ContextWithTransport(ctx, f.hosts, f.transport)
+}
+
+// Returns the hosts of the registries polled by the feed.
+func registryHosts(feed ScheduledFeed) []string {
+	var urls []string
+	if f, ok := feed.(RegistryURLsFeed); ok {
+		urls = f.GetRegistryURLs()
+	} else if f, ok := feed.(BaseURLFeed); ok {
+		urls = []string{f.GetBaseURL()}
+	}
+	hosts := []string{}
+	for _, registryURL := range urls {
+		if u, err := url.Parse(registryURL); err == nil && u.Host != "" {
+			hosts = append(hosts, u.Host)
+		}
+	}
+	return hosts
+}
+
+func (f *requestOptionsFeed) Latest(ctx context.Context, cutoff time.Time) ([]*Package, []error) {
+	return f.ScheduledFeed.Latest(f.context(ctx), cutoff)
+}
+
+func (f *requestOptionsFeed) GetBaseURL() string {
+	if feed, ok := f.ScheduledFeed.(BaseURLFeed); ok {
+		return feed.GetBaseURL()
+	}
+	return ""
+}
+
+func (f *requestOptionsFeed) Commit() error {
+	if feed, ok := f.ScheduledFeed.(CommittingFeed); ok {
+		return feed.Commit()
+	}
+	return nil
+}
+
+func (f *requestOptionsFeed) Close() error {
+	return Close(f.ScheduledFeed)
+}
+
+func (f *requestOptionsFeed) CarryState(previous ScheduledFeed) {
+	if wrapped, ok := previous.(*requestOptionsFeed); ok {
+		previous = wrapped.ScheduledFeed
+	}
+	if feed, ok := f.ScheduledFeed.(StatefulFeed); ok {
+		feed.CarryState(previous)
+	}
+}
+
+func (f *requestOptionsFeed) Between(ctx context.Context, from, to time.Time) ([]*Package, []error) {
+	return Between(f.context(ctx), f.ScheduledFeed, from, to)
+}
@@ -1,9 +1,11 @@
 package rubygems
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/ossf/package-feeds/events"
@@ -12,13 +14,19 @@ import (
 )
 
 const (
-	FeedName     = "rubygems"
-	activityPath = "/api/v1/activity"
+	FeedName      = "rubygems"
+	activityPath  = "/api/v1/activity"
+	timeframePath = "/api/v1/timeframe_versions.json"
+
+	// The number of versions listed by each activity endpoint.
+	activityLimit = 50
+	// The longest timeframe the timeframe versions API allows to be requested.
+	maxTimeframe = 7 * 24 * time.Hour
+	// The maximum number of pages of timeframe versions requested by a single poll.
+	maxPages = 10
 )
 
-var httpClient = &http.Client{
-	Timeout: 10 * time.Second,
-}
+var httpClient = utils.NewHTTPClient(10 * time.Second)
 
 type Package struct {
 	Name        string    `json:"name"`
@@ -26,8 +34,8 @@ type Package struct {
 	CreatedDate time.Time `json:"version_created_at"`
 }
 
-func fetchPackages(url string) ([]*Package, error) {
-	resp, err := httpClient.Get(url)
+func fetchPackages(ctx context.Context, url string) ([]*Package, error) {
+	resp, err := utils.Get(ctx, httpClient, url)
 	if err != nil {
 		return nil, err
 	}
@@ -43,12 +51,47 @@ func fetchPackages(url string) ([]*Package, error) {
 	return response, err
 }
 
+// Fetches the versions created between from and to from the pages of the timeframe
+// versions API, most recently created first, until an empty page is returned. The
+// timeframe is limited to maxTimeframe by the API.
+func fetchTimeframeVersions(ctx context.Context, baseURL string, from, to time.Time) ([]*Package, error) {
+	timeframeURL, err := utils.URLPathJoin(baseURL, timeframePath)
+	if err != nil {
+		return nil, err
+	}
+	if to.Sub(from) > maxTimeframe {
+		from = to.Add(-maxTimeframe)
+	}
+	versions := []*Package{}
+	for page := 1; page <= maxPages; page++ {
+		params := url.Values{}
+		params.Set("from", from.UTC().Format(time.RFC3339))
+		params.Set("to", to.UTC().Format(time.RFC3339))
+		params.Set("page", strconv.Itoa(page))
+		pkgs, err := fetchPackages(ctx, timeframeURL+"?"+params.Encode())
+		if err != nil {
+			return versions, err
+		}
+		if len(pkgs) == 0 {
+			break
+		}
+		versions = append(versions, pkgs...)
+	}
+	return versions, nil
+}
+
 type Feed struct {
 	lossyFeedAlerter *feeds.LossyFeedAlerter
 	baseURL          string
 	options          feeds.FeedOptions
 }
 
+func init() { //nolint:gochecknoinits
+	feeds.Register(FeedName, func(options feeds.FeedOptions, eventHandler *events.Handler) (feeds.ScheduledFeed, error) {
+		return New(options, eventHandler)
+	})
+}
+
 func New(feedOptions feeds.FeedOptions, eventHandler *events.Handler) (*Feed, error) {
 	if feedOptions.Packages != nil {
 		return nil, feeds.UnsupportedOptionError{
@@ -63,23 +106,26 @@ func New(feedOptions feeds.FeedOptions, eventHandler *events.Handler) (*Feed, er
 	}, nil
 }
 
-func (feed Feed) Latest(cutoff time.Time) ([]*feeds.Package, []error) {
+func (feed Feed) Latest(ctx context.Context, cutoff time.Time) ([]*feeds.Package, []error) {
 	pkgs := []*feeds.Package{}
 	packages := make(map[string]*Package)
 	var errs []error
+	// The oldest version listed as recently updated, versions created between the cutoff
+	// and this are fetched from the timeframe versions when the activity is full.
+	var oldestUpdated *Package
 
 	newPackagesURL, err := utils.URLPathJoin(feed.baseURL, activityPath, "latest.json")
 	if err != nil {
 		// Failure to construct a url should lead to a hard failure.
 		return nil, append(errs, err)
 	}
-	newPackages, err := fetchPackages(newPackagesURL)
+	newPackages, err := fetchPackages(ctx, newPackagesURL)
 	if err != nil {
 		// Updated Packages could still be processed.
 		errs = append(errs, err)
 	} else {
 		for _, pkg := range newPackages {
-			packages[pkg.Name] = pkg
+			packages[pkg.Name+"@"+pkg.Version] = pkg
 		}
 	}
 	updatedPackagesURL, err := utils.URLPathJoin(feed.baseURL, activityPath, "just_updated.json")
@@ -87,18 +133,32 @@ func (feed Feed) Latest(cutoff time.Time) ([]*feeds.Package, []error) {
 		// Failure to construct a url should lead to a hard failure.
 		return nil, append(errs, err)
 	}
-	updatedPackages, err := fetchPackages(updatedPackagesURL)
+	updatedPackages, err := fetchPackages(ctx, updatedPackagesURL)
 	if err != nil {
 		// New Packages could still be processed.
 		errs = append(errs, err)
 	} else {
 		for _, pkg := range updatedPackages {
-			packages[pkg.Name] = pkg
+			packages[pkg.Name+"@"+pkg.Version] = pkg
+			if oldestUpdated == nil || pkg.CreatedDate.Before(oldestUpdated.CreatedDate) {
+				oldestUpdated = pkg
+			}
+		}
+	}
+
+	if len(updatedPackages) >= activityLimit && oldestUpdated.CreatedDate.After(cutoff) {
+		versions, err := fetchTimeframeVersions(ctx, feed.baseURL, cutoff, oldestUpdated.CreatedDate)
+		if err != nil {
+			// Versions fetched from earlier pages could still be processed.
+			errs = append(errs, err)
+		}
+		for _, pkg := range versions {
+			packages[pkg.Name+"@"+pkg.Version] = pkg
 		}
 	}
 
 	for _, pkg := range packages {
-		pkg := feeds.NewPackage(pkg.CreatedDate, pkg.Name, pkg.Version, FeedName)
+		pkg := feeds.NewPackage(pkg.CreatedDate, pkg.Name, pkg.Version, FeedName, feeds.EcosystemRubyGems)
 		pkgs = append(pkgs, pkg)
 	}
 	feed.lossyFeedAlerter.ProcessPackages(FeedName, pkgs)
@@ -114,3 +174,7 @@ func (feed Feed) GetName() string {
 func (feed Feed) GetFeedOptions() feeds.FeedOptions {
 	return feed.options
 }
+
+func (feed Feed) GetBaseURL() string {
+	return feed.baseURL
+}
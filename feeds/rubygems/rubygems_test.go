@@ -1,8 +1,13 @@
 package rubygems
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -28,7 +33,7 @@ func TestRubyLatest(t *testing.T) {
 	}
 
 	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
-	pkgs, errs := feed.Latest(cutoff)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
 	if len(errs) != 0 {
 		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
 	}
@@ -59,6 +64,9 @@ func TestRubyLatest(t *testing.T) {
 		if p.Type != FeedName {
 			t.Errorf("Feed type not set correctly in ruby package following Latest()")
 		}
+		if p.Ecosystem != feeds.EcosystemRubyGems {
+			t.Errorf("Ecosystem not set correctly in ruby package following Latest()")
+		}
 	}
 }
 
@@ -78,7 +86,7 @@ func TestRubyGemsNotFound(t *testing.T) {
 	}
 
 	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
-	_, errs := feed.Latest(cutoff)
+	_, errs := feed.Latest(context.Background(), cutoff)
 	if len(errs) == 0 {
 		t.Fatalf("feed.Latest() was successful when an error was expected")
 	}
@@ -103,7 +111,7 @@ func TestRubyGemsPartialNotFound(t *testing.T) {
 	}
 
 	cutoff := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
-	pkgs, errs := feed.Latest(cutoff)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
 	if len(errs) != 1 {
 		t.Fatalf("feed.Latest() returned %v errors when 1 was expected", len(errs))
 	}
@@ -117,6 +125,93 @@ func TestRubyGemsPartialNotFound(t *testing.T) {
 	}
 }
 
+func TestRubyGemsLatestPaged(t *testing.T) {
+	t.Parallel()
+
+	const (
+		numVersions      = 500
+		timeframePerPage = 30
+	)
+	baseTime := time.Date(2021, 3, 19, 12, 0, 0, 0, time.UTC)
+	// Versions of gems named gem-N created N minutes before the base time.
+	version := func(i int) *Package {
+		return &Package{
+			Name:        fmt.Sprintf("gem-%d", i),
+			Version:     "1.0.0",
+			CreatedDate: baseTime.Add(-time.Duration(i) * time.Minute),
+		}
+	}
+	writePackages := func(w http.ResponseWriter, pkgs []*Package) {
+		if err := json.NewEncoder(w).Encode(pkgs); err != nil {
+			http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+		}
+	}
+
+	var mu sync.Mutex
+	pagesRequested := []int{}
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/api/v1/activity/latest.json": func(w http.ResponseWriter, r *http.Request) {
+			writePackages(w, []*Package{})
+		},
+		"/api/v1/activity/just_updated.json": func(w http.ResponseWriter, r *http.Request) {
+			pkgs := []*Package{}
+			for i := 0; i < activityLimit; i++ {
+				pkgs = append(pkgs, version(i))
+			}
+			writePackages(w, pkgs)
+		},
+		// Lists the versions created within the timeframe, most recently created first.
+		timeframePath: func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			from, fromErr := time.Parse(time.RFC3339, query.Get("from"))
+			to, toErr := time.Parse(time.RFC3339, query.Get("to"))
+			page, pageErr := strconv.Atoi(query.Get("page"))
+			if fromErr != nil || toErr != nil || pageErr != nil {
+				http.Error(w, "invalid query", http.StatusBadRequest)
+				return
+			}
+			mu.Lock()
+			pagesRequested = append(pagesRequested, page)
+			mu.Unlock()
+			inTimeframe := []*Package{}
+			for i := 0; i < numVersions; i++ {
+				pkg := version(i)
+				if !pkg.CreatedDate.Before(from) && !pkg.CreatedDate.After(to) {
+					inTimeframe = append(inTimeframe, pkg)
+				}
+			}
+			pkgs := []*Package{}
+			for i := (page - 1) * timeframePerPage; i < page*timeframePerPage && i < len(inTimeframe); i++ {
+				pkgs = append(pkgs, inTimeframe[i])
+			}
+			writePackages(w, pkgs)
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	feed, err := New(feeds.FeedOptions{}, events.NewNullHandler())
+	if err != nil {
+		t.Fatalf("failed to create new ruby feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	// The versions created between the cutoff and the oldest just updated version span
+	// two pages of the timeframe versions.
+	cutoff := baseTime.Add(-100 * time.Minute).Add(-time.Second)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 101 {
+		t.Fatalf("Latest() produced %v packages instead of the expected 101", len(pkgs))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(pagesRequested) != 3 {
+		t.Errorf("Pages %v were requested when only pages 1 to 3 were expected", pagesRequested)
+	}
+}
+
 func rubyGemsPackagesResponse(w http.ResponseWriter, r *http.Request) {
 	_, err := w.Write([]byte(`
 [
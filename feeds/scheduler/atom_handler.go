@@ -0,0 +1,129 @@
+package scheduler
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	atomPath      = "/feed.atom"
+	atomNamespace = "http://www.w3.org/2005/Atom"
+	atomTitle     = "package-feeds"
+)
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	XMLNS   string      `xml:"xmlns,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	ID        string         `xml:"id"`
+	Title     string         `xml:"title"`
+	Updated   string         `xml:"updated"`
+	Published string         `xml:"published"`
+	Summary   string         `xml:"summary"`
+	Category  []atomCategory `xml:"category"`
+	Links     []atomLink     `xml:"link"`
+}
+
+// AtomHandler serves the packages most recently published across all feeds as an Atom
+// feed through `GET /feed.atom?feed={name}&limit={n}`. Each package is an entry with its
+// purl as the id, updated when it was published and published when it was created. Both
+// query parameters are optional, the limit defaults to the number of packages kept per feed.
+type AtomHandler struct {
+	recent *RecentPackages
+}
+
+func NewAtomHandler(recent *RecentPackages) *AtomHandler {
+	return &AtomHandler{recent: recent}
+}
+
+func (h *AtomHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	limit := h.recent.size
+	if s := query.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			http.Error(w, errInvalidLimit.Error(), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	var pkgs []publishedPackage
+	if feed := query.Get("feed"); feed != "" {
+		pkgs = h.recent.recentFeed(feed, limit)
+	} else {
+		pkgs = h.recent.recentAll(limit)
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	self := fmt.Sprintf("%s://%s%s", scheme, r.Host, r.URL.RequestURI())
+	doc := atomFeed{
+		XMLNS:   atomNamespace,
+		ID:      self,
+		Title:   atomTitle,
+		Updated: h.recent.clock.Now().UTC().Format(time.RFC3339),
+		Author:  atomAuthor{Name: atomTitle},
+		Links:   []atomLink{{Href: self, Rel: "self"}},
+		Entries: []atomEntry{},
+	}
+	if len(pkgs) > 0 {
+		doc.Updated = pkgs[0].published.UTC().Format(time.RFC3339)
+	}
+	for _, p := range pkgs {
+		entry := atomEntry{
+			ID:        p.pkg.PURL(),
+			Title:     fmt.Sprintf("%s %s", p.pkg.Name, p.pkg.Version),
+			Updated:   p.published.UTC().Format(time.RFC3339),
+			Published: p.pkg.CreatedDate.UTC().Format(time.RFC3339),
+			Summary:   fmt.Sprintf("%s %s was published by the %s feed", p.pkg.Name, p.pkg.Version, p.pkg.Type),
+			Category:  []atomCategory{{Term: p.pkg.Type}},
+		}
+		if p.pkg.SourceRepo != "" {
+			entry.Links = append(entry.Links, atomLink{Href: p.pkg.SourceRepo, Rel: "related"})
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	if _, err := w.Write([]byte(xml.Header)); err != nil {
+		log.WithError(err).Error("Failed to write atom feed")
+		return
+	}
+	if err := xml.NewEncoder(w).Encode(doc); err != nil {
+		log.WithError(err).Error("Failed to write atom feed")
+	}
+}
@@ -0,0 +1,121 @@
+package scheduler
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+)
+
+func TestAtomHandler(t *testing.T) {
+	t.Parallel()
+
+	recent := NewRecentPackages(DefaultRecentPackages)
+	created := time.Date(2021, 5, 11, 12, 0, 0, 0, time.UTC)
+	foo := feeds.NewPackage(created, "foo", "1.0.0", "npm", feeds.EcosystemNPM)
+	foo.SourceRepo = "https://github.com/foo/foo"
+	recent.record(foo)
+	recent.record(feeds.NewPackage(created, "bar", "2.0.0", "pypi", feeds.EcosystemPyPI))
+	handler := NewAtomHandler(recent)
+
+	req := httptest.NewRequest(http.MethodGet, atomPath, nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Atom request returned status %v when %v was expected", rec.Code, http.StatusOK)
+	}
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/atom+xml; charset=utf-8" {
+		t.Errorf("Atom request returned content type %q", contentType)
+	}
+	doc := atomFeed{}
+	if err := xml.NewDecoder(rec.Body).Decode(&doc); err != nil {
+		t.Fatalf("Failed to decode atom feed: %v", err)
+	}
+	if doc.XMLName.Space != atomNamespace {
+		t.Errorf("Atom feed has namespace %q instead of %q", doc.XMLName.Space, atomNamespace)
+	}
+	// Packages of all feeds are included, most recently published first.
+	if len(doc.Entries) != 2 {
+		t.Fatalf("Atom feed has %v entries when 2 were expected", len(doc.Entries))
+	}
+	bar, fooEntry := doc.Entries[0], doc.Entries[1]
+	if bar.ID != "pkg:pypi/bar@2.0.0" || fooEntry.ID != "pkg:npm/foo@1.0.0" {
+		t.Errorf("Atom feed has entries %v and %v when bar and foo were expected", bar.ID, fooEntry.ID)
+	}
+	if fooEntry.Published != "2021-05-11T12:00:00Z" {
+		t.Errorf("Entry has published %v when the created date was expected", fooEntry.Published)
+	}
+	if _, err := time.Parse(time.RFC3339, fooEntry.Updated); err != nil {
+		t.Errorf("Entry has updated %q which is not an RFC 3339 timestamp", fooEntry.Updated)
+	}
+	if len(fooEntry.Category) != 1 || fooEntry.Category[0].Term != "npm" {
+		t.Errorf("Entry has categories %v when only npm was expected", fooEntry.Category)
+	}
+	if len(fooEntry.Links) != 1 || fooEntry.Links[0].Href != foo.SourceRepo {
+		t.Errorf("Entry has links %v when a link to the source repo was expected", fooEntry.Links)
+	}
+
+	// Entries can be limited and filtered by feed.
+	req = httptest.NewRequest(http.MethodGet, atomPath+"?feed=npm&limit=1", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	doc = atomFeed{}
+	if err := xml.NewDecoder(rec.Body).Decode(&doc); err != nil {
+		t.Fatalf("Failed to decode atom feed: %v", err)
+	}
+	if len(doc.Entries) != 1 || doc.Entries[0].ID != "pkg:npm/foo@1.0.0" {
+		t.Errorf("Atom feed has entries %v when only foo was expected", doc.Entries)
+	}
+}
+
+func TestAtomHandlerClock(t *testing.T) {
+	t.Parallel()
+
+	published := time.Date(2021, 5, 11, 13, 0, 0, 0, time.UTC)
+	clock := feeds.NewFakeClock(published)
+	recent := NewRecentPackages(DefaultRecentPackages)
+	recent.SetClock(clock)
+	recent.record(feeds.NewPackage(published.Add(-time.Hour), "foo", "1.0.0", "npm", feeds.EcosystemNPM))
+	clock.Advance(time.Hour)
+	handler := NewAtomHandler(recent)
+
+	for _, test := range []struct {
+		query   string
+		updated string
+	}{
+		// Entries are updated at the time the package was published.
+		{query: "?feed=npm", updated: "2021-05-11T13:00:00Z"},
+		// A feed without entries is updated at the current time.
+		{query: "?feed=pypi", updated: "2021-05-11T14:00:00Z"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, atomPath+test.query, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		doc := atomFeed{}
+		if err := xml.NewDecoder(rec.Body).Decode(&doc); err != nil {
+			t.Fatalf("Failed to decode atom feed: %v", err)
+		}
+		if doc.Updated != test.updated {
+			t.Errorf("Atom feed %s has updated %v when %v was expected", test.query, doc.Updated, test.updated)
+		}
+	}
+}
+
+func TestAtomHandlerBadRequest(t *testing.T) {
+	t.Parallel()
+
+	handler := NewAtomHandler(NewRecentPackages(DefaultRecentPackages))
+	for _, query := range []string{"?limit=0", "?limit=bar"} {
+		req := httptest.NewRequest(http.MethodGet, atomPath+query, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Atom request `%v` returned status %v when %v was expected", query, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
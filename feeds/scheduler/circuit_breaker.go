@@ -0,0 +1,133 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/metrics"
+)
+
+type breakerState int
+
+const (
+	// Polling proceeds as normal.
+	breakerClosed breakerState = iota
+	// Polling is skipped until the cooldown has elapsed.
+	breakerOpen
+	// A single trial poll is allowed to test whether the feed has recovered.
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerClosed:
+		return "closed"
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// circuitBreaker stops polling of a feed after a number of consecutive failed polls, polling
+// resumes with a single trial poll once the cooldown has elapsed. A successful trial poll
+// closes the circuit, a failed trial poll opens it for another cooldown.
+type circuitBreaker struct {
+	feed      string
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	clock    feeds.Clock
+	state    breakerState
+	failures int
+	openedAt time.Time
+	// Whether the trial poll of the half-open circuit is in progress.
+	trial bool
+}
+
+func newCircuitBreaker(feed string, threshold int, cooldown time.Duration, clock feeds.Clock) *circuitBreaker {
+	metrics.CircuitBreakerState.WithLabelValues(feed).Set(float64(breakerClosed))
+	return &circuitBreaker{
+		feed:      feed,
+		threshold: threshold,
+		cooldown:  cooldown,
+		clock:     clock,
+		state:     breakerClosed,
+	}
+}
+
+// Sets the Clock the cooldown is measured with.
+func (cb *circuitBreaker) setClock(clock feeds.Clock) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.clock = clock
+}
+
+// Reports whether the feed should be polled, an open circuit transitions to half-open
+// once the cooldown has elapsed. A half-open circuit allows a single trial poll, further
+// polls are skipped until its result is recorded.
+func (cb *circuitBreaker) allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == breakerOpen && cb.clock.Now().Sub(cb.openedAt) >= cb.cooldown {
+		cb.setState(breakerHalfOpen)
+	}
+	switch cb.state {
+	case breakerOpen:
+		return false
+	case breakerHalfOpen:
+		if cb.trial {
+			return false
+		}
+		cb.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Records the outcome of a poll of the feed.
+func (cb *circuitBreaker) recordResult(success bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.trial = false
+	if success {
+		cb.failures = 0
+		if cb.state != breakerClosed {
+			cb.setState(breakerClosed)
+		}
+		return
+	}
+	cb.failures++
+	if cb.state == breakerHalfOpen || cb.failures >= cb.threshold {
+		cb.openedAt = cb.clock.Now()
+		if cb.state != breakerOpen {
+			cb.setState(breakerOpen)
+		}
+	}
+}
+
+func (cb *circuitBreaker) getState() breakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+func (cb *circuitBreaker) setState(state breakerState) {
+	log.WithFields(log.Fields{
+		"feed":                 cb.feed,
+		"from":                 cb.state.String(),
+		"to":                   state.String(),
+		"consecutive_failures": cb.failures,
+	}).Warn("Circuit breaker changed state")
+	cb.state = state
+	metrics.CircuitBreakerState.WithLabelValues(cb.feed).Set(float64(state))
+}
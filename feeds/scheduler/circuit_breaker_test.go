@@ -0,0 +1,102 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/metrics"
+)
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	t.Parallel()
+
+	clock := feeds.NewFakeClock(time.Date(2021, 5, 20, 10, 0, 0, 0, time.UTC))
+	cb := newCircuitBreaker("foo", 3, time.Minute, clock)
+
+	// Failures below the threshold keep the circuit closed.
+	cb.recordResult(false)
+	cb.recordResult(false)
+	if !cb.allow() || cb.getState() != breakerClosed {
+		t.Fatalf("Circuit breaker is %v when closed was expected", cb.getState())
+	}
+
+	// A success resets the consecutive failure count.
+	cb.recordResult(true)
+	cb.recordResult(false)
+	cb.recordResult(false)
+	if cb.getState() != breakerClosed {
+		t.Fatalf("Circuit breaker is %v when closed was expected", cb.getState())
+	}
+
+	cb.recordResult(false)
+	if cb.allow() || cb.getState() != breakerOpen {
+		t.Fatalf("Circuit breaker is %v when open was expected", cb.getState())
+	}
+	if state := testutil.ToFloat64(metrics.CircuitBreakerState.WithLabelValues("foo")); state != 1 {
+		t.Errorf("Circuit breaker state metric is %v when 1 was expected", state)
+	}
+
+	// The circuit remains open until the cooldown elapses.
+	clock.Advance(30 * time.Second)
+	if cb.allow() {
+		t.Fatalf("Circuit breaker allowed polling before the cooldown elapsed")
+	}
+
+	clock.Advance(30 * time.Second)
+	if !cb.allow() || cb.getState() != breakerHalfOpen {
+		t.Fatalf("Circuit breaker is %v when half-open was expected", cb.getState())
+	}
+
+	// A failed trial poll opens the circuit again.
+	cb.recordResult(false)
+	if cb.allow() || cb.getState() != breakerOpen {
+		t.Fatalf("Circuit breaker is %v when open was expected", cb.getState())
+	}
+
+	// A successful trial poll closes the circuit.
+	clock.Advance(time.Minute)
+	if !cb.allow() {
+		t.Fatalf("Circuit breaker did not allow a trial poll after the cooldown elapsed")
+	}
+	cb.recordResult(true)
+	if !cb.allow() || cb.getState() != breakerClosed {
+		t.Fatalf("Circuit breaker is %v when closed was expected", cb.getState())
+	}
+}
+
+func TestCircuitBreakerSingleTrial(t *testing.T) {
+	t.Parallel()
+
+	clock := feeds.NewFakeClock(time.Date(2021, 5, 20, 10, 0, 0, 0, time.UTC))
+	cb := newCircuitBreaker("bar", 1, time.Minute, clock)
+	cb.recordResult(false)
+	clock.Advance(time.Minute)
+
+	// Only the first poll once the cooldown has elapsed is allowed as the trial poll.
+	if !cb.allow() {
+		t.Fatalf("Circuit breaker did not allow a trial poll after the cooldown elapsed")
+	}
+	if cb.allow() {
+		t.Errorf("Circuit breaker allowed a second poll whilst the trial poll was in progress")
+	}
+	if state := testutil.ToFloat64(metrics.CircuitBreakerState.WithLabelValues("bar")); state != 2 {
+		t.Errorf("Circuit breaker state metric is %v when 2 was expected", state)
+	}
+
+	// A failed trial poll opens the circuit, the next cooldown allows another trial.
+	cb.recordResult(false)
+	clock.Advance(time.Minute)
+	if !cb.allow() {
+		t.Fatalf("Circuit breaker did not allow a trial poll after the cooldown elapsed")
+	}
+	cb.recordResult(true)
+	if !cb.allow() || !cb.allow() {
+		t.Errorf("Circuit breaker is %v when closed was expected", cb.getState())
+	}
+	if state := testutil.ToFloat64(metrics.CircuitBreakerState.WithLabelValues("bar")); state != 0 {
+		t.Errorf("Circuit breaker state metric is %v when 0 was expected", state)
+	}
+}
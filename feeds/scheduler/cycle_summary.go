@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ossf/package-feeds/feeds"
+)
+
+const cycleSummaryEvent = "cycle_summary"
+
+// CycleSummaryEvent is published at the end of each poll cycle of a group when enabled,
+// summarizing the results of polling each feed as a compact time series.
+type CycleSummaryEvent struct {
+	// Distinguishes summaries from packages, always "cycle_summary".
+	Event string `json:"event"`
+	// Identifies the cycle, as published in the envelope of the packages it polled.
+	CycleID   string    `json:"cycle_id"`
+	StartedAt time.Time `json:"started_at"`
+	// The number of packages polled, and of distinct packages by feed and name.
+	NumPackages       int `json:"num_packages"`
+	NumUniquePackages int `json:"num_unique_packages"`
+	NumErrors         int `json:"num_errors"`
+	// The result of polling each feed, as served by `GET /status`.
+	Feeds []feeds.PollResult `json:"feeds"`
+}
+
+// Returns a random identifier of a poll cycle, falling back to the start of the cycle if
+// no random bytes can be read.
+func newCycleID(startedAt time.Time) string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(startedAt.UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+func newCycleSummary(cycleID string, startedAt time.Time, results []feeds.PollResult) CycleSummaryEvent {
+	summary := CycleSummaryEvent{
+		Event:     cycleSummaryEvent,
+		CycleID:   cycleID,
+		StartedAt: startedAt,
+		Feeds:     results,
+	}
+	unique := map[string]bool{}
+	for _, result := range results {
+		summary.NumPackages += len(result.Packages)
+		summary.NumErrors += len(result.Errors)
+		for _, pkg := range result.Packages {
+			unique[result.Feed+"/"+pkg.Name] = true
+		}
+	}
+	summary.NumUniquePackages = len(unique)
+	return summary
+}
+
+// Publishes the summary of a poll cycle, returning whether it was sent.
+func (fg *FeedGroup) publishCycleSummary(cycleID string, startedAt time.Time, results []feeds.PollResult) bool {
+	if !fg.cycleSummary {
+		return false
+	}
+	return fg.sendEvent(newCycleSummary(cycleID, startedAt, results), log.WithField("event", cycleSummaryEvent))
+}
@@ -0,0 +1,64 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+)
+
+// deferredPackages holds the packages of feeds configured with a minimum age which were too
+// young to be emitted by the poll which returned them. Feeds which only return a snapshot of
+// the most recent packages, such as RSS feeds, may no longer return a deferred package once
+// it reaches the minimum age, so deferred packages are emitted from the buffer instead.
+// Deferred packages do not persist across restarts.
+type deferredPackages struct {
+	mu sync.Mutex
+	// The deferred packages of each feed, indexed by feed name and seenKey.
+	packages map[string]map[string]*feeds.Package
+}
+
+func newDeferredPackages() *deferredPackages {
+	return &deferredPackages{packages: map[string]map[string]*feeds.Package{}}
+}
+
+// Returns the packages polled from the feed which are at least minAge old at now, along
+// with the deferred packages of the feed which have since reached minAge. Younger packages
+// are deferred until a later poll. A package which was deferred and is polled again is
+// returned once.
+func (d *deferredPackages) apply(feed string, pkgs []*feeds.Package, minAge time.Duration,
+	now time.Time) []*feeds.Package {
+	if minAge <= 0 {
+		return pkgs
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	pending, ok := d.packages[feed]
+	if !ok {
+		pending = map[string]*feeds.Package{}
+		d.packages[feed] = pending
+	}
+	ready := feeds.ApplyMinAge(pkgs, minAge, now)
+	emitted := map[string]bool{}
+	for _, pkg := range ready {
+		emitted[seenKey(pkg)] = true
+	}
+	for _, pkg := range pkgs {
+		if key := seenKey(pkg); !emitted[key] {
+			pending[key] = pkg
+		}
+	}
+	maxCreated := now.Add(-minAge)
+	released := []*feeds.Package{}
+	for key, pkg := range pending {
+		if pkg.CreatedDate.After(maxCreated) {
+			continue
+		}
+		delete(pending, key)
+		if !emitted[key] {
+			released = append(released, pkg)
+		}
+	}
+	feeds.SortByCreatedDateWithOrder(released, feeds.OldestFirst)
+	return append(ready, released...)
+}
@@ -0,0 +1,48 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ossf/package-feeds/feeds"
+)
+
+// The maximum number of packages enriched at once, each enrichment may make requests to a
+// remote service.
+const maxConcurrentEnrichments = 8
+
+// Enriches the packages of a poll before they are published. Enrichment is fail-soft, a
+// package which fails to be enriched is logged and published as it is.
+func (fg *FeedGroup) enrich(pkgs []*feeds.Package) {
+	if fg.enricher == nil || len(pkgs) == 0 {
+		return
+	}
+	work := make(chan *feeds.Package)
+	workers := maxConcurrentEnrichments
+	if len(pkgs) < workers {
+		workers = len(pkgs)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pkg := range work {
+				if err := fg.enricher.Enrich(context.Background(), pkg); err != nil {
+					log.WithFields(log.Fields{
+						"feed":    pkg.Type,
+						"name":    pkg.Name,
+						"version": pkg.Version,
+					}).WithError(err).Warn("Failed to enrich package")
+				}
+			}
+		}()
+	}
+	for _, pkg := range pkgs {
+		work <- pkg
+	}
+	close(work)
+	wg.Wait()
+}
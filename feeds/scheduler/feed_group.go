@@ -4,9 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"math/rand"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/ossf/package-feeds/feeds"
 	"github.com/ossf/package-feeds/publisher"
@@ -15,15 +21,94 @@ import (
 var (
 	errPoll = errors.New("error when polling for packages")
 	errPub  = errors.New("error when publishing packages")
+
+	// Jitter does not require a cryptographically secure source of randomness.
+	jitterRand   = rand.New(rand.NewSource(time.Now().UnixNano())) //nolint:gosec
+	jitterRandMu sync.Mutex
+
+	tracer = otel.Tracer("github.com/ossf/package-feeds/feeds/scheduler")
 )
 
 type FeedGroup struct {
 	feeds     []feeds.ScheduledFeed
 	publisher publisher.Publisher
-	lastPoll  time.Time
+
+	// Guards lastPoll and polled, which are read by polls of a single feed requested through
+	// the FeedPollHandler whilst the group is polled on its schedule.
+	lastPollMu sync.Mutex
+	lastPoll   time.Time
+	// Whether lastPoll is the start of a previous poll, rather than the initial cutoff.
+	polled bool
+
+	// The cutoff of the first poll, as a duration before the group was created.
+	initialCutoff time.Duration
+
+	// Provides the current time used for poll cutoffs and the age of packages.
+	clock feeds.Clock
+
+	// The maximum random delay applied before polling each feed on a scheduled run.
+	maxJitter time.Duration
+
+	// The furthest the cutoff may be before the start of a poll, zero if unlimited.
+	maxLookback time.Duration
+
+	// Circuit breakers indexed by feed name, nil if circuit breaking is disabled.
+	breakers map[string]*circuitBreaker
+
+	// The cutoffs of feeds whose polls were skipped by their circuit breaker, indexed by feed
+	// name. Such feeds are polled from the cutoff of their first skipped poll until a poll
+	// succeeds, so that packages created whilst they were skipped aren't missed.
+	skippedCutoffs   map[string]cutoff
+	skippedCutoffsMu sync.Mutex
+
+	// Records the result of each poll of the group's feeds, shared between groups.
+	status *PollStatus
+
+	// Keeps the packages most recently published by the group, shared between groups.
+	recent *RecentPackages
+
+	// Remembers when packages emitted by the group's feeds were first seen.
+	seen *seenCache
+
+	// Holds the packages deferred by the minimum age of their feed until they are old enough.
+	deferred *deferredPackages
+
+	// Limits the number of feeds polled at once, shared between groups. Nil if unlimited.
+	limiter *PollLimiter
+
+	// Static labels added to every package published by the group.
+	labels map[string]string
+
+	// Queues packages to be published, shared between groups. Nil if packages are published
+	// as part of each poll.
+	queue *PublishQueue
+
+	// Whether a summary of each poll cycle is published.
+	cycleSummary bool
+
+	// Enriches packages between polling and publishing, nil if packages aren't enriched.
+	enricher feeds.Enricher
+
+	// Serializes packages before they are sent to the publisher.
+	serializer publisher.Serializer
+
+	// Whether the packages of all feeds polled in a cycle are published in order of their
+	// creation, rather than grouped by feed in the order the feeds completed.
+	ordered bool
+
+	// The names of feeds configured with a backfill which have completed their first poll.
+	backfilled   map[string]bool
+	backfilledMu sync.Mutex
+}
+
+// The cutoff of a poll, and whether packages created exactly at it were already emitted.
+type cutoff struct {
+	time      time.Time
+	exclusive bool
 }
 
 type groupResult struct {
+	numPackages  int
 	numPublished int
 	pollErr      error
 	pubErr       error
@@ -31,10 +116,20 @@ type groupResult struct {
 
 func NewFeedGroup(scheduledFeeds []feeds.ScheduledFeed,
 	pub publisher.Publisher, initialCutoff time.Duration) *FeedGroup {
+	clock := feeds.RealClock()
 	return &FeedGroup{
-		feeds:     scheduledFeeds,
-		publisher: pub,
-		lastPoll:  time.Now().UTC().Add(-initialCutoff),
+		feeds:          scheduledFeeds,
+		publisher:      pub,
+		lastPoll:       clock.Now().UTC().Add(-initialCutoff),
+		initialCutoff:  initialCutoff,
+		clock:          clock,
+		status:         NewPollStatus(),
+		recent:         NewRecentPackages(DefaultRecentPackages),
+		seen:           newSeenCache(seenCacheSize),
+		deferred:       newDeferredPackages(),
+		skippedCutoffs: map[string]cutoff{},
+		backfilled:     map[string]bool{},
+		serializer:     publisher.JSONSerializer{},
 	}
 }
 
@@ -42,8 +137,97 @@ func (fg *FeedGroup) AddFeed(feed feeds.ScheduledFeed) {
 	fg.feeds = append(fg.feeds, feed)
 }
 
+// Sets the maximum random delay applied before polling each feed on a scheduled run,
+// this spreads the load of feeds which share a schedule.
+func (fg *FeedGroup) SetJitter(maxJitter time.Duration) {
+	fg.maxJitter = maxJitter
+}
+
+// Sets the furthest the cutoff may be before the start of a poll. When the group hasn't
+// been polled for longer, e.g. after an outage of the service dispatching polls, the cutoff
+// is capped so that a flood of old packages isn't published.
+func (fg *FeedGroup) SetMaxLookback(maxLookback time.Duration) {
+	fg.maxLookback = maxLookback
+}
+
+// Enables a circuit breaker for each feed in the group, polling of a feed is skipped for
+// the cooldown after threshold consecutive polls fail. The cooldown is measured with the
+// Clock of the group.
+func (fg *FeedGroup) SetCircuitBreaker(threshold int, cooldown time.Duration) {
+	fg.breakers = map[string]*circuitBreaker{}
+	for _, feed := range fg.feeds {
+		fg.breakers[feed.GetName()] = newCircuitBreaker(feed.GetName(), threshold, cooldown, fg.clock)
+	}
+}
+
+// Sets the Clock providing the current time for poll cutoffs and the age of packages, the
+// initial cutoff is measured from the current time of the clock. This must be set before
+// the group is first polled.
+func (fg *FeedGroup) SetClock(clock feeds.Clock) {
+	fg.clock = clock
+	for _, breaker := range fg.breakers {
+		breaker.setClock(clock)
+	}
+	fg.lastPollMu.Lock()
+	defer fg.lastPollMu.Unlock()
+	fg.lastPoll = clock.Now().UTC().Add(-fg.initialCutoff)
+}
+
+// Sets the PollStatus recording the results of polls of the group, allowing the results
+// of several groups to be recorded together.
+func (fg *FeedGroup) SetStatus(status *PollStatus) {
+	fg.status = status
+}
+
+// Sets the RecentPackages keeping the packages published by the group, allowing the
+// packages of several groups to be served together.
+func (fg *FeedGroup) SetRecentPackages(recent *RecentPackages) {
+	fg.recent = recent
+}
+
+// Sets the PollLimiter limiting the number of the group's feeds polled at once, allowing
+// the limit to apply across several groups.
+func (fg *FeedGroup) SetPollLimiter(limiter *PollLimiter) {
+	fg.limiter = limiter
+}
+
+// Sets static labels added to every package published by the group, identifying the
+// deployment which published them.
+func (fg *FeedGroup) SetLabels(labels map[string]string) {
+	fg.labels = labels
+}
+
+// Sets the PublishQueue which packages polled by the group are published through, allowing
+// the queue to bound the packages waiting to be published across several groups.
+func (fg *FeedGroup) SetPublishQueue(queue *PublishQueue) {
+	fg.queue = queue
+}
+
+// Enables publishing a summary of each poll cycle of the group, aggregated from the results
+// of polling each feed, at the end of the cycle.
+func (fg *FeedGroup) SetCycleSummary(enabled bool) {
+	fg.cycleSummary = enabled
+}
+
+// Enables publishing the packages of each poll cycle in order of their creation across the
+// feeds of the group.
+func (fg *FeedGroup) SetOrdered(enabled bool) {
+	fg.ordered = enabled
+}
+
+// Sets the enricher run on each package after it is polled and before it is published.
+func (fg *FeedGroup) SetEnricher(enricher feeds.Enricher) {
+	fg.enricher = enricher
+}
+
+// Sets the serializer packages are serialized with before they are sent to the publisher,
+// JSON by default.
+func (fg *FeedGroup) SetSerializer(serializer publisher.Serializer) {
+	fg.serializer = serializer
+}
+
 func (fg *FeedGroup) Run() {
-	result := fg.pollAndPublish()
+	result := fg.pollAndPublish(fg.maxJitter)
 	if result.pollErr != nil {
 		log.Error(result.pollErr)
 	}
@@ -52,16 +236,67 @@ func (fg *FeedGroup) Run() {
 	}
 }
 
-func (fg *FeedGroup) pollAndPublish() groupResult {
-	result := groupResult{}
-	pkgs, err := fg.poll()
-	result.pollErr = err
+func (fg *FeedGroup) pollAndPublish(maxJitter time.Duration) groupResult {
+	pkgs, polled, err := fg.poll(maxJitter)
+	result := fg.publish(pkgs, err)
+	fg.commit(polled, result)
+	return result
+}
+
+// Polls a single feed of the group using the current cutoff and publishes the results. The
+// cutoff of the group is not advanced, so the packages may be published again by the next
+// scheduled poll of the group.
+func (fg *FeedGroup) pollAndPublishFeed(feed feeds.ScheduledFeed) (groupResult, []error) {
+	pkgs, polled, errs := fg.pollFeeds([]feeds.ScheduledFeed{feed}, fg.clock.Now().UTC(), 0)
+	var err error
+	if len(errs) > 0 {
+		err = errPoll
+	}
+	result := fg.publish(pkgs, err)
+	fg.commit(polled, result)
+	return result, errs
+}
+
+// Commits the position reached by each polled feed which tracks its own position, once the
+// packages of the poll have been published. Queued packages count as published. When
+// publishing failed the feeds poll the same packages again.
+func (fg *FeedGroup) commit(polled []feeds.CommittingFeed, result groupResult) {
+	if result.pubErr != nil || result.numPublished < result.numPackages {
+		return
+	}
+	for _, feed := range polled {
+		if err := feed.Commit(); err != nil {
+			log.WithError(err).Error("Failed to commit the position of the feed")
+		}
+	}
+}
+
+func (fg *FeedGroup) publish(pkgs []*feeds.Package, pollErr error) groupResult {
+	result := groupResult{numPackages: len(pkgs)}
+	result.pollErr = pollErr
 	// Return early if no packages to process
 	if len(pkgs) == 0 {
 		return result
 	}
+	if fg.ordered {
+		feeds.SortByCreatedDateWithOrder(pkgs, feeds.OldestFirst)
+	}
+	fg.enrich(pkgs)
+	// Queued packages are published in the background, so count as published once queued.
+	if fg.queue != nil {
+		for _, pkg := range pkgs {
+			fg.queue.enqueue(fg, pkg)
+		}
+		result.numPublished = len(pkgs)
+		log.WithField("num_packages", len(pkgs)).Printf("Queued packages for publishing")
+		return result
+	}
 	log.WithField("num_packages", len(pkgs)).Printf("Publishing packages...")
 	numPublished, pubErr := fg.publishPackages(pkgs)
+	// The end of a poll cycle, publishers which batch packages publish the packages sent so far.
+	if err := publisher.Flush(context.Background(), fg.publisher); err != nil && pubErr == nil {
+		pubErr = err
+	}
 	result.numPublished = numPublished
 	if pubErr != nil {
 		log.Errorf("Failed to publish %v packages due to err: %v", len(pkgs)-numPublished, pubErr)
@@ -72,47 +307,245 @@ func (fg *FeedGroup) pollAndPublish() groupResult {
 	return result
 }
 
-// Poll fetches the latest packages from each registered feed.
-func (fg *FeedGroup) poll() ([]*feeds.Package, error) {
-	results := make(chan pollResult, len(fg.feeds))
-	for _, feed := range fg.feeds {
+// Poll fetches the latest packages from each registered feed, each feed is polled
+// after a random delay of up to maxJitter. The cutoff for the next poll is the time at
+// which this poll started, so packages created whilst polling are not missed. The polled
+// feeds whose position must be committed once the packages are published are returned.
+func (fg *FeedGroup) poll(maxJitter time.Duration) ([]*feeds.Package, []feeds.CommittingFeed, error) {
+	pollStart := fg.clock.Now().UTC()
+	packages, polled, errs := fg.pollFeeds(fg.feeds, pollStart, maxJitter)
+	err := errPoll
+	if len(errs) == 0 {
+		err = nil
+	}
+	fg.lastPollMu.Lock()
+	fg.lastPoll = pollStart
+	fg.polled = true
+	fg.lastPollMu.Unlock()
+
+	log.Printf("%d packages processed", len(packages))
+	return packages, polled, err
+}
+
+// Returns the cutoff of the group for a poll starting at pollStart, this is the start of
+// the previous poll capped to the maximum lookback. The cutoff is exclusive when it is the
+// start of the previous poll, as that poll emitted the packages created exactly at it.
+func (fg *FeedGroup) groupCutoff(pollStart time.Time) cutoff {
+	fg.lastPollMu.Lock()
+	c := cutoff{time: fg.lastPoll, exclusive: fg.polled}
+	fg.lastPollMu.Unlock()
+	return fg.capLookback(pollStart, c)
+}
+
+// Caps the cutoff of a poll starting at pollStart to the maximum lookback.
+func (fg *FeedGroup) capLookback(pollStart time.Time, c cutoff) cutoff {
+	if fg.maxLookback <= 0 {
+		return c
+	}
+	earliest := pollStart.Add(-fg.maxLookback)
+	if !c.time.Before(earliest) {
+		return c
+	}
+	log.WithFields(log.Fields{
+		"last_poll":    c.time,
+		"max_lookback": fg.maxLookback,
+		"cutoff":       earliest,
+	}).Warn("Time since the last poll exceeds the maximum lookback, packages created before the cutoff are skipped")
+	return cutoff{time: earliest}
+}
+
+// Returns the cutoff used to poll the feed, this is the cutoff of its first skipped poll
+// when the circuit breaker skipped the feed since it last succeeded, otherwise the cutoff
+// of the group.
+func (fg *FeedGroup) resumedCutoff(name string, pollStart time.Time, groupCutoff cutoff) cutoff {
+	fg.skippedCutoffsMu.Lock()
+	defer fg.skippedCutoffsMu.Unlock()
+	if skipped, ok := fg.skippedCutoffs[name]; ok {
+		return fg.capLookback(pollStart, skipped)
+	}
+	return groupCutoff
+}
+
+// Records the cutoff of a poll of the feed skipped by its circuit breaker, unless an earlier
+// poll was already skipped.
+func (fg *FeedGroup) setSkippedCutoff(name string, c cutoff) {
+	fg.skippedCutoffsMu.Lock()
+	defer fg.skippedCutoffsMu.Unlock()
+	if _, ok := fg.skippedCutoffs[name]; !ok {
+		fg.skippedCutoffs[name] = c
+	}
+}
+
+func (fg *FeedGroup) clearSkippedCutoff(name string) {
+	fg.skippedCutoffsMu.Lock()
+	defer fg.skippedCutoffsMu.Unlock()
+	delete(fg.skippedCutoffs, name)
+}
+
+// Returns the cutoff used to poll the feed, this is the cutoff of the group unless the feed
+// is configured with a backfill and has not yet been successfully polled, in which case the
+// cutoff is the start of the backfill window.
+func (fg *FeedGroup) feedCutoff(name string, options feeds.FeedOptions, pollStart, cutoff time.Time) time.Time {
+	if options.Backfill <= 0 {
+		return cutoff
+	}
+	fg.backfilledMu.Lock()
+	defer fg.backfilledMu.Unlock()
+	backfillStart := pollStart.Add(-options.Backfill)
+	if fg.backfilled[name] || !backfillStart.Before(cutoff) {
+		return cutoff
+	}
+	return backfillStart
+}
+
+func (fg *FeedGroup) setBackfilled(name string) {
+	fg.backfilledMu.Lock()
+	defer fg.backfilledMu.Unlock()
+	fg.backfilled[name] = true
+}
+
+// Fetches the latest packages from the given feeds using the current cutoff of the group.
+// Feeds configured with a minimum package age are polled with a cutoff shifted back by the
+// minimum age, with packages younger than the minimum age at pollStart being deferred until
+// a later poll, even if the feed no longer returns them.
+// The polled feeds which implement feeds.CommittingFeed are returned with the packages.
+func (fg *FeedGroup) pollFeeds(scheduledFeeds []feeds.ScheduledFeed, pollStart time.Time,
+	maxJitter time.Duration) ([]*feeds.Package, []feeds.CommittingFeed, []error) {
+	results := make(chan feeds.PollResult, len(scheduledFeeds))
+	committing := map[string]feeds.CommittingFeed{}
+	for _, feed := range scheduledFeeds {
+		if committingFeed, ok := feed.(feeds.CommittingFeed); ok {
+			committing[feed.GetName()] = committingFeed
+		}
+	}
+	groupCutoff := fg.groupCutoff(pollStart)
+	cycleID := newCycleID(pollStart)
+	for _, feed := range scheduledFeeds {
 		go func(feed feeds.ScheduledFeed) {
-			result := pollResult{
-				name: feed.GetName(),
-				feed: feed,
+			time.Sleep(randomDelay(maxJitter))
+			result := feeds.PollResult{
+				Feed:     feed.GetName(),
+				PolledAt: fg.clock.Now().UTC(),
+			}
+			breaker := fg.breakers[result.Feed]
+			if breaker != nil && !breaker.allow() {
+				log.WithField("feed", result.Feed).Warn("Circuit breaker is open or its trial poll is in progress, skipping poll")
+				fg.setSkippedCutoff(result.Feed, groupCutoff)
+				result.Skipped = true
+				results <- result
+				return
+			}
+			fg.limiter.acquire()
+			// Time spent waiting for other polls to complete is not part of the poll.
+			result.PolledAt = fg.clock.Now().UTC()
+			options := feed.GetFeedOptions()
+			ctx, span := tracer.Start(context.Background(), "poll",
+				trace.WithAttributes(attribute.String("feed.name", result.Feed)))
+			cancel := func() {}
+			if options.PollTimeout > 0 {
+				ctx, cancel = context.WithTimeout(ctx, options.PollTimeout)
+			}
+			resumed := fg.resumedCutoff(result.Feed, pollStart, groupCutoff)
+			cutoff := fg.feedCutoff(result.Feed, options, pollStart, resumed.time).Add(-options.MinAge)
+			result.Packages, result.Errors = feed.Latest(ctx, cutoff)
+			if resumed.exclusive && !cutoff.Before(resumed.time.Add(-options.MinAge)) {
+				// Feeds apply the cutoff inclusively, the packages created exactly at it were
+				// emitted by the previous poll.
+				result.Packages = feeds.ApplyCutoffExclusive(result.Packages, cutoff)
+			}
+			cancel()
+			fg.limiter.release()
+			result.Duration = fg.clock.Now().Sub(result.PolledAt)
+			span.SetAttributes(
+				attribute.Int("feed.package_count", len(result.Packages)),
+				attribute.Int("feed.error_count", len(result.Errors)))
+			if len(result.Errors) > 0 {
+				span.SetStatus(codes.Error, result.Errors[0].Error())
+			}
+			span.End()
+			result.Packages = fg.deferred.apply(result.Feed, result.Packages, options.MinAge, pollStart)
+			var dropped int
+			result.Packages, dropped = feeds.ApplyMaxPackages(result.Packages, options.MaxPackagesPerPoll)
+			if dropped > 0 {
+				log.WithFields(log.Fields{
+					"feed":                  result.Feed,
+					"max_packages_per_poll": options.MaxPackagesPerPoll,
+					"num_dropped":           dropped,
+				}).Warn("Poll exceeded the maximum number of packages, older packages were dropped")
+			}
+			// A poll is considered failed if it produced errors without any packages.
+			succeeded := len(result.Errors) == 0 || len(result.Packages) > 0
+			if breaker != nil {
+				breaker.recordResult(succeeded)
+			}
+			if succeeded {
+				fg.clearSkippedCutoff(result.Feed)
+			}
+			if succeeded && options.Backfill > 0 {
+				fg.setBackfilled(result.Feed)
 			}
-			result.packages, result.errs = feed.Latest(fg.lastPoll)
 			results <- result
 		}(feed)
 	}
 	errs := []error{}
 	packages := []*feeds.Package{}
-	for i := 0; i < len(fg.feeds); i++ {
+	pollResults := []feeds.PollResult{}
+	polled := []feeds.CommittingFeed{}
+	for i := 0; i < len(scheduledFeeds); i++ {
 		result := <-results
+		pollResults = append(pollResults, result)
+		if feed, ok := committing[result.Feed]; ok && !result.Skipped {
+			polled = append(polled, feed)
+		}
 
-		logger := log.WithField("feed", result.name)
-		for _, err := range result.errs {
+		logger := log.WithField("feed", result.Feed)
+		for _, err := range result.Errors {
 			logger.WithError(err).Error("Error fetching packages")
 			errs = append(errs, err)
 		}
-		for _, pkg := range result.packages {
+		for _, pkg := range result.Packages {
 			log.WithFields(log.Fields{
-				"feed":    result.name,
+				"feed":    result.Feed,
 				"name":    pkg.Name,
 				"version": pkg.Version,
 			}).Print("Processing Package")
+			pkg.CycleID = cycleID
+			pkg.PolledAt = result.PolledAt
 		}
-		packages = append(packages, result.packages...)
-		logger.WithField("num_processed", len(result.packages)).Print("Packages successfully processed")
+		fg.seen.setFirstSeen(result.Packages, result.PolledAt)
+		packages = append(packages, result.Packages...)
+		logger.WithField("num_processed", len(result.Packages)).Print("Packages successfully processed")
 	}
-	err := errPoll
-	if len(errs) == 0 {
-		err = nil
+	fg.status.record(pollResults)
+	logPollResults(pollResults)
+	// Each event is published even if another failed to send.
+	heartbeats := fg.publishHeartbeats(scheduledFeeds, pollResults)
+	summary := fg.publishCycleSummary(cycleID, pollStart, pollResults)
+	if heartbeats || summary {
+		fg.flushEvents()
 	}
-	fg.lastPoll = time.Now().UTC()
+	return packages, polled, errs
+}
 
-	log.Printf("%d packages processed", len(packages))
-	return packages, err
+// Logs the results of polling several feeds as a single record.
+func logPollResults(results []feeds.PollResult) {
+	numPackages := 0
+	numErrors := 0
+	for _, result := range results {
+		numPackages += len(result.Packages)
+		numErrors += len(result.Errors)
+	}
+	summary, err := json.Marshal(results)
+	if err != nil {
+		log.WithError(err).Error("Failed to summarize poll results")
+		return
+	}
+	log.WithFields(log.Fields{
+		"num_feeds":    len(results),
+		"num_packages": numPackages,
+		"num_errors":   numErrors,
+		"results":      string(summary),
+	}).Print("Poll cycle completed")
 }
 
 func (fg *FeedGroup) publishPackages(pkgs []*feeds.Package) (int, error) {
@@ -123,16 +556,48 @@ func (fg *FeedGroup) publishPackages(pkgs []*feeds.Package) (int, error) {
 			"feed":         pkg.Type,
 			"created_date": pkg.CreatedDate,
 		}).Print("Sending package upstream")
-		b, err := json.Marshal(pkg)
+		if len(fg.labels) > 0 {
+			pkg.Labels = fg.labels
+		}
+		b, err := fg.serializer.Serialize(pkg)
 		if err != nil {
 			log.Printf("Error marshaling package: %#v", pkg)
 			return processed, err
 		}
-		if err := (fg.publisher).Send(context.Background(), b); err != nil {
+		if err := fg.send(pkg, b); err != nil {
 			log.Printf("Error sending package to upstream publisher %v", err)
 			return processed, err
 		}
+		fg.recent.record(pkg)
 		processed++
 	}
 	return processed, nil
 }
+
+// Sends a serialized package to the publisher within a trace span.
+func (fg *FeedGroup) send(pkg *feeds.Package, body []byte) error {
+	ctx, span := tracer.Start(context.Background(), "publish", trace.WithAttributes(
+		attribute.String("feed.name", pkg.Type),
+		attribute.String("package.name", pkg.Name),
+		attribute.String("package.version", pkg.Version),
+		attribute.String("publisher.name", fg.publisher.Name())))
+	defer span.End()
+
+	ctx = publisher.ContextWithContentType(publisher.ContextWithPackage(ctx, pkg), fg.serializer.ContentType())
+	err := fg.publisher.Send(ctx, body)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// Produces a random delay in the range [0, maxDelay).
+func randomDelay(maxDelay time.Duration) time.Duration {
+	if maxDelay <= 0 {
+		return 0
+	}
+	jitterRandMu.Lock()
+	defer jitterRandMu.Unlock()
+	return time.Duration(jitterRand.Int63n(int64(maxDelay)))
+}
@@ -1,7 +1,13 @@
 package scheduler
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -37,7 +43,7 @@ func TestFeedGroupPoll(t *testing.T) {
 	feedGroup := NewFeedGroup(mockFeeds, pub, time.Minute)
 	startLastPollValue := feedGroup.lastPoll
 
-	pkgs, err := feedGroup.poll()
+	pkgs, _, err := feedGroup.poll(0)
 	if err != nil {
 		t.Fatalf("Unexpected error arose during polling: %v", err)
 	}
@@ -47,6 +53,11 @@ func TestFeedGroupPoll(t *testing.T) {
 	if startLastPollValue.Equal(feedGroup.lastPoll) {
 		t.Fatalf("Feed Group did not update last poll as expected")
 	}
+	for _, pkg := range pkgs {
+		if pkg.FirstSeen == nil {
+			t.Errorf("poll() did not set the first seen time of %v", pkg.Name)
+		}
+	}
 }
 
 func TestFeedGroupPollWithErr(t *testing.T) {
@@ -70,7 +81,7 @@ func TestFeedGroupPollWithErr(t *testing.T) {
 	feedGroup := NewFeedGroup(mockFeeds, pub, time.Minute)
 	startLastPollValue := feedGroup.lastPoll
 
-	pkgs, err := feedGroup.poll()
+	pkgs, _, err := feedGroup.poll(0)
 	if err == nil {
 		t.Fatalf("Expected error during polling")
 	}
@@ -108,6 +119,38 @@ func TestFeedGroupPublish(t *testing.T) {
 	}
 }
 
+func TestFeedGroupPublishWithLabels(t *testing.T) {
+	t.Parallel()
+
+	pkgs := []*feeds.Package{
+		{Name: "Baz"},
+	}
+	pubMessages := []string{}
+	mockPub := mockPublisher{sendCallback: func(msg string) error {
+		pubMessages = append(pubMessages, msg)
+		return nil
+	}}
+
+	feedGroup := NewFeedGroup([]feeds.ScheduledFeed{}, mockPub, time.Minute)
+	feedGroup.SetLabels(map[string]string{"env": "prod", "region": "us"})
+	if _, err := feedGroup.publishPackages(pkgs); err != nil {
+		t.Fatalf("Unexpected error whilst publishing packages: %v", err)
+	}
+	if len(pubMessages) != 1 {
+		t.Fatalf("%v messages were published when 1 was expected", len(pubMessages))
+	}
+	event := struct {
+		Name   string            `json:"name"`
+		Labels map[string]string `json:"labels"`
+	}{}
+	if err := json.Unmarshal([]byte(pubMessages[0]), &event); err != nil {
+		t.Fatalf("Failed to decode published message: %v", err)
+	}
+	if event.Name != "Baz" || event.Labels["env"] != "prod" || event.Labels["region"] != "us" {
+		t.Errorf("Published message %s does not contain the configured labels", pubMessages[0])
+	}
+}
+
 func TestFeedGroupPublishWithErr(t *testing.T) {
 	t.Parallel()
 
@@ -128,3 +171,945 @@ func TestFeedGroupPublishWithErr(t *testing.T) {
 		t.Fatalf("publishPackages provided no error when publishing produced an error")
 	}
 }
+
+func TestFeedGroupPublishFlushes(t *testing.T) {
+	t.Parallel()
+
+	events := []string{}
+	pub := mockPublisher{
+		sendCallback: func(msg string) error {
+			events = append(events, "send")
+			return nil
+		},
+		flushCallback: func() error {
+			events = append(events, "flush")
+			return nil
+		},
+	}
+	feedGroup := NewFeedGroup([]feeds.ScheduledFeed{
+		mockFeed{
+			packages: []*feeds.Package{
+				{Name: "Baz"},
+				{Name: "Qux"},
+			},
+		},
+	}, pub, time.Minute)
+	result := feedGroup.pollAndPublish(0)
+	if result.pubErr != nil {
+		t.Fatalf("Unexpected error whilst publishing packages: %v", result.pubErr)
+	}
+	if strings.Join(events, ",") != "send,send,flush" {
+		t.Errorf("Publisher received %v when the packages were expected to be sent then flushed once", events)
+	}
+}
+
+func TestFeedGroupPollWithJitter(t *testing.T) {
+	t.Parallel()
+
+	mockFeeds := []feeds.ScheduledFeed{
+		mockFeed{
+			packages: []*feeds.Package{
+				{Name: "Foo"},
+			},
+		},
+		mockFeed{
+			packages: []*feeds.Package{
+				{Name: "Bar"},
+			},
+		},
+	}
+	feedGroup := NewFeedGroup(mockFeeds, mockPublisher{}, time.Minute)
+
+	pkgs, _, err := feedGroup.poll(10 * time.Millisecond)
+	if err != nil {
+		t.Fatalf("Unexpected error arose during polling: %v", err)
+	}
+	if len(pkgs) != 2 {
+		t.Fatalf("poll() returned %v packages when 2 were expected", len(pkgs))
+	}
+}
+
+func TestRandomDelay(t *testing.T) {
+	t.Parallel()
+
+	if randomDelay(0) != 0 {
+		t.Errorf("randomDelay produced a non-zero delay when jitter is disabled")
+	}
+	maxDelay := time.Second
+	for i := 0; i < 100; i++ {
+		delay := randomDelay(maxDelay)
+		if delay < 0 || delay >= maxDelay {
+			t.Fatalf("randomDelay produced %v which is outside of the range [0, %v)", delay, maxDelay)
+		}
+	}
+}
+
+func TestFeedGroupPollWithMinAge(t *testing.T) {
+	t.Parallel()
+
+	mockFeeds := []feeds.ScheduledFeed{
+		mockFeed{
+			packages: []*feeds.Package{
+				{Name: "Fresh", CreatedDate: time.Now().UTC()},
+				{Name: "Stable", CreatedDate: time.Now().UTC().Add(-time.Hour)},
+			},
+			options: feeds.FeedOptions{MinAge: time.Minute},
+		},
+	}
+	feedGroup := NewFeedGroup(mockFeeds, mockPublisher{}, time.Minute)
+
+	pkgs, _, err := feedGroup.poll(0)
+	if err != nil {
+		t.Fatalf("Unexpected error arose during polling: %v", err)
+	}
+	if len(pkgs) != 1 {
+		t.Fatalf("poll() returned %v packages when 1 was expected", len(pkgs))
+	}
+	if pkgs[0].Name != "Stable" {
+		t.Errorf("poll() did not defer the package younger than the minimum age")
+	}
+}
+
+func TestFeedGroupPollWithMinAgeSnapshot(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2021, 5, 11, 12, 0, 0, 0, time.UTC)
+	clock := feeds.NewFakeClock(start)
+	options := feeds.FeedOptions{MinAge: 45 * time.Second}
+	feedGroup := NewFeedGroup([]feeds.ScheduledFeed{
+		mockFeed{
+			packages: []*feeds.Package{
+				{Name: "Foo", Version: "1.0.0", CreatedDate: start.Add(-30 * time.Second)},
+			},
+			options: options,
+		},
+	}, mockPublisher{}, time.Minute)
+	feedGroup.SetClock(clock)
+
+	pkgs, _, err := feedGroup.poll(0)
+	if err != nil {
+		t.Fatalf("Unexpected error arose during polling: %v", err)
+	}
+	if len(pkgs) != 0 {
+		t.Fatalf("poll() returned %v packages when Foo was expected to be deferred", len(pkgs))
+	}
+
+	// The feed only lists the most recent packages, so no longer lists Foo once it is old enough.
+	clock.Advance(time.Minute)
+	feedGroup.feeds[0] = mockFeed{
+		packages: []*feeds.Package{
+			{Name: "Bar", Version: "1.0.0", CreatedDate: clock.Now().Add(-10 * time.Second)},
+		},
+		options: options,
+	}
+	pkgs, _, err = feedGroup.poll(0)
+	if err != nil {
+		t.Fatalf("Unexpected error arose during polling: %v", err)
+	}
+	if len(pkgs) != 1 || pkgs[0].Name != "Foo" {
+		t.Fatalf("poll() returned %v packages when only the deferred Foo was expected", len(pkgs))
+	}
+
+	clock.Advance(time.Minute)
+	feedGroup.feeds[0] = mockFeed{
+		packages: []*feeds.Package{
+			{Name: "Bar", Version: "1.0.0", CreatedDate: clock.Now().Add(-70 * time.Second)},
+		},
+		options: options,
+	}
+	pkgs, _, err = feedGroup.poll(0)
+	if err != nil {
+		t.Fatalf("Unexpected error arose during polling: %v", err)
+	}
+	if len(pkgs) != 1 || pkgs[0].Name != "Bar" {
+		t.Errorf("poll() returned %v packages when Bar was expected once", len(pkgs))
+	}
+}
+
+func TestFeedGroupPollWithPollTimeout(t *testing.T) {
+	t.Parallel()
+
+	deadlines := make(chan time.Time, 1)
+	mockFeeds := []feeds.ScheduledFeed{
+		mockFeed{
+			packages: []*feeds.Package{{Name: "Foo", CreatedDate: time.Now().UTC()}},
+			options:  feeds.FeedOptions{PollTimeout: time.Minute},
+			contextCallback: func(ctx context.Context) {
+				deadline, _ := ctx.Deadline()
+				deadlines <- deadline
+			},
+		},
+	}
+	feedGroup := NewFeedGroup(mockFeeds, mockPublisher{}, time.Minute)
+
+	start := time.Now()
+	if _, _, err := feedGroup.poll(0); err != nil {
+		t.Fatalf("Unexpected error arose during polling: %v", err)
+	}
+	deadline := <-deadlines
+	if deadline.Before(start) || deadline.After(start.Add(time.Minute+time.Second)) {
+		t.Errorf("Latest() was called with deadline %v when a deadline a minute after %v was expected", deadline, start)
+	}
+}
+
+func TestFeedGroupPollWithPollLimiter(t *testing.T) {
+	t.Parallel()
+
+	const maxConcurrent = 2
+	var mu sync.Mutex
+	active := 0
+	maxActive := 0
+	polled := 0
+	contextCallback := func(ctx context.Context) {
+		mu.Lock()
+		active++
+		polled++
+		if active > maxActive {
+			maxActive = active
+		}
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		active--
+		mu.Unlock()
+	}
+
+	// The limit applies across groups sharing the limiter.
+	limiter := NewPollLimiter(maxConcurrent)
+	feedGroups := []*FeedGroup{}
+	for i := 0; i < 2; i++ {
+		mockFeeds := []feeds.ScheduledFeed{}
+		for j := 0; j < 4; j++ {
+			mockFeeds = append(mockFeeds, mockFeed{
+				name:            fmt.Sprintf("Feed%d%d", i, j),
+				contextCallback: contextCallback,
+			})
+		}
+		feedGroup := NewFeedGroup(mockFeeds, mockPublisher{}, time.Minute)
+		feedGroup.SetPollLimiter(limiter)
+		feedGroups = append(feedGroups, feedGroup)
+	}
+
+	var wg sync.WaitGroup
+	for _, feedGroup := range feedGroups {
+		wg.Add(1)
+		go func(feedGroup *FeedGroup) {
+			defer wg.Done()
+			if _, _, err := feedGroup.poll(0); err != nil {
+				t.Errorf("Unexpected error arose during polling: %v", err)
+			}
+		}(feedGroup)
+	}
+	wg.Wait()
+
+	if polled != 8 {
+		t.Errorf("%v feeds were polled when 8 were expected", polled)
+	}
+	if maxActive > maxConcurrent {
+		t.Errorf("%v feeds were polled at once when at most %v were expected", maxActive, maxConcurrent)
+	}
+}
+
+func TestFeedGroupPollWithBackfill(t *testing.T) {
+	t.Parallel()
+
+	mockFeeds := []feeds.ScheduledFeed{
+		mockFeed{
+			packages: []*feeds.Package{
+				{Name: "Recent", CreatedDate: time.Now().UTC().Add(-time.Hour)},
+				{Name: "Old", CreatedDate: time.Now().UTC().Add(-48 * time.Hour)},
+			},
+			options:     feeds.FeedOptions{Backfill: 24 * time.Hour},
+			applyCutoff: true,
+		},
+	}
+	feedGroup := NewFeedGroup(mockFeeds, mockPublisher{}, time.Minute)
+
+	// The first poll uses the start of the backfill window as the cutoff.
+	pkgs, _, err := feedGroup.poll(0)
+	if err != nil {
+		t.Fatalf("Unexpected error arose during polling: %v", err)
+	}
+	if len(pkgs) != 1 || pkgs[0].Name != "Recent" {
+		t.Fatalf("poll() returned %v packages when only the package within the backfill was expected", len(pkgs))
+	}
+
+	// Subsequent polls use the cutoff of the group.
+	pkgs, _, err = feedGroup.poll(0)
+	if err != nil {
+		t.Fatalf("Unexpected error arose during polling: %v", err)
+	}
+	if len(pkgs) != 0 {
+		t.Errorf("poll() returned %v packages after the backfill when 0 were expected", len(pkgs))
+	}
+}
+
+func TestFeedGroupPollCutoffBoundary(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2021, 5, 11, 12, 0, 0, 0, time.UTC)
+	clock := feeds.NewFakeClock(start)
+	var lastCutoff time.Time
+	mockFeeds := []feeds.ScheduledFeed{
+		mockFeed{
+			packages: []*feeds.Package{
+				// Created exactly at the start of the first poll, the cutoff of the second.
+				{Name: "Boundary", CreatedDate: start},
+				{Name: "Later", CreatedDate: start.Add(time.Second)},
+			},
+			applyCutoff:    true,
+			cutoffCallback: func(cutoff time.Time) { lastCutoff = cutoff },
+		},
+	}
+	feedGroup := NewFeedGroup(mockFeeds, mockPublisher{}, time.Minute)
+	feedGroup.SetClock(clock)
+
+	pkgs, _, err := feedGroup.poll(0)
+	if err != nil {
+		t.Fatalf("Unexpected error arose during polling: %v", err)
+	}
+	if len(pkgs) != 2 {
+		t.Fatalf("poll() returned %v packages when 2 were expected", len(pkgs))
+	}
+
+	// The package created exactly at the cutoff carried over from the first poll isn't
+	// emitted again.
+	clock.Advance(time.Minute)
+	pkgs, _, err = feedGroup.poll(0)
+	if err != nil {
+		t.Fatalf("Unexpected error arose during polling: %v", err)
+	}
+	if len(pkgs) != 1 || pkgs[0].Name != "Later" {
+		t.Errorf("poll() returned %v packages when only Later was expected", len(pkgs))
+	}
+	// The feed is provided the carried over cutoff unchanged, the boundary is excluded by
+	// the scheduler.
+	if !lastCutoff.Equal(start) {
+		t.Errorf("Latest() was called with cutoff %v when %v was expected", lastCutoff, start)
+	}
+}
+
+func TestFeedGroupPollWithCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	mockFeeds := []feeds.ScheduledFeed{
+		mockFeed{
+			errs: []error{errPackage},
+		},
+	}
+	feedGroup := NewFeedGroup(mockFeeds, mockPublisher{}, time.Minute)
+	feedGroup.SetCircuitBreaker(2, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := feedGroup.poll(0); err == nil {
+			t.Fatalf("Expected error during polling")
+		}
+	}
+	if state := feedGroup.breakers["mockFeed"].getState(); state != breakerOpen {
+		t.Fatalf("Circuit breaker is %v when open was expected", state)
+	}
+	// The open circuit breaker skips polling of the failing feed.
+	if _, _, err := feedGroup.poll(0); err != nil {
+		t.Fatalf("Feed was polled despite the circuit breaker being open: %v", err)
+	}
+}
+
+func TestFeedGroupPollCircuitBreakerCutoff(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2021, 5, 11, 12, 0, 0, 0, time.UTC)
+	clock := feeds.NewFakeClock(start)
+	cutoffs := []time.Time{}
+	recordCutoff := func(cutoff time.Time) {
+		cutoffs = append(cutoffs, cutoff)
+	}
+	mockFeeds := []feeds.ScheduledFeed{
+		mockFeed{errs: []error{errPackage}, cutoffCallback: recordCutoff},
+	}
+	feedGroup := NewFeedGroup(mockFeeds, mockPublisher{}, time.Minute)
+	feedGroup.SetClock(clock)
+	feedGroup.SetCircuitBreaker(1, time.Hour)
+
+	// The failed poll opens the circuit breaker, skipping the following polls.
+	if _, _, err := feedGroup.poll(0); err == nil {
+		t.Fatalf("Expected error during polling")
+	}
+	for i := 0; i < 2; i++ {
+		clock.Advance(20 * time.Minute)
+		if _, _, err := feedGroup.poll(0); err != nil {
+			t.Fatalf("Feed was polled despite the circuit breaker being open: %v", err)
+		}
+	}
+	if len(cutoffs) != 1 {
+		t.Fatalf("Feed was polled %v times when the circuit breaker was expected to skip polls", len(cutoffs))
+	}
+
+	// The trial poll covers the packages created whilst the feed was skipped, from the cutoff
+	// of the first skipped poll, the start of the failed poll.
+	feedGroup.feeds[0] = mockFeed{cutoffCallback: recordCutoff}
+	clock.Advance(30 * time.Minute)
+	trialStart := clock.Now()
+	if _, _, err := feedGroup.poll(0); err != nil {
+		t.Fatalf("Unexpected error arose during polling: %v", err)
+	}
+	if expected := start; len(cutoffs) != 2 || !cutoffs[1].Equal(expected) {
+		t.Fatalf("Trial poll used the cutoff %v when %v was expected", cutoffs[len(cutoffs)-1], expected)
+	}
+
+	// Once the feed recovers, it is polled from the cutoff of the group.
+	clock.Advance(time.Minute)
+	if _, _, err := feedGroup.poll(0); err != nil {
+		t.Fatalf("Unexpected error arose during polling: %v", err)
+	}
+	if expected := trialStart; len(cutoffs) != 3 || !cutoffs[2].Equal(expected) {
+		t.Errorf("Poll after recovering used the cutoff %v when %v was expected", cutoffs[len(cutoffs)-1], expected)
+	}
+}
+
+func TestFeedGroupPollWithFakeClock(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2021, 5, 11, 12, 0, 0, 0, time.UTC)
+	clock := feeds.NewFakeClock(start)
+	mockFeeds := []feeds.ScheduledFeed{
+		mockFeed{
+			name: "foo",
+			packages: []*feeds.Package{
+				{Name: "Foo", CreatedDate: start.Add(-90 * time.Second)},
+				{Name: "Bar", CreatedDate: start.Add(-30 * time.Second)},
+			},
+			applyCutoff: true,
+		},
+		mockFeed{
+			name: "baz",
+			packages: []*feeds.Package{
+				{Name: "Baz", CreatedDate: start.Add(-30 * time.Second)},
+			},
+			options:     feeds.FeedOptions{MinAge: 45 * time.Second},
+			applyCutoff: true,
+		},
+	}
+	feedGroup := NewFeedGroup(mockFeeds, mockPublisher{}, time.Minute)
+	feedGroup.SetClock(clock)
+	if expected := start.Add(-time.Minute); !feedGroup.lastPoll.Equal(expected) {
+		t.Fatalf("Initial cutoff is %v when %v was expected", feedGroup.lastPoll, expected)
+	}
+
+	expectedPolls := [][]string{
+		// Baz is younger than the minimum age of its feed, so is deferred.
+		{"Bar"},
+		// The cutoff has advanced past Bar, whilst Baz has reached the minimum age.
+		{"Baz"},
+		{},
+	}
+	for i, expected := range expectedPolls {
+		pollStart := clock.Now()
+		pkgs, _, err := feedGroup.poll(0)
+		if err != nil {
+			t.Fatalf("Unexpected error arose during poll %v: %v", i, err)
+		}
+		names := []string{}
+		for _, pkg := range pkgs {
+			names = append(names, pkg.Name)
+			if pkg.FirstSeen == nil || !pkg.FirstSeen.Equal(pollStart) {
+				t.Errorf("Poll %v set the first seen time of %v to %v when %v was expected",
+					i, pkg.Name, pkg.FirstSeen, pollStart)
+			}
+		}
+		if strings.Join(names, ",") != strings.Join(expected, ",") {
+			t.Errorf("Poll %v returned %v when %v was expected", i, names, expected)
+		}
+		if !feedGroup.lastPoll.Equal(pollStart) {
+			t.Errorf("Poll %v set the cutoff to %v when %v was expected", i, feedGroup.lastPoll, pollStart)
+		}
+		clock.Advance(time.Minute)
+	}
+}
+
+func TestFeedGroupPollWithMaxLookback(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2021, 5, 11, 12, 0, 0, 0, time.UTC)
+	clock := feeds.NewFakeClock(start)
+	mockFeeds := []feeds.ScheduledFeed{
+		mockFeed{
+			packages: []*feeds.Package{
+				{Name: "Foo", CreatedDate: start.Add(2 * time.Hour)},
+				{Name: "Bar", CreatedDate: start.Add(47 * time.Hour)},
+			},
+			applyCutoff: true,
+		},
+	}
+	feedGroup := NewFeedGroup(mockFeeds, mockPublisher{}, time.Minute)
+	feedGroup.SetClock(clock)
+	feedGroup.SetMaxLookback(time.Hour)
+	if _, _, err := feedGroup.poll(0); err != nil {
+		t.Fatalf("Unexpected error arose during polling: %v", err)
+	}
+
+	// After an outage the stale cutoff is capped to the maximum lookback, so only Bar is
+	// polled rather than every package created during the outage.
+	clock.Advance(48 * time.Hour)
+	pkgs, _, err := feedGroup.poll(0)
+	if err != nil {
+		t.Fatalf("Unexpected error arose during polling: %v", err)
+	}
+	if len(pkgs) != 1 || pkgs[0].Name != "Bar" {
+		t.Errorf("poll() returned %v packages when only Bar was expected", len(pkgs))
+	}
+	if !feedGroup.lastPoll.Equal(clock.Now()) {
+		t.Errorf("poll() set the cutoff to %v when %v was expected", feedGroup.lastPoll, clock.Now())
+	}
+
+	// Without a maximum lookback every package since the stale cutoff is polled.
+	feedGroup.SetMaxLookback(0)
+	feedGroup.lastPoll = start
+	pkgs, _, err = feedGroup.poll(0)
+	if err != nil {
+		t.Fatalf("Unexpected error arose during polling: %v", err)
+	}
+	if len(pkgs) != 2 {
+		t.Errorf("poll() returned %v packages when 2 were expected", len(pkgs))
+	}
+}
+
+func TestFeedGroupPollWithHeartbeat(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2021, 5, 11, 12, 0, 0, 0, time.UTC)
+	var mu sync.Mutex
+	messages := []string{}
+	flushes := 0
+	pub := mockPublisher{
+		sendCallback: func(msg string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			messages = append(messages, msg)
+			return nil
+		},
+		flushCallback: func() error {
+			flushes++
+			return nil
+		},
+	}
+	mockFeeds := []feeds.ScheduledFeed{
+		mockFeed{name: "quiet", options: feeds.FeedOptions{Heartbeat: true}},
+		// Heartbeats are only published by feeds which enable them, for successful polls
+		// which found no packages.
+		mockFeed{name: "disabled"},
+		mockFeed{
+			name:     "busy",
+			packages: []*feeds.Package{{Name: "Foo", CreatedDate: start}},
+			options:  feeds.FeedOptions{Heartbeat: true},
+		},
+		mockFeed{
+			name:    "failing",
+			errs:    []error{errPackage},
+			options: feeds.FeedOptions{Heartbeat: true},
+		},
+	}
+	feedGroup := NewFeedGroup(mockFeeds, pub, time.Minute)
+	feedGroup.SetClock(feeds.NewFakeClock(start))
+	pkgs, _, _ := feedGroup.poll(0)
+	if len(pkgs) != 1 {
+		t.Fatalf("poll() returned %v packages when 1 was expected", len(pkgs))
+	}
+
+	if len(messages) != 1 {
+		t.Fatalf("poll() published %v heartbeats when 1 was expected: %v", len(messages), messages)
+	}
+	heartbeat := HeartbeatEvent{}
+	if err := json.Unmarshal([]byte(messages[0]), &heartbeat); err != nil {
+		t.Fatalf("Failed to unmarshal heartbeat: %v", err)
+	}
+	if heartbeat.Event != heartbeatEvent || heartbeat.Feed != "quiet" || !heartbeat.PolledAt.Equal(start) {
+		t.Errorf("poll() published heartbeat %+v when a heartbeat of quiet polled at %v was expected",
+			heartbeat, start)
+	}
+	if flushes != 1 {
+		t.Errorf("The publisher was flushed %v times after publishing heartbeats when once was expected", flushes)
+	}
+}
+
+func TestFeedGroupPollWithCycleSummary(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2021, 5, 11, 12, 0, 0, 0, time.UTC)
+	messages := []string{}
+	flushes := 0
+	pub := mockPublisher{
+		sendCallback: func(msg string) error {
+			messages = append(messages, msg)
+			return nil
+		},
+		flushCallback: func() error {
+			flushes++
+			return nil
+		},
+	}
+	mockFeeds := []feeds.ScheduledFeed{
+		mockFeed{
+			name: "busy",
+			packages: []*feeds.Package{
+				{Name: "Foo", Version: "1.0.0", CreatedDate: start},
+				{Name: "Foo", Version: "1.0.1", CreatedDate: start},
+				{Name: "Bar", Version: "2.0.0", CreatedDate: start},
+			},
+		},
+		mockFeed{
+			name:     "failing",
+			packages: []*feeds.Package{{Name: "Baz", CreatedDate: start}},
+			errs:     []error{errPackage, errPackage},
+		},
+	}
+	feedGroup := NewFeedGroup(mockFeeds, pub, time.Minute)
+	feedGroup.SetClock(feeds.NewFakeClock(start))
+	feedGroup.SetCycleSummary(true)
+	if _, _, err := feedGroup.poll(0); err == nil {
+		t.Fatalf("poll() returned no error when the errors of the failing feed were expected")
+	}
+
+	if len(messages) != 1 {
+		t.Fatalf("poll() published %v events when 1 summary was expected: %v", len(messages), messages)
+	}
+	var summary struct {
+		Event             string    `json:"event"`
+		StartedAt         time.Time `json:"started_at"`
+		NumPackages       int       `json:"num_packages"`
+		NumUniquePackages int       `json:"num_unique_packages"`
+		NumErrors         int       `json:"num_errors"`
+		Feeds             []struct {
+			Feed        string   `json:"feed"`
+			NumPackages int      `json:"num_packages"`
+			Errors      []string `json:"errors"`
+		} `json:"feeds"`
+	}
+	if err := json.Unmarshal([]byte(messages[0]), &summary); err != nil {
+		t.Fatalf("Failed to unmarshal cycle summary: %v", err)
+	}
+	if summary.Event != cycleSummaryEvent || !summary.StartedAt.Equal(start) {
+		t.Errorf("poll() published summary %+v when a cycle summary started at %v was expected", summary, start)
+	}
+	if summary.NumPackages != 4 || summary.NumUniquePackages != 3 || summary.NumErrors != 2 {
+		t.Errorf("poll() published summary with %v packages, %v unique and %v errors "+
+			"when 4 packages, 3 unique and 2 errors were expected",
+			summary.NumPackages, summary.NumUniquePackages, summary.NumErrors)
+	}
+	counts := map[string][2]int{}
+	for _, feed := range summary.Feeds {
+		counts[feed.Feed] = [2]int{feed.NumPackages, len(feed.Errors)}
+	}
+	expected := map[string][2]int{"busy": {3, 0}, "failing": {1, 2}}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Errorf("poll() published packages and errors by feed %v when %v was expected", counts, expected)
+	}
+	if flushes != 1 {
+		t.Errorf("The publisher was flushed %v times after publishing the summary when once was expected", flushes)
+	}
+}
+
+// packageOnlyPublisher skips messages without a package, as Avro serialization does.
+type packageOnlyPublisher struct {
+	mu   sync.Mutex
+	sent int
+}
+
+func (pub *packageOnlyPublisher) Send(ctx context.Context, body []byte) error {
+	if _, ok := publisher.PackageFromContext(ctx); !ok {
+		return publisher.ErrNoPackage
+	}
+	pub.mu.Lock()
+	defer pub.mu.Unlock()
+	pub.sent++
+	return nil
+}
+
+func (pub *packageOnlyPublisher) Name() string {
+	return "packageOnly"
+}
+
+func TestFeedGroupPollWithCycleSummaryAndBlockingRetry(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2021, 5, 11, 12, 0, 0, 0, time.UTC)
+	var mu sync.Mutex
+	messages := []string{}
+	osv, err := publisher.WithSerializer(mockPublisher{
+		sendCallback: func(msg string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			messages = append(messages, msg)
+			return nil
+		},
+	}, publisher.FormatOSV)
+	if err != nil {
+		t.Fatalf("WithSerializer() returned unexpected error: %v", err)
+	}
+	packageOnly := &packageOnlyPublisher{}
+	policy := publisher.RetryPolicy{Backoff: time.Millisecond, OnFailure: publisher.OnFailureBlock}
+	pubs := []publisher.Publisher{}
+	for _, pub := range []publisher.Publisher{osv, packageOnly} {
+		retrying, err := publisher.WithRetry(pub, policy)
+		if err != nil {
+			t.Fatalf("WithRetry() returned unexpected error: %v", err)
+		}
+		pubs = append(pubs, retrying)
+	}
+	mockFeeds := []feeds.ScheduledFeed{
+		mockFeed{name: "busy", packages: []*feeds.Package{{Name: "Foo", CreatedDate: start}}},
+		mockFeed{name: "quiet", options: feeds.FeedOptions{Heartbeat: true}},
+	}
+	feedGroup := NewFeedGroup(mockFeeds, publisher.NewMulti(pubs...), time.Minute)
+	feedGroup.SetClock(feeds.NewFakeClock(start))
+	feedGroup.SetCycleSummary(true)
+
+	// The summary and heartbeat were previously retried forever by the package only publisher.
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := feedGroup.poll(0)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("poll() returned unexpected error: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("poll() blocked on publishing events")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	events := map[string]bool{}
+	for _, msg := range messages {
+		var event struct {
+			Event string `json:"event"`
+		}
+		if err := json.Unmarshal([]byte(msg), &event); err != nil {
+			t.Fatalf("Failed to unmarshal event: %v", err)
+		}
+		events[event.Event] = true
+	}
+	if len(messages) != 2 || !events[heartbeatEvent] || !events[cycleSummaryEvent] {
+		t.Errorf("The osv publisher received %v when the heartbeat and summary were expected unchanged", messages)
+	}
+	if packageOnly.sent != 0 {
+		t.Errorf("The package only publisher sent %v events when they were expected to be skipped", packageOnly.sent)
+	}
+}
+
+type mockEnricher func(pkg *feeds.Package) error
+
+func (e mockEnricher) Enrich(ctx context.Context, pkg *feeds.Package) error {
+	return e(pkg)
+}
+
+func TestFeedGroupPublishWithEnricher(t *testing.T) {
+	t.Parallel()
+
+	pkgs := []*feeds.Package{
+		{Name: "Baz"},
+		{Name: "Qux"},
+	}
+	var mu sync.Mutex
+	pubMessages := map[string]string{}
+	mockPub := mockPublisher{sendCallback: func(msg string) error {
+		event := struct {
+			Name string `json:"name"`
+		}{}
+		if err := json.Unmarshal([]byte(msg), &event); err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		pubMessages[event.Name] = msg
+		return nil
+	}}
+
+	feedGroup := NewFeedGroup([]feeds.ScheduledFeed{}, mockPub, time.Minute)
+	feedGroup.SetEnricher(mockEnricher(func(pkg *feeds.Package) error {
+		if pkg.Name == "Qux" {
+			return errPackage
+		}
+		pkg.Vulnerabilities = []feeds.Vulnerability{{ID: "sonatype-2021-0001", CVSSScore: 7.5}}
+		return nil
+	}))
+	result := feedGroup.publish(pkgs, nil)
+	if result.numPublished != len(pkgs) {
+		t.Fatalf("%v packages were published when %v were expected, including the package which "+
+			"failed to be enriched", result.numPublished, len(pkgs))
+	}
+	if !strings.Contains(pubMessages["Baz"], `"vulnerabilities":[{"id":"sonatype-2021-0001"`) {
+		t.Errorf("Published message %s does not contain the enriched vulnerabilities", pubMessages["Baz"])
+	}
+	if strings.Contains(pubMessages["Qux"], "vulnerabilities") {
+		t.Errorf("Published message %s contains vulnerabilities when enriching it failed", pubMessages["Qux"])
+	}
+}
+
+func TestFeedGroupPublishWithSerializer(t *testing.T) {
+	t.Parallel()
+
+	pkgs := []*feeds.Package{
+		{Name: "Baz", Version: "1.0.0", Ecosystem: feeds.EcosystemNPM},
+	}
+	var pubMessages []string
+	mockPub := mockPublisher{sendCallback: func(msg string) error {
+		pubMessages = append(pubMessages, msg)
+		return nil
+	}}
+
+	feedGroup := NewFeedGroup([]feeds.ScheduledFeed{}, mockPub, time.Minute)
+	feedGroup.SetSerializer(publisher.OSVSerializer{})
+	result := feedGroup.publish(pkgs, nil)
+	if result.pubErr != nil {
+		t.Fatalf("publish() returned error %v", result.pubErr)
+	}
+	want, err := publisher.OSVSerializer{}.Serialize(pkgs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pubMessages) != 1 || pubMessages[0] != string(want) {
+		t.Errorf("Published messages %v when %s was expected", pubMessages, want)
+	}
+
+	// Packages which the serializer cannot serialize aren't published.
+	pubMessages = nil
+	result = feedGroup.publish([]*feeds.Package{{Name: "Qux", Ecosystem: "unknown"}}, nil)
+	if result.pubErr == nil {
+		t.Error("publish() returned no error when serializing the package failed")
+	}
+	if len(pubMessages) != 0 {
+		t.Errorf("Published messages %v when none were expected", pubMessages)
+	}
+}
+
+func TestFeedGroupCommitAfterPublish(t *testing.T) {
+	t.Parallel()
+
+	commits := 0
+	mockFeeds := []feeds.ScheduledFeed{
+		mockCommittingFeed{
+			mockFeed: mockFeed{packages: []*feeds.Package{{Name: "Foo"}, {Name: "Bar"}}},
+			commits:  &commits,
+		},
+	}
+	failPublish := true
+	pub := mockPublisher{sendCallback: func(msg string) error {
+		if failPublish && strings.Contains(msg, "Bar") {
+			return errPackage
+		}
+		return nil
+	}}
+	feedGroup := NewFeedGroup(mockFeeds, pub, time.Minute)
+
+	// The position isn't committed until every package of the poll is published.
+	if result := feedGroup.pollAndPublish(0); result.pubErr == nil {
+		t.Fatalf("pollAndPublish() succeeded when publishing Bar failed")
+	}
+	if commits != 0 {
+		t.Fatalf("Feed was committed %v times when publishing failed", commits)
+	}
+	failPublish = false
+	if result := feedGroup.pollAndPublish(0); result.pubErr != nil {
+		t.Fatalf("pollAndPublish() returned unexpected error: %v", result.pubErr)
+	}
+	if commits != 1 {
+		t.Errorf("Feed was committed %v times instead of once after publishing", commits)
+	}
+}
+
+func TestFeedGroupPublishWithEnvelope(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2021, 5, 11, 12, 0, 0, 0, time.UTC)
+	messages := []string{}
+	pub := publisher.WithEnvelope(mockPublisher{sendCallback: func(msg string) error {
+		messages = append(messages, msg)
+		return nil
+	}})
+	mockFeeds := []feeds.ScheduledFeed{
+		mockFeed{
+			name:     "foo",
+			packages: []*feeds.Package{{Name: "Foo", Version: "1.0.0", Type: "foo", CreatedDate: start}},
+		},
+	}
+	feedGroup := NewFeedGroup(mockFeeds, pub, time.Minute)
+	feedGroup.SetClock(feeds.NewFakeClock(start))
+	pkgs, _, err := feedGroup.poll(0)
+	if err != nil {
+		t.Fatalf("poll() returned unexpected error: %v", err)
+	}
+	if result := feedGroup.publish(pkgs, nil); result.numPublished != 1 {
+		t.Fatalf("%v packages were published when 1 was expected", result.numPublished)
+	}
+
+	if len(messages) != 1 {
+		t.Fatalf("%v messages were published when 1 was expected", len(messages))
+	}
+	var envelope publisher.Envelope
+	if err := json.Unmarshal([]byte(messages[0]), &envelope); err != nil {
+		t.Fatalf("Failed to decode published envelope: %v", err)
+	}
+	if envelope.CycleID == "" || !envelope.PolledAt.Equal(start) || envelope.Feed != "foo" ||
+		!envelope.DedupFirstSeen.Equal(start) {
+		t.Errorf("Published envelope %s is missing the cycle, poll time, feed or first seen time", messages[0])
+	}
+	pkg := struct {
+		Name string `json:"name"`
+	}{}
+	if err := json.Unmarshal(envelope.Package, &pkg); err != nil || pkg.Name != "Foo" {
+		t.Errorf("Published envelope %s does not wrap the package", messages[0])
+	}
+}
+
+func TestFeedGroupPollWithOrderedPublishing(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2021, 5, 11, 12, 0, 0, 0, time.UTC)
+	var mu sync.Mutex
+	published := []string{}
+	pub := mockPublisher{sendCallback: func(msg string) error {
+		event := struct {
+			Name string `json:"name"`
+		}{}
+		if err := json.Unmarshal([]byte(msg), &event); err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		published = append(published, event.Name)
+		return nil
+	}}
+	mockFeeds := []feeds.ScheduledFeed{
+		mockFeed{
+			name: "foo",
+			packages: []*feeds.Package{
+				{Name: "foo-3", Type: "foo", CreatedDate: start.Add(3 * time.Minute)},
+				{Name: "foo-1", Type: "foo", CreatedDate: start.Add(time.Minute)},
+			},
+		},
+		mockFeed{
+			name: "bar",
+			packages: []*feeds.Package{
+				{Name: "bar-2", Type: "bar", CreatedDate: start.Add(2 * time.Minute)},
+				{Name: "bar-3", Type: "bar", CreatedDate: start.Add(3 * time.Minute)},
+				{Name: "bar-0", Type: "bar", CreatedDate: start},
+			},
+		},
+	}
+	feedGroup := NewFeedGroup(mockFeeds, pub, time.Minute)
+	feedGroup.SetClock(feeds.NewFakeClock(start))
+	feedGroup.SetOrdered(true)
+	pkgs, _, err := feedGroup.poll(0)
+	if err != nil {
+		t.Fatalf("poll() returned unexpected error: %v", err)
+	}
+	feedGroup.publish(pkgs, nil)
+
+	// Packages created at the same time are ordered by feed.
+	expected := []string{"bar-0", "foo-1", "bar-2", "bar-3", "foo-3"}
+	if !reflect.DeepEqual(published, expected) {
+		t.Errorf("Packages were published in the order %v when %v was expected", published, expected)
+	}
+}
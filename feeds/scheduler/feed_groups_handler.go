@@ -21,7 +21,7 @@ func (srv *FeedGroupsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 	var errStrings []string
 	for _, group := range srv.feedGroups {
 		go func(group *FeedGroup) {
-			result := group.pollAndPublish()
+			result := group.pollAndPublish(0)
 			resultChannel <- result
 		}(group)
 	}
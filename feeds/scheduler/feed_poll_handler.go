@@ -0,0 +1,89 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ossf/package-feeds/feeds"
+)
+
+const (
+	feedsPathPrefix = "/feeds/"
+	pollPathSuffix  = "/poll"
+)
+
+// FeedPollHandler serves requests to poll a single feed on demand through
+// `POST /feeds/{name}/poll`.
+type FeedPollHandler struct {
+	feeds map[string]feedEntry
+}
+
+type feedEntry struct {
+	feed  feeds.ScheduledFeed
+	group *FeedGroup
+}
+
+type pollSummary struct {
+	Feed         string   `json:"feed"`
+	NumPackages  int      `json:"num_packages"`
+	NumPublished int      `json:"num_published"`
+	Errors       []string `json:"errors"`
+	Duration     string   `json:"duration"`
+}
+
+func NewFeedPollHandler(feedGroups []*FeedGroup) *FeedPollHandler {
+	entries := map[string]feedEntry{}
+	for _, group := range feedGroups {
+		for _, feed := range group.feeds {
+			entries[feed.GetName()] = feedEntry{feed: feed, group: group}
+		}
+	}
+	return &FeedPollHandler{feeds: entries}
+}
+
+func (h *FeedPollHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	if !strings.HasPrefix(path, feedsPathPrefix) || !strings.HasSuffix(path, pollPathSuffix) {
+		http.NotFound(w, r)
+		return
+	}
+	name := strings.TrimSuffix(strings.TrimPrefix(path, feedsPathPrefix), pollPathSuffix)
+	entry, ok := h.feeds[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	start := time.Now()
+	result, errs := entry.group.pollAndPublishFeed(entry.feed)
+	summary := pollSummary{
+		Feed:         name,
+		NumPackages:  result.numPackages,
+		NumPublished: result.numPublished,
+		Errors:       []string{},
+		Duration:     time.Since(start).String(),
+	}
+	for _, err := range errs {
+		summary.Errors = append(summary.Errors, err.Error())
+	}
+	if result.pubErr != nil {
+		summary.Errors = append(summary.Errors, result.pubErr.Error())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if len(summary.Errors) > 0 {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	if err := json.NewEncoder(w).Encode(summary); err != nil {
+		log.WithError(err).Error("Failed to write poll summary")
+	}
+}
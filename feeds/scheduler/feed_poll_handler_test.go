@@ -0,0 +1,151 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+)
+
+func TestFeedPollHandler(t *testing.T) {
+	t.Parallel()
+
+	pubMessages := []string{}
+	pub := mockPublisher{sendCallback: func(msg string) error {
+		pubMessages = append(pubMessages, msg)
+		return nil
+	}}
+	feedGroup := NewFeedGroup([]feeds.ScheduledFeed{
+		mockFeed{
+			packages: []*feeds.Package{
+				{Name: "Foo"},
+				{Name: "Bar"},
+			},
+		},
+	}, pub, time.Minute)
+	startLastPollValue := feedGroup.lastPoll
+	handler := NewFeedPollHandler([]*FeedGroup{feedGroup})
+
+	req := httptest.NewRequest(http.MethodPost, "/feeds/mockFeed/poll", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Poll request returned status %v when %v was expected", rec.Code, http.StatusOK)
+	}
+	summary := pollSummary{}
+	if err := json.NewDecoder(rec.Body).Decode(&summary); err != nil {
+		t.Fatalf("Failed to decode poll summary: %v", err)
+	}
+	if summary.Feed != "mockFeed" {
+		t.Errorf("Poll summary was for feed `%v` when `mockFeed` was expected", summary.Feed)
+	}
+	if summary.NumPackages != 2 || summary.NumPublished != 2 {
+		t.Errorf("Poll summary reported %v packages and %v published when 2 of each were expected",
+			summary.NumPackages, summary.NumPublished)
+	}
+	if len(summary.Errors) != 0 {
+		t.Errorf("Poll summary reported unexpected errors: %v", summary.Errors)
+	}
+	if len(pubMessages) != 2 {
+		t.Errorf("%v packages were published when 2 were expected", len(pubMessages))
+	}
+	if !startLastPollValue.Equal(feedGroup.lastPoll) {
+		t.Errorf("Polling a single feed advanced the cutoff of the feed group")
+	}
+}
+
+func TestFeedPollHandlerWithErr(t *testing.T) {
+	t.Parallel()
+
+	feedGroup := NewFeedGroup([]feeds.ScheduledFeed{
+		mockFeed{
+			errs: []error{errPackage},
+		},
+	}, mockPublisher{}, time.Minute)
+	handler := NewFeedPollHandler([]*FeedGroup{feedGroup})
+
+	req := httptest.NewRequest(http.MethodPost, "/feeds/mockFeed/poll", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("Poll request returned status %v when %v was expected", rec.Code, http.StatusInternalServerError)
+	}
+	summary := pollSummary{}
+	if err := json.NewDecoder(rec.Body).Decode(&summary); err != nil {
+		t.Fatalf("Failed to decode poll summary: %v", err)
+	}
+	if len(summary.Errors) != 1 || summary.Errors[0] != errPackage.Error() {
+		t.Errorf("Poll summary reported errors %v when `%v` was expected", summary.Errors, errPackage)
+	}
+}
+
+func TestFeedPollHandlerUnknownFeed(t *testing.T) {
+	t.Parallel()
+
+	feedGroup := NewFeedGroup([]feeds.ScheduledFeed{mockFeed{}}, mockPublisher{}, time.Minute)
+	handler := NewFeedPollHandler([]*FeedGroup{feedGroup})
+
+	for _, path := range []string{"/feeds/foo/poll", "/feeds/mockFeed", "/feeds/"} {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("Request to `%v` returned status %v when %v was expected", path, rec.Code, http.StatusNotFound)
+		}
+	}
+}
+
+func TestFeedPollHandlerMethodNotAllowed(t *testing.T) {
+	t.Parallel()
+
+	feedGroup := NewFeedGroup([]feeds.ScheduledFeed{mockFeed{}}, mockPublisher{}, time.Minute)
+	handler := NewFeedPollHandler([]*FeedGroup{feedGroup})
+
+	req := httptest.NewRequest(http.MethodGet, "/feeds/mockFeed/poll", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET request returned status %v when %v was expected", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestFeedPollHandlerConcurrentPoll(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	published := 0
+	pub := mockPublisher{sendCallback: func(msg string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		published++
+		return nil
+	}}
+	feedGroup := NewFeedGroup([]feeds.ScheduledFeed{
+		mockFeed{packages: []*feeds.Package{{Name: "Foo"}}},
+	}, pub, time.Minute)
+	handler := NewFeedPollHandler([]*FeedGroup{feedGroup})
+
+	// A poll requested through the handler reads the cutoff whilst the group is polled.
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			feedGroup.pollAndPublish(0)
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/feeds/mockFeed/poll", nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("Poll request returned status %v when %v was expected", rec.Code, http.StatusOK)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,76 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/publisher"
+)
+
+const heartbeatEvent = "heartbeat"
+
+// HeartbeatEvent is published for feeds configured with a heartbeat after a successful poll
+// which found no packages, so that consumers can tell a quiet feed from a dead one.
+type HeartbeatEvent struct {
+	// Distinguishes heartbeats from packages, always "heartbeat".
+	Event    string    `json:"event"`
+	Feed     string    `json:"feed"`
+	PolledAt time.Time `json:"polled_at"`
+}
+
+// Publishes a heartbeat for each feed configured with a heartbeat whose poll succeeded
+// without any packages, returning whether any were sent.
+func (fg *FeedGroup) publishHeartbeats(scheduledFeeds []feeds.ScheduledFeed, results []feeds.PollResult) bool {
+	enabled := map[string]bool{}
+	for _, feed := range scheduledFeeds {
+		enabled[feed.GetName()] = feed.GetFeedOptions().Heartbeat
+	}
+	sent := false
+	for _, result := range results {
+		if !enabled[result.Feed] || result.Skipped || len(result.Errors) > 0 || len(result.Packages) > 0 {
+			continue
+		}
+		event := HeartbeatEvent{
+			Event:    heartbeatEvent,
+			Feed:     result.Feed,
+			PolledAt: result.PolledAt,
+		}
+		if fg.sendEvent(event, log.WithField("feed", result.Feed)) {
+			sent = true
+		}
+	}
+	return sent
+}
+
+// Sends an event, such as a heartbeat, through the publisher in place of a package.
+// Failing to send an event is logged rather than failing the poll, as no packages are lost.
+// Publishers which require a package, e.g. Avro or a topic routed by package, skip events.
+func (fg *FeedGroup) sendEvent(event interface{}, logger *log.Entry) bool {
+	body, err := json.Marshal(event)
+	if err != nil {
+		logger.WithError(err).Error("Failed to marshal event")
+		return false
+	}
+	err = fg.publisher.Send(context.Background(), body)
+	if errors.Is(err, publisher.ErrNoPackage) {
+		logger.WithError(err).Debug("Event skipped by the publisher")
+		return false
+	}
+	if err != nil {
+		logger.WithError(err).Error("Failed to publish event")
+		return false
+	}
+	return true
+}
+
+// Flushes the events sent at the end of a poll cycle.
+func (fg *FeedGroup) flushEvents() {
+	if err := publisher.Flush(context.Background(), fg.publisher); err != nil {
+		log.WithError(err).Error("Failed to flush events")
+	}
+}
@@ -8,12 +8,22 @@ import (
 )
 
 type mockFeed struct {
+	name     string
 	packages []*feeds.Package
 	errs     []error
 	options  feeds.FeedOptions
+	// Whether Latest only returns packages created after the cutoff, as real feeds do.
+	applyCutoff bool
+	// Called with the context provided to Latest.
+	contextCallback func(context.Context)
+	// Called with the cutoff provided to Latest.
+	cutoffCallback func(time.Time)
 }
 
 func (feed mockFeed) GetName() string {
+	if feed.name != "" {
+		return feed.name
+	}
 	return "mockFeed"
 }
 
@@ -21,12 +31,45 @@ func (feed mockFeed) GetFeedOptions() feeds.FeedOptions {
 	return feed.options
 }
 
-func (feed mockFeed) Latest(cutoff time.Time) ([]*feeds.Package, []error) {
+func (feed mockFeed) Latest(ctx context.Context, cutoff time.Time) ([]*feeds.Package, []error) {
+	if feed.contextCallback != nil {
+		feed.contextCallback(ctx)
+	}
+	if feed.cutoffCallback != nil {
+		feed.cutoffCallback(cutoff)
+	}
+	if feed.applyCutoff {
+		return feeds.ApplyCutoff(feed.packages, cutoff), feed.errs
+	}
 	return feed.packages, feed.errs
 }
 
+// A mockFeed tracking its own position, counting the number of times it was committed.
+type mockCommittingFeed struct {
+	mockFeed
+	commits *int
+}
+
+func (feed mockCommittingFeed) Commit() error {
+	*feed.commits++
+	return nil
+}
+
+// A mockFeed holding resources, recording whether it was closed.
+type mockClosingFeed struct {
+	mockFeed
+	closed   *bool
+	closeErr error
+}
+
+func (feed mockClosingFeed) Close() error {
+	*feed.closed = true
+	return feed.closeErr
+}
+
 type mockPublisher struct {
-	sendCallback func(string) error
+	sendCallback  func(string) error
+	flushCallback func() error
 }
 
 func (pub mockPublisher) Send(ctx context.Context, body []byte) error {
@@ -39,3 +82,10 @@ func (pub mockPublisher) Send(ctx context.Context, body []byte) error {
 func (pub mockPublisher) Name() string {
 	return "mockPublisher"
 }
+
+func (pub mockPublisher) Flush(ctx context.Context) error {
+	if pub.flushCallback != nil {
+		return pub.flushCallback()
+	}
+	return nil
+}
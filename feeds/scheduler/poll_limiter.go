@@ -0,0 +1,30 @@
+package scheduler
+
+// PollLimiter limits the number of feeds polled concurrently, feeds which would exceed
+// the limit wait for another poll to complete. A PollLimiter may be shared between
+// FeedGroups to limit polling across all groups, a nil PollLimiter is unlimited.
+type PollLimiter struct {
+	slots chan struct{}
+}
+
+// NewPollLimiter returns a PollLimiter allowing up to maxConcurrent feeds to be polled
+// at once.
+func NewPollLimiter(maxConcurrent int) *PollLimiter {
+	return &PollLimiter{slots: make(chan struct{}, maxConcurrent)}
+}
+
+// Blocks until a feed may be polled.
+func (l *PollLimiter) acquire() {
+	if l == nil {
+		return
+	}
+	l.slots <- struct{}{}
+}
+
+// Releases a slot acquired for a poll which has completed.
+func (l *PollLimiter) release() {
+	if l == nil {
+		return
+	}
+	<-l.slots
+}
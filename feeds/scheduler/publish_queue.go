@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/metrics"
+	"github.com/ossf/package-feeds/publisher"
+)
+
+// QueuePolicy determines how a full PublishQueue handles further packages.
+type QueuePolicy string
+
+const (
+	// QueueBlock blocks polling until the publisher has made space in the queue.
+	QueueBlock QueuePolicy = "block"
+	// QueueDropOldest discards the oldest queued package to make space for the newest.
+	QueueDropOldest QueuePolicy = "drop_oldest"
+)
+
+var (
+	errInvalidQueueCapacity = errors.New("publish queue capacity must be positive")
+	errInvalidQueuePolicy   = errors.New("publish queue policy must be one of block or drop_oldest")
+)
+
+type queuedPackage struct {
+	feedGroup *FeedGroup
+	pkg       *feeds.Package
+}
+
+// PublishQueue is a bounded queue between the packages produced by polls and the publisher,
+// which bounds the memory used by packages waiting to be published when the publisher is
+// slower than polling. Packages are sent to the publisher of the FeedGroup which queued
+// them, in the order they were queued. A PublishQueue may be shared between FeedGroups, a
+// nil PublishQueue publishes packages as part of each poll.
+type PublishQueue struct {
+	packages chan queuedPackage
+	policy   QueuePolicy
+
+	// Serializes making space in the queue under the drop oldest policy.
+	mu    sync.Mutex
+	start sync.Once
+}
+
+// NewPublishQueue returns a PublishQueue holding up to capacity packages, applying policy
+// when it is full.
+func NewPublishQueue(capacity int, policy QueuePolicy) (*PublishQueue, error) {
+	if capacity < 1 {
+		return nil, fmt.Errorf("%w : %v", errInvalidQueueCapacity, capacity)
+	}
+	if policy != QueueBlock && policy != QueueDropOldest {
+		return nil, fmt.Errorf("%w : %v", errInvalidQueuePolicy, policy)
+	}
+	return &PublishQueue{
+		packages: make(chan queuedPackage, capacity),
+		policy:   policy,
+	}, nil
+}
+
+// Adds a package to the queue, starting publishing on first use. When the queue is full
+// this either blocks or drops the oldest queued package, depending on the policy.
+func (q *PublishQueue) enqueue(fg *FeedGroup, pkg *feeds.Package) {
+	q.start.Do(func() {
+		go q.run()
+	})
+	item := queuedPackage{feedGroup: fg, pkg: pkg}
+	if q.policy == QueueBlock {
+		q.packages <- item
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		select {
+		case q.packages <- item:
+			return
+		default:
+		}
+		select {
+		case dropped := <-q.packages:
+			metrics.PublishQueueDropped.WithLabelValues(dropped.pkg.Type).Inc()
+			log.WithFields(log.Fields{
+				"feed":    dropped.pkg.Type,
+				"name":    dropped.pkg.Name,
+				"version": dropped.pkg.Version,
+			}).Warn("Publish queue is full, dropped the oldest package")
+		default:
+		}
+	}
+}
+
+// Sends queued packages to the publisher, flushing the publisher whenever the queue has
+// been drained.
+func (q *PublishQueue) run() {
+	for item := range q.packages {
+		fg := item.feedGroup
+		if _, err := fg.publishPackages([]*feeds.Package{item.pkg}); err != nil {
+			log.WithError(err).WithField("feed", item.pkg.Type).Error("Failed to publish queued package")
+		}
+		if len(q.packages) > 0 {
+			continue
+		}
+		if err := publisher.Flush(context.Background(), fg.publisher); err != nil {
+			log.WithError(err).Error("Failed to flush publisher")
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/metrics"
+)
+
+// A publisher which blocks sending each package until it is released, recording the names
+// of the packages sent.
+type slowPublisher struct {
+	mockPublisher
+	started chan string
+	release chan struct{}
+
+	mu   sync.Mutex
+	sent []string
+}
+
+func newSlowPublisher() *slowPublisher {
+	pub := &slowPublisher{
+		started: make(chan string, 100),
+		release: make(chan struct{}),
+	}
+	pub.sendCallback = func(body string) error {
+		pkg := &feeds.Package{}
+		if err := json.Unmarshal([]byte(body), pkg); err != nil {
+			return err
+		}
+		pub.started <- pkg.Name
+		<-pub.release
+		pub.mu.Lock()
+		defer pub.mu.Unlock()
+		pub.sent = append(pub.sent, pkg.Name)
+		return nil
+	}
+	return pub
+}
+
+// Waits for the given number of packages to have been sent.
+func (pub *slowPublisher) waitSent(t *testing.T, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		pub.mu.Lock()
+		sent := append([]string{}, pub.sent...)
+		pub.mu.Unlock()
+		if len(sent) >= n {
+			return sent
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %v packages to be sent", n)
+	return nil
+}
+
+func queuePackages(feed string, names ...string) []*feeds.Package {
+	pkgs := []*feeds.Package{}
+	for _, name := range names {
+		pkgs = append(pkgs, &feeds.Package{Name: name, Type: feed})
+	}
+	return pkgs
+}
+
+func TestPublishQueueDropOldest(t *testing.T) {
+	t.Parallel()
+
+	droppedBefore := testutil.ToFloat64(metrics.PublishQueueDropped.WithLabelValues("drop-oldest"))
+	pub := newSlowPublisher()
+	queue, err := NewPublishQueue(2, QueueDropOldest)
+	if err != nil {
+		t.Fatalf("Failed to create publish queue: %v", err)
+	}
+	feedGroup := NewFeedGroup(nil, pub, time.Minute)
+	feedGroup.SetPublishQueue(queue)
+
+	// The first package is taken from the queue by the publisher, which is then too slow
+	// for the queue to hold all of the following packages.
+	feedGroup.publish(queuePackages("drop-oldest", "Foo"), nil)
+	<-pub.started
+	result := feedGroup.publish(queuePackages("drop-oldest", "Bar", "Baz", "Qux", "Quux"), nil)
+	if result.numPublished != 4 || result.pubErr != nil {
+		t.Errorf("publish() queued %v packages with error %v when 4 were expected", result.numPublished, result.pubErr)
+	}
+	close(pub.release)
+
+	sent := pub.waitSent(t, 3)
+	if !equalNames(sent, []string{"Foo", "Qux", "Quux"}) {
+		t.Errorf("Publisher was sent %v when Foo, Qux and Quux were expected", sent)
+	}
+	dropped := testutil.ToFloat64(metrics.PublishQueueDropped.WithLabelValues("drop-oldest")) - droppedBefore
+	if dropped != 2 {
+		t.Errorf("Publish queue dropped %v packages when 2 were expected", dropped)
+	}
+}
+
+func TestPublishQueueBlock(t *testing.T) {
+	t.Parallel()
+
+	droppedBefore := testutil.ToFloat64(metrics.PublishQueueDropped.WithLabelValues("block"))
+	pub := newSlowPublisher()
+	queue, err := NewPublishQueue(1, QueueBlock)
+	if err != nil {
+		t.Fatalf("Failed to create publish queue: %v", err)
+	}
+	feedGroup := NewFeedGroup(nil, pub, time.Minute)
+	feedGroup.SetPublishQueue(queue)
+
+	feedGroup.publish(queuePackages("block", "Foo"), nil)
+	<-pub.started
+	done := make(chan struct{})
+	go func() {
+		feedGroup.publish(queuePackages("block", "Bar", "Baz"), nil)
+		close(done)
+	}()
+	// Bar fills the queue, so queueing Baz blocks until the publisher makes space.
+	select {
+	case <-done:
+		t.Fatalf("publish() returned whilst the publish queue was full")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(pub.release)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("publish() remained blocked after the publisher made space in the queue")
+	}
+
+	sent := pub.waitSent(t, 3)
+	if !equalNames(sent, []string{"Foo", "Bar", "Baz"}) {
+		t.Errorf("Publisher was sent %v when Foo, Bar and Baz were expected", sent)
+	}
+	dropped := testutil.ToFloat64(metrics.PublishQueueDropped.WithLabelValues("block")) - droppedBefore
+	if dropped != 0 {
+		t.Errorf("Publish queue dropped %v packages when none were expected", dropped)
+	}
+}
+
+func TestPublishQueueInvalid(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewPublishQueue(0, QueueBlock); !errors.Is(err, errInvalidQueueCapacity) {
+		t.Errorf("NewPublishQueue() returned `%v` when an invalid capacity error was expected", err)
+	}
+	if _, err := NewPublishQueue(10, "drop_newest"); !errors.Is(err, errInvalidQueuePolicy) {
+		t.Errorf("NewPublishQueue() returned `%v` when an invalid policy error was expected", err)
+	}
+}
+
+func equalNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
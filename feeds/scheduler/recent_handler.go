@@ -0,0 +1,223 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ossf/package-feeds/feeds"
+)
+
+const (
+	recentPath = "/recent"
+
+	// DefaultRecentPackages is the number of recently published packages kept per feed.
+	DefaultRecentPackages = 100
+	// MaxRecentPackages bounds the number of recently published packages kept per feed.
+	MaxRecentPackages = 10000
+)
+
+var (
+	errInvalidRecentPackages = errors.New("recent packages must be within the range [1, 10000]")
+	errMissingFeed           = errors.New("feed must be provided")
+	errInvalidLimit          = errors.New("limit must be a positive integer")
+	errInvalidSince          = errors.New("since must be an RFC 3339 time")
+)
+
+// RecentPackages keeps the most recently published packages of each feed in a ring buffer
+// of a fixed size.
+type RecentPackages struct {
+	mu    sync.Mutex
+	size  int
+	rings map[string]*packageRing
+	clock feeds.Clock
+}
+
+// A fixed size ring buffer of packages, next is the index the next package is written to.
+type packageRing struct {
+	packages []publishedPackage
+	next     int
+}
+
+// A package and the time it was published.
+type publishedPackage struct {
+	pkg       *feeds.Package
+	published time.Time
+}
+
+func NewRecentPackages(size int) *RecentPackages {
+	return &RecentPackages{size: size, rings: map[string]*packageRing{}, clock: feeds.RealClock()}
+}
+
+// Sets the Clock providing the time packages are published, this must be set before a
+// package is recorded.
+func (r *RecentPackages) SetClock(clock feeds.Clock) {
+	r.clock = clock
+}
+
+func (r *RecentPackages) record(pkg *feeds.Package) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ring, ok := r.rings[pkg.Type]
+	if !ok {
+		ring = &packageRing{}
+		r.rings[pkg.Type] = ring
+	}
+	published := publishedPackage{pkg: pkg, published: r.clock.Now()}
+	if len(ring.packages) < r.size {
+		ring.packages = append(ring.packages, published)
+	} else {
+		ring.packages[ring.next] = published
+	}
+	ring.next = (ring.next + 1) % r.size
+}
+
+// Recent returns up to limit of the packages most recently published for the feed, most
+// recently published first.
+func (r *RecentPackages) Recent(feed string, limit int) []*feeds.Package {
+	pkgs := []*feeds.Package{}
+	for _, p := range r.recentFeed(feed, limit) {
+		pkgs = append(pkgs, p.pkg)
+	}
+	return pkgs
+}
+
+// RecentSince returns up to limit of the packages most recently published for the feed which
+// were first seen after since, most recently published first. Packages without a first seen
+// time are compared by their created date. Only the packages kept in the ring buffer of the
+// feed are returned, older packages have been evicted.
+func (r *RecentPackages) RecentSince(feed string, since time.Time, limit int) []*feeds.Package {
+	pkgs := []*feeds.Package{}
+	for _, p := range r.recentFeed(feed, r.size) {
+		if len(pkgs) == limit {
+			break
+		}
+		if seenTime(p.pkg).After(since) {
+			pkgs = append(pkgs, p.pkg)
+		}
+	}
+	return pkgs
+}
+
+// Returns the time the package was first seen, or its created date when it has no first
+// seen time.
+func seenTime(pkg *feeds.Package) time.Time {
+	if pkg.FirstSeen == nil {
+		return pkg.CreatedDate
+	}
+	return *pkg.FirstSeen
+}
+
+// Returns up to limit of the packages most recently published for the feed along with the
+// time they were published, most recently published first.
+func (r *RecentPackages) recentFeed(feed string, limit int) []publishedPackage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ring, ok := r.rings[feed]
+	if !ok {
+		return []publishedPackage{}
+	}
+	return ring.recent(limit)
+}
+
+// Returns up to limit of the packages most recently published across all feeds, most
+// recently published first.
+func (r *RecentPackages) recentAll(limit int) []publishedPackage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pkgs := []publishedPackage{}
+	for _, ring := range r.rings {
+		pkgs = append(pkgs, ring.recent(limit)...)
+	}
+	// Packages of different feeds published at the same time are ordered by feed, the
+	// order of the packages of each feed is kept.
+	sort.SliceStable(pkgs, func(i, j int) bool {
+		if !pkgs[i].published.Equal(pkgs[j].published) {
+			return pkgs[j].published.Before(pkgs[i].published)
+		}
+		return pkgs[i].pkg.Type < pkgs[j].pkg.Type
+	})
+	if limit < len(pkgs) {
+		pkgs = pkgs[:limit]
+	}
+	return pkgs
+}
+
+// Returns up to limit of the packages in the ring, most recently published first.
+func (ring *packageRing) recent(limit int) []publishedPackage {
+	if limit > len(ring.packages) {
+		limit = len(ring.packages)
+	}
+	pkgs := make([]publishedPackage, 0, limit)
+	for i := 1; i <= limit; i++ {
+		index := (ring.next - i + len(ring.packages)) % len(ring.packages)
+		pkgs = append(pkgs, ring.packages[index])
+	}
+	return pkgs
+}
+
+// RecentHandler serves the packages most recently published for a feed through
+// `GET /recent?feed={name}&limit={n}&since={time}`, the limit defaults to all kept packages.
+// When since is given only the packages first seen after it are served, allowing clients to
+// fetch the packages published since their last request. The Last-Modified header is set to
+// the latest time a served package was first seen.
+type RecentHandler struct {
+	recent *RecentPackages
+}
+
+func NewRecentHandler(recent *RecentPackages) *RecentHandler {
+	return &RecentHandler{recent: recent}
+}
+
+func (h *RecentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	feed := query.Get("feed")
+	if feed == "" {
+		http.Error(w, errMissingFeed.Error(), http.StatusBadRequest)
+		return
+	}
+	limit := h.recent.size
+	if s := query.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			http.Error(w, errInvalidLimit.Error(), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	var pkgs []*feeds.Package
+	if s := query.Get("since"); s != "" {
+		since, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			http.Error(w, errInvalidSince.Error(), http.StatusBadRequest)
+			return
+		}
+		pkgs = h.recent.RecentSince(feed, since, limit)
+	} else {
+		pkgs = h.recent.Recent(feed, limit)
+	}
+	var lastModified time.Time
+	for _, pkg := range pkgs {
+		if seen := seenTime(pkg); seen.After(lastModified) {
+			lastModified = seen
+		}
+	}
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(pkgs); err != nil {
+		log.WithError(err).Error("Failed to write recent packages")
+	}
+}
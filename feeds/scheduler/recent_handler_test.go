@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+)
+
+func TestRecentPackagesRing(t *testing.T) {
+	t.Parallel()
+
+	recent := NewRecentPackages(2)
+	for _, name := range []string{"Foo", "Bar", "Baz"} {
+		recent.record(&feeds.Package{Name: name, Type: "foo"})
+	}
+	recent.record(&feeds.Package{Name: "Qux", Type: "bar"})
+
+	pkgs := recent.Recent("foo", 10)
+	if len(pkgs) != 2 {
+		t.Fatalf("Recent() returned %v packages when 2 were expected", len(pkgs))
+	}
+	if pkgs[0].Name != "Baz" || pkgs[1].Name != "Bar" {
+		t.Errorf("Recent() returned %v and %v when Baz and Bar were expected", pkgs[0].Name, pkgs[1].Name)
+	}
+	if pkgs := recent.Recent("foo", 1); len(pkgs) != 1 || pkgs[0].Name != "Baz" {
+		t.Errorf("Recent() with a limit of 1 returned %v packages when only Baz was expected", len(pkgs))
+	}
+	if pkgs := recent.Recent("missing", 10); len(pkgs) != 0 {
+		t.Errorf("Recent() returned %v packages for a feed without published packages", len(pkgs))
+	}
+}
+
+func TestRecentHandler(t *testing.T) {
+	t.Parallel()
+
+	pub := mockPublisher{sendCallback: func(msg string) error { return nil }}
+	feedGroup := NewFeedGroup([]feeds.ScheduledFeed{
+		mockFeed{
+			name: "foo",
+			packages: []*feeds.Package{
+				feeds.NewPackage(time.Now(), "Foo", "1.0.0", "foo", ""),
+				feeds.NewPackage(time.Now(), "Bar", "1.0.0", "foo", ""),
+			},
+		},
+	}, pub, time.Minute)
+	feedGroup.pollAndPublish(0)
+	handler := NewRecentHandler(feedGroup.recent)
+
+	req := httptest.NewRequest(http.MethodGet, recentPath+"?feed=foo&limit=1", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Recent request returned status %v when %v was expected", rec.Code, http.StatusOK)
+	}
+	pkgs := []feeds.Package{}
+	if err := json.NewDecoder(rec.Body).Decode(&pkgs); err != nil {
+		t.Fatalf("Failed to decode recent packages: %v", err)
+	}
+	if len(pkgs) != 1 || pkgs[0].Name != "Bar" || pkgs[0].Type != "foo" {
+		t.Errorf("Recent request returned %v when only Bar of feed foo was expected", pkgs)
+	}
+}
+
+func TestRecentHandlerSince(t *testing.T) {
+	t.Parallel()
+
+	recent := NewRecentPackages(DefaultRecentPackages)
+	start := time.Date(2021, 5, 11, 18, 0, 0, 0, time.UTC)
+	for i, name := range []string{"Foo", "Bar", "Baz", "Qux"} {
+		pkg := feeds.NewPackage(start, name, "1.0.0", "foo", "")
+		firstSeen := start.Add(time.Duration(i) * time.Minute)
+		pkg.FirstSeen = &firstSeen
+		recent.record(pkg)
+	}
+	// Packages without a first seen time are compared by their created date.
+	recent.record(feeds.NewPackage(start.Add(time.Hour), "Quux", "1.0.0", "foo", ""))
+	handler := NewRecentHandler(recent)
+
+	tests := []struct {
+		query        string
+		expected     []string
+		lastModified string
+	}{
+		{
+			query:        "?feed=foo&since=2021-05-11T18:01:00Z",
+			expected:     []string{"Quux", "Qux", "Baz"},
+			lastModified: "Tue, 11 May 2021 19:00:00 GMT",
+		},
+		{
+			query:        "?feed=foo&since=2021-05-11T18:01:00Z&limit=2",
+			expected:     []string{"Quux", "Qux"},
+			lastModified: "Tue, 11 May 2021 19:00:00 GMT",
+		},
+		{
+			query:        "?feed=foo&since=2021-05-11T18:00:30.5Z",
+			expected:     []string{"Quux", "Qux", "Baz", "Bar"},
+			lastModified: "Tue, 11 May 2021 19:00:00 GMT",
+		},
+		{
+			query:    "?feed=foo&since=2021-05-11T19:00:00Z",
+			expected: []string{},
+		},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, recentPath+test.query, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Recent request `%v` returned status %v when %v was expected", test.query, rec.Code, http.StatusOK)
+		}
+		if lastModified := rec.Header().Get("Last-Modified"); lastModified != test.lastModified {
+			t.Errorf("Recent request `%v` returned Last-Modified %q when %q was expected",
+				test.query, lastModified, test.lastModified)
+		}
+		pkgs := []feeds.Package{}
+		if err := json.NewDecoder(rec.Body).Decode(&pkgs); err != nil {
+			t.Fatalf("Failed to decode recent packages: %v", err)
+		}
+		names := []string{}
+		for _, pkg := range pkgs {
+			names = append(names, pkg.Name)
+		}
+		if !reflect.DeepEqual(names, test.expected) {
+			t.Errorf("Recent request `%v` returned %v when %v was expected", test.query, names, test.expected)
+		}
+	}
+}
+
+func TestRecentHandlerBadRequest(t *testing.T) {
+	t.Parallel()
+
+	handler := NewRecentHandler(NewRecentPackages(DefaultRecentPackages))
+	for _, query := range []string{"", "?feed=foo&limit=0", "?feed=foo&limit=bar", "?feed=foo&since=yesterday"} {
+		req := httptest.NewRequest(http.MethodGet, recentPath+query, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Recent request `%v` returned status %v when %v was expected", query, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package scheduler
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
@@ -10,79 +12,418 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/metrics"
 	"github.com/ossf/package-feeds/publisher"
 )
 
+const metricsPath = "/metrics"
+
+var (
+	errInvalidJitter             = errors.New("jitter must be within the range [0, 1)")
+	errInvalidMaxConcurrentFeeds = errors.New("max concurrent feeds must not be negative")
+	errInvalidMaxLookback        = errors.New("max lookback must not be negative")
+	errCloseFeeds                = errors.New("failed to close feeds")
+)
+
 // Scheduler is a registry of feeds that should be run on a schedule.
 type Scheduler struct {
 	registry  map[string]feeds.ScheduledFeed
 	publisher publisher.Publisher
 	httpPort  int
+
+	// The maximum random delay applied to each scheduled poll, as a fraction of the poll interval.
+	jitter float64
+
+	// The number of consecutive failed polls before polling of a feed is paused for
+	// breakerCooldown, zero disables circuit breaking.
+	breakerThreshold int
+	breakerCooldown  time.Duration
+
+	// The number of recently published packages kept per feed.
+	recentPackages int
+
+	// The maximum number of feeds polled at once across all schedules, zero allows every
+	// feed to be polled at once.
+	maxConcurrentFeeds int
+
+	// Static labels added to every published package.
+	labels map[string]string
+
+	// The capacity of the queue between polling and the publisher, and the policy applied
+	// when it is full. Zero publishes packages as part of each poll.
+	queueCapacity int
+	queuePolicy   QueuePolicy
+
+	// Provides the current time for poll cutoffs and the age of packages.
+	clock feeds.Clock
+
+	// The furthest the cutoff may be before the start of a poll, zero if unlimited.
+	maxLookback time.Duration
+
+	// Whether a summary of each poll cycle is published.
+	cycleSummary bool
+
+	// Whether the packages of each poll cycle are published in order of their creation.
+	ordered bool
+
+	// Enriches packages between polling and publishing, nil if packages aren't enriched.
+	enricher feeds.Enricher
+
+	// Serializes packages before they are published, nil to serialize them as JSON.
+	serializer publisher.Serializer
+
+	// Whether feeds polled on a timer are polled once when the scheduler starts, rather
+	// than waiting for the first tick of their timer.
+	pollOnStart bool
+
+	// The names of feeds which are configured but disabled, these are reported by
+	// `GET /status` without being polled.
+	disabledFeeds []string
+}
+
+// Option configures optional behaviour of a Scheduler.
+type Option func(*Scheduler)
+
+// WithJitter delays each scheduled poll of a feed by a random duration of up to the given
+// fraction of the poll interval, e.g. 0.1 allows a delay of up to 10% of the interval. This
+// spreads the load of feeds which share a poll interval.
+func WithJitter(jitter float64) Option {
+	return func(s *Scheduler) {
+		s.jitter = jitter
+	}
+}
+
+// WithCircuitBreaker pauses polling of a feed for the cooldown after threshold consecutive
+// polls of the feed fail, a threshold of zero disables circuit breaking.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(s *Scheduler) {
+		s.breakerThreshold = threshold
+		s.breakerCooldown = cooldown
+	}
+}
+
+// WithRecentPackages sets the number of recently published packages kept per feed and
+// served by `GET /recent`, up to MaxRecentPackages.
+func WithRecentPackages(size int) Option {
+	return func(s *Scheduler) {
+		s.recentPackages = size
+	}
+}
+
+// WithMaxConcurrentFeeds limits the number of feeds polled at once across all schedules,
+// polls of further feeds wait for another poll to complete. This defaults to the number
+// of feeds, allowing every feed to be polled at once.
+func WithMaxConcurrentFeeds(maxConcurrent int) Option {
+	return func(s *Scheduler) {
+		s.maxConcurrentFeeds = maxConcurrent
+	}
+}
+
+// WithLabels adds static labels to every published package, e.g. identifying the
+// environment or region of the deployment.
+func WithLabels(labels map[string]string) Option {
+	return func(s *Scheduler) {
+		s.labels = labels
+	}
+}
+
+// WithPublishQueue publishes packages through a queue holding up to capacity packages, so
+// that polling continues whilst the publisher is slow. When the queue is full the policy
+// either blocks polling until there is space, or drops the oldest queued package.
+func WithPublishQueue(capacity int, policy QueuePolicy) Option {
+	return func(s *Scheduler) {
+		s.queueCapacity = capacity
+		s.queuePolicy = policy
+	}
+}
+
+// WithClock sets the Clock providing the current time for poll cutoffs and the age of
+// packages, allowing polls to be driven deterministically in tests. This defaults to the
+// system time. Feeds should be constructed with the same Clock in their FeedOptions, as
+// config.ScheduledFeedConfig does.
+func WithClock(clock feeds.Clock) Option {
+	return func(s *Scheduler) {
+		s.clock = clock
+	}
+}
+
+// WithMaxLookback caps the cutoff of each poll to maxLookback before the start of the poll.
+// When feeds haven't been polled for longer, e.g. after an outage of the service dispatching
+// polls, the packages created before the capped cutoff are skipped rather than flooding the
+// publisher. Backfills are not capped.
+func WithMaxLookback(maxLookback time.Duration) Option {
+	return func(s *Scheduler) {
+		s.maxLookback = maxLookback
+	}
+}
+
+// WithDisabledFeeds reports the named feeds as disabled by `GET /status`, these feeds are
+// configured but are not part of the registry so are never polled.
+func WithDisabledFeeds(names ...string) Option {
+	return func(s *Scheduler) {
+		s.disabledFeeds = names
+	}
+}
+
+// WithCycleSummary publishes a CycleSummaryEvent through the publisher at the end of each
+// poll cycle, summarizing the packages and errors of each feed polled.
+func WithCycleSummary() Option {
+	return func(s *Scheduler) {
+		s.cycleSummary = true
+	}
+}
+
+// WithOrderedPublishing publishes the packages of all feeds polled together in a cycle in
+// order of their creation, giving a single timeline rather than the packages of each feed
+// in turn. Packages are already buffered until every feed of the cycle has been polled, so
+// a slow feed delays the cycle up to its poll timeout in either case.
+func WithOrderedPublishing() Option {
+	return func(s *Scheduler) {
+		s.ordered = true
+	}
+}
+
+// WithPollOnStart polls each feed with a timer once when the scheduler starts, so that
+// packages are published soon after a deploy rather than up to a poll interval later.
+// Feeds only polled through HTTP requests are unaffected.
+func WithPollOnStart() Option {
+	return func(s *Scheduler) {
+		s.pollOnStart = true
+	}
+}
+
+// WithEnricher runs the enricher on each polled package before it is published, e.g. to
+// attach the vulnerabilities of the package. Packages which fail to be enriched are still
+// published, compose several enrichers with feeds.Enrichers.
+func WithEnricher(enricher feeds.Enricher) Option {
+	return func(s *Scheduler) {
+		s.enricher = enricher
+	}
+}
+
+// WithSerializer serializes packages with the serializer before they are published, in
+// place of the default JSON serializer.
+func WithSerializer(serializer publisher.Serializer) Option {
+	return func(s *Scheduler) {
+		s.serializer = serializer
+	}
 }
 
 // New returns a new Scheduler with a publisher and feeds configured for polling.
-func New(feedsMap map[string]feeds.ScheduledFeed, pub publisher.Publisher, httpPort int) *Scheduler {
-	return &Scheduler{
+func New(feedsMap map[string]feeds.ScheduledFeed, pub publisher.Publisher, httpPort int, opts ...Option) *Scheduler {
+	s := &Scheduler{
 		registry:  feedsMap,
 		publisher: pub,
 		httpPort:  httpPort,
+
+		recentPackages: DefaultRecentPackages,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-type pollResult struct {
-	name     string
-	feed     feeds.ScheduledFeed
-	packages []*feeds.Package
-	errs     []error
+// A FeedGroup and the cron schedule it is polled on, an empty schedule means the
+// FeedGroup is only polled through HTTP requests.
+type scheduledGroup struct {
+	schedule  string
+	feedGroup *FeedGroup
 }
 
 // Runs several services for the operation of scheduler, this call is blocking until application exit
 // or failure in the HTTP server
 // Services include: Cron polling via FeedGroups, HTTP serving of FeedGroupsHandler.
 func (s *Scheduler) Run(initialCutoff time.Duration, enableDefaultTimer bool) error {
-	defaultSchedule := fmt.Sprintf("@every %s", initialCutoff.String())
-
-	schedules, err := buildSchedules(s.registry, s.publisher, initialCutoff)
+	groups, err := s.prepareGroups(initialCutoff, enableDefaultTimer)
 	if err != nil {
 		return err
 	}
 	feedGroups := []*FeedGroup{}
+	for _, group := range groups {
+		feedGroups = append(feedGroups, group.feedGroup)
+	}
+	if _, err := s.startTimers(groups); err != nil {
+		return err
+	}
+	for _, name := range s.disabledFeeds {
+		log.WithField("feed", name).Print("Feed is disabled, skipping")
+	}
 
-	// Configure cron job for scheduled polling.
-	cronJob := cron.New()
-	for schedule, feedGroup := range schedules {
-		feedGroups = append(feedGroups, feedGroup)
+	// Start http server for polling via HTTP requests
+	pollServer := NewFeedGroupsHandler(feedGroups)
+	log.Infof("Listening on port %v\n", s.httpPort)
+	http.Handle("/", pollServer)
+	http.Handle(feedsPathPrefix, NewFeedPollHandler(feedGroups))
+	if len(feedGroups) > 0 {
+		http.Handle(statusPath, NewStatusHandler(feedGroups[0].status))
+		http.Handle(recentPath, NewRecentHandler(feedGroups[0].recent))
+		http.Handle(atomPath, NewAtomHandler(feedGroups[0].recent))
+	}
+	http.Handle(metricsPath, metrics.Handler())
+	if err := http.ListenAndServe(fmt.Sprintf(":%v", s.httpPort), nil); err != nil {
+		return err
+	}
 
-		// Undefined schedules will follow the default schedule, if the default timer is enabled.
-		if schedule == "" {
-			if !enableDefaultTimer {
-				continue
-			}
-			schedule = defaultSchedule
+	return nil
+}
+
+// Close releases the resources held by the feeds of the scheduler, such as watchers of
+// directories, once they are no longer polled. Every feed is closed even if another fails.
+func (s *Scheduler) Close() error {
+	failed := []string{}
+	for name, feed := range s.registry {
+		if err := feeds.Close(feed); err != nil {
+			log.WithError(err).WithField("feed", name).Error("Failed to close feed")
+			failed = append(failed, name)
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("%w : %v", errCloseFeeds, strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// Starts a timer polling each group with a schedule, groups without a schedule are only
+// polled through HTTP requests. When polling on start, each group with a timer is polled
+// immediately rather than waiting for the first tick.
+func (s *Scheduler) startTimers(groups []scheduledGroup) (*cron.Cron, error) {
+	cronJob := cron.New()
+	timed := []*FeedGroup{}
+	for _, group := range groups {
+		if group.schedule == "" {
+			continue
 		}
 
-		err := cronJob.AddJob(schedule, feedGroup)
+		err := cronJob.AddJob(group.schedule, group.feedGroup)
 		if err != nil {
-			return fmt.Errorf("failed to parse schedule `%s`: %w", schedule, err)
+			return nil, fmt.Errorf("failed to parse schedule `%s`: %w", group.schedule, err)
 		}
+		timed = append(timed, group.feedGroup)
 
 		feedNames := []string{}
-		for _, f := range feedGroup.feeds {
+		for _, f := range group.feedGroup.feeds {
 			feedNames = append(feedNames, f.GetName())
 		}
-		log.Printf("Running a timer for %s with schedule %s", strings.Join(feedNames, ", "), schedule)
+		log.Printf("Running a timer for %s with schedule %s", strings.Join(feedNames, ", "), group.schedule)
+	}
+	if s.pollOnStart {
+		for _, feedGroup := range timed {
+			go feedGroup.Run()
+		}
 	}
 	cronJob.Start()
+	return cronJob, nil
+}
 
-	// Start http server for polling via HTTP requests
-	pollServer := NewFeedGroupsHandler(feedGroups)
-	log.Infof("Listening on port %v\n", s.httpPort)
-	http.Handle("/", pollServer)
-	if err := http.ListenAndServe(fmt.Sprintf(":%v", s.httpPort), nil); err != nil {
-		return err
+// Validate checks the configuration of the scheduler as Run would, without polling any
+// feeds. The schedule of each feed is returned indexed by feed name, feeds with an empty
+// schedule are only polled through HTTP requests.
+func (s *Scheduler) Validate(initialCutoff time.Duration, enableDefaultTimer bool) (map[string]string, error) {
+	groups, err := s.prepareGroups(initialCutoff, enableDefaultTimer)
+	if err != nil {
+		return nil, err
 	}
+	feedSchedules := map[string]string{}
+	for _, group := range groups {
+		for _, f := range group.feedGroup.feeds {
+			feedSchedules[f.GetName()] = group.schedule
+		}
+	}
+	return feedSchedules, nil
+}
 
-	return nil
+// Builds the FeedGroups of the scheduler and resolves their cron schedules, applying the
+// configured options to each FeedGroup.
+func (s *Scheduler) prepareGroups(initialCutoff time.Duration, enableDefaultTimer bool) ([]scheduledGroup, error) {
+	defaultSchedule := fmt.Sprintf("@every %s", initialCutoff.String())
+	if s.jitter < 0 || s.jitter >= 1 {
+		return nil, fmt.Errorf("%w : %v", errInvalidJitter, s.jitter)
+	}
+	if s.recentPackages < 1 || s.recentPackages > MaxRecentPackages {
+		return nil, fmt.Errorf("%w : %v", errInvalidRecentPackages, s.recentPackages)
+	}
+	if s.maxConcurrentFeeds < 0 {
+		return nil, fmt.Errorf("%w : %v", errInvalidMaxConcurrentFeeds, s.maxConcurrentFeeds)
+	}
+	if s.maxLookback < 0 {
+		return nil, fmt.Errorf("%w : %v", errInvalidMaxLookback, s.maxLookback)
+	}
+	maxConcurrentFeeds := s.maxConcurrentFeeds
+	if maxConcurrentFeeds == 0 {
+		maxConcurrentFeeds = len(s.registry)
+	}
+
+	var queue *PublishQueue
+	if s.queueCapacity != 0 {
+		var err error
+		queue, err = NewPublishQueue(s.queueCapacity, s.queuePolicy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	schedules, err := buildSchedules(s.registry, s.publisher, initialCutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	status := NewPollStatus()
+	disabled := []feeds.PollResult{}
+	for _, name := range s.disabledFeeds {
+		disabled = append(disabled, feeds.PollResult{Feed: name, Disabled: true})
+	}
+	status.record(disabled)
+	recent := NewRecentPackages(s.recentPackages)
+	if s.clock != nil {
+		recent.SetClock(s.clock)
+	}
+	limiter := NewPollLimiter(maxConcurrentFeeds)
+	groups := []scheduledGroup{}
+	for schedule, feedGroup := range schedules {
+		feedGroup.SetStatus(status)
+		feedGroup.SetRecentPackages(recent)
+		feedGroup.SetPollLimiter(limiter)
+		feedGroup.SetLabels(s.labels)
+		feedGroup.SetPublishQueue(queue)
+		feedGroup.SetMaxLookback(s.maxLookback)
+		feedGroup.SetCycleSummary(s.cycleSummary)
+		feedGroup.SetOrdered(s.ordered)
+		feedGroup.SetEnricher(s.enricher)
+		if s.serializer != nil {
+			feedGroup.SetSerializer(s.serializer)
+		}
+		if s.clock != nil {
+			feedGroup.SetClock(s.clock)
+		}
+		if s.breakerThreshold > 0 {
+			feedGroup.SetCircuitBreaker(s.breakerThreshold, s.breakerCooldown)
+		}
+
+		// Undefined schedules will follow the default schedule, if the default timer is enabled.
+		if schedule == "" && enableDefaultTimer {
+			schedule = defaultSchedule
+		}
+		if schedule == "" {
+			groups = append(groups, scheduledGroup{feedGroup: feedGroup})
+			continue
+		}
+
+		if _, err := cron.Parse(schedule); err != nil {
+			return nil, fmt.Errorf("failed to parse schedule `%s`: %w", schedule, err)
+		}
+
+		if s.jitter > 0 {
+			interval, err := time.ParseDuration(strings.TrimPrefix(schedule, "@every "))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse schedule `%s` as duration: %w", schedule, err)
+			}
+			feedGroup.SetJitter(time.Duration(s.jitter * float64(interval)))
+		}
+		groups = append(groups, scheduledGroup{schedule: schedule, feedGroup: feedGroup})
+	}
+	return groups, nil
 }
 
 // Prepares a map of FeedGroups indexed by their appropriate cron schedule
@@ -1,6 +1,9 @@
 package scheduler
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -65,3 +68,166 @@ func TestBuildSchedules(t *testing.T) {
 		t.Fatalf("30s schedule contained %v feeds when %v was expected.", len(thirtySecFg.feeds), 2)
 	}
 }
+
+func TestClose(t *testing.T) {
+	t.Parallel()
+
+	var fooClosed, barClosed bool
+	errClose := errors.New("close failure")
+	sched := New(map[string]feeds.ScheduledFeed{
+		"foo": mockClosingFeed{mockFeed: mockFeed{name: "foo"}, closed: &fooClosed, closeErr: errClose},
+		"bar": mockClosingFeed{mockFeed: mockFeed{name: "bar"}, closed: &barClosed},
+		"baz": mockFeed{name: "baz"},
+	}, mockPublisher{}, 8080)
+	err := sched.Close()
+	if !errors.Is(err, errCloseFeeds) {
+		t.Errorf("Close() returned %v when %v was expected", err, errCloseFeeds)
+	}
+	if !fooClosed || !barClosed {
+		t.Errorf("Close() closed foo: %v and bar: %v when every feed should be closed", fooClosed, barClosed)
+	}
+}
+
+func TestRunInvalidJitter(t *testing.T) {
+	t.Parallel()
+
+	s := New(map[string]feeds.ScheduledFeed{}, mockPublisher{}, 0, WithJitter(1.5))
+	err := s.Run(time.Minute, false)
+	if !errors.Is(err, errInvalidJitter) {
+		t.Fatalf("Run() returned `%v` when an invalid jitter error was expected", err)
+	}
+}
+
+func TestRunInvalidRecentPackages(t *testing.T) {
+	t.Parallel()
+
+	s := New(map[string]feeds.ScheduledFeed{}, mockPublisher{}, 0, WithRecentPackages(MaxRecentPackages+1))
+	err := s.Run(time.Minute, false)
+	if !errors.Is(err, errInvalidRecentPackages) {
+		t.Fatalf("Run() returned `%v` when an invalid recent packages error was expected", err)
+	}
+}
+
+func TestRunInvalidMaxConcurrentFeeds(t *testing.T) {
+	t.Parallel()
+
+	s := New(map[string]feeds.ScheduledFeed{}, mockPublisher{}, 0, WithMaxConcurrentFeeds(-1))
+	err := s.Run(time.Minute, false)
+	if !errors.Is(err, errInvalidMaxConcurrentFeeds) {
+		t.Fatalf("Run() returned `%v` when an invalid max concurrent feeds error was expected", err)
+	}
+}
+
+func TestRunInvalidMaxLookback(t *testing.T) {
+	t.Parallel()
+
+	s := New(map[string]feeds.ScheduledFeed{}, mockPublisher{}, 0, WithMaxLookback(-time.Hour))
+	err := s.Run(time.Minute, false)
+	if !errors.Is(err, errInvalidMaxLookback) {
+		t.Fatalf("Run() returned `%v` when an invalid max lookback error was expected", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	scheduledFeeds := map[string]feeds.ScheduledFeed{
+		"Foo": mockFeed{
+			name:    "Foo",
+			options: feeds.FeedOptions{PollRate: "30s"},
+		},
+		"Bar": mockFeed{
+			name: "Bar",
+		},
+	}
+	s := New(scheduledFeeds, mockPublisher{}, 0)
+	feedSchedules, err := s.Validate(time.Minute, true)
+	if err != nil {
+		t.Fatalf("Validate() returned unexpected error: %v", err)
+	}
+	if feedSchedules["Foo"] != "@every 30s" {
+		t.Errorf("Foo has schedule `%v` when `@every 30s` was expected", feedSchedules["Foo"])
+	}
+	if feedSchedules["Bar"] != "@every 1m0s" {
+		t.Errorf("Bar has schedule `%v` when `@every 1m0s` was expected", feedSchedules["Bar"])
+	}
+}
+
+func TestDisabledFeedsStatus(t *testing.T) {
+	t.Parallel()
+
+	scheduledFeeds := map[string]feeds.ScheduledFeed{
+		"Foo": mockFeed{name: "Foo"},
+	}
+	s := New(scheduledFeeds, mockPublisher{}, 0, WithDisabledFeeds("Bar"))
+	groups, err := s.prepareGroups(time.Minute, true)
+	if err != nil {
+		t.Fatalf("prepareGroups() returned unexpected error: %v", err)
+	}
+	if len(groups) != 1 || len(groups[0].feedGroup.feeds) != 1 {
+		t.Fatalf("prepareGroups() returned %v groups when only the enabled feed was expected", len(groups))
+	}
+	results := groups[0].feedGroup.status.Results()
+	if len(results) != 1 || results[0].Feed != "Bar" || !results[0].Disabled {
+		t.Errorf("Status has results %+v when Bar was expected to be reported as disabled", results)
+	}
+}
+
+func TestValidateInvalidPollRate(t *testing.T) {
+	t.Parallel()
+
+	scheduledFeeds := map[string]feeds.ScheduledFeed{
+		"Foo": mockFeed{
+			options: feeds.FeedOptions{PollRate: "every 5 minutes"},
+		},
+	}
+	s := New(scheduledFeeds, mockPublisher{}, 0)
+	if _, err := s.Validate(time.Minute, true); err == nil {
+		t.Fatalf("Validate() did not return an error for an invalid poll rate")
+	}
+}
+
+func TestStartTimersPollOnStart(t *testing.T) {
+	t.Parallel()
+
+	for _, pollOnStart := range []bool{true, false} {
+		pollOnStart := pollOnStart
+		t.Run(fmt.Sprintf("poll_on_start=%v", pollOnStart), func(t *testing.T) {
+			t.Parallel()
+
+			polled := make(chan time.Time, 10)
+			feed := mockFeed{
+				name: "Foo",
+				contextCallback: func(context.Context) {
+					polled <- time.Now()
+				},
+			}
+			opts := []Option{}
+			if pollOnStart {
+				opts = append(opts, WithPollOnStart())
+			}
+			s := New(map[string]feeds.ScheduledFeed{"Foo": feed}, mockPublisher{}, 0, opts...)
+			groups := []scheduledGroup{{
+				schedule:  "@every 2s",
+				feedGroup: NewFeedGroup([]feeds.ScheduledFeed{feed}, mockPublisher{}, time.Second),
+			}}
+			start := time.Now()
+			cronJob, err := s.startTimers(groups)
+			if err != nil {
+				t.Fatalf("startTimers() returned unexpected error: %v", err)
+			}
+			defer cronJob.Stop()
+
+			select {
+			case at := <-polled:
+				// The first tick of the timer is at least a second after it starts.
+				if elapsed := at.Sub(start); pollOnStart != (elapsed < 500*time.Millisecond) {
+					t.Errorf("The feed was first polled %v after starting with poll on start %v",
+						elapsed, pollOnStart)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("The feed wasn't polled within 5s of starting")
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/metrics"
+)
+
+// The maximum number of packages remembered by a seenCache.
+const seenCacheSize = 100000
+
+// seenCache remembers when each package was first seen by a poll, so that packages emitted
+// by several polls keep the time they first appeared. The oldest entries are forgotten once
+// seenCacheSize is exceeded, and entries do not persist across restarts. Hits, misses,
+// evictions and the number of entries are recorded as metrics labelled by feed.
+type seenCache struct {
+	mu        sync.Mutex
+	size      int
+	firstSeen map[string]time.Time
+	// Entries in the order they were first seen, used to evict the oldest entries.
+	order []seenEntry
+	// The number of entries of each feed.
+	entries map[string]int
+}
+
+type seenEntry struct {
+	key  string
+	feed string
+}
+
+func newSeenCache(size int) *seenCache {
+	return &seenCache{size: size, firstSeen: map[string]time.Time{}, entries: map[string]int{}}
+}
+
+// Identifies a package by feed, name and version. Republished versions are distinct from
+// the original publication of the version.
+func seenKey(pkg *feeds.Package) string {
+	return fmt.Sprintf("%s/%s/%s/%t", pkg.Type, pkg.Name, pkg.Version, pkg.Republished)
+}
+
+// Sets the FirstSeen time of each package, to the time it was previously first seen or
+// otherwise to polledAt.
+func (c *seenCache) setFirstSeen(pkgs []*feeds.Package, polledAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, pkg := range pkgs {
+		key := seenKey(pkg)
+		firstSeen, ok := c.firstSeen[key]
+		if ok {
+			metrics.SeenCacheHits.WithLabelValues(pkg.Type).Inc()
+		} else {
+			metrics.SeenCacheMisses.WithLabelValues(pkg.Type).Inc()
+			firstSeen = polledAt
+			c.add(seenEntry{key: key, feed: pkg.Type}, polledAt)
+		}
+		pkg.FirstSeen = &firstSeen
+	}
+}
+
+func (c *seenCache) add(entry seenEntry, t time.Time) {
+	c.firstSeen[entry.key] = t
+	c.order = append(c.order, entry)
+	c.setEntries(entry.feed, c.entries[entry.feed]+1)
+	if len(c.order) > c.size {
+		evicted := c.order[0]
+		delete(c.firstSeen, evicted.key)
+		c.order = c.order[1:]
+		c.setEntries(evicted.feed, c.entries[evicted.feed]-1)
+		metrics.SeenCacheEvictions.WithLabelValues(evicted.feed).Inc()
+	}
+}
+
+func (c *seenCache) setEntries(feed string, n int) {
+	c.entries[feed] = n
+	metrics.SeenCacheEntries.WithLabelValues(feed).Set(float64(n))
+}
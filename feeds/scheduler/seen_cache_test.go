@@ -0,0 +1,95 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/metrics"
+)
+
+func TestSeenCacheKeepsFirstSeen(t *testing.T) {
+	t.Parallel()
+
+	cache := newSeenCache(seenCacheSize)
+	first := time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)
+	second := first.Add(time.Minute)
+
+	pkg := feeds.NewPackage(first.Add(-time.Hour), "foo", "1.0.0", "npm", feeds.EcosystemNPM)
+	cache.setFirstSeen([]*feeds.Package{pkg}, first)
+	if pkg.FirstSeen == nil || !pkg.FirstSeen.Equal(first) {
+		t.Errorf("FirstSeen was set to %v when %v was expected", pkg.FirstSeen, first)
+	}
+
+	again := feeds.NewPackage(first.Add(-time.Hour), "foo", "1.0.0", "npm", feeds.EcosystemNPM)
+	republished := feeds.NewPackage(second, "foo", "1.0.0", "npm", feeds.EcosystemNPM)
+	republished.Republished = true
+	cache.setFirstSeen([]*feeds.Package{again, republished}, second)
+	if again.FirstSeen == nil || !again.FirstSeen.Equal(first) {
+		t.Errorf("FirstSeen of a package seen again was set to %v when %v was expected", again.FirstSeen, first)
+	}
+	if republished.FirstSeen == nil || !republished.FirstSeen.Equal(second) {
+		t.Errorf("FirstSeen of a republished package was set to %v when %v was expected", republished.FirstSeen, second)
+	}
+}
+
+func TestSeenCacheEvictsOldest(t *testing.T) {
+	t.Parallel()
+
+	cache := newSeenCache(2)
+	first := time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)
+	cache.setFirstSeen([]*feeds.Package{
+		feeds.NewPackage(first, "foo", "1.0.0", "npm", ""),
+		feeds.NewPackage(first, "bar", "1.0.0", "npm", ""),
+		feeds.NewPackage(first, "baz", "1.0.0", "npm", ""),
+	}, first)
+	if len(cache.firstSeen) != 2 {
+		t.Fatalf("Cache kept %v packages when 2 were expected", len(cache.firstSeen))
+	}
+
+	later := first.Add(time.Minute)
+	foo := feeds.NewPackage(first, "foo", "1.0.0", "npm", "")
+	cache.setFirstSeen([]*feeds.Package{foo}, later)
+	if !foo.FirstSeen.Equal(later) {
+		t.Errorf("FirstSeen of an evicted package was set to %v when %v was expected", foo.FirstSeen, later)
+	}
+}
+
+func TestSeenCacheMetrics(t *testing.T) {
+	t.Parallel()
+
+	// Packages are of a feed used only by this test, as metrics are shared by all caches.
+	const feed = "seencachemetrics"
+	hits := testutil.ToFloat64(metrics.SeenCacheHits.WithLabelValues(feed))
+	misses := testutil.ToFloat64(metrics.SeenCacheMisses.WithLabelValues(feed))
+	evictions := testutil.ToFloat64(metrics.SeenCacheEvictions.WithLabelValues(feed))
+
+	cache := newSeenCache(2)
+	first := time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)
+	newPackages := func(names ...string) []*feeds.Package {
+		pkgs := []*feeds.Package{}
+		for _, name := range names {
+			pkgs = append(pkgs, feeds.NewPackage(first, name, "1.0.0", feed, ""))
+		}
+		return pkgs
+	}
+	cache.setFirstSeen(newPackages("foo", "bar"), first)
+	cache.setFirstSeen(newPackages("foo", "bar", "baz"), first.Add(time.Minute))
+	// foo was evicted to make space for baz, so it is missed again.
+	cache.setFirstSeen(newPackages("foo"), first.Add(2*time.Minute))
+
+	expected := map[string]float64{"hits": 2, "misses": 4, "evictions": 2, "entries": 2}
+	actual := map[string]float64{
+		"hits":      testutil.ToFloat64(metrics.SeenCacheHits.WithLabelValues(feed)) - hits,
+		"misses":    testutil.ToFloat64(metrics.SeenCacheMisses.WithLabelValues(feed)) - misses,
+		"evictions": testutil.ToFloat64(metrics.SeenCacheEvictions.WithLabelValues(feed)) - evictions,
+		"entries":   testutil.ToFloat64(metrics.SeenCacheEntries.WithLabelValues(feed)),
+	}
+	for name, value := range expected {
+		if actual[name] != value {
+			t.Errorf("Seen cache recorded %v %s when %v was expected", actual[name], name, value)
+		}
+	}
+}
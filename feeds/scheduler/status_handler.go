@@ -0,0 +1,68 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ossf/package-feeds/feeds"
+)
+
+const statusPath = "/status"
+
+// PollStatus records the result of the most recent poll of each feed.
+type PollStatus struct {
+	mu      sync.Mutex
+	results map[string]feeds.PollResult
+}
+
+func NewPollStatus() *PollStatus {
+	return &PollStatus{results: map[string]feeds.PollResult{}}
+}
+
+func (s *PollStatus) record(results []feeds.PollResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, result := range results {
+		s.results[result.Feed] = result
+	}
+}
+
+// Results returns the result of the most recent poll of each feed, ordered by feed name.
+func (s *PollStatus) Results() []feeds.PollResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	results := make([]feeds.PollResult, 0, len(s.results))
+	for _, result := range s.results {
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Feed < results[j].Feed
+	})
+	return results
+}
+
+// StatusHandler serves the result of the most recent poll of each feed through
+// `GET /status`.
+type StatusHandler struct {
+	status *PollStatus
+}
+
+func NewStatusHandler(status *PollStatus) *StatusHandler {
+	return &StatusHandler{status: status}
+}
+
+func (h *StatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.status.Results()); err != nil {
+		log.WithError(err).Error("Failed to write poll status")
+	}
+}
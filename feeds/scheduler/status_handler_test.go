@@ -0,0 +1,81 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+)
+
+var errStatus = errors.New("status error")
+
+func TestStatusHandler(t *testing.T) {
+	t.Parallel()
+
+	pub := mockPublisher{sendCallback: func(msg string) error { return nil }}
+	feedGroup := NewFeedGroup([]feeds.ScheduledFeed{
+		mockFeed{
+			name: "foo",
+			packages: []*feeds.Package{
+				{Name: "Foo"},
+				{Name: "Bar"},
+			},
+		},
+		mockFeed{
+			name: "bar",
+			errs: []error{errStatus},
+		},
+	}, pub, time.Minute)
+	feedGroup.pollAndPublish(0)
+	handler := NewStatusHandler(feedGroup.status)
+
+	req := httptest.NewRequest(http.MethodGet, statusPath, nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Status request returned status %v when %v was expected", rec.Code, http.StatusOK)
+	}
+	results := []struct {
+		Feed        string   `json:"feed"`
+		NumPackages int      `json:"num_packages"`
+		Errors      []string `json:"errors"`
+		Duration    string   `json:"duration"`
+	}{}
+	if err := json.NewDecoder(rec.Body).Decode(&results); err != nil {
+		t.Fatalf("Failed to decode poll status: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Status reported %v feeds when 2 were expected", len(results))
+	}
+	if results[0].Feed != "bar" || results[1].Feed != "foo" {
+		t.Errorf("Status reported feeds %v and %v when bar and foo were expected", results[0].Feed, results[1].Feed)
+	}
+	if len(results[0].Errors) != 1 || results[0].Errors[0] != errStatus.Error() {
+		t.Errorf("Status reported errors %v for bar when `%v` was expected", results[0].Errors, errStatus)
+	}
+	if results[1].NumPackages != 2 || len(results[1].Errors) != 0 {
+		t.Errorf("Status reported %v packages and %v errors for foo when 2 packages and no errors were expected",
+			results[1].NumPackages, len(results[1].Errors))
+	}
+	if _, err := time.ParseDuration(results[1].Duration); err != nil {
+		t.Errorf("Status reported an invalid duration `%v`: %v", results[1].Duration, err)
+	}
+}
+
+func TestStatusHandlerMethodNotAllowed(t *testing.T) {
+	t.Parallel()
+
+	handler := NewStatusHandler(NewPollStatus())
+	req := httptest.NewRequest(http.MethodPost, statusPath, nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Status request returned status %v when %v was expected", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,60 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/ossf/package-feeds/feeds"
+)
+
+func TestFeedGroupTracing(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	mockFeeds := []feeds.ScheduledFeed{
+		mockFeed{
+			name: "tracedFeed",
+			packages: []*feeds.Package{
+				{Name: "Foo", Type: "tracedFeed"},
+				{Name: "Bar", Type: "tracedFeed"},
+			},
+		},
+	}
+	feedGroup := NewFeedGroup(mockFeeds, mockPublisher{}, time.Minute)
+	if result := feedGroup.pollAndPublish(0); result.pollErr != nil || result.pubErr != nil {
+		t.Fatalf("Unexpected errors polling and publishing: %v, %v", result.pollErr, result.pubErr)
+	}
+
+	numPolls, numPublishes := 0, 0
+	for _, span := range recorder.Ended() {
+		attrs := map[attribute.Key]attribute.Value{}
+		for _, kv := range span.Attributes() {
+			attrs[kv.Key] = kv.Value
+		}
+		if attrs["feed.name"].AsString() != "tracedFeed" {
+			continue
+		}
+		switch span.Name() {
+		case "poll":
+			numPolls++
+			if count := attrs["feed.package_count"].AsInt64(); count != 2 {
+				t.Errorf("Poll span has package count %v when 2 was expected", count)
+			}
+		case "publish":
+			numPublishes++
+		}
+	}
+	if numPolls != 1 {
+		t.Errorf("Recorded %v poll spans when 1 was expected", numPolls)
+	}
+	if numPublishes != 2 {
+		t.Errorf("Recorded %v publish spans when 2 was expected", numPublishes)
+	}
+}
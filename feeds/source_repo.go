@@ -0,0 +1,58 @@
+package feeds
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Hosts of the shorthand repositories accepted by npm, e.g. `github:user/repo`.
+var sourceRepoShorthands = map[string]string{
+	"github":    "github.com",
+	"gitlab":    "gitlab.com",
+	"bitbucket": "bitbucket.org",
+}
+
+// NormalizeSourceRepo converts a source repository reference as given by a registry into an
+// https URL, e.g. `git+ssh://git@github.com/user/repo.git` becomes `https://github.com/user/repo`.
+// SCP-like references such as `git@github.com:user/repo.git`, and npm shorthands such as
+// `github:user/repo` or `user/repo`, are also accepted. Returns an empty string if the
+// reference can't be converted.
+func NormalizeSourceRepo(repo string) string {
+	repo = strings.TrimSpace(repo)
+	repo = strings.TrimPrefix(repo, "git+")
+	if repo == "" {
+		return ""
+	}
+
+	if !strings.Contains(repo, "://") {
+		if i := strings.Index(repo, ":"); i >= 0 {
+			prefix, path := repo[:i], repo[i+1:]
+			if host, ok := sourceRepoShorthands[prefix]; ok {
+				repo = "https://" + host + "/" + path
+			} else {
+				// SCP-like references, e.g. "git@github.com:user/repo".
+				repo = "ssh://" + prefix + "/" + path
+			}
+		} else if strings.Count(repo, "/") == 1 {
+			repo = "https://github.com/" + repo
+		} else {
+			return ""
+		}
+	}
+
+	u, err := url.Parse(repo)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	switch u.Scheme {
+	case "http", "https", "git", "ssh":
+	default:
+		return ""
+	}
+	path := strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), ".git")
+	if path == "" {
+		return ""
+	}
+	normalized := url.URL{Scheme: "https", Host: u.Hostname(), Path: path}
+	return normalized.String()
+}
@@ -0,0 +1,32 @@
+package feeds
+
+import "testing"
+
+func TestNormalizeSourceRepo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		repo     string
+		expected string
+	}{
+		{"https://github.com/foo/bar", "https://github.com/foo/bar"},
+		{"git+https://github.com/foo/bar.git", "https://github.com/foo/bar"},
+		{"http://github.com/foo/bar/", "https://github.com/foo/bar"},
+		{"git://github.com/foo/bar.git", "https://github.com/foo/bar"},
+		{"git+ssh://git@github.com/foo/bar.git", "https://github.com/foo/bar"},
+		{"ssh://git@gitlab.example.com:2222/group/bar.git", "https://gitlab.example.com/group/bar"},
+		{"git@github.com:foo/bar.git", "https://github.com/foo/bar"},
+		{"github:foo/bar", "https://github.com/foo/bar"},
+		{"gitlab:foo/bar", "https://gitlab.com/foo/bar"},
+		{"foo/bar", "https://github.com/foo/bar"},
+		{"", ""},
+		{"bar", ""},
+		{"file:///tmp/bar", ""},
+		{"https://github.com", ""},
+	}
+	for _, test := range tests {
+		if repo := NormalizeSourceRepo(test.repo); repo != test.expected {
+			t.Errorf("NormalizeSourceRepo(%q) returned %q when %q was expected", test.repo, repo, test.expected)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package swiftpackageindex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ossf/package-feeds/events"
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/utils"
+)
+
+const (
+	FeedName           = "swiftpackageindex"
+	releasesPathFormat = "/repos/%s/releases?per_page=%d&page=%d"
+	releasesPerPage    = 100
+	// The most pages of releases fetched for a package in a single poll.
+	maxReleasePages   = 10
+	githubTokenEnvVar = "GITHUB_TOKEN"
+)
+
+var (
+	httpClient = utils.NewHTTPClient(10 * time.Second)
+
+	errPackagesRequired   = errors.New("the swiftpackageindex feed requires packages, a global feed is not available")
+	errInvalidPackageName = errors.New("invalid package name, expected owner/repository")
+
+	// Swift Package Manager resolves versions from semantic version tags, optionally prefixed with "v".
+	semverTagRegex = regexp.MustCompile(`^v?\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+)
+
+// A GitHub release, listed newest first. Drafts have no publish date.
+type Release struct {
+	TagName     string    `json:"tag_name"`
+	Draft       bool      `json:"draft"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+type Feed struct {
+	packages *[]string
+	baseURL  string
+	token    string
+	options  feeds.FeedOptions
+}
+
+func init() { //nolint:gochecknoinits
+	feeds.Register(FeedName, func(options feeds.FeedOptions, _ *events.Handler) (feeds.ScheduledFeed, error) {
+		return New(options)
+	})
+}
+
+func New(feedOptions feeds.FeedOptions) (*Feed, error) {
+	if feedOptions.Packages == nil {
+		return nil, errPackagesRequired
+	}
+	for _, pkg := range *feedOptions.Packages {
+		if _, err := repository(pkg); err != nil {
+			return nil, err
+		}
+	}
+	token := feedOptions.RegistryToken
+	if token == "" {
+		token = os.Getenv(githubTokenEnvVar)
+	}
+	return &Feed{
+		packages: feedOptions.Packages,
+		baseURL:  "https://api.github.com/",
+		token:    token,
+		options:  feedOptions,
+	}, nil
+}
+
+// Returns the GitHub repository of a package, packages are identified by owner/repository as on
+// the Swift Package Index, but full GitHub URLs are also accepted.
+func repository(pkg string) (string, error) {
+	repo := strings.TrimPrefix(pkg, "https://")
+	repo = strings.TrimPrefix(repo, "github.com/")
+	repo = strings.TrimSuffix(repo, ".git")
+	parts := strings.Split(repo, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("%w : %v", errInvalidPackageName, pkg)
+	}
+	return repo, nil
+}
+
+func (feed *Feed) get(ctx context.Context, path string, out interface{}) error {
+	reqURL := strings.TrimSuffix(feed.baseURL, "/") + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	if feed.token != "" {
+		req.Header.Set("Authorization", "token "+feed.token)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	err = utils.CheckResponseStatus(resp)
+	if err != nil {
+		return fmt.Errorf("failed to fetch github data: %w", err)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Fetches the releases of a package with a semantic version tag published after the cutoff,
+// paging through the releases until one published before the cutoff is found.
+func (feed *Feed) fetchPackage(ctx context.Context, pkg string, cutoff time.Time) ([]*feeds.Package, error) {
+	repo, err := repository(pkg)
+	if err != nil {
+		return nil, err
+	}
+	pkgs := []*feeds.Package{}
+	for page := 1; page <= maxReleasePages; page++ {
+		releases := []*Release{}
+		err = feed.get(ctx, fmt.Sprintf(releasesPathFormat, repo, releasesPerPage, page), &releases)
+		if err != nil {
+			return nil, err
+		}
+		reachedCutoff := false
+		for _, release := range releases {
+			if release.Draft || release.PublishedAt.IsZero() {
+				continue
+			}
+			if release.PublishedAt.Before(cutoff) {
+				reachedCutoff = true
+			}
+			if !semverTagRegex.MatchString(release.TagName) {
+				continue
+			}
+			pkgs = append(pkgs, feeds.NewPackage(release.PublishedAt, pkg, release.TagName, FeedName,
+				feeds.EcosystemSwiftURL))
+		}
+		if reachedCutoff || len(releases) < releasesPerPage {
+			break
+		}
+	}
+	return pkgs, nil
+}
+
+func (feed *Feed) Latest(ctx context.Context, cutoff time.Time) ([]*feeds.Package, []error) {
+	type result struct {
+		pkgs []*feeds.Package
+		err  error
+	}
+	results := make(chan result)
+	for _, pkg := range *feed.packages {
+		go func(pkg string) {
+			pkgs, err := feed.fetchPackage(ctx, pkg, cutoff)
+			if err != nil {
+				err = feeds.PackagePollError{Name: pkg, Err: err}
+			}
+			results <- result{pkgs: pkgs, err: err}
+		}(pkg)
+	}
+
+	pkgs := []*feeds.Package{}
+	errs := []error{}
+	for i := 0; i < len(*feed.packages); i++ {
+		r := <-results
+		if r.err != nil {
+			errs = append(errs, r.err)
+			continue
+		}
+		pkgs = append(pkgs, r.pkgs...)
+	}
+	if len(pkgs) == 0 && len(errs) > 0 {
+		return nil, append(errs, feeds.ErrNoPackagesPolled)
+	}
+	return feeds.ApplyCutoff(pkgs, cutoff), errs
+}
+
+func (feed *Feed) GetName() string {
+	return FeedName
+}
+
+func (feed *Feed) GetFeedOptions() feeds.FeedOptions {
+	return feed.options
+}
+
+func (feed *Feed) GetBaseURL() string {
+	return feed.baseURL
+}
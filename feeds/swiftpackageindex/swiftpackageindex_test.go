@@ -0,0 +1,164 @@
+package swiftpackageindex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+	testutils "github.com/ossf/package-feeds/utils/test"
+)
+
+func TestSwiftPackageIndexLatest(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/repos/apple/swift-foo/releases": func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "token s3cret" {
+				http.Error(w, "missing token", http.StatusUnauthorized)
+				return
+			}
+			writeResponse(w, `[
+				{"tag_name": "1.2.0", "draft": true, "published_at": null},
+				{"tag_name": "1.1.0", "published_at": "2021-05-01T10:00:00Z"},
+				{"tag_name": "nightly", "published_at": "2021-04-15T10:00:00Z"},
+				{"tag_name": "v1.0.0", "published_at": "2021-03-01T10:00:00Z"}
+			]`)
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	packages := []string{"apple/swift-foo"}
+	feed, err := New(feeds.FeedOptions{Packages: &packages, RegistryToken: "s3cret"})
+	if err != nil {
+		t.Fatalf("Failed to create swiftpackageindex feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	cutoff := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	if len(pkgs) != 1 {
+		t.Fatalf("Latest() produced %v packages instead of the expected 1", len(pkgs))
+	}
+	if pkgs[0].Name != "apple/swift-foo" || pkgs[0].Version != "1.1.0" {
+		t.Errorf("Unexpected package %s@%s found in place of apple/swift-foo@1.1.0", pkgs[0].Name, pkgs[0].Version)
+	}
+	if pkgs[0].Ecosystem != feeds.EcosystemSwiftURL {
+		t.Errorf("Unexpected ecosystem %s found in place of %s", pkgs[0].Ecosystem, feeds.EcosystemSwiftURL)
+	}
+	expectedCreated := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+	if !pkgs[0].CreatedDate.Equal(expectedCreated) {
+		t.Errorf("Package created date %v does not match expected %v", pkgs[0].CreatedDate, expectedCreated)
+	}
+}
+
+func TestSwiftPackageIndexLatestPaging(t *testing.T) {
+	t.Parallel()
+
+	newest := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+	pages := []string{}
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/repos/apple/swift-foo/releases": func(w http.ResponseWriter, r *http.Request) {
+			page, err := strconv.Atoi(r.URL.Query().Get("page"))
+			if err != nil {
+				http.Error(w, "invalid page", http.StatusBadRequest)
+				return
+			}
+			pages = append(pages, r.URL.Query().Get("page"))
+			// Full pages of releases published a day apart, newest first.
+			releases := []string{}
+			for i := 0; i < releasesPerPage; i++ {
+				n := (page-1)*releasesPerPage + i
+				releases = append(releases, fmt.Sprintf(`{"tag_name": "1.0.%d", "published_at": %q}`,
+					1000-n, newest.Add(-time.Duration(n)*24*time.Hour).Format(time.RFC3339)))
+			}
+			writeResponse(w, "["+strings.Join(releases, ",")+"]")
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	packages := []string{"apple/swift-foo"}
+	feed, err := New(feeds.FeedOptions{Packages: &packages})
+	if err != nil {
+		t.Fatalf("Failed to create swiftpackageindex feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	// Paging stops at the page holding the cutoff.
+	cutoff := newest.Add(-150 * 24 * time.Hour)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 0 {
+		t.Fatalf("feed.Latest returned error: %v", errs[len(errs)-1])
+	}
+	if len(pages) != 2 {
+		t.Errorf("%v pages of releases were requested when 2 were expected", len(pages))
+	}
+	if len(pkgs) != 151 {
+		t.Errorf("Latest() produced %v packages instead of the expected 151", len(pkgs))
+	}
+}
+
+func TestSwiftPackageIndexPackagesRequired(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(feeds.FeedOptions{})
+	if !errors.Is(err, errPackagesRequired) {
+		t.Fatalf("Expected errPackagesRequired creating a feed without packages, got: %v", err)
+	}
+}
+
+func TestSwiftPackageIndexInvalidPackage(t *testing.T) {
+	t.Parallel()
+
+	packages := []string{"swift-foo"}
+	_, err := New(feeds.FeedOptions{Packages: &packages})
+	if !errors.Is(err, errInvalidPackageName) {
+		t.Fatalf("Expected errInvalidPackageName creating a feed with an invalid package, got: %v", err)
+	}
+}
+
+func TestSwiftPackageIndexNotFound(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/repos/apple/swift-foo/releases": testutils.NotFoundHandlerFunc,
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	packages := []string{"apple/swift-foo"}
+	feed, err := New(feeds.FeedOptions{Packages: &packages})
+	if err != nil {
+		t.Fatalf("Failed to create swiftpackageindex feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	_, errs := feed.Latest(context.Background(), time.Time{})
+	if len(errs) != 2 {
+		t.Fatalf("Latest() returned %v errors instead of the expected 2", len(errs))
+	}
+	var pollErr feeds.PackagePollError
+	if !errors.As(errs[0], &pollErr) || pollErr.Name != "apple/swift-foo" {
+		t.Errorf("Expected a feeds.PackagePollError for apple/swift-foo, got: %v", errs[0])
+	}
+	if !strings.Contains(errs[0].Error(), "404") {
+		t.Errorf("Failed to wrap expected 404 error in feeds.PackagePollError, instead: %v", errs[0])
+	}
+	if !errors.Is(errs[1], feeds.ErrNoPackagesPolled) {
+		t.Errorf("Expected feeds.ErrNoPackagesPolled, got: %v", errs[1])
+	}
+}
+
+func writeResponse(w http.ResponseWriter, body string) {
+	_, err := w.Write([]byte(body))
+	if err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,356 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ossf/package-feeds/events"
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/utils"
+)
+
+const (
+	FeedName = "terraform"
+
+	modulesKind   = "modules"
+	providersKind = "providers"
+
+	// The number of modules or providers requested per page of a namespace, and the maximum
+	// number of pages requested for a namespace by a single poll.
+	namespacePageSize = 100
+	maxNamespacePages = 10
+	// The maximum number of versions of a module or provider whose details are requested by
+	// a single poll, the versions API doesn't include when versions were published.
+	maxVersionsPerPoll = 20
+)
+
+var (
+	httpClient = utils.NewHTTPClient(10 * time.Second)
+
+	errMissingPackages = errors.New("packages must be provided for the terraform feed, " +
+		"the Terraform Registry does not offer a feed of all published versions")
+	errInvalidPackage = errors.New("invalid terraform package, expected modules/{namespace}[/{name}/{provider}] " +
+		"or providers/{namespace}[/{type}]")
+)
+
+// A module or provider version as returned by the registry, the id is formatted as
+// "{namespace}/{name}/{provider}/{version}" for modules and "{namespace}/{type}/{version}"
+// for providers.
+type versionDetails struct {
+	ID          string    `json:"id"`
+	Version     string    `json:"version"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// The name of the module or provider of the version, its id without the version.
+func (v *versionDetails) name() string {
+	return strings.TrimSuffix(v.ID, "/"+v.Version)
+}
+
+type namespaceResponse struct {
+	Meta struct {
+		NextOffset *int `json:"next_offset"`
+	} `json:"meta"`
+	Modules   []*versionDetails `json:"modules"`
+	Providers []*versionDetails `json:"providers"`
+}
+
+type moduleVersionsResponse struct {
+	Modules []struct {
+		Versions []struct {
+			Version string `json:"version"`
+		} `json:"versions"`
+	} `json:"modules"`
+}
+
+type providerVersionsResponse struct {
+	Versions []struct {
+		Version string `json:"version"`
+	} `json:"versions"`
+}
+
+// A configured package, either a namespace or a single module or provider, e.g.
+// "modules/hashicorp/consul/aws" or "providers/hashicorp".
+type target struct {
+	kind string
+	path string
+}
+
+func (t target) isNamespace() bool {
+	return !strings.Contains(t.path, "/")
+}
+
+func parseTarget(pkg string) (target, error) {
+	parts := strings.Split(pkg, "/")
+	for _, part := range parts {
+		if part == "" {
+			return target{}, fmt.Errorf("%w : %v", errInvalidPackage, pkg)
+		}
+	}
+	switch {
+	case parts[0] == modulesKind && (len(parts) == 2 || len(parts) == 4):
+	case parts[0] == providersKind && (len(parts) == 2 || len(parts) == 3):
+	default:
+		return target{}, fmt.Errorf("%w : %v", errInvalidPackage, pkg)
+	}
+	return target{kind: parts[0], path: strings.Join(parts[1:], "/")}, nil
+}
+
+type Feed struct {
+	baseURL  string
+	targets  []target
+	packages *[]string
+	options  feeds.FeedOptions
+}
+
+func init() { //nolint:gochecknoinits
+	feeds.Register(FeedName, func(options feeds.FeedOptions, _ *events.Handler) (feeds.ScheduledFeed, error) {
+		return New(options)
+	})
+}
+
+func New(feedOptions feeds.FeedOptions) (*Feed, error) {
+	if feedOptions.Packages == nil || len(*feedOptions.Packages) == 0 {
+		return nil, errMissingPackages
+	}
+	targets := []target{}
+	for _, pkg := range *feedOptions.Packages {
+		t, err := parseTarget(pkg)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+	return &Feed{
+		baseURL:  "https://registry.terraform.io",
+		targets:  targets,
+		packages: feedOptions.Packages,
+		options:  feedOptions,
+	}, nil
+}
+
+func (feed *Feed) getJSON(ctx context.Context, path string, query url.Values, v interface{}) error {
+	reqURL, err := utils.URLPathJoin(feed.baseURL, "/v1", path)
+	if err != nil {
+		return err
+	}
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	resp, err := utils.Get(ctx, httpClient, reqURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	err = utils.CheckResponseStatus(resp)
+	if err != nil {
+		return fmt.Errorf("failed to fetch terraform registry data: %w", err)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// Fetches the latest version of each module or provider in a namespace.
+func (feed *Feed) fetchNamespace(ctx context.Context, t target) ([]*versionDetails, error) {
+	versions := []*versionDetails{}
+	offset := 0
+	for page := 0; page < maxNamespacePages; page++ {
+		query := url.Values{}
+		query.Set("limit", strconv.Itoa(namespacePageSize))
+		query.Set("offset", strconv.Itoa(offset))
+		resp := &namespaceResponse{}
+		if err := feed.getJSON(ctx, t.kind+"/"+t.path, query, resp); err != nil {
+			return nil, err
+		}
+		versions = append(versions, resp.Modules...)
+		versions = append(versions, resp.Providers...)
+		if resp.Meta.NextOffset == nil {
+			break
+		}
+		offset = *resp.Meta.NextOffset
+	}
+	return versions, nil
+}
+
+// Fetches the versions of a module or provider published after the cutoff. The details of
+// versions are requested most recent first, stopping at the first version published before
+// the cutoff.
+func (feed *Feed) fetchVersions(ctx context.Context, t target, cutoff time.Time) ([]*versionDetails, error) {
+	versions := []string{}
+	if t.kind == modulesKind {
+		resp := &moduleVersionsResponse{}
+		if err := feed.getJSON(ctx, t.kind+"/"+t.path+"/versions", nil, resp); err != nil {
+			return nil, err
+		}
+		for _, module := range resp.Modules {
+			for _, v := range module.Versions {
+				versions = append(versions, v.Version)
+			}
+		}
+	} else {
+		resp := &providerVersionsResponse{}
+		if err := feed.getJSON(ctx, t.kind+"/"+t.path+"/versions", nil, resp); err != nil {
+			return nil, err
+		}
+		for _, v := range resp.Versions {
+			versions = append(versions, v.Version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return compareVersions(versions[i], versions[j]) > 0
+	})
+
+	details := []*versionDetails{}
+	for i, version := range versions {
+		if i == maxVersionsPerPoll {
+			break
+		}
+		v := &versionDetails{}
+		if err := feed.getJSON(ctx, t.kind+"/"+t.path+"/"+version, nil, v); err != nil {
+			return nil, err
+		}
+		details = append(details, v)
+		if v.PublishedAt.Before(cutoff) {
+			break
+		}
+	}
+	return details, nil
+}
+
+func (feed *Feed) fetchTarget(ctx context.Context, t target, cutoff time.Time) ([]*versionDetails, error) {
+	if t.isNamespace() {
+		return feed.fetchNamespace(ctx, t)
+	}
+	return feed.fetchVersions(ctx, t, cutoff)
+}
+
+func (feed *Feed) Latest(ctx context.Context, cutoff time.Time) ([]*feeds.Package, []error) {
+	type result struct {
+		versions []*versionDetails
+		err      error
+	}
+	results := make(chan result)
+	for i, t := range feed.targets {
+		go func(name string, t target) {
+			versions, err := feed.fetchTarget(ctx, t, cutoff)
+			if err != nil {
+				err = feeds.PackagePollError{Name: name, Err: err}
+			}
+			results <- result{versions: versions, err: err}
+		}((*feed.packages)[i], t)
+	}
+
+	pkgs := []*feeds.Package{}
+	errs := []error{}
+	for range feed.targets {
+		r := <-results
+		if r.err != nil {
+			errs = append(errs, r.err)
+			continue
+		}
+		for _, v := range r.versions {
+			pkgs = append(pkgs, feeds.NewPackage(v.PublishedAt, v.name(), v.Version, FeedName, ""))
+		}
+	}
+	if len(pkgs) == 0 && len(errs) > 0 {
+		return nil, append(errs, feeds.ErrNoPackagesPolled)
+	}
+	// Packages polled concurrently are returned in a consistent order.
+	feeds.SortByCreatedDate(pkgs)
+	return feeds.ApplyCutoff(pkgs, cutoff), errs
+}
+
+func (feed *Feed) GetName() string {
+	return FeedName
+}
+
+func (feed *Feed) GetFeedOptions() feeds.FeedOptions {
+	return feed.options
+}
+
+func (feed *Feed) GetBaseURL() string {
+	return feed.baseURL
+}
+
+// Compares semantic versions, e.g. "1.2.0" and "1.10.0-beta.1", returning a positive number
+// if a is greater than b, a negative number if a is less than b, or zero if they are equal.
+// Build metadata is ignored and a missing minor or patch version is treated as zero.
+func compareVersions(a, b string) int {
+	aRelease, aPre := splitVersion(a)
+	bRelease, bPre := splitVersion(b)
+	for i := 0; i < len(aRelease) || i < len(bRelease); i++ {
+		if c := compareIdentifiers(versionPart(aRelease, i), versionPart(bRelease, i)); c != 0 {
+			return c
+		}
+	}
+	// A pre-release version is less than the release it precedes.
+	switch {
+	case len(aPre) == 0 && len(bPre) == 0:
+		return 0
+	case len(aPre) == 0:
+		return 1
+	case len(bPre) == 0:
+		return -1
+	}
+	for i := 0; i < len(aPre) && i < len(bPre); i++ {
+		if c := compareIdentifiers(aPre[i], bPre[i]); c != 0 {
+			return c
+		}
+	}
+	return len(aPre) - len(bPre)
+}
+
+func splitVersion(version string) ([]string, []string) {
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.Index(version, "+"); i >= 0 {
+		version = version[:i]
+	}
+	var pre []string
+	if i := strings.Index(version, "-"); i >= 0 {
+		pre = strings.Split(version[i+1:], ".")
+		version = version[:i]
+	}
+	return strings.Split(version, "."), pre
+}
+
+func versionPart(parts []string, i int) string {
+	if i < len(parts) {
+		return parts[i]
+	}
+	return "0"
+}
+
+// Numeric identifiers are compared numerically and have lower precedence than
+// alphanumeric identifiers, which are compared lexically.
+func compareIdentifiers(a, b string) int {
+	aNum, aErr := strconv.ParseUint(a, 10, 64)
+	bNum, bErr := strconv.ParseUint(b, 10, 64)
+	switch {
+	case aErr == nil && bErr == nil:
+		if aNum == bNum {
+			return 0
+		}
+		if aNum > bNum {
+			return 1
+		}
+		return -1
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+	if a == b {
+		return 0
+	}
+	if a > b {
+		return 1
+	}
+	return -1
+}
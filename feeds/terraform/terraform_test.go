@@ -0,0 +1,203 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/utils"
+	testutils "github.com/ossf/package-feeds/utils/test"
+)
+
+var baseTime = time.Date(2021, 5, 11, 12, 0, 0, 0, time.UTC)
+
+// The versions of the consul module and the aws provider, and how long before the base
+// time each was published.
+var publishedBefore = map[string]time.Duration{
+	"0.10.0":       0,
+	"0.9.0":        time.Hour,
+	"0.2.0":        2 * time.Hour,
+	"0.1.0":        3 * time.Hour,
+	"3.0.0":        30 * time.Minute,
+	"3.0.0-beta.1": 2 * time.Hour,
+	"2.70.0":       3 * time.Hour,
+}
+
+func TestTerraformLatest(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	detailsRequested := map[string]bool{}
+	details := func(id, version string) testutils.HTTPHandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			detailsRequested[id+"/"+version] = true
+			mu.Unlock()
+			writeJSON(w, versionDetails{
+				ID:          id + "/" + version,
+				Version:     version,
+				PublishedAt: baseTime.Add(-publishedBefore[version]),
+			})
+		}
+	}
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/v1/modules/hashicorp/consul/aws/versions": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{
+				"modules": []interface{}{map[string]interface{}{
+					"source": "hashicorp/consul/aws",
+					"versions": []interface{}{
+						map[string]string{"version": "0.1.0"},
+						map[string]string{"version": "0.2.0"},
+						map[string]string{"version": "0.10.0"},
+						map[string]string{"version": "0.9.0"},
+					},
+				}},
+			})
+		},
+		"/v1/providers/hashicorp/aws/versions": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{
+				"id": "hashicorp/aws",
+				"versions": []interface{}{
+					map[string]string{"version": "2.70.0"},
+					map[string]string{"version": "3.0.0-beta.1"},
+					map[string]string{"version": "3.0.0"},
+				},
+			})
+		},
+		"/v1/modules/acme": func(w http.ResponseWriter, r *http.Request) {
+			// Two pages of modules, the second module of the first page is too old.
+			page := map[string]interface{}{
+				"meta": map[string]interface{}{"limit": namespacePageSize, "current_offset": 0, "next_offset": 2},
+				"modules": []versionDetails{
+					{ID: "acme/foo/aws/1.0.0", Version: "1.0.0", PublishedAt: baseTime},
+					{ID: "acme/bar/aws/1.0.0", Version: "1.0.0", PublishedAt: baseTime.Add(-24 * time.Hour)},
+				},
+			}
+			if r.URL.Query().Get("offset") == "2" {
+				page = map[string]interface{}{
+					"meta": map[string]interface{}{"limit": namespacePageSize, "current_offset": 2},
+					"modules": []versionDetails{
+						{ID: "acme/baz/google/2.0.0", Version: "2.0.0", PublishedAt: baseTime.Add(-time.Minute)},
+					},
+				}
+			}
+			writeJSON(w, page)
+		},
+	}
+	for version := range publishedBefore {
+		if strings.HasPrefix(version, "0.") {
+			handlers["/v1/modules/hashicorp/consul/aws/"+version] = details("hashicorp/consul/aws", version)
+		} else {
+			handlers["/v1/providers/hashicorp/aws/"+version] = details("hashicorp/aws", version)
+		}
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	packages := []string{
+		"modules/hashicorp/consul/aws",
+		"providers/hashicorp/aws",
+		"modules/acme",
+		"modules/missing/foo/aws",
+	}
+	feed, err := New(feeds.FeedOptions{Packages: &packages})
+	if err != nil {
+		t.Fatalf("Failed to create terraform feed: %v", err)
+	}
+	feed.baseURL = srv.URL
+
+	cutoff := baseTime.Add(-90 * time.Minute)
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	if len(errs) != 1 {
+		t.Fatalf("feed.Latest returned %v errors when 1 was expected", len(errs))
+	}
+	var pollErr feeds.PackagePollError
+	isNotFound := errors.As(errs[0], &pollErr) && errors.Is(pollErr.Err, utils.ErrUnsuccessfulRequest)
+	if !isNotFound || pollErr.Name != "modules/missing/foo/aws" {
+		t.Errorf("feed.Latest returned `%v` when a poll error for the missing module was expected", errs[0])
+	}
+
+	expected := []string{
+		"acme/foo/aws@1.0.0",
+		"hashicorp/consul/aws@0.10.0",
+		"acme/baz/google@2.0.0",
+		"hashicorp/aws@3.0.0",
+		"hashicorp/consul/aws@0.9.0",
+	}
+	if len(pkgs) != len(expected) {
+		t.Fatalf("Latest() produced %v packages instead of the expected %v", len(pkgs), len(expected))
+	}
+	for i, pkg := range pkgs {
+		if name := fmt.Sprintf("%s@%s", pkg.Name, pkg.Version); name != expected[i] {
+			t.Errorf("Unexpected package %s found in place of %s", name, expected[i])
+		}
+		if pkg.Type != FeedName {
+			t.Errorf("Feed type not set correctly in terraform package following Latest()")
+		}
+	}
+
+	// Details are requested most recent version first, until a version published before the
+	// cutoff is found.
+	mu.Lock()
+	defer mu.Unlock()
+	if detailsRequested["hashicorp/consul/aws/0.1.0"] || !detailsRequested["hashicorp/consul/aws/0.2.0"] {
+		t.Errorf("Module details were requested for %v when 0.10.0, 0.9.0 and 0.2.0 were expected", detailsRequested)
+	}
+	if detailsRequested["hashicorp/aws/2.70.0"] {
+		t.Errorf("Provider details were requested for %v when 3.0.0 and 3.0.0-beta.1 were expected", detailsRequested)
+	}
+}
+
+func TestTerraformRequiredPackages(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(feeds.FeedOptions{})
+	if !errors.Is(err, errMissingPackages) {
+		t.Errorf("New() returned `%v` when a missing packages error was expected", err)
+	}
+	for _, pkg := range []string{"hashicorp/consul/aws", "modules/hashicorp/consul", "providers/hashicorp/aws/3.0.0",
+		"modules//consul/aws"} {
+		packages := []string{pkg}
+		_, err := New(feeds.FeedOptions{Packages: &packages})
+		if !errors.Is(err, errInvalidPackage) {
+			t.Errorf("New() returned `%v` for %q when an invalid package error was expected", err, pkg)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.10.0", "1.9.0", 1},
+		{"v1.2.3", "1.2.4", -1},
+		{"1.0.0", "1.0.0-rc.1", 1},
+		{"1.0.0-beta.2", "1.0.0-beta.10", -1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-1", "1.0.0-alpha", -1},
+		{"1.0", "1.0.0+build.1", 0},
+	}
+	for _, test := range tests {
+		c := compareVersions(test.a, test.b)
+		if (c > 0) != (test.expected > 0) || (c < 0) != (test.expected < 0) {
+			t.Errorf("compareVersions(%q, %q) returned %v when %v was expected", test.a, test.b, c, test.expected)
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,123 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+)
+
+// FeedConformanceTest checks the feed follows the contract of feeds.ScheduledFeed, polling
+// it several times with different cutoffs:
+//   - every package is complete and names the feed as its type,
+//   - no package created before the cutoff is returned, and those created after it are,
+//   - a cutoff after every package returns no packages, without ErrNoPackagesPolled,
+//   - errors are either a PackagePollError naming the package which failed, so that the
+//     scheduler can tell which packages were polled, or ErrNoPackagesPolled.
+//
+// The feed should poll a registry served by ServeFixtures, returning at least two packages
+// with distinct creation dates and the same packages on each poll. Registry requests which
+// are not for a single package should all succeed. If missing is not empty, it names a
+// package the feed polls which is missing from the fixtures, the first poll must return a
+// PackagePollError naming it, which checks the errors of the package are wrapped.
+func FeedConformanceTest(t *testing.T, feed feeds.ScheduledFeed, missing string) {
+	t.Helper()
+	epoch := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	all, errs := feed.Latest(context.Background(), epoch)
+	checkErrors(t, errs)
+	if len(all) == 0 {
+		t.Fatal("Latest() returned no packages, the fixtures must provide packages to check conformance")
+	}
+	t.Run("missing", func(t *testing.T) {
+		if missing == "" {
+			t.Skip("No package missing from the fixtures was given, so its errors can't be checked")
+		}
+		for _, err := range errs {
+			var pollErr feeds.PackagePollError
+			if errors.As(err, &pollErr) && pollErr.Name == missing && pollErr.Err != nil {
+				return
+			}
+		}
+		t.Errorf("Latest() returned %v without a feeds.PackagePollError for the missing package %s", errs, missing)
+	})
+	t.Run("packages", func(t *testing.T) {
+		for _, pkg := range all {
+			if pkg.Name == "" || pkg.Version == "" || pkg.CreatedDate.IsZero() || pkg.SchemaVer == "" {
+				t.Errorf("Package %s@%s created %v with schema %q is incomplete",
+					pkg.Name, pkg.Version, pkg.CreatedDate, pkg.SchemaVer)
+			}
+			if pkg.Type != feed.GetName() {
+				t.Errorf("Package %s@%s has type %q instead of the feed %q", pkg.Name, pkg.Version, pkg.Type, feed.GetName())
+			}
+		}
+	})
+
+	oldest, newest := all[0].CreatedDate, all[0].CreatedDate
+	for _, pkg := range all {
+		if pkg.CreatedDate.Before(oldest) {
+			oldest = pkg.CreatedDate
+		}
+		if pkg.CreatedDate.After(newest) {
+			newest = pkg.CreatedDate
+		}
+	}
+	t.Run("cutoff", func(t *testing.T) {
+		if !oldest.Before(newest) {
+			t.Skip("The fixtures provide packages created at a single time, so the cutoff can't be checked")
+		}
+		// A cutoff between the oldest and newest packages.
+		cutoff := oldest.Add(newest.Sub(oldest) / 2)
+		returned := map[string]bool{}
+		for _, pkg := range latest(t, feed, cutoff) {
+			returned[packageKey(pkg)] = true
+			if pkg.CreatedDate.Before(cutoff) {
+				t.Errorf("Package %s@%s created %v was returned for the later cutoff %v",
+					pkg.Name, pkg.Version, pkg.CreatedDate, cutoff)
+			}
+		}
+		for _, pkg := range all {
+			if pkg.CreatedDate.After(cutoff) && !returned[packageKey(pkg)] {
+				t.Errorf("Package %s@%s created %v was not returned for the earlier cutoff %v",
+					pkg.Name, pkg.Version, pkg.CreatedDate, cutoff)
+			}
+		}
+	})
+	t.Run("empty", func(t *testing.T) {
+		pkgs, errs := feed.Latest(context.Background(), newest.Add(time.Hour))
+		if len(pkgs) != 0 {
+			t.Errorf("Latest() returned %v packages for a cutoff after every package", len(pkgs))
+		}
+		checkErrors(t, errs)
+		for _, err := range errs {
+			if errors.Is(err, feeds.ErrNoPackagesPolled) {
+				t.Errorf("Latest() returned %v when packages were polled but none were after the cutoff", err)
+			}
+		}
+	})
+}
+
+// Polls the feed, failing the test if it returns errors which don't follow the contract.
+func latest(t *testing.T, feed feeds.ScheduledFeed, cutoff time.Time) []*feeds.Package {
+	t.Helper()
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	checkErrors(t, errs)
+	return pkgs
+}
+
+func checkErrors(t *testing.T, errs []error) {
+	t.Helper()
+	for _, err := range errs {
+		var pollErr feeds.PackagePollError
+		switch {
+		case errors.Is(err, feeds.ErrNoPackagesPolled):
+		case errors.As(err, &pollErr):
+			if pollErr.Name == "" || pollErr.Err == nil {
+				t.Errorf("Latest() returned %v without the package which failed and its error", err)
+			}
+		default:
+			t.Errorf("Latest() returned %v which is not a feeds.PackagePollError", err)
+		}
+	}
+}
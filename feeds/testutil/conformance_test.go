@@ -0,0 +1,42 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+)
+
+var errNotFound = errors.New("not found")
+
+// A feed returning fixed packages, along with the error of a package which failed.
+type staticFeed struct {
+	pkgs []*feeds.Package
+}
+
+func (feed staticFeed) Latest(ctx context.Context, cutoff time.Time) ([]*feeds.Package, []error) {
+	errs := []error{feeds.PackagePollError{Name: "missing", Err: errNotFound}}
+	return feeds.ApplyCutoff(feed.pkgs, cutoff), errs
+}
+
+func (feed staticFeed) GetFeedOptions() feeds.FeedOptions {
+	return feeds.FeedOptions{}
+}
+
+func (feed staticFeed) GetName() string {
+	return "static"
+}
+
+func TestFeedConformanceTest(t *testing.T) {
+	t.Parallel()
+
+	feed := staticFeed{pkgs: []*feeds.Package{
+		feeds.NewPackage(time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC), "foo", "1.0.0", "static", ""),
+		feeds.NewPackage(time.Date(2021, 5, 2, 0, 0, 0, 0, time.UTC), "foo", "1.1.0", "static", ""),
+		feeds.NewPackage(time.Date(2021, 5, 3, 0, 0, 0, 0, time.UTC), "bar", "0.1.0", "static", ""),
+	}}
+	FeedConformanceTest(t, feed, "missing")
+	AssertPackages(t, feed.pkgs, feed.pkgs)
+}
@@ -0,0 +1,123 @@
+// Package testutil provides helpers for testing feeds against registries served from
+// fixture files, along with a test of the contract every feed is expected to follow.
+package testutil
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+)
+
+// ServeFixtures returns a server responding to each request with the file at the path of
+// the request within dir, or that path with a .json extension, e.g. a request for /foo is
+// served testdata/registry/foo.json. Query parameters are ignored and paths without a file
+// are not found. The server is closed once the test completes.
+func ServeFixtures(t *testing.T, dir string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := filepath.Join(dir, filepath.FromSlash(path.Clean("/"+r.URL.Path)))
+		body, err := ioutil.ReadFile(name)
+		if os.IsNotExist(err) {
+			body, err = ioutil.ReadFile(name + ".json")
+		}
+		if os.IsNotExist(err) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if _, err := w.Write(body); err != nil {
+			t.Errorf("Failed to write fixture %s: %v", name, err)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// ReadExpectedPackages reads the packages a feed is expected to return from a file holding
+// a json array of packages following the package schema.
+func ReadExpectedPackages(t *testing.T, file string) []*feeds.Package {
+	t.Helper()
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Failed to read expected packages: %v", err)
+	}
+	pkgs := []*feeds.Package{}
+	if err := json.Unmarshal(data, &pkgs); err != nil {
+		t.Fatalf("Failed to parse expected packages from %s: %v", file, err)
+	}
+	return pkgs
+}
+
+// AssertPackages fails the test unless got holds the packages of want in any order. Packages
+// are compared as they are published, as json, so every published field must match.
+func AssertPackages(t *testing.T, got, want []*feeds.Package) {
+	t.Helper()
+	gotJSON := packagesJSON(t, got)
+	wantJSON := packagesJSON(t, want)
+	for key, pkg := range wantJSON {
+		gotPkg, ok := gotJSON[key]
+		if !ok {
+			t.Errorf("Expected package %s was not returned", key)
+			continue
+		}
+		if gotPkg != pkg {
+			t.Errorf("Package %s is\n%s\ninstead of the expected\n%s", key, gotPkg, pkg)
+		}
+	}
+	unexpected := []string{}
+	for key := range gotJSON {
+		if _, ok := wantJSON[key]; !ok {
+			unexpected = append(unexpected, key)
+		}
+	}
+	sort.Strings(unexpected)
+	for _, key := range unexpected {
+		t.Errorf("Unexpected package %s was returned", key)
+	}
+	if len(got) != len(gotJSON) {
+		t.Errorf("%v packages were returned when %v were distinct", len(got), len(gotJSON))
+	}
+}
+
+// AssertLatest polls the feed for the packages created after the cutoff, failing the test
+// if the poll returns errors or packages other than those listed in the expected file, as
+// read by ReadExpectedPackages.
+func AssertLatest(t *testing.T, feed feeds.ScheduledFeed, cutoff time.Time, expectedFile string) {
+	t.Helper()
+	pkgs, errs := feed.Latest(context.Background(), cutoff)
+	for _, err := range errs {
+		t.Errorf("Latest() returned unexpected error: %v", err)
+	}
+	AssertPackages(t, pkgs, ReadExpectedPackages(t, expectedFile))
+}
+
+// Returns the packages serialized as json, indexed by their feed, name and version.
+func packagesJSON(t *testing.T, pkgs []*feeds.Package) map[string]string {
+	t.Helper()
+	serialized := make(map[string]string, len(pkgs))
+	for _, pkg := range pkgs {
+		data, err := json.Marshal(pkg)
+		if err != nil {
+			t.Fatalf("Failed to marshal package %s@%s: %v", pkg.Name, pkg.Version, err)
+		}
+		serialized[packageKey(pkg)] = string(data)
+	}
+	return serialized
+}
+
+func packageKey(pkg *feeds.Package) string {
+	return pkg.Type + ":" + pkg.Name + "@" + pkg.Version
+}
@@ -0,0 +1,41 @@
+package testutil
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestServeFixtures(t *testing.T) {
+	t.Parallel()
+
+	srv := ServeFixtures(t, "testdata/registry")
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/foo", http.StatusOK, `{"name":"foo"}`},
+		{"/foo.json?page=2", http.StatusOK, `{"name":"foo"}`},
+		{"/pkg/bar", http.StatusOK, "bar"},
+		{"/missing", http.StatusNotFound, ""},
+		{"/../fixtures.go", http.StatusNotFound, ""},
+	}
+	for _, test := range tests {
+		resp, err := http.Get(srv.URL + test.path)
+		if err != nil {
+			t.Fatalf("Failed to get %s: %v", test.path, err)
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", test.path, err)
+		}
+		if resp.StatusCode != test.status {
+			t.Errorf("%s returned status %v instead of %v", test.path, resp.StatusCode, test.status)
+		}
+		if test.status == http.StatusOK && string(body) != test.body {
+			t.Errorf("%s returned %q instead of %q", test.path, body, test.body)
+		}
+	}
+}
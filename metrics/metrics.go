@@ -0,0 +1,91 @@
+// Package metrics defines the Prometheus metrics of the application, which are served by the
+// scheduler through `GET /metrics`.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry is the registry all metrics of the application are registered with.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+// RegistryRequestDuration records the duration of requests made by feeds to their registry,
+// labelled by feed and the endpoint requested, e.g. "rss" or "package".
+var RegistryRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "registry_request_duration_seconds",
+	Help:    "Duration of requests made by feeds to package registries.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"feed", "endpoint"})
+
+// PublishQueueDropped counts the packages dropped from a full publish queue under the
+// drop_oldest policy, labelled by feed.
+var PublishQueueDropped = factory.NewCounterVec(prometheus.CounterOpts{
+	Name: "publish_queue_dropped_packages_total",
+	Help: "Number of packages dropped from a full publish queue before being published.",
+}, []string{"feed"})
+
+// DeniedPackages counts the packages skipped by feeds as their name matches the denylist of
+// the feed, labelled by feed.
+var DeniedPackages = factory.NewCounterVec(prometheus.CounterOpts{
+	Name: "denied_packages_total",
+	Help: "Number of packages skipped by feeds as their name matches a denylist.",
+}, []string{"feed"})
+
+// MalformedTimestamps counts the versions skipped by feeds as the registry reported a creation
+// time which couldn't be parsed, labelled by feed.
+var MalformedTimestamps = factory.NewCounterVec(prometheus.CounterOpts{
+	Name: "malformed_timestamps_total",
+	Help: "Number of versions skipped by feeds as their creation time couldn't be parsed.",
+}, []string{"feed"})
+
+// SeenCacheHits and SeenCacheMisses count the packages whose first seen time was and wasn't
+// remembered from a previous poll, labelled by feed. A package missed after being evicted
+// is re-emitted with a new first seen time.
+var (
+	SeenCacheHits = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "seen_cache_hits_total",
+		Help: "Number of polled packages whose first seen time was remembered from a previous poll.",
+	}, []string{"feed"})
+	SeenCacheMisses = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "seen_cache_misses_total",
+		Help: "Number of polled packages which weren't remembered from a previous poll.",
+	}, []string{"feed"})
+)
+
+// SeenCacheEvictions counts the packages forgotten by the seen cache as it is full,
+// labelled by feed. Frequent evictions alongside misses indicate the cache is undersized.
+var SeenCacheEvictions = factory.NewCounterVec(prometheus.CounterOpts{
+	Name: "seen_cache_evictions_total",
+	Help: "Number of packages forgotten by the seen cache to make space for newly seen packages.",
+}, []string{"feed"})
+
+// SeenCacheEntries is the number of packages currently remembered by the seen cache,
+// labelled by feed.
+var SeenCacheEntries = factory.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "seen_cache_entries",
+	Help: "Number of packages currently remembered by the seen cache.",
+}, []string{"feed"})
+
+// ObserveRegistryRequest records the duration of a registry request which began at start.
+func ObserveRegistryRequest(feed, endpoint string, start time.Time) {
+	RegistryRequestDuration.WithLabelValues(feed, endpoint).Observe(time.Since(start).Seconds())
+}
+
+// Handler serves the metrics of Registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// CircuitBreakerState is the state of the circuit breaker of each feed, labelled by feed: 0
+// when closed, 1 when open and polls are skipped, and 2 when half-open for a trial poll.
+var CircuitBreakerState = factory.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "circuit_breaker_state",
+	Help: "State of the circuit breaker of the feed, 0 closed, 1 open and 2 half-open.",
+}, []string{"feed"})
@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandlerServesRegistryRequestDuration(t *testing.T) {
+	t.Parallel()
+
+	ObserveRegistryRequest("foo", "rss", time.Now().Add(-time.Second))
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Metrics request returned status %v when %v was expected", rec.Code, http.StatusOK)
+	}
+	body, err := ioutil.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatalf("Failed to read metrics: %v", err)
+	}
+	expected := `registry_request_duration_seconds_count{endpoint="rss",feed="foo"} 1`
+	if !strings.Contains(string(body), expected) {
+		t.Errorf("Metrics did not contain `%v`:\n%s", expected, body)
+	}
+}
@@ -0,0 +1,238 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"gocloud.dev/blob"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/publisher"
+
+	// Load bucket drivers.
+	_ "gocloud.dev/blob/fileblob"
+	_ "gocloud.dev/blob/gcsblob"
+	_ "gocloud.dev/blob/memblob"
+	_ "gocloud.dev/blob/s3blob"
+)
+
+const (
+	PublisherType = "blob"
+
+	defaultPrefix         = `{{.Type}}/{{.Time.Format "2006/01/02"}}`
+	defaultRotateInterval = 15 * time.Minute
+	defaultRotateSize     = 64 << 20
+	// Sortable and unique within a prefix, e.g. events-20210322T134533.123456789Z.ndjson.
+	objectTimeFormat = "20060102T150405.000000000Z"
+)
+
+var (
+	errMissingURL = errors.New("blob publisher requires a bucket url")
+	errNoPackage  = fmt.Errorf("%w : blob", publisher.ErrNoPackage)
+)
+
+type Config struct {
+	// The URL of the bucket, e.g. "gs://my-bucket", "s3://my-bucket?region=us-east-1" or
+	// "file:///var/lib/package-feeds/events".
+	URL string `mapstructure:"url"`
+	// Template for the prefix of objects, rendered from the fields of the package and the
+	// Time the object was opened. Defaults to `{{.Type}}/{{.Time.Format "2006/01/02"}}`.
+	Prefix string `mapstructure:"prefix"`
+	// The maximum duration an object is written to before it is closed, defaults to 15m.
+	RotateInterval string `mapstructure:"rotate_interval"`
+	// The maximum size in bytes of an object, defaults to 64MiB.
+	RotateSize int64 `mapstructure:"rotate_size"`
+}
+
+// Blob is a Publisher which writes packages as newline delimited JSON objects to a bucket.
+// Packages are appended to an open object for each prefix, objects are closed and become
+// visible in the bucket once they reach the rotation size or interval, or the publisher is
+// closed on shutdown. Packages written to objects which are still open are lost if the
+// process exits without closing the publisher.
+type Blob struct {
+	bucket         *blob.Bucket
+	prefix         *template.Template
+	rotateInterval time.Duration
+	rotateSize     int64
+	currentTime    func() time.Time
+
+	mu sync.Mutex
+	// The open objects indexed by prefix.
+	objects map[string]*object
+}
+
+// An object being written to the bucket.
+type object struct {
+	writer *blob.Writer
+	opened time.Time
+	size   int64
+}
+
+// The fields available to the prefix template.
+type prefixData struct {
+	*feeds.Package
+	Time time.Time
+}
+
+func New(ctx context.Context, config Config) (*Blob, error) {
+	if config.URL == "" {
+		return nil, errMissingURL
+	}
+	bucket, err := blob.OpenBucket(ctx, config.URL)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := newWithBucket(bucket, config)
+	if err != nil {
+		_ = bucket.Close()
+		return nil, err
+	}
+	return pub, nil
+}
+
+func FromConfig(ctx context.Context, config Config) (*Blob, error) {
+	return New(ctx, config)
+}
+
+func newWithBucket(bucket *blob.Bucket, config Config) (*Blob, error) {
+	prefix := config.Prefix
+	if prefix == "" {
+		prefix = defaultPrefix
+	}
+	tmpl, err := template.New("").Option("missingkey=error").Parse(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prefix template: %w", err)
+	}
+	rotateInterval := defaultRotateInterval
+	if config.RotateInterval != "" {
+		rotateInterval, err = time.ParseDuration(config.RotateInterval)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse rotate_interval: %w", err)
+		}
+	}
+	rotateSize := int64(defaultRotateSize)
+	if config.RotateSize > 0 {
+		rotateSize = config.RotateSize
+	}
+	return &Blob{
+		bucket:         bucket,
+		prefix:         tmpl,
+		rotateInterval: rotateInterval,
+		rotateSize:     rotateSize,
+		currentTime:    time.Now,
+		objects:        map[string]*object{},
+	}, nil
+}
+
+func (pub *Blob) Name() string {
+	return PublisherType
+}
+
+// Send appends the message to the open object for the prefix of the package being
+// published, opening a new object if there is none or the open object is due for rotation.
+func (pub *Blob) Send(ctx context.Context, body []byte) error {
+	pkg, ok := publisher.PackageFromContext(ctx)
+	if !ok {
+		return errNoPackage
+	}
+	now := pub.currentTime().UTC()
+	var sb strings.Builder
+	if err := pub.prefix.Execute(&sb, prefixData{Package: pkg, Time: now}); err != nil {
+		return fmt.Errorf("failed to render object prefix: %w", err)
+	}
+	prefix := sb.String()
+
+	pub.mu.Lock()
+	defer pub.mu.Unlock()
+	obj := pub.objects[prefix]
+	if obj != nil && now.Sub(obj.opened) >= pub.rotateInterval {
+		if err := pub.closeObject(prefix); err != nil {
+			return err
+		}
+		obj = nil
+	}
+	if obj == nil {
+		key := path.Join(prefix, "events-"+now.Format(objectTimeFormat)+".ndjson")
+		// Objects are written across many sends, so aren't bound to the context of a send.
+		writer, err := pub.bucket.NewWriter(context.Background(), key, &blob.WriterOptions{
+			ContentType: "application/x-ndjson",
+		})
+		if err != nil {
+			return fmt.Errorf("failed to open object %s: %w", key, err)
+		}
+		obj = &object{writer: writer, opened: now}
+		pub.objects[prefix] = obj
+	}
+
+	line := bytes.TrimSpace(body)
+	n, err := obj.writer.Write(line)
+	if err == nil {
+		_, err = obj.writer.Write([]byte{'\n'})
+		n++
+	}
+	obj.size += int64(n)
+	if err != nil {
+		// The object can't be written to further, the error closing it is superseded by the
+		// write error and the next send opens a new object.
+		_ = pub.closeObject(prefix)
+		return fmt.Errorf("failed to write to object: %w", err)
+	}
+	if obj.size >= pub.rotateSize {
+		return pub.closeObject(prefix)
+	}
+	return nil
+}
+
+// Flush closes the objects which are due for rotation, so that objects for prefixes which
+// are no longer sent to are closed once the rotation interval has passed.
+func (pub *Blob) Flush(ctx context.Context) error {
+	pub.mu.Lock()
+	defer pub.mu.Unlock()
+	now := pub.currentTime().UTC()
+	var firstErr error
+	for prefix, obj := range pub.objects {
+		if now.Sub(obj.opened) < pub.rotateInterval {
+			continue
+		}
+		if err := pub.closeObject(prefix); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Close closes every open object, so that the packages written to them become visible in
+// the bucket, then closes the bucket. An object which fails to close does not prevent
+// closing the others.
+func (pub *Blob) Close(ctx context.Context) error {
+	pub.mu.Lock()
+	defer pub.mu.Unlock()
+	var firstErr error
+	for prefix := range pub.objects {
+		if err := pub.closeObject(prefix); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if err := pub.bucket.Close(); err != nil && firstErr == nil {
+		firstErr = fmt.Errorf("failed to close bucket: %w", err)
+	}
+	return firstErr
+}
+
+// Closes the open object of the prefix, completing the upload of the object. The caller
+// must hold mu.
+func (pub *Blob) closeObject(prefix string) error {
+	obj := pub.objects[prefix]
+	delete(pub.objects, prefix)
+	if err := obj.writer.Close(); err != nil {
+		return fmt.Errorf("failed to close object: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/blob/fileblob"
+	"gocloud.dev/blob/memblob"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/publisher"
+)
+
+// Lists the objects of the bucket with their contents, indexed by key.
+func listObjects(t *testing.T, bucket *blob.Bucket) map[string]string {
+	t.Helper()
+	objects := map[string]string{}
+	iter := bucket.List(nil)
+	for {
+		obj, err := iter.Next(context.Background())
+		if errors.Is(err, io.EOF) {
+			return objects
+		}
+		if err != nil {
+			t.Fatalf("Failed to list objects: %v", err)
+		}
+		b, err := bucket.ReadAll(context.Background(), obj.Key)
+		if err != nil {
+			t.Fatalf("Failed to read object %s: %v", obj.Key, err)
+		}
+		objects[obj.Key] = string(b)
+	}
+}
+
+func send(t *testing.T, pub *Blob, pkg *feeds.Package, body string) {
+	t.Helper()
+	ctx := publisher.ContextWithPackage(context.Background(), pkg)
+	if err := pub.Send(ctx, []byte(body)); err != nil {
+		t.Fatalf("Send() returned unexpected error: %v", err)
+	}
+}
+
+func TestBlobRotateInterval(t *testing.T) {
+	t.Parallel()
+
+	bucket := memblob.OpenBucket(nil)
+	pub, err := newWithBucket(bucket, Config{RotateInterval: "1h"})
+	if err != nil {
+		t.Fatalf("Failed to create blob publisher: %v", err)
+	}
+	now := time.Date(2021, 3, 22, 13, 45, 33, 0, time.UTC)
+	pub.currentTime = func() time.Time { return now }
+
+	npmPkg := feeds.NewPackage(now, "foo", "1.0.0", "npm", feeds.EcosystemNPM)
+	pypiPkg := feeds.NewPackage(now, "bar", "2.0.0", "pypi", feeds.EcosystemPyPI)
+	send(t, pub, npmPkg, `{"name":"foo"}`)
+	send(t, pub, pypiPkg, `{"name":"bar"}`+"\n")
+	now = now.Add(30 * time.Minute)
+	send(t, pub, npmPkg, `{"name":"baz"}`)
+
+	// Objects aren't visible until they are closed.
+	if err := pub.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() returned unexpected error: %v", err)
+	}
+	if objects := listObjects(t, bucket); len(objects) != 0 {
+		t.Errorf("Objects %v were written before the rotation interval", objects)
+	}
+
+	now = now.Add(30 * time.Minute)
+	if err := pub.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() returned unexpected error: %v", err)
+	}
+	expected := map[string]string{
+		"npm/2021/03/22/events-20210322T134533.000000000Z.ndjson":  "{\"name\":\"foo\"}\n{\"name\":\"baz\"}\n",
+		"pypi/2021/03/22/events-20210322T134533.000000000Z.ndjson": "{\"name\":\"bar\"}\n",
+	}
+	objects := listObjects(t, bucket)
+	if len(objects) != len(expected) {
+		t.Fatalf("Bucket contains objects %v when %v were expected", objects, expected)
+	}
+	for key, content := range expected {
+		if objects[key] != content {
+			t.Errorf("Object %s contains %q when %q was expected", key, objects[key], content)
+		}
+	}
+}
+
+func TestBlobClose(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	bucket, err := fileblob.OpenBucket(dir, nil)
+	if err != nil {
+		t.Fatalf("Failed to open bucket: %v", err)
+	}
+	pub, err := newWithBucket(bucket, Config{RotateInterval: "1h"})
+	if err != nil {
+		t.Fatalf("Failed to create blob publisher: %v", err)
+	}
+	now := time.Date(2021, 3, 22, 13, 45, 33, 0, time.UTC)
+	pub.currentTime = func() time.Time { return now }
+
+	pkg := feeds.NewPackage(now, "foo", "1.0.0", "npm", feeds.EcosystemNPM)
+	send(t, pub, pkg, `{"name":"foo"}`)
+
+	// Closing the publisher closes the open object before the rotation interval.
+	if err := pub.Close(context.Background()); err != nil {
+		t.Fatalf("Close() returned unexpected error: %v", err)
+	}
+	reopened, err := fileblob.OpenBucket(dir, nil)
+	if err != nil {
+		t.Fatalf("Failed to reopen bucket: %v", err)
+	}
+	defer reopened.Close()
+	expected := map[string]string{
+		"npm/2021/03/22/events-20210322T134533.000000000Z.ndjson": "{\"name\":\"foo\"}\n",
+	}
+	objects := listObjects(t, reopened)
+	if len(objects) != len(expected) {
+		t.Fatalf("Bucket contains objects %v when %v were expected", objects, expected)
+	}
+	for key, content := range expected {
+		if objects[key] != content {
+			t.Errorf("Object %s contains %q when %q was expected", key, objects[key], content)
+		}
+	}
+}
+
+func TestBlobRotateSize(t *testing.T) {
+	t.Parallel()
+
+	bucket := memblob.OpenBucket(nil)
+	pub, err := newWithBucket(bucket, Config{Prefix: "events/{{.Ecosystem}}", RotateSize: 30})
+	if err != nil {
+		t.Fatalf("Failed to create blob publisher: %v", err)
+	}
+	now := time.Date(2021, 3, 22, 13, 45, 33, 0, time.UTC)
+	pub.currentTime = func() time.Time { return now }
+
+	pkg := feeds.NewPackage(now, "foo", "1.0.0", "npm", feeds.EcosystemNPM)
+	for _, body := range []string{`{"name":"foo"}`, `{"name":"bar"}`, `{"name":"baz"}`} {
+		send(t, pub, pkg, body)
+		now = now.Add(time.Second)
+	}
+
+	// The first object reached the rotation size after two packages.
+	expected := map[string]string{
+		"events/npm/events-20210322T134533.000000000Z.ndjson": "{\"name\":\"foo\"}\n{\"name\":\"bar\"}\n",
+	}
+	objects := listObjects(t, bucket)
+	if len(objects) != len(expected) {
+		t.Fatalf("Bucket contains objects %v when %v were expected", objects, expected)
+	}
+	for key, content := range expected {
+		if objects[key] != content {
+			t.Errorf("Object %s contains %q when %q was expected", key, objects[key], content)
+		}
+	}
+}
+
+func TestBlobInvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New(context.Background(), Config{}); !errors.Is(err, errMissingURL) {
+		t.Errorf("New() returned `%v` when a missing url error was expected", err)
+	}
+	if _, err := newWithBucket(memblob.OpenBucket(nil), Config{Prefix: "{{.Type"}); err == nil {
+		t.Errorf("newWithBucket() succeeded with an invalid prefix template")
+	}
+	if _, err := newWithBucket(memblob.OpenBucket(nil), Config{RotateInterval: "hourly"}); err == nil {
+		t.Errorf("newWithBucket() succeeded with an invalid rotate interval")
+	}
+}
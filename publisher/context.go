@@ -0,0 +1,41 @@
+package publisher
+
+import (
+	"context"
+
+	"github.com/ossf/package-feeds/feeds"
+)
+
+type contextKey int
+
+const (
+	packageKey contextKey = iota
+	contentTypeKey
+)
+
+// ContextWithPackage returns a context carrying the package being published, allowing
+// publishers to derive message metadata from the package.
+func ContextWithPackage(ctx context.Context, pkg *feeds.Package) context.Context {
+	return context.WithValue(ctx, packageKey, pkg)
+}
+
+// PackageFromContext returns the package being published, if the context carries one.
+func PackageFromContext(ctx context.Context) (*feeds.Package, bool) {
+	pkg, ok := ctx.Value(packageKey).(*feeds.Package)
+	return pkg, ok
+}
+
+// ContextWithContentType returns a context carrying the MIME type of the body being
+// published, for publishers whose transport labels the content.
+func ContextWithContentType(ctx context.Context, contentType string) context.Context {
+	return context.WithValue(ctx, contentTypeKey, contentType)
+}
+
+// ContentTypeFromContext returns the MIME type of the body being published, defaulting to
+// application/json.
+func ContentTypeFromContext(ctx context.Context) string {
+	if contentType, ok := ctx.Value(contentTypeKey).(string); ok {
+		return contentType
+	}
+	return JSONSerializer{}.ContentType()
+}
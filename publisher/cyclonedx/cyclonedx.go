@@ -0,0 +1,188 @@
+package cyclonedx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/publisher"
+)
+
+const (
+	PublisherType = "cyclonedx"
+	specVersion   = "1.4"
+	// Sortable and unique within a directory, e.g. bom-20210322T134533.123456789Z.json.
+	fileTimeFormat = "20060102T150405.000000000Z"
+)
+
+var (
+	errMissingDirectory = errors.New("cyclonedx publisher requires a directory")
+	errNoPackage        = fmt.Errorf("%w : cyclonedx", publisher.ErrNoPackage)
+)
+
+type Config struct {
+	// The directory a BOM document is written to for each poll cycle.
+	Directory string `mapstructure:"directory"`
+}
+
+// CycloneDX is a Publisher which accumulates the packages published within a poll cycle,
+// writing them as the components of a CycloneDX BOM document when the cycle is flushed.
+// The message body is ignored, components are produced from the package being published.
+type CycloneDX struct {
+	directory   string
+	currentTime func() time.Time
+
+	mu         sync.Mutex
+	components []component
+}
+
+type bom struct {
+	BOMFormat    string      `json:"bomFormat"`
+	SpecVersion  string      `json:"specVersion"`
+	SerialNumber string      `json:"serialNumber"`
+	Version      int         `json:"version"`
+	Metadata     metadata    `json:"metadata"`
+	Components   []component `json:"components"`
+}
+
+type metadata struct {
+	Timestamp string `json:"timestamp"`
+	Tools     []tool `json:"tools"`
+}
+
+type tool struct {
+	Vendor string `json:"vendor"`
+	Name   string `json:"name"`
+}
+
+type component struct {
+	Type       string     `json:"type"`
+	BOMRef     string     `json:"bom-ref"`
+	Name       string     `json:"name"`
+	Version    string     `json:"version"`
+	PURL       string     `json:"purl"`
+	Properties []property `json:"properties"`
+}
+
+type property struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func New(directory string) (*CycloneDX, error) {
+	if directory == "" {
+		return nil, errMissingDirectory
+	}
+	if err := os.MkdirAll(directory, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create cyclonedx directory: %w", err)
+	}
+	return &CycloneDX{
+		directory:   directory,
+		currentTime: time.Now,
+	}, nil
+}
+
+func FromConfig(ctx context.Context, config Config) (*CycloneDX, error) {
+	return New(config.Directory)
+}
+
+func (pub *CycloneDX) Name() string {
+	return PublisherType
+}
+
+// Send adds the package being published to the components of the current poll cycle.
+func (pub *CycloneDX) Send(ctx context.Context, body []byte) error {
+	pkg, ok := publisher.PackageFromContext(ctx)
+	if !ok {
+		return errNoPackage
+	}
+	pub.mu.Lock()
+	defer pub.mu.Unlock()
+	pub.components = append(pub.components, newComponent(pkg))
+	return nil
+}
+
+// Flush writes the components accumulated during the poll cycle as a BOM document, no
+// document is written for a cycle without packages.
+func (pub *CycloneDX) Flush(ctx context.Context) error {
+	pub.mu.Lock()
+	components := pub.components
+	pub.components = nil
+	pub.mu.Unlock()
+	if len(components) == 0 {
+		return nil
+	}
+
+	serial, err := newSerialNumber()
+	if err != nil {
+		return err
+	}
+	now := pub.currentTime().UTC()
+	doc := bom{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  specVersion,
+		SerialNumber: serial,
+		Version:      1,
+		Metadata: metadata{
+			Timestamp: now.Format(time.RFC3339),
+			Tools:     []tool{{Vendor: "OpenSSF", Name: "package-feeds"}},
+		},
+		Components: components,
+	}
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(pub.directory, "bom-"+now.Format(fileTimeFormat)+".json")
+	// Write to a temporary file first so that consumers never read a partial document.
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, b, 0o640); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func newComponent(pkg *feeds.Package) component {
+	purl := pkg.PURL()
+	properties := []property{
+		{Name: "package-feeds:feed", Value: pkg.Type},
+		{Name: "package-feeds:created_date", Value: pkg.CreatedDate.UTC().Format(time.RFC3339)},
+	}
+	// Labels are added in key order, e.g. "package-feeds:label:env".
+	keys := make([]string, 0, len(pkg.Labels))
+	for key := range pkg.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		properties = append(properties, property{Name: "package-feeds:label:" + key, Value: pkg.Labels[key]})
+	}
+	return component{
+		Type:       "library",
+		BOMRef:     purl,
+		Name:       pkg.Name,
+		Version:    pkg.Version,
+		PURL:       purl,
+		Properties: properties,
+	}
+}
+
+// Generates a random (version 4) UUID URN as required for BOM serial numbers.
+func newSerialNumber() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
@@ -0,0 +1,110 @@
+package cyclonedx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/publisher"
+)
+
+func TestCycloneDXFlush(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	pub, err := New(dir)
+	if err != nil {
+		t.Fatalf("Failed to create cyclonedx publisher: %v", err)
+	}
+	now := time.Date(2021, 3, 22, 13, 45, 33, 0, time.UTC)
+	pub.currentTime = func() time.Time { return now }
+
+	created := time.Date(2021, 3, 22, 13, 0, 0, 0, time.UTC)
+	pkgs := []*feeds.Package{
+		feeds.NewPackage(created, "@angular/core", "1.0.1", "npm", feeds.EcosystemNPM),
+		feeds.NewPackage(created, "Foo_Bar", "2.0.0", "pypi", feeds.EcosystemPyPI),
+	}
+	pkgs[1].Labels = map[string]string{"env": "prod"}
+	for _, pkg := range pkgs {
+		ctx := publisher.ContextWithPackage(context.Background(), pkg)
+		if err := pub.Send(ctx, []byte("{}")); err != nil {
+			t.Fatalf("Send() returned unexpected error: %v", err)
+		}
+	}
+	if err := pub.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() returned unexpected error: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "bom-20210322T134533.000000000Z.json"))
+	if err != nil {
+		t.Fatalf("Failed to read BOM document: %v", err)
+	}
+	doc := bom{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatalf("Failed to decode BOM document: %v", err)
+	}
+	if doc.BOMFormat != "CycloneDX" || doc.SpecVersion != specVersion {
+		t.Errorf("BOM document has format %v %v when CycloneDX %v was expected",
+			doc.BOMFormat, doc.SpecVersion, specVersion)
+	}
+	if doc.Metadata.Timestamp != "2021-03-22T13:45:33Z" {
+		t.Errorf("BOM document has timestamp %v when 2021-03-22T13:45:33Z was expected", doc.Metadata.Timestamp)
+	}
+	if len(doc.Components) != 2 {
+		t.Fatalf("BOM document has %v components when 2 were expected", len(doc.Components))
+	}
+	angular := doc.Components[0]
+	if angular.Type != "library" || angular.PURL != "pkg:npm/%40angular/core@1.0.1" ||
+		angular.Version != "1.0.1" {
+		t.Errorf("Unexpected component %+v for @angular/core", angular)
+	}
+	if doc.Components[1].PURL != "pkg:pypi/foo-bar@2.0.0" {
+		t.Errorf("Component has purl %v when pkg:pypi/foo-bar@2.0.0 was expected", doc.Components[1].PURL)
+	}
+	createdProperty := property{Name: "package-feeds:created_date", Value: "2021-03-22T13:00:00Z"}
+	if len(angular.Properties) != 2 || angular.Properties[1] != createdProperty {
+		t.Errorf("Component has properties %v when %v was expected", angular.Properties, createdProperty)
+	}
+	labelProperty := property{Name: "package-feeds:label:env", Value: "prod"}
+	if props := doc.Components[1].Properties; len(props) != 3 || props[2] != labelProperty {
+		t.Errorf("Component has properties %v when %v was expected", props, labelProperty)
+	}
+
+	// The components are cleared after each cycle, so a cycle without packages writes nothing.
+	now = now.Add(time.Minute)
+	if err := pub.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() returned unexpected error: %v", err)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	if len(files) != 1 {
+		t.Errorf("%v files were written when 1 was expected", len(files))
+	}
+}
+
+func TestCycloneDXSendWithoutPackage(t *testing.T) {
+	t.Parallel()
+
+	pub, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create cyclonedx publisher: %v", err)
+	}
+	if err := pub.Send(context.Background(), []byte("{}")); !errors.Is(err, errNoPackage) {
+		t.Errorf("Send() returned %v when %v was expected", err, errNoPackage)
+	}
+}
+
+func TestCycloneDXMissingDirectory(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New(""); !errors.Is(err, errMissingDirectory) {
+		t.Errorf("New() returned %v when %v was expected", err, errMissingDirectory)
+	}
+}
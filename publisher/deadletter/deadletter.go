@@ -0,0 +1,234 @@
+// Package deadletter records messages which a publisher failed to send to a file, from
+// which they can later be replayed.
+package deadletter
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/publisher"
+)
+
+// The suffix of the file holding entries taken from the dead-letter file for replay.
+const replaySuffix = ".replay"
+
+var errDeadLetter = errors.New("failed to write to dead-letter file")
+
+// Entry is a message which a publisher failed to send.
+type Entry struct {
+	Time      time.Time `json:"time"`
+	Publisher string    `json:"publisher"`
+	// Identifies the configured publisher which failed to send the message, so that the
+	// entry is only replayed to it.
+	PublisherID string          `json:"publisher_id,omitempty"`
+	Error       string          `json:"error"`
+	Body        json.RawMessage `json:"body"`
+}
+
+// File is a dead-letter file holding one JSON encoded Entry per line.
+type File struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+func (f *File) Path() string {
+	return f.path
+}
+
+// Append adds entries to the end of the file, creating it if necessary.
+func (f *File) Append(entries ...Entry) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return appendEntries(f.path, entries)
+}
+
+// Read returns the entries of the file, a missing file has no entries.
+func (f *File) Read() ([]Entry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return readEntries(f.path)
+}
+
+// Take moves the entries of the file aside for replay and returns them, together with any
+// entries left by an interrupted replay. Entries appended whilst replaying are written to a
+// new file. Done must be called once the entries have been replayed.
+func (f *File) Take() ([]Entry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	replayPath := f.path + replaySuffix
+	leftover, err := readEntries(replayPath)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := readEntries(f.path)
+	if err != nil {
+		return nil, err
+	}
+	if len(leftover) > 0 && len(entries) > 0 {
+		// Combine the entries so that a single file is moved aside.
+		if err := appendEntries(replayPath, entries); err != nil {
+			return nil, err
+		}
+		if err := os.Remove(f.path); err != nil {
+			return nil, err
+		}
+	} else if len(entries) > 0 {
+		if err := os.Rename(f.path, replayPath); err != nil {
+			return nil, err
+		}
+	}
+	return append(leftover, entries...), nil
+}
+
+// Done discards the entries taken for replay.
+func (f *File) Done() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := os.Remove(f.path + replaySuffix); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func readEntries(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := []Entry{}
+	scanner := bufio.NewScanner(file)
+	// Entries hold whole messages, which can exceed the default maximum line length.
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to decode dead-letter entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+func appendEntries(path string, entries []Entry) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	return file.Close()
+}
+
+// deadLetterPublisher is a Publisher which writes messages the wrapped publisher failed to
+// send to a dead-letter file.
+type deadLetterPublisher struct {
+	publisher.Publisher
+	file *File
+	id   string
+}
+
+// WithDeadLetter wraps the publisher so that messages it fails to send are written to the
+// dead-letter file along with the id of the publisher. A message written to the file is
+// considered sent, so that the remaining packages of a poll are still published. Events
+// skipped by the publisher as they are not packages are not written.
+func WithDeadLetter(pub publisher.Publisher, file *File, id string) publisher.Publisher {
+	return &deadLetterPublisher{Publisher: pub, file: file, id: id}
+}
+
+func (d *deadLetterPublisher) Send(ctx context.Context, body []byte) error {
+	sendErr := d.Publisher.Send(ctx, body)
+	if sendErr == nil || errors.Is(sendErr, publisher.ErrNoPackage) {
+		return sendErr
+	}
+	entry := Entry{
+		Time:        time.Now().UTC(),
+		Publisher:   d.Publisher.Name(),
+		PublisherID: d.id,
+		Error:       sendErr.Error(),
+		Body:        body,
+	}
+	if err := d.file.Append(entry); err != nil {
+		return fmt.Errorf("%w : %v after send error: %v", errDeadLetter, err, sendErr)
+	}
+	log.WithError(sendErr).WithField("dead_letter_file", d.file.Path()).
+		Warn("Failed to send message, written to dead-letter file")
+	return nil
+}
+
+func (d *deadLetterPublisher) Flush(ctx context.Context) error {
+	return publisher.Flush(ctx, d.Publisher)
+}
+
+func (d *deadLetterPublisher) Close(ctx context.Context) error {
+	return publisher.Close(ctx, d.Publisher)
+}
+
+// Replay takes the entries of the dead-letter file and sends each to the publisher of byID
+// which failed to send it, so that publishers which received the message aren't sent it
+// again. Entries of publishers missing from byID are sent through pub, which must wrap the
+// publishers of byID. Entries which fail to send again are kept in the file for the next
+// replay. The numbers of replayed and failed entries are returned.
+func Replay(ctx context.Context, file *File, pub publisher.Publisher,
+	byID map[string]publisher.Publisher) (int, int, error) {
+	entries, err := file.Take()
+	if err != nil {
+		return 0, 0, err
+	}
+	failed := []Entry{}
+	for _, entry := range entries {
+		sendCtx := ctx
+		pkg := &feeds.Package{}
+		if err := json.Unmarshal(entry.Body, pkg); err == nil {
+			sendCtx = publisher.ContextWithPackage(ctx, pkg)
+		}
+		target := pub
+		if idPub, ok := byID[entry.PublisherID]; ok {
+			target = idPub
+		}
+		if err := target.Send(sendCtx, entry.Body); err != nil {
+			entry.Time = time.Now().UTC()
+			entry.Publisher = target.Name()
+			entry.Error = err.Error()
+			failed = append(failed, entry)
+		}
+	}
+	if err := publisher.Flush(ctx, pub); err != nil {
+		return 0, 0, err
+	}
+	if len(failed) > 0 {
+		if err := file.Append(failed...); err != nil {
+			return 0, 0, err
+		}
+	}
+	if err := file.Done(); err != nil {
+		return 0, 0, err
+	}
+	return len(entries) - len(failed), len(failed), nil
+}
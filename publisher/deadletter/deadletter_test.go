@@ -0,0 +1,204 @@
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/publisher"
+)
+
+var errMockSend = errors.New("mock send failure")
+
+type mockPublisher struct {
+	// Bodies which fail to send.
+	failing map[string]bool
+	sent    []string
+	// The names of the packages carried by the contexts of sent messages.
+	packages []string
+}
+
+func (pub *mockPublisher) Name() string {
+	return "mock"
+}
+
+func (pub *mockPublisher) Send(ctx context.Context, body []byte) error {
+	if pub.failing[string(body)] {
+		return errMockSend
+	}
+	pub.sent = append(pub.sent, string(body))
+	if pkg, ok := publisher.PackageFromContext(ctx); ok {
+		pub.packages = append(pub.packages, pkg.Name)
+	}
+	return nil
+}
+
+func packageBody(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := json.Marshal(feeds.NewPackage(time.Now().UTC(), name, "1.0.0", "npm", feeds.EcosystemNPM))
+	if err != nil {
+		t.Fatalf("Failed to marshal package: %v", err)
+	}
+	return b
+}
+
+func TestWithDeadLetter(t *testing.T) {
+	t.Parallel()
+
+	foo := packageBody(t, "foo")
+	bar := packageBody(t, "bar")
+	file := NewFile(filepath.Join(t.TempDir(), "dead-letter.jsonl"))
+	pub := WithDeadLetter(&mockPublisher{failing: map[string]bool{string(bar): true}}, file, "0:mock")
+
+	for _, body := range [][]byte{foo, bar} {
+		if err := pub.Send(context.Background(), body); err != nil {
+			t.Fatalf("Send() returned unexpected error: %v", err)
+		}
+	}
+
+	entries, err := file.Read()
+	if err != nil {
+		t.Fatalf("Failed to read dead-letter file: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Dead-letter file has %v entries when 1 was expected", len(entries))
+	}
+	if string(entries[0].Body) != string(bar) {
+		t.Errorf("Dead-letter entry has body %s when %s was expected", entries[0].Body, bar)
+	}
+	if entries[0].Publisher != "mock" || entries[0].Error != errMockSend.Error() {
+		t.Errorf("Dead-letter entry has publisher %q and error %q when mock and %q were expected",
+			entries[0].Publisher, entries[0].Error, errMockSend)
+	}
+	if entries[0].PublisherID != "0:mock" {
+		t.Errorf("Dead-letter entry has publisher id %q when 0:mock was expected", entries[0].PublisherID)
+	}
+}
+
+func TestWithDeadLetterWriteFailure(t *testing.T) {
+	t.Parallel()
+
+	file := NewFile(filepath.Join(t.TempDir(), "missing", "dead-letter.jsonl"))
+	pub := WithDeadLetter(&mockPublisher{failing: map[string]bool{"foo": true}}, file, "0:mock")
+	if err := pub.Send(context.Background(), []byte("foo")); !errors.Is(err, errDeadLetter) {
+		t.Errorf("Send() returned %v when %v was expected", err, errDeadLetter)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	t.Parallel()
+
+	foo := packageBody(t, "foo")
+	bar := packageBody(t, "bar")
+	baz := packageBody(t, "baz")
+	file := NewFile(filepath.Join(t.TempDir(), "dead-letter.jsonl"))
+	entries := []Entry{}
+	for _, body := range [][]byte{foo, bar, baz} {
+		entries = append(entries, Entry{Publisher: "mock", Error: "unavailable", Body: body})
+	}
+	if err := file.Append(entries...); err != nil {
+		t.Fatalf("Failed to write dead-letter file: %v", err)
+	}
+
+	pub := &mockPublisher{failing: map[string]bool{string(bar): true}}
+	replayed, failed, err := Replay(context.Background(), file, pub, nil)
+	if err != nil {
+		t.Fatalf("Replay() returned unexpected error: %v", err)
+	}
+	if replayed != 2 || failed != 1 {
+		t.Errorf("Replay() replayed %v and failed %v entries when 2 and 1 were expected", replayed, failed)
+	}
+	if len(pub.packages) != 2 || pub.packages[0] != "foo" || pub.packages[1] != "baz" {
+		t.Errorf("Replayed messages carried packages %v when [foo baz] were expected", pub.packages)
+	}
+
+	remaining, err := file.Read()
+	if err != nil {
+		t.Fatalf("Failed to read dead-letter file: %v", err)
+	}
+	if len(remaining) != 1 || string(remaining[0].Body) != string(bar) {
+		t.Errorf("Dead-letter file has %v entries after replay when only bar was expected", len(remaining))
+	}
+	if _, err := os.Stat(file.Path() + replaySuffix); !os.IsNotExist(err) {
+		t.Errorf("Replay file was not removed after replay: %v", err)
+	}
+}
+
+func TestReplayToFailedPublisher(t *testing.T) {
+	t.Parallel()
+
+	foo := packageBody(t, "foo")
+	bar := packageBody(t, "bar")
+	baz := packageBody(t, "baz")
+	file := NewFile(filepath.Join(t.TempDir(), "dead-letter.jsonl"))
+	if err := file.Append(
+		Entry{Publisher: "mock", PublisherID: "0:mock", Body: foo},
+		Entry{Publisher: "mock", PublisherID: "1:mock", Body: bar},
+		Entry{Publisher: "mock", PublisherID: "2:removed", Body: baz},
+	); err != nil {
+		t.Fatalf("Failed to write dead-letter file: %v", err)
+	}
+
+	first := &mockPublisher{}
+	second := &mockPublisher{failing: map[string]bool{string(bar): true}}
+	multi := &mockPublisher{}
+	byID := map[string]publisher.Publisher{"0:mock": first, "1:mock": second}
+	replayed, failed, err := Replay(context.Background(), file, multi, byID)
+	if err != nil {
+		t.Fatalf("Replay() returned unexpected error: %v", err)
+	}
+	if replayed != 2 || failed != 1 {
+		t.Errorf("Replay() replayed %v and failed %v entries when 2 and 1 were expected", replayed, failed)
+	}
+	if len(first.packages) != 1 || first.packages[0] != "foo" {
+		t.Errorf("First publisher was sent packages %v when only foo was expected", first.packages)
+	}
+	if len(second.packages) != 0 {
+		t.Errorf("Second publisher was sent packages %v when none were expected", second.packages)
+	}
+	// Entries of publishers which are no longer configured are sent to every publisher.
+	if len(multi.packages) != 1 || multi.packages[0] != "baz" {
+		t.Errorf("Publisher was sent packages %v when only baz was expected", multi.packages)
+	}
+
+	remaining, err := file.Read()
+	if err != nil {
+		t.Fatalf("Failed to read dead-letter file: %v", err)
+	}
+	if len(remaining) != 1 || remaining[0].PublisherID != "1:mock" {
+		t.Errorf("Dead-letter file has %v entries after replay when only bar for 1:mock was expected", len(remaining))
+	}
+}
+
+func TestTakeInterruptedReplay(t *testing.T) {
+	t.Parallel()
+
+	file := NewFile(filepath.Join(t.TempDir(), "dead-letter.jsonl"))
+	if err := file.Append(Entry{Body: []byte(`"foo"`)}); err != nil {
+		t.Fatalf("Failed to write dead-letter file: %v", err)
+	}
+	// Simulate a replay which was interrupted before completing.
+	if _, err := file.Take(); err != nil {
+		t.Fatalf("Take() returned unexpected error: %v", err)
+	}
+	if err := file.Append(Entry{Body: []byte(`"bar"`)}); err != nil {
+		t.Fatalf("Failed to write dead-letter file: %v", err)
+	}
+
+	entries, err := file.Take()
+	if err != nil {
+		t.Fatalf("Take() returned unexpected error: %v", err)
+	}
+	if len(entries) != 2 || string(entries[0].Body) != `"foo"` || string(entries[1].Body) != `"bar"` {
+		t.Errorf("Take() returned %v entries when the interrupted and new entries were expected", len(entries))
+	}
+	// The entries are moved aside together, so none are taken twice.
+	if entries, err := file.Read(); err != nil || len(entries) != 0 {
+		t.Errorf("Dead-letter file has %v entries (%v) after Take() when none were expected", len(entries), err)
+	}
+}
@@ -0,0 +1,216 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ossf/package-feeds/utils"
+)
+
+const (
+	PublisherType = "elasticsearch"
+	bulkPath      = "/_bulk"
+	// Elasticsearch date math style suffix used for daily index rotation.
+	dailyIndexFormat = "2006.01.02"
+	defaultBatchSize = 500
+)
+
+var (
+	errMissingConfig = errors.New("elasticsearch publisher requires a url and index")
+	errBulkItem      = errors.New("elasticsearch failed to index event")
+)
+
+// Elasticsearch is a Publisher which indexes events using the bulk API. Events are buffered
+// and indexed in a single bulk request once the batch size is reached, and at the end of each
+// poll cycle when the publisher is flushed.
+type Elasticsearch struct {
+	bulkURL     string
+	index       string
+	dailyIndex  bool
+	username    string
+	password    string
+	batchSize   int
+	httpClient  *http.Client
+	currentTime func() time.Time
+
+	mu sync.Mutex
+	// The bulk request body of the buffered events, an action line followed by a document
+	// line for each event.
+	batch    bytes.Buffer
+	batchLen int
+}
+
+type Config struct {
+	URL   string `mapstructure:"url"`
+	Index string `mapstructure:"index"`
+	// Appends the current UTC date to the index, formatted as `<index>-YYYY.MM.DD`.
+	DailyIndex bool   `mapstructure:"daily_index"`
+	Username   string `mapstructure:"username"`
+	Password   string `mapstructure:"password"`
+	// The maximum number of events indexed by a bulk request, defaults to 500.
+	BatchSize int `mapstructure:"batch_size"`
+}
+
+type bulkAction struct {
+	Index bulkIndex `json:"index"`
+}
+
+type bulkIndex struct {
+	Index string `json:"_index"`
+}
+
+type bulkResponse struct {
+	Errors bool                      `json:"errors"`
+	Items  []map[string]bulkItemResp `json:"items"`
+}
+
+type bulkItemResp struct {
+	Status int            `json:"status"`
+	Error  *bulkItemError `json:"error"`
+}
+
+type bulkItemError struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+func New(ctx context.Context, config Config) (*Elasticsearch, error) {
+	if config.URL == "" || config.Index == "" {
+		return nil, errMissingConfig
+	}
+	bulkURL, err := utils.URLPathJoin(config.URL, bulkPath)
+	if err != nil {
+		return nil, err
+	}
+	batchSize := defaultBatchSize
+	if config.BatchSize > 0 {
+		batchSize = config.BatchSize
+	}
+	return &Elasticsearch{
+		bulkURL:     bulkURL,
+		index:       config.Index,
+		dailyIndex:  config.DailyIndex,
+		username:    config.Username,
+		password:    config.Password,
+		batchSize:   batchSize,
+		httpClient:  utils.NewHTTPClient(10 * time.Second),
+		currentTime: time.Now,
+	}, nil
+}
+
+func FromConfig(ctx context.Context, config Config) (*Elasticsearch, error) {
+	return New(ctx, config)
+}
+
+func (pub *Elasticsearch) Name() string {
+	return PublisherType
+}
+
+// Resolves the index to write to, applying daily rotation if configured.
+func (pub *Elasticsearch) indexName() string {
+	if !pub.dailyIndex {
+		return pub.index
+	}
+	return fmt.Sprintf("%s-%s", pub.index, pub.currentTime().UTC().Format(dailyIndexFormat))
+}
+
+// Send adds the event to the current batch, indexing the batch once it reaches the batch
+// size. The events of a batch which fails to be indexed are dropped, and the error is
+// returned.
+func (pub *Elasticsearch) Send(ctx context.Context, body []byte) error {
+	action, err := json.Marshal(bulkAction{Index: bulkIndex{Index: pub.indexName()}})
+	if err != nil {
+		return err
+	}
+	pub.mu.Lock()
+	pub.batch.Write(action)
+	pub.batch.WriteByte('\n')
+	pub.batch.Write(bytes.TrimSpace(body))
+	pub.batch.WriteByte('\n')
+	pub.batchLen++
+	if pub.batchLen < pub.batchSize {
+		pub.mu.Unlock()
+		return nil
+	}
+	payload := pub.takeBatch()
+	pub.mu.Unlock()
+	return pub.bulk(ctx, payload)
+}
+
+// Flush indexes the events buffered during the poll cycle.
+func (pub *Elasticsearch) Flush(ctx context.Context) error {
+	pub.mu.Lock()
+	payload := pub.takeBatch()
+	pub.mu.Unlock()
+	if payload == nil {
+		return nil
+	}
+	return pub.bulk(ctx, payload)
+}
+
+// Close indexes the buffered events, so that they aren't lost on shutdown.
+func (pub *Elasticsearch) Close(ctx context.Context) error {
+	return pub.Flush(ctx)
+}
+
+// Returns the body of the bulk request for the buffered events and starts a new batch, nil
+// if no events are buffered. The caller must hold mu.
+func (pub *Elasticsearch) takeBatch() []byte {
+	if pub.batchLen == 0 {
+		return nil
+	}
+	payload := make([]byte, pub.batch.Len())
+	copy(payload, pub.batch.Bytes())
+	pub.batch.Reset()
+	pub.batchLen = 0
+	return payload
+}
+
+// Indexes the events of the payload with a bulk request, errors reported for individual
+// items in the bulk response are returned.
+func (pub *Elasticsearch) bulk(ctx context.Context, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pub.bulkURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if pub.username != "" {
+		req.SetBasicAuth(pub.username, pub.password)
+	}
+
+	resp, err := pub.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	err = utils.CheckResponseStatus(resp)
+	if err != nil {
+		return fmt.Errorf("failed to bulk index events: %w", err)
+	}
+
+	bulkResp := &bulkResponse{}
+	err = json.NewDecoder(resp.Body).Decode(bulkResp)
+	if err != nil {
+		return err
+	}
+	if !bulkResp.Errors {
+		return nil
+	}
+	reasons := []string{}
+	for _, item := range bulkResp.Items {
+		for _, result := range item {
+			if result.Error != nil {
+				reasons = append(reasons, fmt.Sprintf("%v (%v): %v", result.Error.Type, result.Status, result.Error.Reason))
+			}
+		}
+	}
+	return fmt.Errorf("%w : %v", errBulkItem, strings.Join(reasons, "; "))
+}
@@ -0,0 +1,178 @@
+package elasticsearch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	testutils "github.com/ossf/package-feeds/utils/test"
+)
+
+func TestElasticsearchSend(t *testing.T) {
+	t.Parallel()
+
+	var lines []string
+	var username, password string
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		bulkPath: func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Content-Type") != "application/x-ndjson" {
+				http.Error(w, "unexpected content type", http.StatusBadRequest)
+				return
+			}
+			username, password, _ = r.BasicAuth()
+			scanner := bufio.NewScanner(r.Body)
+			for scanner.Scan() {
+				lines = append(lines, scanner.Text())
+			}
+			_, err := w.Write([]byte(
+				`{"took":3,"errors":false,"items":[{"index":{"_index":"events-2021.05.20","status":201}}]}`))
+			if err != nil {
+				http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+			}
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	pub, err := New(context.Background(), Config{
+		URL:        srv.URL,
+		Index:      "events",
+		DailyIndex: true,
+		Username:   "foo",
+		Password:   "bar",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create elasticsearch publisher: %v", err)
+	}
+	pub.currentTime = func() time.Time {
+		return time.Date(2021, 5, 20, 10, 0, 0, 0, time.UTC)
+	}
+
+	err = pub.Send(context.Background(), []byte(`{"name":"foopackage"}`))
+	if err != nil {
+		t.Fatalf("Failed to send event: %v", err)
+	}
+	if len(lines) != 0 {
+		t.Fatalf("Bulk request was sent before the batch was flushed")
+	}
+	if err := pub.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush events: %v", err)
+	}
+
+	if len(lines) != 2 {
+		t.Fatalf("Bulk request contained %v lines when 2 were expected", len(lines))
+	}
+	action := bulkAction{}
+	if err := json.Unmarshal([]byte(lines[0]), &action); err != nil {
+		t.Fatalf("Failed to decode bulk action: %v", err)
+	}
+	if action.Index.Index != "events-2021.05.20" {
+		t.Errorf("Event was indexed into `%v` when `events-2021.05.20` was expected", action.Index.Index)
+	}
+	if lines[1] != `{"name":"foopackage"}` {
+		t.Errorf("Bulk request contained unexpected document `%v`", lines[1])
+	}
+	if username != "foo" || password != "bar" {
+		t.Errorf("Bulk request was not sent with the configured basic auth credentials")
+	}
+}
+
+func TestElasticsearchSendItemError(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		bulkPath: func(w http.ResponseWriter, r *http.Request) {
+			_, err := w.Write([]byte(`{"took":3,"errors":true,"items":[{"index":{"_index":"events","status":400,
+			"error":{"type":"mapper_parsing_exception","reason":"failed to parse field [created_date]"}}}]}`))
+			if err != nil {
+				http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+			}
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	pub, err := New(context.Background(), Config{URL: srv.URL, Index: "events"})
+	if err != nil {
+		t.Fatalf("Failed to create elasticsearch publisher: %v", err)
+	}
+
+	err = pub.Send(context.Background(), []byte(`{"name":"foopackage"}`))
+	if err != nil {
+		t.Fatalf("Failed to send event: %v", err)
+	}
+	err = pub.Flush(context.Background())
+	if !errors.Is(err, errBulkItem) {
+		t.Fatalf("Flush() returned `%v` when a bulk item error was expected", err)
+	}
+}
+
+func TestElasticsearchSendBatch(t *testing.T) {
+	t.Parallel()
+
+	var requests [][]string
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		bulkPath: func(w http.ResponseWriter, r *http.Request) {
+			lines := []string{}
+			scanner := bufio.NewScanner(r.Body)
+			for scanner.Scan() {
+				lines = append(lines, scanner.Text())
+			}
+			requests = append(requests, lines)
+			_, err := w.Write([]byte(`{"took":3,"errors":false,"items":[]}`))
+			if err != nil {
+				http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+			}
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+	defer srv.Close()
+
+	pub, err := New(context.Background(), Config{URL: srv.URL, Index: "events", BatchSize: 2})
+	if err != nil {
+		t.Fatalf("Failed to create elasticsearch publisher: %v", err)
+	}
+
+	for _, body := range []string{`{"name":"foo"}`, `{"name":"bar"}`, `{"name":"baz"}`} {
+		if err := pub.Send(context.Background(), []byte(body)); err != nil {
+			t.Fatalf("Failed to send event: %v", err)
+		}
+	}
+	if len(requests) != 1 {
+		t.Fatalf("%v bulk requests were sent when 1 was expected for a full batch", len(requests))
+	}
+	if len(requests[0]) != 4 || requests[0][1] != `{"name":"foo"}` || requests[0][3] != `{"name":"bar"}` {
+		t.Errorf("Bulk request contained `%v` when the first two events were expected", requests[0])
+	}
+
+	if err := pub.Close(context.Background()); err != nil {
+		t.Fatalf("Failed to close publisher: %v", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("%v bulk requests were sent when 2 were expected after closing", len(requests))
+	}
+	if len(requests[1]) != 2 || requests[1][1] != `{"name":"baz"}` {
+		t.Errorf("Bulk request contained `%v` when the remaining event was expected", requests[1])
+	}
+
+	// Nothing is sent when no events are buffered.
+	if err := pub.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush events: %v", err)
+	}
+	if len(requests) != 2 {
+		t.Errorf("%v bulk requests were sent when the flushed batch was empty", len(requests))
+	}
+}
+
+func TestElasticsearchMissingConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(context.Background(), Config{URL: "http://localhost:9200"})
+	if !errors.Is(err, errMissingConfig) {
+		t.Fatalf("New() returned `%v` when a missing config error was expected", err)
+	}
+}
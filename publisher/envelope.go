@@ -0,0 +1,65 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Envelope wraps a published package with metadata about the poll which produced it, to
+// trace a package received downstream back to the poll cycle which published it.
+type Envelope struct {
+	// Identifies the poll cycle, as published in its cycle summary.
+	CycleID  string    `json:"cycle_id"`
+	PolledAt time.Time `json:"polled_at"`
+	Feed     string    `json:"feed"`
+	// When the package was first seen, a time before PolledAt means the package was seen
+	// by an earlier poll and published again rather than deduplicated.
+	DedupFirstSeen time.Time `json:"dedup_first_seen"`
+	// The package as it would be published without an envelope.
+	Package json.RawMessage `json:"package"`
+}
+
+// enveloping is a Publisher which wraps each package in an Envelope before sending it to
+// the wrapped publisher, messages which are not packages are sent unchanged.
+type enveloping struct {
+	Publisher
+}
+
+// WithEnvelope wraps the publisher so that packages are sent in an Envelope. The package
+// payload is unchanged, so the envelope must wrap the publisher before it is wrapped by
+// any change of format or field naming.
+func WithEnvelope(pub Publisher) Publisher {
+	return &enveloping{Publisher: pub}
+}
+
+func (e *enveloping) Send(ctx context.Context, body []byte) error {
+	pkg, ok := PackageFromContext(ctx)
+	if !ok {
+		// Events published in place of packages, such as heartbeats, have no poll to describe.
+		return e.Publisher.Send(ctx, body)
+	}
+	var firstSeen time.Time
+	if pkg.FirstSeen != nil {
+		firstSeen = *pkg.FirstSeen
+	}
+	wrapped, err := json.Marshal(Envelope{
+		CycleID:        pkg.CycleID,
+		PolledAt:       pkg.PolledAt,
+		Feed:           pkg.Type,
+		DedupFirstSeen: firstSeen,
+		Package:        body,
+	})
+	if err != nil {
+		return err
+	}
+	return e.Publisher.Send(ctx, wrapped)
+}
+
+func (e *enveloping) Flush(ctx context.Context) error {
+	return Flush(ctx, e.Publisher)
+}
+
+func (e *enveloping) Close(ctx context.Context) error {
+	return Close(ctx, e.Publisher)
+}
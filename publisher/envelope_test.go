@@ -0,0 +1,50 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+)
+
+func TestWithEnvelope(t *testing.T) {
+	t.Parallel()
+
+	mock := &mockPublisher{name: "mock"}
+	pub := WithEnvelope(mock)
+	if pub.Name() != "mock" {
+		t.Errorf("Name() returned %q instead of the wrapped publisher name", pub.Name())
+	}
+
+	firstSeen := time.Date(2021, 5, 11, 18, 0, 0, 0, time.UTC)
+	polledAt := firstSeen.Add(time.Minute)
+	pkg := &feeds.Package{Name: "foo", Type: "npm", FirstSeen: &firstSeen, CycleID: "0123abcd", PolledAt: polledAt}
+	body := []byte(`{"name":"foo","type":"npm"}`)
+	if err := pub.Send(ContextWithPackage(context.Background(), pkg), body); err != nil {
+		t.Fatalf("Send() returned unexpected error: %v", err)
+	}
+	if len(mock.received) != 1 {
+		t.Fatalf("Wrapped publisher received %v messages instead of 1", len(mock.received))
+	}
+	var envelope Envelope
+	if err := json.Unmarshal(mock.received[0], &envelope); err != nil {
+		t.Fatalf("Failed to unmarshal sent envelope: %v", err)
+	}
+	if envelope.CycleID != "0123abcd" || !envelope.PolledAt.Equal(polledAt) || envelope.Feed != "npm" ||
+		!envelope.DedupFirstSeen.Equal(firstSeen) {
+		t.Errorf("Sent envelope %s does not carry the metadata of the poll", mock.received[0])
+	}
+	if string(envelope.Package) != string(body) {
+		t.Errorf("Sent envelope wraps %s instead of the unchanged package %s", envelope.Package, body)
+	}
+
+	event := []byte(`{"event":"heartbeat"}`)
+	if err := pub.Send(context.Background(), event); err != nil {
+		t.Fatalf("Send() returned unexpected error: %v", err)
+	}
+	if len(mock.received) != 2 || string(mock.received[1]) != string(event) {
+		t.Errorf("Sent %s when an event without a package should be sent unchanged", mock.received[len(mock.received)-1])
+	}
+}
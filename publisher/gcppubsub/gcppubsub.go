@@ -2,8 +2,17 @@ package gcppubsub
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
 
+	gcppubsub "cloud.google.com/go/pubsub"
 	"gocloud.dev/pubsub"
+	"google.golang.org/api/option"
+
+	"github.com/ossf/package-feeds/publisher"
 
 	// Load gcp driver.
 	_ "gocloud.dev/pubsub/gcppubsub"
@@ -13,12 +22,29 @@ const (
 	PublisherType = "gcp_pubsub"
 )
 
+// The path of a topic within the URL of an ordered topic.
+var topicPathRE = regexp.MustCompile("^projects/([^/]+)/topics/([^/]+)$")
+
+var errOrderedTopicURL = errors.New("ordered topics must have a gcppubsub://projects/<project>/topics/<topic> url")
+
 type GCPPubSub struct {
 	topic *pubsub.Topic
+	// Set in place of topic when messages have an ordering key, as ordering must be enabled
+	// on the topic of a Pub/Sub client.
+	orderedTopic *gcppubsub.Topic
+	orderingKey  *publisher.Template
+	attributes   map[string]*publisher.Template
 }
 
 type Config struct {
+	// The URL of the topic, which may be a template of the package fields to publish packages
+	// to several topics e.g. "gcppubsub://projects/myproject/topics/pkgfeeds-{{.Type}}".
 	URL string `mapstructure:"url"`
+	// Template for the ordering key of messages, e.g. "{{.Type}}/{{.Name}}". Ordering
+	// is only enabled when this is set, the URL must then be the full path of the topic.
+	OrderingKey string `mapstructure:"ordering_key"`
+	// Message attributes, values may be templates e.g. "{{.Type}}".
+	Attributes map[string]string `mapstructure:"attributes"`
 }
 
 func New(ctx context.Context, url string) (*GCPPubSub, error) {
@@ -32,8 +58,82 @@ func New(ctx context.Context, url string) (*GCPPubSub, error) {
 	return pub, nil
 }
 
-func FromConfig(ctx context.Context, config Config) (*GCPPubSub, error) {
-	return New(ctx, config.URL)
+// FromConfig returns a publisher for the configured topic, or a publisher.TopicRouter when
+// the URL is a template.
+func FromConfig(ctx context.Context, config Config) (publisher.Publisher, error) {
+	topicURL, err := publisher.NewTemplate(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url template: %w", err)
+	}
+	if topicURL.Static() {
+		pub, err := fromConfig(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+		return pub, nil
+	}
+	return publisher.NewTopicRouter(PublisherType, topicURL,
+		func(ctx context.Context, topic string) (publisher.Publisher, error) {
+			topicConfig := config
+			topicConfig.URL = topic
+			pub, err := fromConfig(ctx, topicConfig)
+			if err != nil {
+				return nil, err
+			}
+			return pub, nil
+		}), nil
+}
+
+// NewOrdered returns a publisher for the topic of the URL which publishes messages sharing
+// an ordering key in order.
+func NewOrdered(ctx context.Context, topicURL string, opts ...option.ClientOption) (*GCPPubSub, error) {
+	u, err := url.Parse(topicURL)
+	if err != nil {
+		return nil, err
+	}
+	match := topicPathRE.FindStringSubmatch(path.Join(u.Host, u.Path))
+	if u.Scheme != "gcppubsub" || match == nil {
+		return nil, fmt.Errorf("%w : %v", errOrderedTopicURL, topicURL)
+	}
+	client, err := gcppubsub.NewClient(ctx, match[1], opts...)
+	if err != nil {
+		return nil, err
+	}
+	topic := client.Topic(match[2])
+	topic.EnableMessageOrdering = true
+	return &GCPPubSub{orderedTopic: topic}, nil
+}
+
+// Returns the publisher of the config, the options configure the Pub/Sub client of ordered
+// topics.
+func fromConfig(ctx context.Context, config Config, opts ...option.ClientOption) (*GCPPubSub, error) {
+	var pub *GCPPubSub
+	var err error
+	if config.OrderingKey != "" {
+		pub, err = NewOrdered(ctx, config.URL, opts...)
+		if err != nil {
+			return nil, err
+		}
+		pub.orderingKey, err = publisher.NewTemplate(config.OrderingKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ordering_key template: %w", err)
+		}
+	} else {
+		pub, err = New(ctx, config.URL)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(config.Attributes) > 0 {
+		pub.attributes = map[string]*publisher.Template{}
+		for key, value := range config.Attributes {
+			pub.attributes[key], err = publisher.NewTemplate(value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse template for attribute %s: %w", key, err)
+			}
+		}
+	}
+	return pub, nil
 }
 
 func (pub *GCPPubSub) Name() string {
@@ -41,7 +141,57 @@ func (pub *GCPPubSub) Name() string {
 }
 
 func (pub *GCPPubSub) Send(ctx context.Context, body []byte) error {
-	return pub.topic.Send(ctx, &pubsub.Message{
-		Body: body,
+	attributes, orderingKey, err := pub.render(ctx)
+	if err != nil {
+		return err
+	}
+	if pub.orderedTopic == nil {
+		return pub.topic.Send(ctx, &pubsub.Message{
+			Body:     body,
+			Metadata: attributes,
+		})
+	}
+	result := pub.orderedTopic.Publish(ctx, &gcppubsub.Message{
+		Data:        body,
+		Attributes:  attributes,
+		OrderingKey: orderingKey,
 	})
+	if _, err := result.Get(ctx); err != nil {
+		// Publishing messages with the ordering key is paused after a failure, so that later
+		// messages aren't published before it. It is resumed for the caller to retry the message.
+		if orderingKey != "" {
+			pub.orderedTopic.ResumePublish(orderingKey)
+		}
+		return err
+	}
+	return nil
+}
+
+// Renders the attributes and ordering key of a message from the package carried by the
+// context. Events published in place of packages, such as heartbeats, only carry the
+// attributes which don't reference the package, and no ordering key.
+func (pub *GCPPubSub) render(ctx context.Context) (map[string]string, string, error) {
+	pkg, isPackage := publisher.PackageFromContext(ctx)
+	var attributes map[string]string
+	if len(pub.attributes) > 0 {
+		attributes = map[string]string{}
+		for key, tmpl := range pub.attributes {
+			if !isPackage && !tmpl.Static() {
+				continue
+			}
+			value, err := tmpl.Execute(pkg)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to render attribute %s: %w", key, err)
+			}
+			attributes[key] = value
+		}
+	}
+	if pub.orderingKey == nil || !isPackage {
+		return attributes, "", nil
+	}
+	orderingKey, err := pub.orderingKey.Execute(pkg)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to render ordering key: %w", err)
+	}
+	return attributes, orderingKey, nil
 }
@@ -0,0 +1,169 @@
+package gcppubsub
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gcppubsub "cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+	"gocloud.dev/pubsub"
+	_ "gocloud.dev/pubsub/mempubsub"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/publisher"
+)
+
+func TestGCPPubSubAttributes(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	// The in-memory driver stands in for GCP, the topic must exist before subscribing.
+	topicURL := "mem://gcppubsub-test"
+	pub, err := fromConfig(ctx, Config{
+		URL: topicURL,
+		Attributes: map[string]string{
+			"feed":   "{{.Type}}",
+			"source": "package-feeds",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create publisher: %v", err)
+	}
+	sub, err := pubsub.OpenSubscription(ctx, topicURL)
+	if err != nil {
+		t.Fatalf("Failed to open subscription: %v", err)
+	}
+	defer sub.Shutdown(ctx) //nolint:errcheck
+
+	pkg := feeds.NewPackage(time.Now(), "foo", "1.0.0", "npm", feeds.EcosystemNPM)
+	if err := pub.Send(publisher.ContextWithPackage(ctx, pkg), []byte("body")); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+	received, err := sub.Receive(ctx)
+	if err != nil {
+		t.Fatalf("Failed to receive message: %v", err)
+	}
+	received.Ack()
+	if received.Metadata["feed"] != "npm" {
+		t.Errorf("Attribute feed `%v` does not match expected `npm`", received.Metadata["feed"])
+	}
+	if received.Metadata["source"] != "package-feeds" {
+		t.Errorf("Attribute source `%v` does not match expected `package-feeds`", received.Metadata["source"])
+	}
+
+	// Events have no package to render the attributes of the package from.
+	attributes, _, err := pub.render(ctx)
+	if err != nil {
+		t.Fatalf("Failed to render event attributes: %v", err)
+	}
+	if len(attributes) != 1 || attributes["source"] != "package-feeds" {
+		t.Errorf("Event message has attributes %v when only the static source was expected", attributes)
+	}
+}
+
+func TestGCPPubSubOrderingKey(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	srv := pstest.NewServer()
+	defer srv.Close()
+	conn, err := grpc.Dial(srv.Addr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("Failed to connect to the fake Pub/Sub server: %v", err)
+	}
+	defer conn.Close()
+	client, err := gcppubsub.NewClient(ctx, "my-project", option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := client.CreateTopic(ctx, "packagefeeds"); err != nil {
+		t.Fatalf("Failed to create topic: %v", err)
+	}
+
+	pub, err := fromConfig(ctx, Config{
+		URL:         "gcppubsub://projects/my-project/topics/packagefeeds",
+		OrderingKey: "{{.Type}}/{{.Name}}",
+	}, option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatalf("Failed to create publisher: %v", err)
+	}
+	defer pub.orderedTopic.Stop()
+	if !pub.orderedTopic.EnableMessageOrdering {
+		t.Errorf("Message ordering was not enabled on the topic")
+	}
+
+	pkg := feeds.NewPackage(time.Now(), "foo", "1.0.0", "npm", feeds.EcosystemNPM)
+	if err := pub.Send(publisher.ContextWithPackage(ctx, pkg), []byte("body")); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+	// Events have no package to render the ordering key from.
+	if err := pub.Send(ctx, []byte(`{"event":"heartbeat"}`)); err != nil {
+		t.Fatalf("Failed to send event: %v", err)
+	}
+	msgs := srv.Messages()
+	if len(msgs) != 2 {
+		t.Fatalf("%v messages were published when 2 were expected", len(msgs))
+	}
+	if msgs[0].OrderingKey != "npm/foo" {
+		t.Errorf("Ordering key `%v` does not match expected `npm/foo`", msgs[0].OrderingKey)
+	}
+	if msgs[1].OrderingKey != "" {
+		t.Errorf("Event message was given the ordering key `%v`", msgs[1].OrderingKey)
+	}
+
+	// Ordering is enabled on a topic of a project, so the topic can't be the short form.
+	_, err = fromConfig(ctx, Config{URL: "gcppubsub://my-project/packagefeeds", OrderingKey: "{{.Name}}"})
+	if !errors.Is(err, errOrderedTopicURL) {
+		t.Errorf("fromConfig() returned `%v` when %v was expected", err, errOrderedTopicURL)
+	}
+}
+
+func TestGCPPubSubTopicTemplate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	// Topics are opened before subscribing, the in-memory driver shares topics by name.
+	subs := map[string]*pubsub.Subscription{}
+	for _, feed := range []string{"npm", "pypi"} {
+		topicURL := "mem://gcppubsub-routed-" + feed
+		topic, err := pubsub.OpenTopic(ctx, topicURL)
+		if err != nil {
+			t.Fatalf("Failed to open topic: %v", err)
+		}
+		defer topic.Shutdown(ctx) //nolint:errcheck
+		sub, err := pubsub.OpenSubscription(ctx, topicURL)
+		if err != nil {
+			t.Fatalf("Failed to open subscription: %v", err)
+		}
+		defer sub.Shutdown(ctx) //nolint:errcheck
+		subs[feed] = sub
+	}
+
+	pub, err := FromConfig(ctx, Config{URL: "mem://gcppubsub-routed-{{.Type}}"})
+	if err != nil {
+		t.Fatalf("Failed to create publisher: %v", err)
+	}
+	for _, pkg := range []*feeds.Package{
+		feeds.NewPackage(time.Now(), "foo", "1.0.0", "npm", feeds.EcosystemNPM),
+		feeds.NewPackage(time.Now(), "bar", "1.0.0", "pypi", feeds.EcosystemPyPI),
+	} {
+		if err := pub.Send(publisher.ContextWithPackage(ctx, pkg), []byte(pkg.Name)); err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+	}
+
+	for feed, expected := range map[string]string{"npm": "foo", "pypi": "bar"} {
+		received, err := subs[feed].Receive(ctx)
+		if err != nil {
+			t.Fatalf("Failed to receive message: %v", err)
+		}
+		received.Ack()
+		if string(received.Body) != expected {
+			t.Errorf("Topic of %s received `%s` when `%s` was expected", feed, received.Body, expected)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package httpendpoint
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ossf/package-feeds/publisher"
+	"github.com/ossf/package-feeds/utils"
+)
+
+const PublisherType = "httpendpoint"
+
+var (
+	errMissingURL       = errors.New("httpendpoint publisher requires a url")
+	errConflictingAuth  = errors.New("httpendpoint publisher accepts either basic auth or a bearer token, not both")
+	errAuthorizationSet = errors.New("the Authorization header is set by username and bearer_token")
+)
+
+// HTTPEndpoint POSTs each event to a URL as JSON.
+type HTTPEndpoint struct {
+	url         string
+	username    string
+	password    string
+	bearerToken string
+	headers     map[string]string
+	httpClient  *http.Client
+}
+
+type Config struct {
+	URL string `mapstructure:"url"`
+	// Credentials for basic authentication, the password may reference a secret.
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// A token sent as `Authorization: Bearer <token>`, this may reference a secret.
+	BearerToken string `mapstructure:"bearer_token"`
+	// Static headers added to every request.
+	Headers map[string]string `mapstructure:"headers"`
+}
+
+func New(ctx context.Context, config Config) (*HTTPEndpoint, error) {
+	if config.URL == "" {
+		return nil, errMissingURL
+	}
+	if config.Username != "" && config.BearerToken != "" {
+		return nil, errConflictingAuth
+	}
+	for name := range config.Headers {
+		if http.CanonicalHeaderKey(name) == "Authorization" {
+			return nil, errAuthorizationSet
+		}
+	}
+	return &HTTPEndpoint{
+		url:         config.URL,
+		username:    config.Username,
+		password:    config.Password,
+		bearerToken: config.BearerToken,
+		headers:     config.Headers,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}, nil
+}
+
+func FromConfig(ctx context.Context, config Config) (*HTTPEndpoint, error) {
+	return New(ctx, config)
+}
+
+func (pub *HTTPEndpoint) Name() string {
+	return PublisherType
+}
+
+// Send POSTs the event to the endpoint, any response other than 2xx is returned as an error.
+func (pub *HTTPEndpoint) Send(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pub.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for name, value := range pub.headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", publisher.ContentTypeFromContext(ctx))
+	if pub.username != "" {
+		req.SetBasicAuth(pub.username, pub.password)
+	}
+	if pub.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+pub.bearerToken)
+	}
+
+	resp, err := pub.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := utils.CheckResponseStatus(resp); err != nil {
+		return fmt.Errorf("failed to post event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package httpendpoint
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/ossf/package-feeds/utils"
+	testutils "github.com/ossf/package-feeds/utils/test"
+)
+
+type receivedEvent struct {
+	header http.Header
+	body   string
+}
+
+// Returns a receiver which only accepts events with the given Authorization header, each
+// accepted event is sent to received.
+func authReceiver(authorization string, received chan<- receivedEvent) map[string]testutils.HTTPHandlerFunc {
+	return map[string]testutils.HTTPHandlerFunc{
+		"/events": func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != authorization {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			received <- receivedEvent{header: r.Header, body: string(body)}
+		},
+	}
+}
+
+func TestHTTPEndpointBearerToken(t *testing.T) {
+	t.Parallel()
+
+	received := make(chan receivedEvent, 1)
+	srv := testutils.HTTPServerMock(authReceiver("Bearer s3cret", received))
+	defer srv.Close()
+
+	pub, err := New(context.Background(), Config{
+		URL:         srv.URL + "/events",
+		BearerToken: "s3cret",
+		Headers:     map[string]string{"X-Source": "package-feeds"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create httpendpoint publisher: %v", err)
+	}
+	if err := pub.Send(context.Background(), []byte(`{"name":"foopackage"}`)); err != nil {
+		t.Fatalf("Failed to send event: %v", err)
+	}
+	event := <-received
+	if event.header.Get("X-Source") != "package-feeds" {
+		t.Errorf("X-Source header was %q when the configured header was expected", event.header.Get("X-Source"))
+	}
+	if event.header.Get("Content-Type") != "application/json" {
+		t.Errorf("Content-Type header was %q when application/json was expected", event.header.Get("Content-Type"))
+	}
+	if event.body != `{"name":"foopackage"}` {
+		t.Errorf("Received body %q when the event was expected", event.body)
+	}
+
+	// Without the token the receiver rejects the event.
+	pub, err = New(context.Background(), Config{URL: srv.URL + "/events"})
+	if err != nil {
+		t.Fatalf("Failed to create httpendpoint publisher: %v", err)
+	}
+	if err := pub.Send(context.Background(), []byte(`{}`)); !errors.Is(err, utils.ErrUnsuccessfulRequest) {
+		t.Errorf("Send() returned `%v` when an unsuccessful request error was expected", err)
+	}
+}
+
+func TestHTTPEndpointBasicAuth(t *testing.T) {
+	t.Parallel()
+
+	received := make(chan receivedEvent, 1)
+	// base64("foo:bar")
+	srv := testutils.HTTPServerMock(authReceiver("Basic Zm9vOmJhcg==", received))
+	defer srv.Close()
+
+	pub, err := New(context.Background(), Config{
+		URL:      srv.URL + "/events",
+		Username: "foo",
+		Password: "bar",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create httpendpoint publisher: %v", err)
+	}
+	if err := pub.Send(context.Background(), []byte(`{"name":"foopackage"}`)); err != nil {
+		t.Fatalf("Failed to send event: %v", err)
+	}
+	<-received
+}
+
+func TestHTTPEndpointInvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config Config
+		err    error
+	}{
+		"missing url": {
+			config: Config{},
+			err:    errMissingURL,
+		},
+		"basic auth and bearer token": {
+			config: Config{URL: "http://127.0.0.1", Username: "foo", BearerToken: "bar"},
+			err:    errConflictingAuth,
+		},
+		"authorization header": {
+			config: Config{URL: "http://127.0.0.1", Headers: map[string]string{"authorization": "foo"}},
+			err:    errAuthorizationSet,
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := New(context.Background(), test.config); !errors.Is(err, test.err) {
+				t.Errorf("New() returned `%v` when `%v` was expected", err, test.err)
+			}
+		})
+	}
+}
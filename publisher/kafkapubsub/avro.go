@@ -0,0 +1,270 @@
+package kafkapubsub
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/utils"
+)
+
+// The Avro schema of packages, this must be kept in sync with package.schema.json.
+const packageAvroSchema = `{
+	"type": "record",
+	"name": "Package",
+	"namespace": "dev.openssf.packagefeeds",
+	"fields": [
+		{"name": "name", "type": "string"},
+		{"name": "version", "type": "string"},
+		{"name": "created_date", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "type", "type": "string"},
+		{"name": "schema_ver", "type": "string"},
+		{"name": "republished", "type": "boolean", "default": false},
+		{"name": "integrity", "type": ["null", "string"], "default": null},
+		{"name": "signed", "type": "boolean", "default": false},
+		{"name": "attestations", "type": {"type": "array", "items": "string"}, "default": []},
+		{"name": "ecosystem", "type": ["null", "string"], "default": null},
+		{"name": "first_seen", "type": ["null", {"type": "long", "logicalType": "timestamp-millis"}], "default": null},
+		{"name": "labels", "type": {"type": "map", "values": "string"}, "default": {}},
+		{"name": "source_repo", "type": ["null", "string"], "default": null},
+		{"name": "downloads", "type": "long", "default": 0},
+		{"name": "has_install_scripts", "type": "boolean", "default": false},
+		{"name": "deprecated", "type": "boolean", "default": false},
+		{"name": "deprecation_message", "type": ["null", "string"], "default": null},
+		{"name": "unpacked_size", "type": "long", "default": 0},
+		{"name": "file_count", "type": "long", "default": 0},
+		{"name": "license", "type": ["null", "string"], "default": null},
+		{"name": "vulnerabilities", "type": {"type": "array", "items": {
+			"type": "record",
+			"name": "Vulnerability",
+			"fields": [
+				{"name": "id", "type": "string"},
+				{"name": "title", "type": ["null", "string"], "default": null},
+				{"name": "cve", "type": ["null", "string"], "default": null},
+				{"name": "cvss_score", "type": "double", "default": 0},
+				{"name": "reference", "type": ["null", "string"], "default": null}
+			]
+		}}, "default": []},
+		{"name": "raw", "type": ["null", "string"], "default": null}
+	]
+}`
+
+// The Confluent wire format prefixes the Avro payload with a zero magic byte and the 4 byte
+// big-endian schema ID.
+const wireFormatMagicByte = 0
+
+var (
+	registryHTTPClient = utils.NewHTTPClient(10 * time.Second)
+
+	errSchemaRegistryURL = errors.New("schema_registry_url is required for avro serialization")
+)
+
+// avroEncoder serializes packages as Avro in the Confluent wire format, the schema is
+// registered with the schema registry on first use.
+type avroEncoder struct {
+	registryURL string
+	subject     string
+
+	mu       sync.Mutex
+	schemaID int
+}
+
+func newAvroEncoder(registryURL, subject string) (*avroEncoder, error) {
+	if registryURL == "" {
+		return nil, errSchemaRegistryURL
+	}
+	return &avroEncoder{registryURL: registryURL, subject: subject}, nil
+}
+
+// Registers the package schema under the subject, returning its ID. Registering a schema
+// which is already registered returns the existing ID.
+func (e *avroEncoder) registerSchema(ctx context.Context) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.schemaID != 0 {
+		return e.schemaID, nil
+	}
+
+	registerURL, err := utils.URLPathJoin(e.registryURL, "/subjects/"+url.PathEscape(e.subject)+"/versions")
+	if err != nil {
+		return 0, err
+	}
+	body, err := json.Marshal(map[string]string{"schema": packageAvroSchema})
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, registerURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	resp, err := registryHTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	err = utils.CheckResponseStatus(resp)
+	if err != nil {
+		return 0, fmt.Errorf("failed to register avro schema: %w", err)
+	}
+	registered := struct {
+		ID int `json:"id"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&registered); err != nil {
+		return 0, err
+	}
+	e.schemaID = registered.ID
+	return e.schemaID, nil
+}
+
+// Serialize implements publisher.Serializer, the schema is registered within the timeout of
+// the registry client.
+func (e *avroEncoder) Serialize(pkg *feeds.Package) ([]byte, error) {
+	return e.encode(context.Background(), pkg)
+}
+
+func (e *avroEncoder) ContentType() string {
+	return "application/vnd.confluent.avro"
+}
+
+func (e *avroEncoder) encode(ctx context.Context, pkg *feeds.Package) ([]byte, error) {
+	schemaID, err := e.registerSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	buf.WriteByte(wireFormatMagicByte)
+	var id [4]byte
+	binary.BigEndian.PutUint32(id[:], uint32(schemaID))
+	buf.Write(id[:])
+	encodeAvroPackage(buf, pkg)
+	return buf.Bytes(), nil
+}
+
+// Encodes the package as an Avro binary record following packageAvroSchema.
+func encodeAvroPackage(buf *bytes.Buffer, pkg *feeds.Package) {
+	writeAvroString(buf, pkg.Name)
+	writeAvroString(buf, pkg.Version)
+	writeAvroLong(buf, pkg.CreatedDate.UnixNano()/int64(time.Millisecond))
+	writeAvroString(buf, pkg.Type)
+	writeAvroString(buf, pkg.SchemaVer)
+	writeAvroBoolean(buf, pkg.Republished)
+	writeAvroOptionalString(buf, pkg.Integrity)
+	writeAvroBoolean(buf, pkg.Signed)
+	writeAvroStringArray(buf, pkg.Attestations)
+	writeAvroOptionalString(buf, string(pkg.Ecosystem))
+	writeAvroOptionalTimestamp(buf, pkg.FirstSeen)
+	writeAvroStringMap(buf, pkg.Labels)
+	writeAvroOptionalString(buf, pkg.SourceRepo)
+	writeAvroLong(buf, int64(pkg.Downloads))
+	writeAvroBoolean(buf, pkg.HasInstallScripts)
+	writeAvroBoolean(buf, pkg.Deprecated)
+	writeAvroOptionalString(buf, pkg.DeprecationMessage)
+	writeAvroLong(buf, pkg.UnpackedSize)
+	writeAvroLong(buf, int64(pkg.FileCount))
+	writeAvroOptionalString(buf, pkg.License)
+	writeAvroVulnerabilities(buf, pkg.Vulnerabilities)
+	writeAvroOptionalString(buf, string(pkg.Raw))
+}
+
+// Longs are encoded as zig-zag variable length integers.
+func writeAvroLong(buf *bytes.Buffer, v int64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(b[:], v)
+	buf.Write(b[:n])
+}
+
+func writeAvroString(buf *bytes.Buffer, s string) {
+	writeAvroLong(buf, int64(len(s)))
+	buf.WriteString(s)
+}
+
+func writeAvroBoolean(buf *bytes.Buffer, v bool) {
+	if v {
+		buf.WriteByte(1)
+	} else {
+		buf.WriteByte(0)
+	}
+}
+
+// Doubles are encoded as 8 bytes in little-endian order.
+func writeAvroDouble(buf *bytes.Buffer, v float64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], math.Float64bits(v))
+	buf.Write(b[:])
+}
+
+// Encodes a ["null", "string"] union, empty strings are encoded as null.
+func writeAvroOptionalString(buf *bytes.Buffer, s string) {
+	if s == "" {
+		writeAvroLong(buf, 0)
+		return
+	}
+	writeAvroLong(buf, 1)
+	writeAvroString(buf, s)
+}
+
+// Encodes a ["null", "timestamp-millis"] union, zero times are encoded as null.
+func writeAvroOptionalTimestamp(buf *bytes.Buffer, t *time.Time) {
+	if t == nil || t.IsZero() {
+		writeAvroLong(buf, 0)
+		return
+	}
+	writeAvroLong(buf, 1)
+	writeAvroLong(buf, t.UnixNano()/int64(time.Millisecond))
+}
+
+// Arrays are encoded as a block of items preceded by their count, followed by an empty block.
+func writeAvroStringArray(buf *bytes.Buffer, items []string) {
+	if len(items) > 0 {
+		writeAvroLong(buf, int64(len(items)))
+		for _, item := range items {
+			writeAvroString(buf, item)
+		}
+	}
+	writeAvroLong(buf, 0)
+}
+
+// Encodes an array of Vulnerability records, whose fields are written in schema order.
+func writeAvroVulnerabilities(buf *bytes.Buffer, vulns []feeds.Vulnerability) {
+	if len(vulns) > 0 {
+		writeAvroLong(buf, int64(len(vulns)))
+		for _, vuln := range vulns {
+			writeAvroString(buf, vuln.ID)
+			writeAvroOptionalString(buf, vuln.Title)
+			writeAvroOptionalString(buf, vuln.CVE)
+			writeAvroDouble(buf, vuln.CVSSScore)
+			writeAvroOptionalString(buf, vuln.Reference)
+		}
+	}
+	writeAvroLong(buf, 0)
+}
+
+// Maps are encoded as a block of key value pairs preceded by their count, followed by an
+// empty block. Keys are written in sorted order so that encoding is deterministic.
+func writeAvroStringMap(buf *bytes.Buffer, m map[string]string) {
+	if len(m) > 0 {
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		writeAvroLong(buf, int64(len(keys)))
+		for _, key := range keys {
+			writeAvroString(buf, key)
+			writeAvroString(buf, m[key])
+		}
+	}
+	writeAvroLong(buf, 0)
+}
@@ -0,0 +1,279 @@
+package kafkapubsub
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"gocloud.dev/pubsub"
+	_ "gocloud.dev/pubsub/mempubsub"
+
+	"github.com/ossf/package-feeds/feeds"
+	"github.com/ossf/package-feeds/publisher"
+	testutils "github.com/ossf/package-feeds/utils/test"
+)
+
+func TestKafkaAvroSend(t *testing.T) {
+	t.Parallel()
+
+	registrations := 0
+	handlers := map[string]testutils.HTTPHandlerFunc{
+		"/subjects/packages-value/versions": func(w http.ResponseWriter, r *http.Request) {
+			registrations++
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			req := map[string]string{}
+			if err := json.Unmarshal(body, &req); err != nil || req["schema"] != packageAvroSchema {
+				http.Error(w, "unexpected schema", http.StatusUnprocessableEntity)
+				return
+			}
+			_, err = w.Write([]byte(`{"id": 42}`))
+			if err != nil {
+				http.Error(w, testutils.UnexpectedWriteError(err), http.StatusInternalServerError)
+			}
+		},
+	}
+	srv := testutils.HTTPServerMock(handlers)
+
+	ctx := context.Background()
+	// The in-memory driver stands in for Kafka.
+	topicURL := "mem://kafka-avro-test"
+	topic, err := pubsub.OpenTopic(ctx, topicURL)
+	if err != nil {
+		t.Fatalf("Failed to open topic: %v", err)
+	}
+	sub, err := pubsub.OpenSubscription(ctx, topicURL)
+	if err != nil {
+		t.Fatalf("Failed to open subscription: %v", err)
+	}
+	defer sub.Shutdown(ctx) //nolint:errcheck
+
+	avro, err := newAvroEncoder(srv.URL, "packages-value")
+	if err != nil {
+		t.Fatalf("Failed to create avro encoder: %v", err)
+	}
+	pub := &KafkaPubSub{topic: topic, serializer: avro}
+
+	created := time.Date(2021, 5, 11, 18, 32, 1, 0, time.UTC)
+	pkg := feeds.NewPackage(created, "foo", "1.0.0", "npm", feeds.EcosystemNPM)
+	pkg.Integrity = "sha1-LNJvAl5ZLT5IYDgcYk4/aQRGbvM="
+	pkg.Signed = true
+	pkg.Attestations = []string{"https://slsa.dev/provenance/v1"}
+	pkg.Labels = map[string]string{"region": "us", "env": "prod"}
+	pkg.SourceRepo = "https://github.com/foo/foo"
+	pkg.Downloads = 1234
+	pkg.HasInstallScripts = true
+	pkg.Deprecated = true
+	pkg.DeprecationMessage = "Use bar instead"
+	pkg.UnpackedSize = 52480
+	pkg.FileCount = 12
+	pkg.License = "MIT"
+	pkg.Vulnerabilities = []feeds.Vulnerability{{ID: "CVE-2021-23337", CVSSScore: 7.2}}
+	pkg.Raw = []byte(`{"name":"foo"}`)
+	for i := 0; i < 2; i++ {
+		if err := pub.Send(publisher.ContextWithPackage(ctx, pkg), []byte("ignored")); err != nil {
+			t.Fatalf("Send() returned unexpected error: %v", err)
+		}
+	}
+	if registrations != 1 {
+		t.Errorf("Schema was registered %v times instead of once", registrations)
+	}
+
+	msg, err := sub.Receive(ctx)
+	if err != nil {
+		t.Fatalf("Failed to receive message: %v", err)
+	}
+	msg.Ack()
+
+	r := bytes.NewReader(msg.Body)
+	if magic, _ := r.ReadByte(); magic != wireFormatMagicByte {
+		t.Fatalf("Message starts with %v instead of the magic byte", magic)
+	}
+	var id uint32
+	if err := binary.Read(r, binary.BigEndian, &id); err != nil || id != 42 {
+		t.Fatalf("Message has schema ID %v (%v) instead of 42", id, err)
+	}
+	for _, expected := range []string{"foo", "1.0.0"} {
+		if s := readAvroString(t, r); s != expected {
+			t.Errorf("Decoded %q in place of %q", s, expected)
+		}
+	}
+	if millis := readAvroLong(t, r); millis != created.Unix()*1000 {
+		t.Errorf("Decoded created date %v in place of %v", millis, created.Unix()*1000)
+	}
+	for _, expected := range []string{"npm", pkg.SchemaVer} {
+		if s := readAvroString(t, r); s != expected {
+			t.Errorf("Decoded %q in place of %q", s, expected)
+		}
+	}
+	if republished, _ := r.ReadByte(); republished != 0 {
+		t.Errorf("Decoded republished as %v instead of false", republished)
+	}
+	if branch := readAvroLong(t, r); branch != 1 {
+		t.Fatalf("Decoded integrity union branch %v instead of string", branch)
+	}
+	if s := readAvroString(t, r); s != pkg.Integrity {
+		t.Errorf("Decoded integrity %q in place of %q", s, pkg.Integrity)
+	}
+	if signed, _ := r.ReadByte(); signed != 1 {
+		t.Errorf("Decoded signed as %v instead of true", signed)
+	}
+	if count := readAvroLong(t, r); count != 1 {
+		t.Fatalf("Decoded attestations block of %v items instead of 1", count)
+	}
+	if s := readAvroString(t, r); s != pkg.Attestations[0] {
+		t.Errorf("Decoded attestation %q in place of %q", s, pkg.Attestations[0])
+	}
+	if count := readAvroLong(t, r); count != 0 {
+		t.Errorf("Decoded attestations block of %v items instead of the terminating block", count)
+	}
+	if branch := readAvroLong(t, r); branch != 1 {
+		t.Fatalf("Decoded ecosystem union branch %v instead of string", branch)
+	}
+	if s := readAvroString(t, r); s != string(feeds.EcosystemNPM) {
+		t.Errorf("Decoded ecosystem %q in place of %q", s, feeds.EcosystemNPM)
+	}
+	if branch := readAvroLong(t, r); branch != 0 {
+		t.Errorf("Decoded first seen union branch %v instead of null", branch)
+	}
+	if count := readAvroLong(t, r); count != 2 {
+		t.Fatalf("Decoded labels block of %v entries instead of 2", count)
+	}
+	// Labels are encoded in key order.
+	for _, expected := range []string{"env", "prod", "region", "us"} {
+		if s := readAvroString(t, r); s != expected {
+			t.Errorf("Decoded label %q in place of %q", s, expected)
+		}
+	}
+	if count := readAvroLong(t, r); count != 0 {
+		t.Errorf("Decoded labels block of %v entries instead of the terminating block", count)
+	}
+	if branch := readAvroLong(t, r); branch != 1 {
+		t.Fatalf("Decoded source repo union branch %v instead of string", branch)
+	}
+	if s := readAvroString(t, r); s != pkg.SourceRepo {
+		t.Errorf("Decoded source repo %q in place of %q", s, pkg.SourceRepo)
+	}
+	if downloads := readAvroLong(t, r); downloads != int64(pkg.Downloads) {
+		t.Errorf("Decoded downloads %v in place of %v", downloads, pkg.Downloads)
+	}
+	if hasInstallScripts, _ := r.ReadByte(); hasInstallScripts != 1 {
+		t.Errorf("Decoded has install scripts as %v instead of true", hasInstallScripts)
+	}
+	if deprecated, _ := r.ReadByte(); deprecated != 1 {
+		t.Errorf("Decoded deprecated as %v instead of true", deprecated)
+	}
+	if branch := readAvroLong(t, r); branch != 1 {
+		t.Fatalf("Decoded deprecation message union branch %v instead of string", branch)
+	}
+	if s := readAvroString(t, r); s != pkg.DeprecationMessage {
+		t.Errorf("Decoded deprecation message %q in place of %q", s, pkg.DeprecationMessage)
+	}
+	if size := readAvroLong(t, r); size != pkg.UnpackedSize {
+		t.Errorf("Decoded unpacked size %v in place of %v", size, pkg.UnpackedSize)
+	}
+	if count := readAvroLong(t, r); count != int64(pkg.FileCount) {
+		t.Errorf("Decoded file count %v in place of %v", count, pkg.FileCount)
+	}
+	if branch := readAvroLong(t, r); branch != 1 {
+		t.Fatalf("Decoded license union branch %v instead of string", branch)
+	}
+	if s := readAvroString(t, r); s != pkg.License {
+		t.Errorf("Decoded license %q in place of %q", s, pkg.License)
+	}
+	if count := readAvroLong(t, r); count != 1 {
+		t.Fatalf("Decoded %v vulnerabilities instead of 1", count)
+	}
+	if s := readAvroString(t, r); s != "CVE-2021-23337" {
+		t.Errorf("Decoded vulnerability ID %q in place of CVE-2021-23337", s)
+	}
+	// The title, CVE and reference are empty so encoded as null.
+	for _, field := range []string{"title", "cve"} {
+		if branch := readAvroLong(t, r); branch != 0 {
+			t.Errorf("Decoded vulnerability %v union branch %v instead of null", field, branch)
+		}
+	}
+	var score float64
+	if err := binary.Read(r, binary.LittleEndian, &score); err != nil || score != 7.2 {
+		t.Errorf("Decoded vulnerability CVSS score %v with error %v in place of 7.2", score, err)
+	}
+	if branch := readAvroLong(t, r); branch != 0 {
+		t.Errorf("Decoded vulnerability reference union branch %v instead of null", branch)
+	}
+	if end := readAvroLong(t, r); end != 0 {
+		t.Errorf("Decoded %v further vulnerabilities instead of the end of the array", end)
+	}
+	if branch := readAvroLong(t, r); branch != 1 {
+		t.Fatalf("Decoded raw union branch %v instead of string", branch)
+	}
+	if s := readAvroString(t, r); s != string(pkg.Raw) {
+		t.Errorf("Decoded raw %q in place of %q", s, pkg.Raw)
+	}
+	if r.Len() != 0 {
+		t.Errorf("%v unexpected trailing bytes in message", r.Len())
+	}
+}
+
+func TestKafkaAvroSendWithoutPackage(t *testing.T) {
+	t.Parallel()
+
+	avro, err := newAvroEncoder("http://127.0.0.1:8081", "packages-value")
+	if err != nil {
+		t.Fatalf("Failed to create avro encoder: %v", err)
+	}
+	pub := &KafkaPubSub{serializer: avro}
+	if err := pub.Send(context.Background(), []byte("body")); !errors.Is(err, publisher.ErrNoPackage) {
+		t.Fatalf("Expected publisher.ErrNoPackage, got: %v", err)
+	}
+}
+
+func TestKafkaFromConfigSerialization(t *testing.T) {
+	t.Parallel()
+
+	_, err := FromConfig(context.Background(), Config{Serialization: "protobuf"})
+	if !errors.Is(err, errUnknownSerialization) {
+		t.Errorf("Expected errUnknownSerialization, got: %v", err)
+	}
+	_, err = FromConfig(context.Background(), Config{Serialization: SerializationAvro})
+	if !errors.Is(err, errSchemaRegistryURL) {
+		t.Errorf("Expected errSchemaRegistryURL, got: %v", err)
+	}
+	serializer, err := newSerializer(Config{Serialization: publisher.FormatOSV})
+	if err != nil {
+		t.Errorf("newSerializer() returned unexpected error for the osv serialization: %v", err)
+	} else if _, ok := serializer.(publisher.OSVSerializer); !ok {
+		t.Errorf("newSerializer() returned %T instead of the OSV serializer", serializer)
+	}
+	// The serialization of a topic template is checked before any topic is opened.
+	_, err = FromConfig(context.Background(), Config{Topic: "pkgfeeds.{{.Type}}", Serialization: "protobuf"})
+	if !errors.Is(err, errUnknownSerialization) {
+		t.Errorf("Expected errUnknownSerialization, got: %v", err)
+	}
+}
+
+func readAvroLong(t *testing.T, r *bytes.Reader) int64 {
+	t.Helper()
+	v, err := binary.ReadVarint(r)
+	if err != nil {
+		t.Fatalf("Failed to decode avro long: %v", err)
+	}
+	return v
+}
+
+func readAvroString(t *testing.T, r *bytes.Reader) string {
+	t.Helper()
+	b := make([]byte, readAvroLong(t, r))
+	if _, err := r.Read(b); err != nil {
+		t.Fatalf("Failed to decode avro string: %v", err)
+	}
+	return string(b)
+}
@@ -2,22 +2,44 @@ package kafkapubsub
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"gocloud.dev/pubsub"
 	"gocloud.dev/pubsub/kafkapubsub"
+
+	"github.com/ossf/package-feeds/publisher"
 )
 
 const (
 	PublisherType = "kafka"
+
+	SerializationJSON = "json"
+	SerializationAvro = "avro"
+)
+
+var (
+	errUnknownSerialization = errors.New("unknown kafka serialization")
+	// Events published in place of packages are not Avro encoded packages, so are skipped
+	// rather than breaking consumers decoding the topic with the package schema.
+	errAvroEvent = fmt.Errorf("%w : avro serialization", publisher.ErrNoPackage)
 )
 
 type KafkaPubSub struct {
 	topic *pubsub.Topic
+	// Set when packages are serialized in another format than the JSON body, e.g. Avro.
+	serializer publisher.Serializer
 }
 
 type Config struct {
 	Brokers []string `mapstructure:"brokers"`
-	Topic   string   `mapstructure:"topic"`
+	// The topic to publish to, which may be a template of the package fields to publish
+	// packages to several topics e.g. "pkgfeeds.{{.Type}}".
+	Topic string `mapstructure:"topic"`
+	// Either json (default), avro or any other format supported by publisher.NewSerializer.
+	Serialization string `mapstructure:"serialization"`
+	// The URL of the Confluent Schema Registry, required for avro serialization.
+	SchemaRegistryURL string `mapstructure:"schema_registry_url"`
 }
 
 func New(ctx context.Context, brokers []string, topic string) (*KafkaPubSub, error) {
@@ -32,15 +54,83 @@ func New(ctx context.Context, brokers []string, topic string) (*KafkaPubSub, err
 	}, nil
 }
 
-func FromConfig(ctx context.Context, config Config) (*KafkaPubSub, error) {
-	return New(ctx, config.Brokers, config.Topic)
+// FromConfig returns a publisher for the configured topic, or a publisher.TopicRouter when
+// the topic is a template.
+func FromConfig(ctx context.Context, config Config) (publisher.Publisher, error) {
+	topic, err := publisher.NewTemplate(config.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse topic template: %w", err)
+	}
+	if topic.Static() {
+		pub, err := fromConfig(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+		return pub, nil
+	}
+	// The serialization is checked upfront, rather than when the first topic is opened.
+	if _, err := newSerializer(config); err != nil {
+		return nil, err
+	}
+	return publisher.NewTopicRouter(PublisherType, topic,
+		func(ctx context.Context, topic string) (publisher.Publisher, error) {
+			topicConfig := config
+			topicConfig.Topic = topic
+			pub, err := fromConfig(ctx, topicConfig)
+			if err != nil {
+				return nil, err
+			}
+			return pub, nil
+		}), nil
+}
+
+// Returns the serializer of the topic, nil for JSON which sends the body unchanged.
+func newSerializer(config Config) (publisher.Serializer, error) {
+	switch config.Serialization {
+	case "", SerializationJSON:
+		return nil, nil
+	case SerializationAvro:
+		// Schemas are registered under the value subject of the topic, following the
+		// default TopicNameStrategy of Confluent serializers.
+		return newAvroEncoder(config.SchemaRegistryURL, config.Topic+"-value")
+	default:
+		serializer, err := publisher.NewSerializer(config.Serialization)
+		if err != nil {
+			return nil, fmt.Errorf("%w : %v", errUnknownSerialization, config.Serialization)
+		}
+		return serializer, nil
+	}
+}
+
+func fromConfig(ctx context.Context, config Config) (*KafkaPubSub, error) {
+	serializer, err := newSerializer(config)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := New(ctx, config.Brokers, config.Topic)
+	if err != nil {
+		return nil, err
+	}
+	pub.serializer = serializer
+	return pub, nil
 }
 
 func (pub *KafkaPubSub) Name() string {
 	return PublisherType
 }
 
+// Send publishes the body, when serializing in another format, e.g. Avro, the package
+// being published is serialized in place of the body.
 func (pub *KafkaPubSub) Send(ctx context.Context, body []byte) error {
+	if _, avro := pub.serializer.(*avroEncoder); avro {
+		if _, ok := publisher.PackageFromContext(ctx); !ok {
+			return errAvroEvent
+		}
+	}
+	body, err := publisher.Serialize(ctx, pub.serializer, body)
+	if err != nil {
+		return err
+	}
 	return pub.topic.Send(ctx, &pubsub.Message{
 		Body: body,
 	})
@@ -0,0 +1,93 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var ErrMultiPublish = errors.New("failed to send to publishers")
+
+// Multi is a Publisher which fans out each Send to several publishers.
+type Multi struct {
+	publishers []Publisher
+}
+
+func NewMulti(publishers ...Publisher) *Multi {
+	return &Multi{publishers: publishers}
+}
+
+func (m *Multi) Name() string {
+	names := []string{}
+	for _, pub := range m.publishers {
+		names = append(names, pub.Name())
+	}
+	return fmt.Sprintf("multi(%s)", strings.Join(names, ", "))
+}
+
+// Send sends the body to all publishers concurrently, a failure of one publisher does not
+// prevent sending to the others. An error is returned if any publisher failed. Publishers
+// which skip events published in place of packages aren't failures, ErrNoPackage is only
+// returned when every publisher skipped the event.
+func (m *Multi) Send(ctx context.Context, body []byte) error {
+	errs := make([]error, len(m.publishers))
+	var wg sync.WaitGroup
+	for i, pub := range m.publishers {
+		wg.Add(1)
+		go func(i int, pub Publisher) {
+			defer wg.Done()
+			errs[i] = pub.Send(ctx, body)
+		}(i, pub)
+	}
+	wg.Wait()
+
+	failures := []string{}
+	skipped := 0
+	for i, err := range errs {
+		switch {
+		case errors.Is(err, ErrNoPackage):
+			skipped++
+		case err != nil:
+			failures = append(failures, fmt.Sprintf("%s: %v", m.publishers[i].Name(), err))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%w : %v", ErrMultiPublish, strings.Join(failures, "; "))
+	}
+	if skipped > 0 && skipped == len(m.publishers) {
+		return ErrNoPackage
+	}
+	return nil
+}
+
+// Flush flushes each publisher which batches packages, a failure of one publisher does not
+// prevent flushing the others.
+func (m *Multi) Flush(ctx context.Context) error {
+	failures := []string{}
+	for _, pub := range m.publishers {
+		if err := Flush(ctx, pub); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", pub.Name(), err))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%w : %v", ErrMultiPublish, strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// Close closes each publisher, a failure of one publisher does not prevent closing the
+// others.
+func (m *Multi) Close(ctx context.Context) error {
+	failures := []string{}
+	for _, pub := range m.publishers {
+		if err := Close(ctx, pub); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", pub.Name(), err))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%w : %v", ErrMultiPublish, strings.Join(failures, "; "))
+	}
+	return nil
+}
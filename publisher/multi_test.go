@@ -0,0 +1,107 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+var errMockSend = errors.New("mock send failure")
+
+type mockPublisher struct {
+	name string
+	err  error
+
+	mu       sync.Mutex
+	received [][]byte
+}
+
+func (pub *mockPublisher) Name() string {
+	return pub.name
+}
+
+func (pub *mockPublisher) Send(ctx context.Context, body []byte) error {
+	pub.mu.Lock()
+	defer pub.mu.Unlock()
+	pub.received = append(pub.received, body)
+	return pub.err
+}
+
+type mockFlusher struct {
+	mockPublisher
+	flushed int
+}
+
+func (pub *mockFlusher) Flush(ctx context.Context) error {
+	pub.flushed++
+	return pub.err
+}
+
+func TestMultiFlush(t *testing.T) {
+	t.Parallel()
+
+	foo := &mockFlusher{mockPublisher: mockPublisher{name: "foo", err: errMockSend}}
+	bar := &mockFlusher{mockPublisher: mockPublisher{name: "bar"}}
+	baz := &mockPublisher{name: "baz"}
+	multi := NewMulti(foo, bar, baz)
+
+	err := Flush(context.Background(), multi)
+	if !errors.Is(err, ErrMultiPublish) {
+		t.Errorf("Flush() returned %v when %v was expected", err, ErrMultiPublish)
+	}
+	if foo.flushed != 1 || bar.flushed != 1 {
+		t.Errorf("Publishers were flushed %v and %v times when 1 was expected", foo.flushed, bar.flushed)
+	}
+}
+
+func TestMultiSend(t *testing.T) {
+	t.Parallel()
+
+	foo := &mockPublisher{name: "foo"}
+	bar := &mockPublisher{name: "bar"}
+	multi := NewMulti(foo, bar)
+
+	if err := multi.Send(context.Background(), []byte("event")); err != nil {
+		t.Fatalf("Send() returned unexpected error: %v", err)
+	}
+	for _, pub := range []*mockPublisher{foo, bar} {
+		if len(pub.received) != 1 || string(pub.received[0]) != "event" {
+			t.Errorf("Publisher %v received %q when a single event was expected", pub.name, pub.received)
+		}
+	}
+	if multi.Name() != "multi(foo, bar)" {
+		t.Errorf("Unexpected publisher name %v", multi.Name())
+	}
+}
+
+func TestMultiSendPartialFailure(t *testing.T) {
+	t.Parallel()
+
+	foo := &mockPublisher{name: "foo", err: errMockSend}
+	bar := &mockPublisher{name: "bar"}
+	multi := NewMulti(foo, bar)
+
+	err := multi.Send(context.Background(), []byte("event"))
+	if !errors.Is(err, ErrMultiPublish) {
+		t.Fatalf("Send() returned `%v` when a multi publish error was expected", err)
+	}
+	if len(bar.received) != 1 {
+		t.Errorf("Failure of one publisher prevented sending to the others")
+	}
+}
+
+func TestMultiSendSkipped(t *testing.T) {
+	t.Parallel()
+
+	// A publisher skipping an event isn't a failure when the others send it.
+	foo := &mockPublisher{name: "foo", err: ErrNoPackage}
+	bar := &mockPublisher{name: "bar"}
+	if err := NewMulti(foo, bar).Send(context.Background(), []byte("event")); err != nil {
+		t.Errorf("Send() returned unexpected error: %v", err)
+	}
+	baz := &mockPublisher{name: "baz", err: ErrNoPackage}
+	if err := NewMulti(foo, baz).Send(context.Background(), []byte("event")); !errors.Is(err, ErrNoPackage) {
+		t.Errorf("Send() returned `%v` when ErrNoPackage was expected from every publisher skipping", err)
+	}
+}
@@ -0,0 +1,104 @@
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// FieldNamingSnakeCase publishes packages with snake_case field names, e.g. created_date.
+	// This is the naming used by the package schema.
+	FieldNamingSnakeCase = "snake_case"
+	// FieldNamingCamelCase publishes packages with camelCase field names, e.g. createdDate.
+	FieldNamingCamelCase = "camelCase"
+)
+
+var errUnknownFieldNaming = errors.New("unknown field naming")
+
+// Fields whose values are not part of the package schema, such as labels keyed by names
+// chosen by the deployment and the raw response of the registry, so the keys within them are
+// published as they are. This applies at any depth, e.g. to a package within an envelope.
+var opaqueFields = map[string]bool{
+	"labels": true,
+	"raw":    true,
+}
+
+// fieldNaming is a Publisher which renames the fields of JSON messages before sending them
+// to the wrapped publisher.
+type fieldNaming struct {
+	Publisher
+	rename func(string) string
+}
+
+// WithFieldNaming wraps the publisher so that published packages use the given field naming.
+// The publisher is returned unchanged for the default snake_case naming.
+func WithFieldNaming(pub Publisher, naming string) (Publisher, error) {
+	switch naming {
+	case "", FieldNamingSnakeCase:
+		return pub, nil
+	case FieldNamingCamelCase:
+		return &fieldNaming{Publisher: pub, rename: snakeToCamel}, nil
+	default:
+		return nil, fmt.Errorf("%w : %v", errUnknownFieldNaming, naming)
+	}
+}
+
+func (f *fieldNaming) Send(ctx context.Context, body []byte) error {
+	var msg interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	// Numbers are preserved as written rather than converted to floats.
+	decoder.UseNumber()
+	if err := decoder.Decode(&msg); err != nil {
+		return err
+	}
+	msg = renameFields(msg, f.rename)
+	renamed, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return f.Publisher.Send(ctx, renamed)
+}
+
+func (f *fieldNaming) Flush(ctx context.Context) error {
+	return Flush(ctx, f.Publisher)
+}
+
+func (f *fieldNaming) Close(ctx context.Context) error {
+	return Close(ctx, f.Publisher)
+}
+
+func renameFields(v interface{}, rename func(string) string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		renamed := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			if opaqueFields[key] {
+				renamed[rename(key)] = value
+				continue
+			}
+			renamed[rename(key)] = renameFields(value, rename)
+		}
+		return renamed
+	case []interface{}:
+		for i, value := range v {
+			v[i] = renameFields(value, rename)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func snakeToCamel(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
@@ -0,0 +1,97 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestWithFieldNamingCamelCase(t *testing.T) {
+	t.Parallel()
+
+	mock := &mockPublisher{name: "mock"}
+	pub, err := WithFieldNaming(mock, FieldNamingCamelCase)
+	if err != nil {
+		t.Fatalf("WithFieldNaming() returned unexpected error: %v", err)
+	}
+	if pub.Name() != "mock" {
+		t.Errorf("Name() returned %q instead of the wrapped publisher name", pub.Name())
+	}
+
+	body := []byte(`{"name":"foo","created_date":"2021-05-11T18:32:01Z","schema_ver":"1.1","size":12345678901,` +
+		`"raw":{"_id":"foo","dist_tags":{}},"labels":{"deploy_env":"prod"}}`)
+	if err := pub.Send(context.Background(), body); err != nil {
+		t.Fatalf("Send() returned unexpected error: %v", err)
+	}
+	if len(mock.received) != 1 {
+		t.Fatalf("Wrapped publisher received %v messages instead of 1", len(mock.received))
+	}
+	msg := map[string]json.RawMessage{}
+	if err := json.Unmarshal(mock.received[0], &msg); err != nil {
+		t.Fatalf("Failed to unmarshal sent message: %v", err)
+	}
+	expected := map[string]string{
+		"name":        `"foo"`,
+		"createdDate": `"2021-05-11T18:32:01Z"`,
+		"schemaVer":   `"1.1"`,
+		"size":        `12345678901`,
+		// The raw registry response is not renamed.
+		"raw": `{"_id":"foo","dist_tags":{}}`,
+		// The keys of labels are chosen by the deployment, so are not renamed.
+		"labels": `{"deploy_env":"prod"}`,
+	}
+	if len(msg) != len(expected) {
+		t.Errorf("Sent message %s does not have the expected fields", mock.received[0])
+	}
+	for field, value := range expected {
+		if string(msg[field]) != value {
+			t.Errorf("Field %s is %s instead of the expected %s", field, msg[field], value)
+		}
+	}
+}
+
+func TestWithFieldNamingNested(t *testing.T) {
+	t.Parallel()
+
+	mock := &mockPublisher{name: "mock"}
+	pub, err := WithFieldNaming(mock, FieldNamingCamelCase)
+	if err != nil {
+		t.Fatalf("WithFieldNaming() returned unexpected error: %v", err)
+	}
+	// A package within an envelope.
+	body := []byte(`{"cycle_id":"abc","package":{"created_date":"2021-05-11T18:32:01Z",` +
+		`"raw":{"dist_tags":{}},"labels":{"deploy_env":"prod"}}}`)
+	if err := pub.Send(context.Background(), body); err != nil {
+		t.Fatalf("Send() returned unexpected error: %v", err)
+	}
+	expected := `{"cycleId":"abc","package":{"createdDate":"2021-05-11T18:32:01Z",` +
+		`"labels":{"deploy_env":"prod"},"raw":{"dist_tags":{}}}}`
+	if len(mock.received) != 1 || string(mock.received[0]) != expected {
+		t.Errorf("Wrapped publisher received %s instead of %s", mock.received, expected)
+	}
+}
+
+func TestWithFieldNamingDefault(t *testing.T) {
+	t.Parallel()
+
+	mock := &mockPublisher{name: "mock"}
+	for _, naming := range []string{"", FieldNamingSnakeCase} {
+		pub, err := WithFieldNaming(mock, naming)
+		if err != nil {
+			t.Fatalf("WithFieldNaming(%q) returned unexpected error: %v", naming, err)
+		}
+		if pub != mock {
+			t.Errorf("WithFieldNaming(%q) wrapped the publisher instead of returning it unchanged", naming)
+		}
+	}
+}
+
+func TestWithFieldNamingUnknown(t *testing.T) {
+	t.Parallel()
+
+	_, err := WithFieldNaming(&mockPublisher{name: "mock"}, "kebab-case")
+	if !errors.Is(err, errUnknownFieldNaming) {
+		t.Fatalf("Expected errUnknownFieldNaming, got: %v", err)
+	}
+}
@@ -8,3 +8,31 @@ type Publisher interface {
 	Send(ctx context.Context, body []byte) error
 	Name() string
 }
+
+// Flusher is implemented by publishers which batch the packages sent to them, Flush is
+// called at the end of each poll cycle to publish the batch.
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// Flush flushes the publisher if it batches packages, otherwise it does nothing.
+func Flush(ctx context.Context, pub Publisher) error {
+	if f, ok := pub.(Flusher); ok {
+		return f.Flush(ctx)
+	}
+	return nil
+}
+
+// Closer is implemented by publishers holding messages or resources which must be written
+// or released before the process exits, Close is called on shutdown.
+type Closer interface {
+	Close(ctx context.Context) error
+}
+
+// Close closes the publisher if it holds messages or resources, otherwise it does nothing.
+func Close(ctx context.Context, pub Publisher) error {
+	if c, ok := pub.(Closer); ok {
+		return c.Close(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// OnFailureDrop logs and discards messages which still fail to send after retrying.
+	OnFailureDrop = "drop"
+	// OnFailureDeadLetter returns the error of messages which still fail to send after
+	// retrying, so that they are written to the dead-letter file.
+	OnFailureDeadLetter = "deadletter"
+	// OnFailureBlock retries messages until they are sent or the context is done, pausing
+	// publishing whilst the publisher is failing.
+	OnFailureBlock = "block"
+
+	// The maximum delay between retries as the backoff increases.
+	maxBackoff = 5 * time.Minute
+	// The delay before the first retry of a blocking policy without a backoff, so that a
+	// failing publisher isn't retried without pause.
+	defaultBlockBackoff = time.Second
+)
+
+var errUnknownOnFailure = errors.New("unknown on_failure policy")
+
+// RetryPolicy determines how a message which failed to send is retried.
+type RetryPolicy struct {
+	// The number of times a failed send is retried.
+	MaxRetries int
+	// The delay before the first retry, doubled for each subsequent retry. Blocking policies
+	// default to a delay of a second.
+	Backoff time.Duration
+	// The handling of messages which still fail after retrying, the error is returned when
+	// unset.
+	OnFailure string
+}
+
+// retrying is a Publisher which retries failed sends to the wrapped publisher.
+type retrying struct {
+	Publisher
+	policy RetryPolicy
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+// WithRetry wraps the publisher so that failed sends are retried according to the policy.
+// The publisher is returned unchanged when the policy neither retries nor handles failures.
+func WithRetry(pub Publisher, policy RetryPolicy) (Publisher, error) {
+	switch policy.OnFailure {
+	case "", OnFailureDeadLetter:
+		if policy.MaxRetries == 0 {
+			return pub, nil
+		}
+	case OnFailureBlock:
+		if policy.Backoff <= 0 {
+			policy.Backoff = defaultBlockBackoff
+		}
+	case OnFailureDrop:
+	default:
+		return nil, fmt.Errorf("%w : %v", errUnknownOnFailure, policy.OnFailure)
+	}
+	return &retrying{Publisher: pub, policy: policy, sleep: sleep}, nil
+}
+
+// Send sends the body, retrying failed sends. Messages the publisher can't send without a
+// package are not retried, and events published in place of packages are never blocked on,
+// as they would otherwise hold up the poll.
+func (r *retrying) Send(ctx context.Context, body []byte) error {
+	_, isPackage := PackageFromContext(ctx)
+	backoff := r.policy.Backoff
+	for attempt := 0; ; attempt++ {
+		err := r.Publisher.Send(ctx, body)
+		if err == nil || errors.Is(err, ErrNoPackage) {
+			return err
+		}
+		blocking := r.policy.OnFailure == OnFailureBlock && isPackage
+		if attempt >= r.policy.MaxRetries && !blocking {
+			if r.policy.OnFailure == OnFailureDrop {
+				log.WithError(err).WithField("publisher", r.Publisher.Name()).
+					Warn("Failed to send message, dropped after retrying")
+				return nil
+			}
+			return err
+		}
+		if sleepErr := r.sleep(ctx, backoff); sleepErr != nil {
+			return fmt.Errorf("%v after send error: %w", sleepErr, err)
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+func (r *retrying) Flush(ctx context.Context) error {
+	return Flush(ctx, r.Publisher)
+}
+
+func (r *retrying) Close(ctx context.Context) error {
+	return Close(ctx, r.Publisher)
+}
+
+// Waits for the duration, returning early with the error of the context if it is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
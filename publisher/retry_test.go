@@ -0,0 +1,171 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+)
+
+// flakyPublisher fails the given number of sends before succeeding.
+type flakyPublisher struct {
+	failures int
+
+	mu       sync.Mutex
+	attempts int
+	sent     [][]byte
+}
+
+func (pub *flakyPublisher) Name() string {
+	return "flaky"
+}
+
+func (pub *flakyPublisher) Send(ctx context.Context, body []byte) error {
+	pub.mu.Lock()
+	defer pub.mu.Unlock()
+	pub.attempts++
+	if pub.attempts <= pub.failures {
+		return errMockSend
+	}
+	pub.sent = append(pub.sent, body)
+	return nil
+}
+
+// Wraps the publisher with the policy, recording the delays between retries instead of
+// waiting for them.
+func withRecordedRetry(t *testing.T, pub Publisher, policy RetryPolicy) (Publisher, *[]time.Duration) {
+	t.Helper()
+	wrapped, err := WithRetry(pub, policy)
+	if err != nil {
+		t.Fatalf("WithRetry() returned unexpected error: %v", err)
+	}
+	delays := []time.Duration{}
+	wrapped.(*retrying).sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return ctx.Err()
+	}
+	return wrapped, &delays
+}
+
+func TestRetryOnFailureDrop(t *testing.T) {
+	t.Parallel()
+
+	flaky := &flakyPublisher{failures: 5}
+	pub, delays := withRecordedRetry(t, flaky, RetryPolicy{MaxRetries: 2, Backoff: time.Second, OnFailure: OnFailureDrop})
+	if err := pub.Send(context.Background(), []byte("foo")); err != nil {
+		t.Errorf("Send() returned `%v` when the message was expected to be dropped", err)
+	}
+	if flaky.attempts != 3 || len(flaky.sent) != 0 {
+		t.Errorf("Send() made %v attempts and sent %v messages when 3 and 0 were expected", flaky.attempts, len(flaky.sent))
+	}
+	expected := []time.Duration{time.Second, 2 * time.Second}
+	if len(*delays) != len(expected) || (*delays)[0] != expected[0] || (*delays)[1] != expected[1] {
+		t.Errorf("Retries were delayed by %v when %v was expected", *delays, expected)
+	}
+
+	// A send which succeeds whilst retrying is delivered.
+	flaky = &flakyPublisher{failures: 1}
+	pub, _ = withRecordedRetry(t, flaky, RetryPolicy{MaxRetries: 2, OnFailure: OnFailureDrop})
+	if err := pub.Send(context.Background(), []byte("foo")); err != nil {
+		t.Fatalf("Send() returned unexpected error: %v", err)
+	}
+	if flaky.attempts != 2 || len(flaky.sent) != 1 {
+		t.Errorf("Send() made %v attempts and sent %v messages when 2 and 1 were expected", flaky.attempts, len(flaky.sent))
+	}
+}
+
+func TestRetryOnFailureDeadLetter(t *testing.T) {
+	t.Parallel()
+
+	// The error is returned once retries are exhausted, for the dead-letter file to record.
+	flaky := &flakyPublisher{failures: 5}
+	pub, _ := withRecordedRetry(t, flaky, RetryPolicy{MaxRetries: 1, OnFailure: OnFailureDeadLetter})
+	if err := pub.Send(context.Background(), []byte("foo")); !errors.Is(err, errMockSend) {
+		t.Errorf("Send() returned `%v` when the send error was expected", err)
+	}
+	if flaky.attempts != 2 {
+		t.Errorf("Send() made %v attempts when 2 were expected", flaky.attempts)
+	}
+}
+
+func TestRetryOnFailureBlock(t *testing.T) {
+	t.Parallel()
+
+	pkgCtx := ContextWithPackage(context.Background(), feeds.NewPackage(time.Now(), "foo", "1.0.0", "npm", ""))
+	flaky := &flakyPublisher{failures: 10}
+	policy := RetryPolicy{MaxRetries: 1, Backoff: 2 * time.Minute, OnFailure: OnFailureBlock}
+	pub, delays := withRecordedRetry(t, flaky, policy)
+	if err := pub.Send(pkgCtx, []byte("foo")); err != nil {
+		t.Fatalf("Send() returned unexpected error: %v", err)
+	}
+	if flaky.attempts != 11 || len(flaky.sent) != 1 {
+		t.Errorf("Send() made %v attempts and sent %v messages when 11 and 1 were expected", flaky.attempts, len(flaky.sent))
+	}
+	if last := (*delays)[len(*delays)-1]; last != maxBackoff {
+		t.Errorf("Retries were delayed by %v when the backoff was expected to be capped at %v", last, maxBackoff)
+	}
+
+	// Blocking without a backoff still pauses between retries.
+	pub, delays = withRecordedRetry(t, &flakyPublisher{failures: 2}, RetryPolicy{OnFailure: OnFailureBlock})
+	if err := pub.Send(pkgCtx, []byte("foo")); err != nil {
+		t.Fatalf("Send() returned unexpected error: %v", err)
+	}
+	if len(*delays) != 2 || (*delays)[0] != defaultBlockBackoff || (*delays)[1] != 2*defaultBlockBackoff {
+		t.Errorf("Retries were delayed by %v when the default backoff of %v was expected", *delays, defaultBlockBackoff)
+	}
+
+	// Blocking ends when the context is done.
+	ctx, cancel := context.WithCancel(pkgCtx)
+	cancel()
+	pub, _ = withRecordedRetry(t, &flakyPublisher{failures: 10}, RetryPolicy{OnFailure: OnFailureBlock})
+	if err := pub.Send(ctx, []byte("foo")); !errors.Is(err, errMockSend) {
+		t.Errorf("Send() returned `%v` when the send error was expected", err)
+	}
+}
+
+func TestRetryEvents(t *testing.T) {
+	t.Parallel()
+
+	// Events published in place of packages are not blocked on, the error is returned
+	// once retries are exhausted.
+	flaky := &flakyPublisher{failures: 10}
+	policy := RetryPolicy{MaxRetries: 2, Backoff: time.Second, OnFailure: OnFailureBlock}
+	pub, _ := withRecordedRetry(t, flaky, policy)
+	if err := pub.Send(context.Background(), []byte(`{"event":"heartbeat"}`)); !errors.Is(err, errMockSend) {
+		t.Errorf("Send() returned `%v` when the send error was expected", err)
+	}
+	if flaky.attempts != 3 {
+		t.Errorf("Send() made %v attempts when 3 were expected", flaky.attempts)
+	}
+
+	// Publishers which skip messages without a package are not retried.
+	mock := &mockPublisher{name: "mock", err: ErrNoPackage}
+	pub, delays := withRecordedRetry(t, mock, policy)
+	if err := pub.Send(context.Background(), []byte(`{"event":"heartbeat"}`)); !errors.Is(err, ErrNoPackage) {
+		t.Errorf("Send() returned `%v` when ErrNoPackage was expected", err)
+	}
+	if len(mock.received) != 1 || len(*delays) != 0 {
+		t.Errorf("Send() made %v attempts with delays %v when a single attempt was expected", len(mock.received), *delays)
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	t.Parallel()
+
+	mock := &mockPublisher{name: "mock"}
+	for _, policy := range []RetryPolicy{{}, {OnFailure: OnFailureDeadLetter}} {
+		pub, err := WithRetry(mock, policy)
+		if err != nil {
+			t.Fatalf("WithRetry(%+v) returned unexpected error: %v", policy, err)
+		}
+		if pub != mock {
+			t.Errorf("WithRetry(%+v) wrapped the publisher instead of returning it unchanged", policy)
+		}
+	}
+	if _, err := WithRetry(mock, RetryPolicy{OnFailure: "retry"}); !errors.Is(err, errUnknownOnFailure) {
+		t.Errorf("WithRetry() returned `%v` when an unknown on_failure error was expected", err)
+	}
+}
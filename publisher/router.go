@@ -0,0 +1,104 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Events published in place of packages have no topic, so are skipped.
+var errNoRoutedPackage = fmt.Errorf("%w : routing to a topic", ErrNoPackage)
+
+// TopicRouter is a Publisher which sends each package to the topic rendered from a template
+// of the package fields, e.g. "pkgfeeds.{{.Type}}" publishes the packages of each feed to
+// their own topic. A publisher is opened for a topic when the first package is routed to it.
+type TopicRouter struct {
+	name  string
+	topic *Template
+	open  func(ctx context.Context, topic string) (Publisher, error)
+
+	mu sync.Mutex
+	// The publisher of each topic routed to, indexed by topic.
+	publishers map[string]Publisher
+}
+
+func NewTopicRouter(name string, topic *Template,
+	open func(ctx context.Context, topic string) (Publisher, error)) *TopicRouter {
+	return &TopicRouter{
+		name:       name,
+		topic:      topic,
+		open:       open,
+		publishers: map[string]Publisher{},
+	}
+}
+
+func (r *TopicRouter) Name() string {
+	return r.name
+}
+
+func (r *TopicRouter) Send(ctx context.Context, body []byte) error {
+	pkg, ok := PackageFromContext(ctx)
+	if !ok {
+		return errNoRoutedPackage
+	}
+	topic, err := r.topic.Execute(pkg)
+	if err != nil {
+		return fmt.Errorf("failed to render topic: %w", err)
+	}
+	pub, err := r.publisher(ctx, topic)
+	if err != nil {
+		return err
+	}
+	return pub.Send(ctx, body)
+}
+
+// Returns the publisher of the topic, opening it if no package has been routed to the
+// topic before.
+func (r *TopicRouter) publisher(ctx context.Context, topic string) (Publisher, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if pub, ok := r.publishers[topic]; ok {
+		return pub, nil
+	}
+	pub, err := r.open(ctx, topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open topic %s: %w", topic, err)
+	}
+	r.publishers[topic] = pub
+	return pub, nil
+}
+
+// Flush flushes the publisher of each topic, a failure of one topic does not prevent
+// flushing the others.
+func (r *TopicRouter) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	failures := []string{}
+	for topic, pub := range r.publishers {
+		if err := Flush(ctx, pub); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", topic, err))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%w : %v", ErrMultiPublish, strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// Close closes the publisher of each topic, a failure of one topic does not prevent
+// closing the others.
+func (r *TopicRouter) Close(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	failures := []string{}
+	for topic, pub := range r.publishers {
+		if err := Close(ctx, pub); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", topic, err))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%w : %v", ErrMultiPublish, strings.Join(failures, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+)
+
+func TestTopicRouter(t *testing.T) {
+	t.Parallel()
+
+	topic, err := NewTemplate("pkgfeeds.{{.Type}}")
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	opened := map[string]*mockFlusher{}
+	router := NewTopicRouter("mock", topic, func(ctx context.Context, topic string) (Publisher, error) {
+		pub := &mockFlusher{mockPublisher: mockPublisher{name: topic}}
+		opened[topic] = pub
+		return pub, nil
+	})
+
+	now := time.Now()
+	for _, pkg := range []*feeds.Package{
+		feeds.NewPackage(now, "foo", "1.0.0", "npm", feeds.EcosystemNPM),
+		feeds.NewPackage(now, "bar", "2.0.0", "pypi", feeds.EcosystemPyPI),
+		feeds.NewPackage(now, "baz", "3.0.0", "npm", feeds.EcosystemNPM),
+	} {
+		ctx := ContextWithPackage(context.Background(), pkg)
+		if err := router.Send(ctx, []byte(pkg.Name)); err != nil {
+			t.Fatalf("Send() returned unexpected error: %v", err)
+		}
+	}
+
+	expected := map[string][]string{
+		"pkgfeeds.npm":  {"foo", "baz"},
+		"pkgfeeds.pypi": {"bar"},
+	}
+	if len(opened) != len(expected) {
+		t.Fatalf("Router opened %v topics when %v were expected", len(opened), len(expected))
+	}
+	for name, bodies := range expected {
+		pub := opened[name]
+		if pub == nil {
+			t.Fatalf("Router did not open topic %s", name)
+		}
+		if len(pub.received) != len(bodies) {
+			t.Fatalf("Topic %s received %v messages when %v were expected", name, len(pub.received), len(bodies))
+		}
+		for i, body := range bodies {
+			if string(pub.received[i]) != body {
+				t.Errorf("Topic %s received %s when %s was expected", name, pub.received[i], body)
+			}
+		}
+	}
+
+	if err := router.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() returned unexpected error: %v", err)
+	}
+	for name, pub := range opened {
+		if pub.flushed != 1 {
+			t.Errorf("Topic %s was flushed %v times when 1 was expected", name, pub.flushed)
+		}
+	}
+}
+
+func TestTopicRouterErrors(t *testing.T) {
+	t.Parallel()
+
+	topic, err := NewTemplate("pkgfeeds.{{.Type}}")
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	router := NewTopicRouter("mock", topic, func(ctx context.Context, topic string) (Publisher, error) {
+		return nil, errMockSend
+	})
+	if err := router.Send(context.Background(), []byte("foo")); !errors.Is(err, errNoRoutedPackage) {
+		t.Errorf("Send() returned `%v` without a package when errNoRoutedPackage was expected", err)
+	}
+	ctx := ContextWithPackage(context.Background(), feeds.NewPackage(time.Now(), "foo", "1.0.0", "npm", ""))
+	if err := router.Send(ctx, []byte("foo")); !errors.Is(err, errMockSend) {
+		t.Errorf("Send() returned `%v` when the error opening the topic was expected", err)
+	}
+}
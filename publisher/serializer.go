@@ -0,0 +1,171 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+)
+
+const (
+	// FormatJSON publishes packages as JSON following the package schema, this is the
+	// default format.
+	FormatJSON = "json"
+	// FormatOSV publishes packages as OSV affected package objects.
+	// https://ossf.github.io/osv-schema/#affected-fields
+	FormatOSV = "osv"
+)
+
+// ErrNoPackage is returned by publishers which require the package being published when
+// sent a message without one, such as an event published in place of a package. These
+// messages are skipped by the publisher rather than retried.
+var ErrNoPackage = errors.New("publisher requires the package being published")
+
+var (
+	errUnknownFormat     = errors.New("unknown format")
+	errNoOSVEcosystem    = errors.New("package ecosystem is not defined by OSV")
+	errFormatFieldNaming = errors.New("field naming only applies to the json format")
+)
+
+// Serializer encodes the packages sent to a publisher, allowing any publisher to send
+// packages in any format.
+type Serializer interface {
+	Serialize(pkg *feeds.Package) ([]byte, error)
+	// The MIME type of the serialized packages, e.g. application/json.
+	ContentType() string
+}
+
+// NewSerializer returns the serializer of the named format.
+func NewSerializer(format string) (Serializer, error) {
+	switch format {
+	case "", FormatJSON:
+		return JSONSerializer{}, nil
+	case FormatOSV:
+		return OSVSerializer{}, nil
+	default:
+		return nil, fmt.Errorf("%w : %v", errUnknownFormat, format)
+	}
+}
+
+// ValidateFormat returns an error when the field naming cannot be used with the format, field
+// names are only renamed in JSON messages.
+func ValidateFormat(format, naming string) error {
+	if format != "" && format != FormatJSON && naming != "" && naming != FieldNamingSnakeCase {
+		return fmt.Errorf("%w : %v", errFormatFieldNaming, format)
+	}
+	return nil
+}
+
+// JSONSerializer serializes packages as JSON following the package schema.
+type JSONSerializer struct{}
+
+func (JSONSerializer) Serialize(pkg *feeds.Package) ([]byte, error) {
+	return json.Marshal(pkg)
+}
+
+func (JSONSerializer) ContentType() string {
+	return "application/json"
+}
+
+// OSVSerializer serializes packages as OSV affected package objects, holding the package
+// and its version. Packages of ecosystems which OSV does not define cannot be serialized.
+type OSVSerializer struct{}
+
+type osvAffected struct {
+	Package          osvPackage          `json:"package"`
+	Versions         []string            `json:"versions"`
+	DatabaseSpecific osvDatabaseSpecific `json:"database_specific"`
+}
+
+type osvPackage struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+	PURL      string `json:"purl,omitempty"`
+}
+
+// The fields of the package which OSV has no place for.
+type osvDatabaseSpecific struct {
+	CreatedDate time.Time `json:"created_date"`
+	Feed        string    `json:"feed"`
+	SchemaVer   string    `json:"schema_ver"`
+}
+
+func (OSVSerializer) Serialize(pkg *feeds.Package) ([]byte, error) {
+	ecosystem := pkg.Ecosystem.OSV()
+	if ecosystem == "" {
+		return nil, fmt.Errorf("%w : %v", errNoOSVEcosystem, pkg.Ecosystem)
+	}
+	return json.Marshal(osvAffected{
+		Package: osvPackage{
+			Ecosystem: ecosystem,
+			Name:      pkg.Name,
+			PURL:      pkg.PURL(),
+		},
+		Versions: []string{pkg.Version},
+		DatabaseSpecific: osvDatabaseSpecific{
+			CreatedDate: pkg.CreatedDate,
+			Feed:        pkg.Type,
+			SchemaVer:   pkg.SchemaVer,
+		},
+	})
+}
+
+func (OSVSerializer) ContentType() string {
+	return "application/json"
+}
+
+// Serialize returns the package being published serialized by the serializer, or the body
+// unchanged when the serializer is nil. Events published in place of packages, such as
+// heartbeats, are also returned unchanged.
+func Serialize(ctx context.Context, serializer Serializer, body []byte) ([]byte, error) {
+	if serializer == nil {
+		return body, nil
+	}
+	pkg, ok := PackageFromContext(ctx)
+	if !ok {
+		return body, nil
+	}
+	return serializer.Serialize(pkg)
+}
+
+// serializing is a Publisher which sends the package being published serialized in the
+// configured format, in place of the JSON body, to the wrapped publisher.
+type serializing struct {
+	Publisher
+	serializer Serializer
+}
+
+// WithSerializer wraps the publisher so that packages are sent in the named format. The
+// publisher is returned unchanged for the default json format.
+func WithSerializer(pub Publisher, format string) (Publisher, error) {
+	if format == "" || format == FormatJSON {
+		return pub, nil
+	}
+	serializer, err := NewSerializer(format)
+	if err != nil {
+		return nil, err
+	}
+	return &serializing{Publisher: pub, serializer: serializer}, nil
+}
+
+func (s *serializing) Send(ctx context.Context, body []byte) error {
+	if _, ok := PackageFromContext(ctx); !ok {
+		return s.Publisher.Send(ctx, body)
+	}
+	body, err := Serialize(ctx, s.serializer, body)
+	if err != nil {
+		return err
+	}
+	return s.Publisher.Send(ContextWithContentType(ctx, s.serializer.ContentType()), body)
+}
+
+func (s *serializing) Flush(ctx context.Context) error {
+	return Flush(ctx, s.Publisher)
+}
+
+func (s *serializing) Close(ctx context.Context) error {
+	return Close(ctx, s.Publisher)
+}
@@ -0,0 +1,131 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+)
+
+func TestWithSerializerOSV(t *testing.T) {
+	t.Parallel()
+
+	mock := &mockPublisher{name: "mock"}
+	pub, err := WithSerializer(mock, FormatOSV)
+	if err != nil {
+		t.Fatalf("WithSerializer() returned unexpected error: %v", err)
+	}
+	if pub.Name() != "mock" {
+		t.Errorf("Name() returned %q instead of the wrapped publisher name", pub.Name())
+	}
+
+	created := time.Date(2021, 5, 11, 18, 32, 1, 0, time.UTC)
+	pkg := feeds.NewPackage(created, "@scope/foo", "1.0.0", "npm", feeds.EcosystemNPM)
+	if err := pub.Send(ContextWithPackage(context.Background(), pkg), []byte(`{}`)); err != nil {
+		t.Fatalf("Send() returned unexpected error: %v", err)
+	}
+	if len(mock.received) != 1 {
+		t.Fatalf("Wrapped publisher received %v messages instead of 1", len(mock.received))
+	}
+	affected := osvAffected{}
+	if err := json.Unmarshal(mock.received[0], &affected); err != nil {
+		t.Fatalf("Failed to unmarshal sent message: %v", err)
+	}
+	expected := osvAffected{
+		Package: osvPackage{
+			Ecosystem: "npm",
+			Name:      "@scope/foo",
+			PURL:      "pkg:npm/%40scope/foo@1.0.0",
+		},
+		Versions: []string{"1.0.0"},
+		DatabaseSpecific: osvDatabaseSpecific{
+			CreatedDate: created,
+			Feed:        "npm",
+			SchemaVer:   pkg.SchemaVer,
+		},
+	}
+	if affected.Package != expected.Package {
+		t.Errorf("Sent package %+v instead of %+v", affected.Package, expected.Package)
+	}
+	if len(affected.Versions) != 1 || affected.Versions[0] != "1.0.0" {
+		t.Errorf("Sent versions %v instead of %v", affected.Versions, expected.Versions)
+	}
+	if !affected.DatabaseSpecific.CreatedDate.Equal(created) ||
+		affected.DatabaseSpecific.Feed != expected.DatabaseSpecific.Feed ||
+		affected.DatabaseSpecific.SchemaVer != expected.DatabaseSpecific.SchemaVer {
+		t.Errorf("Sent database_specific %+v instead of %+v", affected.DatabaseSpecific, expected.DatabaseSpecific)
+	}
+}
+
+func TestWithSerializerOSVUnknownEcosystem(t *testing.T) {
+	t.Parallel()
+
+	pub, err := WithSerializer(&mockPublisher{name: "mock"}, FormatOSV)
+	if err != nil {
+		t.Fatalf("WithSerializer() returned unexpected error: %v", err)
+	}
+	pkg := feeds.NewPackage(time.Now(), "foo", "1.0.0", "conda", feeds.EcosystemConda)
+	err = pub.Send(ContextWithPackage(context.Background(), pkg), []byte(`{}`))
+	if !errors.Is(err, errNoOSVEcosystem) {
+		t.Errorf("Expected errNoOSVEcosystem, got: %v", err)
+	}
+}
+
+func TestWithSerializerWithoutPackage(t *testing.T) {
+	t.Parallel()
+
+	pub, err := WithSerializer(&mockPublisher{name: "mock"}, FormatOSV)
+	if err != nil {
+		t.Fatalf("WithSerializer() returned unexpected error: %v", err)
+	}
+	mock := &mockPublisher{name: "mock"}
+	pub, err = WithSerializer(mock, FormatOSV)
+	if err != nil {
+		t.Fatalf("WithSerializer() returned unexpected error: %v", err)
+	}
+	// Events published in place of packages are sent unchanged.
+	if err := pub.Send(context.Background(), []byte(`{"event":"heartbeat"}`)); err != nil {
+		t.Fatalf("Send() returned unexpected error: %v", err)
+	}
+	if len(mock.received) != 1 || string(mock.received[0]) != `{"event":"heartbeat"}` {
+		t.Errorf("Wrapped publisher received %q when the unchanged event was expected", mock.received)
+	}
+}
+
+func TestWithSerializerDefault(t *testing.T) {
+	t.Parallel()
+
+	mock := &mockPublisher{name: "mock"}
+	for _, format := range []string{"", FormatJSON} {
+		pub, err := WithSerializer(mock, format)
+		if err != nil {
+			t.Fatalf("WithSerializer(%q) returned unexpected error: %v", format, err)
+		}
+		if pub != mock {
+			t.Errorf("WithSerializer(%q) wrapped the publisher instead of returning it unchanged", format)
+		}
+	}
+}
+
+func TestWithSerializerUnknown(t *testing.T) {
+	t.Parallel()
+
+	_, err := WithSerializer(&mockPublisher{name: "mock"}, "protobuf")
+	if !errors.Is(err, errUnknownFormat) {
+		t.Fatalf("Expected errUnknownFormat, got: %v", err)
+	}
+}
+
+func TestValidateFormatFieldNaming(t *testing.T) {
+	t.Parallel()
+
+	if err := ValidateFormat(FormatOSV, FieldNamingCamelCase); !errors.Is(err, errFormatFieldNaming) {
+		t.Errorf("Expected errFormatFieldNaming, got: %v", err)
+	}
+	if err := ValidateFormat(FormatJSON, FieldNamingCamelCase); err != nil {
+		t.Errorf("ValidateFormat() returned unexpected error for the json format: %v", err)
+	}
+}
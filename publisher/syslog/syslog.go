@@ -0,0 +1,179 @@
+package syslog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	PublisherType = "syslog"
+
+	defaultNetwork = "unix"
+	defaultAddress = "/dev/log"
+	defaultTag     = "package-feeds"
+
+	// The RFC 5424 timestamp format, which allows at most microsecond precision.
+	timestampFormat = "2006-01-02T15:04:05.000000Z07:00"
+	dialTimeout     = 10 * time.Second
+)
+
+var (
+	errUnknownNetwork  = errors.New("syslog network must be one of unix, udp or tcp")
+	errUnknownFacility = errors.New("unknown syslog facility")
+	errUnknownSeverity = errors.New("unknown syslog severity")
+	errMissingAddress  = errors.New("syslog address is required for udp and tcp")
+)
+
+var facilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+var severities = map[string]int{
+	"emerg": 0, "alert": 1, "crit": 2, "err": 3, "warning": 4, "notice": 5, "info": 6, "debug": 7,
+}
+
+// Syslog writes each event as the message of an RFC 5424 syslog message, to a local syslog
+// socket or a remote syslog server.
+type Syslog struct {
+	network  string
+	address  string
+	tag      string
+	priority int
+	hostname string
+	pid      int
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+type Config struct {
+	// The network used to reach syslog, one of unix (the default), udp or tcp.
+	Network string `mapstructure:"network"`
+	// The address of syslog, defaulting to /dev/log for unix.
+	Address string `mapstructure:"address"`
+	// The APP-NAME of each message, defaulting to package-feeds.
+	Tag string `mapstructure:"tag"`
+	// The facility and severity of each message, defaulting to user and info.
+	Facility string `mapstructure:"facility"`
+	Severity string `mapstructure:"severity"`
+}
+
+func New(ctx context.Context, config Config) (*Syslog, error) {
+	network := config.Network
+	if network == "" {
+		network = defaultNetwork
+	}
+	address := config.Address
+	switch network {
+	case "unix":
+		if address == "" {
+			address = defaultAddress
+		}
+	case "udp", "tcp":
+		if address == "" {
+			return nil, errMissingAddress
+		}
+	default:
+		return nil, fmt.Errorf("%w : %v", errUnknownNetwork, network)
+	}
+	tag := config.Tag
+	if tag == "" {
+		tag = defaultTag
+	}
+	facility := "user"
+	if config.Facility != "" {
+		facility = config.Facility
+	}
+	facilityCode, ok := facilities[facility]
+	if !ok {
+		return nil, fmt.Errorf("%w : %v", errUnknownFacility, facility)
+	}
+	severity := "info"
+	if config.Severity != "" {
+		severity = config.Severity
+	}
+	severityCode, ok := severities[severity]
+	if !ok {
+		return nil, fmt.Errorf("%w : %v", errUnknownSeverity, severity)
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "-"
+	}
+	return &Syslog{
+		network:  network,
+		address:  address,
+		tag:      tag,
+		priority: facilityCode*8 + severityCode,
+		hostname: hostname,
+		pid:      os.Getpid(),
+	}, nil
+}
+
+func FromConfig(ctx context.Context, config Config) (*Syslog, error) {
+	return New(ctx, config)
+}
+
+func (pub *Syslog) Name() string {
+	return PublisherType
+}
+
+// Formats the event as an RFC 5424 message without structured data. Messages sent over tcp
+// are framed by octet counting as described by RFC 6587.
+func (pub *Syslog) format(body []byte) []byte {
+	msg := &bytes.Buffer{}
+	fmt.Fprintf(msg, "<%d>1 %s %s %s %d - - ", pub.priority,
+		time.Now().UTC().Format(timestampFormat), pub.hostname, pub.tag, pub.pid)
+	msg.Write(bytes.TrimSpace(body))
+	if pub.network != "tcp" {
+		return msg.Bytes()
+	}
+	return append([]byte(strconv.Itoa(msg.Len())+" "), msg.Bytes()...)
+}
+
+// Send writes the event to syslog, connecting when first called. A failed write is retried
+// once over a new connection, as a stream connection may have been closed by the server.
+func (pub *Syslog) Send(ctx context.Context, body []byte) error {
+	pub.mu.Lock()
+	defer pub.mu.Unlock()
+
+	msg := pub.format(body)
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if pub.conn == nil {
+			if pub.conn, err = pub.dial(ctx); err != nil {
+				return err
+			}
+		}
+		if _, err = pub.conn.Write(msg); err == nil {
+			return nil
+		}
+		pub.conn.Close()
+		pub.conn = nil
+	}
+	return fmt.Errorf("failed to write to syslog: %w", err)
+}
+
+func (pub *Syslog) dial(ctx context.Context) (net.Conn, error) {
+	network := pub.network
+	// Local syslog daemons, including journald, listen on a datagram socket.
+	if network == "unix" {
+		network = "unixgram"
+	}
+	dialer := net.Dialer{Timeout: dialTimeout}
+	conn, err := dialer.DialContext(ctx, network, pub.address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return conn, nil
+}
@@ -0,0 +1,168 @@
+package syslog
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Matches an RFC 5424 message without structured data, capturing the priority, tag and message.
+var messagePattern = regexp.MustCompile(`^<(\d+)>1 \S+ \S+ (\S+) \d+ - - (.*)$`)
+
+func parseMessage(t *testing.T, msg string) (priority int, tag, body string) {
+	t.Helper()
+	match := messagePattern.FindStringSubmatch(msg)
+	if match == nil {
+		t.Fatalf("Received %q which is not an RFC 5424 message", msg)
+	}
+	priority, err := strconv.Atoi(match[1])
+	if err != nil {
+		t.Fatalf("Failed to parse priority of %q: %v", msg, err)
+	}
+	return priority, match[2], match[3]
+}
+
+func TestSyslogUDP(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	pub, err := New(context.Background(), Config{
+		Network:  "udp",
+		Address:  listener.LocalAddr().String(),
+		Tag:      "pkgfeeds",
+		Facility: "local0",
+		Severity: "notice",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create syslog publisher: %v", err)
+	}
+	if err := pub.Send(context.Background(), []byte(`{"name":"foopackage"}`+"\n")); err != nil {
+		t.Fatalf("Failed to send event: %v", err)
+	}
+
+	buf := make([]byte, 4096)
+	if err := listener.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatalf("Failed to set deadline: %v", err)
+	}
+	n, _, err := listener.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	priority, tag, body := parseMessage(t, string(buf[:n]))
+	// local0 (16) * 8 + notice (5).
+	if priority != 133 {
+		t.Errorf("Message has priority %v when 133 was expected", priority)
+	}
+	if tag != "pkgfeeds" {
+		t.Errorf("Message has tag %q when pkgfeeds was expected", tag)
+	}
+	if body != `{"name":"foopackage"}` {
+		t.Errorf("Message has body %q when the event was expected", body)
+	}
+}
+
+func TestSyslogTCP(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	received := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// Messages are framed by octet counting.
+		reader := bufio.NewReader(conn)
+		for {
+			length, err := reader.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(length))
+			if err != nil {
+				return
+			}
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(reader, msg); err != nil {
+				return
+			}
+			received <- string(msg)
+		}
+	}()
+
+	pub, err := New(context.Background(), Config{Network: "tcp", Address: listener.Addr().String()})
+	if err != nil {
+		t.Fatalf("Failed to create syslog publisher: %v", err)
+	}
+	for _, event := range []string{`{"name":"foo"}`, `{"name":"bar"}`} {
+		if err := pub.Send(context.Background(), []byte(event)); err != nil {
+			t.Fatalf("Failed to send event: %v", err)
+		}
+	}
+	for _, expected := range []string{`{"name":"foo"}`, `{"name":"bar"}`} {
+		var msg string
+		select {
+		case msg = <-received:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for message %v", expected)
+		}
+		priority, tag, body := parseMessage(t, msg)
+		// user (1) * 8 + info (6).
+		if priority != 14 || tag != defaultTag || body != expected {
+			t.Errorf("Received priority %v, tag %q and body %q when 14, %q and %q were expected",
+				priority, tag, body, defaultTag, expected)
+		}
+	}
+}
+
+func TestSyslogInvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config Config
+		err    error
+	}{
+		"unknown network": {
+			config: Config{Network: "http"},
+			err:    errUnknownNetwork,
+		},
+		"missing address": {
+			config: Config{Network: "udp"},
+			err:    errMissingAddress,
+		},
+		"unknown facility": {
+			config: Config{Facility: "local8"},
+			err:    errUnknownFacility,
+		},
+		"unknown severity": {
+			config: Config{Severity: "error"},
+			err:    errUnknownSeverity,
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := New(context.Background(), test.config); !errors.Is(err, test.err) {
+				t.Errorf("New() returned `%v` when `%v` was expected", err, test.err)
+			}
+		})
+	}
+}
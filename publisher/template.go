@@ -0,0 +1,46 @@
+package publisher
+
+import (
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/ossf/package-feeds/feeds"
+)
+
+// Template renders a string from the fields of a package, e.g. "{{.Type}}/{{.Name}}".
+type Template struct {
+	tmpl *template.Template
+}
+
+func NewTemplate(text string) (*Template, error) {
+	tmpl, err := template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{tmpl: tmpl}, nil
+}
+
+// Execute renders the template for the package, templates referencing package fields
+// return an error if pkg is nil.
+func (t *Template) Execute(pkg *feeds.Package) (string, error) {
+	var sb strings.Builder
+	if err := t.tmpl.Execute(&sb, pkg); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// Static reports whether the template contains no actions, so renders the same string for
+// every package.
+func (t *Template) Static() bool {
+	if t.tmpl.Tree == nil {
+		return true
+	}
+	for _, node := range t.tmpl.Tree.Root.Nodes {
+		if node.Type() != parse.NodeText {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,49 @@
+package publisher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ossf/package-feeds/feeds"
+)
+
+func TestTemplateExecute(t *testing.T) {
+	t.Parallel()
+
+	tmpl, err := NewTemplate("{{.Type}}/{{.Name}}@{{.Version}}")
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	pkg := feeds.NewPackage(time.Now(), "foo", "1.0.0", "npm", feeds.EcosystemNPM)
+	value, err := tmpl.Execute(pkg)
+	if err != nil {
+		t.Fatalf("Failed to execute template: %v", err)
+	}
+	if value != "npm/foo@1.0.0" {
+		t.Errorf("Template produced `%v` when `npm/foo@1.0.0` was expected", value)
+	}
+
+	if _, err := tmpl.Execute(nil); err == nil {
+		t.Errorf("Template referencing package fields executed without a package")
+	}
+}
+
+func TestTemplateStatic(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]bool{
+		"":                   true,
+		"packagefeeds":       true,
+		"pkgfeeds.{{.Type}}": false,
+		"{{/* comment */}}":  true,
+	}
+	for text, static := range tests {
+		tmpl, err := NewTemplate(text)
+		if err != nil {
+			t.Fatalf("Failed to parse template %q: %v", text, err)
+		}
+		if tmpl.Static() != static {
+			t.Errorf("Static() of %q returned %v when %v was expected", text, tmpl.Static(), static)
+		}
+	}
+}
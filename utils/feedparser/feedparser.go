@@ -0,0 +1,136 @@
+// Package feedparser parses RSS 2.0 and Atom 1.0 feeds into a common list of entries.
+package feedparser
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ossf/package-feeds/utils"
+)
+
+var errUnknownFormat = errors.New("unknown feed format")
+
+// Entry is an item of an RSS feed or an entry of an Atom feed.
+type Entry struct {
+	Title string
+	Link  string
+	// The time the entry was published or updated, zero if missing or not parseable.
+	Updated time.Time
+	Author  string
+}
+
+type rss struct {
+	Items []struct {
+		Title   string `xml:"title"`
+		Link    string `xml:"link"`
+		PubDate string `xml:"pubDate"`
+		Author  string `xml:"author"`
+		// The Dublin Core creator, commonly used in place of author.
+		Creator string `xml:"creator"`
+	} `xml:"channel>item"`
+}
+
+type atom struct {
+	Entries []struct {
+		Title string `xml:"title"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Updated   string `xml:"updated"`
+		Published string `xml:"published"`
+		Author    struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+	} `xml:"entry"`
+}
+
+var rssTimeLayouts = []string{time.RFC1123, time.RFC1123Z, time.RFC822, time.RFC822Z}
+
+// Parse reads an RSS or Atom feed, the format is detected from the root element. Non
+// utf-8 characters are ignored.
+func Parse(r io.Reader) ([]*Entry, error) {
+	decoder := xml.NewDecoder(utils.NewUTF8OnlyReader(r))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "rss":
+			return parseRSS(decoder, &start)
+		case "feed":
+			return parseAtom(decoder, &start)
+		default:
+			return nil, fmt.Errorf("%w : root element %v", errUnknownFormat, start.Name.Local)
+		}
+	}
+}
+
+func parseRSS(decoder *xml.Decoder, start *xml.StartElement) ([]*Entry, error) {
+	feed := &rss{}
+	if err := decoder.DecodeElement(feed, start); err != nil {
+		return nil, err
+	}
+	entries := make([]*Entry, 0, len(feed.Items))
+	for _, item := range feed.Items {
+		author := item.Author
+		if author == "" {
+			author = item.Creator
+		}
+		entries = append(entries, &Entry{
+			Title:   strings.TrimSpace(item.Title),
+			Link:    strings.TrimSpace(item.Link),
+			Updated: parseTime(item.PubDate, rssTimeLayouts...),
+			Author:  strings.TrimSpace(author),
+		})
+	}
+	return entries, nil
+}
+
+func parseAtom(decoder *xml.Decoder, start *xml.StartElement) ([]*Entry, error) {
+	feed := &atom{}
+	if err := decoder.DecodeElement(feed, start); err != nil {
+		return nil, err
+	}
+	entries := make([]*Entry, 0, len(feed.Entries))
+	for _, e := range feed.Entries {
+		link := ""
+		for _, l := range e.Links {
+			// Links without a rel are alternate links, which point to the entry itself.
+			if l.Rel == "" || l.Rel == "alternate" {
+				link = l.Href
+				break
+			}
+		}
+		updated := e.Updated
+		if updated == "" {
+			updated = e.Published
+		}
+		entries = append(entries, &Entry{
+			Title:   strings.TrimSpace(e.Title),
+			Link:    strings.TrimSpace(link),
+			Updated: parseTime(updated, time.RFC3339),
+			Author:  strings.TrimSpace(e.Author.Name),
+		})
+	}
+	return entries, nil
+}
+
+func parseTime(value string, layouts ...string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,97 @@
+package feedparser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRSS(t *testing.T) {
+	t.Parallel()
+
+	entries, err := Parse(strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/">
+	<channel>
+		<title>npm recent updates</title>
+		<item>
+			<title><![CDATA[FooPackage]]></title>
+			<link>https://www.npmjs.com/package/FooPackage</link>
+			<dc:creator><![CDATA[FooMan]]></dc:creator>
+			<pubDate>Mon, 22 Mar 2021 13:45:16 GMT</pubDate>
+		</item>
+		<item>
+			<title>Bar` + "\xff" + `Package</title>
+			<author>bar@example.com</author>
+			<pubDate>not a date</pubDate>
+		</item>
+	</channel>
+</rss>`))
+	if err != nil {
+		t.Fatalf("Parse() returned unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Parse() produced %v entries instead of the expected 2", len(entries))
+	}
+	expected := &Entry{
+		Title:   "FooPackage",
+		Link:    "https://www.npmjs.com/package/FooPackage",
+		Updated: time.Date(2021, 3, 22, 13, 45, 16, 0, time.UTC),
+		Author:  "FooMan",
+	}
+	if *entries[0] != *expected {
+		t.Errorf("Parse() produced entry %+v instead of %+v", entries[0], expected)
+	}
+	// Non utf-8 characters are dropped and unparseable dates are left unset.
+	if entries[1].Title != "BarPackage" || entries[1].Author != "bar@example.com" || !entries[1].Updated.IsZero() {
+		t.Errorf("Parse() produced unexpected entry %+v", entries[1])
+	}
+}
+
+func TestParseAtom(t *testing.T) {
+	t.Parallel()
+
+	entries, err := Parse(strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+	<title>Recently updated packages</title>
+	<entry>
+		<title>v1.2.0 of foo</title>
+		<link rel="self" href="https://example.com/api/foo"/>
+		<link href="https://example.com/packages/foo"/>
+		<updated>2021-05-11T18:32:01Z</updated>
+		<author><name>Foo Man</name></author>
+	</entry>
+	<entry>
+		<title>v0.1.0 of bar</title>
+		<published>2021-05-10T08:00:00+02:00</published>
+	</entry>
+</feed>`))
+	if err != nil {
+		t.Fatalf("Parse() returned unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Parse() produced %v entries instead of the expected 2", len(entries))
+	}
+	expected := &Entry{
+		Title:   "v1.2.0 of foo",
+		Link:    "https://example.com/packages/foo",
+		Updated: time.Date(2021, 5, 11, 18, 32, 1, 0, time.UTC),
+		Author:  "Foo Man",
+	}
+	if *entries[0] != *expected {
+		t.Errorf("Parse() produced entry %+v instead of %+v", entries[0], expected)
+	}
+	// The published date is used when an entry has no updated date.
+	if !entries[1].Updated.Equal(time.Date(2021, 5, 10, 6, 0, 0, 0, time.UTC)) {
+		t.Errorf("Parse() produced updated date %v instead of the published date", entries[1].Updated)
+	}
+}
+
+func TestParseUnknownFormat(t *testing.T) {
+	t.Parallel()
+
+	_, err := Parse(strings.NewReader(`<?xml version="1.0"?><html><body></body></html>`))
+	if !errors.Is(err, errUnknownFormat) {
+		t.Fatalf("Expected errUnknownFormat, got: %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// NewHTTPClient returns a client for requests to registries, requests made with a context
+// carrying a trace span produce child spans. Gzip and deflate encoded responses are
+// transparently decompressed. Clients share a pool of connections, see ConfigureTransport.
+// Requests made with a context of ContextWithRequestOptions carry its headers and parameters,
+// those made with a context of ContextWithTransport use its transport.
+// Requests are logged at debug level when enabled, see ConfigureHTTPDebug.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	return newHTTPClient(timeout, contextTransport{base: sharedTransport})
+}
+
+func newHTTPClient(timeout time.Duration, transport http.RoundTripper) *http.Client {
+	// Requests are logged before the request options are added, so that credentials held by
+	// the options aren't logged, and responses are logged once decompressed.
+	transport = newDebugTransport(requestOptionsTransport{base: decompressingTransport{base: transport}})
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: otelhttp.NewTransport(transport),
+	}
+}
+
+// Get issues a GET request to url which is bound to ctx.
+func Get(ctx context.Context, client *http.Client, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultMaxDebugBodySize is the number of bytes of each response body logged when
+// logging of bodies is enabled.
+const DefaultMaxDebugBodySize = 4096
+
+// HTTPDebugConfig enables logging of the requests made to registries at debug level, to
+// troubleshoot a feed misbehaving against a registry.
+type HTTPDebugConfig struct {
+	// Logs the method and URL of each request, along with the status of its response.
+	Enabled bool `yaml:"enabled"`
+
+	// Also logs the body of each response, truncated to MaxBodySize bytes.
+	LogBodies bool `yaml:"log_bodies"`
+
+	// The maximum number of bytes of each response body logged. Defaults to 4KiB.
+	MaxBodySize int `yaml:"max_body_size"`
+}
+
+// The debug configuration of the clients of NewHTTPClient, read on each request as clients
+// are created before the configuration is loaded.
+var (
+	debugConfig   HTTPDebugConfig
+	debugConfigMu sync.RWMutex
+)
+
+// ConfigureHTTPDebug applies the config to the requests made by every client of
+// NewHTTPClient.
+func ConfigureHTTPDebug(config HTTPDebugConfig) {
+	debugConfigMu.Lock()
+	defer debugConfigMu.Unlock()
+	debugConfig = config
+}
+
+func loadHTTPDebugConfig() HTTPDebugConfig {
+	debugConfigMu.RLock()
+	defer debugConfigMu.RUnlock()
+	return debugConfig
+}
+
+// debugTransport logs requests and their responses when debugging is enabled. Headers are
+// not logged, as they may hold credentials.
+type debugTransport struct {
+	base   http.RoundTripper
+	config func() HTTPDebugConfig
+	logger *log.Logger
+}
+
+func newDebugTransport(base http.RoundTripper) debugTransport {
+	return debugTransport{base: base, config: loadHTTPDebugConfig, logger: log.StandardLogger()}
+}
+
+func (t debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	config := t.config()
+	if !config.Enabled {
+		return t.base.RoundTrip(req)
+	}
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	entry := t.logger.WithFields(log.Fields{
+		"method":   req.Method,
+		"url":      req.URL.Redacted(),
+		"duration": time.Since(start).String(),
+	})
+	if err != nil {
+		entry.WithError(err).Debug("HTTP request failed")
+		return resp, err
+	}
+	entry = entry.WithField("status", resp.StatusCode)
+	if !config.LogBodies || resp.Body == nil {
+		entry.Debug("HTTP request")
+		return resp, nil
+	}
+	maxBodySize := config.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxDebugBodySize
+	}
+	// The body is logged once it has been read or closed by the feed, rather than read
+	// up front, so that streamed responses are still processed as they arrive.
+	resp.Body = &debugBody{body: resp.Body, entry: entry, limit: maxBodySize}
+	return resp, nil
+}
+
+// debugBody captures up to limit bytes of a response body as it is read, logging them
+// with the request once the body is exhausted or closed.
+type debugBody struct {
+	body  io.ReadCloser
+	entry *log.Entry
+	limit int
+
+	buf  bytes.Buffer
+	size int64
+	once sync.Once
+}
+
+func (b *debugBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.size += int64(n)
+	if remaining := b.limit - b.buf.Len(); remaining > 0 {
+		if remaining > n {
+			remaining = n
+		}
+		b.buf.Write(p[:remaining])
+	}
+	if err == io.EOF {
+		b.log()
+	}
+	return n, err
+}
+
+func (b *debugBody) Close() error {
+	b.log()
+	return b.body.Close()
+}
+
+func (b *debugBody) log() {
+	b.once.Do(func() {
+		b.entry.WithFields(log.Fields{
+			"body":      b.buf.String(),
+			"body_read": b.size,
+			"truncated": b.size > int64(b.buf.Len()),
+		}).Debug("HTTP request")
+	})
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestDebugTransport(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("x", 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Errorf("Failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		config HTTPDebugConfig
+		// The body logged, or nil if no request should be logged.
+		logged *string
+	}{
+		{"disabled", HTTPDebugConfig{LogBodies: true}, nil},
+		{"enabled", HTTPDebugConfig{Enabled: true}, stringPtr("")},
+		{"bodies", HTTPDebugConfig{Enabled: true, LogBodies: true, MaxBodySize: 10}, stringPtr("xxxxxxxxxx")},
+	}
+	for _, test := range tests {
+		logger, hook := testLogger()
+		config := test.config
+		client := &http.Client{Transport: debugTransport{
+			base:   http.DefaultTransport,
+			config: func() HTTPDebugConfig { return config },
+			logger: logger,
+		}}
+		resp, err := client.Get(srv.URL + "/foo?bar=baz")
+		if err != nil {
+			t.Fatalf("%s: Get() returned unexpected error: %v", test.name, err)
+		}
+		read, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil || string(read) != body {
+			t.Errorf("%s: Read %q with error %v when the full body was expected", test.name, read, err)
+		}
+
+		entries := hook.AllEntries()
+		if test.logged == nil {
+			if len(entries) != 0 {
+				t.Errorf("%s: %v requests were logged when none were expected", test.name, len(entries))
+			}
+			continue
+		}
+		if len(entries) != 1 {
+			t.Fatalf("%s: %v requests were logged when 1 was expected", test.name, len(entries))
+		}
+		entry := entries[0]
+		if entry.Level != logrus.DebugLevel || entry.Data["url"] != srv.URL+"/foo?bar=baz" ||
+			entry.Data["status"] != http.StatusTeapot {
+			t.Errorf("%s: Logged %v %v when the URL and status were expected at debug level",
+				test.name, entry.Level, entry.Data)
+		}
+		logged, _ := entry.Data["body"].(string)
+		if logged != *test.logged {
+			t.Errorf("%s: Logged body %q when %q was expected", test.name, logged, *test.logged)
+		}
+		if *test.logged != "" && (entry.Data["truncated"] != true || entry.Data["body_read"] != int64(len(body))) {
+			t.Errorf("%s: Logged %v when a truncated body of %v bytes was expected", test.name, entry.Data, len(body))
+		}
+	}
+}
+
+func testLogger() (*logrus.Logger, *test.Hook) {
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+	return logger, hook
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
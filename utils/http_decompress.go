@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const acceptEncoding = "gzip, deflate"
+
+// decompressingTransport requests gzip or deflate encoded responses and transparently
+// decompresses them. Unlike the automatic decompression of http.Transport, responses are
+// decompressed even if the registry encodes them without being asked to, and deflate
+// encoded responses are supported.
+type decompressingTransport struct {
+	base http.RoundTripper
+}
+
+func (t decompressingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Accept-Encoding") == "" {
+		// RoundTrippers must not modify the request.
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || req.Method == http.MethodHead {
+		return resp, err
+	}
+	body := resp.Body
+	var decoder io.ReadCloser
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		decoder = &lazyReader{open: func() (io.ReadCloser, error) { return gzip.NewReader(body) }}
+	case "deflate":
+		decoder = &lazyReader{open: func() (io.ReadCloser, error) { return newDeflateReader(body) }}
+	default:
+		return resp, nil
+	}
+	resp.Body = &decompressedBody{decoder: decoder, body: body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+// newDeflateReader decodes a deflate encoded body, which should be zlib wrapped but is
+// sent as raw deflate by some servers.
+func newDeflateReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// lazyReader defers reading the encoding header until the body is first read, so that
+// a body which is never read does not block the response.
+type lazyReader struct {
+	open   func() (io.ReadCloser, error)
+	reader io.ReadCloser
+	err    error
+}
+
+func (r *lazyReader) Read(p []byte) (int, error) {
+	if r.reader == nil && r.err == nil {
+		r.reader, r.err = r.open()
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+	return r.reader.Read(p)
+}
+
+func (r *lazyReader) Close() error {
+	if r.reader == nil {
+		return nil
+	}
+	return r.reader.Close()
+}
+
+type decompressedBody struct {
+	decoder io.ReadCloser
+	body    io.ReadCloser
+}
+
+func (b *decompressedBody) Read(p []byte) (int, error) {
+	return b.decoder.Read(p)
+}
+
+func (b *decompressedBody) Close() error {
+	decoderErr := b.decoder.Close()
+	if err := b.body.Close(); err != nil {
+		return err
+	}
+	return decoderErr
+}
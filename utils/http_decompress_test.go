@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const decompressBody = `{"name": "foo"}`
+
+func compress(t *testing.T, encoding string) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(buf)
+	case "deflate":
+		w = zlib.NewWriter(buf)
+	case "raw-deflate":
+		fw, err := flate.NewWriter(buf, flate.DefaultCompression)
+		if err != nil {
+			t.Fatalf("Failed to create flate writer: %v", err)
+		}
+		w = fw
+	default:
+		t.Fatalf("Unknown encoding %v", encoding)
+	}
+	if _, err := w.Write([]byte(decompressBody)); err != nil {
+		t.Fatalf("Failed to compress body: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to compress body: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestHTTPClientDecompresses(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		encoding        string
+		contentEncoding string
+	}{
+		{encoding: "gzip", contentEncoding: "gzip"},
+		{encoding: "deflate", contentEncoding: "deflate"},
+		{encoding: "raw-deflate", contentEncoding: "deflate"},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.encoding, func(t *testing.T) {
+			t.Parallel()
+
+			body := compress(t, test.encoding)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Accept-Encoding") != acceptEncoding {
+					t.Errorf("Request had Accept-Encoding `%v` when `%v` was expected",
+						r.Header.Get("Accept-Encoding"), acceptEncoding)
+				}
+				w.Header().Set("Content-Encoding", test.contentEncoding)
+				if _, err := w.Write(body); err != nil {
+					t.Errorf("Failed to write response: %v", err)
+				}
+			}))
+			defer srv.Close()
+
+			resp, err := Get(context.Background(), NewHTTPClient(10*time.Second), srv.URL)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			defer resp.Body.Close()
+			got, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response: %v", err)
+			}
+			if string(got) != decompressBody {
+				t.Errorf("Response body was `%s` when `%s` was expected", got, decompressBody)
+			}
+			if resp.Header.Get("Content-Encoding") != "" {
+				t.Errorf("Decompressed response still has Content-Encoding `%v`", resp.Header.Get("Content-Encoding"))
+			}
+		})
+	}
+}
+
+func TestHTTPClientUncompressed(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte(decompressBody)); err != nil {
+			t.Errorf("Failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	resp, err := Get(context.Background(), NewHTTPClient(10*time.Second), srv.URL)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	got, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	if string(got) != decompressBody {
+		t.Errorf("Response body was `%s` when `%s` was expected", got, decompressBody)
+	}
+}
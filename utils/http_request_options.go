@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+)
+
+type requestOptionsKey struct{}
+
+// The extra headers and query parameters added to requests made with a context to the hosts.
+type requestOptions struct {
+	hosts   map[string]bool
+	headers map[string]string
+	params  map[string]string
+}
+
+// ContextWithRequestOptions returns a context which adds the headers and query parameters to
+// every request made with it by a client of NewHTTPClient to one of the hosts, e.g. the API
+// key of a registry. Hosts are compared with the host of each request's URL, including any
+// port, so that the options aren't sent to other services the feed requests. Headers replace
+// those set on the request, parameters replace those of the same name.
+func ContextWithRequestOptions(ctx context.Context, hosts []string, headers, params map[string]string) context.Context {
+	if len(hosts) == 0 || (len(headers) == 0 && len(params) == 0) {
+		return ctx
+	}
+	options := requestOptions{hosts: map[string]bool{}, headers: headers, params: params}
+	for _, host := range hosts {
+		options.hosts[host] = true
+	}
+	return context.WithValue(ctx, requestOptionsKey{}, options)
+}
+
+// requestOptionsTransport adds the headers and query parameters of the request's context.
+type requestOptionsTransport struct {
+	base http.RoundTripper
+}
+
+func (t requestOptionsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	options, ok := req.Context().Value(requestOptionsKey{}).(requestOptions)
+	if !ok || !options.hosts[req.URL.Host] {
+		return t.base.RoundTrip(req)
+	}
+	// RoundTrippers must not modify the request.
+	req = req.Clone(req.Context())
+	for name, value := range options.headers {
+		req.Header.Set(name, value)
+	}
+	if len(options.params) > 0 {
+		query := req.URL.Query()
+		for name, value := range options.params {
+			query.Set(name, value)
+		}
+		req.URL.RawQuery = query.Encode()
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestContextWithRequestOptions(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "s3cret" {
+			http.Error(w, "missing header", http.StatusUnauthorized)
+			return
+		}
+		query := r.URL.Query()
+		if query.Get("key") != "s3cret" || query.Get("page") != "2" {
+			http.Error(w, "missing query parameters", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server url: %v", err)
+	}
+	ctx := ContextWithRequestOptions(context.Background(), []string{u.Host},
+		map[string]string{"X-Api-Key": "s3cret"}, map[string]string{"key": "s3cret"})
+	resp, err := Get(ctx, NewHTTPClient(time.Second), srv.URL+"?page=2")
+	if err != nil {
+		t.Fatalf("Get() returned unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Request was made without the headers and parameters of the context, status %v", resp.StatusCode)
+	}
+
+	// Requests to other hosts are unchanged.
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "" || r.URL.Query().Get("key") != "" {
+			http.Error(w, "unexpected request options", http.StatusBadRequest)
+		}
+	}))
+	defer other.Close()
+	resp, err = Get(ctx, NewHTTPClient(time.Second), other.URL)
+	if err != nil {
+		t.Fatalf("Get() returned unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Request to another host was made with the headers and parameters of the context, status %v",
+			resp.StatusCode)
+	}
+
+	// Requests made without the options are unchanged.
+	resp, err = Get(context.Background(), NewHTTPClient(time.Second), srv.URL+"?page=2")
+	if err != nil {
+		t.Fatalf("Get() returned unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Request without request options returned status %v instead of %v",
+			resp.StatusCode, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultMaxIdleConnsPerHost is the number of idle connections kept per registry host.
+	// Feeds make bursts of requests to a single host, e.g. a request per critical package,
+	// so more are kept than the 2 of http.DefaultTransport.
+	DefaultMaxIdleConnsPerHost = 32
+	// DefaultIdleConnTimeout is how long an idle connection is kept before it is closed.
+	DefaultIdleConnTimeout = 90 * time.Second
+)
+
+// TransportConfig tunes the pool of connections to registries. Zero values keep the
+// defaults.
+type TransportConfig struct {
+	// The maximum number of idle connections kept per host.
+	MaxIdleConnsPerHost int `yaml:"max_idle_conns_per_host"`
+
+	// The maximum number of connections per host, including those in use. Zero is unlimited.
+	MaxConnsPerHost int `yaml:"max_conns_per_host"`
+
+	// How long an idle connection is kept before it is closed.
+	IdleConnTimeout time.Duration `yaml:"idle_conn_timeout"`
+}
+
+// The transport shared by the clients of NewHTTPClient, and cloned by NewTLSHTTPClient.
+var sharedTransport = newTransport()
+
+func newTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	transport.IdleConnTimeout = DefaultIdleConnTimeout
+	return transport
+}
+
+// ConfigureTransport applies the config to the transport shared by the clients made for
+// registries. It must be called before any requests are made, as clients created at
+// startup already hold the transport.
+func ConfigureTransport(config TransportConfig) {
+	config.apply(sharedTransport)
+}
+
+func (c TransportConfig) apply(transport *http.Transport) {
+	if c.MaxIdleConnsPerHost != 0 {
+		transport.MaxIdleConnsPerHost = c.MaxIdleConnsPerHost
+		// The idle connections of every host are bounded by MaxIdleConns, which must allow
+		// those of a single host.
+		if transport.MaxIdleConns != 0 && transport.MaxIdleConns < c.MaxIdleConnsPerHost {
+			transport.MaxIdleConns = c.MaxIdleConnsPerHost
+		}
+	}
+	if c.MaxConnsPerHost != 0 {
+		transport.MaxConnsPerHost = c.MaxConnsPerHost
+	}
+	if c.IdleConnTimeout != 0 {
+		transport.IdleConnTimeout = c.IdleConnTimeout
+	}
+}
+
+type transportKey struct{}
+
+// The transport used for requests made with a context to the hosts.
+type hostsTransport struct {
+	hosts     map[string]bool
+	transport http.RoundTripper
+}
+
+// ContextWithTransport returns a context whose requests to one of the hosts, made by a client
+// of NewHTTPClient, use the transport rather than the shared one, e.g. one of NewTLSTransport
+// trusting the CA of a private registry. Hosts are compared as by ContextWithRequestOptions.
+func ContextWithTransport(ctx context.Context, hosts []string, transport http.RoundTripper) context.Context {
+	if len(hosts) == 0 || transport == nil {
+		return ctx
+	}
+	value := hostsTransport{hosts: map[string]bool{}, transport: transport}
+	for _, host := range hosts {
+		value.hosts[host] = true
+	}
+	return context.WithValue(ctx, transportKey{}, value)
+}
+
+// contextTransport makes requests with the transport of the request's context, or base.
+type contextTransport struct {
+	base http.RoundTripper
+}
+
+func (t contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	value, ok := req.Context().Value(transportKey{}).(hostsTransport)
+	if !ok || !value.hosts[req.URL.Host] {
+		return t.base.RoundTrip(req)
+	}
+	return value.transport.RoundTrip(req)
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTransportDefaults(t *testing.T) {
+	t.Parallel()
+
+	transport := newTransport()
+	if transport.MaxIdleConnsPerHost != DefaultMaxIdleConnsPerHost {
+		t.Errorf("MaxIdleConnsPerHost is %v when %v was expected", transport.MaxIdleConnsPerHost,
+			DefaultMaxIdleConnsPerHost)
+	}
+	if transport.MaxConnsPerHost != 0 {
+		t.Errorf("MaxConnsPerHost is %v when unlimited was expected", transport.MaxConnsPerHost)
+	}
+	if transport.IdleConnTimeout != DefaultIdleConnTimeout {
+		t.Errorf("IdleConnTimeout is %v when %v was expected", transport.IdleConnTimeout, DefaultIdleConnTimeout)
+	}
+}
+
+func TestTransportConfigApply(t *testing.T) {
+	t.Parallel()
+
+	transport := newTransport()
+	TransportConfig{
+		MaxIdleConnsPerHost: 200,
+		MaxConnsPerHost:     250,
+		IdleConnTimeout:     30 * time.Second,
+	}.apply(transport)
+	if transport.MaxIdleConnsPerHost != 200 {
+		t.Errorf("MaxIdleConnsPerHost is %v when 200 was expected", transport.MaxIdleConnsPerHost)
+	}
+	if transport.MaxIdleConns != 200 {
+		t.Errorf("MaxIdleConns is %v when it was expected to be raised to 200", transport.MaxIdleConns)
+	}
+	if transport.MaxConnsPerHost != 250 {
+		t.Errorf("MaxConnsPerHost is %v when 250 was expected", transport.MaxConnsPerHost)
+	}
+	if transport.IdleConnTimeout != 30*time.Second {
+		t.Errorf("IdleConnTimeout is %v when 30s was expected", transport.IdleConnTimeout)
+	}
+
+	// Zero values keep the defaults.
+	transport = newTransport()
+	TransportConfig{MaxConnsPerHost: 8}.apply(transport)
+	if transport.MaxIdleConnsPerHost != DefaultMaxIdleConnsPerHost || transport.IdleConnTimeout != DefaultIdleConnTimeout {
+		t.Errorf("Unset values changed the transport to %v idle connections per host with a timeout of %v",
+			transport.MaxIdleConnsPerHost, transport.IdleConnTimeout)
+	}
+}
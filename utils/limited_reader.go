@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// DefaultMaxResponseSize is the default limit on the size of a registry response body.
+// npm metadata for packages with many versions can reach tens of megabytes.
+const DefaultMaxResponseSize = 32 << 20
+
+var ErrResponseTooLarge = errors.New("response body exceeds the maximum size")
+
+// LimitedReader reads from an underlying reader, returning ErrResponseTooLarge once more
+// than the limit of bytes have been read. A limit of zero or less disables the limit.
+type LimitedReader struct {
+	r     io.Reader
+	limit int64
+	read  int64
+}
+
+func NewLimitedReader(r io.Reader, limit int64) *LimitedReader {
+	return &LimitedReader{r: r, limit: limit}
+}
+
+func (lr *LimitedReader) Read(p []byte) (int, error) {
+	if lr.limit <= 0 {
+		return lr.r.Read(p)
+	}
+	if lr.read > lr.limit {
+		return 0, fmt.Errorf("%w : %d bytes", ErrResponseTooLarge, lr.limit)
+	}
+	// Read at most one byte beyond the limit, which is enough to detect that it was exceeded.
+	if remaining := lr.limit - lr.read + 1; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := lr.r.Read(p)
+	lr.read += int64(n)
+	if lr.read > lr.limit {
+		return n - int(lr.read-lr.limit), fmt.Errorf("%w : %d bytes", ErrResponseTooLarge, lr.limit)
+	}
+	return n, err
+}
+
+// LimitedReadAll reads r until EOF, returning ErrResponseTooLarge if more than limit
+// bytes are read. A limit of zero or less disables the limit.
+func LimitedReadAll(r io.Reader, limit int64) ([]byte, error) {
+	return ioutil.ReadAll(NewLimitedReader(r, limit))
+}
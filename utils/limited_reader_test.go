@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLimitedReadAll(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		body    string
+		limit   int64
+		wantErr bool
+	}{
+		{name: "under limit", body: "foo", limit: 4},
+		{name: "at limit", body: "fooo", limit: 4},
+		{name: "over limit", body: "foooo", limit: 4, wantErr: true},
+		{name: "no limit", body: strings.Repeat("foo", 1000), limit: 0},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			body, err := LimitedReadAll(strings.NewReader(test.body), test.limit)
+			if test.wantErr {
+				if !errors.Is(err, ErrResponseTooLarge) {
+					t.Errorf("LimitedReadAll returned error %v when ErrResponseTooLarge was expected", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LimitedReadAll returned unexpected error: %v", err)
+			}
+			if string(body) != test.body {
+				t.Errorf("LimitedReadAll returned `%s` when `%s` was expected", body, test.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// The maximum length of a line decoded by an NDJSONDecoder.
+const maxNDJSONLineSize = 1 << 20
+
+// NDJSONDecoder decodes newline delimited JSON values from a stream, such as a chunked
+// response body, reading a line at a time rather than buffering the whole stream. Empty
+// lines, e.g. the heartbeats of a CouchDB continuous changes feed, are skipped.
+type NDJSONDecoder struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func NewNDJSONDecoder(r io.Reader) *NDJSONDecoder {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxNDJSONLineSize)
+	return &NDJSONDecoder{scanner: scanner}
+}
+
+// Decode decodes the next line of the stream into v, returning io.EOF once the stream is
+// exhausted. The error of ctx is returned once it is done, so that a stream is abandoned
+// part way through when a poll is cancelled. Reads which are blocked are interrupted by
+// the cancellation of a request bound to ctx.
+func (d *NDJSONDecoder) Decode(ctx context.Context, v interface{}) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !d.scanner.Scan() {
+			if err := d.scanner.Err(); err != nil {
+				return err
+			}
+			return io.EOF
+		}
+		d.line++
+		line := bytes.TrimSpace(d.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(line, v); err != nil {
+			return fmt.Errorf("failed to decode line %d: %w", d.line, err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+type ndjsonValue struct {
+	Name string `json:"name"`
+}
+
+func TestNDJSONDecoderIncremental(t *testing.T) {
+	t.Parallel()
+
+	r, w := io.Pipe()
+	defer r.Close()
+	// Each line is only written once the previous line has been decoded, so decoding
+	// would block if the decoder buffered the whole stream.
+	next := make(chan string)
+	go func() {
+		for line := range next {
+			if _, err := io.WriteString(w, line); err != nil {
+				return
+			}
+		}
+		w.Close()
+	}()
+
+	decoder := NewNDJSONDecoder(r)
+	for _, name := range []string{"foo", "bar", "baz"} {
+		next <- `{"name": "` + name + `"}` + "\n\n"
+		var v ndjsonValue
+		if err := decoder.Decode(context.Background(), &v); err != nil {
+			t.Fatalf("Decode() returned unexpected error: %v", err)
+		}
+		if v.Name != name {
+			t.Errorf("Decode() decoded %q when %q was expected", v.Name, name)
+		}
+	}
+	close(next)
+	var v ndjsonValue
+	if err := decoder.Decode(context.Background(), &v); !errors.Is(err, io.EOF) {
+		t.Errorf("Decode() returned `%v` at the end of the stream when io.EOF was expected", err)
+	}
+}
+
+func TestNDJSONDecoderCancelled(t *testing.T) {
+	t.Parallel()
+
+	decoder := NewNDJSONDecoder(strings.NewReader("{\"name\": \"foo\"}\n{\"name\": \"bar\"}\n"))
+	ctx, cancel := context.WithCancel(context.Background())
+	var v ndjsonValue
+	if err := decoder.Decode(ctx, &v); err != nil {
+		t.Fatalf("Decode() returned unexpected error: %v", err)
+	}
+	cancel()
+	if err := decoder.Decode(ctx, &v); !errors.Is(err, context.Canceled) {
+		t.Errorf("Decode() returned `%v` after cancellation when context.Canceled was expected", err)
+	}
+}
+
+func TestNDJSONDecoderInvalidLine(t *testing.T) {
+	t.Parallel()
+
+	decoder := NewNDJSONDecoder(strings.NewReader("{\"name\": \"foo\"}\nnot json\n"))
+	var v ndjsonValue
+	if err := decoder.Decode(context.Background(), &v); err != nil {
+		t.Fatalf("Decode() returned unexpected error: %v", err)
+	}
+	if err := decoder.Decode(context.Background(), &v); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Decode() returned `%v` when an error decoding line 2 was expected", err)
+	}
+}
@@ -0,0 +1,83 @@
+package pep440
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	sdistExtensions = []string{".tar.gz", ".tgz", ".tar.bz2", ".tar.xz", ".tar", ".zip"}
+
+	nameSeparators = regexp.MustCompile(`[-_.]+`)
+)
+
+// VersionFromFilename extracts the normalized version from the filename of a distribution
+// of project, e.g. "foopy-1.0a1-py3-none-any.whl" or "foopy-1.0.post1.tar.gz". Wheels and
+// eggs encode the version as the second component of the filename, source distributions
+// end with it. The project name disambiguates source distributions of projects with
+// hyphenated names, it may be empty if unknown.
+func VersionFromFilename(project, filename string) (string, error) {
+	lower := strings.ToLower(filename)
+	var version string
+	switch {
+	case strings.HasSuffix(lower, ".whl"):
+		// {distribution}-{version}(-{build tag})?-{python tag}-{abi tag}-{platform tag}.whl
+		parts := strings.Split(filename[:len(filename)-len(".whl")], "-")
+		if len(parts) != 5 && len(parts) != 6 {
+			return "", fmt.Errorf("%w : %v", ErrInvalidFilename, filename)
+		}
+		version = parts[1]
+	case strings.HasSuffix(lower, ".egg"):
+		// {distribution}-{version}(-{python tag}(-{platform})?)?.egg
+		parts := strings.Split(filename[:len(filename)-len(".egg")], "-")
+		if len(parts) < 2 {
+			return "", fmt.Errorf("%w : %v", ErrInvalidFilename, filename)
+		}
+		version = parts[1]
+	default:
+		base, ok := trimSdistExtension(filename)
+		if !ok {
+			return "", fmt.Errorf("%w : %v", ErrInvalidFilename, filename)
+		}
+		version = sdistVersion(project, base)
+		if version == "" {
+			return "", fmt.Errorf("%w : %v", ErrInvalidFilename, filename)
+		}
+	}
+	normalized, err := Normalize(version)
+	if err != nil {
+		return "", fmt.Errorf("%w : %v", ErrInvalidFilename, err)
+	}
+	return normalized, nil
+}
+
+func trimSdistExtension(filename string) (string, bool) {
+	lower := strings.ToLower(filename)
+	for _, ext := range sdistExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return filename[:len(filename)-len(ext)], true
+		}
+	}
+	return "", false
+}
+
+// Extracts the version from the filename of a source distribution without its extension,
+// "{name}-{version}". Source distribution names are not escaped, so the name may itself
+// contain hyphens.
+func sdistVersion(project, base string) string {
+	if project != "" && len(base) > len(project)+1 && base[len(project)] == '-' &&
+		normalizeName(base[:len(project)]) == normalizeName(project) {
+		return base[len(project)+1:]
+	}
+	i := strings.LastIndex(base, "-")
+	if i < 0 {
+		return ""
+	}
+	return base[i+1:]
+}
+
+// Normalizes a project name as specified by PEP 503.
+func normalizeName(name string) string {
+	return nameSeparators.ReplaceAllString(strings.ToLower(name), "-")
+}
@@ -0,0 +1,46 @@
+package pep440
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVersionFromFilename(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		project  string
+		filename string
+		want     string
+	}{
+		{project: "foopy", filename: "foopy-1.0a1-py3-none-any.whl", want: "1.0a1"},
+		{project: "foo-py", filename: "foo_py-1.0.post1-1-cp39-cp39-manylinux1_x86_64.whl", want: "1.0.post1"},
+		{project: "foopy", filename: "foopy-1!2.0-py2.py3-none-any.whl", want: "1!2.0"},
+		{project: "foopy", filename: "foopy-1.0.post1.tar.gz", want: "1.0.post1"},
+		{project: "foo-py", filename: "foo-py-1.0-alpha1.tar.gz", want: "1.0a1"},
+		{project: "Foo_Py", filename: "foo.py-2.0.zip", want: "2.0"},
+		{project: "", filename: "foopy-1.0+local.tar.bz2", want: "1.0+local"},
+		{project: "foopy", filename: "foopy-1.0-py3.9.egg", want: "1.0"},
+	}
+	for _, test := range tests {
+		got, err := VersionFromFilename(test.project, test.filename)
+		if err != nil {
+			t.Errorf("VersionFromFilename(%q, %q) returned unexpected error: %v", test.project, test.filename, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("VersionFromFilename(%q, %q) = %q when %q was expected",
+				test.project, test.filename, got, test.want)
+		}
+	}
+}
+
+func TestVersionFromFilenameInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, filename := range []string{"foopy.exe", "foopy-1.0.whl", "foopy.tar.gz", "foopy-latest.tar.gz"} {
+		if _, err := VersionFromFilename("foopy", filename); !errors.Is(err, ErrInvalidFilename) {
+			t.Errorf("VersionFromFilename(%q) returned %v when ErrInvalidFilename was expected", filename, err)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+// Package pep440 parses, normalizes and compares Python package versions as specified
+// by PEP 440, https://peps.python.org/pep-0440/.
+package pep440
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidVersion  = errors.New("invalid PEP 440 version")
+	ErrInvalidFilename = errors.New("invalid distribution filename")
+
+	// The permissive version pattern of PEP 440 Appendix B, accepting the alternative
+	// spellings which are normalized by Parse.
+	versionPattern = regexp.MustCompile(`(?i)^v?` +
+		`(?:(?P<epoch>[0-9]+)!)?` +
+		`(?P<release>[0-9]+(?:\.[0-9]+)*)` +
+		`(?:[-_.]?(?P<pre_l>alpha|a|beta|b|preview|pre|c|rc)[-_.]?(?P<pre_n>[0-9]+)?)?` +
+		`(?:-(?P<post_n1>[0-9]+)|[-_.]?(?P<post_l>post|rev|r)[-_.]?(?P<post_n2>[0-9]+)?)?` +
+		`(?:[-_.]?(?P<dev_l>dev)[-_.]?(?P<dev_n>[0-9]+)?)?` +
+		`(?:\+(?P<local>[a-z0-9]+(?:[-_.][a-z0-9]+)*))?$`)
+
+	preReleaseLabels = map[string]string{
+		"a":       "a",
+		"alpha":   "a",
+		"b":       "b",
+		"beta":    "b",
+		"c":       "rc",
+		"rc":      "rc",
+		"pre":     "rc",
+		"preview": "rc",
+	}
+	preReleaseOrder = map[string]int{"a": 0, "b": 1, "rc": 2}
+
+	localSeparators = strings.NewReplacer("-", ".", "_", ".")
+)
+
+// Version is a parsed PEP 440 version. Pre, Post and Dev are nil for versions without
+// the corresponding segment.
+type Version struct {
+	Epoch   int
+	Release []int
+	Pre     *PreRelease
+	Post    *int
+	Dev     *int
+	Local   []string
+}
+
+type PreRelease struct {
+	// One of "a", "b" or "rc".
+	Label  string
+	Number int
+}
+
+// Parse parses a version, accepting the alternative spellings permitted by PEP 440 such
+// as "1.0-alpha1" or "v1.0.post".
+func Parse(version string) (*Version, error) {
+	match := versionPattern.FindStringSubmatch(strings.TrimSpace(version))
+	if match == nil {
+		return nil, fmt.Errorf("%w : %v", ErrInvalidVersion, version)
+	}
+	groups := map[string]string{}
+	for i, name := range versionPattern.SubexpNames() {
+		if name != "" {
+			groups[name] = match[i]
+		}
+	}
+
+	v := &Version{}
+	var err error
+	if v.Epoch, err = atoi(groups["epoch"], version); err != nil {
+		return nil, err
+	}
+	for _, part := range strings.Split(groups["release"], ".") {
+		n, err := atoi(part, version)
+		if err != nil {
+			return nil, err
+		}
+		v.Release = append(v.Release, n)
+	}
+	if label := groups["pre_l"]; label != "" {
+		n, err := atoi(groups["pre_n"], version)
+		if err != nil {
+			return nil, err
+		}
+		v.Pre = &PreRelease{Label: preReleaseLabels[strings.ToLower(label)], Number: n}
+	}
+	if groups["post_n1"] != "" || groups["post_l"] != "" {
+		n, err := atoi(groups["post_n1"]+groups["post_n2"], version)
+		if err != nil {
+			return nil, err
+		}
+		v.Post = &n
+	}
+	if groups["dev_l"] != "" {
+		n, err := atoi(groups["dev_n"], version)
+		if err != nil {
+			return nil, err
+		}
+		v.Dev = &n
+	}
+	if local := groups["local"]; local != "" {
+		v.Local = strings.Split(localSeparators.Replace(strings.ToLower(local)), ".")
+	}
+	return v, nil
+}
+
+// Parses a numeric segment of version, an omitted number is implicitly zero.
+func atoi(s, version string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%w : %v", ErrInvalidVersion, version)
+	}
+	return n, nil
+}
+
+// Normalize returns the normalized form of version, e.g. "1.0-ALPHA.1" is normalized
+// to "1.0a1".
+func Normalize(version string) (string, error) {
+	v, err := Parse(version)
+	if err != nil {
+		return "", err
+	}
+	return v.String(), nil
+}
+
+// String returns the normalized form of the version.
+func (v *Version) String() string {
+	var sb strings.Builder
+	if v.Epoch != 0 {
+		fmt.Fprintf(&sb, "%d!", v.Epoch)
+	}
+	for i, n := range v.Release {
+		if i > 0 {
+			sb.WriteString(".")
+		}
+		sb.WriteString(strconv.Itoa(n))
+	}
+	if v.Pre != nil {
+		fmt.Fprintf(&sb, "%s%d", v.Pre.Label, v.Pre.Number)
+	}
+	if v.Post != nil {
+		fmt.Fprintf(&sb, ".post%d", *v.Post)
+	}
+	if v.Dev != nil {
+		fmt.Fprintf(&sb, ".dev%d", *v.Dev)
+	}
+	if len(v.Local) > 0 {
+		sb.WriteString("+" + strings.Join(v.Local, "."))
+	}
+	return sb.String()
+}
+
+// Compare returns -1, 0 or 1 if v sorts before, equal to or after other.
+func (v *Version) Compare(other *Version) int {
+	if c := compareInts(v.Epoch, other.Epoch); c != 0 {
+		return c
+	}
+	if c := compareRelease(v.Release, other.Release); c != 0 {
+		return c
+	}
+	if c := compareInts(v.preRank(), other.preRank()); c != 0 {
+		return c
+	}
+	if v.Pre != nil && other.Pre != nil {
+		if c := compareInts(v.Pre.Number, other.Pre.Number); c != 0 {
+			return c
+		}
+	}
+	if c := compareOptional(v.Post, other.Post, -1); c != 0 {
+		return c
+	}
+	if c := compareOptional(v.Dev, other.Dev, 1); c != 0 {
+		return c
+	}
+	return compareLocal(v.Local, other.Local)
+}
+
+// Ranks the pre-release segment, developmental releases of a final release sort before
+// its pre-releases, which sort before the final release.
+func (v *Version) preRank() int {
+	switch {
+	case v.Pre != nil:
+		return preReleaseOrder[v.Pre.Label]
+	case v.Post == nil && v.Dev != nil:
+		return -1
+	default:
+		return len(preReleaseOrder)
+	}
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Compares release segments, ignoring trailing zeros so that 1.0 is equal to 1.0.0.
+func compareRelease(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if c := compareInts(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// Compares optional segments, an absent segment sorts before any number if missing is
+// -1, or after any number if missing is 1.
+func compareOptional(a, b *int, missing int) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return missing
+	case b == nil:
+		return -missing
+	default:
+		return compareInts(*a, *b)
+	}
+}
+
+// Compares local version labels segment by segment, numeric segments sort after
+// alphanumeric segments and a shorter label sorts before a longer label it prefixes.
+func compareLocal(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		x, xErr := strconv.Atoi(a[i])
+		y, yErr := strconv.Atoi(b[i])
+		var c int
+		switch {
+		case xErr == nil && yErr == nil:
+			c = compareInts(x, y)
+		case xErr == nil:
+			c = 1
+		case yErr == nil:
+			c = -1
+		default:
+			c = strings.Compare(a[i], b[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(a), len(b))
+}
@@ -0,0 +1,112 @@
+package pep440
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{version: "1.0", want: "1.0"},
+		{version: "1.0a1", want: "1.0a1"},
+		{version: "1.0-ALPHA.1", want: "1.0a1"},
+		{version: "1.0b", want: "1.0b0"},
+		{version: "1.0c2", want: "1.0rc2"},
+		{version: "1.0.preview3", want: "1.0rc3"},
+		{version: "1.0.post1", want: "1.0.post1"},
+		{version: "1.0-1", want: "1.0.post1"},
+		{version: "1.0rev", want: "1.0.post0"},
+		{version: "1.0.dev", want: "1.0.dev0"},
+		{version: "1.0a1.post2.dev3", want: "1.0a1.post2.dev3"},
+		{version: "0!1.0", want: "1.0"},
+		{version: "2!1.0", want: "2!1.0"},
+		{version: "1.0+local", want: "1.0+local"},
+		{version: "1.0+Ubuntu-1_2", want: "1.0+ubuntu.1.2"},
+		{version: "v01.02", want: "1.2"},
+		{version: " 1.0 ", want: "1.0"},
+	}
+	for _, test := range tests {
+		got, err := Normalize(test.version)
+		if err != nil {
+			t.Errorf("Normalize(%q) returned unexpected error: %v", test.version, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Normalize(%q) = %q when %q was expected", test.version, got, test.want)
+		}
+	}
+}
+
+func TestNormalizeInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, version := range []string{"", "foo", "1.0-", "1.0+", "1.0+local+again", "1..0"} {
+		if _, err := Normalize(version); !errors.Is(err, ErrInvalidVersion) {
+			t.Errorf("Normalize(%q) returned %v when ErrInvalidVersion was expected", version, err)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	// Versions in ascending order, as listed in PEP 440.
+	ordered := []string{
+		"1.0.dev456",
+		"1.0a1",
+		"1.0a2.dev456",
+		"1.0a12.dev456",
+		"1.0a12",
+		"1.0b1.dev456",
+		"1.0b2",
+		"1.0b2.post345.dev456",
+		"1.0b2.post345",
+		"1.0rc1.dev456",
+		"1.0rc1",
+		"1.0",
+		"1.0+abc.5",
+		"1.0+abc.7",
+		"1.0+5",
+		"1.0.post456.dev34",
+		"1.0.post456",
+		"1.1.dev1",
+		"1!0.1",
+	}
+	versions := []*Version{}
+	for _, s := range ordered {
+		v, err := Parse(s)
+		if err != nil {
+			t.Fatalf("Parse(%q) returned unexpected error: %v", s, err)
+		}
+		versions = append(versions, v)
+	}
+	for i := range versions {
+		for j := range versions {
+			want := compareInts(i, j)
+			if got := versions[i].Compare(versions[j]); got != want {
+				t.Errorf("Compare(%q, %q) = %v when %v was expected", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+}
+
+func TestCompareTrailingZeros(t *testing.T) {
+	t.Parallel()
+
+	a, err := Parse("1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Parse("1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Compare(b) != 0 {
+		t.Errorf("1.0 and 1.0.0 were not considered equal")
+	}
+}
@@ -0,0 +1,127 @@
+// Package semver parses and compares semantic versions as specified by Semantic Versioning
+// 2.0.0, https://semver.org/.
+package semver
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidVersion = errors.New("invalid semantic version")
+
+	// Accepts a leading "v" as commonly used in tags, build metadata is ignored.
+	versionPattern = regexp.MustCompile(`^v?(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)` +
+		`(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?$`)
+)
+
+// Version is a parsed semantic version, Prerelease is empty for release versions.
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease []string
+}
+
+// Parse parses a semantic version, e.g. "1.2.3" or "v2.0.0-rc.1+build.5".
+func Parse(version string) (*Version, error) {
+	match := versionPattern.FindStringSubmatch(version)
+	if match == nil {
+		return nil, fmt.Errorf("%w : %v", ErrInvalidVersion, version)
+	}
+	v := &Version{}
+	for i, field := range []*int{&v.Major, &v.Minor, &v.Patch} {
+		n, err := strconv.Atoi(match[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("%w : %v", ErrInvalidVersion, version)
+		}
+		*field = n
+	}
+	if match[4] != "" {
+		v.Prerelease = strings.Split(match[4], ".")
+	}
+	return v, nil
+}
+
+func (v *Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 when v has lower, equal or higher precedence than other. A
+// pre-release has lower precedence than the release of the same version.
+func (v *Version) Compare(other *Version) int {
+	if c := compareInts(v.Major, other.Major); c != 0 {
+		return c
+	}
+	if c := compareInts(v.Minor, other.Minor); c != 0 {
+		return c
+	}
+	if c := compareInts(v.Patch, other.Patch); c != 0 {
+		return c
+	}
+	switch {
+	case len(v.Prerelease) == 0 && len(other.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(other.Prerelease) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.Prerelease) && i < len(other.Prerelease); i++ {
+		if c := compareIdentifiers(v.Prerelease[i], other.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(v.Prerelease), len(other.Prerelease))
+}
+
+// Numeric identifiers are compared numerically and have lower precedence than
+// alphanumeric identifiers, which are compared lexically.
+func compareIdentifiers(a, b string) int {
+	aNum, aErr := strconv.Atoi(a)
+	bNum, bErr := strconv.Atoi(b)
+	switch {
+	case aErr == nil && bErr == nil:
+		return compareInts(aNum, bNum)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Latest returns the version of highest precedence, versions which are not semantic
+// versions are ignored. Nil is returned if none of the versions are semantic versions.
+func Latest(versions []string) *Version {
+	var latest *Version
+	for _, version := range versions {
+		v, err := Parse(version)
+		if err != nil {
+			continue
+		}
+		if latest == nil || v.Compare(latest) > 0 {
+			latest = v
+		}
+	}
+	return latest
+}
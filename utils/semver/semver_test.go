@@ -0,0 +1,79 @@
+package semver
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{version: "1.2.3", want: "1.2.3"},
+		{version: "v1.2.3", want: "1.2.3"},
+		{version: "1.0.0-rc.1", want: "1.0.0-rc.1"},
+		{version: "1.0.0-alpha+build.5", want: "1.0.0-alpha"},
+	}
+	for _, test := range tests {
+		v, err := Parse(test.version)
+		if err != nil {
+			t.Errorf("Parse(%q) returned unexpected error: %v", test.version, err)
+			continue
+		}
+		if got := v.String(); got != test.want {
+			t.Errorf("Parse(%q) = %q when %q was expected", test.version, got, test.want)
+		}
+	}
+	for _, version := range []string{"1.2", "01.2.3", "1.2.3-", "latest"} {
+		if _, err := Parse(version); !errors.Is(err, ErrInvalidVersion) {
+			t.Errorf("Parse(%q) returned `%v` when ErrInvalidVersion was expected", version, err)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	// Ordered by increasing precedence, as in the examples of the specification.
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"2.0.0",
+		"10.0.0",
+	}
+	for i := 0; i < len(ordered)-1; i++ {
+		a, err := Parse(ordered[i])
+		if err != nil {
+			t.Fatalf("Parse(%q) returned unexpected error: %v", ordered[i], err)
+		}
+		b, err := Parse(ordered[i+1])
+		if err != nil {
+			t.Fatalf("Parse(%q) returned unexpected error: %v", ordered[i+1], err)
+		}
+		if a.Compare(b) != -1 || b.Compare(a) != 1 || a.Compare(a) != 0 {
+			t.Errorf("%v and %v were not ordered by precedence", ordered[i], ordered[i+1])
+		}
+	}
+}
+
+func TestLatest(t *testing.T) {
+	t.Parallel()
+
+	if latest := Latest([]string{"1.9.0", "not-a-version", "1.10.0", "2.0.0-rc.1"}); latest.String() != "2.0.0-rc.1" {
+		t.Errorf("Latest() = %v when 2.0.0-rc.1 was expected", latest)
+	}
+	if latest := Latest([]string{"latest"}); latest != nil {
+		t.Errorf("Latest() = %v when nil was expected", latest)
+	}
+}
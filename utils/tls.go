@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+var (
+	errCAFile        = errors.New("failed to load CA certificates")
+	errClientKeyPair = errors.New("client certificate and key must be provided together")
+)
+
+// TLSConfig configures the TLS connections made to a registry.
+type TLSConfig struct {
+	// The path of a PEM encoded bundle of CA certificates trusted in addition to the
+	// system's CA certificates.
+	CAFile string `yaml:"ca_file"`
+
+	// The paths of a PEM encoded client certificate and key, used for mutual TLS.
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+
+	// Disables verification of the registry's certificate. This is insecure and should
+	// only be used for testing.
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+}
+
+// NewTLSHTTPClient returns a client as NewHTTPClient does, with TLS connections configured
+// by config. A nil config uses the default TLS configuration.
+func NewTLSHTTPClient(timeout time.Duration, config *TLSConfig) (*http.Client, error) {
+	if config == nil {
+		return NewHTTPClient(timeout), nil
+	}
+	transport, err := NewTLSTransport(config)
+	if err != nil {
+		return nil, err
+	}
+	return newHTTPClient(timeout, transport), nil
+}
+
+// NewTLSTransport returns a transport sharing the configuration of the clients of
+// NewHTTPClient, with TLS connections configured by config. Requests use it through
+// ContextWithTransport.
+func NewTLSTransport(config *TLSConfig) (*http.Transport, error) {
+	tlsConfig, err := config.build()
+	if err != nil {
+		return nil, err
+	}
+	transport := sharedTransport.Clone()
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+func (c *TLSConfig) build() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// Explicitly opted into through configuration.
+		InsecureSkipVerify: c.InsecureSkipVerify, //nolint:gosec
+	}
+	if c.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := ioutil.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w : %v", errCAFile, err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w : no certificates found in %v", errCAFile, c.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		if c.CertFile == "" || c.KeyFile == "" {
+			return nil, errClientKeyPair
+		}
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,193 @@
+package utils
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("Failed to write %v: %v", path, err)
+	}
+}
+
+// Generates a self-signed client certificate, returning the paths of the certificate and key
+// along with the parsed certificate.
+func newClientCert(t *testing.T, dir string) (string, string, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "package-feeds"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client-key.pem")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile, cert
+}
+
+func newTLSServer(t *testing.T, clientCA *x509.Certificate) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	if clientCA != nil {
+		pool := x509.NewCertPool()
+		pool.AddCert(clientCA)
+		srv.TLS = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			ClientAuth: tls.RequireAndVerifyClientCert,
+			ClientCAs:  pool,
+		}
+	}
+	srv.StartTLS()
+	return srv
+}
+
+func get(t *testing.T, config *TLSConfig, url string) error {
+	t.Helper()
+	client, err := NewTLSHTTPClient(10*time.Second, config)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	resp, err := Get(context.Background(), client, url)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func TestTLSHTTPClientCAFile(t *testing.T) {
+	t.Parallel()
+
+	srv := newTLSServer(t, nil)
+	defer srv.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	writePEM(t, caFile, "CERTIFICATE", srv.Certificate().Raw)
+
+	var unknownAuthority x509.UnknownAuthorityError
+	if err := get(t, nil, srv.URL); !errors.As(err, &unknownAuthority) {
+		t.Errorf("Request without the CA returned %v when an unknown authority error was expected", err)
+	}
+	if err := get(t, &TLSConfig{CAFile: caFile}, srv.URL); err != nil {
+		t.Errorf("Request with the CA returned unexpected error: %v", err)
+	}
+	if err := get(t, &TLSConfig{InsecureSkipVerify: true}, srv.URL); err != nil {
+		t.Errorf("Request skipping verification returned unexpected error: %v", err)
+	}
+}
+
+func TestTLSHTTPClientCertificate(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	certFile, keyFile, cert := newClientCert(t, dir)
+	srv := newTLSServer(t, cert)
+	defer srv.Close()
+	caFile := filepath.Join(dir, "ca.pem")
+	writePEM(t, caFile, "CERTIFICATE", srv.Certificate().Raw)
+
+	if err := get(t, &TLSConfig{CAFile: caFile}, srv.URL); err == nil {
+		t.Errorf("Request without a client certificate succeeded when it was expected to be rejected")
+	}
+	config := &TLSConfig{CAFile: caFile, CertFile: certFile, KeyFile: keyFile}
+	if err := get(t, config, srv.URL); err != nil {
+		t.Errorf("Request with a client certificate returned unexpected error: %v", err)
+	}
+}
+
+func TestContextWithTransport(t *testing.T) {
+	t.Parallel()
+
+	srv := newTLSServer(t, nil)
+	defer srv.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	writePEM(t, caFile, "CERTIFICATE", srv.Certificate().Raw)
+	transport, err := NewTLSTransport(&TLSConfig{CAFile: caFile})
+	if err != nil {
+		t.Fatalf("Failed to create transport: %v", err)
+	}
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server url: %v", err)
+	}
+	client := NewHTTPClient(10 * time.Second)
+
+	resp, err := Get(ContextWithTransport(context.Background(), []string{u.Host}, transport), client, srv.URL)
+	if err != nil {
+		t.Fatalf("Request with the transport of the context returned unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	// Requests to other hosts use the shared transport, which doesn't trust the CA.
+	var unknownAuthority x509.UnknownAuthorityError
+	ctx := ContextWithTransport(context.Background(), []string{"registry.example.com"}, transport)
+	if _, err := Get(ctx, client, srv.URL); !errors.As(err, &unknownAuthority) {
+		t.Errorf("Request to another host returned %v when an unknown authority error was expected", err)
+	}
+}
+
+func TestTLSHTTPClientInvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	emptyFile := filepath.Join(dir, "empty.pem")
+	if err := ioutil.WriteFile(emptyFile, []byte{}, 0o600); err != nil {
+		t.Fatalf("Failed to write %v: %v", emptyFile, err)
+	}
+	tests := []struct {
+		name    string
+		config  *TLSConfig
+		wantErr error
+	}{
+		{name: "missing CA file", config: &TLSConfig{CAFile: filepath.Join(dir, "missing.pem")}, wantErr: errCAFile},
+		{name: "empty CA file", config: &TLSConfig{CAFile: emptyFile}, wantErr: errCAFile},
+		{name: "certificate without key", config: &TLSConfig{CertFile: emptyFile}, wantErr: errClientKeyPair},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := NewTLSHTTPClient(time.Second, test.config); !errors.Is(err, test.wantErr) {
+				t.Errorf("NewTLSHTTPClient returned %v when %v was expected", err, test.wantErr)
+			}
+		})
+	}
+}